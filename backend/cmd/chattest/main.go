@@ -9,10 +9,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -33,6 +36,45 @@ type Metrics struct {
 
 var metrics Metrics
 
+// latencyRecorder collects message round-trip times from all clients.
+type latencyRecorder struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+func (r *latencyRecorder) Record(d time.Duration) {
+	r.mu.Lock()
+	r.samples = append(r.samples, d)
+	r.mu.Unlock()
+}
+
+// Snapshot returns a sorted copy of the recorded samples.
+func (r *latencyRecorder) Snapshot() []time.Duration {
+	r.mu.Lock()
+	out := append([]time.Duration(nil), r.samples...)
+	r.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+var latencies latencyRecorder
+
+// percentile returns the nearest-rank p-th percentile (0 < p <= 100) of
+// sorted samples, or 0 when there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(float64(len(sorted)) * p / 100))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
 func main() {
 	host := flag.String("host", "localhost:8080", "API server host")
 	email := flag.String("email", "admin@example.com", "Test user email")
@@ -40,13 +82,23 @@ func main() {
 	wsPath := flag.String("ws-path", "/api/ws/chat", "WebSocket path")
 	conversationIDFlag := flag.Uint("conversation-id", 0, "Conversation ID to send chat messages to")
 	clients := flag.Int("clients", 50, "Number of concurrent clients")
-	duration := flag.Duration("duration", 30*time.Second, "Test duration")
+	duration := flag.Duration("duration", 30*time.Second, "Test duration, including any ramp")
+	shape := flag.String("profile", profileConstant, "Load profile: constant, ramp or spike")
+	ramp := flag.Duration("ramp", 10*time.Second, "Time to bring every client online with -profile ramp")
+	rate := flag.Float64("rate", 0.2, "Messages per second sent by each client")
+	spikeFactor := flag.Float64("spike-factor", 5, "Send rate multiplier during the spike with -profile spike")
 	flag.Parse()
 
+	profile := loadProfile{Shape: *shape, Rate: *rate, Ramp: *ramp, SpikeFactor: *spikeFactor, Duration: *duration}
+	if err := profile.validate(); err != nil {
+		log.Fatalf("❌ Invalid load profile: %v", err)
+	}
+
 	log.Printf("🚀 Starting Chat Stress Test")
 	log.Printf("Target: %s", *host)
 	log.Printf("Clients: %d", *clients)
 	log.Printf("Duration: %v", *duration)
+	log.Printf("Profile: %s at %.2f msg/s per client", profile.Shape, profile.Rate)
 
 	// Get a token first
 	token, err := login(*host, *email, *password)
@@ -70,16 +122,18 @@ func main() {
 	var wg sync.WaitGroup
 	stopChan := make(chan struct{})
 
-	// Start clients
+	started := time.Now()
+
+	// Start clients on the profile's connection schedule
 	for i := 0; i < *clients; i++ {
+		time.Sleep(time.Until(started.Add(profile.connectDelay(i, *clients))))
 		wg.Add(1)
-		go runClient(*host, *wsPath, token, conversationID, i, stopChan, &wg)
-		time.Sleep(50 * time.Millisecond) // Stagger connections to allow ticket issuance
+		go runClient(*host, *wsPath, token, conversationID, i, profile, started, stopChan, &wg)
 	}
 
 	// Wait for duration or interrupt
 	select {
-	case <-time.After(*duration):
+	case <-time.After(time.Until(started.Add(*duration))):
 		log.Println("⏱️  Test duration reached")
 	case <-interrupt:
 		log.Println("🛑 Interrupted by user")
@@ -89,7 +143,7 @@ func main() {
 	log.Println("Waiting for clients to disconnect...")
 	wg.Wait()
 
-	printMetrics()
+	printMetrics(time.Since(started))
 }
 
 func login(host, email, password string) (string, error) {
@@ -250,7 +304,7 @@ func ensureConversationWithRetry(host, token string, attempts int) (uint, error)
 	return 0, fmt.Errorf("conversation setup failed after %d attempts: %w", attempts, lastErr)
 }
 
-func runClient(host, wsPath, token string, conversationID uint, id int, stopChan <-chan struct{}, wg *sync.WaitGroup) {
+func runClient(host, wsPath, token string, conversationID uint, id int, profile loadProfile, started time.Time, stopChan <-chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 	atomic.AddInt64(&metrics.ConnectionsAttempted, 1)
 
@@ -288,43 +342,82 @@ func runClient(host, wsPath, token string, conversationID uint, id int, stopChan
 		return
 	}
 
+	// Every message carries a unique tag in its content; when the broadcast
+	// echo comes back the tag is matched to measure the round trip.
+	var pendingMu sync.Mutex
+	pending := make(map[string]time.Time)
+	seq := 0
+
 	// Read loop
 	go func() {
 		for {
-			_, _, err := c.ReadMessage()
+			_, data, err := c.ReadMessage()
 			if err != nil {
 				return
 			}
 			atomic.AddInt64(&metrics.MessagesReceived, 1)
+
+			var frame struct {
+				Type    string `json:"type"`
+				Payload struct {
+					Content string `json:"content"`
+				} `json:"payload"`
+			}
+			if json.Unmarshal(data, &frame) != nil || frame.Type != "message" {
+				continue
+			}
+			tag := echoTag(frame.Payload.Content)
+			pendingMu.Lock()
+			sentAt, ok := pending[tag]
+			delete(pending, tag)
+			pendingMu.Unlock()
+			if ok {
+				latencies.Record(time.Since(sentAt))
+			}
 		}
 	}()
 
-	ticker := time.NewTicker(time.Second * 5)
-	defer ticker.Stop()
+	timer := time.NewTimer(profile.interval(time.Since(started)))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-stopChan:
 			_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
 			return
-		case <-ticker.C:
+		case <-timer.C:
+			seq++
+			tag := fmt.Sprintf("c%d-m%d", id, seq)
 			msg := map[string]interface{}{
 				"type":            "message",
 				"conversation_id": conversationID,
-				"content":         fmt.Sprintf("Stress test message from client %d", id),
+				"content":         fmt.Sprintf("Stress test message from client %d [%s]", id, tag),
 			}
 			msgJSON, _ := json.Marshal(msg)
+			pendingMu.Lock()
+			pending[tag] = time.Now()
+			pendingMu.Unlock()
 			err := c.WriteMessage(websocket.TextMessage, msgJSON)
 			if err != nil {
 				atomic.AddInt64(&metrics.Errors, 1)
 				return
 			}
 			atomic.AddInt64(&metrics.MessagesSent, 1)
+			timer.Reset(profile.interval(time.Since(started)))
 		}
 	}
 }
 
-func printMetrics() {
+// echoTag extracts the trailing "[tag]" that runClient appends to content.
+func echoTag(content string) string {
+	start := strings.LastIndex(content, "[")
+	if start < 0 || !strings.HasSuffix(content, "]") {
+		return ""
+	}
+	return content[start+1 : len(content)-1]
+}
+
+func printMetrics(elapsed time.Duration) {
 	log.Println("\n📊 Test Results")
 	log.Println("===============")
 	log.Printf("Connections Attempted: %d", atomic.LoadInt64(&metrics.ConnectionsAttempted))
@@ -333,4 +426,23 @@ func printMetrics() {
 	log.Printf("Messages Sent: %d", atomic.LoadInt64(&metrics.MessagesSent))
 	log.Printf("Messages Received: %d", atomic.LoadInt64(&metrics.MessagesReceived))
 	log.Printf("Total Errors: %d", atomic.LoadInt64(&metrics.Errors))
+
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		log.Printf("Throughput: %.1f sent/s, %.1f received/s over %v",
+			float64(atomic.LoadInt64(&metrics.MessagesSent))/seconds,
+			float64(atomic.LoadInt64(&metrics.MessagesReceived))/seconds,
+			elapsed.Round(time.Millisecond))
+	}
+
+	samples := latencies.Snapshot()
+	if len(samples) == 0 {
+		log.Println("Round-trip latency: no echoes received")
+		return
+	}
+	log.Printf("Round-trip latency (%d echoes): p50=%v p95=%v p99=%v max=%v",
+		len(samples),
+		percentile(samples, 50).Round(time.Microsecond),
+		percentile(samples, 95).Round(time.Microsecond),
+		percentile(samples, 99).Round(time.Microsecond),
+		samples[len(samples)-1].Round(time.Microsecond))
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPercentile_NearestRank(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 50, want: 50 * time.Millisecond},
+		{p: 95, want: 95 * time.Millisecond},
+		{p: 99, want: 99 * time.Millisecond},
+		{p: 100, want: 100 * time.Millisecond},
+		{p: 0.1, want: time.Millisecond},
+	} {
+		if got := percentile(sorted, tc.p); got != tc.want {
+			t.Errorf("p%v = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+
+	small := []time.Duration{10, 20, 30}
+	if got := percentile(small, 50); got != 20 {
+		t.Errorf("p50 of 3 samples = %v, want 20", got)
+	}
+	if got := percentile(small, 99); got != 30 {
+		t.Errorf("p99 of 3 samples = %v, want 30", got)
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no samples = %v, want 0", got)
+	}
+}
+
+func TestLatencyRecorder_ConcurrentRecordSnapshotSorted(t *testing.T) {
+	var r latencyRecorder
+	var wg sync.WaitGroup
+	for i := 50; i > 0; i-- {
+		wg.Add(1)
+		go func(d time.Duration) {
+			defer wg.Done()
+			r.Record(d)
+		}(time.Duration(i))
+	}
+	wg.Wait()
+
+	got := r.Snapshot()
+	if len(got) != 50 {
+		t.Fatalf("expected 50 samples, got %d", len(got))
+	}
+	for i, d := range got {
+		if d != time.Duration(i+1) {
+			t.Fatalf("snapshot not sorted at %d: %v", i, got)
+		}
+	}
+}
+
+func TestEchoTag(t *testing.T) {
+	if got := echoTag("Stress test message from client 3 [c3-m7]"); got != "c3-m7" {
+		t.Fatalf("echoTag = %q", got)
+	}
+	if got := echoTag("no tag here"); got != "" {
+		t.Fatalf("expected empty tag, got %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Load profile shapes.
+const (
+	profileConstant = "constant" // all clients connect up front and send at Rate
+	profileRamp     = "ramp"     // clients connect evenly over Ramp, then send at Rate
+	profileSpike    = "spike"    // like constant, with Rate*SpikeFactor in the middle fifth
+)
+
+// connectStagger spaces connections when no ramp is configured so ticket
+// issuance keeps up.
+const connectStagger = 50 * time.Millisecond
+
+// loadProfile decides when clients connect and how fast each one sends.
+type loadProfile struct {
+	Shape       string
+	Rate        float64 // messages per second per client
+	Ramp        time.Duration
+	SpikeFactor float64
+	Duration    time.Duration // whole test, used to place the spike
+}
+
+func (p loadProfile) validate() error {
+	switch p.Shape {
+	case profileConstant, profileSpike:
+	case profileRamp:
+		if p.Ramp <= 0 || p.Ramp >= p.Duration {
+			return fmt.Errorf("ramp must be positive and shorter than the test duration")
+		}
+	default:
+		return fmt.Errorf("unknown profile %q (want constant, ramp or spike)", p.Shape)
+	}
+	if p.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if p.Shape == profileSpike && p.SpikeFactor < 1 {
+		return fmt.Errorf("spike factor must be at least 1")
+	}
+	return nil
+}
+
+// connectDelay is how long after the start client i (of clients) connects.
+func (p loadProfile) connectDelay(i, clients int) time.Duration {
+	if p.Shape == profileRamp && clients > 0 {
+		return p.Ramp * time.Duration(i) / time.Duration(clients)
+	}
+	return connectStagger * time.Duration(i)
+}
+
+// rateAt is the per-client send rate at elapsed time since the start.
+func (p loadProfile) rateAt(elapsed time.Duration) float64 {
+	if p.Shape == profileSpike {
+		start, end := p.Duration*2/5, p.Duration*3/5
+		if elapsed >= start && elapsed < end {
+			return p.Rate * p.SpikeFactor
+		}
+	}
+	return p.Rate
+}
+
+// interval is the wait before the next send for a message sent at elapsed.
+func (p loadProfile) interval(elapsed time.Duration) time.Duration {
+	return time.Duration(float64(time.Second) / p.rateAt(elapsed))
+}
+
+// sendTimes simulates the schedule of a client that connected at from, up to
+// (but excluding) to. Like runClient, it waits one interval before each send.
+func (p loadProfile) sendTimes(from, to time.Duration) []time.Duration {
+	var times []time.Duration
+	for at := from + p.interval(from); at < to; at += p.interval(at) {
+		times = append(times, at)
+	}
+	return times
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadProfile_SendCadence(t *testing.T) {
+	window := 10 * time.Second
+
+	constant := loadProfile{Shape: profileConstant, Rate: 2, Duration: window}
+	times := constant.sendTimes(0, window)
+	if len(times) != 19 {
+		t.Fatalf("constant 2/s over 10s: got %d sends, want 19", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i] - times[i-1]; gap != 500*time.Millisecond {
+			t.Fatalf("send %d came %v after the previous one, want 500ms", i, gap)
+		}
+	}
+
+	// The spike runs from 4s to 6s at 5x the base rate.
+	spike := loadProfile{Shape: profileSpike, Rate: 2, SpikeFactor: 5, Duration: window}
+	var before, during, after int
+	for _, at := range spike.sendTimes(0, window) {
+		switch {
+		case at < 4*time.Second:
+			before++
+		case at < 6*time.Second:
+			during++
+		default:
+			after++
+		}
+	}
+	if before != 7 || during != 20 || after != 8 {
+		t.Fatalf("spike sends before/during/after = %d/%d/%d, want 7/20/8", before, during, after)
+	}
+}
+
+func TestLoadProfile_ConnectDelay(t *testing.T) {
+	ramp := loadProfile{Shape: profileRamp, Rate: 1, Ramp: 10 * time.Second, Duration: 30 * time.Second}
+	if got := ramp.connectDelay(0, 4); got != 0 {
+		t.Fatalf("first client delay = %v, want 0", got)
+	}
+	if got := ramp.connectDelay(3, 4); got != 7500*time.Millisecond {
+		t.Fatalf("last client delay = %v, want 7.5s", got)
+	}
+
+	constant := loadProfile{Shape: profileConstant, Rate: 1, Duration: 30 * time.Second}
+	if got := constant.connectDelay(3, 4); got != 3*connectStagger {
+		t.Fatalf("constant delay = %v, want %v", got, 3*connectStagger)
+	}
+}
+
+func TestLoadProfile_Validate(t *testing.T) {
+	for _, p := range []loadProfile{
+		{Shape: "burst", Rate: 1, Duration: time.Minute},
+		{Shape: profileConstant, Rate: 0, Duration: time.Minute},
+		{Shape: profileRamp, Rate: 1, Ramp: time.Minute, Duration: time.Minute},
+		{Shape: profileSpike, Rate: 1, SpikeFactor: 0.5, Duration: time.Minute},
+	} {
+		if err := p.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", p)
+		}
+	}
+	if err := (loadProfile{Shape: profileRamp, Rate: 0.2, Ramp: 10 * time.Second, Duration: time.Minute}).validate(); err != nil {
+		t.Fatalf("valid ramp rejected: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Command image-backfill generates image derivatives that are missing for the
+// current variant sizes, e.g. after IMAGE_VARIANT_SIZES gains a width.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"sanctum/internal/config"
+	"sanctum/internal/database"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	dryRun := flag.Bool("dry-run", false, "report missing derivatives without writing them")
+	concurrency := flag.Int("concurrency", 2, "images processed at once")
+	flag.Parse()
+	if *concurrency <= 0 {
+		return fmt.Errorf("-concurrency must be greater than 0")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	db, err := database.ConnectWithOptions(cfg, database.ConnectOptions{ApplySchema: false})
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	svc := service.NewImageService(repository.NewImageRepository(db), cfg)
+	result, err := svc.BackfillVariants(ctx, service.BackfillVariantsInput{
+		DryRun:      *dryRun,
+		Concurrency: *concurrency,
+	})
+	verb := "generated"
+	if *dryRun {
+		verb = "missing"
+	}
+	log.Printf("scanned %d images: %d derivatives %s across %d images, %d failed",
+		result.Scanned, result.Generated, verb, result.Updated, result.Failed)
+	if err != nil {
+		return fmt.Errorf("backfill interrupted: %w", err)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 	"strings"
 
@@ -20,7 +21,7 @@ func main() {
 }
 
 func usage() error {
-	return fmt.Errorf("usage: go run ./cmd/migrate/main.go <up|auto|status|down> [version]")
+	return fmt.Errorf("usage: go run ./cmd/migrate/main.go <up|auto|status|down|sql> [args]")
 }
 
 func run() error {
@@ -54,13 +55,20 @@ func run() error {
 		}
 		log.Println("automigrations applied")
 	case "status":
+		// A failed status (e.g. unknown applied versions) is exactly when the
+		// per-file report below is needed, so it is logged, not returned.
 		status, err := database.GetSchemaStatus(ctx, db, cfg)
 		if err != nil {
-			return fmt.Errorf("schema status failed: %w", err)
+			log.Printf("schema status: %v", err)
+		} else {
+			log.Printf("mode=%s env=%s run_sql=%t run_auto=%t", status.Mode, status.Environment, status.WillRunSQL, status.WillRunAutoMigrate)
 		}
-		log.Printf("mode=%s env=%s run_sql=%t run_auto=%t applied=%d pending=%d", status.Mode, status.Environment, status.WillRunSQL, status.WillRunAutoMigrate, len(status.AppliedVersions), len(status.PendingMigrations))
-		for _, m := range status.PendingMigrations {
-			log.Printf("pending: %06d_%s", m.Version, m.Name)
+		report, err := database.GetMigrationReport(ctx, db, database.GetMigrations())
+		if err != nil {
+			return fmt.Errorf("migration report failed: %w", err)
+		}
+		if err := report.Write(os.Stdout); err != nil {
+			return err
 		}
 	case "down":
 		if flag.NArg() < 2 {
@@ -74,6 +82,29 @@ func run() error {
 			return fmt.Errorf("rollback failed: %w", err)
 		}
 		log.Printf("rolled back migration %d", version)
+	case "sql":
+		// Print what up/down would execute without applying it.
+		switch strings.ToLower(strings.TrimSpace(flag.Arg(1))) {
+		case "up":
+			pending, err := database.WritePendingMigrationSQL(ctx, db, os.Stdout)
+			if err != nil {
+				return fmt.Errorf("print pending migrations failed: %w", err)
+			}
+			log.Printf("%d pending migration(s); nothing was applied", pending)
+		case "down":
+			if flag.NArg() < 3 {
+				return fmt.Errorf("usage: go run ./cmd/migrate/main.go sql down <version>")
+			}
+			version, err := strconv.Atoi(flag.Arg(2))
+			if err != nil {
+				return fmt.Errorf("invalid version %q: %w", flag.Arg(2), err)
+			}
+			if err := database.WriteRollbackSQL(ctx, db, version, os.Stdout); err != nil {
+				return fmt.Errorf("print rollback failed: %w", err)
+			}
+		default:
+			return fmt.Errorf("usage: go run ./cmd/migrate/main.go sql <up|down <version>>")
+		}
 	default:
 		return usage()
 	}
@@ -2,9 +2,11 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
@@ -24,19 +26,66 @@ var supportedMethods = map[string]struct{}{
 
 type operation struct {
 	Responses map[string]struct{}
+	// Parameters holds non-body parameters keyed as "in:name", e.g. "query:limit".
+	Parameters map[string]struct{}
+	// ParameterEnums holds the allowed values of enum parameters by the same key.
+	ParameterEnums map[string]map[string]struct{}
+	// BodyFields and RequiredBodyFields list the top-level properties of the
+	// JSON request body.
+	BodyFields         map[string]struct{}
+	RequiredBodyFields map[string]struct{}
 }
 
+// maxRefDepth bounds $ref/allOf resolution so cyclic schemas terminate.
+const maxRefDepth = 16
+
 type parsedSpec struct {
 	Paths map[string]map[string]operation
 }
 
+// Issue types reported by compare.
+const (
+	issueRemovedPath          = "removed_path"
+	issueRemovedOperation     = "removed_operation"
+	issueRemovedResponseCode  = "removed_response_code"
+	issueRemovedParameter     = "removed_parameter"
+	issueRemovedRequiredField = "removed_required_request_field"
+
+	// Reported only in strict mode.
+	issueAddedResponseCode = "added_response_code"
+	issueRemovedEnumValue  = "removed_enum_value"
+)
+
+// issue is one backward-incompatible change between two specs.
+type issue struct {
+	Type   string `json:"type"`
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (i issue) String() string {
+	label := strings.ReplaceAll(i.Type, "_", " ")
+	switch {
+	case i.Method == "":
+		return fmt.Sprintf("%s: %s", label, i.Path)
+	case i.Detail == "":
+		return fmt.Sprintf("%s: %s %s", label, i.Method, i.Path)
+	default:
+		return fmt.Sprintf("%s: %s %s -> %s", label, i.Method, i.Path, i.Detail)
+	}
+}
+
 func main() {
 	basePath := flag.String("base", "", "base OpenAPI swagger.yaml path")
 	revisionPath := flag.String("revision", "", "revision OpenAPI swagger.yaml path")
+	format := flag.String("format", "text", "output format: text or json")
+	strict := flag.Bool("strict", false, "also report added response codes and removed enum values")
 	flag.Parse()
 
-	if strings.TrimSpace(*basePath) == "" || strings.TrimSpace(*revisionPath) == "" {
-		fmt.Fprintln(os.Stderr, "usage: openapi-compat -base <path> -revision <path>")
+	if strings.TrimSpace(*basePath) == "" || strings.TrimSpace(*revisionPath) == "" ||
+		(*format != "text" && *format != "json") {
+		fmt.Fprintln(os.Stderr, "usage: openapi-compat -base <path> -revision <path> [-format text|json] [-strict]")
 		os.Exit(2)
 	}
 
@@ -51,7 +100,18 @@ func main() {
 		os.Exit(1)
 	}
 
-	issues := compare(baseSpec, revisionSpec)
+	issues := compare(baseSpec, revisionSpec, *strict)
+	if *format == "json" {
+		if err := writeJSON(os.Stdout, issues); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write json: %v\n", err)
+			os.Exit(1)
+		}
+		if len(issues) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(issues) > 0 {
 		fmt.Fprintln(os.Stderr, "backward compatibility check failed:")
 		for _, issue := range issues {
@@ -63,6 +123,16 @@ func main() {
 	fmt.Println("openapi compatibility check passed")
 }
 
+// writeJSON writes issues as a JSON array; a passing check writes [].
+func writeJSON(w io.Writer, issues []issue) error {
+	if issues == nil {
+		issues = []issue{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
+
 func loadSpec(path string) (parsedSpec, error) {
 	// #nosec G304: path comes from CLI flags in a dev tool
 	raw, err := os.ReadFile(path)
@@ -92,6 +162,7 @@ func loadSpec(path string) (parsedSpec, error) {
 		if !ok {
 			continue
 		}
+		pathParams := pathOpsRaw["parameters"]
 
 		ops := make(map[string]operation)
 		for methodKey, methodEntry := range pathOpsRaw {
@@ -117,7 +188,20 @@ func loadSpec(path string) (parsedSpec, error) {
 				}
 			}
 
-			ops[methodLower] = operation{Responses: responseSet}
+			op := operation{
+				Responses:          responseSet,
+				Parameters:         make(map[string]struct{}),
+				ParameterEnums:     make(map[string]map[string]struct{}),
+				BodyFields:         make(map[string]struct{}),
+				RequiredBodyFields: make(map[string]struct{}),
+			}
+			collectParameters(doc, pathParams, &op)
+			collectParameters(doc, methodMap["parameters"], &op)
+			if bodyRaw, exists := methodMap["requestBody"]; exists {
+				collectRequestBody(doc, bodyRaw, &op)
+			}
+
+			ops[methodLower] = op
 		}
 
 		if len(ops) > 0 {
@@ -128,6 +212,142 @@ func loadSpec(path string) (parsedSpec, error) {
 	return spec, nil
 }
 
+// collectParameters records the parameters of an operation. A swagger 2.0
+// body parameter contributes its schema fields instead of a parameter name.
+func collectParameters(doc map[string]interface{}, raw interface{}, op *operation) {
+	params, ok := raw.([]interface{})
+	if !ok {
+		return
+	}
+	for _, paramRaw := range params {
+		param, ok := toMap(resolveRef(doc, paramRaw, 0))
+		if !ok {
+			continue
+		}
+		in, _ := param["in"].(string)
+		name, _ := param["name"].(string)
+		in = strings.ToLower(strings.TrimSpace(in))
+		name = strings.TrimSpace(name)
+		if in == "body" {
+			collectSchemaFields(doc, param["schema"], op, 0)
+			continue
+		}
+		if in == "" || name == "" {
+			continue
+		}
+		key := in + ":" + name
+		op.Parameters[key] = struct{}{}
+		if values := parameterEnum(doc, param); len(values) > 0 {
+			op.ParameterEnums[key] = values
+		}
+	}
+}
+
+// parameterEnum returns the enum values of a parameter, looking at the
+// swagger 2.0 fields (enum, items.enum) and the OpenAPI 3 schema.
+func parameterEnum(doc map[string]interface{}, param map[string]interface{}) map[string]struct{} {
+	candidates := []interface{}{param, param["items"]}
+	if schema, ok := toMap(resolveRef(doc, param["schema"], 0)); ok {
+		candidates = append(candidates, schema, schema["items"])
+	}
+	values := make(map[string]struct{})
+	for _, candidate := range candidates {
+		m, ok := toMap(resolveRef(doc, candidate, 0))
+		if !ok {
+			continue
+		}
+		enum, ok := m["enum"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, v := range enum {
+			values[fmt.Sprint(v)] = struct{}{}
+		}
+	}
+	return values
+}
+
+// collectRequestBody records the JSON body fields of an OpenAPI 3 requestBody.
+func collectRequestBody(doc map[string]interface{}, raw interface{}, op *operation) {
+	body, ok := toMap(resolveRef(doc, raw, 0))
+	if !ok {
+		return
+	}
+	content, ok := toMap(body["content"])
+	if !ok {
+		return
+	}
+	for mediaType, mediaRaw := range content {
+		if !strings.Contains(strings.ToLower(mediaType), "json") {
+			continue
+		}
+		media, ok := toMap(mediaRaw)
+		if !ok {
+			continue
+		}
+		collectSchemaFields(doc, media["schema"], op, 0)
+	}
+}
+
+// collectSchemaFields adds the top-level properties and required fields of a
+// request body schema, following $ref and allOf.
+func collectSchemaFields(doc map[string]interface{}, raw interface{}, op *operation, depth int) {
+	if depth > maxRefDepth {
+		return
+	}
+	schema, ok := toMap(resolveRef(doc, raw, depth))
+	if !ok {
+		return
+	}
+	if props, ok := toMap(schema["properties"]); ok {
+		for field := range props {
+			op.BodyFields[field] = struct{}{}
+		}
+	}
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, fieldRaw := range required {
+			if field, ok := fieldRaw.(string); ok && field != "" {
+				op.RequiredBodyFields[field] = struct{}{}
+				op.BodyFields[field] = struct{}{}
+			}
+		}
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, part := range allOf {
+			collectSchemaFields(doc, part, op, depth+1)
+		}
+	}
+}
+
+// resolveRef follows local "#/..." references until it reaches a value that
+// is not a reference. Unresolvable references resolve to nil.
+func resolveRef(doc map[string]interface{}, v interface{}, depth int) interface{} {
+	for ; depth <= maxRefDepth; depth++ {
+		m, ok := toMap(v)
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil
+		}
+		var cur interface{} = doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			node, ok := toMap(cur)
+			if !ok {
+				return nil
+			}
+			cur = node[part]
+		}
+		v = cur
+	}
+	return nil
+}
+
 func toMap(v interface{}) (map[string]interface{}, bool) {
 	switch t := v.(type) {
 	case map[string]interface{}:
@@ -147,34 +367,80 @@ func toMap(v interface{}) (map[string]interface{}, bool) {
 	}
 }
 
-func compare(base, revision parsedSpec) []string {
-	var issues []string
+// compare reports changes in revision that break clients of base. strict adds
+// checks that are breaking for clients that handle every documented outcome.
+func compare(base, revision parsedSpec, strict bool) []issue {
+	var issues []issue
 
 	for path, baseOps := range base.Paths {
 		revOps, ok := revision.Paths[path]
 		if !ok {
-			issues = append(issues, fmt.Sprintf("removed path: %s", path))
+			issues = append(issues, issue{Type: issueRemovedPath, Path: path})
 			continue
 		}
 
 		for method, baseOp := range baseOps {
+			upperMethod := strings.ToUpper(method)
 			revOp, ok := revOps[method]
 			if !ok {
-				issues = append(issues, fmt.Sprintf("removed operation: %s %s", strings.ToUpper(method), path))
+				issues = append(issues, issue{Type: issueRemovedOperation, Method: upperMethod, Path: path})
 				continue
 			}
 
 			for responseCode := range baseOp.Responses {
 				if _, ok := revOp.Responses[responseCode]; !ok {
-					issues = append(issues, fmt.Sprintf(
-						"removed response code: %s %s -> %s",
-						strings.ToUpper(method), path, strings.ToUpper(responseCode),
-					))
+					issues = append(issues, issue{
+						Type: issueRemovedResponseCode, Method: upperMethod, Path: path,
+						Detail: strings.ToUpper(responseCode),
+					})
+				}
+			}
+
+			for param := range baseOp.Parameters {
+				if _, ok := revOp.Parameters[param]; !ok {
+					issues = append(issues, issue{
+						Type: issueRemovedParameter, Method: upperMethod, Path: path, Detail: param,
+					})
+				}
+			}
+
+			if strict {
+				for responseCode := range revOp.Responses {
+					if _, ok := baseOp.Responses[responseCode]; !ok {
+						issues = append(issues, issue{
+							Type: issueAddedResponseCode, Method: upperMethod, Path: path,
+							Detail: strings.ToUpper(responseCode),
+						})
+					}
+				}
+				for param, baseValues := range baseOp.ParameterEnums {
+					revValues, ok := revOp.ParameterEnums[param]
+					if !ok {
+						continue // the parameter was removed or no longer restricted
+					}
+					for value := range baseValues {
+						if _, ok := revValues[value]; !ok {
+							issues = append(issues, issue{
+								Type: issueRemovedEnumValue, Method: upperMethod, Path: path,
+								Detail: param + "=" + value,
+							})
+						}
+					}
+				}
+			}
+
+			// A required field that no longer exists in the body was removed or
+			// renamed; clients still sending it would be silently ignored.
+			for field := range baseOp.RequiredBodyFields {
+				if _, ok := revOp.BodyFields[field]; !ok {
+					issues = append(issues, issue{
+						Type: issueRemovedRequiredField, Method: upperMethod, Path: path, Detail: field,
+					})
 				}
 			}
 		}
 	}
 
-	sort.Strings(issues)
+	sort.Slice(issues, func(i, j int) bool { return issues[i].String() < issues[j].String() })
 	return issues
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) parsedSpec {
+	t.Helper()
+	spec, err := loadSpec("testdata/" + name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return spec
+}
+
+func issueStrings(issues []issue) []string {
+	out := make([]string, 0, len(issues))
+	for _, i := range issues {
+		out = append(out, i.String())
+	}
+	return out
+}
+
+func TestCompare_FlagsRemovedParametersAndRequiredFields(t *testing.T) {
+	base := loadFixture(t, "base.yaml")
+	revision := loadFixture(t, "revision.yaml")
+
+	want := []string{
+		"removed parameter: GET /posts -> query:limit",
+		"removed required request field: POST /posts -> title",
+	}
+	if got := issueStrings(compare(base, revision, false)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("issues = %#v, want %#v", got, want)
+	}
+}
+
+func TestCompare_StrictFlagsAddedResponseCodesAndRemovedEnumValues(t *testing.T) {
+	base := loadFixture(t, "base.yaml")
+	revision := loadFixture(t, "revision.yaml")
+
+	want := []string{
+		"added response code: POST /posts -> 422",
+		"removed enum value: GET /posts -> query:sort=hot",
+		"removed parameter: GET /posts -> query:limit",
+		"removed required request field: POST /posts -> title",
+	}
+	if got := issueStrings(compare(base, revision, true)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("issues = %#v, want %#v", got, want)
+	}
+
+	// The default mode keeps ignoring the added 422 and the narrowed enum.
+	for _, got := range issueStrings(compare(base, revision, false)) {
+		if got == want[0] || got == want[1] {
+			t.Fatalf("default mode reported strict-only issue %q", got)
+		}
+	}
+}
+
+func TestCompare_OpenAPI3RequestBody(t *testing.T) {
+	base := loadFixture(t, "base_oas3.yaml")
+	revision := loadFixture(t, "revision_oas3.yaml")
+
+	// is_group was required through allOf and is renamed in the revision.
+	want := []string{"removed required request field: POST /rooms -> is_group"}
+	if got := issueStrings(compare(base, revision, false)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("issues = %#v, want %#v", got, want)
+	}
+
+	// Dropping optional fields or relaxing required ones is compatible.
+	if got := compare(revision, base, false); len(got) != 0 {
+		t.Fatalf("expected no issues, got %#v", got)
+	}
+
+	op := base.Paths["/rooms"]["post"]
+	if _, ok := op.RequiredBodyFields["name"]; !ok {
+		t.Fatalf("expected name resolved through allOf $ref, got %#v", op.RequiredBodyFields)
+	}
+}
+
+func TestCompare_IdenticalSpecsPass(t *testing.T) {
+	base := loadFixture(t, "base.yaml")
+	if got := compare(base, base, false); len(got) != 0 {
+		t.Fatalf("expected no issues, got %#v", got)
+	}
+}
+
+func TestWriteJSON_RemovedPath(t *testing.T) {
+	base := loadFixture(t, "base.yaml")
+	revision := loadFixture(t, "base.yaml")
+	delete(revision.Paths, "/posts/{id}")
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, compare(base, revision, false)); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	want := `[
+  {
+    "type": "removed_path",
+    "path": "/posts/{id}"
+  }
+]
+`
+	if buf.String() != want {
+		t.Fatalf("json = %s, want %s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeJSON(&buf, compare(base, base, false)); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Fatalf("expected empty array for a passing check, got %q", buf.String())
+	}
+}
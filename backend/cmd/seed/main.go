@@ -25,6 +25,7 @@ func main() {
 	dryRun := flag.Bool("dry-run", false, "Print planned seed actions without writing to DB")
 	batchSize := flag.Int("batch-size", 0, "Batch size for bulk inserts (0 = disabled)")
 	maxDays := flag.Int("max-days", 90, "Max days in the past to spread CreatedAt timestamps")
+	variedGames := flag.Bool("varied-games", false, "Seed game rooms including Battleship/Othello mid-play and finished games with stats")
 	flag.Parse()
 
 	log.Println("🌱 Database Seeder")
@@ -50,10 +51,11 @@ func main() {
 
 	// Run seeder
 	opts := seed.Options{
-		SkipBcrypt: *skipBcrypt,
-		DryRun:     *dryRun,
-		BatchSize:  *batchSize,
-		MaxDays:    *maxDays,
+		SkipBcrypt:  *skipBcrypt,
+		DryRun:      *dryRun,
+		BatchSize:   *batchSize,
+		MaxDays:     *maxDays,
+		VariedGames: *variedGames,
 	}
 	s := seed.NewSeeder(database.DB, opts)
 
@@ -127,6 +129,11 @@ func main() {
 		if _, err := s.SeedEngagement(users, *numPosts); err != nil {
 			log.Fatalf("❌ Engagement seeding failed: %v", err)
 		}
+		if *variedGames {
+			if err := s.SeedActiveGames(users); err != nil {
+				log.Fatalf("❌ Game seeding failed: %v", err)
+			}
+		}
 	}
 
 	log.Println("✨ All done! Your database is now populated with test data.")
@@ -4,15 +4,29 @@ package config
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/viper"
 )
 
+// Default accepted access token issuers and audiences. The vibeshift names
+// are kept so tokens minted before the rename stay valid.
+const (
+	DefaultJWTIssuers   = "sanctum-api,vibeshift-api"
+	DefaultJWTAudiences = "sanctum-client,vibeshift-client"
+)
+
 // Config holds application configuration values loaded from file or environment variables.
 type Config struct {
 	JWTSecret                     string  `mapstructure:"JWT_SECRET"` // #nosec G117 -- config struct must map env var name
+	JWTIssuers                    string  `mapstructure:"JWT_ISSUERS"`
+	JWTAudiences                  string  `mapstructure:"JWT_AUDIENCES"`
 	Port                          string  `mapstructure:"PORT"`
 	DBHost                        string  `mapstructure:"DB_HOST"`
 	DBPort                        string  `mapstructure:"DB_PORT"`
@@ -32,6 +46,13 @@ type Config struct {
 	DBAutoMigrateAllowDestructive bool    `mapstructure:"DB_AUTOMIGRATE_ALLOW_DESTRUCTIVE"`
 	ImageUploadDir                string  `mapstructure:"IMAGE_UPLOAD_DIR"`
 	ImageMaxUploadSizeMB          int     `mapstructure:"IMAGE_MAX_UPLOAD_SIZE_MB"`
+	ImageMaxMegapixels            int     `mapstructure:"IMAGE_MAX_MEGAPIXELS"`
+	ImageAVIFEnabled              bool    `mapstructure:"IMAGE_AVIF_ENABLED"`
+	ImageSkipWebPVariants         bool    `mapstructure:"IMAGE_SKIP_WEBP_VARIANTS"`
+	ImageVariantSizes             string  `mapstructure:"IMAGE_VARIANT_SIZES"`
+	ImageJPEGQuality              int     `mapstructure:"IMAGE_JPEG_QUALITY"`
+	ImageWebPQuality              int     `mapstructure:"IMAGE_WEBP_QUALITY"`
+	ImageWorkerConcurrency        int     `mapstructure:"IMAGE_WORKER_CONCURRENCY"`
 	TURNURL                       string  `mapstructure:"TURN_URL"`
 	TURNUsername                  string  `mapstructure:"TURN_USERNAME"`
 	TURNPassword                  string  `mapstructure:"TURN_PASSWORD"`
@@ -49,6 +70,23 @@ type Config struct {
 	OTELServiceName               string  `mapstructure:"OTEL_SERVICE_NAME"`
 	OTELTracesSamplerRatio        float64 `mapstructure:"OTEL_TRACES_SAMPLER_RATIO"`
 	EnableProxyHeader             bool    `mapstructure:"ENABLE_PROXY_HEADER"`
+	SanctumOwnerInactiveDays      int     `mapstructure:"SANCTUM_OWNER_INACTIVE_DAYS"`
+	SoftDeleteRetentionDays       int     `mapstructure:"SOFT_DELETE_RETENTION_DAYS"`
+	AccountReactivationGraceDays  int     `mapstructure:"ACCOUNT_REACTIVATION_GRACE_DAYS"`
+	AdminAuditRetentionDays       int     `mapstructure:"ADMIN_AUDIT_RETENTION_DAYS"`
+	DMMinAccountAgeHours          int     `mapstructure:"DM_MIN_ACCOUNT_AGE_HOURS"`
+	ConversationNameMaxLength     int     `mapstructure:"CONVERSATION_NAME_MAX_LENGTH"`
+	ProfanityExtraWords           string  `mapstructure:"PROFANITY_EXTRA_WORDS"`
+	ChatProfanityFilter           string  `mapstructure:"CHAT_PROFANITY_FILTER"`
+	FeedHotHalfLifeHours          float64 `mapstructure:"FEED_HOT_HALF_LIFE_HOURS"`
+	ReportEscalationThreshold     int     `mapstructure:"REPORT_ESCALATION_THRESHOLD"`
+	VAPIDPublicKey                string  `mapstructure:"VAPID_PUBLIC_KEY"`
+	VAPIDPrivateKey               string  `mapstructure:"VAPID_PRIVATE_KEY"`
+	VAPIDSubject                  string  `mapstructure:"VAPID_SUBJECT"`
+	WSPingIntervalSeconds         int     `mapstructure:"WS_PING_INTERVAL_SECONDS"`
+	WSPongTimeoutSeconds          int     `mapstructure:"WS_PONG_TIMEOUT_SECONDS"`
+	RateLimitEnabled              string  `mapstructure:"RATE_LIMIT_ENABLED"`
+	LogLevel                      string  `mapstructure:"LOG_LEVEL"`
 }
 
 // LoadConfig loads application configuration from file and environment variables.
@@ -99,7 +137,9 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("DB_READ_PASSWORD", "password")
 	viper.SetDefault("REDIS_URL", "localhost:6379")
 	viper.SetDefault("JWT_SECRET", "your-secret-key-change-in-production")
-	viper.SetDefault("ALLOWED_ORIGINS", "http://localhost:5173,http://localhost:3000,http://127.0.0.1:5173")
+	viper.SetDefault("JWT_ISSUERS", DefaultJWTIssuers)
+	viper.SetDefault("JWT_AUDIENCES", DefaultJWTAudiences)
+	viper.SetDefault("ALLOWED_ORIGINS", "")
 	viper.SetDefault("FEATURE_FLAGS", "")
 	viper.SetDefault("APP_ENV", "development")
 	viper.SetDefault("DB_SSLMODE", "disable")
@@ -109,6 +149,13 @@ func LoadConfig() (*Config, error) {
 	// Production nginx expects /var/sanctum/uploads/images; use the same layout in dev.
 	viper.SetDefault("IMAGE_UPLOAD_DIR", "/var/sanctum/uploads/images")
 	viper.SetDefault("IMAGE_MAX_UPLOAD_SIZE_MB", 10)
+	viper.SetDefault("IMAGE_MAX_MEGAPIXELS", 40)
+	viper.SetDefault("IMAGE_AVIF_ENABLED", false)
+	viper.SetDefault("IMAGE_SKIP_WEBP_VARIANTS", false)
+	viper.SetDefault("IMAGE_VARIANT_SIZES", "")
+	viper.SetDefault("IMAGE_JPEG_QUALITY", 82)
+	viper.SetDefault("IMAGE_WEBP_QUALITY", 70)
+	viper.SetDefault("IMAGE_WORKER_CONCURRENCY", 1)
 	viper.SetDefault("DEV_BOOTSTRAP_ROOT", true)
 	viper.SetDefault("DEV_ROOT_USERNAME", "sanctum_root")
 	viper.SetDefault("DEV_ROOT_EMAIL", "root@sanctum.local")
@@ -123,6 +170,23 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("OTEL_SERVICE_NAME", "sanctum-api")
 	viper.SetDefault("OTEL_TRACES_SAMPLER_RATIO", 1.0)
 	viper.SetDefault("ENABLE_PROXY_HEADER", false)
+	viper.SetDefault("SANCTUM_OWNER_INACTIVE_DAYS", 0)
+	viper.SetDefault("SOFT_DELETE_RETENTION_DAYS", 0)
+	viper.SetDefault("ACCOUNT_REACTIVATION_GRACE_DAYS", 30)
+	viper.SetDefault("ADMIN_AUDIT_RETENTION_DAYS", 0)
+	viper.SetDefault("DM_MIN_ACCOUNT_AGE_HOURS", 0)
+	viper.SetDefault("CONVERSATION_NAME_MAX_LENGTH", 64)
+	viper.SetDefault("PROFANITY_EXTRA_WORDS", "")
+	viper.SetDefault("CHAT_PROFANITY_FILTER", "off")
+	viper.SetDefault("FEED_HOT_HALF_LIFE_HOURS", 12)
+	viper.SetDefault("REPORT_ESCALATION_THRESHOLD", 5)
+	viper.SetDefault("VAPID_PUBLIC_KEY", "")
+	viper.SetDefault("VAPID_PRIVATE_KEY", "")
+	viper.SetDefault("VAPID_SUBJECT", "")
+	viper.SetDefault("WS_PING_INTERVAL_SECONDS", 3)
+	viper.SetDefault("WS_PONG_TIMEOUT_SECONDS", 10)
+	viper.SetDefault("RATE_LIMIT_ENABLED", "")
+	viper.SetDefault("LOG_LEVEL", "")
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -136,13 +200,18 @@ func LoadConfig() (*Config, error) {
 	return &config, nil
 }
 
-// Validate ensures that required configuration values are present and meet security standards.
+// Validate ensures that required configuration values are present and meet
+// security standards. It checks everything before returning so a single
+// startup failure lists every problem, one per line.
 func (c *Config) Validate() error {
+	var problems []error
+	fail := func(err error) { problems = append(problems, err) }
+
 	if c.Port == "" {
-		return errors.New("PORT is required")
+		fail(errors.New("PORT is required"))
 	}
 	if c.JWTSecret == "" {
-		return errors.New("JWT_SECRET is required")
+		fail(errors.New("JWT_SECRET is required"))
 	}
 	if c.DBSchemaMode == "" {
 		c.DBSchemaMode = "sql"
@@ -151,65 +220,282 @@ func (c *Config) Validate() error {
 	switch mode {
 	case "hybrid", "sql", "auto":
 	default:
-		return fmt.Errorf("DB_SCHEMA_MODE must be one of hybrid|sql|auto, got %q", c.DBSchemaMode)
+		fail(fmt.Errorf("DB_SCHEMA_MODE must be one of hybrid|sql|auto, got %q", c.DBSchemaMode))
 	}
 	c.DBSchemaMode = mode
 	if c.ImageUploadDir == "" {
 		c.ImageUploadDir = "/var/sanctum/uploads/images"
 	}
 	if c.ImageMaxUploadSizeMB <= 0 {
-		return errors.New("IMAGE_MAX_UPLOAD_SIZE_MB must be greater than 0")
+		fail(errors.New("IMAGE_MAX_UPLOAD_SIZE_MB must be greater than 0"))
+	}
+	if c.ImageMaxMegapixels < 0 {
+		fail(errors.New("IMAGE_MAX_MEGAPIXELS must be >= 0"))
+	}
+	if _, err := c.ImageVariantSizeList(); err != nil {
+		fail(err)
+	}
+	if err := checkDirWritable(c.ImageUploadDir); err != nil {
+		fail(fmt.Errorf("IMAGE_UPLOAD_DIR %q is not writable: %w", c.ImageUploadDir, err))
+	}
+	if c.ImageJPEGQuality == 0 {
+		c.ImageJPEGQuality = 82
+	}
+	if c.ImageWebPQuality == 0 {
+		c.ImageWebPQuality = 70
+	}
+	if c.ImageJPEGQuality < 1 || c.ImageJPEGQuality > 100 {
+		fail(errors.New("IMAGE_JPEG_QUALITY must be between 1 and 100"))
+	}
+	if c.ImageWebPQuality < 1 || c.ImageWebPQuality > 100 {
+		fail(errors.New("IMAGE_WEBP_QUALITY must be between 1 and 100"))
+	}
+	if c.ImageWorkerConcurrency < 0 {
+		fail(errors.New("IMAGE_WORKER_CONCURRENCY must be >= 0"))
 	}
 
+	if err := checkPort(c.DBPort); err != nil {
+		fail(fmt.Errorf("DB_PORT %w", err))
+	}
+	if err := checkPort(c.DBReadPort); err != nil {
+		fail(fmt.Errorf("DB_READ_PORT %w", err))
+	}
+	if err := checkRedisURL(c.RedisURL); err != nil {
+		fail(fmt.Errorf("REDIS_URL %w", err))
+	}
 	if c.DBMaxOpenConns < 0 {
-		return errors.New("DB_MAX_OPEN_CONNS must be >= 0")
+		fail(errors.New("DB_MAX_OPEN_CONNS must be >= 0"))
 	}
 	if c.DBMaxIdleConns < 0 {
-		return errors.New("DB_MAX_IDLE_CONNS must be >= 0")
+		fail(errors.New("DB_MAX_IDLE_CONNS must be >= 0"))
 	}
 	if c.DBConnMaxLifetimeMinutes < 0 {
-		return errors.New("DB_CONN_MAX_LIFETIME_MINUTES must be >= 0")
+		fail(errors.New("DB_CONN_MAX_LIFETIME_MINUTES must be >= 0"))
 	}
 	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
-		return errors.New("DB_MAX_IDLE_CONNS cannot be greater than DB_MAX_OPEN_CONNS")
+		fail(errors.New("DB_MAX_IDLE_CONNS cannot be greater than DB_MAX_OPEN_CONNS"))
+	}
+	if c.SanctumOwnerInactiveDays < 0 {
+		fail(errors.New("SANCTUM_OWNER_INACTIVE_DAYS must be >= 0"))
+	}
+	if c.SoftDeleteRetentionDays < 0 {
+		fail(errors.New("SOFT_DELETE_RETENTION_DAYS must be >= 0"))
+	}
+	if c.AccountReactivationGraceDays < 0 {
+		fail(errors.New("ACCOUNT_REACTIVATION_GRACE_DAYS must be >= 0"))
+	}
+	if c.AdminAuditRetentionDays < 0 {
+		fail(errors.New("ADMIN_AUDIT_RETENTION_DAYS must be >= 0"))
+	}
+	if c.DMMinAccountAgeHours < 0 {
+		fail(errors.New("DM_MIN_ACCOUNT_AGE_HOURS must be >= 0"))
+	}
+	if c.ConversationNameMaxLength < 0 {
+		fail(errors.New("CONVERSATION_NAME_MAX_LENGTH must be >= 0"))
+	}
+	switch c.ChatProfanityFilter {
+	case "":
+		c.ChatProfanityFilter = "off"
+	case "off", "mask", "reject":
+	default:
+		fail(errors.New("CHAT_PROFANITY_FILTER must be one of off, mask, reject"))
+	}
+	if c.FeedHotHalfLifeHours < 0 {
+		fail(errors.New("FEED_HOT_HALF_LIFE_HOURS must be >= 0"))
+	}
+	if c.FeedHotHalfLifeHours == 0 {
+		c.FeedHotHalfLifeHours = 12
+	}
+	if c.ReportEscalationThreshold < 0 {
+		fail(errors.New("REPORT_ESCALATION_THRESHOLD must be >= 0"))
+	}
+	if c.WSPingIntervalSeconds < 0 {
+		fail(errors.New("WS_PING_INTERVAL_SECONDS must be >= 0"))
+	}
+	if c.WSPongTimeoutSeconds < 0 {
+		fail(errors.New("WS_PONG_TIMEOUT_SECONDS must be >= 0"))
+	}
+	if c.WSPingIntervalSeconds == 0 {
+		c.WSPingIntervalSeconds = 3
+	}
+	if c.WSPongTimeoutSeconds == 0 {
+		c.WSPongTimeoutSeconds = 10
+	}
+	// A pong answers the previous ping, so the timeout has to outlast the
+	// interval or every client would be dropped between pings.
+	if c.WSPingIntervalSeconds >= c.WSPongTimeoutSeconds {
+		fail(errors.New("WS_PING_INTERVAL_SECONDS must be less than WS_PONG_TIMEOUT_SECONDS"))
+	}
+	if (c.VAPIDPublicKey == "") != (c.VAPIDPrivateKey == "") {
+		fail(errors.New("VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY must be set together"))
 	}
 
-	isProduction := c.Env == "production" || c.Env == "prod"
+	if _, err := parseRateLimitEnabled(c.RateLimitEnabled); err != nil {
+		fail(err)
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		fail(err)
+	}
+
+	profile := c.Profile()
+	c.AllowedOrigins = profile.AllowedOrigins
 
 	// DB SSL Mode normalization
 	c.DBSSLMode = strings.ToLower(strings.TrimSpace(c.DBSSLMode))
 
 	// Strict checks for production
-	if isProduction {
+	if profile.Production {
 		if c.DBConnMaxLifetimeMinutes < 1 {
-			return errors.New("DB_CONN_MAX_LIFETIME_MINUTES must be >= 1 in production")
+			fail(errors.New("DB_CONN_MAX_LIFETIME_MINUTES must be >= 1 in production"))
 		}
 		if c.JWTSecret == "your-secret-key-change-in-production" {
-			return errors.New("JWT_SECRET must be changed from the default value in production")
+			fail(errors.New("JWT_SECRET must be changed from the default value in production"))
 		}
-		if len(c.JWTSecret) < 32 {
-			return errors.New("JWT_SECRET must be at least 32 characters in production")
+		if c.JWTSecret != "" && len(c.JWTSecret) < 32 {
+			fail(errors.New("JWT_SECRET must be at least 32 characters in production"))
 		}
 		if c.DBPassword == "password" || c.DBPassword == "" {
-			return errors.New("a strong DB_PASSWORD is required in production")
+			fail(errors.New("a strong DB_PASSWORD is required in production"))
 		}
 		if c.DBSSLMode == "disable" || c.DBSSLMode == "" {
-			return errors.New("DB_SSLMODE must not be 'disable' or empty in production")
+			fail(errors.New("DB_SSLMODE must not be 'disable' or empty in production"))
+		}
+		if c.AllowedOrigins == "" {
+			fail(errors.New("ALLOWED_ORIGINS is required in production (e.g. 'https://yourdomain.com')"))
 		}
 		if c.AllowedOrigins == "*" {
 			log.Println("WARNING: ALLOWED_ORIGINS is set to '*' in production. This is insecure.")
 		}
-		const localhostOrigins = "http://localhost:5173,http://localhost:3000,http://127.0.0.1:5173"
-		if c.AllowedOrigins == localhostOrigins {
+		if c.AllowedOrigins == DevAllowedOrigins {
 			log.Println("WARNING: ALLOWED_ORIGINS is still the development default in production. WebSocket ticket requests from your production domain will be blocked by CORS. Set ALLOWED_ORIGINS to your production domain (e.g. 'https://yourdomain.com').")
 		}
 		if c.RedisURL == "" {
-			return errors.New("REDIS_URL is required in production (auth, rate limiting, and WebSocket features depend on it)")
+			fail(errors.New("REDIS_URL is required in production (auth, rate limiting, and WebSocket features depend on it)"))
 		}
-	} else if len(c.JWTSecret) < 32 {
+	} else if c.JWTSecret != "" && len(c.JWTSecret) < 32 {
 		// Development/Test warnings
 		log.Println("WARNING: JWT_SECRET is shorter than 32 characters. Consider using a stronger secret for production.")
 	}
 
+	return errors.Join(problems...)
+}
+
+// checkPort accepts an empty value (the default applies) or a TCP port number.
+func checkPort(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	port, err := strconv.Atoi(raw)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("must be a port number between 1 and 65535, got %q", raw)
+	}
 	return nil
 }
+
+// checkRedisURL accepts the two forms cache.InitRedis understands: a
+// redis:// or rediss:// URL, or a bare host:port address.
+func checkRedisURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if strings.Contains(raw, "://") {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("is not a valid URL: %w", err)
+		}
+		if parsed.Scheme != "redis" && parsed.Scheme != "rediss" {
+			return fmt.Errorf("must use the redis:// or rediss:// scheme, got %q", parsed.Scheme+"://")
+		}
+		if parsed.Host == "" {
+			return fmt.Errorf("must include a host, e.g. redis://localhost:6379")
+		}
+		if p := parsed.Port(); p != "" {
+			return checkPort(p)
+		}
+		return nil
+	}
+	host, port, err := net.SplitHostPort(raw)
+	if err != nil || host == "" {
+		return fmt.Errorf("must be a redis:// URL or host:port address, got %q", raw)
+	}
+	return checkPort(port)
+}
+
+// checkDirWritable probes dir with a temporary file. A directory that does
+// not exist yet is fine; the image service creates it on first upload.
+func checkDirWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New("not a directory")
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// ImageVariantSizeList parses IMAGE_VARIANT_SIZES, the comma-separated pixel
+// widths derivatives are generated at, smallest first. An empty value returns
+// nil, meaning the built-in sizes.
+func (c *Config) ImageVariantSizeList() ([]int, error) {
+	raw := strings.TrimSpace(c.ImageVariantSizes)
+	if raw == "" {
+		return nil, nil
+	}
+	var sizes []int
+	for _, part := range strings.Split(raw, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("IMAGE_VARIANT_SIZES must be a comma-separated list of positive integers, got %q", c.ImageVariantSizes)
+		}
+		if len(sizes) > 0 && size <= sizes[len(sizes)-1] {
+			return nil, fmt.Errorf("IMAGE_VARIANT_SIZES widths must be strictly ascending, got %q", c.ImageVariantSizes)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// JWTIssuerList splits JWT_ISSUERS, the comma-separated issuers accepted on
+// access tokens. The first entry is the issuer new tokens are minted with.
+func (c *Config) JWTIssuerList() []string {
+	if list := splitList(c.JWTIssuers); len(list) > 0 {
+		return list
+	}
+	return splitList(DefaultJWTIssuers)
+}
+
+// JWTAudienceList splits JWT_AUDIENCES, the comma-separated audiences accepted
+// on access tokens. The first entry is the audience new tokens are minted with.
+func (c *Config) JWTAudienceList() []string {
+	if list := splitList(c.JWTAudiences); len(list) > 0 {
+		return list
+	}
+	return splitList(DefaultJWTAudiences)
+}
+
+// ProfanityExtraWordList splits PROFANITY_EXTRA_WORDS, a comma-separated list
+// of words blocked in addition to the built-in list.
+func (c *Config) ProfanityExtraWordList() []string {
+	return splitList(c.ProfanityExtraWords)
+}
+
+// splitList splits a comma-separated value, dropping blank entries.
+func splitList(raw string) []string {
+	var items []string
+	for _, part := range strings.Split(raw, ",") {
+		if item := strings.TrimSpace(part); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
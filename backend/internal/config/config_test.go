@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -36,6 +37,7 @@ func TestConfig_ValidateSSLMode(t *testing.T) {
 				ImageMaxUploadSizeMB:     10,
 				DBConnMaxLifetimeMinutes: 1,
 				RedisURL:                 "redis://localhost:6379",
+				AllowedOrigins:           "https://sanctum.example.com",
 			}
 
 			err := c.Validate()
@@ -65,3 +67,151 @@ func TestLoadConfig_SSLModeNormalization(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "disable", c.DBSSLMode)
 }
+
+func TestConfig_ValidateImageLadderAndQuality(t *testing.T) {
+	tests := []struct {
+		name        string
+		ladder      string
+		jpegQuality int
+		webpQuality int
+		expectError bool
+	}{
+		{"defaults", "", 0, 0, false},
+		{"custom ladder", "320, 960, 1920", 90, 60, false},
+		{"descending ladder", "1080,640", 82, 70, true},
+		{"duplicate width", "640,640", 82, 70, true},
+		{"zero width", "0,640", 82, 70, true},
+		{"non-numeric width", "640,big", 82, 70, true},
+		{"jpeg quality too high", "", 101, 70, true},
+		{"webp quality negative", "", 82, -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{
+				Env:                      "test",
+				JWTSecret:                "secure-secret-at-least-32-chars-long",
+				DBPassword:               "secure-password",
+				Port:                     "8080",
+				ImageMaxUploadSizeMB:     10,
+				ImageVariantSizes:        tt.ladder,
+				ImageJPEGQuality:         tt.jpegQuality,
+				ImageWebPQuality:         tt.webpQuality,
+				DBConnMaxLifetimeMinutes: 1,
+				RedisURL:                 "redis://localhost:6379",
+			}
+
+			err := c.Validate()
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateListsEveryProblem(t *testing.T) {
+	c := &Config{
+		Env:                  "test",
+		Port:                 "8080",
+		ImageMaxUploadSizeMB: 10,
+		DBPort:               "postgres",
+		RedisURL:             "redis://localhost:6379",
+	}
+
+	err := c.Validate()
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "JWT_SECRET is required")
+	assert.ErrorContains(t, err, `DB_PORT must be a port number between 1 and 65535, got "postgres"`)
+}
+
+func TestConfig_ValidateRedisURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		redisURL    string
+		expectError bool
+	}{
+		{"redis url", "redis://:secret@cache:6379/0", false},
+		{"tls redis url", "rediss://cache.example.com:6380", false},
+		{"host and port", "localhost:6379", false},
+		{"empty", "", false},
+		{"wrong scheme", "http://localhost:6379", true},
+		{"missing host", "redis://", true},
+		{"bad port", "redis://localhost:notaport", true},
+		{"bare host", "localhost", true},
+		{"port out of range", "localhost:70000", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{
+				Env:                  "test",
+				JWTSecret:            "secure-secret-at-least-32-chars-long",
+				Port:                 "8080",
+				ImageMaxUploadSizeMB: 10,
+				RedisURL:             tt.redisURL,
+			}
+
+			err := c.Validate()
+			if tt.expectError {
+				assert.ErrorContains(t, err, "REDIS_URL")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateImageUploadDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "uploads")
+	assert.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	c := &Config{
+		Env:                  "test",
+		JWTSecret:            "secure-secret-at-least-32-chars-long",
+		Port:                 "8080",
+		ImageMaxUploadSizeMB: 10,
+		ImageUploadDir:       t.TempDir(),
+	}
+	assert.NoError(t, c.Validate())
+
+	c.ImageUploadDir = file
+	assert.ErrorContains(t, c.Validate(), "IMAGE_UPLOAD_DIR")
+}
+
+func TestConfig_ValidateWebSocketHeartbeat(t *testing.T) {
+	tests := []struct {
+		name        string
+		ping        int
+		pong        int
+		expectError bool
+	}{
+		{"defaults", 0, 0, false},
+		{"custom", 5, 20, false},
+		{"ping equals timeout", 10, 10, true},
+		{"ping above default timeout", 15, 0, true},
+		{"negative timeout", 3, -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{
+				Env:                   "test",
+				JWTSecret:             "secure-secret-at-least-32-chars-long",
+				Port:                  "8080",
+				ImageMaxUploadSizeMB:  10,
+				WSPingIntervalSeconds: tt.ping,
+				WSPongTimeoutSeconds:  tt.pong,
+			}
+
+			err := c.Validate()
+			if tt.expectError {
+				assert.ErrorContains(t, err, "WS_")
+			} else {
+				assert.NoError(t, err)
+				assert.Less(t, c.WSPingIntervalSeconds, c.WSPongTimeoutSeconds)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// DevAllowedOrigins is the CORS allow-list used by the local profiles when
+// ALLOWED_ORIGINS is unset: the Vite dev server and the local preview port.
+const DevAllowedOrigins = "http://localhost:5173,http://localhost:3000,http://127.0.0.1:5173"
+
+// Profile holds the environment-specific defaults selected by APP_ENV.
+// Callers should read behaviour from a profile rather than comparing Env
+// strings, so adding an environment only touches the profiles table.
+type Profile struct {
+	Name string
+	// Production enables the strict startup checks in Validate.
+	Production bool
+	// RateLimit turns on the global per-IP limiter in SetupMiddleware.
+	RateLimit bool
+	// AllowedOrigins is the CORS allow-list used when ALLOWED_ORIGINS is
+	// unset. Production has none; the deployment must configure it.
+	AllowedOrigins string
+	LogLevel       slog.Level
+	// JSONLogs switches the structured logger from text to JSON output.
+	JSONLogs bool
+}
+
+var profiles = map[string]Profile{
+	"development": {
+		Name:           "development",
+		AllowedOrigins: DevAllowedOrigins,
+		LogLevel:       slog.LevelDebug,
+	},
+	"test": {
+		Name:           "test",
+		AllowedOrigins: DevAllowedOrigins,
+		LogLevel:       slog.LevelWarn,
+	},
+	"stress": {
+		Name:           "stress",
+		AllowedOrigins: DevAllowedOrigins,
+		LogLevel:       slog.LevelWarn,
+	},
+	"production": {
+		Name:       "production",
+		Production: true,
+		RateLimit:  true,
+		LogLevel:   slog.LevelInfo,
+		JSONLogs:   true,
+	},
+}
+
+// profileAliases maps shorthand APP_ENV values onto a canonical profile.
+var profileAliases = map[string]string{
+	"dev":  "development",
+	"prod": "production",
+}
+
+// ProfileFor returns the built-in profile for env. Unknown environments
+// (staging, preview, ...) get rate limiting and the development origins so
+// they behave like a deployed server without the production-only checks.
+func ProfileFor(env string) Profile {
+	name := strings.ToLower(strings.TrimSpace(env))
+	if alias, ok := profileAliases[name]; ok {
+		name = alias
+	}
+	if p, ok := profiles[name]; ok {
+		return p
+	}
+	return Profile{
+		Name:           name,
+		RateLimit:      true,
+		AllowedOrigins: DevAllowedOrigins,
+		LogLevel:       slog.LevelInfo,
+	}
+}
+
+// Profile returns the profile for c.Env with the explicit overrides from
+// ALLOWED_ORIGINS, RATE_LIMIT_ENABLED and LOG_LEVEL applied on top.
+// Invalid override values are ignored here; Validate reports them.
+func (c *Config) Profile() Profile {
+	p := ProfileFor(c.Env)
+	if c.AllowedOrigins != "" {
+		p.AllowedOrigins = c.AllowedOrigins
+	}
+	if enabled, err := parseRateLimitEnabled(c.RateLimitEnabled); err == nil && enabled != nil {
+		p.RateLimit = *enabled
+	}
+	if level, err := parseLogLevel(c.LogLevel); err == nil && level != nil {
+		p.LogLevel = *level
+	}
+	return p
+}
+
+// parseRateLimitEnabled returns nil for an empty value, meaning the profile
+// default applies.
+func parseRateLimitEnabled(raw string) (*bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_ENABLED must be true or false, got %q", raw)
+	}
+	return &enabled, nil
+}
+
+// parseLogLevel returns nil for an empty value, meaning the profile default
+// applies.
+func parseLogLevel(raw string) (*slog.Level, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL must be one of debug, info, warn, error, got %q", raw)
+	}
+	return &level, nil
+}
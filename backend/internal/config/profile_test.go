@@ -0,0 +1,79 @@
+package config
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileFor(t *testing.T) {
+	tests := []struct {
+		env        string
+		name       string
+		rateLimit  bool
+		production bool
+	}{
+		{"development", "development", false, false},
+		{"dev", "development", false, false},
+		{"test", "test", false, false},
+		{"stress", "stress", false, false},
+		{"production", "production", true, true},
+		{"PROD", "production", true, true},
+		{"staging", "staging", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			p := ProfileFor(tt.env)
+			assert.Equal(t, tt.name, p.Name)
+			assert.Equal(t, tt.rateLimit, p.RateLimit)
+			assert.Equal(t, tt.production, p.Production)
+		})
+	}
+}
+
+func TestConfig_ProfileOverrides(t *testing.T) {
+	c := &Config{Env: "stress"}
+	p := c.Profile()
+	assert.False(t, p.RateLimit)
+	assert.Equal(t, DevAllowedOrigins, p.AllowedOrigins)
+
+	c.RateLimitEnabled = "true"
+	c.LogLevel = "error"
+	c.AllowedOrigins = "https://load.example.com"
+	p = c.Profile()
+	assert.True(t, p.RateLimit)
+	assert.Equal(t, slog.LevelError, p.LogLevel)
+	assert.Equal(t, "https://load.example.com", p.AllowedOrigins)
+}
+
+func TestConfig_ValidateProfileOverrides(t *testing.T) {
+	c := &Config{
+		Env:                  "test",
+		JWTSecret:            "secure-secret-at-least-32-chars-long",
+		Port:                 "8080",
+		ImageMaxUploadSizeMB: 10,
+		RateLimitEnabled:     "sometimes",
+		LogLevel:             "loud",
+	}
+
+	err := c.Validate()
+	assert.ErrorContains(t, err, "RATE_LIMIT_ENABLED")
+	assert.ErrorContains(t, err, "LOG_LEVEL")
+}
+
+func TestConfig_ValidateProductionRequiresOrigins(t *testing.T) {
+	c := &Config{
+		Env:                      "production",
+		JWTSecret:                "secure-secret-at-least-32-chars-long",
+		DBPassword:               "secure-password",
+		DBSSLMode:                "require",
+		Port:                     "8080",
+		ImageMaxUploadSizeMB:     10,
+		DBConnMaxLifetimeMinutes: 1,
+		RedisURL:                 "redis://localhost:6379",
+	}
+
+	assert.ErrorContains(t, c.Validate(), "ALLOWED_ORIGINS is required in production")
+}
@@ -3,8 +3,9 @@ package database
 import (
 	"embed"
 	"fmt"
+	"io/fs"
 	"log/slog"
-	"path/filepath"
+	"path"
 	"sort"
 	"strings"
 
@@ -32,11 +33,19 @@ func init() {
 
 // RegisterMigrations scans an embedded filesystem for .sql migration files.
 func RegisterMigrations(efs embed.FS) error {
-	migrations = nil
+	loaded, err := LoadMigrations(efs)
+	migrations = loaded
+	return err
+}
+
+// LoadMigrations reads the up/down .sql pairs under the migrations directory
+// of fsys, sorted by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	var loaded []Migration
 
-	entries, err := efs.ReadDir("migrations")
+	entries, err := fs.ReadDir(fsys, "migrations")
 	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
 	}
 
 	seenVersions := make(map[int]string)
@@ -64,22 +73,22 @@ func RegisterMigrations(efs embed.FS) error {
 			continue
 		}
 		if existing, ok := seenVersions[version]; ok {
-			return fmt.Errorf("duplicate migration version %06d: %s and %s", version, existing, name)
+			return nil, fmt.Errorf("duplicate migration version %06d: %s and %s", version, existing, name)
 		}
 		seenVersions[version] = name
 
-		upBytes, err := efs.ReadFile(filepath.Join("migrations", name))
+		upBytes, err := fs.ReadFile(fsys, path.Join("migrations", name))
 		if err != nil {
-			return fmt.Errorf("failed to read up migration %s: %w", name, err)
+			return nil, fmt.Errorf("failed to read up migration %s: %w", name, err)
 		}
 
 		downName := base + ".down.sql"
-		downBytes, err := efs.ReadFile(filepath.Join("migrations", downName))
+		downBytes, err := fs.ReadFile(fsys, path.Join("migrations", downName))
 		if err != nil {
-			return fmt.Errorf("missing required down migration for %s: %w", name, err)
+			return nil, fmt.Errorf("missing required down migration for %s: %w", name, err)
 		}
 
-		migrations = append(migrations, Migration{
+		loaded = append(loaded, Migration{
 			Version:    version,
 			Name:       parts[1],
 			UpScript:   string(upBytes),
@@ -87,11 +96,11 @@ func RegisterMigrations(efs embed.FS) error {
 		})
 	}
 
-	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].Version < migrations[j].Version
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].Version < loaded[j].Version
 	})
 
-	return nil
+	return loaded, nil
 }
 
 // GetMigrations returns all registered migrations sorted by version.
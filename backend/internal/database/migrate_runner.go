@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"sort"
 	"strings"
@@ -144,44 +145,90 @@ func validateAppliedVersions(applied []int, registered []Migration) error {
 	}
 
 	sort.Ints(unknown)
-	parts := make([]string, 0, len(unknown))
-	for _, version := range unknown {
-		parts = append(parts, fmt.Sprintf("%06d", version))
-	}
 	return fmt.Errorf(
 		"migration_logs contains unknown versions not present in code: %s (run make db-reset-dev in development to rebuild)",
-		strings.Join(parts, ", "),
+		formatVersions(unknown),
 	)
 }
 
 // RollbackMigration reverts a specific migration by version number.
 func RollbackMigration(ctx context.Context, db *gorm.DB, version int) error {
 	store := NewMigrationStore(db)
+	m, err := appliedMigration(ctx, store, version)
+	if err != nil {
+		return err
+	}
+
+	middleware.Logger.Info("Rolling back migration", slog.Int("version", version), slog.String("name", m.Name))
+	if err := db.WithContext(ctx).Exec(m.DownScript).Error; err != nil {
+		return fmt.Errorf("failed to run rollback SQL for migration %d (%s): %w", version, m.Name, err)
+	}
+	return store.RemoveMigration(ctx, version)
+}
+
+// appliedMigration returns the registered migration for version, failing if
+// it is unknown or has not been applied.
+func appliedMigration(ctx context.Context, store MigrationStore, version int) (*Migration, error) {
 	m := GetMigrationByVersion(version)
 	if m == nil {
-		return fmt.Errorf("migration version %d not found", version)
+		return nil, fmt.Errorf("migration version %d not found", version)
 	}
 
 	applied, err := store.GetAppliedMigrations(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	found := false
 	for _, v := range applied {
 		if v == version {
-			found = true
-			break
+			return m, nil
 		}
 	}
+	return nil, fmt.Errorf("migration %d has not been applied", version)
+}
 
-	if !found {
-		return fmt.Errorf("migration %d has not been applied", version)
+// WritePendingMigrationSQL writes the SQL that RunMigrations would execute,
+// in order, without applying anything. It returns the number of pending
+// migrations written.
+func WritePendingMigrationSQL(ctx context.Context, db *gorm.DB, w io.Writer) (int, error) {
+	store := NewMigrationStore(db)
+	applied, err := store.GetAppliedMigrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := validateAppliedVersions(applied, migrations); err != nil {
+		return 0, err
 	}
 
-	middleware.Logger.Info("Rolling back migration", slog.Int("version", version), slog.String("name", m.Name))
-	if err := db.WithContext(ctx).Exec(m.DownScript).Error; err != nil {
-		return fmt.Errorf("failed to run rollback SQL for migration %d (%s): %w", version, m.Name, err)
+	appliedSet := make(map[int]bool, len(applied))
+	for _, v := range applied {
+		appliedSet[v] = true
 	}
-	return store.RemoveMigration(ctx, version)
+
+	pending := 0
+	for i := range migrations {
+		m := &migrations[i]
+		if appliedSet[m.Version] {
+			continue
+		}
+		if err := writeMigrationScript(w, m, "up", m.UpScript); err != nil {
+			return pending, err
+		}
+		pending++
+	}
+	return pending, nil
+}
+
+// WriteRollbackSQL writes the SQL that RollbackMigration would execute for
+// version without running it.
+func WriteRollbackSQL(ctx context.Context, db *gorm.DB, version int, w io.Writer) error {
+	m, err := appliedMigration(ctx, NewMigrationStore(db), version)
+	if err != nil {
+		return err
+	}
+	return writeMigrationScript(w, m, "down", m.DownScript)
+}
+
+func writeMigrationScript(w io.Writer, m *Migration, direction, script string) error {
+	_, err := fmt.Fprintf(w, "-- %s (%s)\n%s\n", m, direction, strings.TrimRight(script, "\n"))
+	return err
 }
@@ -1,8 +1,13 @@
 package database
 
 import (
+	"bytes"
+	"context"
 	"strings"
 	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestValidateAppliedVersions_AllKnown(t *testing.T) {
@@ -33,3 +38,60 @@ func TestValidateAppliedVersions_UnknownVersion(t *testing.T) {
 		t.Fatalf("expected unknown versions in error, got %q", msg)
 	}
 }
+
+func TestWritePendingMigrationSQL_PrintsWithoutApplying(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&MigrationLog{}); err != nil {
+		t.Fatalf("migrate log table: %v", err)
+	}
+
+	all := GetMigrations()
+	if len(all) < 2 {
+		t.Fatalf("expected registered migrations, got %d", len(all))
+	}
+	next := all[len(all)-1]
+	for _, m := range all[:len(all)-1] {
+		if err := db.Create(&MigrationLog{Version: m.Version, Name: m.Name}).Error; err != nil {
+			t.Fatalf("record migration %d: %v", m.Version, err)
+		}
+	}
+
+	ctx := context.Background()
+	var out bytes.Buffer
+	pending, err := WritePendingMigrationSQL(ctx, db, &out)
+	if err != nil {
+		t.Fatalf("write pending sql: %v", err)
+	}
+	if pending != 1 {
+		t.Fatalf("expected 1 pending migration, got %d", pending)
+	}
+	if !strings.HasPrefix(out.String(), "-- "+next.String()+" (up)\n") {
+		t.Fatalf("expected header for %s, got %q", next.String(), out.String())
+	}
+	if !strings.Contains(out.String(), strings.TrimSpace(next.UpScript)) {
+		t.Fatalf("expected up script of %s in output", next.String())
+	}
+
+	applied, err := NewMigrationStore(db).GetAppliedMigrations(ctx)
+	if err != nil {
+		t.Fatalf("applied migrations: %v", err)
+	}
+	if len(applied) != len(all)-1 || applied[len(applied)-1] == next.Version {
+		t.Fatalf("dry run changed the schema version: %v", applied)
+	}
+
+	out.Reset()
+	if err := WriteRollbackSQL(ctx, db, next.Version, &out); err == nil {
+		t.Fatal("expected rollback of an unapplied migration to fail")
+	}
+	prev := all[len(all)-2]
+	if err := WriteRollbackSQL(ctx, db, prev.Version, &out); err != nil {
+		t.Fatalf("write rollback sql: %v", err)
+	}
+	if !strings.Contains(out.String(), strings.TrimSpace(prev.DownScript)) {
+		t.Fatalf("expected down script of %s in output", prev.String())
+	}
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MigrationState is a registered migration and when it was applied, if ever.
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Applied reports whether the migration has been applied.
+func (s MigrationState) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// MigrationReport compares registered migrations with migration_logs.
+type MigrationReport struct {
+	States []MigrationState
+	// Current is the highest applied registered version, 0 when none is.
+	Current int
+	// OutOfOrder lists pending versions older than Current, which usually
+	// means a deploy stopped partway or branches were merged out of order.
+	OutOfOrder []int
+	// Unknown lists applied versions with no migration file.
+	Unknown []int
+}
+
+// Dirty reports whether the applied set is not a clean prefix of the
+// registered migrations.
+func (r *MigrationReport) Dirty() bool {
+	return len(r.OutOfOrder) > 0 || len(r.Unknown) > 0
+}
+
+// GetMigrationReport reads migration_logs and marks each registered
+// migration as applied or pending.
+func GetMigrationReport(ctx context.Context, db *gorm.DB, registered []Migration) (*MigrationReport, error) {
+	var logs []MigrationLog
+	if err := db.WithContext(ctx).Order("version ASC").Find(&logs).Error; err != nil && !isMissingTableError(err) {
+		return nil, fmt.Errorf("failed to read migration logs: %w", err)
+	}
+
+	appliedAt := make(map[int]time.Time, len(logs))
+	for _, l := range logs {
+		appliedAt[l.Version] = l.AppliedAt
+	}
+
+	report := &MigrationReport{States: make([]MigrationState, 0, len(registered))}
+	known := make(map[int]struct{}, len(registered))
+	for _, m := range registered {
+		known[m.Version] = struct{}{}
+		state := MigrationState{Migration: m}
+		if at, ok := appliedAt[m.Version]; ok {
+			state.AppliedAt = &at
+			if m.Version > report.Current {
+				report.Current = m.Version
+			}
+		}
+		report.States = append(report.States, state)
+	}
+	for _, state := range report.States {
+		if !state.Applied() && state.Version < report.Current {
+			report.OutOfOrder = append(report.OutOfOrder, state.Version)
+		}
+	}
+	for _, l := range logs {
+		if _, ok := known[l.Version]; !ok {
+			report.Unknown = append(report.Unknown, l.Version)
+		}
+	}
+	sort.Ints(report.Unknown)
+	return report, nil
+}
+
+// Write prints one line per migration with an applied/pending marker,
+// followed by the current version and whether the state is dirty.
+func (r *MigrationReport) Write(w io.Writer) error {
+	applied := 0
+	for _, state := range r.States {
+		marker, detail := "[ ]", "pending"
+		if state.Applied() {
+			applied++
+			marker, detail = "[x]", "applied "+state.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		if state.Version == r.Current {
+			detail += " <- current"
+		} else if !state.Applied() && state.Version < r.Current {
+			detail += " (older than current)"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s  %s\n", marker, state.String(), detail); err != nil {
+			return err
+		}
+	}
+
+	current := "none"
+	if r.Current > 0 {
+		current = fmt.Sprintf("%06d", r.Current)
+	}
+	state := "clean"
+	if r.Dirty() {
+		var reasons []string
+		if len(r.OutOfOrder) > 0 {
+			reasons = append(reasons, "pending below current: "+formatVersions(r.OutOfOrder))
+		}
+		if len(r.Unknown) > 0 {
+			reasons = append(reasons, "applied but unknown: "+formatVersions(r.Unknown))
+		}
+		state = "dirty (" + strings.Join(reasons, "; ") + ")"
+	}
+	_, err := fmt.Fprintf(w, "current: %s  applied: %d  pending: %d  state: %s\n",
+		current, applied, len(r.States)-applied, state)
+	return err
+}
+
+func formatVersions(versions []int) string {
+	parts := make([]string, 0, len(versions))
+	for _, v := range versions {
+		parts = append(parts, fmt.Sprintf("%06d", v))
+	}
+	return strings.Join(parts, ", ")
+}
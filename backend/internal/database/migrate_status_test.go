@@ -0,0 +1,96 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func writeTestMigrations(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "migrations"), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range names {
+		for _, suffix := range []string{".up.sql", ".down.sql"} {
+			if err := os.WriteFile(filepath.Join(dir, "migrations", name+suffix), []byte("SELECT 1;"), 0o600); err != nil {
+				t.Fatalf("write %s: %v", name, err)
+			}
+		}
+	}
+	return dir
+}
+
+func TestGetMigrationReport_MarksAppliedAndPending(t *testing.T) {
+	dir := writeTestMigrations(t, "000001_baseline", "000002_posts", "000003_chat")
+	registered, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if len(registered) != 3 {
+		t.Fatalf("expected 3 migrations, got %d", len(registered))
+	}
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&MigrationLog{}); err != nil {
+		t.Fatalf("migrate log table: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := db.Create(&MigrationLog{Version: 1, Name: "baseline", AppliedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}).Error; err != nil {
+		t.Fatalf("record migration: %v", err)
+	}
+	report, err := GetMigrationReport(ctx, db, registered)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	var out bytes.Buffer
+	if err := report.Write(&out); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	want := "[x] 000001_baseline  applied 2026-01-02T03:04:05Z <- current\n" +
+		"[ ] 000002_posts  pending\n" +
+		"[ ] 000003_chat  pending\n" +
+		"current: 000001  applied: 1  pending: 2  state: clean\n"
+	if out.String() != want {
+		t.Fatalf("report =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	// Applying 3 before 2 and leaving a stray version behind is a dirty state.
+	for _, l := range []MigrationLog{{Version: 3, Name: "chat"}, {Version: 99, Name: "gone"}} {
+		if err := db.Create(&l).Error; err != nil {
+			t.Fatalf("record migration: %v", err)
+		}
+	}
+	report, err = GetMigrationReport(ctx, db, registered)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if !report.Dirty() || report.Current != 3 {
+		t.Fatalf("expected dirty report at version 3, got %+v", report)
+	}
+	out.Reset()
+	if err := report.Write(&out); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	for _, line := range []string{
+		"[ ] 000002_posts  pending (older than current)",
+		"<- current",
+		"state: dirty (pending below current: 000002; applied but unknown: 000099)",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Fatalf("expected %q in report:\n%s", line, out.String())
+		}
+	}
+}
@@ -0,0 +1,2 @@
+ALTER TABLE conversation_participants
+    ADD COLUMN IF NOT EXISTS last_read_message_id BIGINT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_conversation_webhooks_user_id;
+DROP INDEX IF EXISTS idx_conversation_webhooks_conv_user;
+DROP TABLE IF EXISTS conversation_webhooks;
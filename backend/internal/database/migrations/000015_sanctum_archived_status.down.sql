@@ -0,0 +1,5 @@
+-- Archived sanctums have no pre-migration equivalent; keep them hidden.
+UPDATE sanctums SET status = 'banned' WHERE status = 'archived';
+ALTER TABLE sanctums DROP CONSTRAINT IF EXISTS chk_sanctums_status;
+ALTER TABLE sanctums
+    ADD CONSTRAINT chk_sanctums_status CHECK (status IN ('active', 'pending', 'rejected', 'banned'));
@@ -0,0 +1,3 @@
+ALTER TABLE sanctums DROP CONSTRAINT IF EXISTS chk_sanctums_status;
+ALTER TABLE sanctums
+    ADD CONSTRAINT chk_sanctums_status CHECK (status IN ('active', 'pending', 'rejected', 'banned', 'archived'));
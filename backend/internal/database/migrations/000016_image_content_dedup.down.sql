@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_images_content_hash;
+ALTER TABLE images
+  DROP COLUMN IF EXISTS ref_count,
+  DROP COLUMN IF EXISTS content_hash;
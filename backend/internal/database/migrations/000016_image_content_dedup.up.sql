@@ -0,0 +1,5 @@
+ALTER TABLE images
+  ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64),
+  ADD COLUMN IF NOT EXISTS ref_count INT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_images_content_hash ON images(content_hash);
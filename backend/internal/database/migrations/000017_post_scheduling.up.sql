@@ -0,0 +1,5 @@
+ALTER TABLE posts
+  ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published',
+  ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_posts_status_publish_at ON posts(status, publish_at);
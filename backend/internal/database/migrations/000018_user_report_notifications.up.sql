@@ -0,0 +1,2 @@
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS notify_report_updates BOOLEAN NOT NULL DEFAULT TRUE;
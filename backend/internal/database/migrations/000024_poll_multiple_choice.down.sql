@@ -0,0 +1,15 @@
+-- Keep only the earliest vote per user per poll before restoring the
+-- single-choice constraint.
+DELETE FROM poll_votes v
+USING poll_votes earlier
+WHERE v.user_id = earlier.user_id
+  AND v.poll_id = earlier.poll_id
+  AND v.id > earlier.id;
+
+DROP INDEX IF EXISTS idx_poll_votes_user_poll;
+DROP INDEX IF EXISTS uq_poll_votes_user_option;
+ALTER TABLE poll_votes ADD CONSTRAINT uq_poll_votes_user_poll UNIQUE (user_id, poll_id);
+
+ALTER TABLE polls
+  DROP COLUMN IF EXISTS closes_at,
+  DROP COLUMN IF EXISTS allow_multiple;
@@ -0,0 +1,9 @@
+ALTER TABLE polls
+  ADD COLUMN IF NOT EXISTS allow_multiple BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS closes_at TIMESTAMPTZ;
+
+-- A multiple-choice ballot is one row per selected option, so uniqueness
+-- moves from (user, poll) to (user, option).
+ALTER TABLE poll_votes DROP CONSTRAINT IF EXISTS uq_poll_votes_user_poll;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_poll_votes_user_option ON poll_votes (user_id, poll_option_id);
+CREATE INDEX IF NOT EXISTS idx_poll_votes_user_poll ON poll_votes (user_id, poll_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_users_original_email;
+DROP INDEX IF EXISTS idx_users_deactivated_at;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS original_email,
+  DROP COLUMN IF EXISTS original_username,
+  DROP COLUMN IF EXISTS deactivated_at;
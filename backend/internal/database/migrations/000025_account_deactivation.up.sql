@@ -0,0 +1,10 @@
+-- Self-service account deactivation. The public username and email are
+-- anonymized straight away; the originals are kept only for the reactivation
+-- grace window so the owner can log back in and restore the account.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS original_username TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS original_email TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_users_deactivated_at ON users (deactivated_at);
+CREATE INDEX IF NOT EXISTS idx_users_original_email ON users (original_email) WHERE original_email <> '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_pinned_messages_conversation_id;
+DROP TABLE IF EXISTS pinned_messages;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS pinned_messages (
+    id BIGSERIAL PRIMARY KEY,
+    conversation_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    pinned_by_user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_pinned_messages_conversation FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_pinned_messages_message FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    CONSTRAINT fk_pinned_messages_pinned_by_user FOREIGN KEY (pinned_by_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_pinned_messages UNIQUE (conversation_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pinned_messages_conversation_id ON pinned_messages (conversation_id);
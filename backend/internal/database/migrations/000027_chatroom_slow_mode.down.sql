@@ -0,0 +1,2 @@
+ALTER TABLE conversations
+  DROP COLUMN IF EXISTS slow_mode_seconds;
@@ -0,0 +1,2 @@
+ALTER TABLE conversations
+  ADD COLUMN IF NOT EXISTS slow_mode_seconds INTEGER NOT NULL DEFAULT 0;
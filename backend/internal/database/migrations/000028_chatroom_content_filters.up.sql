@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS chatroom_content_filters (
+    conversation_id BIGINT PRIMARY KEY,
+    profanity_mode VARCHAR(16) NOT NULL DEFAULT '',
+    blocked_words TEXT NOT NULL DEFAULT '',
+    block_links BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by_user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_chatroom_content_filters_conversation FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+    CONSTRAINT chk_chatroom_content_filters_mode CHECK (profanity_mode IN ('', 'off', 'mask', 'reject'))
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_push_subscriptions_user_id;
+DROP INDEX IF EXISTS idx_push_subscriptions_endpoint;
+DROP TABLE IF EXISTS push_subscriptions;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_admin_audit_logs_created_at;
+DROP INDEX IF EXISTS idx_admin_audit_logs_target;
+DROP INDEX IF EXISTS idx_admin_audit_logs_action;
+DROP INDEX IF EXISTS idx_admin_audit_logs_actor_user_id;
+DROP TABLE IF EXISTS admin_audit_logs;
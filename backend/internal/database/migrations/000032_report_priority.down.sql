@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_moderation_reports_priority;
+
+ALTER TABLE moderation_reports
+  DROP COLUMN IF EXISTS priority;
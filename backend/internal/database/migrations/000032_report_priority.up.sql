@@ -0,0 +1,4 @@
+ALTER TABLE moderation_reports
+  ADD COLUMN IF NOT EXISTS priority VARCHAR(10) NOT NULL DEFAULT 'normal';
+
+CREATE INDEX IF NOT EXISTS idx_moderation_reports_priority ON moderation_reports (priority);
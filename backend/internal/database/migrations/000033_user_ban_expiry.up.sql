@@ -0,0 +1,3 @@
+-- Time-boxed bans (suspensions). A NULL expiry keeps the ban permanent.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS ban_expires_at TIMESTAMPTZ;
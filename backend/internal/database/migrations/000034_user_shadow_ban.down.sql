@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_shadow_banned;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS shadow_banned;
@@ -0,0 +1,5 @@
+-- Shadow bans hide a user's content from everyone but the user.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS shadow_banned BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_users_shadow_banned ON users (id) WHERE shadow_banned;
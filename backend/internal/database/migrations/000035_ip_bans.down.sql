@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ip_bans;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS device_hash,
+  DROP COLUMN IF EXISTS last_ip;
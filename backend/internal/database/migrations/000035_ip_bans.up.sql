@@ -0,0 +1,19 @@
+-- Where each user last signed up or logged in from, and the IP/device bans
+-- checked at signup so banned users cannot simply register again.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS last_ip VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS device_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS ip_bans (
+    id BIGSERIAL PRIMARY KEY,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    device_hash VARCHAR(64) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    banned_by_user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_ip_bans_banned_by FOREIGN KEY (banned_by_user_id) REFERENCES users(id),
+    CONSTRAINT chk_ip_bans_identifier CHECK (ip <> '' OR device_hash <> '')
+);
+
+CREATE INDEX IF NOT EXISTS idx_ip_bans_ip ON ip_bans (ip) WHERE ip <> '';
+CREATE INDEX IF NOT EXISTS idx_ip_bans_device_hash ON ip_bans (device_hash) WHERE device_hash <> '';
@@ -0,0 +1,2 @@
+ALTER TABLE game_stats
+  DROP COLUMN IF EXISTS rating;
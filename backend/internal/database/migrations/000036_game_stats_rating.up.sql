@@ -0,0 +1,3 @@
+-- Elo rating per user per game type, updated after each finished game.
+ALTER TABLE game_stats
+  ADD COLUMN IF NOT EXISTS rating INTEGER NOT NULL DEFAULT 1200;
@@ -0,0 +1,3 @@
+-- Per-participant mute: suppresses bell and push notifications only.
+ALTER TABLE conversation_participants
+  ADD COLUMN IF NOT EXISTS muted BOOLEAN NOT NULL DEFAULT FALSE;
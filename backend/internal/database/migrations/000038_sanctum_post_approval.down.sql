@@ -0,0 +1,2 @@
+ALTER TABLE sanctums
+  DROP COLUMN IF EXISTS requires_post_approval;
@@ -0,0 +1,4 @@
+-- Approval-gated sanctums hold new posts in pending_review until a sanctum
+-- owner or moderator approves them.
+ALTER TABLE sanctums
+  ADD COLUMN IF NOT EXISTS requires_post_approval BOOLEAN NOT NULL DEFAULT FALSE;
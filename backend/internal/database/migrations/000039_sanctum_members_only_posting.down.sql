@@ -0,0 +1,2 @@
+ALTER TABLE sanctums
+  DROP COLUMN IF EXISTS members_only_posting;
@@ -0,0 +1,3 @@
+-- Members-only sanctums reject posts from users without a membership row.
+ALTER TABLE sanctums
+  ADD COLUMN IF NOT EXISTS members_only_posting BOOLEAN NOT NULL DEFAULT FALSE;
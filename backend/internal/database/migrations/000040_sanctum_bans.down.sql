@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sanctum_bans_banned_by_user_id;
+DROP INDEX IF EXISTS idx_sanctum_bans_user_id;
+DROP TABLE IF EXISTS sanctum_bans;
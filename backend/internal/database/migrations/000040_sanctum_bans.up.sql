@@ -0,0 +1,17 @@
+-- Community-scoped bans: block posting and commenting in one sanctum without
+-- touching the platform-wide users.is_banned flag.
+CREATE TABLE IF NOT EXISTS sanctum_bans (
+    sanctum_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    banned_by_user_id BIGINT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT pk_sanctum_bans PRIMARY KEY (sanctum_id, user_id),
+    CONSTRAINT fk_sanctum_bans_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_bans_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_bans_banned_by_user FOREIGN KEY (banned_by_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctum_bans_user_id ON sanctum_bans (user_id);
+CREATE INDEX IF NOT EXISTS idx_sanctum_bans_banned_by_user_id ON sanctum_bans (banned_by_user_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_crossposts_user_id;
+DROP INDEX IF EXISTS idx_crossposts_sanctum_id;
+DROP INDEX IF EXISTS idx_crossposts_post_sanctum;
+DROP TABLE IF EXISTS crossposts;
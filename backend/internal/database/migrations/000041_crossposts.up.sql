@@ -0,0 +1,16 @@
+-- Crossposts surface an existing post in another sanctum's feed. One row per
+-- (post, sanctum) pair; the post itself is never duplicated.
+CREATE TABLE IF NOT EXISTS crossposts (
+    id BIGSERIAL PRIMARY KEY,
+    post_id BIGINT NOT NULL,
+    sanctum_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_crossposts_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    CONSTRAINT fk_crossposts_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_crossposts_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_crossposts_post_sanctum ON crossposts (post_id, sanctum_id);
+CREATE INDEX IF NOT EXISTS idx_crossposts_sanctum_id ON crossposts (sanctum_id);
+CREATE INDEX IF NOT EXISTS idx_crossposts_user_id ON crossposts (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE game_rooms
+  DROP COLUMN IF EXISTS version;
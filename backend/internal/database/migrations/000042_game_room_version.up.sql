@@ -0,0 +1,4 @@
+-- Game rooms carry a version that every write bumps, so a move computed from
+-- stale state is rejected instead of overwriting a newer one.
+ALTER TABLE game_rooms
+  ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE chatroom_bans
+  DROP COLUMN IF EXISTS from_sanctum_ban;
@@ -0,0 +1,4 @@
+-- Room bans created by a sanctum ban are marked, so lifting the sanctum ban
+-- removes only those and leaves bans moderators placed on their own.
+ALTER TABLE chatroom_bans
+  ADD COLUMN IF NOT EXISTS from_sanctum_ban BOOLEAN NOT NULL DEFAULT FALSE;
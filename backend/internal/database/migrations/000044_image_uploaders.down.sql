@@ -0,0 +1,8 @@
+ALTER TABLE images
+  ADD COLUMN IF NOT EXISTS ref_count INT NOT NULL DEFAULT 1;
+
+UPDATE images SET ref_count = GREATEST(1, (
+    SELECT COUNT(*) FROM image_uploaders WHERE image_uploaders.image_id = images.id
+));
+
+DROP TABLE IF EXISTS image_uploaders;
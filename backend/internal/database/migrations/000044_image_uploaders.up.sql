@@ -0,0 +1,20 @@
+-- Deduplicated images track who uploaded them instead of a bare counter, so
+-- re-uploads by the same user do not inflate it and purging a user's content
+-- releases only that user's claim.
+CREATE TABLE IF NOT EXISTS image_uploaders (
+    image_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (image_id, user_id),
+    CONSTRAINT fk_image_uploaders_image FOREIGN KEY (image_id) REFERENCES images(id) ON DELETE CASCADE,
+    CONSTRAINT fk_image_uploaders_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_uploaders_user_id ON image_uploaders (user_id);
+
+INSERT INTO image_uploaders (image_id, user_id, created_at)
+SELECT id, user_id, created_at FROM images
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE images
+  DROP COLUMN IF EXISTS ref_count;
@@ -0,0 +1,3 @@
+-- Group room read positions are only published for participants who opt in.
+ALTER TABLE conversation_participants
+  ADD COLUMN IF NOT EXISTS share_read_receipts BOOLEAN NOT NULL DEFAULT FALSE;
@@ -7,24 +7,36 @@ func PersistentModels() []interface{} {
 	return []interface{}{
 		&models.User{},
 		&models.Post{},
+		&models.PostRevision{},
+		&models.Tag{},
 		&models.Poll{},
 		&models.PollOption{},
 		&models.PollVote{},
 		&models.Image{},
 		&models.ImageVariant{},
+		&models.ImageUploader{},
 		&models.Comment{},
 		&models.Like{},
+		&models.CommentLike{},
+		&models.Bookmark{},
 		&models.Conversation{},
 		&models.ChatroomModerator{},
 		&models.ChatroomBan{},
 		&models.Message{},
 		&models.MessageReaction{},
 		&models.MessageMention{},
+		&models.PinnedMessage{},
 		&models.ConversationParticipant{},
+		&models.ConversationWebhook{},
 		&models.UserBlock{},
 		&models.ModerationReport{},
+		&models.AdminAuditLog{},
+		&models.IPBan{},
 		&models.ChatroomMute{},
+		&models.ChatroomContentFilter{},
 		&models.WelcomeBotEvent{},
+		&models.Notification{},
+		&models.PushSubscription{},
 		&models.Friendship{},
 		&models.GameRoom{},
 		&models.GameMove{},
@@ -33,5 +45,7 @@ func PersistentModels() []interface{} {
 		&models.Sanctum{},
 		&models.SanctumRequest{},
 		&models.SanctumMembership{},
+		&models.SanctumBan{},
+		&models.Crosspost{},
 	}
 }
@@ -9,7 +9,7 @@ import (
 )
 
 // Manager evaluates feature flags defined in a simple key=value list.
-// Example: "new_chat=on,new_feed=25%,legacy_ui=off"
+// Example: "new_chat=on,new_feed=25%+users:7,legacy_ui=off,beta_dm=users:1|42"
 type Manager struct {
 	flags map[string]string
 }
@@ -38,12 +38,17 @@ func NewManager(raw string) *Manager {
 	return &Manager{flags: out}
 }
 
-// Enabled returns whether a flag is enabled for a given user.
+// IsEnabledForUser returns whether a flag is enabled for a given user. The
+// result is deterministic: the same user always lands on the same side of a
+// percentage rollout, so handlers can gate behaviour per request.
 // Supported values:
 // - on/true/1
 // - off/false/0
 // - N% (deterministic user rollout, e.g. 25%)
-func (m *Manager) Enabled(name string, userID uint) bool {
+// - users:ID|ID (explicit per-user allow-list, e.g. users:1|42)
+// Rules can be combined with "+" and the flag is on if any rule matches,
+// e.g. "10%+users:1|42" rolls out to 10% plus two named testers.
+func (m *Manager) IsEnabledForUser(name string, userID uint) bool {
 	if m == nil {
 		return false
 	}
@@ -53,15 +58,38 @@ func (m *Manager) Enabled(name string, userID uint) bool {
 		return false
 	}
 
-	switch value {
+	for _, rule := range strings.Split(value, "+") {
+		if ruleEnabled(name, strings.TrimSpace(rule), userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleEnabled evaluates one rule of a flag value.
+func ruleEnabled(name, rule string, userID uint) bool {
+	switch rule {
 	case "on", "true", "1":
 		return true
 	case "off", "false", "0":
 		return false
 	}
 
-	if strings.HasSuffix(value, "%") {
-		pctRaw := strings.TrimSuffix(value, "%")
+	if ids, ok := strings.CutPrefix(rule, "users:"); ok {
+		if userID == 0 {
+			return false
+		}
+		for _, raw := range strings.Split(ids, "|") {
+			id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+			if err == nil && uint(id) == userID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if strings.HasSuffix(rule, "%") {
+		pctRaw := strings.TrimSuffix(rule, "%")
 		pct, err := strconv.Atoi(pctRaw)
 		if err != nil {
 			return false
@@ -94,7 +122,7 @@ func (m *Manager) Raw() map[string]string {
 func (m *Manager) Snapshot(userID uint) map[string]bool {
 	out := make(map[string]bool, len(m.flags))
 	for name := range m.flags {
-		out[name] = m.Enabled(name, userID)
+		out[name] = m.IsEnabledForUser(name, userID)
 	}
 	return out
 }
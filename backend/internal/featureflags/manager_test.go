@@ -1,14 +1,17 @@
 package featureflags
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestEnabled_BooleanValues(t *testing.T) {
 	m := NewManager("a=on,b=off,c=true,d=false,e=1,f=0")
 
-	if !m.Enabled("a", 1) || !m.Enabled("c", 1) || !m.Enabled("e", 1) {
+	if !m.IsEnabledForUser("a", 1) || !m.IsEnabledForUser("c", 1) || !m.IsEnabledForUser("e", 1) {
 		t.Fatal("expected enabled boolean values to evaluate true")
 	}
-	if m.Enabled("b", 1) || m.Enabled("d", 1) || m.Enabled("f", 1) {
+	if m.IsEnabledForUser("b", 1) || m.IsEnabledForUser("d", 1) || m.IsEnabledForUser("f", 1) {
 		t.Fatal("expected disabled boolean values to evaluate false")
 	}
 }
@@ -16,21 +19,21 @@ func TestEnabled_BooleanValues(t *testing.T) {
 func TestEnabled_PercentageValues(t *testing.T) {
 	m := NewManager("always=100%,never=0%,canary=25%")
 
-	if !m.Enabled("always", 1) {
+	if !m.IsEnabledForUser("always", 1) {
 		t.Fatal("100% rollout should always be enabled")
 	}
-	if m.Enabled("never", 1) {
+	if m.IsEnabledForUser("never", 1) {
 		t.Fatal("0% rollout should always be disabled")
 	}
 
-	first := m.Enabled("canary", 42)
+	first := m.IsEnabledForUser("canary", 42)
 	for i := 0; i < 5; i++ {
-		if got := m.Enabled("canary", 42); got != first {
+		if got := m.IsEnabledForUser("canary", 42); got != first {
 			t.Fatal("rollout evaluation must be deterministic per user")
 		}
 	}
 
-	if m.Enabled("canary", 0) {
+	if m.IsEnabledForUser("canary", 0) {
 		t.Fatal("percentage rollout requires non-zero userID")
 	}
 }
@@ -51,3 +54,61 @@ func TestParseAndSnapshot(t *testing.T) {
 		t.Fatalf("expected snapshot size 3, got %d", len(snap))
 	}
 }
+
+func TestEnabled_UserAllowList(t *testing.T) {
+	m := NewManager("beta_dm=users:7| 42,empty=users:")
+
+	if !m.IsEnabledForUser("beta_dm", 7) || !m.IsEnabledForUser("beta_dm", 42) {
+		t.Fatal("listed users should be enabled")
+	}
+	if m.IsEnabledForUser("beta_dm", 8) || m.IsEnabledForUser("beta_dm", 0) {
+		t.Fatal("unlisted and anonymous users should be disabled")
+	}
+	if m.IsEnabledForUser("empty", 7) {
+		t.Fatal("an empty allow-list should enable nobody")
+	}
+}
+
+func TestIsEnabledForUser_HalfRolloutIsStable(t *testing.T) {
+	m := NewManager("half=50%")
+
+	enabled := 0
+	for userID := uint(1); userID <= 1000; userID++ {
+		first := m.IsEnabledForUser("half", userID)
+		for i := 0; i < 3; i++ {
+			if m.IsEnabledForUser("half", userID) != first {
+				t.Fatalf("user %d flipped between evaluations", userID)
+			}
+		}
+		if first {
+			enabled++
+		}
+	}
+
+	if enabled < 400 || enabled > 600 {
+		t.Fatalf("expected roughly half of 1000 users enabled, got %d", enabled)
+	}
+}
+
+func TestIsEnabledForUser_AllowListOverridesRollout(t *testing.T) {
+	m := NewManager("half=50%")
+
+	// Find a user the rollout excludes, then allow-list them explicitly.
+	var excluded uint
+	for userID := uint(1); userID <= 1000; userID++ {
+		if !m.IsEnabledForUser("half", userID) {
+			excluded = userID
+			break
+		}
+	}
+	if excluded == 0 {
+		t.Fatal("expected at least one user outside a 50% rollout")
+	}
+
+	m = NewManager(fmt.Sprintf("half=50%%+users:%d", excluded))
+	for i := 0; i < 5; i++ {
+		if !m.IsEnabledForUser("half", excluded) {
+			t.Fatal("allow-listed user must always get the flag")
+		}
+	}
+}
@@ -45,15 +45,15 @@ func (h *ctxHandler) Handle(ctx context.Context, r slog.Record) error {
 
 func init() {
 	// Initialize with a default text handler until InitLogger is called explicitly
-	InitLogger("development")
+	InitLogger(false, slog.LevelInfo)
 }
 
-// InitLogger initializes the global structured logger instance based on the provided environment.
-func InitLogger(env string) {
+// InitLogger initializes the global structured logger instance. Production
+// emits JSON; local environments get readable text output.
+func InitLogger(jsonOutput bool, level slog.Level) {
 	var handler slog.Handler
-	level := slog.LevelInfo
 
-	if env == "production" {
+	if jsonOutput {
 		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
 	} else {
 		// Pretty text output for local development/test
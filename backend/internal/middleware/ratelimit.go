@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"sanctum/internal/observability"
@@ -23,46 +24,87 @@ const (
 	FailClosed
 )
 
+// RateLimitState describes a caller's position in the current window.
+type RateLimitState struct {
+	Limit     int
+	Remaining int
+	// Reset is the time left until the window's counter expires.
+	Reset time.Duration
+}
+
 // CheckRateLimit checks if a resource has exceeded its rate limit.
 // Returns true if allowed, false if limit exceeded.
 // Rate limiting is disabled when env is "test", "development" or "stress" so dev and load test workflows are not throttled.
 func CheckRateLimit(ctx context.Context, rdb *redis.Client, env, resource, id string, limit int, window time.Duration) (bool, error) {
+	allowed, _, err := checkRateLimitState(ctx, rdb, env, resource, id, limit, window)
+	return allowed, err
+}
+
+// checkRateLimitState is CheckRateLimit that also reports the counter state.
+// The state is nil when rate limiting is bypassed for the environment.
+func checkRateLimitState(ctx context.Context, rdb *redis.Client, env, resource, id string, limit int, window time.Duration) (bool, *RateLimitState, error) {
 	if env == "" {
 		env = "development"
 	}
 
 	switch env {
 	case "test", "development", "stress":
-		return true, nil
+		return true, nil, nil
 	}
 
 	if rdb == nil {
-		return false, fmt.Errorf("redis client is nil")
+		return false, nil, fmt.Errorf("redis client is nil")
 	}
 
 	key := fmt.Sprintf("rl:%s:%s", resource, id)
 
 	// Atomic INCR+EXPIRE via Lua script to prevent race window where
 	// EXPIRE fails after INCR, permanently rate-limiting the user.
-	cnt, err := rateLimitScript.Run(ctx, rdb, []string{key}, int(window.Seconds())).Int64()
+	res, err := rateLimitScript.Run(ctx, rdb, []string{key}, int(window.Seconds())).Int64Slice()
 	if err != nil {
-		return false, err
+		return false, nil, err
+	}
+	if len(res) != 2 {
+		return false, nil, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	cnt, ttl := res[0], res[1]
+	if ttl < 0 {
+		ttl = int64(window.Seconds())
 	}
-	if cnt > int64(limit) {
-		return false, nil
+
+	state := &RateLimitState{
+		Limit:     limit,
+		Remaining: max(limit-int(cnt), 0),
+		Reset:     time.Duration(ttl) * time.Second,
 	}
-	return true, nil
+	return cnt <= int64(limit), state, nil
 }
 
-// rateLimitScript atomically increments and sets expiry on first use.
+// rateLimitScript atomically increments and sets expiry on first use,
+// returning the new count and the key's remaining TTL in seconds.
 var rateLimitScript = redis.NewScript(`
 	local cnt = redis.call('INCR', KEYS[1])
 	if cnt == 1 then
 		redis.call('EXPIRE', KEYS[1], ARGV[1])
 	end
-	return cnt
+	return {cnt, redis.call('TTL', KEYS[1])}
 `)
 
+// setRateLimitHeaders writes the X-RateLimit-* headers for state. Reset is
+// expressed, like Retry-After, in seconds from now.
+func setRateLimitHeaders(c *fiber.Ctx, state *RateLimitState) {
+	c.Set("X-RateLimit-Limit", strconv.Itoa(state.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(state.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(resetSeconds(state.Reset)))
+}
+
+// resetSeconds rounds d up to whole seconds, never below one, so clients
+// never retry before the window has actually expired.
+func resetSeconds(d time.Duration) int {
+	secs := int((d + time.Second - 1) / time.Second)
+	return max(secs, 1)
+}
+
 // RateLimit returns a Fiber middleware enforcing `limit` requests per `window`.
 // It keys by authenticated userID (if set in c.Locals("userID")) otherwise by remote IP.
 // It defaults to FailOpen policy.
@@ -88,7 +130,7 @@ func RateLimitWithPolicy(rdb *redis.Client, env string, limit int, window time.D
 			resource = name[0]
 		}
 
-		allowed, err := CheckRateLimit(ctx, rdb, env, resource, id, limit, window)
+		allowed, state, err := checkRateLimitState(ctx, rdb, env, resource, id, limit, window)
 		if err != nil {
 			if policy == FailClosed {
 				observability.GlobalLogger.WarnContext(c.UserContext(), "rate limit fail-closed",
@@ -105,8 +147,12 @@ func RateLimitWithPolicy(rdb *redis.Client, env string, limit int, window time.D
 			return c.Next()
 		}
 
+		if state != nil {
+			setRateLimitHeaders(c, state)
+		}
 		if !allowed {
 			// Too many requests
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(resetSeconds(state.Reset)))
 			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
 				"error": "rate limit exceeded",
 			})
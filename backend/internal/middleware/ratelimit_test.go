@@ -4,12 +4,15 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Note: Testing RateLimit middleware requires a real or mocked Redis.
@@ -150,3 +153,42 @@ func TestRateLimitMiddleware(t *testing.T) {
 		_ = resp.Body.Close()
 	})
 }
+
+func TestRateLimitHeaders(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	app := fiber.New()
+	app.Get("/limited", RateLimitWithPolicy(rdb, "production", 2, time.Minute, FailClosed, "limited"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	call := func() *http.Response {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/limited", nil))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	first := call()
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Equal(t, "2", first.Header.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", first.Header.Get("X-RateLimit-Reset"))
+	assert.Empty(t, first.Header.Get(fiber.HeaderRetryAfter))
+
+	second := call()
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+	assert.Equal(t, "0", second.Header.Get("X-RateLimit-Remaining"))
+
+	mr.FastForward(20 * time.Second)
+	throttled := call()
+	assert.Equal(t, http.StatusTooManyRequests, throttled.StatusCode)
+	assert.Equal(t, "0", throttled.Header.Get("X-RateLimit-Remaining"))
+	retryAfter, err := strconv.Atoi(throttled.Header.Get(fiber.HeaderRetryAfter))
+	require.NoError(t, err, "Retry-After must be numeric")
+	assert.Equal(t, 40, retryAfter)
+	assert.Equal(t, strconv.Itoa(retryAfter), throttled.Header.Get("X-RateLimit-Reset"))
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Admin audit actions.
+const (
+	AuditActionUserBan               = "user.ban"
+	AuditActionUserUnban             = "user.unban"
+	AuditActionUserShadowBan         = "user.shadow_ban"
+	AuditActionSanctumRequestApprove = "sanctum_request.approve"
+	AuditActionSanctumRequestReject  = "sanctum_request.reject"
+	AuditActionReportResolve         = "report.resolve"
+	AuditActionIPBanCreate           = "ip_ban.create"
+	AuditActionIPBanDelete           = "ip_ban.delete"
+	AuditActionSanctumBanCreate      = "sanctum_ban.create"
+	AuditActionSanctumBanDelete      = "sanctum_ban.delete"
+	AuditActionAuditLogExport        = "audit_log.export"
+)
+
+// AdminAuditLog records one admin mutation (or an export of the log itself):
+// who did it, to what, and the target's relevant state before and after.
+type AdminAuditLog struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	ActorUserID uint            `gorm:"not null;index" json:"actor_user_id"`
+	Actor       *User           `gorm:"foreignKey:ActorUserID" json:"actor,omitempty"`
+	Action      string          `gorm:"type:varchar(64);not null;index" json:"action"`
+	TargetType  string          `gorm:"type:varchar(32);not null;index:idx_admin_audit_logs_target,priority:1" json:"target_type"`
+	TargetID    uint            `gorm:"not null;index:idx_admin_audit_logs_target,priority:2" json:"target_id"`
+	Before      json.RawMessage `gorm:"type:json" json:"before,omitempty" swaggertype:"object"`
+	After       json.RawMessage `gorm:"type:json" json:"after,omitempty" swaggertype:"object"`
+	CreatedAt   time.Time       `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Bookmark represents a post a user saved for later. Bookmarks are private to
+// the user who created them. The combination of UserID and PostID must be unique.
+type Bookmark struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_bookmarks_user_post" json:"user_id"`
+	PostID    uint      `gorm:"not null;uniqueIndex:idx_bookmarks_user_post;index" json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+	Post Post `gorm:"foreignKey:PostID" json:"post"`
+}
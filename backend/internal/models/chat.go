@@ -10,44 +10,67 @@ import (
 
 // Conversation represents a chat conversation (can be 1-on-1 or group)
 type Conversation struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	Name         string         `json:"name"` // For group chats
-	IsGroup      bool           `gorm:"default:false" json:"is_group"`
-	Avatar       string         `json:"avatar"` // For group chats
-	CreatedBy    uint           `json:"created_by"`
-	SanctumID    *uint          `gorm:"uniqueIndex:idx_conversations_sanctum_id_unique,where:sanctum_id IS NOT NULL" json:"sanctum_id,omitempty"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
-	Participants []User         `gorm:"many2many:conversation_participants;" json:"participants,omitempty"`
-	Messages     []Message      `gorm:"foreignKey:ConversationID" json:"messages,omitempty"`
-	UnreadCount  int            `gorm:"-" json:"unread_count"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Name            string         `json:"name"` // For group chats
+	IsGroup         bool           `gorm:"default:false" json:"is_group"`
+	Avatar          string         `json:"avatar"` // For group chats
+	CreatedBy       uint           `json:"created_by"`
+	SanctumID       *uint          `gorm:"uniqueIndex:idx_conversations_sanctum_id_unique,where:sanctum_id IS NOT NULL" json:"sanctum_id,omitempty"`
+	SlowModeSeconds int            `gorm:"not null;default:0" json:"slow_mode_seconds"` // per-user send cooldown in group rooms; 0 disables
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	Participants    []User         `gorm:"many2many:conversation_participants;" json:"participants,omitempty"`
+	Messages        []Message      `gorm:"foreignKey:ConversationID" json:"messages,omitempty"`
+	UnreadCount     int            `gorm:"-" json:"unread_count"`
 }
 
 // Message represents a chat message
 type Message struct {
-	ID             uint              `gorm:"primaryKey" json:"id"`
-	ConversationID uint              `gorm:"not null;index" json:"conversation_id"`
-	Conversation   *Conversation     `gorm:"foreignKey:ConversationID" json:"conversation,omitempty"`
-	SenderID       uint              `gorm:"not null;index" json:"sender_id"`
-	Sender         *User             `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
-	Content        string            `gorm:"type:text;not null" json:"content"`
-	MessageType    string            `gorm:"default:'text'" json:"message_type"`                       // text, image, file, etc.
-	Metadata       json.RawMessage   `gorm:"type:json" json:"metadata,omitempty" swaggertype:"object"` // For file URLs, image URLs, etc.
-	IsRead         bool              `gorm:"default:false" json:"is_read"`
-	ReadAt         *time.Time        `json:"read_at,omitempty"`
-	Reactions      []MessageReaction `gorm:"foreignKey:MessageID" json:"reactions,omitempty"`
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt    `gorm:"index" json:"-"`
+	ID              uint                     `gorm:"primaryKey" json:"id"`
+	ConversationID  uint                     `gorm:"not null;index" json:"conversation_id"`
+	Conversation    *Conversation            `gorm:"foreignKey:ConversationID" json:"conversation,omitempty"`
+	SenderID        uint                     `gorm:"not null;index" json:"sender_id"`
+	Sender          *User                    `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
+	Content         string                   `gorm:"type:text;not null" json:"content"`
+	MessageType     string                   `gorm:"default:'text'" json:"message_type"`                       // text, image, file, etc.
+	Metadata        json.RawMessage          `gorm:"type:json" json:"metadata,omitempty" swaggertype:"object"` // For file URLs, image URLs, etc.
+	DeliveredAt     *time.Time               `json:"delivered_at,omitempty"`                                   // set when the recipient's client acks receipt
+	IsRead          bool                     `gorm:"default:false" json:"is_read"`
+	ReadAt          *time.Time               `json:"read_at,omitempty"`
+	Reactions       []MessageReaction        `gorm:"foreignKey:MessageID" json:"reactions,omitempty"`
+	ReactionSummary []MessageReactionSummary `gorm:"-" json:"reaction_summary,omitempty"` // filled per viewer when listing messages
+	CreatedAt       time.Time                `json:"created_at"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt           `gorm:"index" json:"-"`
 }
 
 // ConversationParticipant tracks user participation in conversations
 // This is the join table that GORM will use for the many2many relationship
 type ConversationParticipant struct {
-	ConversationID uint      `gorm:"primaryKey" json:"conversation_id"`
-	UserID         uint      `gorm:"primaryKey;index" json:"user_id"`
-	JoinedAt       time.Time `gorm:"autoCreateTime" json:"joined_at"`
-	LastReadAt     time.Time `json:"last_read_at"`
-	UnreadCount    int       `gorm:"default:0" json:"unread_count"`
+	ConversationID    uint      `gorm:"primaryKey" json:"conversation_id"`
+	UserID            uint      `gorm:"primaryKey;index" json:"user_id"`
+	JoinedAt          time.Time `gorm:"autoCreateTime" json:"joined_at"`
+	LastReadAt        time.Time `json:"last_read_at"`
+	LastReadMessageID *uint     `json:"last_read_message_id,omitempty"` // newest message seen at last mark-read
+	UnreadCount       int       `gorm:"default:0" json:"unread_count"`
+	// Muted suppresses bell and push notifications for this participant;
+	// messages are still delivered in realtime.
+	Muted bool `gorm:"not null;default:false" json:"muted"`
+	// ShareReadReceipts opts in to publishing this participant's read
+	// position to the rest of a group room. Direct messages always share it.
+	ShareReadReceipts bool `gorm:"not null;default:false" json:"share_read_receipts"`
+}
+
+// ConversationUnread is one conversation's unread count for the viewer.
+type ConversationUnread struct {
+	ConversationID uint `json:"conversation_id"`
+	UnreadCount    int  `json:"unread_count"`
+}
+
+// UnreadSummary totals the viewer's unread messages across conversations so
+// clients can draw badges without loading every conversation.
+type UnreadSummary struct {
+	Total         int                  `json:"total"`
+	Conversations []ConversationUnread `json:"conversations"`
 }
@@ -4,10 +4,13 @@ import "time"
 
 // ChatroomBan stores room-scoped bans for moderation.
 type ChatroomBan struct {
-	ConversationID uint      `gorm:"primaryKey;autoIncrement:false" json:"conversation_id"`
-	UserID         uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
-	BannedByUserID uint      `gorm:"not null;index" json:"banned_by_user_id"`
-	Reason         string    `gorm:"type:text;default:''" json:"reason"`
+	ConversationID uint   `gorm:"primaryKey;autoIncrement:false" json:"conversation_id"`
+	UserID         uint   `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	BannedByUserID uint   `gorm:"not null;index" json:"banned_by_user_id"`
+	Reason         string `gorm:"type:text;default:''" json:"reason"`
+	// FromSanctumBan marks bans placed by a sanctum ban, which lifting that
+	// sanctum ban removes again.
+	FromSanctumBan bool      `gorm:"not null;default:false" json:"from_sanctum_ban"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Profanity filter modes for chatroom messages.
+const (
+	ProfanityFilterOff    = "off"
+	ProfanityFilterMask   = "mask"
+	ProfanityFilterReject = "reject"
+)
+
+// ChatroomContentFilter holds a chatroom's message filter settings. Rooms
+// without a row use the global defaults.
+type ChatroomContentFilter struct {
+	ConversationID  uint      `gorm:"primaryKey;autoIncrement:false" json:"conversation_id"`
+	ProfanityMode   string    `gorm:"type:varchar(16);not null;default:''" json:"profanity_mode"` // empty inherits the global mode
+	BlockedWords    string    `gorm:"type:text;not null;default:''" json:"-"`                     // comma-separated, on top of the global list
+	BlockLinks      bool      `gorm:"not null;default:false" json:"block_links"`
+	UpdatedByUserID uint      `gorm:"not null" json:"updated_by_user_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (ChatroomContentFilter) TableName() string {
+	return "chatroom_content_filters"
+}
+
+// BlockedWordList returns the room's extra blocked words.
+func (f ChatroomContentFilter) BlockedWordList() []string {
+	words := []string{}
+	for _, w := range strings.Split(f.BlockedWords, ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
@@ -8,14 +8,21 @@ import (
 )
 
 // Comment represents a comment on a post in the Sanctum application.
+// Replies point at their parent through ParentCommentID; Depth is 0 for
+// top-level comments and grows by one per level of nesting.
 type Comment struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Content   string         `gorm:"not null" json:"content"`
-	UserID    uint           `gorm:"not null" json:"user_id"`
-	PostID    uint           `gorm:"not null" json:"post_id"`
-	User      User           `gorm:"foreignKey:UserID" json:"user"`
-	Post      Post           `gorm:"foreignKey:PostID" json:"post,omitempty"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Content         string     `gorm:"not null" json:"content"`
+	UserID          uint       `gorm:"not null" json:"user_id"`
+	PostID          uint       `gorm:"not null" json:"post_id"`
+	ParentCommentID *uint      `gorm:"index" json:"parent_comment_id,omitempty"`
+	Depth           int        `gorm:"not null;default:0" json:"depth"`
+	Replies         []*Comment `gorm:"-" json:"replies,omitempty"`
+	// LikesCount is not persisted; computed at query time
+	LikesCount int            `gorm:"->" json:"likes_count"`
+	User       User           `gorm:"foreignKey:UserID" json:"user"`
+	Post       Post           `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -0,0 +1,15 @@
+// Package models contains data structures for the application's domain models.
+package models
+
+import (
+	"time"
+)
+
+// CommentLike represents a user's like on a comment.
+// The combination of UserID and CommentID must be unique.
+type CommentLike struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_comment_likes_user_comment" json:"user_id"`
+	CommentID uint      `gorm:"not null;uniqueIndex:idx_comment_likes_user_comment;index" json:"comment_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
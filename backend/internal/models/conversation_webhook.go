@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ConversationWebhook is a participant-owned outbound webhook that receives a
+// signed POST whenever a message is sent in a direct conversation.
+type ConversationWebhook struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	ConversationID uint      `gorm:"not null;uniqueIndex:idx_conversation_webhooks_conv_user" json:"conversation_id"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_conversation_webhooks_conv_user;index" json:"user_id"`
+	URL            string    `gorm:"type:text;not null" json:"url"`
+	Secret         string    `gorm:"type:varchar(128);not null" json:"-"`
+	IncludeContent bool      `gorm:"not null" json:"include_content"` // opt-in to forwarding the owner's own message bodies
+	Enabled        bool      `gorm:"not null" json:"enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName returns the database table name for ConversationWebhook.
+func (ConversationWebhook) TableName() string {
+	return "conversation_webhooks"
+}
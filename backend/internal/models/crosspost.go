@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Crosspost shares an existing post into another sanctum's feed without
+// copying it. The post keeps its own SanctumID and author, which is how the
+// target feed attributes it to the source.
+type Crosspost struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;uniqueIndex:idx_crossposts_post_sanctum" json:"post_id"`
+	SanctumID uint      `gorm:"not null;uniqueIndex:idx_crossposts_post_sanctum;index" json:"sanctum_id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Post    *Post    `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Sanctum *Sanctum `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
+	User    *User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName specifies the table name for GORM.
+func (Crosspost) TableName() string {
+	return "crossposts"
+}
@@ -76,6 +76,14 @@ func NewForbiddenError(message string) *AppError {
 	}
 }
 
+// NewConflictError creates a new conflict error with the given message.
+func NewConflictError(message string) *AppError {
+	return &AppError{
+		Code:    "CONFLICT",
+		Message: message,
+	}
+}
+
 // IsSchemaMissingError reports whether err indicates a missing table/column relation.
 func IsSchemaMissingError(err error) bool {
 	if err == nil {
@@ -43,3 +43,10 @@ func (Friendship) TableName() string {
 func (f *Friendship) BeforeCreate(_ *gorm.DB) error {
 	return nil
 }
+
+// FriendSuggestion is a user the viewer might know, with how many friends
+// they have in common.
+type FriendSuggestion struct {
+	User          User `json:"user"`
+	MutualFriends int  `json:"mutual_friends"`
+}
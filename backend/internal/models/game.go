@@ -2,9 +2,11 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GameType defines the type of game
@@ -50,12 +52,35 @@ type GameRoom struct {
 	Configuration string         `gorm:"type:json" json:"configuration,omitempty"` // e.g., board size, game-specific rules
 	CurrentState  string         `gorm:"type:json" json:"current_state"`           // Current board state
 	NextTurnID    uint           `json:"next_turn_id"`                             // ID of user whose turn it is
+	Version       int64          `gorm:"not null;default:0" json:"version"`        // Bumped on every write; see SaveGameRoom
 
 	Creator  User `gorm:"foreignKey:CreatorID" json:"creator,omitempty"`
 	Opponent User `gorm:"foreignKey:OpponentID" json:"opponent,omitempty"`
 	Winner   User `gorm:"foreignKey:WinnerID" json:"winner,omitempty"`
 }
 
+// ErrGameRoomConflict is returned by SaveGameRoom when the room was written by
+// someone else after it was loaded.
+var ErrGameRoomConflict = errors.New("game room was modified concurrently")
+
+// SaveGameRoom writes every column of room, but only if the stored row still
+// has the version room was loaded with. On success room.Version is advanced;
+// otherwise room is left as it was and ErrGameRoomConflict is returned.
+func SaveGameRoom(db *gorm.DB, room *GameRoom) error {
+	loaded := room.Version
+	room.Version++
+	res := db.Model(room).Select("*").Omit(clause.Associations).
+		Where("version = ?", loaded).
+		Updates(room)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrGameRoomConflict
+	}
+	if res.Error != nil {
+		room.Version = loaded
+	}
+	return res.Error
+}
+
 // SetState sets the board state (abstracted as JSON)
 func (r *GameRoom) SetState(board interface{}) {
 	bytes, _ := json.Marshal(board)
@@ -278,6 +303,31 @@ type GameStats struct {
 	Draws      int      `gorm:"default:0" json:"draws"`
 	TotalGames int      `gorm:"default:0" json:"total_games"`
 	Points     int      `gorm:"default:0" json:"points"`
+	// Rating is the user's Elo rating for this game type.
+	Rating int `gorm:"not null;default:1200" json:"rating"`
+}
+
+// LeaderboardEntry is one user's ranked standing for a game type.
+type LeaderboardEntry struct {
+	Rank       int     `json:"rank"`
+	UserID     uint    `json:"user_id"`
+	Username   string  `json:"username"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	Draws      int     `json:"draws"`
+	TotalGames int     `json:"total_games"`
+	Points     int     `json:"points"`
+	Rating     int     `json:"rating"`
+	WinRate    float64 `json:"win_rate"`
+}
+
+// GameLeaderboard is the top of a game type's rankings plus the caller's own
+// standing, which may fall outside Entries.
+type GameLeaderboard struct {
+	GameType GameType           `json:"game_type"`
+	Entries  []LeaderboardEntry `json:"entries"`
+	// Me is nil when the caller has not finished a game of this type.
+	Me *LeaderboardEntry `json:"me"`
 }
 
 // MaxGameRoomMessages is the number of recent chat messages retained per room.
@@ -0,0 +1,24 @@
+package models
+
+import "math"
+
+const (
+	// DefaultGameRating is the rating every player starts a game type with.
+	DefaultGameRating = 1200
+	// EloKFactor caps how many rating points a single game can move.
+	EloKFactor = 32
+)
+
+// EloExpectedScore is the probability, under the Elo model, that a player
+// rated rating beats one rated opponentRating.
+func EloExpectedScore(rating, opponentRating int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponentRating-rating)/400))
+}
+
+// EloRatings returns both players' new ratings after a game in which player A
+// scored scoreA: 1 for a win, 0.5 for a draw, 0 for a loss.
+func EloRatings(ratingA, ratingB int, scoreA float64) (int, int) {
+	newA := float64(ratingA) + EloKFactor*(scoreA-EloExpectedScore(ratingA, ratingB))
+	newB := float64(ratingB) + EloKFactor*((1-scoreA)-EloExpectedScore(ratingB, ratingA))
+	return int(math.Round(newA)), int(math.Round(newB))
+}
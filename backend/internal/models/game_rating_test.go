@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEloExpectedScore(t *testing.T) {
+	require.Equal(t, 0.5, EloExpectedScore(1200, 1200))
+	// A 400-point gap is 10:1 odds.
+	require.InDelta(t, 10.0/11.0, EloExpectedScore(1600, 1200), 1e-9)
+	require.InDelta(t, 1.0, EloExpectedScore(1350, 1180)+EloExpectedScore(1180, 1350), 1e-9)
+}
+
+func TestEloRatings(t *testing.T) {
+	tests := []struct {
+		name             string
+		ratingA, ratingB int
+		scoreA           float64
+		wantA, wantB     int
+	}{
+		{"even win moves half of K", 1200, 1200, 1, 1200 + EloKFactor/2, 1200 - EloKFactor/2},
+		{"even draw changes nothing", 1200, 1200, 0.5, 1200, 1200},
+		{"favourite win gains little", 1600, 1200, 1, 1603, 1197},
+		{"upset win gains nearly K", 1200, 1600, 1, 1229, 1571},
+		{"draw against stronger player gains", 1200, 1600, 0.5, 1213, 1587},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotA, gotB := EloRatings(tt.ratingA, tt.ratingB, tt.scoreA)
+			require.Equal(t, tt.wantA, gotA)
+			require.Equal(t, tt.wantB, gotB)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import "time"
 type Image struct {
 	ID                  uint           `gorm:"primaryKey" json:"id"`
 	Hash                string         `gorm:"size:64;not null;uniqueIndex" json:"hash"`
+	ContentHash         string         `gorm:"size:64;index" json:"-"` // SHA-256 of the uploaded bytes, shared across users
 	UserID              uint           `gorm:"not null;index" json:"user_id"`
 	User                *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	OriginalFilename    string         `gorm:"size:255;not null" json:"original_filename"`
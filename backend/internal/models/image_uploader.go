@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ImageUploader records that a user uploaded an image. Identical uploads are
+// deduplicated onto one image, so an image can have several uploaders; each
+// user is recorded once however often they upload the same bytes.
+type ImageUploader struct {
+	ImageID   uint      `gorm:"primaryKey;autoIncrement:false" json:"image_id"`
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (ImageUploader) TableName() string {
+	return "image_uploaders"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IPBan blocks new signups from an IP address and, when known, from a device
+// fingerprint hash. At least one of IP and DeviceHash is set.
+type IPBan struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	IP             string    `gorm:"type:varchar(64);not null;default:'';index" json:"ip,omitempty"`
+	DeviceHash     string    `gorm:"type:varchar(64);not null;default:'';index" json:"device_hash,omitempty"`
+	Reason         string    `gorm:"type:text;not null;default:''" json:"reason,omitempty"`
+	BannedByUserID uint      `gorm:"not null" json:"banned_by_user_id"`
+	BannedByUser   *User     `gorm:"foreignKey:BannedByUserID" json:"banned_by_user,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (IPBan) TableName() string {
+	return "ip_bans"
+}
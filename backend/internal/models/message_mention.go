@@ -22,3 +22,11 @@ type MessageMention struct {
 func (MessageMention) TableName() string {
 	return "message_mentions"
 }
+
+// MentionSuggestion is a candidate for @-autocomplete. IsFriend and
+// RecentlyInteracted explain its rank.
+type MentionSuggestion struct {
+	User               User `json:"user"`
+	IsFriend           bool `json:"is_friend"`
+	RecentlyInteracted bool `json:"recently_interacted"`
+}
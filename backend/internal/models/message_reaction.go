@@ -17,3 +17,11 @@ type MessageReaction struct {
 func (MessageReaction) TableName() string {
 	return "message_reactions"
 }
+
+// MessageReactionSummary is the per-emoji reaction count on a message, as seen
+// by one viewer.
+type MessageReactionSummary struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
@@ -16,6 +16,13 @@ const (
 	ReportStatusDismissed = "dismissed"
 )
 
+// Report priorities. Reports are escalated to high priority once their target
+// collects enough open reports.
+const (
+	ReportPriorityNormal = "normal"
+	ReportPriorityHigh   = "high"
+)
+
 // ModerationReport stores a user-submitted moderation report against content or users.
 type ModerationReport struct {
 	ID               uint       `gorm:"primaryKey" json:"id"`
@@ -26,6 +33,7 @@ type ModerationReport struct {
 	Reason           string     `gorm:"type:varchar(120);not null" json:"reason"`
 	Details          string     `gorm:"type:text;not null;default:''" json:"details"`
 	Status           string     `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	Priority         string     `gorm:"type:varchar(10);not null;default:'normal';index" json:"priority"`
 	ResolvedByUserID *uint      `gorm:"index" json:"resolved_by_user_id,omitempty"`
 	ResolvedAt       *time.Time `json:"resolved_at,omitempty"`
 	ResolutionNote   string     `gorm:"type:text;not null;default:''" json:"resolution_note"`
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification is a persisted copy of a user-facing realtime event, kept so
+// the notification list survives reconnects.
+type Notification struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	UserID    uint            `gorm:"not null;index:idx_notifications_user_created,priority:1" json:"user_id"`
+	Type      string          `gorm:"type:varchar(64);not null" json:"type"`
+	Payload   json.RawMessage `gorm:"type:json" json:"payload" swaggertype:"object"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+	CreatedAt time.Time       `gorm:"index:idx_notifications_user_created,priority:2,sort:desc" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (Notification) TableName() string {
+	return "notifications"
+}
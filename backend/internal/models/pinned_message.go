@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PinnedMessage marks a message that moderators pinned to the top of a chatroom.
+type PinnedMessage struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	ConversationID uint      `gorm:"not null;index;uniqueIndex:uq_pinned_messages" json:"conversation_id"`
+	MessageID      uint      `gorm:"not null;uniqueIndex:uq_pinned_messages" json:"message_id"`
+	PinnedByUserID uint      `gorm:"not null" json:"pinned_by_user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	Message      *Message `gorm:"foreignKey:MessageID" json:"message,omitempty"`
+	PinnedByUser *User    `gorm:"foreignKey:PinnedByUserID" json:"pinned_by_user,omitempty"`
+}
+
+// TableName returns the database table name for PinnedMessage.
+func (PinnedMessage) TableName() string {
+	return "pinned_messages"
+}
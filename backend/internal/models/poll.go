@@ -3,16 +3,25 @@ package models
 
 import "time"
 
-// Poll represents a poll attached to a post.
+// Poll represents a poll attached to a post. AllowMultiple lets a voter
+// select several options; once ClosesAt passes no further votes are taken.
 type Poll struct {
-	ID               uint         `gorm:"primaryKey" json:"id"`
-	PostID           uint         `gorm:"uniqueIndex:polls_post_id_key;not null" json:"post_id"`
-	Post             *Post        `gorm:"foreignKey:PostID" json:"-"`
-	Question         string       `gorm:"type:text;not null" json:"question"`
-	Options          []PollOption `gorm:"foreignKey:PollID" json:"options,omitempty"`
-	UserVoteOptionID *uint        `gorm:"-" json:"user_vote_option_id,omitempty"` // filled when loading for current user
-	CreatedAt        time.Time    `json:"created_at"`
-	UpdatedAt        time.Time    `json:"updated_at"`
+	ID                uint         `gorm:"primaryKey" json:"id"`
+	PostID            uint         `gorm:"uniqueIndex:polls_post_id_key;not null" json:"post_id"`
+	Post              *Post        `gorm:"foreignKey:PostID" json:"-"`
+	Question          string       `gorm:"type:text;not null" json:"question"`
+	AllowMultiple     bool         `gorm:"not null;default:false" json:"allow_multiple"`
+	ClosesAt          *time.Time   `json:"closes_at,omitempty"`
+	Options           []PollOption `gorm:"foreignKey:PollID" json:"options,omitempty"`
+	UserVoteOptionID  *uint        `gorm:"-" json:"user_vote_option_id,omitempty"`  // filled when loading for current user
+	UserVoteOptionIDs []uint       `gorm:"-" json:"user_vote_option_ids,omitempty"` // every option the current user selected
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// IsClosed reports whether the poll stopped accepting votes at or before now.
+func (p *Poll) IsClosed(now time.Time) bool {
+	return p.ClosesAt != nil && !now.Before(*p.ClosesAt)
 }
 
 // PollOption represents a single choice in a poll.
@@ -25,12 +34,13 @@ type PollOption struct {
 	VotesCount int `gorm:"->" json:"votes_count,omitempty"`
 }
 
-// PollVote records a user's vote for one option in a poll.
+// PollVote records a user's vote for one option in a poll. A multiple-choice
+// ballot is stored as one row per selected option.
 type PollVote struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	UserID       uint      `gorm:"uniqueIndex:uq_poll_votes_user_poll;not null" json:"user_id"`
-	PollID       uint      `gorm:"uniqueIndex:uq_poll_votes_user_poll;not null" json:"poll_id"`
-	PollOptionID uint      `gorm:"not null" json:"poll_option_id"`
+	UserID       uint      `gorm:"uniqueIndex:uq_poll_votes_user_option;not null" json:"user_id"`
+	PollID       uint      `gorm:"index;not null" json:"poll_id"`
+	PollOptionID uint      `gorm:"uniqueIndex:uq_poll_votes_user_option;not null" json:"poll_option_id"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -16,21 +16,32 @@ const (
 	PostTypePoll  = "poll"
 )
 
+// PostStatus values. Scheduled posts stay out of feeds until PublishAt;
+// pending_review posts stay out until a sanctum admin approves them.
+const (
+	PostStatusPublished     = "published"
+	PostStatusScheduled     = "scheduled"
+	PostStatusPendingReview = "pending_review"
+)
+
 // Post represents a post in the Sanctum application.
 type Post struct {
-	ID         uint     `gorm:"primaryKey" json:"id"`
-	Title      string   `gorm:"not null" json:"title"`
-	Content    string   `gorm:"type:text;not null" json:"content"`
-	ImageURL   string   `json:"image_url"`
-	ImageHash  string   `gorm:"size:64;index" json:"-"`
-	PostType   string   `gorm:"type:varchar(20);not null;default:text" json:"post_type"`
-	LinkURL    string   `gorm:"type:varchar(2048)" json:"link_url,omitempty"`
-	YoutubeURL string   `gorm:"type:varchar(512)" json:"youtube_url,omitempty"`
-	UserID     uint     `gorm:"not null;index" json:"user_id"`
-	User       User     `gorm:"foreignKey:UserID" json:"user"`
-	SanctumID  *uint    `gorm:"index" json:"sanctum_id,omitempty"`
-	Sanctum    *Sanctum `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
-	Poll       *Poll    `gorm:"foreignKey:PostID" json:"poll,omitempty"`
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Title      string     `gorm:"not null" json:"title"`
+	Content    string     `gorm:"type:text;not null" json:"content"`
+	ImageURL   string     `json:"image_url"`
+	ImageHash  string     `gorm:"size:64;index" json:"-"`
+	PostType   string     `gorm:"type:varchar(20);not null;default:text" json:"post_type"`
+	LinkURL    string     `gorm:"type:varchar(2048)" json:"link_url,omitempty"`
+	YoutubeURL string     `gorm:"type:varchar(512)" json:"youtube_url,omitempty"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"user"`
+	SanctumID  *uint      `gorm:"index" json:"sanctum_id,omitempty"`
+	Sanctum    *Sanctum   `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
+	Poll       *Poll      `gorm:"foreignKey:PostID" json:"poll,omitempty"`
+	Tags       []Tag      `gorm:"many2many:post_tags" json:"tags,omitempty"`
+	Status     string     `gorm:"type:varchar(20);not null;default:published;index" json:"status"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
 	// LikesCount is not persisted; computed at query time
 	LikesCount int `gorm:"->" json:"likes_count"`
 	// CommentsCount is not persisted; computed at query time
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PostRevision captures a post's content as it was before an edit. Rows are
+// append-only; the current content always lives on the post itself.
+type PostRevision struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PostID     uint      `gorm:"not null;index" json:"post_id"`
+	EditorID   uint      `gorm:"not null" json:"editor_id"`
+	Title      string    `gorm:"not null" json:"title"`
+	Content    string    `gorm:"type:text;not null" json:"content"`
+	ImageURL   string    `json:"image_url,omitempty"`
+	LinkURL    string    `gorm:"type:varchar(2048)" json:"link_url,omitempty"`
+	YoutubeURL string    `gorm:"type:varchar(512)" json:"youtube_url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
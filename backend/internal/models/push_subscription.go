@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PushSubscription is a browser Web Push endpoint registered by a user. The
+// P256dh and Auth keys are the subscription's base64url-encoded encryption
+// keys.
+type PushSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Endpoint  string    `gorm:"type:text;not null;uniqueIndex" json:"endpoint"`
+	P256dh    string    `gorm:"column:p256dh;not null" json:"-"`
+	Auth      string    `gorm:"not null" json:"-"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (PushSubscription) TableName() string {
+	return "push_subscriptions"
+}
@@ -14,19 +14,23 @@ const (
 	SanctumStatusRejected SanctumStatus = "rejected"
 	// SanctumStatusBanned indicates a sanctum is disabled by moderation.
 	SanctumStatusBanned SanctumStatus = "banned"
+	// SanctumStatusArchived indicates a sanctum was retired after losing its leadership.
+	SanctumStatusArchived SanctumStatus = "archived"
 )
 
 // Sanctum represents a branded community namespace.
 type Sanctum struct {
-	ID              uint          `gorm:"primaryKey" json:"id"`
-	Name            string        `gorm:"size:120;not null" json:"name"`
-	Slug            string        `gorm:"size:24;not null;unique" json:"slug"`
-	Description     string        `gorm:"type:text" json:"description"`
-	CreatedByUserID *uint         `json:"created_by_user_id"`
-	CreatedByUser   *User         `gorm:"foreignKey:CreatedByUserID" json:"created_by_user,omitempty"`
-	Status          SanctumStatus `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
+	ID                   uint          `gorm:"primaryKey" json:"id"`
+	Name                 string        `gorm:"size:120;not null" json:"name"`
+	Slug                 string        `gorm:"size:24;not null;unique" json:"slug"`
+	Description          string        `gorm:"type:text" json:"description"`
+	CreatedByUserID      *uint         `json:"created_by_user_id"`
+	CreatedByUser        *User         `gorm:"foreignKey:CreatedByUserID" json:"created_by_user,omitempty"`
+	Status               SanctumStatus `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	RequiresPostApproval bool          `gorm:"not null;default:false" json:"requires_post_approval"`
+	MembersOnlyPosting   bool          `gorm:"not null;default:false" json:"members_only_posting"`
+	CreatedAt            time.Time     `json:"created_at"`
+	UpdatedAt            time.Time     `json:"updated_at"`
 }
 
 // TableName specifies the table name for GORM.
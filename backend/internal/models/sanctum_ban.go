@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// SanctumBan bars a user from posting and commenting in one sanctum. It is
+// independent of the platform-wide User.IsBanned flag.
+type SanctumBan struct {
+	SanctumID      uint      `gorm:"primaryKey;autoIncrement:false" json:"sanctum_id"`
+	UserID         uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	BannedByUserID uint      `gorm:"not null;index" json:"banned_by_user_id"`
+	Reason         string    `gorm:"type:text;default:''" json:"reason"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	User         *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	BannedByUser *User `gorm:"foreignKey:BannedByUserID" json:"banned_by_user,omitempty"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumBan) TableName() string {
+	return "sanctum_bans"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Tag is a normalized post tag (lowercase letters, digits, - and _). Tags are
+// shared across sanctums; posts reference them through the post_tags join table.
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:32;not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// TagCount is a tag with the number of posts carrying it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
@@ -9,19 +9,44 @@ import (
 
 // User represents a user in the Sanctum application.
 type User struct {
-	ID             uint           `gorm:"primaryKey" json:"id"`
-	Username       string         `gorm:"unique;not null" json:"username"`
-	Email          string         `gorm:"unique;not null" json:"email"`
-	Password       string         `gorm:"not null" json:"-"`
-	Bio            string         `json:"bio"`
-	Avatar         string         `json:"avatar"`
-	IsAdmin        bool           `gorm:"default:false" json:"is_admin"`
-	IsBanned       bool           `gorm:"default:false" json:"is_banned"`
-	BannedAt       *time.Time     `json:"banned_at,omitempty"`
-	BannedReason   string         `gorm:"type:text;default:''" json:"banned_reason,omitempty"`
-	BannedByUserID *uint          `json:"banned_by_user_id,omitempty"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
-	Posts          []Post         `gorm:"foreignKey:UserID" json:"posts,omitempty"`
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Username       string     `gorm:"unique;not null" json:"username"`
+	Email          string     `gorm:"unique;not null" json:"email"`
+	Password       string     `gorm:"not null" json:"-"`
+	Bio            string     `json:"bio"`
+	Avatar         string     `json:"avatar"`
+	IsAdmin        bool       `gorm:"default:false" json:"is_admin"`
+	IsBanned       bool       `gorm:"default:false" json:"is_banned"`
+	BannedAt       *time.Time `json:"banned_at,omitempty"`
+	BannedReason   string     `gorm:"type:text;default:''" json:"banned_reason,omitempty"`
+	BannedByUserID *uint      `json:"banned_by_user_id,omitempty"`
+	BanExpiresAt   *time.Time `json:"ban_expires_at,omitempty"`
+	// ShadowBanned is never serialized so the user cannot tell.
+	ShadowBanned bool `gorm:"not null;default:false" json:"-"`
+	// LastIP and DeviceHash are where the user last signed up or logged in
+	// from; only admins see them, through the user detail endpoint.
+	LastIP              string         `gorm:"type:varchar(64);not null;default:''" json:"-"`
+	DeviceHash          string         `gorm:"type:varchar(64);not null;default:''" json:"-"`
+	NotifyReportUpdates bool           `gorm:"not null;default:true" json:"notify_report_updates"`
+	DeactivatedAt       *time.Time     `gorm:"index" json:"deactivated_at,omitempty"`
+	OriginalUsername    string         `gorm:"type:text;not null;default:''" json:"-"`
+	OriginalEmail       string         `gorm:"type:text;not null;default:''" json:"-"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+	Posts               []Post         `gorm:"foreignKey:UserID" json:"posts,omitempty"`
+}
+
+// IsBanActive reports whether the user is banned at now. A ban with an
+// expiry in the past is a finished suspension and no longer applies.
+func (u *User) IsBanActive(now time.Time) bool {
+	if !u.IsBanned {
+		return false
+	}
+	return u.BanExpiresAt == nil || now.Before(*u.BanExpiresAt)
+}
+
+// IsDeactivated reports whether the owner has deleted the account.
+func (u *User) IsDeactivated() bool {
+	return u.DeactivatedAt != nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"sanctum/internal/observability"
 
@@ -34,9 +35,25 @@ type ChatHub struct {
 // Name returns a human-readable identifier for this hub.
 func (h *ChatHub) Name() string { return "chat hub" }
 
+// Stats returns the hub's current connection and conversation counts.
+func (h *ChatHub) Stats() HubStats {
+	h.mu.RLock()
+	stats := HubStats{Users: len(h.userConns), Rooms: len(h.conversations)}
+	for _, clients := range h.userConns {
+		stats.Connections += len(clients)
+	}
+	presence := h.presence
+	h.mu.RUnlock()
+	stats.OnlineUsers = stats.Users
+	if presence != nil {
+		stats.OnlineUsers = presence.OnlineUserCount(context.Background())
+	}
+	return stats
+}
+
 // ChatMessage represents a message broadcast to a conversation
 type ChatMessage struct {
-	Type           string      `json:"type"` // "message", "typing", "presence", "read", "room_message", "user_status", "connected_users"
+	Type           string      `json:"type"` // "message", "typing", "typing_stopped", "presence", "read", "room_message", "user_status", "connected_users"
 	ConversationID uint        `json:"conversation_id"`
 	RoomID         uint        `json:"room_id,omitempty"`
 	UserID         uint        `json:"user_id,omitempty"`
@@ -229,6 +246,23 @@ func (h *ChatHub) UnregisterClient(client *Client) {
 	h.BroadcastGlobalStatus(client.UserID, "offline")
 }
 
+// DisconnectUser closes every chat connection the user has on this instance.
+// Each closed client unregisters itself, which takes the user offline.
+func (h *ChatHub) DisconnectUser(userID uint) {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.userConns[userID]))
+	for client := range h.userConns[userID] {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		if client.Conn != nil {
+			_ = client.Conn.Close()
+		}
+	}
+}
+
 // IsUserOnline returns true when the user has at least one active chat websocket client.
 func (h *ChatHub) IsUserOnline(userID uint) bool {
 	if h.presence != nil {
@@ -241,6 +275,15 @@ func (h *ChatHub) IsUserOnline(userID uint) bool {
 	return ok && len(clients) > 0
 }
 
+// LastSeen returns when the user last went offline, as tracked by the
+// shared presence manager.
+func (h *ChatHub) LastSeen(userID uint) (time.Time, bool) {
+	if h.presence == nil {
+		return time.Time{}, false
+	}
+	return h.presence.LastSeen(context.Background(), userID)
+}
+
 // JoinConversation subscribes a user to a conversation's messages
 func (h *ChatHub) JoinConversation(userID, conversationID uint) {
 	h.mu.Lock()
@@ -478,41 +521,31 @@ func (h *ChatHub) BroadcastGlobalStatus(userID uint, status string) {
 	}
 }
 
-// Shutdown gracefully closes all websocket connections
-func (h *ChatHub) Shutdown(_ context.Context) error {
+// Shutdown drains all websocket connections: each client is sent a
+// server_draining frame and given until DrainTimeout (or ctx's deadline) to
+// flush before its socket is closed.
+func (h *ChatHub) Shutdown(ctx context.Context) error {
 	if h.presence != nil {
 		h.presence.Stop()
 	}
 
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	// Close all user connections
-	for userID, clients := range h.userConns {
-		for client := range clients {
-			if client.Conn == nil {
-				continue
-			}
-			if err := client.Conn.WriteMessage(1, // TextMessage
-				[]byte(`{"type":"server_shutdown","message":"Server is shutting down"}`)); err != nil {
-				observability.GlobalLogger.ErrorContext(context.Background(), "chat hub failed to write shutdown message",
-					slog.Uint64("user_id", uint64(userID)),
-					slog.String("error", err.Error()),
-				)
-			}
-			if err := client.Conn.Close(); err != nil {
-				observability.GlobalLogger.ErrorContext(context.Background(), "chat hub failed to close websocket",
-					slog.Uint64("user_id", uint64(userID)),
-					slog.String("error", err.Error()),
-				)
-			}
+	var clients []*Client
+	for _, userClients := range h.userConns {
+		for client := range userClients {
+			clients = append(clients, client)
 		}
 	}
+	h.mu.Unlock()
+
+	drainClients(ctx, h.Name(), clients)
 
 	// Clear all state
+	h.mu.Lock()
 	h.conversations = make(map[uint]map[uint]struct{})
 	h.userActiveConvs = make(map[uint]map[uint]struct{})
 	h.userConns = make(map[uint]map[*Client]bool)
+	h.mu.Unlock()
 
 	return nil
 }
@@ -1,7 +1,14 @@
 package notifications
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"sanctum/internal/observability"
@@ -17,15 +24,43 @@ var (
 	WriteWait = 15 * time.Second
 
 	// PongWait is the time allowed to read the next pong message from the peer (shortened to target ~10s offline detection).
+	// A client that misses it is closed and unregistered. Set with SetHeartbeat.
 	PongWait = 10 * time.Second
 
 	// PingPeriod is the period for sending pings to the peer; must be less than PongWait.
+	// Set with SetHeartbeat.
 	PingPeriod = 3 * time.Second
 
 	// MaxMessageSize is the maximum message size allowed from the peer.
 	MaxMessageSize = 16384
+
+	// SlowClientDropLimit is how many consecutive messages may be dropped
+	// for a full send buffer before the client is disconnected. A client that
+	// stays this far behind is stalled, and the frames it missed are gone.
+	SlowClientDropLimit = 32
+
+	// DrainTimeout is how long a hub's Shutdown waits for clients to flush
+	// their pending sends before force-closing them.
+	DrainTimeout = 5 * time.Second
+
+	// DrainReconnectDelay is the base reconnect delay suggested to clients
+	// in the server_draining frame. Each client gets up to double this, so a
+	// restart does not bring every client back at once.
+	DrainReconnectDelay = 2 * time.Second
 )
 
+// SetHeartbeat overrides PingPeriod and PongWait for clients created
+// afterwards. Zero values keep the current setting. Call it before the hubs
+// start accepting connections.
+func SetHeartbeat(pingPeriod, pongWait time.Duration) {
+	if pingPeriod > 0 {
+		PingPeriod = pingPeriod
+	}
+	if pongWait > 0 {
+		PongWait = pongWait
+	}
+}
+
 // WSHub is an interface for hubs that manage generic clients
 type WSHub interface {
 	UnregisterClient(c *Client)
@@ -51,16 +86,45 @@ type Client struct {
 	// OnActivity is invoked whenever client activity indicates the connection is alive
 	// (incoming message or pong heartbeat).
 	OnActivity func(userID uint)
+
+	// draining is set once the hub starts shutting down; incoming frames are
+	// ignored from then on.
+	draining  atomic.Bool
+	closeSend sync.Once
+	// drops counts consecutive sends dropped for a full buffer.
+	drops          atomic.Int32
+	disconnectOnce sync.Once
+	// writeDone is closed when WritePump exits.
+	writeDone chan struct{}
+	// pingPeriod and pongWait are the heartbeat settings captured when the
+	// client was created; zero falls back to the package defaults.
+	pingPeriod time.Duration
+	pongWait   time.Duration
 }
 
 // NewClient creates a new Client instance
 func NewClient(hub WSHub, conn *websocket.Conn, userID uint) *Client {
 	return &Client{
-		Hub:    hub,
-		Conn:   conn,
-		UserID: userID,
-		Send:   make(chan []byte, 256),
+		Hub:        hub,
+		Conn:       conn,
+		UserID:     userID,
+		Send:       make(chan []byte, 256),
+		writeDone:  make(chan struct{}),
+		pingPeriod: PingPeriod,
+		pongWait:   PongWait,
+	}
+}
+
+// heartbeat returns the client's ping period and pong wait.
+func (c *Client) heartbeat() (time.Duration, time.Duration) {
+	pingPeriod, pongWait := c.pingPeriod, c.pongWait
+	if pingPeriod <= 0 {
+		pingPeriod = PingPeriod
+	}
+	if pongWait <= 0 {
+		pongWait = PongWait
 	}
+	return pingPeriod, pongWait
 }
 
 // ReadPump pumps messages from the websocket connection to the hub.
@@ -70,10 +134,11 @@ func (c *Client) ReadPump() {
 		_ = c.Conn.Close()
 	}()
 
+	_, pongWait := c.heartbeat()
 	c.Conn.SetReadLimit(int64(MaxMessageSize))
-	_ = c.Conn.SetReadDeadline(time.Now().Add(PongWait))
+	_ = c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.Conn.SetPongHandler(func(string) error {
-		_ = c.Conn.SetReadDeadline(time.Now().Add(PongWait))
+		_ = c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 		if c.OnActivity != nil {
 			c.OnActivity(c.UserID)
 		}
@@ -83,12 +148,23 @@ func (c *Client) ReadPump() {
 	for {
 		_, message, err := c.Conn.ReadMessage()
 		if err != nil {
+			// The read deadline only moves on pongs, so a timeout means the
+			// peer stopped answering pings: a dead or half-open connection.
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				observability.WebSocketHeartbeatTimeouts.WithLabelValues(c.hubName()).Inc()
+				log.Printf("Client %d (%s): no pong within %s, disconnecting", c.UserID, c.hubName(), pongWait)
+			}
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("ReadPump Error (User %d): %v", c.UserID, err)
 			}
 			break
 		}
 
+		if c.draining.Load() {
+			continue
+		}
+
 		if c.OnActivity != nil {
 			c.OnActivity(c.UserID)
 		}
@@ -101,10 +177,14 @@ func (c *Client) ReadPump() {
 
 // WritePump pumps messages from the hub to the websocket connection.
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(PingPeriod)
+	pingPeriod, _ := c.heartbeat()
+	ticker := time.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
 		_ = c.Conn.Close()
+		if c.writeDone != nil {
+			close(c.writeDone)
+		}
 	}()
 
 	for {
@@ -136,20 +216,28 @@ func (c *Client) WritePump() {
 	}
 }
 
-// TrySend attempts to send a message to the client, handling closed channels and full buffers
+// TrySend queues a message for the client without ever blocking the caller,
+// so one slow client cannot stall a broadcast. When the send buffer is full
+// the message is dropped; after SlowClientDropLimit drops in a row the client
+// is disconnected.
 func (c *Client) TrySend(message []byte) {
 	defer func() {
 		if r := recover(); r != nil {
-			observability.WebSocketBackpressureDrops.WithLabelValues(c.Hub.Name(), "closed").Inc()
+			observability.WebSocketBackpressureDrops.WithLabelValues(c.hubName(), "closed").Inc()
 		}
 	}()
 
 	select {
 	case c.Send <- message:
+		c.drops.Store(0)
 	default:
 		// Buffer full, drop message and notify client so it can re-fetch
-		observability.WebSocketBackpressureDrops.WithLabelValues(c.Hub.Name(), "full").Inc()
-		log.Printf("Client %d (%s): Buffer full, dropped message", c.UserID, c.Hub.Name())
+		observability.WebSocketBackpressureDrops.WithLabelValues(c.hubName(), "full").Inc()
+		log.Printf("Client %d (%s): Buffer full, dropped message", c.UserID, c.hubName())
+		if c.drops.Add(1) >= int32(SlowClientDropLimit) {
+			c.disconnectSlow()
+			return
+		}
 
 		// Best-effort notification to the client that messages were dropped.
 		// This allows the frontend to detect the gap and re-fetch.
@@ -161,3 +249,94 @@ func (c *Client) TrySend(message []byte) {
 		}
 	}
 }
+
+// disconnectSlow unregisters a client that cannot keep up and closes its
+// socket. It runs asynchronously because TrySend is usually called with the
+// hub's lock held.
+func (c *Client) disconnectSlow() {
+	c.disconnectOnce.Do(func() {
+		observability.WebSocketSlowClientDisconnects.WithLabelValues(c.hubName()).Inc()
+		log.Printf("Client %d (%s): disconnecting slow client after %d dropped messages", c.UserID, c.hubName(), SlowClientDropLimit)
+		go func() {
+			if c.Hub != nil {
+				c.Hub.UnregisterClient(c)
+			}
+			if c.Conn != nil {
+				_ = c.Conn.Close()
+			}
+		}()
+	})
+}
+
+func (c *Client) hubName() string {
+	if c.Hub == nil {
+		return "unknown"
+	}
+	return c.Hub.Name()
+}
+
+// drain queues frame as the client's last message and closes its send
+// channel, so WritePump flushes what is pending and then closes the socket.
+// The returned channel closes once that has happened; it is nil when the
+// client has no WritePump to wait for.
+func (c *Client) drain(frame []byte) <-chan struct{} {
+	c.draining.Store(true)
+	c.TrySend(frame)
+	c.closeSend.Do(func() { close(c.Send) })
+	if c.writeDone == nil {
+		return nil
+	}
+	return c.writeDone
+}
+
+// drainingFrame builds the server_draining frame sent to each client.
+func drainingFrame(hub string) []byte {
+	delay := DrainReconnectDelay + rand.N(DrainReconnectDelay+1)
+	frame, _ := json.Marshal(map[string]interface{}{
+		"type": "server_draining",
+		"payload": map[string]interface{}{
+			"hub":                hub,
+			"reconnect_after_ms": delay.Milliseconds(),
+		},
+	})
+	return frame
+}
+
+// drainClients tells every client the server is draining, waits for their
+// pending sends to flush until DrainTimeout or ctx's deadline, whichever is
+// sooner, and then force-closes whatever is left. Callers must not hold the
+// hub lock: closing sockets unregisters clients.
+func drainClients(ctx context.Context, hub string, clients []*Client) {
+	if len(clients) == 0 {
+		return
+	}
+	deadline := time.Now().Add(DrainTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	pending := make([]<-chan struct{}, len(clients))
+	for i, client := range clients {
+		pending[i] = client.drain(drainingFrame(hub))
+	}
+
+wait:
+	for _, done := range pending {
+		if done == nil {
+			continue
+		}
+		select {
+		case <-done:
+		case <-timer.C:
+			break wait
+		}
+	}
+
+	for _, client := range clients {
+		if client.Conn != nil {
+			_ = client.Conn.Close()
+		}
+	}
+}
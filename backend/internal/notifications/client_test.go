@@ -0,0 +1,173 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"sanctum/internal/observability"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	gorilla "github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatHub_ShutdownDrainsClients(t *testing.T) {
+	hub := NewChatHub()
+	registered := make(chan struct{})
+
+	app := fiber.New()
+	app.Get("/ws", websocket.New(func(conn *websocket.Conn) {
+		client := NewClient(hub, conn, 7)
+		hub.RegisterUser(client)
+		close(registered)
+		go client.WritePump()
+		client.ReadPump()
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	conn, resp, err := gorilla.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/ws", nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	t.Cleanup(func() { _ = conn.Close() })
+
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Fatal("client was never registered")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- hub.Shutdown(ctx) }()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err, "the draining frame arrives before the socket closes")
+	var frame struct {
+		Type    string `json:"type"`
+		Payload struct {
+			Hub              string `json:"hub"`
+			ReconnectAfterMS int64  `json:"reconnect_after_ms"`
+		} `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal(data, &frame))
+	assert.Equal(t, "server_draining", frame.Type)
+	assert.Equal(t, "chat hub", frame.Payload.Hub)
+	assert.GreaterOrEqual(t, frame.Payload.ReconnectAfterMS, DrainReconnectDelay.Milliseconds())
+
+	_, _, err = conn.ReadMessage()
+	assert.Error(t, err, "the socket closes after the draining frame")
+
+	select {
+	case err := <-shutdownDone:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return after draining")
+	}
+	assert.Equal(t, 0, hub.Stats().Connections)
+}
+
+func TestChatHub_DisconnectsSlowClient(t *testing.T) {
+	hub := NewChatHub()
+	slow := &Client{Hub: hub, UserID: 7, Send: make(chan []byte, 1)}
+	hub.RegisterUser(slow)
+	hub.JoinConversation(slow.UserID, 1)
+
+	before := testutil.ToFloat64(observability.WebSocketSlowClientDisconnects.WithLabelValues(hub.Name()))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < SlowClientDropLimit+1; i++ {
+			hub.BroadcastToConversation(1, ChatMessage{Type: "message", ConversationID: 1})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("broadcasting to a stalled client blocked the hub")
+	}
+
+	assert.Eventually(t, func() bool {
+		return hub.Stats().Connections == 0
+	}, time.Second, 10*time.Millisecond, "slow client is unregistered")
+	assert.Equal(t, before+1, testutil.ToFloat64(observability.WebSocketSlowClientDisconnects.WithLabelValues(hub.Name())))
+}
+
+func TestChatHub_DisconnectsClientThatStopsPonging(t *testing.T) {
+	pingPeriod, pongWait := PingPeriod, PongWait
+	SetHeartbeat(50*time.Millisecond, 200*time.Millisecond)
+	t.Cleanup(func() { PingPeriod, PongWait = pingPeriod, pongWait })
+
+	hub := NewChatHub()
+	app := fiber.New()
+	app.Get("/ws/:id", websocket.New(func(conn *websocket.Conn) {
+		userID := uint(7)
+		if conn.Params("id") == "silent" {
+			userID = 8
+		}
+		client := NewClient(hub, conn, userID)
+		hub.RegisterUser(client)
+		go client.WritePump()
+		client.ReadPump()
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	dial := func(id string) *gorilla.Conn {
+		conn, resp, err := gorilla.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/ws/"+id, nil)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+
+	before := testutil.ToFloat64(observability.WebSocketHeartbeatTimeouts.WithLabelValues(hub.Name()))
+
+	// gorilla answers pings while a read is in progress, so the healthy
+	// client keeps reading and the silent one never does.
+	healthy := dial("healthy")
+	go func() {
+		for {
+			if _, _, err := healthy.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	silent := dial("silent")
+
+	require.Eventually(t, func() bool {
+		return hub.Stats().Connections == 2
+	}, time.Second, 10*time.Millisecond, "both clients register")
+
+	assert.Eventually(t, func() bool {
+		return hub.Stats().Connections == 1
+	}, 2*time.Second, 10*time.Millisecond, "the silent client is unregistered")
+	assert.Equal(t, before+1, testutil.ToFloat64(observability.WebSocketHeartbeatTimeouts.WithLabelValues(hub.Name())))
+
+	hub.mu.RLock()
+	_, healthyConnected := hub.userConns[7]
+	_, silentConnected := hub.userConns[8]
+	hub.mu.RUnlock()
+	assert.True(t, healthyConnected, "a client answering pings outlives the timeout")
+	assert.False(t, silentConnected)
+
+	// Once the silent client starts reading it finds the socket closed.
+	_ = silent.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := silent.ReadMessage(); err != nil {
+			break
+		}
+	}
+}
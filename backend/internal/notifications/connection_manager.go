@@ -2,7 +2,10 @@ package notifications
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"log"
+	"os"
 	"strconv"
 	"sync"
 	"time"
@@ -13,10 +16,16 @@ import (
 const (
 	defaultPresenceOnlineSetKey  = "ws:online_users"
 	defaultPresenceLastSeenKeyNS = "ws:last_seen:"
-	// TTL for last-seen key in Redis. Must exceed PongWait (10s) by a comfortable
+	// Per-user set of the instances holding a live connection for that user.
+	defaultPresenceInstancesKeyNS = "ws:presence_instances:"
+	// Records when a user last went offline; outlives the short presence TTL.
+	defaultPresenceLastOfflineKeyNS = "ws:last_offline:"
+	defaultLastOfflineTTL           = 30 * 24 * time.Hour
+	// TTL for each instance's last-seen key in Redis. Must exceed PongWait (10s) by a comfortable
 	// margin so that a pong arriving late under production network jitter does not
 	// expire the key before it can be refreshed, causing false offline events.
-	// 25s gives 2.5× headroom above PongWait and 8× the PingPeriod (3s).
+	// 25s gives 2.5× headroom above PongWait and 8× the PingPeriod (3s); the
+	// server scales it the same way when the heartbeat is configured.
 	defaultPresenceTTL = 25 * time.Second
 	// Small grace to avoid transient flaps but remain responsive.
 	defaultOfflineGrace = 2 * time.Second
@@ -26,17 +35,31 @@ const (
 
 // ConnectionManagerConfig controls Redis presence and cleanup behavior.
 type ConnectionManagerConfig struct {
-	OnlineSetKey       string
-	LastSeenKeyPrefix  string
-	LastSeenTTL        time.Duration
-	OfflineGracePeriod time.Duration
-	ReaperInterval     time.Duration
-	OnUserOnline       func(userID uint)
-	OnUserOffline      func(userID uint)
+	OnlineSetKey      string
+	LastSeenKeyPrefix string
+	LastSeenTTL       time.Duration
+	// InstancesKeyPrefix namespaces the per-user set of instances that hold
+	// a connection for the user.
+	InstancesKeyPrefix string
+	// InstanceID identifies this process in Redis. Defaults to the hostname
+	// plus a random suffix.
+	InstanceID string
+	// LastOfflineKeyPrefix and LastOfflineTTL control where the time a user
+	// went offline is kept in Redis.
+	LastOfflineKeyPrefix string
+	LastOfflineTTL       time.Duration
+	OfflineGracePeriod   time.Duration
+	ReaperInterval       time.Duration
+	OnUserOnline         func(userID uint)
+	OnUserOffline        func(userID uint)
 }
 
 // ConnectionManager tracks active users, mirrors presence in Redis, and emits
 // online/offline transitions with an offline grace window.
+//
+// Each instance keeps its own last-seen key per user, refreshed by Touch. If
+// an instance dies without calling Unregister its keys simply expire, and the
+// user drops offline once no other instance still holds a live key.
 type ConnectionManager struct {
 	rdb *redis.Client
 
@@ -44,12 +67,17 @@ type ConnectionManager struct {
 	localConnCounts map[uint]int
 	offlineTimers   map[uint]*time.Timer
 	offlineNotified map[uint]bool
-
-	onlineSetKey      string
-	lastSeenKeyPrefix string
-	lastSeenTTL       time.Duration
-	offlineGrace      time.Duration
-	reaperInterval    time.Duration
+	lastOffline     map[uint]time.Time
+
+	onlineSetKey         string
+	lastSeenKeyPrefix    string
+	lastSeenTTL          time.Duration
+	instancesKeyPrefix   string
+	instanceID           string
+	lastOfflineKeyPrefix string
+	lastOfflineTTL       time.Duration
+	offlineGrace         time.Duration
+	reaperInterval       time.Duration
 
 	onUserOnline  func(userID uint)
 	onUserOffline func(userID uint)
@@ -66,18 +94,23 @@ type ConnectionManager struct {
 // NewConnectionManager creates a manager and starts a Redis reaper when Redis is available.
 func NewConnectionManager(rdb *redis.Client, cfg ConnectionManagerConfig) *ConnectionManager {
 	m := &ConnectionManager{
-		rdb:               rdb,
-		localConnCounts:   make(map[uint]int),
-		offlineTimers:     make(map[uint]*time.Timer),
-		offlineNotified:   make(map[uint]bool),
-		onlineSetKey:      defaultPresenceOnlineSetKey,
-		lastSeenKeyPrefix: defaultPresenceLastSeenKeyNS,
-		lastSeenTTL:       defaultPresenceTTL,
-		offlineGrace:      defaultOfflineGrace,
-		reaperInterval:    defaultReaperInterval,
-		onUserOnline:      cfg.OnUserOnline,
-		onUserOffline:     cfg.OnUserOffline,
-		stopCh:            make(chan struct{}),
+		rdb:                  rdb,
+		localConnCounts:      make(map[uint]int),
+		offlineTimers:        make(map[uint]*time.Timer),
+		offlineNotified:      make(map[uint]bool),
+		lastOffline:          make(map[uint]time.Time),
+		onlineSetKey:         defaultPresenceOnlineSetKey,
+		lastSeenKeyPrefix:    defaultPresenceLastSeenKeyNS,
+		lastSeenTTL:          defaultPresenceTTL,
+		instancesKeyPrefix:   defaultPresenceInstancesKeyNS,
+		instanceID:           cfg.InstanceID,
+		lastOfflineKeyPrefix: defaultPresenceLastOfflineKeyNS,
+		lastOfflineTTL:       defaultLastOfflineTTL,
+		offlineGrace:         defaultOfflineGrace,
+		reaperInterval:       defaultReaperInterval,
+		onUserOnline:         cfg.OnUserOnline,
+		onUserOffline:        cfg.OnUserOffline,
+		stopCh:               make(chan struct{}),
 	}
 
 	if cfg.OnlineSetKey != "" {
@@ -89,6 +122,18 @@ func NewConnectionManager(rdb *redis.Client, cfg ConnectionManagerConfig) *Conne
 	if cfg.LastSeenTTL > 0 {
 		m.lastSeenTTL = cfg.LastSeenTTL
 	}
+	if cfg.InstancesKeyPrefix != "" {
+		m.instancesKeyPrefix = cfg.InstancesKeyPrefix
+	}
+	if m.instanceID == "" {
+		m.instanceID = newPresenceInstanceID()
+	}
+	if cfg.LastOfflineKeyPrefix != "" {
+		m.lastOfflineKeyPrefix = cfg.LastOfflineKeyPrefix
+	}
+	if cfg.LastOfflineTTL > 0 {
+		m.lastOfflineTTL = cfg.LastOfflineTTL
+	}
 	if cfg.OfflineGracePeriod > 0 {
 		m.offlineGrace = cfg.OfflineGracePeriod
 	}
@@ -176,7 +221,8 @@ func (m *ConnectionManager) Register(ctx context.Context, userID uint) {
 	}
 }
 
-// Touch updates the last-seen timestamp for the user in the presence store.
+// Touch refreshes this instance's last-seen key for the user in the presence
+// store.
 func (m *ConnectionManager) Touch(ctx context.Context, userID uint) {
 	if m.rdb == nil {
 		return
@@ -185,7 +231,10 @@ func (m *ConnectionManager) Touch(ctx context.Context, userID uint) {
 	if err := m.rdb.SAdd(ctx, m.onlineSetKey, uid).Err(); err != nil {
 		log.Printf("presence touch SADD failed for user %d: %v", userID, err)
 	}
-	if err := m.rdb.Set(ctx, m.lastSeenKey(userID), strconv.FormatInt(time.Now().Unix(), 10), m.lastSeenTTL).Err(); err != nil {
+	if err := m.rdb.SAdd(ctx, m.instancesKey(userID), m.instanceID).Err(); err != nil {
+		log.Printf("presence touch instance SADD failed for user %d: %v", userID, err)
+	}
+	if err := m.rdb.Set(ctx, m.lastSeenKey(userID, m.instanceID), strconv.FormatInt(time.Now().Unix(), 10), m.lastSeenTTL).Err(); err != nil {
 		log.Printf("presence touch SET failed for user %d: %v", userID, err)
 	}
 }
@@ -213,7 +262,8 @@ func (m *ConnectionManager) Unregister(_ context.Context, userID uint) {
 	m.mu.Unlock()
 }
 
-// IsOnline returns whether the user has at least one registered connection or is in the presence set.
+// IsOnline returns whether the user has a local connection or a live
+// last-seen key on any instance.
 func (m *ConnectionManager) IsOnline(ctx context.Context, userID uint) bool {
 	m.mu.RLock()
 	if m.localConnCounts[userID] > 0 {
@@ -226,15 +276,56 @@ func (m *ConnectionManager) IsOnline(ctx context.Context, userID uint) bool {
 		return false
 	}
 
-	exists, err := m.rdb.Exists(ctx, m.lastSeenKey(userID)).Result()
+	live, err := m.liveInstances(ctx, userID)
 	if err != nil {
 		return false
 	}
-	return exists > 0
+	return live > 0
 }
 
-// GetOnlineUserIDs returns online user IDs from Redis (with stale filtering),
-// unioned with local connections as a fallback safety net.
+// LastSeen returns when the user last went offline. The second result is
+// false when no offline transition has been recorded for the user.
+func (m *ConnectionManager) LastSeen(ctx context.Context, userID uint) (time.Time, bool) {
+	m.mu.RLock()
+	seen, ok := m.lastOffline[userID]
+	m.mu.RUnlock()
+
+	if m.rdb == nil {
+		return seen, ok
+	}
+	raw, err := m.rdb.Get(ctx, m.lastOfflineKey(userID)).Result()
+	if err != nil {
+		return seen, ok
+	}
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return seen, ok
+	}
+	// Another instance may have seen a later disconnect.
+	if stored := time.Unix(unix, 0).UTC(); !ok || stored.After(seen) {
+		return stored, true
+	}
+	return seen, ok
+}
+
+// OnlineUserCount returns the size of the Redis online set, or the local
+// user count when Redis is unavailable. Unlike GetOnlineUserIDs it does not
+// verify each member's instances, so it may briefly include users whose
+// last-seen keys have expired; it is meant for cheap load reporting.
+func (m *ConnectionManager) OnlineUserCount(ctx context.Context) int {
+	local := len(m.localUserIDs())
+	if m.rdb == nil {
+		return local
+	}
+	count, err := m.rdb.SCard(ctx, m.onlineSetKey).Result()
+	if err != nil {
+		return local
+	}
+	return max(int(count), local)
+}
+
+// GetOnlineUserIDs returns the users in the Redis online set that still have a
+// live last-seen key, unioned with local connections as a fallback safety net.
 func (m *ConnectionManager) GetOnlineUserIDs(ctx context.Context) []uint {
 	local := m.localUserIDs()
 	if m.rdb == nil {
@@ -255,11 +346,11 @@ func (m *ConnectionManager) GetOnlineUserIDs(ctx context.Context) []uint {
 			continue
 		}
 		userID := uint(id64)
-		exists, existsErr := m.rdb.Exists(ctx, m.lastSeenKey(userID)).Result()
-		if existsErr != nil {
+		live, liveErr := m.liveInstances(ctx, userID)
+		if liveErr != nil {
 			continue
 		}
-		if exists == 0 {
+		if live == 0 {
 			_ = m.rdb.SRem(ctx, m.onlineSetKey, raw).Err()
 			continue
 		}
@@ -298,11 +389,11 @@ func (m *ConnectionManager) reapOnce(ctx context.Context) {
 			continue
 		}
 		userID := uint(id64)
-		exists, existsErr := m.rdb.Exists(ctx, m.lastSeenKey(userID)).Result()
-		if existsErr != nil {
+		live, liveErr := m.liveInstances(ctx, userID)
+		if liveErr != nil {
 			continue
 		}
-		if exists > 0 {
+		if live > 0 {
 			continue
 		}
 
@@ -347,9 +438,13 @@ func (m *ConnectionManager) finalizeOffline(ctx context.Context, userID uint) {
 	m.mu.Unlock()
 
 	if m.rdb != nil {
-		exists, err := m.rdb.Exists(ctx, m.lastSeenKey(userID)).Result()
-		if err == nil && exists > 0 {
-			// Another process likely refreshed presence. Keep user online.
+		// This instance no longer holds the user; only other instances'
+		// keys should keep them online.
+		_ = m.rdb.Del(ctx, m.lastSeenKey(userID, m.instanceID)).Err()
+		_ = m.rdb.SRem(ctx, m.instancesKey(userID), m.instanceID).Err()
+		live, err := m.liveInstances(ctx, userID)
+		if err == nil && live > 0 {
+			// Another instance still holds a connection. Keep user online.
 			return
 		}
 		_ = m.rdb.SRem(ctx, m.onlineSetKey, strconv.FormatUint(uint64(userID), 10)).Err()
@@ -385,6 +480,8 @@ func (m *ConnectionManager) emitOffline(userID uint) {
 		return
 	}
 	m.offlineNotified[userID] = true
+	now := time.Now().UTC()
+	m.lastOffline[userID] = now
 	cb := m.onUserOffline
 	listeners := make([]struct {
 		onOnline  func(userID uint)
@@ -392,6 +489,7 @@ func (m *ConnectionManager) emitOffline(userID uint) {
 	}, len(m.listeners))
 	copy(listeners, m.listeners)
 	m.mu.Unlock()
+	m.persistLastOffline(userID, now)
 	if cb != nil {
 		cb(userID)
 	}
@@ -414,6 +512,59 @@ func (m *ConnectionManager) localUserIDs() []uint {
 	return ids
 }
 
-func (m *ConnectionManager) lastSeenKey(userID uint) string {
-	return m.lastSeenKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+// liveInstances counts the instances whose last-seen key for the user has not
+// expired, pruning the ones that have from the user's instance set.
+func (m *ConnectionManager) liveInstances(ctx context.Context, userID uint) (int, error) {
+	instances, err := m.rdb.SMembers(ctx, m.instancesKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	live := 0
+	for _, instanceID := range instances {
+		exists, err := m.rdb.Exists(ctx, m.lastSeenKey(userID, instanceID)).Result()
+		if err != nil {
+			return 0, err
+		}
+		if exists == 0 {
+			_ = m.rdb.SRem(ctx, m.instancesKey(userID), instanceID).Err()
+			continue
+		}
+		live++
+	}
+	return live, nil
+}
+
+func (m *ConnectionManager) lastSeenKey(userID uint, instanceID string) string {
+	return m.lastSeenKeyPrefix + strconv.FormatUint(uint64(userID), 10) + ":" + instanceID
+}
+
+func (m *ConnectionManager) instancesKey(userID uint) string {
+	return m.instancesKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+func (m *ConnectionManager) lastOfflineKey(userID uint) string {
+	return m.lastOfflineKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// persistLastOffline mirrors the offline time to Redis so other instances
+// (and restarts) can report it.
+func (m *ConnectionManager) persistLastOffline(userID uint, at time.Time) {
+	if m.rdb == nil {
+		return
+	}
+	if err := m.rdb.Set(context.Background(), m.lastOfflineKey(userID), strconv.FormatInt(at.Unix(), 10), m.lastOfflineTTL).Err(); err != nil {
+		log.Printf("presence last-offline SET failed for user %d: %v", userID, err)
+	}
+}
+
+// newPresenceInstanceID returns an ID unique to this process, so restarts on
+// the same host do not inherit the previous process's keys.
+func newPresenceInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "instance"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
 }
@@ -0,0 +1,106 @@
+package notifications
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPresence(t *testing.T, rdb *redis.Client, instanceID string, onOffline func(uint)) *ConnectionManager {
+	t.Helper()
+	m := NewConnectionManager(rdb, ConnectionManagerConfig{
+		InstanceID:         instanceID,
+		LastSeenTTL:        10 * time.Second,
+		OfflineGracePeriod: 10 * time.Millisecond,
+		// Reaper passes are driven by the test.
+		ReaperInterval: time.Hour,
+		OnUserOffline:  onOffline,
+	})
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func TestConnectionManager_CrashedInstancePresenceExpires(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	ctx := context.Background()
+
+	crashed := newTestPresence(t, rdb, "crashed", nil)
+	var offline atomic.Int32
+	survivor := newTestPresence(t, rdb, "survivor", func(userID uint) {
+		if userID == 5 {
+			offline.Add(1)
+		}
+	})
+
+	crashed.Register(ctx, 5)
+	require.True(t, survivor.IsOnline(ctx, 5), "presence is visible across instances")
+	assert.Contains(t, survivor.GetOnlineUserIDs(ctx), uint(5))
+
+	// The crashed instance never calls Unregister or Touch again.
+	mr.FastForward(5 * time.Second)
+	assert.True(t, survivor.IsOnline(ctx, 5), "key is still within its TTL")
+	mr.FastForward(6 * time.Second)
+
+	survivor.reapOnce(ctx)
+	assert.Equal(t, int32(1), offline.Load())
+	assert.False(t, survivor.IsOnline(ctx, 5))
+	assert.NotContains(t, survivor.GetOnlineUserIDs(ctx), uint(5))
+}
+
+func TestConnectionManager_OtherInstanceKeepsUserOnline(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	ctx := context.Background()
+
+	var offline atomic.Int32
+	countOffline := func(uint) { offline.Add(1) }
+	a := newTestPresence(t, rdb, "a", countOffline)
+	b := newTestPresence(t, rdb, "b", countOffline)
+
+	a.Register(ctx, 8)
+	b.Register(ctx, 8)
+	b.Unregister(ctx, 8)
+
+	// b's grace period ends, but a still holds a live connection.
+	assert.Eventually(t, func() bool {
+		exists, err := rdb.Exists(ctx, b.lastSeenKey(8, "b")).Result()
+		return err == nil && exists == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, b.IsOnline(ctx, 8))
+	assert.Equal(t, int32(0), offline.Load())
+
+	// a dies without unregistering; its key expires and the reaper notices.
+	mr.FastForward(11 * time.Second)
+	b.reapOnce(ctx)
+	assert.False(t, b.IsOnline(ctx, 8))
+	assert.Equal(t, int32(1), offline.Load())
+}
+
+func TestConnectionManager_OnlineUserCountSpansInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	ctx := context.Background()
+
+	first := newTestPresence(t, rdb, "first", nil)
+	second := newTestPresence(t, rdb, "second", nil)
+
+	first.Register(ctx, 5)
+	second.Register(ctx, 6)
+	second.Register(ctx, 5)
+
+	assert.Equal(t, 2, first.OnlineUserCount(ctx))
+
+	local := newTestPresence(t, nil, "local", nil)
+	local.Register(ctx, 9)
+	assert.Equal(t, 1, local.OnlineUserCount(ctx))
+}
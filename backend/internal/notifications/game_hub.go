@@ -3,6 +3,7 @@ package notifications
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -46,6 +47,19 @@ type GameHub struct {
 // Name returns a human-readable identifier for this hub.
 func (h *GameHub) Name() string { return "game hub" }
 
+// Stats returns the hub's current connection and room counts. Game hubs do not
+// track presence, so OnlineUsers mirrors Users.
+func (h *GameHub) Stats() HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	stats := HubStats{Users: len(h.userRooms), Rooms: len(h.rooms)}
+	for _, clients := range h.rooms {
+		stats.Connections += len(clients)
+	}
+	stats.OnlineUsers = stats.Users
+	return stats
+}
+
 // NewGameHub creates a new GameHub instance
 func NewGameHub(db *gorm.DB, notifier *Notifier) *GameHub {
 	return &GameHub{
@@ -217,7 +231,11 @@ func (h *GameHub) handleJoin(userID uint, action GameAction) bool {
 		room.SetState(initialState)
 	}
 
-	if err := h.db.Save(&room).Error; err != nil {
+	if err := models.SaveGameRoom(h.db, &room); err != nil {
+		if errors.Is(err, models.ErrGameRoomConflict) {
+			h.sendError(userID, action.RoomID, "Game already started or finished")
+			return false
+		}
 		h.sendError(userID, action.RoomID, "Failed to start game")
 		return false
 	}
@@ -515,24 +533,6 @@ func (h *GameHub) handleMove(userID uint, action GameAction) bool {
 		return false
 	}
 
-	// Determine move number by counting existing moves for this room
-	var moveCount int64
-	h.db.Model(&models.GameMove{}).Where("game_room_id = ?", room.ID).Count(&moveCount)
-
-	// Persist move
-	moveRecord := models.GameMove{
-		GameRoomID: room.ID,
-		UserID:     userID,
-		MoveData:   string(moveBytes),
-		MoveNumber: int(moveCount) + 1,
-	}
-	if err := h.db.Create(&moveRecord).Error; err != nil {
-		observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to persist move",
-			slog.Uint64("room_id", uint64(room.ID)),
-			slog.String("error", err.Error()),
-		)
-	}
-
 	if room.Type != models.Othello {
 		winnerSym, finished = room.CheckWin()
 	}
@@ -546,81 +546,8 @@ func (h *GameHub) handleMove(userID uint, action GameAction) bool {
 				winID = room.OpponentID
 			}
 			room.WinnerID = winID
-
-			// Award points if winner still exists (upsert to handle missing rows)
-			if winID != nil {
-				points := 10
-				switch room.Type {
-				case models.ConnectFour:
-					points = 15
-				case models.Othello:
-					points = 25
-				case models.Battleship:
-					points = 30
-				case models.Checkers:
-					points = 20
-				}
-				winStats := models.GameStats{UserID: *winID, GameType: room.Type, Wins: 1, TotalGames: 1, Points: points}
-				if err := h.db.Clauses(clause.OnConflict{
-					Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
-					DoUpdates: clause.Assignments(map[string]interface{}{
-						"points":      gorm.Expr("game_stats.points + ?", points),
-						"wins":        gorm.Expr("game_stats.wins + ?", 1),
-						"total_games": gorm.Expr("game_stats.total_games + ?", 1),
-					}),
-				}).Create(&winStats).Error; err != nil {
-					observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to award winner points",
-						slog.Uint64("winner_id", uint64(*winID)),
-						slog.String("error", err.Error()),
-					)
-				}
-			}
-
-			lossID := room.CreatorID
-			if winID == room.CreatorID && room.OpponentID != nil {
-				lossID = room.OpponentID
-			}
-
-			if lossID != nil {
-				lossStats := models.GameStats{UserID: *lossID, GameType: room.Type, Losses: 1, TotalGames: 1}
-				if err := h.db.Clauses(clause.OnConflict{
-					Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
-					DoUpdates: clause.Assignments(map[string]interface{}{
-						"losses":      gorm.Expr("game_stats.losses + ?", 1),
-						"total_games": gorm.Expr("game_stats.total_games + ?", 1),
-					}),
-				}).Create(&lossStats).Error; err != nil {
-					observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to update loser stats",
-						slog.Uint64("loser_id", uint64(*lossID)),
-						slog.String("error", err.Error()),
-					)
-				}
-			}
 		} else {
 			room.IsDraw = true
-			userIDs := make([]uint, 0, 2)
-			if room.CreatorID != nil {
-				userIDs = append(userIDs, *room.CreatorID)
-			}
-			if room.OpponentID != nil {
-				userIDs = append(userIDs, *room.OpponentID)
-			}
-
-			for _, uid := range userIDs {
-				drawStats := models.GameStats{UserID: uid, GameType: room.Type, Draws: 1, TotalGames: 1}
-				if err := h.db.Clauses(clause.OnConflict{
-					Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
-					DoUpdates: clause.Assignments(map[string]interface{}{
-						"draws":       gorm.Expr("game_stats.draws + ?", 1),
-						"total_games": gorm.Expr("game_stats.total_games + ?", 1),
-					}),
-				}).Create(&drawStats).Error; err != nil {
-					observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to update draw stats",
-						slog.Uint64("user_id", uint64(uid)),
-						slog.String("error", err.Error()),
-					)
-				}
-			}
 		}
 	} else if !skipDefaultTurnSwitch {
 		// Switch turn
@@ -638,11 +565,43 @@ func (h *GameHub) handleMove(userID uint, action GameAction) bool {
 		}
 	}
 
-	if err := h.db.Save(&room).Error; err != nil {
+	// The move was validated against the room as loaded above. If another
+	// move landed in the meantime the board it was applied to is stale, so
+	// nothing is persisted and the player has to retry against fresh state.
+	if err := models.SaveGameRoom(h.db, &room); err != nil {
+		if errors.Is(err, models.ErrGameRoomConflict) {
+			h.sendError(userID, action.RoomID, "Game state changed, please retry")
+			return false
+		}
 		observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to save room state",
 			slog.Uint64("room_id", uint64(room.ID)),
 			slog.String("error", err.Error()),
 		)
+		h.sendError(userID, action.RoomID, "Failed to save move")
+		return false
+	}
+
+	// Determine move number by counting existing moves for this room
+	var moveCount int64
+	h.db.Model(&models.GameMove{}).Where("game_room_id = ?", room.ID).Count(&moveCount)
+
+	// Persist move
+	moveRecord := models.GameMove{
+		GameRoomID: room.ID,
+		UserID:     userID,
+		MoveData:   string(moveBytes),
+		MoveNumber: int(moveCount) + 1,
+	}
+	if err := h.db.Create(&moveRecord).Error; err != nil {
+		observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to persist move",
+			slog.Uint64("room_id", uint64(room.ID)),
+			slog.String("error", err.Error()),
+		)
+	}
+	observability.GameMovesProcessed.WithLabelValues(string(room.Type)).Inc()
+
+	if room.Status == models.GameFinished {
+		h.recordGameResult(&room)
 	}
 
 	// Broadcast update
@@ -666,6 +625,98 @@ func (h *GameHub) handleMove(userID uint, action GameAction) bool {
 	return true
 }
 
+// recordGameResult updates both players' stats and ratings for a room that
+// has just finished.
+func (h *GameHub) recordGameResult(room *models.GameRoom) {
+	if !room.IsDraw {
+		winID := room.WinnerID
+		lossID := room.CreatorID
+		if winID != nil && room.CreatorID != nil && *winID == *room.CreatorID && room.OpponentID != nil {
+			lossID = room.OpponentID
+		}
+		winRating, lossRating := models.EloRatings(
+			h.gameRating(winID, room.Type), h.gameRating(lossID, room.Type), 1)
+
+		// Award points if winner still exists (upsert to handle missing rows)
+		if winID != nil {
+			points := 10
+			switch room.Type {
+			case models.ConnectFour:
+				points = 15
+			case models.Othello:
+				points = 25
+			case models.Battleship:
+				points = 30
+			case models.Checkers:
+				points = 20
+			}
+			winStats := models.GameStats{UserID: *winID, GameType: room.Type, Wins: 1, TotalGames: 1, Points: points, Rating: winRating}
+			if err := h.db.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"points":      gorm.Expr("game_stats.points + ?", points),
+					"wins":        gorm.Expr("game_stats.wins + ?", 1),
+					"total_games": gorm.Expr("game_stats.total_games + ?", 1),
+					"rating":      winRating,
+				}),
+			}).Create(&winStats).Error; err != nil {
+				observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to award winner points",
+					slog.Uint64("winner_id", uint64(*winID)),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+
+		if lossID != nil {
+			lossStats := models.GameStats{UserID: *lossID, GameType: room.Type, Losses: 1, TotalGames: 1, Rating: lossRating}
+			if err := h.db.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"losses":      gorm.Expr("game_stats.losses + ?", 1),
+					"total_games": gorm.Expr("game_stats.total_games + ?", 1),
+					"rating":      lossRating,
+				}),
+			}).Create(&lossStats).Error; err != nil {
+				observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to update loser stats",
+					slog.Uint64("loser_id", uint64(*lossID)),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+		return
+	}
+
+	creatorRating, opponentRating := models.EloRatings(
+		h.gameRating(room.CreatorID, room.Type), h.gameRating(room.OpponentID, room.Type), 0.5)
+	drawRatings := make(map[uint]int, 2)
+	userIDs := make([]uint, 0, 2)
+	if room.CreatorID != nil {
+		userIDs = append(userIDs, *room.CreatorID)
+		drawRatings[*room.CreatorID] = creatorRating
+	}
+	if room.OpponentID != nil {
+		userIDs = append(userIDs, *room.OpponentID)
+		drawRatings[*room.OpponentID] = opponentRating
+	}
+
+	for _, uid := range userIDs {
+		drawStats := models.GameStats{UserID: uid, GameType: room.Type, Draws: 1, TotalGames: 1, Rating: drawRatings[uid]}
+		if err := h.db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"draws":       gorm.Expr("game_stats.draws + ?", 1),
+				"total_games": gorm.Expr("game_stats.total_games + ?", 1),
+				"rating":      drawRatings[uid],
+			}),
+		}).Create(&drawStats).Error; err != nil {
+			observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to update draw stats",
+				slog.Uint64("user_id", uint64(uid)),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
 var othelloDirs = [8][2]int{
 	{-1, -1}, {-1, 0}, {-1, 1},
 	{0, -1}, {0, 1},
@@ -826,7 +877,11 @@ func (h *GameHub) handlePlaceShips(userID uint, action GameAction) bool {
 	}
 
 	room.SetState(state)
-	if err := h.db.Save(&room).Error; err != nil {
+	if err := models.SaveGameRoom(h.db, &room); err != nil {
+		if errors.Is(err, models.ErrGameRoomConflict) {
+			h.sendError(userID, action.RoomID, "Game state changed, please retry")
+			return false
+		}
 		observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to save battleship placement",
 			slog.Uint64("room_id", uint64(room.ID)),
 			slog.String("error", err.Error()),
@@ -994,35 +1049,46 @@ func (h *GameHub) StartWiring(ctx context.Context, n *Notifier) error {
 	})
 }
 
-// Shutdown gracefully closes all websocket connections
-func (h *GameHub) Shutdown(_ context.Context) error {
+// Shutdown drains all room connections: each client is sent a
+// server_draining frame and given until DrainTimeout (or ctx's deadline) to
+// flush before its socket is closed.
+func (h *GameHub) Shutdown(ctx context.Context) error {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	// Close all room connections
-	for roomID, users := range h.rooms {
-		for userID, client := range users {
-			shutdownMsg := GameAction{
-				Type:    "server_shutdown",
-				RoomID:  roomID,
-				Payload: map[string]string{"message": "Server is shutting down"},
-			}
-			if msgJSON, err := json.Marshal(shutdownMsg); err == nil {
-				client.TrySend(msgJSON)
-			}
-			if err := client.Conn.Close(); err != nil {
-				observability.GlobalLogger.ErrorContext(context.Background(), "game hub failed to close websocket",
-					slog.Uint64("room_id", uint64(roomID)),
-					slog.Uint64("user_id", uint64(userID)),
-					slog.String("error", err.Error()),
-				)
+	// A client can sit in several rooms; drain it once.
+	seen := make(map[*Client]struct{})
+	var clients []*Client
+	for _, users := range h.rooms {
+		for _, client := range users {
+			if _, ok := seen[client]; ok {
+				continue
 			}
+			seen[client] = struct{}{}
+			clients = append(clients, client)
 		}
 	}
+	h.mu.Unlock()
+
+	drainClients(ctx, h.Name(), clients)
 
 	// Clear all state
+	h.mu.Lock()
 	h.rooms = make(map[uint]map[uint]*Client)
 	h.userRooms = make(map[uint]map[uint]struct{})
+	h.mu.Unlock()
 
 	return nil
 }
+
+// gameRating returns the user's current rating for the game type, or the
+// starting rating when they have none yet.
+func (h *GameHub) gameRating(userID *uint, gameType models.GameType) int {
+	if userID == nil {
+		return models.DefaultGameRating
+	}
+	var stats models.GameStats
+	err := h.db.Select("rating").Where("user_id = ? AND game_type = ?", *userID, gameType).Take(&stats).Error
+	if err != nil || stats.Rating == 0 {
+		return models.DefaultGameRating
+	}
+	return stats.Rating
+}
@@ -177,3 +177,91 @@ func TestGameHubHandleMove_ConnectFourFinishesAndAwardsWinner(t *testing.T) {
 	require.Equal(t, 1, creatorStats.TotalGames)
 	require.Equal(t, 15, creatorStats.Points)
 }
+
+func TestGameHubHandleMove_ConnectFourUpsetWinSwingsRatingMore(t *testing.T) {
+	// winRatingGain plays out a creator win from the given starting ratings
+	// and returns how many rating points the creator gained.
+	winRatingGain := func(creatorRating, opponentRating int) int {
+		t.Helper()
+		db := setupGameSQLiteDB(t)
+		hub := NewGameHub(db, nil)
+		creator, opponent := createGameUsers(t, db)
+		require.NoError(t, db.Create(&models.GameStats{UserID: creator.ID, GameType: models.ConnectFour, Rating: creatorRating}).Error)
+		require.NoError(t, db.Create(&models.GameStats{UserID: opponent.ID, GameType: models.ConnectFour, Rating: opponentRating}).Error)
+
+		var board [6][7]string
+		board[5][0], board[5][1], board[5][2] = "X", "X", "X"
+		board[5][4], board[5][5] = "O", "O"
+		room := createConnectFourRoom(t, db, creator.ID, opponent.ID, board)
+		creatorClient, _ := registerRoomClients(t, hub, room.ID, creator.ID, opponent.ID)
+
+		hub.handleMove(creator.ID, GameAction{Type: "make_move", RoomID: room.ID, Payload: map[string]int{"column": 3}})
+		require.Equal(t, "game_state", mustReadGameAction(t, creatorClient).Type)
+
+		var creatorStats, opponentStats models.GameStats
+		require.NoError(t, db.Where("user_id = ? AND game_type = ?", creator.ID, models.ConnectFour).First(&creatorStats).Error)
+		require.NoError(t, db.Where("user_id = ? AND game_type = ?", opponent.ID, models.ConnectFour).First(&opponentStats).Error)
+		require.Equal(t, 1, creatorStats.Wins)
+		require.Less(t, opponentStats.Rating, opponentRating)
+		return creatorStats.Rating - creatorRating
+	}
+
+	upset := winRatingGain(1000, 1400)
+	expected := winRatingGain(1400, 1000)
+	require.Greater(t, expected, 0)
+	require.Greater(t, upset, expected)
+}
+
+func TestGameHubHandleMove_ConcurrentMovesOnlyOneApplies(t *testing.T) {
+	db := setupGameSQLiteDB(t)
+	hub := NewGameHub(db, nil)
+	creator, opponent := createGameUsers(t, db)
+
+	var board [6][7]string
+	room := createConnectFourRoom(t, db, creator.ID, opponent.ID, board)
+	creatorClient, opponentClient := registerRoomClients(t, hub, room.ID, creator.ID, opponent.ID)
+
+	// Land a second move (say, from another tab) right after the first one
+	// has loaded the room, so the first is computed from a stale board.
+	interleaved := false
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:interleave_move", func(tx *gorm.DB) {
+		if interleaved || tx.Statement.Table != "game_rooms" {
+			return
+		}
+		interleaved = true
+		require.True(t, hub.handleMove(creator.ID, GameAction{
+			Type:    "make_move",
+			RoomID:  room.ID,
+			Payload: map[string]int{"column": 3},
+		}))
+	}))
+
+	mutated := hub.handleMove(creator.ID, GameAction{
+		Type:    "make_move",
+		RoomID:  room.ID,
+		Payload: map[string]int{"column": 0},
+	})
+	require.False(t, mutated)
+
+	require.Equal(t, "game_state", mustReadGameAction(t, opponentClient).Type)
+	expectNoMessage(t, opponentClient)
+
+	require.Equal(t, "game_state", mustReadGameAction(t, creatorClient).Type)
+	errAction := mustReadGameAction(t, creatorClient)
+	require.Equal(t, "error", errAction.Type)
+	var errPayload wireErrorPayload
+	require.NoError(t, json.Unmarshal(errAction.Payload, &errPayload))
+	require.Contains(t, errPayload.Message, "retry")
+
+	var updated models.GameRoom
+	require.NoError(t, db.First(&updated, room.ID).Error)
+	require.Equal(t, int64(1), updated.Version)
+	require.Equal(t, opponent.ID, updated.NextTurnID)
+	stored := updated.GetConnectFourState()
+	require.Equal(t, "X", stored[5][3])
+	require.Empty(t, stored[5][0])
+
+	var moves int64
+	require.NoError(t, db.Model(&models.GameMove{}).Where("game_room_id = ?", room.ID).Count(&moves).Error)
+	require.Equal(t, int64(1), moves)
+}
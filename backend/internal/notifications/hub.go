@@ -30,9 +30,34 @@ type Hub struct {
 	presence   *ConnectionManager
 }
 
+// HubStats is a point-in-time snapshot of a hub's load.
+type HubStats struct {
+	// Connections is the number of live websocket clients on this instance.
+	Connections int `json:"connections"`
+	// Users is the number of distinct users with a live client on this instance.
+	Users int `json:"users"`
+	// Rooms is the number of conversations or game rooms with members.
+	Rooms int `json:"rooms"`
+	// OnlineUsers is the presence total across instances when Redis is shared.
+	OnlineUsers int `json:"online_users"`
+}
+
 // Name returns a human-readable identifier for this hub.
 func (h *Hub) Name() string { return "notification hub" }
 
+// Stats returns the hub's current connection counts.
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	stats := HubStats{Connections: h.totalConns, Users: len(h.conns)}
+	presence := h.presence
+	h.mu.RUnlock()
+	stats.OnlineUsers = stats.Users
+	if presence != nil {
+		stats.OnlineUsers = presence.OnlineUserCount(context.Background())
+	}
+	return stats
+}
+
 // UnregisterClient removes the client from the hub and updates presence.
 func (h *Hub) UnregisterClient(client *Client) {
 	h.mu.Lock()
@@ -56,6 +81,12 @@ func (h *Hub) UnregisterClient(client *Client) {
 
 // Register a connection for a given userID. Returns the Client or error if limits exceeded.
 func (h *Hub) Register(userID uint, conn *websocket.Conn) (*Client, error) {
+	select {
+	case <-h.shutdown:
+		return nil, errors.New("server is shutting down")
+	default:
+	}
+
 	h.mu.Lock()
 
 	if h.totalConns >= maxTotalConns {
@@ -193,34 +224,30 @@ func (h *Hub) StartWiring(ctx context.Context, n *Notifier) error {
 	})
 }
 
-// Shutdown gracefully closes all websocket connections
-func (h *Hub) Shutdown(_ context.Context) error {
+// Shutdown drains all websocket connections: each client is sent a
+// server_draining frame and given until DrainTimeout (or ctx's deadline) to
+// flush before its socket is closed.
+func (h *Hub) Shutdown(ctx context.Context) error {
 	close(h.shutdown)
 
 	if h.presence != nil {
 		h.presence.Stop()
 	}
 
-	// Close all connections gracefully
 	h.mu.Lock()
-	for userID, userConns := range h.conns {
+	var clients []*Client
+	for _, userConns := range h.conns {
 		for client := range userConns {
-			if client.Conn == nil {
-				continue
-			}
-			// Send close message to client
-			if err := client.Conn.WriteMessage(websocket.CloseMessage,
-				websocket.FormatCloseMessage(websocket.CloseGoingAway, "Server shutting down")); err != nil {
-				log.Printf("failed to write close message for user %d: %v", userID, err)
-			}
-			// Close the connection
-			if err := client.Conn.Close(); err != nil {
-				log.Printf("failed to close websocket for user %d: %v", userID, err)
-			}
+			clients = append(clients, client)
 		}
 	}
-	// Clear all connections
+	h.mu.Unlock()
+
+	drainClients(ctx, h.Name(), clients)
+
+	h.mu.Lock()
 	h.conns = make(map[uint]map[*Client]struct{})
+	h.totalConns = 0
 	h.mu.Unlock()
 
 	// Signal completion
@@ -0,0 +1,74 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// ImageStatusEvent describes a processing status transition for an image.
+type ImageStatusEvent struct {
+	Hash   string `json:"hash"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImageStatusChannel derives the Redis channel name for an image's status updates.
+func ImageStatusChannel(hash string) string {
+	return "images:status:" + hash
+}
+
+// PublishImageStatus publishes a status transition for the image with the given hash.
+func (n *Notifier) PublishImageStatus(ctx context.Context, hash, status, errMsg string) error {
+	if n.rdb == nil {
+		return nil
+	}
+	payload, err := json.Marshal(ImageStatusEvent{Hash: hash, Status: status, Error: errMsg})
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	return n.rdb.Publish(ctx, ImageStatusChannel(hash), string(payload)).Err()
+}
+
+// SubscribeImageStatus delivers status transitions for a single image until
+// ctx is cancelled. The subscription is confirmed before it returns, so no
+// transition published afterwards is missed.
+func (n *Notifier) SubscribeImageStatus(ctx context.Context, hash string) (<-chan ImageStatusEvent, error) {
+	if n.rdb == nil {
+		return nil, fmt.Errorf("image status subscription requires redis")
+	}
+	sub := n.rdb.Subscribe(ctx, ImageStatusChannel(hash))
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, fmt.Errorf("subscribe image status: %w", err)
+	}
+
+	out := make(chan ImageStatusEvent, 4)
+	go func() {
+		defer close(out)
+		defer func() { _ = sub.Close() }()
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var event ImageStatusEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					log.Printf("invalid image status payload on %s: %v", msg.Channel, err)
+					continue
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
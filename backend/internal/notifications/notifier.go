@@ -92,23 +92,55 @@ func (n *Notifier) PublishChatMessage(
 func (n *Notifier) PublishTypingIndicator(
 	ctx context.Context, conversationID, userID uint, username string, isTyping bool,
 ) error {
+	return n.PublishTypingEvent(ctx, TypingEvent(conversationID, userID, username, isTyping))
+}
+
+// PublishTypingEvent publishes a prebuilt typing or typing_stopped frame to
+// the event's conversation.
+func (n *Notifier) PublishTypingEvent(ctx context.Context, event ChatMessage) error {
 	if n.rdb == nil {
 		return nil
 	}
-	channel := fmt.Sprintf("typing:conv:%d", conversationID)
-	payload := map[string]interface{}{
-		"user_id":       userID,
-		"username":      username,
-		"is_typing":     isTyping,
-		"expires_in_ms": 5000,
-	}
-	payloadJSON, err := json.Marshal(payload)
+	channel := fmt.Sprintf("typing:conv:%d", event.ConversationID)
+	payloadJSON, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
 	return n.rdb.Publish(ctx, channel, string(payloadJSON)).Err()
 }
 
+// TypingEvent builds the "typing" frame broadcast to a conversation.
+func TypingEvent(conversationID, userID uint, username string, isTyping bool) ChatMessage {
+	return ChatMessage{
+		Type:           "typing",
+		ConversationID: conversationID,
+		UserID:         userID,
+		Username:       username,
+		Payload: map[string]interface{}{
+			"user_id":       userID,
+			"username":      username,
+			"is_typing":     isTyping,
+			"expires_in_ms": TypingIndicatorTTL.Milliseconds(),
+		},
+	}
+}
+
+// TypingStoppedEvent builds the "typing_stopped" frame broadcast to a conversation.
+func TypingStoppedEvent(conversationID, userID uint, username, reason string) ChatMessage {
+	return ChatMessage{
+		Type:           "typing_stopped",
+		ConversationID: conversationID,
+		UserID:         userID,
+		Username:       username,
+		Payload: map[string]interface{}{
+			"user_id":   userID,
+			"username":  username,
+			"is_typing": false,
+			"reason":    reason,
+		},
+	}
+}
+
 // PublishPresence publishes a user's presence status to a conversation
 func (n *Notifier) PublishPresence(
 	ctx context.Context, conversationID, userID uint, username, status string,
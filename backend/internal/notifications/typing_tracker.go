@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"sync"
+	"time"
+)
+
+// TypingIndicatorTTL is how long a typing indicator stays up without a refresh.
+const TypingIndicatorTTL = 5 * time.Second
+
+// Reasons reported in typing_stopped events.
+const (
+	TypingStoppedExplicit = "stopped"
+	TypingStoppedExpired  = "expired"
+)
+
+type typingKey struct {
+	conversationID uint
+	userID         uint
+}
+
+// TypingTracker keeps per-conversation typing state and clears it when a
+// user stops refreshing their indicator, so a client that goes quiet without
+// sending never leaves a stale "is typing" behind.
+type TypingTracker struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	timers   map[typingKey]*time.Timer
+	onExpire func(conversationID, userID uint, username string)
+}
+
+// NewTypingTracker creates a tracker that calls onExpire when an indicator
+// has not been refreshed within ttl. A non-positive ttl uses TypingIndicatorTTL.
+func NewTypingTracker(ttl time.Duration, onExpire func(conversationID, userID uint, username string)) *TypingTracker {
+	if ttl <= 0 {
+		ttl = TypingIndicatorTTL
+	}
+	return &TypingTracker{
+		ttl:      ttl,
+		timers:   make(map[typingKey]*time.Timer),
+		onExpire: onExpire,
+	}
+}
+
+// TTL returns the expiry window for a typing indicator.
+func (t *TypingTracker) TTL() time.Duration {
+	return t.ttl
+}
+
+// Touch marks the user as typing in the conversation and restarts the expiry timer.
+func (t *TypingTracker) Touch(conversationID, userID uint, username string) {
+	key := typingKey{conversationID: conversationID, userID: userID}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if timer, ok := t.timers[key]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(t.ttl, func() {
+		t.mu.Lock()
+		// A newer Touch or a Stop may have replaced this timer already.
+		if t.timers[key] != timer {
+			t.mu.Unlock()
+			return
+		}
+		delete(t.timers, key)
+		t.mu.Unlock()
+
+		if t.onExpire != nil {
+			t.onExpire(conversationID, userID, username)
+		}
+	})
+	t.timers[key] = timer
+}
+
+// Stop clears the user's typing state and reports whether it was set.
+func (t *TypingTracker) Stop(conversationID, userID uint) bool {
+	key := typingKey{conversationID: conversationID, userID: userID}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timer, ok := t.timers[key]
+	if !ok {
+		return false
+	}
+	timer.Stop()
+	delete(t.timers, key)
+	return true
+}
+
+// IsTyping reports whether the user currently has a live typing indicator.
+func (t *TypingTracker) IsTyping(conversationID, userID uint) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.timers[typingKey{conversationID: conversationID, userID: userID}]
+	return ok
+}
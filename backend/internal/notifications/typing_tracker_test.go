@@ -0,0 +1,50 @@
+package notifications
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypingTracker_AutoExpiresWithoutStop(t *testing.T) {
+	expired := make(chan uint, 1)
+	tracker := NewTypingTracker(30*time.Millisecond, func(conversationID, userID uint, username string) {
+		assert.Equal(t, uint(10), conversationID)
+		assert.Equal(t, "alice", username)
+		expired <- userID
+	})
+
+	tracker.Touch(10, 1, "alice")
+	assert.True(t, tracker.IsTyping(10, 1))
+
+	select {
+	case userID := <-expired:
+		assert.Equal(t, uint(1), userID)
+	case <-time.After(time.Second):
+		t.Fatal("typing indicator did not expire")
+	}
+	assert.False(t, tracker.IsTyping(10, 1))
+}
+
+func TestTypingTracker_RefreshAndStop(t *testing.T) {
+	var fired atomic.Int32
+	tracker := NewTypingTracker(60*time.Millisecond, func(uint, uint, string) {
+		fired.Add(1)
+	})
+
+	// Refreshing inside the window keeps the indicator alive.
+	tracker.Touch(10, 1, "alice")
+	time.Sleep(40 * time.Millisecond)
+	tracker.Touch(10, 1, "alice")
+	time.Sleep(40 * time.Millisecond)
+	assert.True(t, tracker.IsTyping(10, 1))
+	assert.Zero(t, fired.Load())
+
+	// An explicit stop cancels the pending expiry.
+	assert.True(t, tracker.Stop(10, 1))
+	assert.False(t, tracker.Stop(10, 1))
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, fired.Load())
+}
@@ -2,6 +2,7 @@ package observability
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"time"
 
@@ -53,8 +54,120 @@ var (
 		Name: "sanctum_websocket_backpressure_drops_total",
 		Help: "Total number of WebSocket messages dropped due to backpressure",
 	}, []string{"hub", "reason"})
+
+	// WebSocketSlowClientDisconnects counts clients disconnected for falling
+	// too far behind on their send buffer.
+	WebSocketSlowClientDisconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sanctum_websocket_slow_client_disconnects_total",
+		Help: "Total number of WebSocket clients disconnected for not keeping up with their send buffer",
+	}, []string{"hub"})
+
+	// WebSocketHeartbeatTimeouts counts clients disconnected for not answering
+	// heartbeat pings in time.
+	WebSocketHeartbeatTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sanctum_websocket_heartbeat_timeouts_total",
+		Help: "Total number of WebSocket clients disconnected for missing heartbeat pongs",
+	}, []string{"hub"})
+
+	// ImageProcessingQueueDepth is the number of uploads waiting for variant generation.
+	ImageProcessingQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "sanctum_image_processing_queue_depth",
+		Help: "Number of uploaded images queued for variant processing",
+	})
+
+	// ChatMessagesSent counts chat messages sent by conversation type (direct or group).
+	ChatMessagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sanctum_chat_messages_sent_total",
+		Help: "Total number of chat messages sent by conversation type",
+	}, []string{"conversation_type"})
+
+	// GameMovesProcessed counts accepted game moves by game type.
+	GameMovesProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sanctum_game_moves_processed_total",
+		Help: "Total number of game moves processed by game type",
+	}, []string{"game_type"})
+
+	// WebSocketConnectionEvents counts WebSocket connects and disconnects by hub.
+	WebSocketConnectionEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sanctum_websocket_connection_events_total",
+		Help: "Total WebSocket connect and disconnect events by hub",
+	}, []string{"hub", "event"})
+)
+
+// ConversationType returns the conversation_type label for ChatMessagesSent.
+func ConversationType(isGroup bool) string {
+	if isGroup {
+		return "group"
+	}
+	return "direct"
+}
+
+// TrackWebSocketConnection records a connect event for hub and returns a
+// function that records the matching disconnect (e.g. defer).
+func TrackWebSocketConnection(hub string) func() {
+	WebSocketConnectionEvents.WithLabelValues(hub, "connect").Inc()
+	return func() {
+		WebSocketConnectionEvents.WithLabelValues(hub, "disconnect").Inc()
+	}
+}
+
+// activeGamesDesc describes the sanctum_active_games gauge.
+var activeGamesDesc = prometheus.NewDesc(
+	"sanctum_active_games",
+	"Number of games currently in progress by game type",
+	[]string{"game_type"}, nil,
 )
 
+// ActiveGamesCollector reports in-progress games per type, read from the
+// database on every scrape so the value is correct across instances.
+type ActiveGamesCollector struct {
+	db *gorm.DB
+}
+
+// NewActiveGamesCollector returns a collector backed by db.
+func NewActiveGamesCollector(db *gorm.DB) *ActiveGamesCollector {
+	return &ActiveGamesCollector{db: db}
+}
+
+// Describe implements prometheus.Collector.
+func (c *ActiveGamesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeGamesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *ActiveGamesCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	if err := c.db.WithContext(ctx).
+		Table("game_rooms").
+		Select("type, COUNT(*) AS count").
+		Where("status = ? AND deleted_at IS NULL", "active").
+		Group("type").
+		Scan(&rows).Error; err != nil {
+		ch <- prometheus.NewInvalidMetric(activeGamesDesc, err)
+		return
+	}
+	for _, row := range rows {
+		ch <- prometheus.MustNewConstMetric(activeGamesDesc, prometheus.GaugeValue, float64(row.Count), row.Type)
+	}
+}
+
+// RegisterActiveGamesCollector registers an ActiveGamesCollector on the
+// default registry. Registering again (e.g. a second server in tests) is a no-op.
+func RegisterActiveGamesCollector(db *gorm.DB) error {
+	err := prometheus.Register(NewActiveGamesCollector(db))
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
+
 // DatabaseMetrics wraps DB access for recording query latency.
 type DatabaseMetrics struct {
 	db *gorm.DB
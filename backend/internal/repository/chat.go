@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"sanctum/internal/cache"
@@ -20,10 +21,13 @@ type ChatRepository interface {
 	AddParticipant(ctx context.Context, convID, userID uint) error
 	RemoveParticipant(ctx context.Context, convID, userID uint) error
 	CreateMessage(ctx context.Context, msg *models.Message) error
-	GetMessages(ctx context.Context, convID uint, limit, offset int) ([]*models.Message, error)
+	GetMessages(ctx context.Context, convID, viewerID uint, limit, offset int) ([]*models.Message, error)
+	GetMessagesBefore(ctx context.Context, convID, viewerID, beforeID uint, limit int) ([]*models.Message, error)
+	GetMessage(ctx context.Context, convID, viewerID, msgID uint) (*models.Message, error)
 	MarkMessageRead(ctx context.Context, msgID uint) error
 	UpdateLastRead(ctx context.Context, convID, userID uint) error
 	IsUserParticipant(ctx context.Context, conversationID, userID uint) (bool, error)
+	GetUnreadCounts(ctx context.Context, userID uint) ([]models.ConversationUnread, error)
 }
 
 // chatRepository implements ChatRepository
@@ -40,6 +44,21 @@ func NewChatRepository(db *gorm.DB) ChatRepository {
 	}
 }
 
+// shadowBannedInRoomSQL is true for a message whose sender is shadow banned
+// and whose conversation is a group chatroom. Direct messages are unaffected.
+const shadowBannedInRoomSQL = `EXISTS (SELECT 1 FROM users JOIN conversations ON conversations.id = messages.conversation_id
+	WHERE users.id = messages.sender_id AND users.shadow_banned = ? AND conversations.is_group = ?)`
+
+// VisibleMessagesFor hides chatroom messages from shadow-banned senders from
+// everyone but the sender and admins. A viewerID of 0 hides them from
+// everyone, which is what shared caches hold.
+func VisibleMessagesFor(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(messages.sender_id = ? OR NOT "+shadowBannedInRoomSQL+" OR "+viewerIsAdminSQL+")",
+			viewerID, true, true, viewerID, true)
+	}
+}
+
 func (r *chatRepository) CreateConversation(ctx context.Context, conv *models.Conversation) error {
 	start := time.Now()
 	defer func() {
@@ -64,7 +83,7 @@ func (r *chatRepository) GetConversation(ctx context.Context, id uint) (*models.
 		err := readDB(r.db).WithContext(ctx).
 			Preload("Participants").
 			Preload("Messages", func(db *gorm.DB) *gorm.DB {
-				return db.Order("created_at ASC").Limit(50)
+				return db.Scopes(VisibleMessagesFor(0)).Order("created_at ASC").Limit(50)
 			}).
 			Preload("Messages.Sender").
 			First(&conv, id).Error
@@ -93,7 +112,7 @@ func (r *chatRepository) GetUserConversations(ctx context.Context, userID uint)
 			Select("conversations.*, COALESCE(cp.unread_count, 0) as unread_count").
 			Preload("Participants").
 			Preload("Messages", func(db *gorm.DB) *gorm.DB {
-				return db.Order("created_at DESC").Limit(1)
+				return db.Scopes(VisibleMessagesFor(userID)).Order("created_at DESC").Limit(1)
 			}).
 			Preload("Messages.Sender").
 			Order("conversations.updated_at DESC").
@@ -158,6 +177,19 @@ func (r *chatRepository) CreateMessage(ctx context.Context, msg *models.Message)
 	}
 	cache.InvalidateRoom(ctx, msg.ConversationID)
 
+	// Everyone but the sender now has one more unread message, unless nobody
+	// else can see it because the sender is shadow banned in a chatroom. The
+	// message is already stored, so a failed counter bump is logged rather
+	// than returned.
+	if err := r.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id <> ?", msg.ConversationID, msg.SenderID).
+		Where(`NOT EXISTS (SELECT 1 FROM users JOIN conversations ON conversations.id = ?
+			WHERE users.id = ? AND users.shadow_banned = ? AND conversations.is_group = ?)`,
+			msg.ConversationID, msg.SenderID, true, true).
+		UpdateColumn("unread_count", gorm.Expr("unread_count + 1")).Error; err != nil {
+		r.logger.LogError(ctx, err, "increment_unread_count")
+	}
+
 	// Invalidate conversation list for all participants
 	var userIDs []uint
 	if err := r.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
@@ -172,7 +204,11 @@ func (r *chatRepository) CreateMessage(ctx context.Context, msg *models.Message)
 	return nil
 }
 
-func (r *chatRepository) GetMessages(ctx context.Context, convID uint, limit, offset int) ([]*models.Message, error) {
+// GetMessages returns a page of messages as seen by viewerID, oldest first.
+// The shared history cache holds what everyone sees; viewers who would see
+// more than that (their own hidden messages, or all of them for admins) read
+// from the database.
+func (r *chatRepository) GetMessages(ctx context.Context, convID, viewerID uint, limit, offset int) ([]*models.Message, error) {
 	start := time.Now()
 	var messages []*models.Message
 	histKey := cache.MessageHistoryKey(convID)
@@ -181,25 +217,38 @@ func (r *chatRepository) GetMessages(ctx context.Context, convID uint, limit, of
 		Messages []*models.Message `json:"messages"`
 	}
 
-	result := &messagesResult{}
-	err := cache.Aside(ctx, histKey, result, cache.MessageHistoryTTL, func() error {
-		rdb := readDB(r.db)
-		query := rdb.WithContext(ctx).
+	// Reactions are summarized per viewer by the service, so they are
+	// neither preloaded here nor kept in the shared history cache.
+	load := func(viewerID uint) error {
+		return readDB(r.db).WithContext(ctx).
 			Where("conversation_id = ?", convID).
-			Preload("Sender")
-		if rdb.Migrator().HasTable(&models.MessageReaction{}) {
-			query = query.Preload("Reactions")
-		}
-		err := query.
+			Scopes(VisibleMessagesFor(viewerID)).
+			Preload("Sender").
 			Order("created_at DESC").
 			Limit(limit).
 			Offset(offset).
 			Find(&messages).Error
-		if err == nil {
-			result.Messages = messages
-		}
-		return err
-	})
+	}
+
+	result := &messagesResult{}
+	var err error
+	privileged, privErr := r.seesHiddenMessages(ctx, viewerID)
+	if privErr != nil {
+		r.logger.LogError(ctx, privErr, "get_messages")
+		return nil, privErr
+	}
+	if privileged {
+		err = load(viewerID)
+		result.Messages = messages
+	} else {
+		err = cache.Aside(ctx, histKey, result, cache.MessageHistoryTTL, func() error {
+			err := load(0)
+			if err == nil {
+				result.Messages = messages
+			}
+			return err
+		})
+	}
 	defer func() {
 		observability.DatabaseQueryLatency.WithLabelValues("read", "messages").Observe(time.Since(start).Seconds())
 	}()
@@ -217,6 +266,81 @@ func (r *chatRepository) GetMessages(ctx context.Context, convID uint, limit, of
 	return messages, nil
 }
 
+// GetMessagesBefore returns up to limit messages visible to viewerID with an
+// id lower than beforeID (or the newest messages when beforeID is 0), oldest
+// first. Cursor pages are
+// keyed on message id, so they stay stable while new messages arrive and are
+// not served from the shared history cache.
+func (r *chatRepository) GetMessagesBefore(ctx context.Context, convID, viewerID, beforeID uint, limit int) ([]*models.Message, error) {
+	start := time.Now()
+	defer func() {
+		observability.DatabaseQueryLatency.WithLabelValues("read", "messages").Observe(time.Since(start).Seconds())
+	}()
+
+	query := readDB(r.db).WithContext(ctx).
+		Where("conversation_id = ?", convID).
+		Scopes(VisibleMessagesFor(viewerID)).
+		Preload("Sender")
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	var messages []*models.Message
+	if err := query.Order("id DESC").Limit(limit).Find(&messages).Error; err != nil {
+		r.logger.LogError(ctx, err, "get_messages_before")
+		return nil, err
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	r.logger.LogRead(ctx, map[string]interface{}{"conversation_id": convID, "before_id": beforeID, "count": len(messages)})
+	return messages, nil
+}
+
+// seesHiddenMessages reports whether viewerID sees messages the shared
+// history cache leaves out: shadow-banned users see their own, admins see
+// all of them.
+func (r *chatRepository) seesHiddenMessages(ctx context.Context, viewerID uint) (bool, error) {
+	if viewerID == 0 {
+		return false, nil
+	}
+	var count int64
+	err := readDB(r.db).WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (shadow_banned = ? OR is_admin = ?)", viewerID, true, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetMessage returns a single message scoped to its conversation with sender
+// and reactions loaded, as visible to viewerID.
+func (r *chatRepository) GetMessage(ctx context.Context, convID, viewerID, msgID uint) (*models.Message, error) {
+	start := time.Now()
+	defer func() {
+		observability.DatabaseQueryLatency.WithLabelValues("read", "messages").Observe(time.Since(start).Seconds())
+	}()
+
+	rdb := readDB(r.db)
+	query := rdb.WithContext(ctx).
+		Where("messages.id = ? AND messages.conversation_id = ?", msgID, convID).
+		Scopes(VisibleMessagesFor(viewerID)).
+		Preload("Sender")
+	if rdb.Migrator().HasTable(&models.MessageReaction{}) {
+		query = query.Preload("Reactions")
+	}
+
+	var message models.Message
+	if err := query.First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Message", msgID)
+		}
+		r.logger.LogError(ctx, err, "get_message")
+		return nil, err
+	}
+	return &message, nil
+}
+
 func (r *chatRepository) MarkMessageRead(ctx context.Context, msgID uint) error {
 	start := time.Now()
 	err := r.db.WithContext(ctx).Model(&models.Message{}).Where("id = ?", msgID).Update("is_read", true).Error
@@ -235,7 +359,7 @@ func (r *chatRepository) UpdateLastRead(ctx context.Context, convID, userID uint
 	start := time.Now()
 	err := r.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
 		Where("conversation_id = ? AND user_id = ?", convID, userID).
-		Update("last_read_at", "NOW()").Error
+		Updates(map[string]interface{}{"last_read_at": time.Now().UTC(), "unread_count": 0}).Error
 	defer func() {
 		observability.DatabaseQueryLatency.WithLabelValues("update", "conversation_participants").Observe(time.Since(start).Seconds())
 	}()
@@ -267,3 +391,25 @@ func (r *chatRepository) IsUserParticipant(ctx context.Context, conversationID,
 	}
 	return count > 0, nil
 }
+
+// GetUnreadCounts lists the user's conversations that have unread messages,
+// read straight from the participant rows.
+func (r *chatRepository) GetUnreadCounts(ctx context.Context, userID uint) ([]models.ConversationUnread, error) {
+	start := time.Now()
+	defer func() {
+		observability.DatabaseQueryLatency.WithLabelValues("select", "conversation_participants").Observe(time.Since(start).Seconds())
+	}()
+	counts := []models.ConversationUnread{}
+	err := r.db.WithContext(ctx).
+		Table("conversation_participants AS cp").
+		Select("cp.conversation_id, cp.unread_count").
+		Joins("JOIN conversations c ON c.id = cp.conversation_id AND c.deleted_at IS NULL").
+		Where("cp.user_id = ? AND cp.unread_count > 0", userID).
+		Order("cp.conversation_id ASC").
+		Scan(&counts).Error
+	if err != nil {
+		r.logger.LogError(ctx, err, "get_unread_counts")
+		return nil, err
+	}
+	return counts, nil
+}
@@ -74,7 +74,7 @@ func TestChatRepository_Integration(t *testing.T) {
 		}
 		testDB.Create(msg)
 
-		msgs, err := repo.GetMessages(ctx, conv.ID, 10, 0)
+		msgs, err := repo.GetMessages(ctx, conv.ID, 0, 10, 0)
 		assert.NoError(t, err)
 		assert.Equal(t, 1, len(msgs))
 		assert.Equal(t, "Msg 1", msgs[0].Content)
@@ -7,15 +7,18 @@ import (
 	"sanctum/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CommentRepository defines interface for comment operations
 type CommentRepository interface {
 	Create(ctx context.Context, comment *models.Comment) error
 	GetByID(ctx context.Context, id uint) (*models.Comment, error)
-	ListByPost(ctx context.Context, postID uint) ([]*models.Comment, error)
+	ListByPost(ctx context.Context, postID, viewerID uint, sort string) ([]*models.Comment, error)
 	Update(ctx context.Context, comment *models.Comment) error
 	Delete(ctx context.Context, id uint) error
+	Like(ctx context.Context, userID, commentID uint) error
+	Unlike(ctx context.Context, userID, commentID uint) error
 }
 
 type commentRepository struct {
@@ -29,29 +32,62 @@ func NewCommentRepository(db *gorm.DB) CommentRepository {
 
 const (
 	maxCommentLimit = 1000
+
+	commentLikesCountSQL = "(SELECT COUNT(*) FROM comment_likes WHERE comment_likes.comment_id = comments.id)"
 )
 
+// withLikesCount selects comment columns plus the computed like count.
+func withLikesCount(db *gorm.DB) *gorm.DB {
+	return db.Select("comments.*, " + commentLikesCountSQL + " as likes_count")
+}
+
+// applyCommentSort appends the ORDER BY clause for the requested sort.
+// "top" orders by like count, "old" oldest first; anything else keeps the
+// default newest-first order. Ties always break on id.
+func applyCommentSort(db *gorm.DB, sort string) *gorm.DB {
+	switch sort {
+	case "top":
+		return db.Order(commentLikesCountSQL + " DESC").Order("comments.id ASC")
+	case "old":
+		return db.Order("comments.created_at ASC").Order("comments.id ASC")
+	default:
+		return db.Order("comments.created_at DESC").Order("comments.id DESC")
+	}
+}
+
+// commentsNotShadowBanned is notShadowBanned for comments.
+func commentsNotShadowBanned(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(comments.user_id = ? OR comments.user_id NOT IN (SELECT id FROM users WHERE shadow_banned = ?) OR "+
+			viewerIsAdminSQL+")", viewerID, true, viewerID, true)
+	}
+}
+
 func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
 	return r.db.WithContext(ctx).Create(comment).Error
 }
 
 func (r *commentRepository) GetByID(ctx context.Context, id uint) (*models.Comment, error) {
 	var comment models.Comment
-	if err := r.db.WithContext(ctx).Preload("User").First(&comment, id).Error; err != nil {
+	if err := withLikesCount(r.db.WithContext(ctx)).Preload("User").First(&comment, id).Error; err != nil {
 		return nil, err
 	}
 	return &comment, nil
 }
 
+// ListByPost returns a post's comments as seen by viewerID, which may be 0
+// for anonymous readers.
 func (r *commentRepository) ListByPost(
 	ctx context.Context,
 	postID uint,
+	viewerID uint,
+	sort string,
 ) ([]*models.Comment, error) {
 	var comments []*models.Comment
-	err := r.db.WithContext(ctx).
+	err := applyCommentSort(withLikesCount(r.db.WithContext(ctx)), sort).
 		Preload("User").
-		Where("post_id = ?", postID).
-		Order("created_at desc").
+		Where("comments.post_id = ?", postID).
+		Scopes(commentsNotShadowBanned(viewerID)).
 		Limit(maxCommentLimit).
 		Find(&comments).Error
 	return comments, err
@@ -64,3 +100,16 @@ func (r *commentRepository) Update(ctx context.Context, comment *models.Comment)
 func (r *commentRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&models.Comment{}, id).Error
 }
+
+func (r *commentRepository) Like(ctx context.Context, userID, commentID uint) error {
+	like := models.CommentLike{UserID: userID, CommentID: commentID}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&like).Error
+}
+
+func (r *commentRepository) Unlike(ctx context.Context, userID, commentID uint) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND comment_id = ?", userID, commentID).
+		Delete(&models.CommentLike{}).Error
+}
@@ -34,7 +34,7 @@ func TestCommentRepository_Integration(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotZero(t, comment.ID)
 
-		comments, err := repo.ListByPost(ctx, post.ID)
+		comments, err := repo.ListByPost(ctx, post.ID, 0, "")
 		assert.NoError(t, err)
 		assert.Len(t, comments, 1)
 		assert.Equal(t, "Nice post!", comments[0].Content)
@@ -60,6 +60,62 @@ func TestCommentRepository_Integration(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("ListByPost Sorts", func(t *testing.T) {
+		testDB.Where("post_id = ?", post.ID).Delete(&models.Comment{})
+
+		likers := make([]*models.User, 3)
+		for i := range likers {
+			likers[i] = &models.User{Username: fmt.Sprintf("liker_%d_%d", ts, i), Email: fmt.Sprintf("liker_%d_%d@e.com", ts, i)}
+			require.NoError(t, testDB.Create(likers[i]).Error)
+		}
+
+		// Likes per comment, in creation order. The two single-like comments
+		// tie and must come back in id order.
+		likeCounts := []int{1, 3, 0, 1}
+		created := make([]*models.Comment, len(likeCounts))
+		for i, n := range likeCounts {
+			c := &models.Comment{
+				Content:   fmt.Sprintf("Sorted %d", i),
+				PostID:    post.ID,
+				UserID:    user.ID,
+				CreatedAt: time.Now().Add(time.Duration(i) * time.Minute),
+			}
+			require.NoError(t, repo.Create(ctx, c))
+			created[i] = c
+			for _, liker := range likers[:n] {
+				require.NoError(t, repo.Like(ctx, liker.ID, c.ID))
+			}
+		}
+		// Liking twice is a no-op.
+		require.NoError(t, repo.Like(ctx, likers[0].ID, created[1].ID))
+
+		ids := func(comments []*models.Comment) []uint {
+			out := make([]uint, 0, len(comments))
+			for _, c := range comments {
+				out = append(out, c.ID)
+			}
+			return out
+		}
+
+		top, err := repo.ListByPost(ctx, post.ID, 0, "top")
+		require.NoError(t, err)
+		assert.Equal(t, []uint{created[1].ID, created[0].ID, created[3].ID, created[2].ID}, ids(top))
+		assert.Equal(t, 3, top[0].LikesCount)
+
+		newest, err := repo.ListByPost(ctx, post.ID, 0, "new")
+		require.NoError(t, err)
+		assert.Equal(t, []uint{created[3].ID, created[2].ID, created[1].ID, created[0].ID}, ids(newest))
+
+		oldest, err := repo.ListByPost(ctx, post.ID, 0, "old")
+		require.NoError(t, err)
+		assert.Equal(t, []uint{created[0].ID, created[1].ID, created[2].ID, created[3].ID}, ids(oldest))
+
+		require.NoError(t, repo.Unlike(ctx, likers[0].ID, created[1].ID))
+		fetched, err := repo.GetByID(ctx, created[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, fetched.LikesCount)
+	})
+
 	t.Run("ListByPost Hard Cap", func(t *testing.T) {
 		// Clear existing comments for this post
 		testDB.Where("post_id = ?", post.ID).Delete(&models.Comment{})
@@ -74,7 +130,7 @@ func TestCommentRepository_Integration(t *testing.T) {
 			require.NoError(t, testDB.Create(c).Error)
 		}
 
-		comments, err := repo.ListByPost(ctx, post.ID)
+		comments, err := repo.ListByPost(ctx, post.ID, 0, "")
 		assert.NoError(t, err)
 		assert.Equal(t, 1000, len(comments))
 	})
@@ -15,12 +15,17 @@ type FriendRepository interface {
 	Create(ctx context.Context, friendship *models.Friendship) error
 	GetByID(ctx context.Context, id uint) (*models.Friendship, error)
 	GetFriendshipBetweenUsers(ctx context.Context, userID1, userID2 uint) (*models.Friendship, error)
+	GetFriendshipsWithUsers(ctx context.Context, userID uint, otherUserIDs []uint) ([]models.Friendship, error)
+	GetBlockedUserIDs(ctx context.Context, userID uint, otherUserIDs []uint) ([]uint, error)
+	AreBlocked(ctx context.Context, userID1, userID2 uint) (bool, error)
 	GetFriends(ctx context.Context, userID uint) ([]models.User, error)
 	GetPendingRequests(ctx context.Context, userID uint) ([]models.Friendship, error)
 	GetSentRequests(ctx context.Context, userID uint) ([]models.Friendship, error)
 	UpdateStatus(ctx context.Context, friendshipID uint, status models.FriendshipStatus) error
 	Delete(ctx context.Context, friendshipID uint) error
 	RemoveFriendship(ctx context.Context, userID1, userID2 uint) error
+	GetFriendSuggestions(ctx context.Context, userID uint, limit, offset int) ([]models.FriendSuggestion, error)
+	GetMutualFriends(ctx context.Context, userID, otherUserID uint) ([]models.User, error)
 }
 
 // friendRepository implements FriendRepository
@@ -73,6 +78,77 @@ func (r *friendRepository) GetFriendshipBetweenUsers(ctx context.Context, userID
 	return &friendship, nil
 }
 
+// GetFriendshipsWithUsers returns every friendship row between userID and any of
+// otherUserIDs, in either direction, using a single query.
+func (r *friendRepository) GetFriendshipsWithUsers(ctx context.Context, userID uint, otherUserIDs []uint) ([]models.Friendship, error) {
+	var friendships []models.Friendship
+	if len(otherUserIDs) == 0 {
+		return friendships, nil
+	}
+
+	if err := r.db.WithContext(ctx).
+		Where("(requester_id = ? AND addressee_id IN ?) OR (addressee_id = ? AND requester_id IN ?)",
+			userID, otherUserIDs, userID, otherUserIDs).
+		Find(&friendships).Error; err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	return friendships, nil
+}
+
+// GetBlockedUserIDs returns the subset of otherUserIDs that userID has blocked
+// or that have blocked userID.
+func (r *friendRepository) GetBlockedUserIDs(ctx context.Context, userID uint, otherUserIDs []uint) ([]uint, error) {
+	var blocks []models.UserBlock
+	if len(otherUserIDs) == 0 {
+		return nil, nil
+	}
+
+	if err := r.db.WithContext(ctx).
+		Where("(blocker_id = ? AND blocked_id IN ?) OR (blocked_id = ? AND blocker_id IN ?)",
+			userID, otherUserIDs, userID, otherUserIDs).
+		Find(&blocks).Error; err != nil {
+		return nil, models.NewInternalError(err)
+	}
+
+	ids := make([]uint, 0, len(blocks))
+	for _, block := range blocks {
+		if block.BlockerID == userID {
+			ids = append(ids, block.BlockedID)
+		} else {
+			ids = append(ids, block.BlockerID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *friendRepository) AreBlocked(ctx context.Context, userID1, userID2 uint) (bool, error) {
+	blocked, err := AreBlocked(ctx, r.db, userID1, userID2)
+	if err != nil {
+		return false, models.NewInternalError(err)
+	}
+	return blocked, nil
+}
+
+// AreBlocked reports whether either user has blocked the other. It is the
+// single block check shared by friend requests and direct messages. A
+// missing user_blocks table (older schemas) counts as no block.
+func AreBlocked(ctx context.Context, db *gorm.DB, userID1, userID2 uint) (bool, error) {
+	var count int64
+	if err := db.WithContext(ctx).
+		Model(&models.UserBlock{}).
+		Where(
+			"(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+			userID1, userID2, userID2, userID1,
+		).
+		Count(&count).Error; err != nil {
+		if models.IsSchemaMissingError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (r *friendRepository) GetFriends(ctx context.Context, userID uint) ([]models.User, error) {
 	var users []models.User
 
@@ -151,3 +227,107 @@ func (r *friendRepository) RemoveFriendship(ctx context.Context, userID1, userID
 	}
 	return nil
 }
+
+// friendSuggestionsSQL ranks friends-of-friends by how many of the user's
+// friends they are connected to. Deactivated accounts, anyone with an existing
+// friendship row in either direction (accepted, pending or blocked) and either
+// side of a user block are skipped.
+const friendSuggestionsSQL = `
+WITH my_friends AS (
+	SELECT addressee_id AS id FROM friendships WHERE requester_id = @user AND status = @accepted
+	UNION
+	SELECT requester_id AS id FROM friendships WHERE addressee_id = @user AND status = @accepted
+),
+candidates AS (
+	SELECT f.addressee_id AS user_id, f.requester_id AS via
+	FROM friendships f JOIN my_friends mf ON f.requester_id = mf.id
+	WHERE f.status = @accepted
+	UNION
+	SELECT f.requester_id AS user_id, f.addressee_id AS via
+	FROM friendships f JOIN my_friends mf ON f.addressee_id = mf.id
+	WHERE f.status = @accepted
+)
+SELECT c.user_id, COUNT(DISTINCT c.via) AS mutual_friends
+FROM candidates c
+JOIN users u ON u.id = c.user_id AND u.deleted_at IS NULL AND u.deactivated_at IS NULL
+WHERE c.user_id <> @user
+	AND c.user_id NOT IN (SELECT addressee_id FROM friendships WHERE requester_id = @user)
+	AND c.user_id NOT IN (SELECT requester_id FROM friendships WHERE addressee_id = @user)
+	AND c.user_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = @user)
+	AND c.user_id NOT IN (SELECT blocker_id FROM user_blocks WHERE blocked_id = @user)
+GROUP BY c.user_id
+ORDER BY mutual_friends DESC, c.user_id ASC
+LIMIT @limit OFFSET @offset`
+
+// GetFriendSuggestions returns friends-of-friends the user is not yet
+// connected to, most mutual friends first.
+func (r *friendRepository) GetFriendSuggestions(ctx context.Context, userID uint, limit, offset int) ([]models.FriendSuggestion, error) {
+	var rows []struct {
+		UserID        uint
+		MutualFriends int
+	}
+	if err := r.db.WithContext(ctx).Raw(friendSuggestionsSQL, map[string]interface{}{
+		"user":     userID,
+		"accepted": models.FriendshipStatusAccepted,
+		"limit":    limit,
+		"offset":   offset,
+	}).Scan(&rows).Error; err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	if len(rows) == 0 {
+		return []models.FriendSuggestion{}, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.UserID)
+	}
+	var users []models.User
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	byID := make(map[uint]models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	suggestions := make([]models.FriendSuggestion, 0, len(rows))
+	for _, row := range rows {
+		if u, ok := byID[row.UserID]; ok {
+			suggestions = append(suggestions, models.FriendSuggestion{User: u, MutualFriends: row.MutualFriends})
+		}
+	}
+	return suggestions, nil
+}
+
+// mutualFriendsSQL selects users who are accepted friends of both @a and
+// @b, leaving out the two users themselves and anyone on either side of a
+// block with @a (the viewer).
+const mutualFriendsSQL = `users.id IN (
+	SELECT CASE WHEN requester_id = @a THEN addressee_id ELSE requester_id END
+	FROM friendships WHERE status = @accepted AND (requester_id = @a OR addressee_id = @a)
+	INTERSECT
+	SELECT CASE WHEN requester_id = @b THEN addressee_id ELSE requester_id END
+	FROM friendships WHERE status = @accepted AND (requester_id = @b OR addressee_id = @b)
+)
+AND users.id NOT IN (@a, @b)
+AND users.id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = @a)
+AND users.id NOT IN (SELECT blocker_id FROM user_blocks WHERE blocked_id = @a)`
+
+// GetMutualFriends returns the friends userID and otherUserID have in
+// common, as seen by userID.
+func (r *friendRepository) GetMutualFriends(ctx context.Context, userID, otherUserID uint) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.WithContext(ctx).
+		Where(mutualFriendsSQL, map[string]interface{}{
+			"a":        userID,
+			"b":        otherUserID,
+			"accepted": models.FriendshipStatusAccepted,
+		}).
+		Order("users.username ASC").
+		Limit(maxFriendLimit).
+		Find(&users).Error; err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	return users, nil
+}
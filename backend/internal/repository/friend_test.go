@@ -114,4 +114,122 @@ func TestFriendRepository_Integration(t *testing.T) {
 		assert.NotEmpty(t, sent)
 		assert.Equal(t, u3.ID, sent[0].AddresseeID)
 	})
+
+	t.Run("GetFriendshipsWithUsers and GetBlockedUserIDs", func(t *testing.T) {
+		ts := time.Now().UnixNano()
+		me := &models.User{Username: fmt.Sprintf("batch_me_%d", ts), Email: fmt.Sprintf("batch_me_%d@e.com", ts)}
+		friend := &models.User{Username: fmt.Sprintf("batch_friend_%d", ts), Email: fmt.Sprintf("batch_friend_%d@e.com", ts)}
+		blocker := &models.User{Username: fmt.Sprintf("batch_blocker_%d", ts), Email: fmt.Sprintf("batch_blocker_%d@e.com", ts)}
+		testDB.Create(me)
+		testDB.Create(friend)
+		testDB.Create(blocker)
+
+		testDB.Create(&models.Friendship{RequesterID: friend.ID, AddresseeID: me.ID, Status: models.FriendshipStatusAccepted})
+		testDB.Create(&models.UserBlock{BlockerID: blocker.ID, BlockedID: me.ID})
+
+		friendships, err := repo.GetFriendshipsWithUsers(ctx, me.ID, []uint{friend.ID, blocker.ID})
+		require.NoError(t, err)
+		require.Len(t, friendships, 1)
+		assert.Equal(t, friend.ID, friendships[0].RequesterID)
+
+		blocked, err := repo.GetBlockedUserIDs(ctx, me.ID, []uint{friend.ID, blocker.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{blocker.ID}, blocked)
+	})
+
+	t.Run("GetFriendSuggestions", func(t *testing.T) {
+		ts := time.Now().UnixNano()
+		newUser := func(name string) *models.User {
+			u := &models.User{Username: fmt.Sprintf("sugg_%s_%d", name, ts), Email: fmt.Sprintf("sugg_%s_%d@e.com", name, ts)}
+			require.NoError(t, testDB.Create(u).Error)
+			return u
+		}
+		befriend := func(a, b *models.User, status models.FriendshipStatus) {
+			require.NoError(t, testDB.Create(&models.Friendship{RequesterID: a.ID, AddresseeID: b.ID, Status: status}).Error)
+		}
+
+		me := newUser("me")
+		alice, bob := newUser("alice"), newUser("bob")
+		// oneMutual gets the lower id, so the id tie-break cannot explain the ranking.
+		oneMutual, twoMutual := newUser("one"), newUser("two")
+		blocked, pending := newUser("blocked"), newUser("pending")
+		gone := newUser("gone")
+
+		befriend(me, alice, models.FriendshipStatusAccepted)
+		befriend(bob, me, models.FriendshipStatusAccepted)
+		befriend(alice, bob, models.FriendshipStatusAccepted)
+		befriend(alice, oneMutual, models.FriendshipStatusAccepted)
+		befriend(twoMutual, alice, models.FriendshipStatusAccepted)
+		befriend(bob, twoMutual, models.FriendshipStatusAccepted)
+		befriend(alice, blocked, models.FriendshipStatusAccepted)
+		befriend(bob, blocked, models.FriendshipStatusAccepted)
+		befriend(alice, pending, models.FriendshipStatusAccepted)
+		befriend(me, pending, models.FriendshipStatusPending)
+		befriend(alice, gone, models.FriendshipStatusAccepted)
+		befriend(bob, gone, models.FriendshipStatusAccepted)
+		require.NoError(t, testDB.Create(&models.UserBlock{BlockerID: blocked.ID, BlockedID: me.ID}).Error)
+		require.NoError(t, testDB.Model(gone).Update("deactivated_at", time.Now()).Error)
+
+		suggestions, err := repo.GetFriendSuggestions(ctx, me.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, suggestions, 2)
+		assert.Equal(t, twoMutual.ID, suggestions[0].User.ID)
+		assert.Equal(t, 2, suggestions[0].MutualFriends)
+		assert.Equal(t, oneMutual.ID, suggestions[1].User.ID)
+		assert.Equal(t, 1, suggestions[1].MutualFriends)
+
+		page, err := repo.GetFriendSuggestions(ctx, me.ID, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, oneMutual.ID, page[0].User.ID)
+	})
+
+	t.Run("GetMutualFriends", func(t *testing.T) {
+		ts := time.Now().UnixNano()
+		newUser := func(name string) *models.User {
+			u := &models.User{Username: fmt.Sprintf("mutual_%s_%d", name, ts), Email: fmt.Sprintf("mutual_%s_%d@e.com", name, ts)}
+			require.NoError(t, testDB.Create(u).Error)
+			return u
+		}
+		befriend := func(a, b *models.User, status models.FriendshipStatus) {
+			require.NoError(t, testDB.Create(&models.Friendship{RequesterID: a.ID, AddresseeID: b.ID, Status: status}).Error)
+		}
+
+		a, b := newUser("a"), newUser("b")
+		shared1, shared2 := newUser("shared1"), newUser("shared2")
+		onlyA, onlyB := newUser("onlya"), newUser("onlyb")
+		pending, blocked := newUser("pending"), newUser("blocked")
+
+		befriend(a, b, models.FriendshipStatusAccepted)
+		befriend(a, shared1, models.FriendshipStatusAccepted)
+		befriend(shared1, b, models.FriendshipStatusAccepted)
+		befriend(shared2, a, models.FriendshipStatusAccepted)
+		befriend(b, shared2, models.FriendshipStatusAccepted)
+		befriend(a, onlyA, models.FriendshipStatusAccepted)
+		befriend(b, onlyB, models.FriendshipStatusAccepted)
+		befriend(a, pending, models.FriendshipStatusAccepted)
+		befriend(b, pending, models.FriendshipStatusPending)
+		befriend(a, blocked, models.FriendshipStatusAccepted)
+		befriend(b, blocked, models.FriendshipStatusAccepted)
+
+		ids := func(users []models.User) []uint {
+			out := make([]uint, 0, len(users))
+			for _, u := range users {
+				out = append(out, u.ID)
+			}
+			return out
+		}
+
+		fromA, err := repo.GetMutualFriends(ctx, a.ID, b.ID)
+		require.NoError(t, err)
+		fromB, err := repo.GetMutualFriends(ctx, b.ID, a.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint{shared1.ID, shared2.ID, blocked.ID}, ids(fromA))
+		assert.Equal(t, ids(fromA), ids(fromB))
+
+		require.NoError(t, testDB.Create(&models.UserBlock{BlockerID: a.ID, BlockedID: blocked.ID}).Error)
+		fromA, err = repo.GetMutualFriends(ctx, a.ID, b.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint{shared1.ID, shared2.ID}, ids(fromA))
+	})
 }
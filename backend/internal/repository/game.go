@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"sanctum/internal/models"
 
@@ -20,6 +21,8 @@ type GameRepository interface {
 	GetMoves(roomID uint) ([]models.GameMove, error)
 	GetStats(userID uint, gameType models.GameType) (*models.GameStats, error)
 	UpdateStats(stats *models.GameStats) error
+	GetLeaderboard(gameType models.GameType, limit int, userID uint) (*models.GameLeaderboard, error)
+	CancelIdleRooms(status models.GameStatus, idleBefore time.Time) ([]models.GameRoom, error)
 }
 
 type gameRepository struct {
@@ -42,7 +45,7 @@ func (r *gameRepository) GetRoom(id uint) (*models.GameRoom, error) {
 }
 
 func (r *gameRepository) UpdateRoom(room *models.GameRoom) error {
-	return r.db.Save(room).Error
+	return models.SaveGameRoom(r.db, room)
 }
 
 func (r *gameRepository) GetAllActiveRooms() ([]models.GameRoom, error) {
@@ -89,3 +92,72 @@ func (r *gameRepository) GetStats(userID uint, gameType models.GameType) (*model
 func (r *gameRepository) UpdateStats(stats *models.GameStats) error {
 	return r.db.Save(stats).Error
 }
+
+// CancelIdleRooms cancels rooms in status that have not been updated since
+// idleBefore and returns the ones it cancelled.
+func (r *gameRepository) CancelIdleRooms(status models.GameStatus, idleBefore time.Time) ([]models.GameRoom, error) {
+	var rooms []models.GameRoom
+	if err := r.db.Where("status = ? AND updated_at < ?", status, idleBefore).Find(&rooms).Error; err != nil {
+		return nil, err
+	}
+
+	cancelled := make([]models.GameRoom, 0, len(rooms))
+	for _, room := range rooms {
+		// Re-check the room is still idle so a move made since the scan wins.
+		res := r.db.Model(&models.GameRoom{}).
+			Where("id = ? AND status = ? AND updated_at < ?", room.ID, status, idleBefore).
+			Updates(map[string]interface{}{
+				"status":       models.GameCancelled,
+				"next_turn_id": 0,
+				"version":      gorm.Expr("version + 1"),
+			})
+		if res.Error != nil {
+			return cancelled, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		room.Status = models.GameCancelled
+		room.NextTurnID = 0
+		room.Version++
+		cancelled = append(cancelled, room)
+	}
+	return cancelled, nil
+}
+
+// rankedGameStatsSQL ranks everyone who has finished at least one game of a
+// type by points, breaking ties on win rate. Equal players share a rank.
+const rankedGameStatsSQL = `WITH ranked AS (
+	SELECT gs.user_id, u.username, gs.wins, gs.losses, gs.draws, gs.total_games, gs.points, gs.rating,
+		CAST(gs.wins AS FLOAT) / gs.total_games AS win_rate,
+		RANK() OVER (ORDER BY gs.points DESC, CAST(gs.wins AS FLOAT) / gs.total_games DESC) AS rank
+	FROM game_stats gs
+	JOIN users u ON u.id = gs.user_id AND u.deleted_at IS NULL
+	WHERE gs.game_type = ? AND gs.total_games > 0
+)
+SELECT * FROM ranked `
+
+func (r *gameRepository) GetLeaderboard(gameType models.GameType, limit int, userID uint) (*models.GameLeaderboard, error) {
+	board := &models.GameLeaderboard{GameType: gameType, Entries: []models.LeaderboardEntry{}}
+	if err := r.db.Raw(rankedGameStatsSQL+"ORDER BY rank, user_id LIMIT ?", gameType, limit).
+		Scan(&board.Entries).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range board.Entries {
+		if board.Entries[i].UserID == userID {
+			me := board.Entries[i]
+			board.Me = &me
+			return board, nil
+		}
+	}
+	var mine []models.LeaderboardEntry
+	if err := r.db.Raw(rankedGameStatsSQL+"WHERE user_id = ?", gameType, userID).
+		Scan(&mine).Error; err != nil {
+		return nil, err
+	}
+	if len(mine) > 0 {
+		board.Me = &mine[0]
+	}
+	return board, nil
+}
@@ -9,6 +9,7 @@ import (
 	"sanctum/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -27,6 +28,9 @@ type ImageRepository interface {
 	Create(ctx context.Context, image *models.Image) error
 	GetByHash(ctx context.Context, hash string) (*models.Image, error)
 	GetByHashWithVariants(ctx context.Context, hash string) (*models.Image, error)
+	GetReadyByContentHash(ctx context.Context, contentHash string) (*models.Image, error)
+	AddUploader(ctx context.Context, imageID, userID uint) error
+	IsUploader(ctx context.Context, imageID, userID uint) (bool, error)
 	UpdateLastAccessed(ctx context.Context, id uint) error
 	UpsertVariant(ctx context.Context, v *models.ImageVariant) error
 	GetVariantsByImageID(ctx context.Context, imageID uint) ([]models.ImageVariant, error)
@@ -34,6 +38,8 @@ type ImageRepository interface {
 	MarkReady(ctx context.Context, imageID uint) error
 	MarkFailed(ctx context.Context, imageID uint, errMsg string) error
 	RequeueStaleProcessing(ctx context.Context, olderThan time.Duration) (int64, error)
+	CountQueued(ctx context.Context) (int64, error)
+	ListReadyAfter(ctx context.Context, afterID uint, limit int) ([]models.Image, error)
 }
 
 type imageRepository struct {
@@ -45,8 +51,14 @@ func NewImageRepository(db *gorm.DB) ImageRepository {
 	return &imageRepository{db: db}
 }
 
+// Create stores the image and records its owner as the first uploader.
 func (r *imageRepository) Create(ctx context.Context, image *models.Image) error {
-	return r.db.WithContext(ctx).Create(image).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ImageUploader{ImageID: image.ID, UserID: image.UserID}).Error
+	})
 }
 
 func (r *imageRepository) GetByHash(ctx context.Context, hash string) (*models.Image, error) {
@@ -68,6 +80,34 @@ func (r *imageRepository) GetByHashWithVariants(ctx context.Context, hash string
 	return &image, nil
 }
 
+func (r *imageRepository) GetReadyByContentHash(ctx context.Context, contentHash string) (*models.Image, error) {
+	var image models.Image
+	if err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Where("content_hash = ? AND status = ?", contentHash, ImageStatusReady).
+		Order("id ASC").
+		First(&image).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// AddUploader records userID as an uploader of the image. Uploading the same
+// image again is a no-op.
+func (r *imageRepository) AddUploader(ctx context.Context, imageID, userID uint) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ImageUploader{ImageID: imageID, UserID: userID}).Error
+}
+
+// IsUploader reports whether userID has uploaded the image.
+func (r *imageRepository) IsUploader(ctx context.Context, imageID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ImageUploader{}).
+		Where("image_id = ? AND user_id = ?", imageID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *imageRepository) UpdateLastAccessed(ctx context.Context, id uint) error {
 	now := time.Now().UTC()
 	return r.db.WithContext(ctx).Model(&models.Image{}).Where("id = ?", id).Update("last_accessed_at", now).Error
@@ -194,3 +234,23 @@ func (r *imageRepository) RequeueStaleProcessing(ctx context.Context, olderThan
 		})
 	return res.RowsAffected, res.Error
 }
+
+func (r *imageRepository) CountQueued(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Image{}).
+		Where("status = ?", ImageStatusQueued).
+		Count(&count).Error
+	return count, err
+}
+
+// ListReadyAfter pages through ready images in id order, starting after
+// afterID.
+func (r *imageRepository) ListReadyAfter(ctx context.Context, afterID uint, limit int) ([]models.Image, error) {
+	var images []models.Image
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND id > ?", ImageStatusReady, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&images).Error
+	return images, err
+}
@@ -11,8 +11,9 @@ import (
 
 // PollRepository defines the interface for poll data operations.
 type PollRepository interface {
-	Create(ctx context.Context, postID uint, question string, options []string) (*models.Poll, error)
-	Vote(ctx context.Context, userID, pollID, pollOptionID uint) error
+	Create(ctx context.Context, poll *models.Poll, options []string) error
+	// SetVotes replaces the user's selection in a poll with optionIDs.
+	SetVotes(ctx context.Context, userID, pollID uint, optionIDs []uint) error
 	EnrichWithResults(ctx context.Context, poll *models.Poll, currentUserID uint) error
 }
 
@@ -25,13 +26,8 @@ func NewPollRepository(db *gorm.DB) PollRepository {
 	return &pollRepository{db: db}
 }
 
-func (r *pollRepository) Create(ctx context.Context, postID uint, question string, options []string) (*models.Poll, error) {
-	poll := &models.Poll{
-		PostID:   postID,
-		Question: question,
-	}
-
-	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+func (r *pollRepository) Create(ctx context.Context, poll *models.Poll, options []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(poll).Error; err != nil {
 			return err
 		}
@@ -51,22 +47,23 @@ func (r *pollRepository) Create(ctx context.Context, postID uint, question strin
 		poll.Options = pollOptions
 		return nil
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return poll, nil
 }
 
-func (r *pollRepository) Vote(ctx context.Context, userID, pollID, pollOptionID uint) error {
-	// Upsert: one vote per user per poll. Use raw SQL for ON CONFLICT.
-	return r.db.WithContext(ctx).Exec(
-		`INSERT INTO poll_votes (user_id, poll_id, poll_option_id, created_at)
-		 VALUES (?, ?, ?, NOW())
-		 ON CONFLICT (user_id, poll_id) DO UPDATE SET poll_option_id = ?`,
-		userID, pollID, pollOptionID, pollOptionID,
-	).Error
+func (r *pollRepository) SetVotes(ctx context.Context, userID, pollID uint, optionIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND poll_id = ?", userID, pollID).
+			Delete(&models.PollVote{}).Error; err != nil {
+			return err
+		}
+		if len(optionIDs) == 0 {
+			return nil
+		}
+		votes := make([]models.PollVote, 0, len(optionIDs))
+		for _, optionID := range optionIDs {
+			votes = append(votes, models.PollVote{UserID: userID, PollID: pollID, PollOptionID: optionID})
+		}
+		return tx.Create(&votes).Error
+	})
 }
 
 func (r *pollRepository) EnrichWithResults(ctx context.Context, poll *models.Poll, currentUserID uint) error {
@@ -83,9 +80,16 @@ func (r *pollRepository) EnrichWithResults(ctx context.Context, poll *models.Pol
 		poll.Options[i].VotesCount = int(count)
 	}
 	if currentUserID != 0 {
-		var vote models.PollVote
-		if err := r.db.WithContext(ctx).Where("poll_id = ? AND user_id = ?", poll.ID, currentUserID).First(&vote).Error; err == nil {
-			poll.UserVoteOptionID = &vote.PollOptionID
+		var optionIDs []uint
+		if err := r.db.WithContext(ctx).Model(&models.PollVote{}).
+			Where("poll_id = ? AND user_id = ?", poll.ID, currentUserID).
+			Order("poll_option_id").
+			Pluck("poll_option_id", &optionIDs).Error; err != nil {
+			return err
+		}
+		poll.UserVoteOptionIDs = optionIDs
+		if len(optionIDs) > 0 {
+			poll.UserVoteOptionID = &optionIDs[0]
 		}
 	}
 	return nil
@@ -5,11 +5,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"sanctum/internal/cache"
 	"sanctum/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PostRepository defines the interface for post data operations
@@ -18,24 +20,56 @@ type PostRepository interface {
 	GetByID(ctx context.Context, id uint, currentUserID uint) (*models.Post, error)
 	GetByUserID(ctx context.Context, userID uint, limit, offset int, currentUserID uint) ([]*models.Post, error)
 	GetBySanctumID(ctx context.Context, sanctumID uint, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error)
+	GetBySanctumTag(ctx context.Context, sanctumID uint, tag string, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error)
+	ListSanctumTags(ctx context.Context, sanctumID uint, limit int) ([]models.TagCount, error)
+	SetTags(ctx context.Context, postID uint, tags []string) error
 	List(ctx context.Context, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error)
 	Search(ctx context.Context, query string, limit, offset int, currentUserID uint) ([]*models.Post, error)
 	Update(ctx context.Context, post *models.Post) error
+	UpdateWithRevision(ctx context.Context, post *models.Post, revision *models.PostRevision) error
+	GetRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error)
 	Delete(ctx context.Context, id uint) error
 	IsLiked(ctx context.Context, userID, postID uint) (bool, error)
 	GetLikedPostIDs(ctx context.Context, userID uint, postIDs []uint) ([]uint, error)
 	Like(ctx context.Context, userID, postID uint) error
 	Unlike(ctx context.Context, userID, postID uint) error
+	PublishDue(ctx context.Context, now time.Time) ([]*models.Post, error)
+	Bookmark(ctx context.Context, userID, postID uint) error
+	Unbookmark(ctx context.Context, userID, postID uint) error
+	GetBookmarked(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error)
+	GetFeed(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error)
 }
 
+// DefaultHotHalfLife is how long it takes a post's engagement to count half as
+// much in the "hot" sort when no other half-life is configured.
+const DefaultHotHalfLife = 12 * time.Hour
+
 // postRepository implements PostRepository
 type postRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	hotHalfLife time.Duration
+}
+
+// PostRepositoryOption customises a post repository.
+type PostRepositoryOption func(*postRepository)
+
+// WithHotHalfLife sets the decay half-life used by the "hot" sort.
+// Non-positive values keep DefaultHotHalfLife.
+func WithHotHalfLife(d time.Duration) PostRepositoryOption {
+	return func(r *postRepository) {
+		if d > 0 {
+			r.hotHalfLife = d
+		}
+	}
 }
 
 // NewPostRepository creates a new post repository
-func NewPostRepository(db *gorm.DB) PostRepository {
-	return &postRepository{db: db}
+func NewPostRepository(db *gorm.DB, opts ...PostRepositoryOption) PostRepository {
+	r := &postRepository{db: db, hotHalfLife: DefaultHotHalfLife}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
@@ -57,6 +91,8 @@ func (r *postRepository) GetByID(ctx context.Context, id uint, currentUserID uin
 				Preload("User").
 				Preload("Poll").
 				Preload("Poll.Options").
+				Preload("Tags").
+				Scopes(notShadowBanned(0)).
 				First(&post, id).Error
 		})
 	} else {
@@ -64,6 +100,8 @@ func (r *postRepository) GetByID(ctx context.Context, id uint, currentUserID uin
 			Preload("User").
 			Preload("Poll").
 			Preload("Poll.Options").
+			Preload("Tags").
+			Scopes(notShadowBanned(currentUserID)).
 			First(&post, id).Error
 	}
 
@@ -83,7 +121,9 @@ func (r *postRepository) GetByUserID(ctx context.Context, userID uint, limit, of
 		Preload("User").
 		Preload("Poll").
 		Preload("Poll.Options").
+		Preload("Tags").
 		Where("user_id = ?", userID).
+		Scopes(visibleTo(userID, currentUserID), notShadowBanned(currentUserID)).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -103,7 +143,9 @@ func (r *postRepository) GetBySanctumID(ctx context.Context, sanctumID uint, lim
 		Preload("User").
 		Preload("Poll").
 		Preload("Poll.Options").
-		Where("sanctum_id = ?", sanctumID)
+		Preload("Tags").
+		Preload("Sanctum").
+		Scopes(inSanctumFeed(sanctumID), publishedOnly, notShadowBanned(currentUserID))
 	err := r.applySort(base, sort).
 		Limit(limit).
 		Offset(offset).
@@ -117,12 +159,84 @@ func (r *postRepository) GetBySanctumID(ctx context.Context, sanctumID uint, lim
 	return posts, nil
 }
 
+// GetBySanctumTag returns a sanctum's posts carrying the given normalized tag.
+func (r *postRepository) GetBySanctumTag(ctx context.Context, sanctumID uint, tag string, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error) {
+	var posts []*models.Post
+	base := r.applyPostDetails(r.db.WithContext(ctx), currentUserID).
+		Preload("User").
+		Preload("Poll").
+		Preload("Poll.Options").
+		Preload("Tags").
+		Preload("Sanctum").
+		Scopes(inSanctumFeed(sanctumID)).
+		Where("EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE post_tags.post_id = posts.id AND tags.name = ?)", tag).
+		Scopes(publishedOnly, notShadowBanned(currentUserID))
+	err := r.applySort(base, sort).
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	if enrichErr := r.enrichImageMetadata(ctx, posts); enrichErr != nil {
+		return nil, enrichErr
+	}
+	return posts, nil
+}
+
+// ListSanctumTags returns the tags used by a sanctum's published posts, most
+// used first.
+func (r *postRepository) ListSanctumTags(ctx context.Context, sanctumID uint, limit int) ([]models.TagCount, error) {
+	var counts []models.TagCount
+	err := r.db.WithContext(ctx).
+		Table("tags").
+		Select("tags.name AS name, COUNT(*) AS count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id").
+		Where("posts.sanctum_id = ? AND posts.deleted_at IS NULL", sanctumID).
+		Scopes(publishedOnly).
+		Group("tags.name").
+		Order("count DESC, tags.name ASC").
+		Limit(limit).
+		Scan(&counts).Error
+	return counts, err
+}
+
+// SetTags replaces a post's tags, creating any tag that does not exist yet.
+// Tags are expected to be normalized already.
+func (r *postRepository) SetTags(ctx context.Context, postID uint, names []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tags := make([]models.Tag, 0, len(names))
+		if len(names) > 0 {
+			rows := make([]models.Tag, len(names))
+			for i, name := range names {
+				rows[i] = models.Tag{Name: name}
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Post{ID: postID}).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		return err
+	}
+	cache.Invalidate(ctx, cache.PostKey(postID))
+	cache.InvalidatePostsList(ctx)
+	return nil
+}
+
 func (r *postRepository) List(ctx context.Context, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error) {
 	var posts []*models.Post
 	base := r.applyPostDetails(r.db.WithContext(ctx), currentUserID).
 		Preload("User").
 		Preload("Poll").
-		Preload("Poll.Options")
+		Preload("Poll.Options").
+		Preload("Tags").
+		Scopes(publishedOnly, notShadowBanned(currentUserID))
 	err := r.applySort(base, sort).
 		Limit(limit).
 		Offset(offset).
@@ -136,23 +250,107 @@ func (r *postRepository) List(ctx context.Context, limit, offset int, currentUse
 	return posts, nil
 }
 
+// inSanctumFeed matches posts made in the sanctum and posts crossposted into
+// it. Crossposts keep their source SanctumID; the preloaded Sanctum lets the
+// feed attribute them.
+func inSanctumFeed(sanctumID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(posts.sanctum_id = ? OR posts.id IN (SELECT post_id FROM crossposts WHERE sanctum_id = ?))",
+			sanctumID, sanctumID)
+	}
+}
+
+// publishedOnly excludes posts that are still waiting for their publish time
+// and posts by authors who have deactivated their account.
+func publishedOnly(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.status = ?", models.PostStatusPublished).
+		Where("posts.user_id NOT IN (SELECT id FROM users WHERE deactivated_at IS NOT NULL)")
+}
+
+// notShadowBanned hides posts by shadow-banned authors from everyone but the
+// author, so the ban is invisible to the banned user, and admins, who still
+// have to moderate them. A viewerID of 0 hides them from everyone.
+func notShadowBanned(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(posts.user_id = ? OR posts.user_id NOT IN (SELECT id FROM users WHERE shadow_banned = ?) OR "+
+			viewerIsAdminSQL+")", viewerID, true, viewerID, true)
+	}
+}
+
+// viewerIsAdminSQL is true when the bound user id belongs to an admin.
+const viewerIsAdminSQL = "EXISTS (SELECT 1 FROM users WHERE users.id = ? AND users.is_admin = ?)"
+
+// visibleTo lets authors see their own scheduled posts on their profile while
+// everyone else only sees published ones.
+func visibleTo(authorID, currentUserID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if currentUserID != 0 && currentUserID == authorID {
+			return db
+		}
+		return publishedOnly(db)
+	}
+}
+
+// PublishDue flips scheduled posts whose publish time has passed to published
+// and returns the posts it flipped. created_at is moved to the publish time so
+// the post sorts as new in the feed. Each row is claimed with a conditional
+// update, so concurrent workers never publish the same post twice.
+func (r *postRepository) PublishDue(ctx context.Context, now time.Time) ([]*models.Post, error) {
+	var due []*models.Post
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND publish_at <= ?", models.PostStatusScheduled, now).
+		Order("publish_at ASC").
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	published := make([]*models.Post, 0, len(due))
+	for _, p := range due {
+		res := r.db.WithContext(ctx).Model(&models.Post{}).
+			Where("id = ? AND status = ?", p.ID, models.PostStatusScheduled).
+			Updates(map[string]interface{}{
+				"status":     models.PostStatusPublished,
+				"created_at": *p.PublishAt,
+			})
+		if res.Error != nil {
+			return published, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		p.Status = models.PostStatusPublished
+		p.CreatedAt = *p.PublishAt
+		cache.Invalidate(ctx, cache.PostKey(p.ID))
+		published = append(published, p)
+	}
+	if len(published) > 0 {
+		cache.InvalidatePostsList(ctx)
+	}
+	return published, nil
+}
+
 // applySort appends the ORDER BY (and optional WHERE) clause for the requested sort type.
-// likes_count and comments_count are SELECT aliases from applyPostDetails; PostgreSQL
-// allows referencing them in ORDER BY within the same query level.
+// PostgreSQL only accepts SELECT aliases such as likes_count as bare ORDER BY
+// terms, so any ranking expression repeats the count subqueries instead.
 func (r *postRepository) applySort(db *gorm.DB, sort string) *gorm.DB {
 	switch sort {
 	case "hot":
+		// Engagement decays by half every hotHalfLife. Ranking by
+		// log2(1 + engagement) + created_at / half-life orders posts the same
+		// way as (1 + engagement) * 2^(-age / half-life), but the score does not
+		// depend on NOW(), so pages stay consistent while paginating.
 		return db.Order(gorm.Expr(
-			"(likes_count + comments_count * 2.0) / POWER(EXTRACT(EPOCH FROM (NOW() - posts.created_at)) / 3600.0 + 2, 1.5) DESC",
+			"LN(1 + "+postLikesCountSQL+" + "+postCommentsCountSQL+" * 2.0) / LN(2.0) + EXTRACT(EPOCH FROM posts.created_at) / ? DESC, posts.id DESC",
+			r.hotHalfLife.Seconds(),
 		))
 	case "top":
 		return db.Order("likes_count DESC, created_at DESC")
 	case "rising":
 		return db.
 			Where("posts.created_at > NOW() - INTERVAL '48 hours'").
-			Order("(likes_count + comments_count * 2) DESC")
+			Order(gorm.Expr("(" + postLikesCountSQL + " + " + postCommentsCountSQL + " * 2) DESC"))
 	case "best":
-		return db.Order(gorm.Expr("(likes_count + comments_count * 1.5) DESC, created_at DESC"))
+		return db.Order(gorm.Expr("(" + postLikesCountSQL + " + " + postCommentsCountSQL + " * 1.5) DESC, posts.created_at DESC"))
 	default: // "new" and anything unrecognized
 		return db.Order("created_at DESC")
 	}
@@ -165,7 +363,9 @@ func (r *postRepository) Search(ctx context.Context, query string, limit, offset
 		Preload("User").
 		Preload("Poll").
 		Preload("Poll.Options").
+		Preload("Tags").
 		Where("title ILIKE ? OR content ILIKE ?", like, like).
+		Scopes(publishedOnly, notShadowBanned(currentUserID)).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -179,11 +379,19 @@ func (r *postRepository) Search(ctx context.Context, query string, limit, offset
 	return posts, nil
 }
 
+// Engagement counts are computed on every read rather than stored on posts,
+// so there is no counter to drift or reconcile. See
+// docs/decisions/0003-computed-engagement-counts.md.
+const (
+	postCommentsCountSQL = "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL)"
+	postLikesCountSQL    = "(SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id)"
+)
+
 // applyPostDetails adds subqueries to fetch counts and liked status in a single query.
 func (r *postRepository) applyPostDetails(db *gorm.DB, currentUserID uint) *gorm.DB {
 	selectQuery := "posts.*, " +
-		"(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL) as comments_count, " +
-		"(SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id) as likes_count"
+		postCommentsCountSQL + " as comments_count, " +
+		postLikesCountSQL + " as likes_count"
 
 	if currentUserID != 0 {
 		return db.Select(selectQuery+", EXISTS(SELECT 1 FROM likes WHERE likes.post_id = posts.id AND likes.user_id = ?) as liked", currentUserID)
@@ -254,6 +462,32 @@ func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
 	return nil
 }
 
+// UpdateWithRevision saves post and records revision, the content it replaced,
+// in one transaction.
+func (r *postRepository) UpdateWithRevision(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return tx.Save(post).Error
+	})
+	if err != nil {
+		return err
+	}
+	cache.Invalidate(ctx, cache.PostKey(post.ID))
+	return nil
+}
+
+// GetRevisions returns a post's revisions, oldest first.
+func (r *postRepository) GetRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := r.db.WithContext(ctx).
+		Where("post_id = ?", postID).
+		Order("created_at ASC, id ASC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
 func (r *postRepository) Delete(ctx context.Context, id uint) error {
 	if err := r.db.WithContext(ctx).Delete(&models.Post{}, id).Error; err != nil {
 		return err
@@ -309,3 +543,71 @@ func (r *postRepository) Unlike(ctx context.Context, userID, postID uint) error
 	}
 	return err
 }
+
+func (r *postRepository) Bookmark(ctx context.Context, userID, postID uint) error {
+	bookmark := models.Bookmark{UserID: userID, PostID: postID}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&bookmark).Error
+}
+
+func (r *postRepository) Unbookmark(ctx context.Context, userID, postID uint) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&models.Bookmark{}).Error
+}
+
+// GetBookmarked returns the user's bookmarked posts, most recently saved first.
+func (r *postRepository) GetBookmarked(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	var posts []*models.Post
+	err := r.applyPostDetails(r.db.WithContext(ctx), userID).
+		Preload("User").
+		Preload("Poll").
+		Preload("Poll.Options").
+		Preload("Tags").
+		Joins("JOIN bookmarks ON bookmarks.post_id = posts.id AND bookmarks.user_id = ?", userID).
+		Scopes(publishedOnly, notShadowBanned(userID)).
+		Order("bookmarks.created_at DESC, bookmarks.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	if enrichErr := r.enrichImageMetadata(ctx, posts); enrichErr != nil {
+		return nil, enrichErr
+	}
+	return posts, nil
+}
+
+// GetFeed returns the user's personalized feed, newest first: posts in the
+// sanctums they belong to plus posts by accepted friends. Posts by users on
+// either side of a block with them are left out.
+func (r *postRepository) GetFeed(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	var posts []*models.Post
+	err := r.applyPostDetails(r.db.WithContext(ctx), userID).
+		Preload("User").
+		Preload("Poll").
+		Preload("Poll.Options").
+		Preload("Tags").
+		Scopes(publishedOnly, notShadowBanned(userID)).
+		Where(`posts.sanctum_id IN (SELECT sanctum_id FROM sanctum_memberships WHERE user_id = ?)
+			OR posts.user_id IN (SELECT addressee_id FROM friendships WHERE requester_id = ? AND status = ?)
+			OR posts.user_id IN (SELECT requester_id FROM friendships WHERE addressee_id = ? AND status = ?)`,
+			userID,
+			userID, models.FriendshipStatusAccepted,
+			userID, models.FriendshipStatusAccepted).
+		Where("posts.user_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = ?)", userID).
+		Where("posts.user_id NOT IN (SELECT blocker_id FROM user_blocks WHERE blocked_id = ?)", userID).
+		Order("posts.created_at DESC, posts.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	if enrichErr := r.enrichImageMetadata(ctx, posts); enrichErr != nil {
+		return nil, enrichErr
+	}
+	return posts, nil
+}
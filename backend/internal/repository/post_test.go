@@ -75,3 +75,57 @@ func TestPostRepository_Integration(t *testing.T) {
 		assert.GreaterOrEqual(t, len(all), 2)
 	})
 }
+
+func TestPostRepository_HotSort(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Now().UnixNano()
+
+	var fans []*models.User
+	for i := range 10 {
+		u := &models.User{Username: fmt.Sprintf("hotfan_%d_%d", ts, i), Email: fmt.Sprintf("hotfan_%d_%d@e.com", ts, i)}
+		require.NoError(t, testDB.Create(u).Error)
+		fans = append(fans, u)
+	}
+	author := fans[0]
+
+	newPost := func(title string, age time.Duration, likes, comments int) *models.Post {
+		post := &models.Post{Title: title, Content: "x", UserID: author.ID}
+		require.NoError(t, testDB.Create(post).Error)
+		require.NoError(t, testDB.Model(post).Update("created_at", time.Now().Add(-age)).Error)
+		for i := range likes {
+			require.NoError(t, testDB.Create(&models.Like{UserID: fans[i].ID, PostID: post.ID}).Error)
+		}
+		for range comments {
+			require.NoError(t, testDB.Create(&models.Comment{Content: "c", UserID: author.ID, PostID: post.ID}).Error)
+		}
+		return post
+	}
+
+	engagedRecent := newPost("engaged recent", 2*time.Hour, 5, 3)
+	oldHighScore := newPost("old high score", 7*24*time.Hour, 10, 10)
+	freshQuiet := newPost("fresh quiet", 0, 0, 0)
+
+	rank := func(repo PostRepository) []uint {
+		posts, err := repo.List(ctx, 1000, 0, 0, "hot")
+		require.NoError(t, err)
+		want := map[uint]bool{engagedRecent.ID: true, oldHighScore.ID: true, freshQuiet.ID: true}
+		var ids []uint
+		for _, p := range posts {
+			if want[p.ID] {
+				ids = append(ids, p.ID)
+			}
+		}
+		return ids
+	}
+
+	t.Run("engagement decays with age", func(t *testing.T) {
+		repo := NewPostRepository(testDB)
+		assert.Equal(t, []uint{engagedRecent.ID, freshQuiet.ID, oldHighScore.ID}, rank(repo))
+	})
+
+	t.Run("half-life is configurable", func(t *testing.T) {
+		// With a one-minute half-life two hours of age outweighs any engagement.
+		repo := NewPostRepository(testDB, WithHotHalfLife(time.Minute))
+		assert.Equal(t, []uint{freshQuiet.ID, engagedRecent.ID, oldHighScore.ID}, rank(repo))
+	})
+}
@@ -4,7 +4,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"sanctum/internal/cache"
 	"sanctum/internal/models"
@@ -23,6 +25,9 @@ type UserRepository interface {
 	Delete(ctx context.Context, id uint) error
 	List(ctx context.Context, limit, offset int) ([]models.User, error)
 	Search(ctx context.Context, q string, limit, offset int) ([]models.User, error)
+	SuggestMentions(ctx context.Context, viewerID uint, prefix string, since time.Time, limit, offset int) ([]models.MentionSuggestion, error)
+	Deactivate(ctx context.Context, id uint, at time.Time, restorable bool) error
+	Reactivate(ctx context.Context, id uint) (*models.User, error)
 }
 
 type userRepository struct {
@@ -78,7 +83,11 @@ func (r *userRepository) GetByIDWithPosts(ctx context.Context, id uint, limit in
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := readDB(r.db).WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	// A deactivated account keeps its address reserved until the original is
+	// forgotten, so login can offer reactivation and signup cannot take it.
+	if err := readDB(r.db).WithContext(ctx).
+		Where("email = ? OR (original_email = ? AND deactivated_at IS NOT NULL)", email, email).
+		First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -156,3 +165,131 @@ func (r *userRepository) Search(ctx context.Context, q string, limit, offset int
 	}
 	return users, nil
 }
+
+// mentionSuggestionsSQL ranks users whose username starts with the prefix:
+// an exact match first, then accepted friends of @user, then people who
+// posted since @since in a conversation @user belongs to, then shorter
+// usernames. The viewer, deactivated accounts and anyone on either side of a
+// user block are left out.
+const mentionSuggestionsSQL = `
+SELECT u.id AS user_id,
+	CASE WHEN EXISTS (
+		SELECT 1 FROM friendships f
+		WHERE f.status = @accepted
+			AND ((f.requester_id = @user AND f.addressee_id = u.id) OR (f.addressee_id = @user AND f.requester_id = u.id))
+	) THEN 1 ELSE 0 END AS is_friend,
+	CASE WHEN EXISTS (
+		SELECT 1 FROM messages m
+		JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id AND cp.user_id = @user
+		WHERE m.sender_id = u.id AND m.created_at >= @since
+	) THEN 1 ELSE 0 END AS recently_interacted
+FROM users u
+WHERE u.deleted_at IS NULL
+	AND u.deactivated_at IS NULL
+	AND u.id <> @user
+	AND LOWER(u.username) LIKE @pattern ESCAPE '\'
+	AND u.id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = @user)
+	AND u.id NOT IN (SELECT blocker_id FROM user_blocks WHERE blocked_id = @user)
+ORDER BY CASE WHEN LOWER(u.username) = @prefix THEN 0 ELSE 1 END,
+	is_friend DESC, recently_interacted DESC, LENGTH(u.username), u.username
+LIMIT @limit OFFSET @offset`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SuggestMentions returns @-autocomplete candidates for viewerID whose
+// username starts with prefix. prefix must already be lower-case.
+func (r *userRepository) SuggestMentions(ctx context.Context, viewerID uint, prefix string, since time.Time, limit, offset int) ([]models.MentionSuggestion, error) {
+	var rows []struct {
+		UserID             uint
+		IsFriend           bool
+		RecentlyInteracted bool
+	}
+	if err := readDB(r.db).WithContext(ctx).Raw(mentionSuggestionsSQL, map[string]interface{}{
+		"user":     viewerID,
+		"accepted": models.FriendshipStatusAccepted,
+		"since":    since,
+		"prefix":   prefix,
+		"pattern":  likeEscaper.Replace(prefix) + "%",
+		"limit":    limit,
+		"offset":   offset,
+	}).Scan(&rows).Error; err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	if len(rows) == 0 {
+		return []models.MentionSuggestion{}, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.UserID)
+	}
+	var users []models.User
+	if err := readDB(r.db).WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	byID := make(map[uint]models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	suggestions := make([]models.MentionSuggestion, 0, len(rows))
+	for _, row := range rows {
+		if u, ok := byID[row.UserID]; ok {
+			suggestions = append(suggestions, models.MentionSuggestion{
+				User:               u,
+				IsFriend:           row.IsFriend,
+				RecentlyInteracted: row.RecentlyInteracted,
+			})
+		}
+	}
+	return suggestions, nil
+}
+
+// Deactivate anonymizes the account's username and email. When restorable,
+// the originals are kept so the account can be reactivated.
+func (r *userRepository) Deactivate(ctx context.Context, id uint, at time.Time, restorable bool) error {
+	updates := map[string]interface{}{
+		"username":       fmt.Sprintf("deleted_user_%d", id),
+		"email":          fmt.Sprintf("deleted_user_%d@deleted.invalid", id),
+		"deactivated_at": at,
+	}
+	if restorable {
+		updates["original_username"] = gorm.Expr("username")
+		updates["original_email"] = gorm.Expr("email")
+	}
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND deactivated_at IS NULL", id).
+		Updates(updates)
+	if result.Error != nil {
+		return models.NewInternalError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.NewNotFoundError("User", id)
+	}
+	cache.InvalidateUser(ctx, id)
+	return nil
+}
+
+// Reactivate restores a deactivated account's email and, unless someone has
+// claimed it in the meantime, its username.
+func (r *userRepository) Reactivate(ctx context.Context, id uint) (*models.User, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND deactivated_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"email": gorm.Expr("original_email"),
+			"username": gorm.Expr(`CASE WHEN original_username <> '' AND NOT EXISTS (
+				SELECT 1 FROM users other WHERE other.username = users.original_username AND other.id <> users.id
+			) THEN original_username ELSE username END`),
+			"original_username": "",
+			"original_email":    "",
+			"deactivated_at":    nil,
+		})
+	if result.Error != nil {
+		return nil, models.NewInternalError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, models.NewNotFoundError("User", id)
+	}
+	cache.InvalidateUser(ctx, id)
+	return r.GetByID(ctx, id)
+}
@@ -0,0 +1,196 @@
+package seed
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gameWinPoints mirrors the points the game hub awards a winner.
+var gameWinPoints = map[models.GameType]int{
+	models.ConnectFour: 15,
+	models.Othello:     25,
+	models.Battleship:  30,
+}
+
+// seedVariedGames adds Battleship rooms mid-setup and mid-battle, an Othello
+// room a few moves in, and finished games won by the creator.
+func (s *Seeder) seedVariedGames(r *rand.Rand, users []*models.User) error {
+	if len(users) < 2 {
+		return fmt.Errorf("seeding varied games needs at least 2 users, got %d", len(users))
+	}
+	log.Println("🎲 Seeding varied game states...")
+
+	pickPair := func() (*models.User, *models.User) {
+		creator := users[r.Intn(len(users))]
+		opponent := users[r.Intn(len(users))]
+		for creator.ID == opponent.ID {
+			opponent = users[r.Intn(len(users))]
+		}
+		return creator, opponent
+	}
+	create := func(gType models.GameType, status models.GameStatus, state interface{}) (*models.GameRoom, error) {
+		creator, opponent := pickPair()
+		return s.factory.CreateGame(creator, gType, status, func(g *models.GameRoom) {
+			oID := opponent.ID
+			g.OpponentID = &oID
+			g.NextTurnID = creator.ID
+			g.SetState(state)
+			if status == models.GameFinished {
+				g.WinnerID = g.CreatorID
+			}
+		})
+	}
+
+	// Creator has placed ships; opponent is still arranging theirs.
+	setup := models.InitialBattleshipState()
+	setup.CreatorReady = true
+	setup.CreatorShips = seedBattleshipFleet(0)
+	if _, err := create(models.Battleship, models.GameActive, setup); err != nil {
+		return err
+	}
+
+	battle := seedBattleshipBattle()
+	battle.CreatorShots = [][2]int{{5, 0}, {5, 1}, {0, 9}}
+	battle.OpponentShots = [][2]int{{0, 0}, {9, 9}}
+	if _, err := create(models.Battleship, models.GameActive, battle); err != nil {
+		return err
+	}
+
+	if _, err := create(models.Othello, models.GameActive, seedOthelloOpening()); err != nil {
+		return err
+	}
+
+	finished := []struct {
+		gType models.GameType
+		state interface{}
+	}{
+		{models.ConnectFour, seedConnectFourWin()},
+		{models.Othello, seedOthelloWin()},
+		{models.Battleship, seedBattleshipWin()},
+	}
+	for _, f := range finished {
+		for i := 0; i < 2; i++ {
+			game, err := create(f.gType, models.GameFinished, f.state)
+			if err != nil {
+				return err
+			}
+			if err := s.recordGameResult(game); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordGameResult credits a finished game to both players' GameStats the
+// same way the game hub does.
+func (s *Seeder) recordGameResult(game *models.GameRoom) error {
+	points := gameWinPoints[game.Type]
+	winRating, lossRating := models.EloRatings(
+		s.gameRating(*game.WinnerID, game.Type), s.gameRating(*game.OpponentID, game.Type), 1)
+	win := models.GameStats{UserID: *game.WinnerID, GameType: game.Type, Wins: 1, TotalGames: 1, Points: points, Rating: winRating}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"points":      gorm.Expr("game_stats.points + ?", points),
+			"wins":        gorm.Expr("game_stats.wins + ?", 1),
+			"total_games": gorm.Expr("game_stats.total_games + ?", 1),
+			"rating":      winRating,
+		}),
+	}).Create(&win).Error; err != nil {
+		return err
+	}
+
+	loss := models.GameStats{UserID: *game.OpponentID, GameType: game.Type, Losses: 1, TotalGames: 1, Rating: lossRating}
+	return s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "game_type"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"losses":      gorm.Expr("game_stats.losses + ?", 1),
+			"total_games": gorm.Expr("game_stats.total_games + ?", 1),
+			"rating":      lossRating,
+		}),
+	}).Create(&loss).Error
+}
+
+func (s *Seeder) gameRating(userID uint, gameType models.GameType) int {
+	var stats models.GameStats
+	if err := s.db.Select("rating").Where("user_id = ? AND game_type = ?", userID, gameType).Take(&stats).Error; err != nil || stats.Rating == 0 {
+		return models.DefaultGameRating
+	}
+	return stats.Rating
+}
+
+// seedBattleshipFleet returns the standard fleet laid out horizontally in
+// rows firstRow..firstRow+4.
+func seedBattleshipFleet(firstRow int) []models.BattleshipShip {
+	return []models.BattleshipShip{
+		{Name: "Carrier", Size: 5, Row: firstRow, Col: 0, Horizontal: true},
+		{Name: "Battleship", Size: 4, Row: firstRow + 1, Col: 0, Horizontal: true},
+		{Name: "Cruiser", Size: 3, Row: firstRow + 2, Col: 0, Horizontal: true},
+		{Name: "Submarine", Size: 3, Row: firstRow + 3, Col: 0, Horizontal: true},
+		{Name: "Destroyer", Size: 2, Row: firstRow + 4, Col: 0, Horizontal: true},
+	}
+}
+
+func seedBattleshipBattle() models.BattleshipState {
+	state := models.InitialBattleshipState()
+	state.Phase = "battle"
+	state.CreatorReady = true
+	state.OpponentReady = true
+	state.CreatorShips = seedBattleshipFleet(0)
+	state.OpponentShips = seedBattleshipFleet(5)
+	return state
+}
+
+// seedBattleshipWin is a battle in which the creator has sunk every ship.
+func seedBattleshipWin() models.BattleshipState {
+	state := seedBattleshipBattle()
+	for _, ship := range state.OpponentShips {
+		for i := 0; i < ship.Size; i++ {
+			state.CreatorShots = append(state.CreatorShots, [2]int{ship.Row, ship.Col + i})
+		}
+	}
+	state.OpponentShots = append(state.OpponentShots, [2]int{0, 0}, [2]int{9, 9})
+	return state
+}
+
+// seedOthelloOpening is the board after X plays d3 and O replies c3.
+func seedOthelloOpening() [8][8]string {
+	board := models.InitialOthelloBoard()
+	board[2][3] = "X"
+	board[2][2] = "O"
+	board[3][3] = "O"
+	return board
+}
+
+// seedOthelloWin is a full board with X holding the majority.
+func seedOthelloWin() [8][8]string {
+	var board [8][8]string
+	for row := range board {
+		for col := range board[row] {
+			board[row][col] = "X"
+			if row >= 5 {
+				board[row][col] = "O"
+			}
+		}
+	}
+	return board
+}
+
+// seedConnectFourWin has X completing a vertical four in the middle column.
+func seedConnectFourWin() [6][7]string {
+	var board [6][7]string
+	for row := 2; row < 6; row++ {
+		board[row][3] = "X"
+	}
+	board[5][2] = "O"
+	board[5][4] = "O"
+	board[4][4] = "O"
+	return board
+}
@@ -0,0 +1,106 @@
+package seed
+
+import (
+	"testing"
+
+	"sanctum/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupGameSeedDB(t *testing.T) (*gorm.DB, []*models.User) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.GameRoom{}, &models.GameStats{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	users := []*models.User{
+		{Username: "alice", Email: "alice@example.com"},
+		{Username: "bob", Email: "bob@example.com"},
+		{Username: "carol", Email: "carol@example.com"},
+	}
+	for _, u := range users {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	return db, users
+}
+
+func TestSeedActiveGames_VariedGames(t *testing.T) {
+	db, users := setupGameSeedDB(t)
+	seeder := NewSeeder(db, Options{SkipBcrypt: true, VariedGames: true})
+	if err := seeder.SeedActiveGames(users); err != nil {
+		t.Fatalf("seed games: %v", err)
+	}
+
+	var rooms []models.GameRoom
+	if err := db.Find(&rooms).Error; err != nil {
+		t.Fatalf("load rooms: %v", err)
+	}
+	phases := map[string]int{}
+	finished := map[models.GameType]int{}
+	othelloMidGame := 0
+	for i := range rooms {
+		room := &rooms[i]
+		if room.Status == models.GameFinished {
+			finished[room.Type]++
+			if room.WinnerID == nil {
+				t.Errorf("finished %s room %d has no winner", room.Type, room.ID)
+			}
+			if _, won := room.CheckWin(); !won {
+				t.Errorf("finished %s room %d state has no winner: %s", room.Type, room.ID, room.CurrentState)
+			}
+		}
+		if room.Type == models.Battleship {
+			phases[room.GetBattleshipState().Phase]++
+		}
+		if room.Type == models.Othello && room.Status == models.GameActive && room.CurrentState != "" {
+			if room.GetOthelloState() == models.InitialOthelloBoard() {
+				t.Errorf("mid-game Othello room %d still has the opening board", room.ID)
+			}
+			othelloMidGame++
+		}
+	}
+	if phases["setup"] == 0 || phases["battle"] == 0 {
+		t.Errorf("expected Battleship rooms in setup and battle, got %v", phases)
+	}
+	if othelloMidGame == 0 {
+		t.Error("expected a mid-game Othello room")
+	}
+	for _, gType := range []models.GameType{models.ConnectFour, models.Othello, models.Battleship} {
+		if finished[gType] == 0 {
+			t.Errorf("expected finished %s games", gType)
+		}
+	}
+
+	var stats []models.GameStats
+	if err := db.Find(&stats).Error; err != nil {
+		t.Fatalf("load stats: %v", err)
+	}
+	wins := 0
+	for _, st := range stats {
+		wins += st.Wins
+	}
+	if wins != 6 {
+		t.Errorf("expected 6 recorded wins, got %d", wins)
+	}
+}
+
+func TestSeedActiveGames_DefaultSkipsVariedGames(t *testing.T) {
+	db, users := setupGameSeedDB(t)
+	if err := NewSeeder(db, Options{SkipBcrypt: true}).SeedActiveGames(users); err != nil {
+		t.Fatalf("seed games: %v", err)
+	}
+
+	var battleships, statsRows int64
+	db.Model(&models.GameRoom{}).Where("type = ?", models.Battleship).Count(&battleships)
+	db.Model(&models.GameStats{}).Count(&statsRows)
+	if battleships != 0 || statsRows != 0 {
+		t.Fatalf("expected no varied games without the option, got %d battleship rooms and %d stats rows", battleships, statsRows)
+	}
+}
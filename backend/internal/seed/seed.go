@@ -31,6 +31,9 @@ type Options struct {
 	Fast       bool
 	// MaxDays controls how far back CreatedAt may be spread
 	MaxDays int
+	// VariedGames makes SeedActiveGames also seed Battleship and Othello rooms
+	// mid-game plus finished games with winners, so GameStats is populated.
+	VariedGames bool
 }
 
 // Distribution describes fractional weights for post types.
@@ -487,6 +490,9 @@ func (s *Seeder) SeedActiveGames(users []*models.User) error {
 		})
 	}
 
+	if s.factory.opts.VariedGames {
+		return s.seedVariedGames(r, users)
+	}
 	return nil
 }
 
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// adminAuditExportBatchSize is how many entries an export reads and flushes
+// at a time.
+const adminAuditExportBatchSize = 500
+
+// adminAuditExportColumns is the CSV header of an audit log export.
+var adminAuditExportColumns = []string{
+	"id", "created_at", "actor_user_id", "action", "target_type", "target_id", "before", "after",
+}
+
+// recordAdminAction writes an audit row using tx, so it commits or rolls back
+// together with the mutation it describes. before and after are JSON-encoded
+// snapshots of the target; either may be nil.
+func recordAdminAction(tx *gorm.DB, actorID uint, action, targetType string, targetID uint, before, after interface{}) error {
+	entry := models.AdminAuditLog{
+		ActorUserID: actorID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+	}
+	var err error
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	return tx.Create(&entry).Error
+}
+
+// userBanSnapshot is the audited part of a user for ban and unban.
+type userBanSnapshot struct {
+	IsBanned       bool       `json:"is_banned"`
+	BannedAt       *time.Time `json:"banned_at,omitempty"`
+	BannedReason   string     `json:"banned_reason,omitempty"`
+	BannedByUserID *uint      `json:"banned_by_user_id,omitempty"`
+	BanExpiresAt   *time.Time `json:"ban_expires_at,omitempty"`
+}
+
+func snapshotUserBan(u *models.User) userBanSnapshot {
+	return userBanSnapshot{
+		IsBanned:       u.IsBanned,
+		BannedAt:       u.BannedAt,
+		BannedReason:   u.BannedReason,
+		BannedByUserID: u.BannedByUserID,
+		BanExpiresAt:   u.BanExpiresAt,
+	}
+}
+
+// reportResolutionSnapshot is the audited part of a moderation report.
+type reportResolutionSnapshot struct {
+	Status           string `json:"status"`
+	ResolutionNote   string `json:"resolution_note,omitempty"`
+	ResolvedByUserID *uint  `json:"resolved_by_user_id,omitempty"`
+}
+
+func snapshotReportResolution(r *models.ModerationReport) reportResolutionSnapshot {
+	return reportResolutionSnapshot{
+		Status:           r.Status,
+		ResolutionNote:   r.ResolutionNote,
+		ResolvedByUserID: r.ResolvedByUserID,
+	}
+}
+
+// sanctumRequestReviewSnapshot is the audited part of a sanctum request.
+type sanctumRequestReviewSnapshot struct {
+	Status           models.SanctumRequestStatus `json:"status"`
+	ReviewNotes      string                      `json:"review_notes,omitempty"`
+	ReviewedByUserID *uint                       `json:"reviewed_by_user_id,omitempty"`
+	SanctumID        *uint                       `json:"sanctum_id,omitempty"`
+}
+
+func snapshotSanctumRequestReview(r *models.SanctumRequest) sanctumRequestReviewSnapshot {
+	return sanctumRequestReviewSnapshot{
+		Status:           r.Status,
+		ReviewNotes:      r.ReviewNotes,
+		ReviewedByUserID: r.ReviewedByUserID,
+	}
+}
+
+// GetAdminAuditLog handles GET /api/admin/audit-log.
+// @Summary List admin audit log
+// @Description List admin actions, newest first. Filter by action, actor_id, or target_type and target_id.
+// @Tags moderation-admin
+// @Produce json
+// @Param action query string false "Filter by action, e.g. user.ban"
+// @Param actor_id query int false "Filter by acting admin"
+// @Param target_type query string false "Filter by target type"
+// @Param target_id query int false "Filter by target ID"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {object} object{entries=[]models.AdminAuditLog,total=int}
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/audit-log [get]
+func (s *Server) GetAdminAuditLog(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	page := parsePagination(c, 50)
+
+	query := s.db.WithContext(ctx).Model(&models.AdminAuditLog{})
+	if action := strings.TrimSpace(c.Query("action")); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if actorID := c.QueryInt("actor_id", 0); actorID > 0 {
+		query = query.Where("actor_user_id = ?", actorID)
+	}
+	if targetType := strings.TrimSpace(c.Query("target_type")); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := c.QueryInt("target_id", 0); targetID > 0 {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	entries := []models.AdminAuditLog{}
+	if err := query.
+		Preload("Actor").
+		Order("created_at DESC").
+		Order("id DESC").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&entries).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": entries,
+		"total":   total,
+	})
+}
+
+// auditExportSnapshot is what an audit log export records about itself.
+type auditExportSnapshot struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Format  string    `json:"format"`
+	Entries int64     `json:"entries"`
+}
+
+// parseAuditExportTime accepts an RFC 3339 timestamp or a YYYY-MM-DD date,
+// which is read as midnight UTC.
+func parseAuditExportTime(raw string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return parsed.UTC(), nil
+	}
+	return time.Parse(analyticsDateLayout, raw)
+}
+
+// ExportAdminAuditLog handles GET /api/admin/audit/export.
+// @Summary Export admin audit log
+// @Description Stream every audit entry created in [from, to) as CSV or JSON, oldest first. The export itself is recorded in the audit log.
+// @Tags moderation-admin
+// @Produce json
+// @Produce text/csv
+// @Param from query string true "Inclusive start, RFC 3339 or YYYY-MM-DD"
+// @Param to query string true "Exclusive end, RFC 3339 or YYYY-MM-DD"
+// @Param format query string false "csv (default) or json"
+// @Success 200 {array} models.AdminAuditLog
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/audit/export [get]
+func (s *Server) ExportAdminAuditLog(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	adminID := c.Locals("userID").(uint)
+
+	from, err := parseAuditExportTime(strings.TrimSpace(c.Query("from")))
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("from must be an RFC 3339 timestamp or a YYYY-MM-DD date"))
+	}
+	to, err := parseAuditExportTime(strings.TrimSpace(c.Query("to")))
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("to must be an RFC 3339 timestamp or a YYYY-MM-DD date"))
+	}
+	if !from.Before(to) {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("from must be before to"))
+	}
+	format := strings.ToLower(strings.TrimSpace(c.Query("format", "csv")))
+	if format != "csv" && format != "json" {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("format must be csv or json"))
+	}
+
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&models.AdminAuditLog{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Count(&total).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	// Record the export before any entry leaves the server.
+	if err := recordAdminAction(s.db.WithContext(ctx), adminID, models.AuditActionAuditLogExport, "audit_log", 0, nil,
+		auditExportSnapshot{From: from, To: to, Format: format, Entries: total}); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	parent := s.shutdownCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	db := s.db
+	c.Attachment(fmt.Sprintf("admin-audit-%s-%s.%s",
+		from.Format(analyticsDateLayout), to.Format(analyticsDateLayout), format))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := writeAdminAuditExport(parent, db, w, from, to, format); err != nil {
+			log.Printf("admin audit export by user %d failed: %v", adminID, err)
+		}
+	})
+	return nil
+}
+
+// writeAdminAuditExport streams entries created in [from, to) to w in id
+// order, flushing after every batch so large ranges are never held in memory.
+func writeAdminAuditExport(ctx context.Context, db *gorm.DB, w *bufio.Writer, from, to time.Time, format string) error {
+	var csvWriter *csv.Writer
+	if format == "csv" {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(adminAuditExportColumns); err != nil {
+			return err
+		}
+	} else if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+
+	written := 0
+	var batch []models.AdminAuditLog
+	err := db.WithContext(ctx).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		FindInBatches(&batch, adminAuditExportBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, entry := range batch {
+				if csvWriter != nil {
+					if err := csvWriter.Write(adminAuditExportRecord(entry)); err != nil {
+						return err
+					}
+					continue
+				}
+				encoded, err := json.Marshal(entry)
+				if err != nil {
+					return err
+				}
+				if written > 0 {
+					if err := w.WriteByte(','); err != nil {
+						return err
+					}
+				}
+				if _, err := w.Write(encoded); err != nil {
+					return err
+				}
+				written++
+			}
+			if csvWriter != nil {
+				csvWriter.Flush()
+				if err := csvWriter.Error(); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+	} else if _, err := w.WriteString("]\n"); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func adminAuditExportRecord(entry models.AdminAuditLog) []string {
+	return []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.FormatUint(uint64(entry.ActorUserID), 10),
+		entry.Action,
+		entry.TargetType,
+		strconv.FormatUint(uint64(entry.TargetID), 10),
+		string(entry.Before),
+		string(entry.After),
+	}
+}
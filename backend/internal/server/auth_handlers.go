@@ -11,14 +11,20 @@ import (
 	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/service"
 	"sanctum/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// refreshTokenTTL is how long a refresh token, and the family it extends,
+// stays valid.
+const refreshTokenTTL = 7 * 24 * time.Hour
+
 // Signup handles POST /api/auth/signup
 // @Summary User signup
 // @Description Register a new user account
@@ -70,6 +76,18 @@ func (s *Server) Signup(c *fiber.Ctx) error {
 			models.NewValidationError(err.Error()))
 	}
 
+	// Banned users should not be able to come back under a new email.
+	ip := c.IP()
+	deviceHash := clientDeviceHash(c)
+	banned, err := s.signupBanned(c.Context(), ip, deviceHash)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if banned {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewForbiddenError("Signups from this network are not allowed"))
+	}
+
 	// Check if user already exists
 	existing, err := s.userRepo.GetByEmail(c.Context(), req.Email)
 	if err != nil {
@@ -89,9 +107,11 @@ func (s *Server) Signup(c *fiber.Ctx) error {
 
 	// Create user
 	user := &models.User{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: string(hashedPassword),
+		Username:   req.Username,
+		Email:      req.Email,
+		Password:   string(hashedPassword),
+		LastIP:     ip,
+		DeviceHash: deviceHash,
 	}
 
 	if createErr := s.userRepo.Create(c.Context(), user); createErr != nil {
@@ -104,14 +124,20 @@ func (s *Server) Signup(c *fiber.Ctx) error {
 	}
 	s.maybeSendWelcomeSignupDM(c.Context(), user.ID)
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(user.ID, user.Username)
+	// Generate tokens for a new session
+	sessionID := s.generateJTI()
+	if err := s.startSession(c, user.ID, sessionID); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError,
+			models.NewInternalError(err))
+	}
+
+	accessToken, err := s.generateAccessToken(user.ID, user.Username, sessionID)
 	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError,
 			models.NewInternalError(err))
 	}
 
-	refreshToken, err := s.generateRefreshToken(user.ID)
+	refreshToken, err := s.generateRefreshToken(user.ID, sessionID)
 	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError,
 			models.NewInternalError(err))
@@ -130,9 +156,9 @@ func (s *Server) setRefreshTokenCookie(c *fiber.Ctx, token string) {
 	c.Cookie(&fiber.Cookie{
 		Name:     "refresh_token",
 		Value:    token,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
+		Expires:  time.Now().Add(refreshTokenTTL),
 		HTTPOnly: true,
-		Secure:   s.config.Env == "production" || s.config.Env == "prod",
+		Secure:   s.config.Profile().Production,
 		SameSite: "Lax",
 		Path:     "/api/auth",
 	})
@@ -153,6 +179,8 @@ func (s *Server) Login(c *fiber.Ctx) error {
 	var req struct {
 		Email    string `json:"email"`
 		Password string `json:"password"` // #nosec G117 -- API request body field
+		// Reactivate restores a deactivated account inside its grace window.
+		Reactivate bool `json:"reactivate"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		// Mirror Refresh behavior: ignore parse error when the body is empty.
@@ -172,7 +200,7 @@ func (s *Server) Login(c *fiber.Ctx) error {
 		return models.RespondWithError(c, fiber.StatusUnauthorized,
 			models.NewUnauthorizedError("Invalid credentials"))
 	}
-	if user.IsBanned {
+	if user.IsBanActive(time.Now()) {
 		return models.RespondWithError(c, fiber.StatusForbidden,
 			models.NewForbiddenError("Account is banned"))
 	}
@@ -183,14 +211,42 @@ func (s *Server) Login(c *fiber.Ctx) error {
 			models.NewUnauthorizedError("Invalid credentials"))
 	}
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(user.ID, user.Username)
+	// Deactivated accounts can only log in to reactivate, and only while the
+	// grace window is open; afterwards they behave like unknown accounts.
+	if user.IsDeactivated() {
+		grace := s.reactivationGrace()
+		if !service.CanReactivate(user, grace, time.Now()) {
+			return models.RespondWithError(c, fiber.StatusUnauthorized,
+				models.NewUnauthorizedError("Invalid credentials"))
+		}
+		if !req.Reactivate {
+			return models.RespondWithError(c, fiber.StatusForbidden,
+				models.NewForbiddenError("Account is deactivated; log in with reactivate to restore it"))
+		}
+		user, err = s.userSvc().ReactivateAccount(c.Context(), user, grace, time.Now())
+		if err != nil {
+			return models.RespondWithError(c, mapServiceError(err), err)
+		}
+	}
+
+	if err := s.recordClientFingerprint(c.Context(), user.ID, c.IP(), clientDeviceHash(c)); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	// Generate tokens for a new session
+	sessionID := s.generateJTI()
+	if err := s.startSession(c, user.ID, sessionID); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError,
+			models.NewInternalError(err))
+	}
+
+	accessToken, err := s.generateAccessToken(user.ID, user.Username, sessionID)
 	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError,
 			models.NewInternalError(err))
 	}
 
-	refreshToken, err := s.generateRefreshToken(user.ID)
+	refreshToken, err := s.generateRefreshToken(user.ID, sessionID)
 	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError,
 			models.NewInternalError(err))
@@ -284,25 +340,52 @@ func (s *Server) Refresh(c *fiber.Ctx) error {
 			models.NewUnauthorizedError("Invalid JTI claim"))
 	}
 
+	// Tokens issued before families existed act as their own family.
+	family, _ := claims["fam"].(string)
+	if family == "" {
+		family = jti
+	}
+
 	if s.redis != nil {
-		redisKey := fmt.Sprintf("refresh_token:%d:%s", userID, jti)
-		exists, errExists := s.redis.Exists(c.Context(), redisKey).Result()
-		if errExists != nil {
-			return models.RespondWithError(c, fiber.StatusInternalServerError,
-				models.NewInternalError(errExists))
-		}
-		if exists == 0 {
+		// Refresh token rotation: consuming the live key atomically means a
+		// token can only ever be exchanged once.
+		redisKey := refreshTokenKey(userID, jti)
+		_, errDel := s.redis.GetDel(c.Context(), redisKey).Result()
+		if errors.Is(errDel, redis.Nil) {
+			used, errUsed := s.redis.Exists(c.Context(), refreshTokenUsedKey(jti)).Result()
+			if errUsed != nil {
+				return models.RespondWithError(c, fiber.StatusInternalServerError,
+					models.NewInternalError(errUsed))
+			}
+			if used > 0 {
+				// A rotated token came back: assume it was stolen and cut
+				// off every token descended from the same login.
+				if errRevoke := s.revokeRefreshTokenFamily(c.Context(), userID, family); errRevoke != nil {
+					return models.RespondWithError(c, fiber.StatusInternalServerError,
+						models.NewInternalError(errRevoke))
+				}
+				c.ClearCookie("refresh_token")
+				return models.RespondWithError(c, fiber.StatusUnauthorized,
+					models.NewUnauthorizedError("Refresh token reuse detected, please log in again"))
+			}
 			return models.RespondWithError(c, fiber.StatusUnauthorized,
 				models.NewUnauthorizedError("Refresh token revoked or already used"))
 		}
-
-		// Refresh token rotation: Revoke current one. If deletion fails,
-		// reject the request to prevent issuing new tokens while the old
-		// refresh token remains valid.
-		if errDel := s.redis.Del(c.Context(), redisKey).Err(); errDel != nil {
+		if errDel != nil {
 			return models.RespondWithError(c, fiber.StatusInternalServerError,
 				models.NewInternalError(fmt.Errorf("failed to revoke refresh token: %w", errDel)))
 		}
+
+		ttl := refreshTokenTTL
+		if exp, okExp := claims["exp"].(float64); okExp {
+			ttl = time.Until(time.Unix(int64(exp), 0))
+		}
+		if ttl > 0 {
+			if errUsed := s.redis.Set(c.Context(), refreshTokenUsedKey(jti), family, ttl).Err(); errUsed != nil {
+				return models.RespondWithError(c, fiber.StatusInternalServerError,
+					models.NewInternalError(fmt.Errorf("failed to record used refresh token: %w", errUsed)))
+			}
+		}
 	}
 
 	// Get user to ensure they still exist and get username
@@ -316,13 +399,13 @@ func (s *Server) Refresh(c *fiber.Ctx) error {
 	}
 
 	// Generate new tokens
-	newAccessToken, err := s.generateAccessToken(user.ID, user.Username)
+	newAccessToken, err := s.generateAccessToken(user.ID, user.Username, family)
 	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError,
 			models.NewInternalError(err))
 	}
 
-	newRefreshToken, err := s.generateRefreshToken(user.ID)
+	newRefreshToken, err := s.generateRefreshToken(user.ID, family)
 	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError,
 			models.NewInternalError(err))
@@ -354,30 +437,7 @@ func (s *Server) Logout(c *fiber.Ctx) error {
 			models.NewValidationError("Invalid request body"))
 	}
 
-	// Blacklist the access token if provided in Authorization header
-	authHeader := c.Get("Authorization")
-	if authHeader != "" {
-		parts := strings.Split(authHeader, " ")
-		if len(parts) == 2 && parts[0] == "Bearer" {
-			accessToken := parts[1]
-			token, err := jwt.Parse(accessToken, func(_ *jwt.Token) (interface{}, error) {
-				return []byte(s.config.JWTSecret), nil
-			})
-			if err == nil && token.Valid {
-				if claims, ok := token.Claims.(jwt.MapClaims); ok {
-					if jti, ok := claims["jti"].(string); ok && s.redis != nil {
-						// Extract expiration to set TTL for blacklist
-						if exp, ok := claims["exp"].(float64); ok {
-							ttl := time.Until(time.Unix(int64(exp), 0))
-							if ttl > 0 {
-								s.redis.Set(c.Context(), "blacklist:"+jti, "1", ttl)
-							}
-						}
-					}
-				}
-			}
-		}
-	}
+	s.blacklistAccessToken(c)
 
 	if req.RefreshToken == "" {
 		req.RefreshToken = c.Cookies("refresh_token")
@@ -399,11 +459,14 @@ func (s *Server) Logout(c *fiber.Ctx) error {
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
 			sub, _ := claims["sub"].(string)
 			jti, _ := claims["jti"].(string)
+			family, _ := claims["fam"].(string)
 			userID, _ := strconv.ParseUint(sub, 10, 32)
 
 			if s.redis != nil && jti != "" {
-				redisKey := fmt.Sprintf("refresh_token:%d:%s", userID, jti)
-				s.redis.Del(c.Context(), redisKey)
+				s.redis.Del(c.Context(), refreshTokenKey(uint(userID), jti))
+			}
+			if s.redis != nil && family != "" {
+				_ = s.revokeSession(c.Context(), uint(userID), family)
 			}
 		}
 	}
@@ -411,46 +474,103 @@ func (s *Server) Logout(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Logged out successfully"})
 }
 
-// generateAccessToken creates a short-lived JWT token for the given user ID and username
-func (s *Server) generateAccessToken(userID uint, username string) (string, error) {
+// generateAccessToken creates a short-lived JWT token for the given user ID
+// and username. A non-empty sessionID ties the token to that session.
+func (s *Server) generateAccessToken(userID uint, username, sessionID string) (string, error) {
 	// Validate secret exists
 	if s.config.JWTSecret == "" {
 		return "", fmt.Errorf("JWT secret not configured")
 	}
 
 	now := time.Now()
+	jti := s.generateJTI()
 	claims := jwt.MapClaims{
 		"sub":      strconv.FormatUint(uint64(userID), 10), // Subject (user ID as string)
 		"username": username,                               // Username (cached in token)
-		"iss":      "sanctum-api",                          // Issuer
-		"aud":      "sanctum-client",                       // Audience
-		"exp":      now.Add(15 * time.Minute).Unix(),       // Expiration (15 minutes)
+		"iss":      s.config.JWTIssuerList()[0],            // Issuer
+		"aud":      s.config.JWTAudienceList()[0],          // Audience
+		"exp":      now.Add(accessTokenTTL).Unix(),         // Expiration (15 minutes)
 		"iat":      now.Unix(),                             // Issued at
 		"nbf":      now.Unix(),                             // Not before
-		"jti":      s.generateJTI(),                        // JWT ID (unique identifier)
+		"jti":      jti,                                    // JWT ID (unique identifier)
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWTSecret))
+	signedToken, err := token.SignedString([]byte(s.config.JWTSecret))
+	if err != nil {
+		return "", err
+	}
+
+	if s.redis != nil && sessionID != "" {
+		if err := s.touchSession(context.Background(), userID, sessionID); err != nil {
+			return "", fmt.Errorf("failed to update session: %w", err)
+		}
+	}
+	return signedToken, nil
+}
+
+// bearerToken returns the token from the Authorization header, if any.
+func bearerToken(c *fiber.Ctx) string {
+	parts := strings.Split(c.Get("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		return parts[1]
+	}
+	return ""
+}
+
+// blacklistAccessToken revokes the request's bearer token until it expires.
+func (s *Server) blacklistAccessToken(c *fiber.Ctx) {
+	accessToken := bearerToken(c)
+	if accessToken == "" || s.redis == nil {
+		return
+	}
+	token, err := jwt.Parse(accessToken, func(_ *jwt.Token) (interface{}, error) {
+		return []byte(s.config.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return
+	}
+	// Extract expiration to set TTL for blacklist
+	if exp, ok := claims["exp"].(float64); ok {
+		ttl := time.Until(time.Unix(int64(exp), 0))
+		if ttl > 0 {
+			s.redis.Set(c.Context(), "blacklist:"+jti, "1", ttl)
+		}
+	}
 }
 
 // generateRefreshToken creates a long-lived JWT token for the given user ID
-func (s *Server) generateRefreshToken(userID uint) (string, error) {
+func (s *Server) generateRefreshToken(userID uint, family string) (string, error) {
 	if s.config.JWTSecret == "" {
 		return "", fmt.Errorf("JWT secret not configured")
 	}
 
 	now := time.Now()
 	jti := s.generateJTI()
-	expiresAt := now.Add(7 * 24 * time.Hour)
+	if family == "" {
+		family = jti
+	}
+	expiresAt := now.Add(refreshTokenTTL)
 
 	claims := jwt.MapClaims{
 		"sub": strconv.FormatUint(uint64(userID), 10),
-		"iss": "sanctum-api",
+		"iss": s.config.JWTIssuerList()[0],
 		"aud": "sanctum-refresh",
 		"exp": expiresAt.Unix(),
 		"iat": now.Unix(),
 		"jti": jti,
+		"fam": family,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -459,11 +579,16 @@ func (s *Server) generateRefreshToken(userID uint) (string, error) {
 		return "", err
 	}
 
-	// Store JTI in Redis
+	// Store JTI in Redis, and track it under its family so a detected reuse
+	// can revoke the live descendant.
 	if s.redis != nil {
-		redisKey := fmt.Sprintf("refresh_token:%d:%s", userID, jti)
-		err = s.redis.Set(context.Background(), redisKey, "1", 7*24*time.Hour).Err()
-		if err != nil {
+		ctx := context.Background()
+		familyKey := refreshTokenFamilyKey(userID, family)
+		pipe := s.redis.TxPipeline()
+		pipe.Set(ctx, refreshTokenKey(userID, jti), family, refreshTokenTTL)
+		pipe.SAdd(ctx, familyKey, jti)
+		pipe.Expire(ctx, familyKey, refreshTokenTTL)
+		if _, err = pipe.Exec(ctx); err != nil {
 			return "", fmt.Errorf("failed to store refresh token: %w", err)
 		}
 	}
@@ -471,6 +596,34 @@ func (s *Server) generateRefreshToken(userID uint) (string, error) {
 	return signedToken, err
 }
 
+// revokeRefreshTokenFamily deletes every live refresh token issued in the
+// family, forcing the user to log in again on all of its descendants.
+func (s *Server) revokeRefreshTokenFamily(ctx context.Context, userID uint, family string) error {
+	familyKey := refreshTokenFamilyKey(userID, family)
+	jtis, err := s.redis.SMembers(ctx, familyKey).Result()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(jtis)+1)
+	for _, jti := range jtis {
+		keys = append(keys, refreshTokenKey(userID, jti))
+	}
+	keys = append(keys, familyKey)
+	return s.redis.Del(ctx, keys...).Err()
+}
+
+func refreshTokenKey(userID uint, jti string) string {
+	return fmt.Sprintf("refresh_token:%d:%s", userID, jti)
+}
+
+func refreshTokenUsedKey(jti string) string {
+	return "refresh_token_used:" + jti
+}
+
+func refreshTokenFamilyKey(userID uint, family string) string {
+	return fmt.Sprintf("refresh_token_family:%d:%s", userID, family)
+}
+
 // generateJTI creates a unique JWT ID to prevent replay attacks
 func (s *Server) generateJTI() string {
 	return fmt.Sprintf("%d-%s", time.Now().Unix(), uuid.New().String()[:8])
@@ -7,11 +7,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"sanctum/internal/config"
 	"sanctum/internal/models"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -80,6 +83,24 @@ func (m *MockUserRepository) Search(ctx context.Context, q string, limit, offset
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) SuggestMentions(ctx context.Context, viewerID uint, prefix string, since time.Time, limit, offset int) ([]models.MentionSuggestion, error) {
+	args := m.Called(ctx, viewerID, prefix, since, limit, offset)
+	return args.Get(0).([]models.MentionSuggestion), args.Error(1)
+}
+
+func (m *MockUserRepository) Deactivate(ctx context.Context, id uint, at time.Time, restorable bool) error {
+	args := m.Called(ctx, id, at, restorable)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Reactivate(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func TestSignup(t *testing.T) {
 	app := fiber.New()
 	mockRepo := new(MockUserRepository)
@@ -255,6 +276,63 @@ func TestLogin(t *testing.T) {
 	}
 }
 
+func TestLoginDeactivatedAccount(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("Password123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	recently := time.Now().Add(-24 * time.Hour)
+	longAgo := time.Now().Add(-60 * 24 * time.Hour)
+
+	login := func(t *testing.T, deactivatedAt time.Time, reactivate bool) (*MockUserRepository, int) {
+		mockRepo := new(MockUserRepository)
+		user := &models.User{
+			ID:               1,
+			Email:            "deleted_user_1@deleted.invalid",
+			Username:         "deleted_user_1",
+			Password:         string(hashedPassword),
+			DeactivatedAt:    &deactivatedAt,
+			OriginalEmail:    "test@example.com",
+			OriginalUsername: "testuser",
+		}
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		mockRepo.On("Reactivate", mock.Anything, uint(1)).
+			Return(&models.User{ID: 1, Email: "test@example.com", Username: "testuser"}, nil)
+
+		s := &Server{
+			config:   &config.Config{JWTSecret: "test_secret", AccountReactivationGraceDays: 30},
+			userRepo: mockRepo,
+		}
+		app := fiber.New()
+		app.Post("/login", s.Login)
+
+		body, err := json.Marshal(map[string]any{"email": "test@example.com", "password": "Password123!", "reactivate": reactivate})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return mockRepo, resp.StatusCode
+	}
+
+	t.Run("blocked during the grace window", func(t *testing.T) {
+		mockRepo, status := login(t, recently, false)
+		assert.Equal(t, http.StatusForbidden, status)
+		mockRepo.AssertNotCalled(t, "Reactivate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reactivates on request", func(t *testing.T) {
+		mockRepo, status := login(t, recently, true)
+		assert.Equal(t, http.StatusOK, status)
+		mockRepo.AssertCalled(t, "Reactivate", mock.Anything, uint(1))
+	})
+
+	t.Run("rejected after the grace window", func(t *testing.T) {
+		mockRepo, status := login(t, longAgo, true)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		mockRepo.AssertNotCalled(t, "Reactivate", mock.Anything, mock.Anything)
+	})
+}
+
 func TestRefresh(t *testing.T) {
 	app := fiber.New()
 	mockRepo := new(MockUserRepository)
@@ -267,7 +345,7 @@ func TestRefresh(t *testing.T) {
 	app.Post("/refresh", s.Refresh)
 
 	// Generate a valid refresh token
-	refreshToken, _ := s.generateRefreshToken(1)
+	refreshToken, _ := s.generateRefreshToken(1, "")
 
 	tests := []struct {
 		name           string
@@ -326,6 +404,63 @@ func TestRefresh(t *testing.T) {
 	}
 }
 
+func TestRefreshRotationReuseDetection(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Username: "testuser"}, nil)
+	s := &Server{
+		config:   &config.Config{JWTSecret: "test_secret"},
+		userRepo: mockRepo,
+		redis:    rdb,
+	}
+	app := fiber.New()
+	app.Post("/refresh", s.Refresh)
+
+	refresh := func(token string) (int, string) {
+		body, err := json.Marshal(map[string]string{"refresh_token": token})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		next, _ := result["refresh_token"].(string)
+		return resp.StatusCode, next
+	}
+
+	original, err := s.generateRefreshToken(1, "")
+	require.NoError(t, err)
+
+	status, rotated := refresh(original)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, rotated)
+	assert.NotEqual(t, original, rotated)
+
+	status, latest := refresh(rotated)
+	require.Equal(t, http.StatusOK, status)
+
+	// Replaying an already-rotated token revokes the whole family...
+	status, _ = refresh(original)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// ...so the newest legitimate token no longer works either.
+	status, _ = refresh(latest)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// Other logins are separate families and are unaffected.
+	other, err := s.generateRefreshToken(1, "")
+	require.NoError(t, err)
+	status, _ = refresh(other)
+	assert.Equal(t, http.StatusOK, status)
+}
+
 func TestLogout(t *testing.T) {
 	app := fiber.New()
 	s := &Server{
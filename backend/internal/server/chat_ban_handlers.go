@@ -84,9 +84,12 @@ func (s *Server) AddChatroomBan(c *fiber.Ctx) error {
 			{Name: "conversation_id"},
 			{Name: "user_id"},
 		},
+		// A moderator's own ban outlives any sanctum ban that already
+		// covered the room.
 		DoUpdates: clause.Assignments(map[string]interface{}{
 			"banned_by_user_id": actorUserID,
 			"reason":            ban.Reason,
+			"from_sanctum_ban":  false,
 			"updated_at":        time.Now().UTC(),
 		}),
 	}).Create(&ban).Error; err != nil {
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"fmt"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxBulkDeleteMessages caps how many messages one bulk-delete request may remove.
+const maxBulkDeleteMessages = 200
+
+// BulkDeleteChatroomMessages handles POST /api/chatrooms/:id/messages/bulk-delete.
+// Moderators pass either explicit message_ids or a user_id with last (the
+// user's N most recent messages in the room). Matching messages are
+// soft-deleted by the chat service and a messages_deleted frame is broadcast.
+func (s *Server) BulkDeleteChatroomMessages(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	roomID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	allowed, err := s.canModerateChatroomByUserID(ctx, actorUserID, roomID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewUnauthorizedError("Chatroom moderation access required"))
+	}
+
+	var req struct {
+		MessageIDs []uint `json:"message_ids"`
+		UserID     uint   `json:"user_id"`
+		Last       int    `json:"last"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	byIDs := len(req.MessageIDs) > 0
+	byUser := req.UserID != 0 || req.Last != 0
+	switch {
+	case byIDs == byUser:
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Provide either message_ids or user_id with last"))
+	case byIDs && len(req.MessageIDs) > maxBulkDeleteMessages,
+		byUser && (req.Last <= 0 || req.Last > maxBulkDeleteMessages):
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError(fmt.Sprintf("Select between 1 and %d messages", maxBulkDeleteMessages)))
+	case byUser && req.UserID == 0:
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("user_id is required with last"))
+	}
+
+	deletedIDs, err := s.chatSvc().BulkDeleteRoomMessages(ctx, service.BulkDeleteRoomMessagesInput{
+		RoomID:     roomID,
+		MessageIDs: req.MessageIDs,
+		UserID:     req.UserID,
+		Last:       req.Last,
+	})
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	if len(deletedIDs) > 0 && s.chatHub != nil {
+		s.chatHub.BroadcastToConversation(roomID, notifications.ChatMessage{
+			Type:           "messages_deleted",
+			ConversationID: roomID,
+			UserID:         actorUserID,
+			Payload: map[string]interface{}{
+				"conversation_id": roomID,
+				"message_ids":     deletedIDs,
+				"deleted_by":      actorUserID,
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"deleted_ids": deletedIDs,
+		"count":       len(deletedIDs),
+	})
+}
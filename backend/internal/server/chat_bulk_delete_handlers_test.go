@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupBulkDeleteTest(t *testing.T) (*gorm.DB, *Server) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.ChatroomModerator{},
+		&models.Message{},
+	))
+	return db, newChatHandlerTestServer(db)
+}
+
+func bulkDeleteRequest(t *testing.T, s *Server, actorID, roomID uint, body string) *http.Response {
+	t.Helper()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		return c.Next()
+	})
+	app.Post("/chatrooms/:id/messages/bulk-delete", s.BulkDeleteChatroomMessages)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/chatrooms/%d/messages/bulk-delete", roomID), bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestBulkDeleteChatroomMessages(t *testing.T) {
+	t.Parallel()
+	db, s := setupBulkDeleteTest(t)
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	moderator := models.User{Username: "mod", Email: "mod@example.com", Password: "pw"}
+	spammer := models.User{Username: "spammer", Email: "spam@example.com", Password: "pw"}
+	regular := models.User{Username: "regular", Email: "regular@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &moderator, &spammer, &regular} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: owner.ID}
+	other := models.Conversation{Name: "Other", IsGroup: true, CreatedBy: owner.ID}
+	require.NoError(t, db.Create(&room).Error)
+	require.NoError(t, db.Create(&other).Error)
+	require.NoError(t, db.Create(&models.ChatroomModerator{ConversationID: room.ID, UserID: moderator.ID, GrantedByUserID: owner.ID}).Error)
+
+	send := func(convID, senderID uint, content string) uint {
+		msg := models.Message{ConversationID: convID, SenderID: senderID, Content: content}
+		require.NoError(t, db.Create(&msg).Error)
+		return msg.ID
+	}
+	var spam []uint
+	for i := range 5 {
+		spam = append(spam, send(room.ID, spammer.ID, fmt.Sprintf("spam %d", i)))
+	}
+	legit := send(room.ID, regular.ID, "hello")
+	elsewhere := send(other.ID, spammer.ID, "other room")
+
+	remaining := func() []uint {
+		var ids []uint
+		require.NoError(t, db.Model(&models.Message{}).Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+
+	t.Run("non-moderator is rejected", func(t *testing.T) {
+		resp := bulkDeleteRequest(t, s, regular.ID, room.ID, fmt.Sprintf(`{"message_ids":[%d]}`, spam[0]))
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Len(t, remaining(), 7)
+	})
+
+	t.Run("moderator removes messages by id", func(t *testing.T) {
+		body := fmt.Sprintf(`{"message_ids":[%d,%d,%d]}`, spam[0], legit, elsewhere)
+		resp := bulkDeleteRequest(t, s, moderator.ID, room.ID, body)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			DeletedIDs []uint `json:"deleted_ids"`
+			Count      int    `json:"count"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		// Messages outside the room are never touched.
+		assert.ElementsMatch(t, []uint{spam[0], legit}, result.DeletedIDs)
+		assert.Equal(t, 2, result.Count)
+		assert.Equal(t, []uint{spam[1], spam[2], spam[3], spam[4], elsewhere}, remaining())
+	})
+
+	t.Run("moderator removes the last N from a user", func(t *testing.T) {
+		body := fmt.Sprintf(`{"user_id":%d,"last":3}`, spammer.ID)
+		resp := bulkDeleteRequest(t, s, moderator.ID, room.ID, body)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []uint{spam[1], elsewhere}, remaining())
+
+		var softDeleted int64
+		require.NoError(t, db.Unscoped().Model(&models.Message{}).Where("deleted_at IS NOT NULL").Count(&softDeleted).Error)
+		assert.Equal(t, int64(5), softDeleted, "messages are soft-deleted")
+	})
+
+	t.Run("rejects an ambiguous selector", func(t *testing.T) {
+		body := fmt.Sprintf(`{"message_ids":[%d],"user_id":%d,"last":1}`, spam[1], spammer.ID)
+		resp := bulkDeleteRequest(t, s, moderator.ID, room.ID, body)
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
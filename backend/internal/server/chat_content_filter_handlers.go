@@ -0,0 +1,149 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxChatroomBlockedWords     = 100
+	maxChatroomBlockedWordBytes = 64
+)
+
+// chatroomContentFilterResponse is the moderator view of a room's filter.
+type chatroomContentFilterResponse struct {
+	ConversationID uint     `json:"conversation_id"`
+	ProfanityMode  string   `json:"profanity_mode"`
+	BlockedWords   []string `json:"blocked_words"`
+	BlockLinks     bool     `json:"block_links"`
+}
+
+func newChatroomContentFilterResponse(roomID uint, f models.ChatroomContentFilter) chatroomContentFilterResponse {
+	return chatroomContentFilterResponse{
+		ConversationID: roomID,
+		ProfanityMode:  f.ProfanityMode,
+		BlockedWords:   f.BlockedWordList(),
+		BlockLinks:     f.BlockLinks,
+	}
+}
+
+// GetChatroomContentFilter handles GET /api/chatrooms/:id/content-filter.
+// An empty profanity_mode means the room uses the global default.
+func (s *Server) GetChatroomContentFilter(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	roomID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	allowed, err := s.canModerateChatroomByUserID(ctx, actorUserID, roomID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewUnauthorizedError("Chatroom moderation access required"))
+	}
+
+	var filter models.ChatroomContentFilter
+	if err := s.db.WithContext(ctx).
+		Where("conversation_id = ?", roomID).
+		Limit(1).
+		Find(&filter).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(newChatroomContentFilterResponse(roomID, filter))
+}
+
+// UpdateChatroomContentFilter handles PUT /api/chatrooms/:id/content-filter.
+func (s *Server) UpdateChatroomContentFilter(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	roomID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	allowed, err := s.canModerateChatroomByUserID(ctx, actorUserID, roomID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewUnauthorizedError("Chatroom moderation access required"))
+	}
+
+	var req struct {
+		ProfanityMode string   `json:"profanity_mode"`
+		BlockedWords  []string `json:"blocked_words"`
+		BlockLinks    bool     `json:"block_links"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	switch req.ProfanityMode {
+	case "", models.ProfanityFilterOff, models.ProfanityFilterMask, models.ProfanityFilterReject:
+	default:
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("profanity_mode must be one of off, mask, reject, or empty for the default"))
+	}
+	if len(req.BlockedWords) > maxChatroomBlockedWords {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError(fmt.Sprintf("At most %d blocked words are allowed", maxChatroomBlockedWords)))
+	}
+	words := make([]string, 0, len(req.BlockedWords))
+	for _, w := range req.BlockedWords {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" {
+			continue
+		}
+		if len(w) > maxChatroomBlockedWordBytes || strings.Contains(w, ",") {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError(fmt.Sprintf("Blocked words must be under %d characters and contain no commas", maxChatroomBlockedWordBytes)))
+		}
+		words = append(words, w)
+	}
+
+	var room models.Conversation
+	if err := s.db.WithContext(ctx).
+		Select("id", "is_group").
+		Where("id = ? AND is_group = ?", roomID, true).
+		Limit(1).
+		Find(&room).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if room.ID == 0 {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Chatroom", roomID))
+	}
+
+	filter := models.ChatroomContentFilter{
+		ConversationID:  roomID,
+		ProfanityMode:   req.ProfanityMode,
+		BlockedWords:    strings.Join(words, ","),
+		BlockLinks:      req.BlockLinks,
+		UpdatedByUserID: actorUserID,
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "conversation_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"profanity_mode":     filter.ProfanityMode,
+			"blocked_words":      filter.BlockedWords,
+			"block_links":        filter.BlockLinks,
+			"updated_by_user_id": actorUserID,
+			"updated_at":         time.Now().UTC(),
+		}),
+	}).Create(&filter).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(newChatroomContentFilterResponse(roomID, filter))
+}
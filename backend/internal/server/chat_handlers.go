@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 
 	"sanctum/internal/models"
@@ -57,6 +58,76 @@ func (s *Server) CreateConversation(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(conv)
 }
 
+// RenameConversation handles PATCH and PUT /api/conversations/:id
+func (s *Server) RenameConversation(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if parseErr := c.BodyParser(&req); parseErr != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	conv, err := s.chatSvc().RenameConversation(ctx, convID, userID, req.Name)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	s.broadcastRoomUpdated(conv, userID)
+	return c.JSON(conv)
+}
+
+// TransferConversation handles POST /api/conversations/:id/transfer
+func (s *Server) TransferConversation(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		UserID uint `json:"user_id"`
+	}
+	if parseErr := c.BodyParser(&req); parseErr != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	conv, err := s.chatSvc().TransferConversation(ctx, convID, userID, req.UserID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	s.broadcastRoomUpdated(conv, userID)
+	return c.JSON(conv)
+}
+
+// broadcastRoomUpdated tells everyone viewing a group conversation that its
+// name or owner changed.
+func (s *Server) broadcastRoomUpdated(conv *models.Conversation, actorUserID uint) {
+	if s.chatHub == nil || conv == nil {
+		return
+	}
+	s.chatHub.BroadcastToConversation(conv.ID, notifications.ChatMessage{
+		Type:           "room_updated",
+		ConversationID: conv.ID,
+		UserID:         actorUserID,
+		Payload: map[string]interface{}{
+			"conversation_id": conv.ID,
+			"name":            conv.Name,
+			"created_by":      conv.CreatedBy,
+		},
+	})
+}
+
 // GetConversations handles GET /api/conversations
 func (s *Server) GetConversations(c *fiber.Ctx) error {
 	ctx := c.UserContext()
@@ -76,6 +147,19 @@ func (s *Server) GetConversations(c *fiber.Ctx) error {
 	return c.JSON(conversations)
 }
 
+// GetUnreadSummary handles GET /api/conversations/unread-summary
+func (s *Server) GetUnreadSummary(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	summary, err := s.chatSvc().GetUnreadSummary(ctx, userID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(summary)
+}
+
 // GetConversation handles GET /api/conversations/:id
 func (s *Server) GetConversation(c *fiber.Ctx) error {
 	ctx := c.UserContext()
@@ -127,6 +211,14 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 			models.NewValidationError("Invalid request body"))
 	}
 
+	wait, releaseSlowMode, err := s.claimSlowModeSlot(ctx, convID, userID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if wait > 0 {
+		return respondSlowMode(c, wait)
+	}
+
 	message, conv, err := s.chatSvc().SendMessage(ctx, service.SendMessageInput{
 		UserID:         userID,
 		ConversationID: convID,
@@ -135,6 +227,7 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 		Metadata:       req.Metadata,
 	})
 	if err != nil {
+		releaseSlowMode()
 		return models.RespondWithError(c, mapServiceError(err), err)
 	}
 
@@ -142,7 +235,12 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 	if message.Sender != nil {
 		senderUsername = message.Sender.Username
 	}
+	if s.echoShadowBannedMessage(ctx, conv, message, senderUsername) {
+		return c.Status(fiber.StatusCreated).JSON(message)
+	}
 	s.persistMessageMentions(ctx, convID, message, userID, conv.Participants)
+	s.webhookService.DispatchMessage(ctx, conv, message)
+	s.pushToOfflineRecipients(conv, message)
 
 	// Broadcast message to all WebSocket-connected participants in real-time via ChatHub
 	if s.chatHub != nil {
@@ -169,21 +267,7 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 
 	// Notify only for direct messages; chatroom/group traffic should not trigger
 	// global bell/toast notifications.
-	if !conv.IsGroup {
-		for _, participant := range conv.Participants {
-			if participant.ID == userID {
-				continue
-			}
-			s.publishUserEvent(participant.ID, EventMessageReceived, map[string]interface{}{
-				"conversation_id": conv.ID,
-				"message_id":      message.ID,
-				"is_group":        conv.IsGroup,
-				"from_user":       userSummaryPtr(message.Sender),
-				"preview":         message.Content,
-				"created_at":      time.Now().UTC().Format(time.RFC3339Nano),
-			})
-		}
-	}
+	s.notifyMessageRecipients(ctx, conv, message)
 
 	return c.Status(fiber.StatusCreated).JSON(message)
 }
@@ -199,6 +283,24 @@ func (s *Server) GetMessages(c *fiber.Ctx) error {
 
 	page := parsePagination(c, 50)
 
+	// Cursor mode: ?before=<message id> (0 for the newest page) returns an
+	// object with next_cursor. Without it, the legacy limit/offset array is kept.
+	if before := c.Query("before"); before != "" {
+		beforeID, parseErr := strconv.ParseUint(before, 10, 64)
+		if parseErr != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid before cursor"))
+		}
+		messages, nextCursor, err := s.chatSvc().GetMessagesBeforeForUser(ctx, convID, userID, uint(beforeID), page.Limit)
+		if err != nil {
+			return models.RespondWithError(c, mapServiceError(err), err)
+		}
+		return c.JSON(fiber.Map{
+			"messages":    messages,
+			"next_cursor": nextCursor,
+		})
+	}
+
 	messages, err := s.chatSvc().GetMessagesForUser(ctx, convID, userID, page.Limit, page.Offset)
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
@@ -207,7 +309,31 @@ func (s *Server) GetMessages(c *fiber.Ctx) error {
 	return c.JSON(messages)
 }
 
+// GetMessage handles GET /api/conversations/:id/messages/:messageId
+func (s *Server) GetMessage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	messageID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+
+	message, err := s.chatSvc().GetMessageForUser(ctx, convID, messageID, userID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(message)
+}
+
 // MarkConversationRead handles POST /api/conversations/:id/read
+// DMs additionally flag each incoming message as read; group rooms only track
+// the participant's read position, broadcasting a lightweight room_read event
+// when the participant shares read receipts.
 func (s *Server) MarkConversationRead(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	userID := c.Locals("userID").(uint)
@@ -220,42 +346,65 @@ func (s *Server) MarkConversationRead(c *fiber.Ctx) error {
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
 	}
-	if conv.IsGroup {
-		return models.RespondWithError(c, fiber.StatusBadRequest,
-			models.NewValidationError("Read receipts are only supported for direct messages"))
-	}
 
 	now := time.Now().UTC()
+	var lastReadMessageID *uint
 	txErr := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest models.Message
+		latestErr := tx.Select("id").
+			Where("conversation_id = ?", convID).
+			Order("id DESC").
+			First(&latest).Error
+		switch {
+		case latestErr == nil:
+			lastReadMessageID = &latest.ID
+		case errors.Is(latestErr, gorm.ErrRecordNotFound):
+		default:
+			return latestErr
+		}
+
+		updates := map[string]interface{}{
+			"last_read_at": now,
+			"unread_count": 0,
+		}
+		if lastReadMessageID != nil {
+			updates["last_read_message_id"] = *lastReadMessageID
+		}
 		if err := tx.Model(&models.ConversationParticipant{}).
 			Where("conversation_id = ? AND user_id = ?", convID, userID).
-			Updates(map[string]interface{}{
-				"last_read_at": now,
-				"unread_count": 0,
-			}).Error; err != nil {
+			Updates(updates).Error; err != nil {
 			return err
 		}
 
+		if conv.IsGroup {
+			return nil
+		}
 		return tx.Model(&models.Message{}).
 			Where("conversation_id = ? AND sender_id <> ? AND is_read = ?", convID, userID, false).
 			Updates(map[string]interface{}{
-				"is_read": true,
-				"read_at": now,
+				"is_read":      true,
+				"read_at":      now,
+				"delivered_at": gorm.Expr("COALESCE(delivered_at, ?)", now),
 			}).Error
 	})
 	if txErr != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, txErr)
 	}
 
-	if s.chatHub != nil {
+	if s.chatHub != nil && s.sharesReadReceipts(ctx, convID, userID) {
+		eventType := "message_read"
+		if conv.IsGroup {
+			eventType = "room_read"
+		}
 		s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
-			Type:           "message_read",
+			Type:           eventType,
 			ConversationID: convID,
 			UserID:         userID,
 			Payload: map[string]interface{}{
-				"conversation_id": convID,
-				"user_id":         userID,
-				"read_at":         now.Format(time.RFC3339Nano),
+				"conversation_id":      convID,
+				"user_id":              userID,
+				"last_read_message_id": lastReadMessageID,
+				"read_at":              now.Format(time.RFC3339Nano),
 			},
 		})
 	}
@@ -263,6 +412,52 @@ func (s *Server) MarkConversationRead(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Conversation marked as read"})
 }
 
+// ParticipantReadState is the API shape for one participant's read position.
+type ParticipantReadState struct {
+	UserID            uint      `json:"user_id"`
+	LastReadAt        time.Time `json:"last_read_at"`
+	LastReadMessageID *uint     `json:"last_read_message_id"`
+}
+
+// GetConversationReadState handles GET /api/conversations/:id/read-state
+// In group rooms only participants sharing read receipts are listed, along
+// with the caller.
+func (s *Server) GetConversationReadState(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	conv, err := s.chatSvc().GetConversationForUser(ctx, convID, userID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	query := s.db.WithContext(ctx).Where("conversation_id = ?", convID)
+	if conv.IsGroup {
+		query = query.Where("share_read_receipts = ? OR user_id = ?", true, userID)
+	}
+	var participants []models.ConversationParticipant
+	if err := query.
+		Order("user_id ASC").
+		Find(&participants).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	resp := make([]ParticipantReadState, 0, len(participants))
+	for _, p := range participants {
+		resp = append(resp, ParticipantReadState{
+			UserID:            p.UserID,
+			LastReadAt:        p.LastReadAt,
+			LastReadMessageID: p.LastReadMessageID,
+		})
+	}
+
+	return c.JSON(resp)
+}
+
 // AddParticipant handles POST /api/conversations/:id/participants
 func (s *Server) AddParticipant(c *fiber.Ctx) error {
 	ctx := c.UserContext()
@@ -4,16 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/repository"
 	"sanctum/internal/service"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 // MockChatRepository is a mock of the ChatRepository interface
@@ -54,11 +64,27 @@ func (m *MockChatRepository) CreateMessage(ctx context.Context, msg *models.Mess
 	return args.Error(0)
 }
 
-func (m *MockChatRepository) GetMessages(ctx context.Context, convID uint, limit, offset int) ([]*models.Message, error) {
+func (m *MockChatRepository) GetMessages(ctx context.Context, convID, viewerID uint, limit, offset int) ([]*models.Message, error) {
 	args := m.Called(ctx, convID, limit, offset)
 	return args.Get(0).([]*models.Message), args.Error(1)
 }
 
+func (m *MockChatRepository) GetMessagesBefore(ctx context.Context, convID, viewerID, beforeID uint, limit int) ([]*models.Message, error) {
+	args := m.Called(ctx, convID, beforeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockChatRepository) GetMessage(ctx context.Context, convID, viewerID, msgID uint) (*models.Message, error) {
+	args := m.Called(ctx, convID, viewerID, msgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
 func (m *MockChatRepository) MarkMessageRead(ctx context.Context, msgID uint) error {
 	args := m.Called(ctx, msgID)
 	return args.Error(0)
@@ -74,6 +100,14 @@ func (m *MockChatRepository) IsUserParticipant(ctx context.Context, conversation
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockChatRepository) GetUnreadCounts(ctx context.Context, userID uint) ([]models.ConversationUnread, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ConversationUnread), args.Error(1)
+}
+
 func TestCreateConversation(t *testing.T) {
 	app := fiber.New()
 	mockChatRepo := new(MockChatRepository)
@@ -129,3 +163,820 @@ func TestCreateConversation(t *testing.T) {
 		})
 	}
 }
+
+func setupChatHandlerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sqlite handle: %v", err)
+	}
+	// A single connection keeps every query on the same in-memory database.
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.Message{},
+		&models.MessageMention{},
+		&models.MessageReaction{},
+		&models.ConversationWebhook{},
+		&models.ChatroomModerator{},
+		&models.ChatroomBan{},
+		&models.ChatroomMute{},
+		&models.ChatroomContentFilter{},
+		&models.UserBlock{},
+	); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+
+	return db
+}
+
+func newChatHandlerTestServer(db *gorm.DB) *Server {
+	s := &Server{db: db, chatRepo: repository.NewChatRepository(db)}
+	s.userRepo = repository.NewUserRepository(db)
+	s.chatService = service.NewChatService(s.chatRepo, s.userRepo, db, s.isAdminByUserID, s.canModerateChatroomByUserID)
+	return s
+}
+
+func TestMarkConversationRead_GroupRoomTracksReadState(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+	s.chatHub = notifications.NewChatHub(nil)
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	bob := models.User{Username: "bob", Email: "bob@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&bob).Error)
+
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: alice.ID}
+	require.NoError(t, db.Create(&room).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: alice.ID}).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: bob.ID, UnreadCount: 2}).Error)
+
+	first := models.Message{ConversationID: room.ID, SenderID: alice.ID, Content: "one"}
+	second := models.Message{ConversationID: room.ID, SenderID: alice.ID, Content: "two"}
+	require.NoError(t, db.Create(&first).Error)
+	require.NoError(t, db.Create(&second).Error)
+
+	watcher := &notifications.Client{Hub: s.chatHub, UserID: alice.ID, Send: make(chan []byte, 8)}
+	s.chatHub.RegisterUser(watcher)
+	s.chatHub.JoinConversation(alice.ID, room.ID)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		_, _ = fmt.Sscan(c.Get("X-User-ID"), &id)
+		c.Locals("userID", id)
+		return c.Next()
+	})
+	app.Post("/conversations/:id/read", s.MarkConversationRead)
+	app.Get("/conversations/:id/read-state", s.GetConversationReadState)
+	app.Post("/conversations/:id/read-receipts", s.ShareReadReceipts)
+
+	do := func(userID uint, method, path string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, fmt.Sprintf(path, room.ID), nil)
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	readState := func(viewerID uint) []ParticipantReadState {
+		t.Helper()
+		resp := do(viewerID, http.MethodGet, "/conversations/%d/read-state")
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var states []ParticipantReadState
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&states))
+		return states
+	}
+	roomReadFrames := func() int {
+		frames := 0
+		for {
+			select {
+			case raw := <-watcher.Send:
+				if strings.Contains(string(raw), `"room_read"`) {
+					frames++
+				}
+			default:
+				return frames
+			}
+		}
+	}
+
+	resp := do(bob.ID, http.MethodPost, "/conversations/%d/read")
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var participant models.ConversationParticipant
+	require.NoError(t, db.Where("conversation_id = ? AND user_id = ?", room.ID, bob.ID).First(&participant).Error)
+	assert.Equal(t, 0, participant.UnreadCount)
+	require.NotNil(t, participant.LastReadMessageID)
+	assert.Equal(t, second.ID, *participant.LastReadMessageID)
+
+	// Group rooms do not flip the per-message DM read flag.
+	var readCount int64
+	require.NoError(t, db.Model(&models.Message{}).Where("conversation_id = ? AND is_read = ?", room.ID, true).Count(&readCount).Error)
+	assert.Zero(t, readCount)
+
+	// Bob has not opted in: his position is tracked but not published.
+	assert.Zero(t, roomReadFrames())
+	states := readState(alice.ID)
+	require.Len(t, states, 1)
+	assert.Equal(t, alice.ID, states[0].UserID)
+	assert.Nil(t, states[0].LastReadMessageID)
+
+	resp = do(bob.ID, http.MethodPost, "/conversations/%d/read-receipts")
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do(bob.ID, http.MethodPost, "/conversations/%d/read")
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, 1, roomReadFrames())
+	states = readState(alice.ID)
+	require.Len(t, states, 2)
+	assert.Equal(t, bob.ID, states[1].UserID)
+	require.NotNil(t, states[1].LastReadMessageID)
+	assert.Equal(t, second.ID, *states[1].LastReadMessageID)
+}
+
+func TestGetConversationReadState_RejectsNonParticipant(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	member := models.User{Username: "member", Email: "member@example.com", Password: "pw"}
+	outsider := models.User{Username: "outsider", Email: "outsider@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&member).Error)
+	require.NoError(t, db.Create(&outsider).Error)
+
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: member.ID}
+	require.NoError(t, db.Create(&room).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: member.ID}).Error)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", outsider.ID)
+		return c.Next()
+	})
+	app.Get("/conversations/:id/read-state", s.GetConversationReadState)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/read-state", room.ID), nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestSendMessage_TriggersConversationWebhook(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+	s.webhookService = service.NewMessageWebhookService(db, true)
+	s.webhookService.AllowPrivateNetworks()
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	sender := models.User{Username: "sender", Email: "sender@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&owner).Error)
+	require.NoError(t, db.Create(&sender).Error)
+
+	dm := models.Conversation{CreatedBy: owner.ID}
+	require.NoError(t, db.Create(&dm).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: dm.ID, UserID: owner.ID}).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: dm.ID, UserID: sender.ID}).Error)
+
+	received := make(chan *http.Request, 1)
+	hookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer hookServer.Close()
+
+	_, _, err := s.webhookService.UpsertWebhook(context.Background(), service.UpsertWebhookInput{
+		ConversationID: dm.ID,
+		UserID:         owner.ID,
+		URL:            hookServer.URL,
+		Enabled:        true,
+	})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", sender.ID)
+		return c.Next()
+	})
+	app.Post("/conversations/:id/messages", s.SendMessage)
+
+	body, _ := json.Marshal(map[string]string{"content": "ping"})
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/conversations/%d/messages", dm.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	select {
+	case r := <-received:
+		assert.NotEmpty(t, r.Header.Get(service.WebhookSignatureHeader))
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestGetMessages_BeforeCursor(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	user := models.User{Username: "reader", Email: "reader@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&user).Error)
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: user.ID}
+	require.NoError(t, db.Create(&room).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: user.ID}).Error)
+
+	msgs := make([]models.Message, 3)
+	for i := range msgs {
+		msgs[i] = models.Message{ConversationID: room.ID, SenderID: user.ID, Content: fmt.Sprintf("m%d", i)}
+		require.NoError(t, db.Create(&msgs[i]).Error)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", user.ID)
+		return c.Next()
+	})
+	app.Get("/conversations/:id/messages", s.GetMessages)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/messages?before=%d&limit=1", room.ID, msgs[2].ID), nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var page struct {
+		Messages   []models.Message `json:"messages"`
+		NextCursor *uint            `json:"next_cursor"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	require.Len(t, page.Messages, 1)
+	assert.Equal(t, msgs[1].ID, page.Messages[0].ID)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, msgs[1].ID, *page.NextCursor)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/messages?before=abc", room.ID), nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetMessage(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+	require.NoError(t, db.AutoMigrate(&models.MessageReaction{}))
+
+	user := models.User{Username: "linker", Email: "linker@example.com", Password: "pw"}
+	outsider := models.User{Username: "outsider", Email: "outsider@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Create(&outsider).Error)
+
+	mine := models.Conversation{Name: "Mine", IsGroup: true, CreatedBy: user.ID}
+	other := models.Conversation{Name: "Other", IsGroup: true, CreatedBy: outsider.ID}
+	require.NoError(t, db.Create(&mine).Error)
+	require.NoError(t, db.Create(&other).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: mine.ID, UserID: user.ID}).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: other.ID, UserID: outsider.ID}).Error)
+
+	target := models.Message{ConversationID: mine.ID, SenderID: user.ID, Content: "deep link"}
+	foreign := models.Message{ConversationID: other.ID, SenderID: outsider.ID, Content: "elsewhere"}
+	require.NoError(t, db.Create(&target).Error)
+	require.NoError(t, db.Create(&foreign).Error)
+	require.NoError(t, db.Create(&models.MessageReaction{MessageID: target.ID, UserID: user.ID, Emoji: "👍"}).Error)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", user.ID)
+		return c.Next()
+	})
+	app.Get("/conversations/:id/messages/:messageId", s.GetMessage)
+
+	tests := []struct {
+		name       string
+		convID     uint
+		messageID  uint
+		wantStatus int
+	}{
+		{name: "authorized fetch", convID: mine.ID, messageID: target.ID, wantStatus: http.StatusOK},
+		{name: "message from another conversation", convID: mine.ID, messageID: foreign.ID, wantStatus: http.StatusNotFound},
+		{name: "conversation the user is not in", convID: other.ID, messageID: foreign.ID, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/messages/%d", tt.convID, tt.messageID), nil)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var msg models.Message
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&msg))
+			assert.Equal(t, target.ID, msg.ID)
+			require.NotNil(t, msg.Sender)
+			assert.Equal(t, user.Username, msg.Sender.Username)
+			assert.Len(t, msg.Reactions, 1)
+			assert.Equal(t, []models.MessageReactionSummary{
+				{Emoji: "👍", Count: 1, ReactedByMe: true},
+			}, msg.ReactionSummary)
+		})
+	}
+}
+
+func TestSendMessage_EnforcesChatroomMutes(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	moderator := models.User{Username: "mod", Email: "mod@example.com", Password: "pw"}
+	muted := models.User{Username: "muted", Email: "muted@example.com", Password: "pw"}
+	expired := models.User{Username: "expired", Email: "expired@example.com", Password: "pw"}
+	speaker := models.User{Username: "speaker", Email: "speaker@example.com", Password: "pw"}
+	for _, u := range []*models.User{&moderator, &muted, &expired, &speaker} {
+		require.NoError(t, db.Create(u).Error)
+	}
+
+	room := models.Conversation{Name: "lobby", IsGroup: true, CreatedBy: moderator.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{moderator, muted, expired, speaker} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+
+	future := time.Now().UTC().Add(time.Hour)
+	past := time.Now().UTC().Add(-time.Hour)
+	require.NoError(t, db.Create(&models.ChatroomMute{ConversationID: room.ID, UserID: muted.ID, MutedByUserID: moderator.ID, MutedUntil: &future}).Error)
+	require.NoError(t, db.Create(&models.ChatroomMute{ConversationID: room.ID, UserID: expired.ID, MutedByUserID: moderator.ID, MutedUntil: &past}).Error)
+
+	send := func(userID uint) *http.Response {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", userID)
+			return c.Next()
+		})
+		app.Post("/conversations/:id/messages", s.SendMessage)
+
+		body, _ := json.Marshal(map[string]string{"content": "hello"})
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/conversations/%d/messages", room.ID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("muted user is rejected", func(t *testing.T) {
+		resp := send(muted.ID)
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		var payload map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		assert.Contains(t, fmt.Sprint(payload["error"]), "muted")
+	})
+
+	t.Run("unmuted user can send", func(t *testing.T) {
+		resp := send(speaker.ID)
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("expired mute no longer blocks", func(t *testing.T) {
+		resp := send(expired.ID)
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	var count int64
+	require.NoError(t, db.Model(&models.Message{}).Where("conversation_id = ? AND sender_id = ?", room.ID, muted.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestSendMessage_EnforcesSlowMode(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	s.redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	member := models.User{Username: "member", Email: "member@example.com", Password: "pw"}
+	other := models.User{Username: "other", Email: "other@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &member, &other} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	room := models.Conversation{Name: "busy", IsGroup: true, CreatedBy: owner.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{owner, member, other} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+
+	do := func(userID uint, method, path string, body any) *http.Response {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", userID)
+			return c.Next()
+		})
+		app.Post("/conversations/:id/messages", s.SendMessage)
+		app.Put("/chatrooms/:id/slow-mode", s.SetChatroomSlowMode)
+
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	send := func(userID uint) *http.Response {
+		return do(userID, http.MethodPost, fmt.Sprintf("/conversations/%d/messages", room.ID), map[string]string{"content": "hello"})
+	}
+	slowModePath := fmt.Sprintf("/chatrooms/%d/slow-mode", room.ID)
+
+	resp := do(member.ID, http.MethodPut, slowModePath, map[string]int{"seconds": 30})
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "only moderators set slow mode")
+
+	resp = do(owner.ID, http.MethodPut, slowModePath, map[string]int{"seconds": 30})
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = send(member.ID)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	mr.FastForward(10 * time.Second)
+	resp = send(member.ID)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "20", resp.Header.Get(fiber.HeaderRetryAfter))
+	var payload map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+	_ = resp.Body.Close()
+	assert.EqualValues(t, 20, payload["retry_after"])
+
+	// The cooldown is per user, and the room creator is exempt.
+	resp = send(other.ID)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	for range 2 {
+		resp = send(owner.ID)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	mr.FastForward(20 * time.Second)
+	resp = send(member.ID)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestGetMessages_ReactionSummary(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	bob := models.User{Username: "bob", Email: "bob@example.com", Password: "pw"}
+	carol := models.User{Username: "carol", Email: "carol@example.com", Password: "pw"}
+	for _, u := range []*models.User{&alice, &bob, &carol} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: alice.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{alice, bob, carol} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+
+	popular := models.Message{ConversationID: room.ID, SenderID: alice.ID, Content: "popular"}
+	quiet := models.Message{ConversationID: room.ID, SenderID: alice.ID, Content: "quiet"}
+	require.NoError(t, db.Create(&popular).Error)
+	require.NoError(t, db.Create(&quiet).Error)
+	for _, r := range []models.MessageReaction{
+		{MessageID: popular.ID, UserID: alice.ID, Emoji: "🔥"},
+		{MessageID: popular.ID, UserID: bob.ID, Emoji: "🔥"},
+		{MessageID: popular.ID, UserID: bob.ID, Emoji: "👍"},
+	} {
+		require.NoError(t, db.Create(&r).Error)
+	}
+
+	fetch := func(userID uint, query string) []byte {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", userID)
+			return c.Next()
+		})
+		app.Get("/conversations/:id/messages", s.GetMessages)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/messages%s", room.ID, query), nil))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body bytes.Buffer
+		_, err = body.ReadFrom(resp.Body)
+		require.NoError(t, err)
+		return body.Bytes()
+	}
+	summaries := func(messages []models.Message) map[string][]models.MessageReactionSummary {
+		out := map[string][]models.MessageReactionSummary{}
+		for _, m := range messages {
+			out[m.Content] = m.ReactionSummary
+		}
+		return out
+	}
+
+	var messages []models.Message
+	require.NoError(t, json.Unmarshal(fetch(alice.ID, ""), &messages))
+	got := summaries(messages)
+	assert.Equal(t, []models.MessageReactionSummary{
+		{Emoji: "🔥", Count: 2, ReactedByMe: true},
+		{Emoji: "👍", Count: 1, ReactedByMe: false},
+	}, got["popular"])
+	assert.Empty(t, got["quiet"])
+
+	var page struct {
+		Messages []models.Message `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(fetch(carol.ID, "?before=0"), &page))
+	got = summaries(page.Messages)
+	assert.Equal(t, []models.MessageReactionSummary{
+		{Emoji: "🔥", Count: 2, ReactedByMe: false},
+		{Emoji: "👍", Count: 1, ReactedByMe: false},
+	}, got["popular"], "the flag is computed for the caller")
+}
+
+func TestSendMessage_ChatroomContentFilter(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	member := models.User{Username: "member", Email: "member@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &member} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	room := models.Conversation{Name: "lobby", IsGroup: true, CreatedBy: owner.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{owner, member} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+
+	do := func(userID uint, method, path string, body any) *http.Response {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", userID)
+			return c.Next()
+		})
+		app.Post("/conversations/:id/messages", s.SendMessage)
+		app.Put("/chatrooms/:id/content-filter", s.UpdateChatroomContentFilter)
+
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	filterPath := fmt.Sprintf("/chatrooms/%d/content-filter", room.ID)
+	configure := func(settings map[string]any) {
+		resp := do(owner.ID, http.MethodPut, filterPath, settings)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	send := func(userID uint, content string) (int, string) {
+		resp := do(userID, http.MethodPost, fmt.Sprintf("/conversations/%d/messages", room.ID), map[string]string{"content": content})
+		defer func() { _ = resp.Body.Close() }()
+		var msg models.Message
+		_ = json.NewDecoder(resp.Body).Decode(&msg)
+		return resp.StatusCode, msg.Content
+	}
+
+	resp := do(member.ID, http.MethodPut, filterPath, map[string]any{"profanity_mode": "mask"})
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "only moderators configure the filter")
+
+	status, content := send(member.ID, "what the shit")
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "what the shit", content, "the filter is off by default")
+
+	configure(map[string]any{"profanity_mode": "mask", "blocked_words": []string{"Frak"}, "block_links": true})
+
+	t.Run("blocked words are masked", func(t *testing.T) {
+		status, content := send(member.ID, "what the shit, frak this")
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "what the ****, **** this", content)
+	})
+
+	t.Run("links from members are rejected", func(t *testing.T) {
+		status, _ := send(member.ID, "join us at https://example.com")
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("moderators bypass the filter", func(t *testing.T) {
+		status, content := send(owner.ID, "shit, see https://example.com")
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "shit, see https://example.com", content)
+	})
+
+	t.Run("link messages are filtered too", func(t *testing.T) {
+		sendLink := func(meta map[string]string) (int, models.Message) {
+			resp := do(member.ID, http.MethodPost, fmt.Sprintf("/conversations/%d/messages", room.ID), map[string]any{
+				"content": "look", "message_type": "link", "metadata": meta,
+			})
+			defer func() { _ = resp.Body.Close() }()
+			var msg models.Message
+			_ = json.NewDecoder(resp.Body).Decode(&msg)
+			return resp.StatusCode, msg
+		}
+
+		status, _ := sendLink(map[string]string{"url": "https://example.com"})
+		assert.Equal(t, http.StatusForbidden, status, "a link message is a link")
+
+		configure(map[string]any{"profanity_mode": "mask"})
+		status, msg := sendLink(map[string]string{"url": "https://example.com", "title": "shit happens", "description": "frak"})
+		require.Equal(t, http.StatusCreated, status)
+		var meta map[string]string
+		require.NoError(t, json.Unmarshal(msg.Metadata, &meta))
+		assert.Equal(t, "**** happens", meta["title"])
+		assert.Equal(t, "frak", meta["description"], "room words are replaced on update")
+		assert.Equal(t, "https://example.com", meta["url"])
+	})
+
+	t.Run("reject mode refuses the message", func(t *testing.T) {
+		configure(map[string]any{"profanity_mode": "reject"})
+		status, _ := send(member.ID, "frak")
+		assert.Equal(t, http.StatusCreated, status, "room words are replaced on update")
+		status, _ = send(member.ID, "shit")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
+
+func TestConversationRenameAndTransfer_BroadcastRoomUpdated(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+	s.chatHub = notifications.NewChatHub(nil)
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	bob := models.User{Username: "bob", Email: "bob@example.com", Password: "pw"}
+	carol := models.User{Username: "carol", Email: "carol@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&bob).Error)
+	require.NoError(t, db.Create(&carol).Error)
+
+	room := models.Conversation{Name: "Night Owls", IsGroup: true, CreatedBy: alice.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{alice, bob, carol} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+
+	viewer := &notifications.Client{Hub: s.chatHub, UserID: carol.ID, Send: make(chan []byte, 8)}
+	s.chatHub.RegisterUser(viewer)
+	s.chatHub.JoinConversation(carol.ID, room.ID)
+	readRoomUpdated := func() map[string]any {
+		t.Helper()
+		select {
+		case raw := <-viewer.Send:
+			var frame struct {
+				Type    string         `json:"type"`
+				Payload map[string]any `json:"payload"`
+			}
+			require.NoError(t, json.Unmarshal(raw, &frame))
+			require.Equal(t, "room_updated", frame.Type)
+			return frame.Payload
+		case <-time.After(time.Second):
+			t.Fatal("expected room_updated broadcast")
+			return nil
+		}
+	}
+
+	var actorID uint
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		return c.Next()
+	})
+	app.Put("/conversations/:id", s.RenameConversation)
+	app.Post("/conversations/:id/transfer", s.TransferConversation)
+	do := func(userID uint, method, path string, body any) int {
+		t.Helper()
+		actorID = userID
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	roomPath := fmt.Sprintf("/conversations/%d", room.ID)
+
+	t.Run("owner renames", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(alice.ID, http.MethodPut, roomPath, map[string]string{"name": "Early Birds"}))
+		assert.Equal(t, "Early Birds", readRoomUpdated()["name"])
+	})
+
+	t.Run("non-owner is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(bob.ID, http.MethodPut, roomPath, map[string]string{"name": "Bob's Room"}))
+		assert.Equal(t, http.StatusForbidden, do(bob.ID, http.MethodPost, roomPath+"/transfer", map[string]uint{"user_id": bob.ID}))
+	})
+
+	t.Run("owner transfers to participant", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(alice.ID, http.MethodPost, roomPath+"/transfer", map[string]uint{"user_id": bob.ID}))
+		assert.EqualValues(t, bob.ID, readRoomUpdated()["created_by"])
+
+		var reloaded models.Conversation
+		require.NoError(t, db.First(&reloaded, room.ID).Error)
+		assert.Equal(t, bob.ID, reloaded.CreatedBy)
+
+		// The previous owner no longer holds creator rights.
+		assert.Equal(t, http.StatusForbidden, do(alice.ID, http.MethodPut, roomPath, map[string]string{"name": "Alice Again"}))
+		require.Equal(t, http.StatusOK, do(bob.ID, http.MethodPut, roomPath, map[string]string{"name": "Bobs Room"}))
+		assert.Equal(t, "Bobs Room", readRoomUpdated()["name"])
+	})
+}
+
+func TestGetUnreadSummary_TracksSendsAndReads(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	bob := models.User{Username: "bob", Email: "bob@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&bob).Error)
+
+	dm := models.Conversation{CreatedBy: alice.ID}
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: alice.ID}
+	require.NoError(t, db.Create(&dm).Error)
+	require.NoError(t, db.Create(&room).Error)
+	for _, convID := range []uint{dm.ID, room.ID} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: convID, UserID: alice.ID}).Error)
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: convID, UserID: bob.ID}).Error)
+	}
+
+	var actorID uint
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		return c.Next()
+	})
+	app.Get("/conversations/unread-summary", s.GetUnreadSummary)
+	app.Post("/conversations/:id/messages", s.SendMessage)
+	app.Post("/conversations/:id/read", s.MarkConversationRead)
+
+	send := func(convID uint) {
+		t.Helper()
+		actorID = alice.ID
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/conversations/%d/messages", convID),
+			bytes.NewReader([]byte(`{"content":"hi"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	summary := func(userID uint) models.UnreadSummary {
+		t.Helper()
+		actorID = userID
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/conversations/unread-summary", nil))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var out models.UnreadSummary
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return out
+	}
+
+	send(dm.ID)
+	send(dm.ID)
+	send(room.ID)
+
+	got := summary(bob.ID)
+	assert.Equal(t, 3, got.Total)
+	assert.Equal(t, []models.ConversationUnread{
+		{ConversationID: dm.ID, UnreadCount: 2},
+		{ConversationID: room.ID, UnreadCount: 1},
+	}, got.Conversations)
+
+	// The sender's own messages never count as unread.
+	assert.Equal(t, 0, summary(alice.ID).Total)
+
+	actorID = bob.ID
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, fmt.Sprintf("/conversations/%d/read", dm.ID), nil))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	got = summary(bob.ID)
+	assert.Equal(t, 1, got.Total)
+	assert.Equal(t, []models.ConversationUnread{{ConversationID: room.ID, UnreadCount: 1}}, got.Conversations)
+}
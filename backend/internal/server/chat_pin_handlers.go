@@ -0,0 +1,179 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxPinnedMessages caps how many messages a chatroom can have pinned at once.
+const maxPinnedMessages = 5
+
+// errPinLimitReached is returned when a chatroom already has maxPinnedMessages pins.
+var errPinLimitReached = errors.New("pin limit reached")
+
+// GetPinnedMessages handles GET /api/conversations/:id/pins, newest pin first.
+func (s *Server) GetPinnedMessages(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	if _, convErr := s.chatSvc().GetConversationForUser(ctx, convID, userID); convErr != nil {
+		status := fiber.StatusForbidden
+		if errors.Is(convErr, gorm.ErrRecordNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return models.RespondWithError(c, status, convErr)
+	}
+
+	// Pins of deleted messages stay in the table but are not shown.
+	var pins []models.PinnedMessage
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN messages ON messages.id = pinned_messages.message_id AND messages.deleted_at IS NULL").
+		Where("pinned_messages.conversation_id = ?", convID).
+		Preload("Message.Sender").
+		Preload("PinnedByUser").
+		Order("pinned_messages.created_at DESC").
+		Find(&pins).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(pins)
+}
+
+// PinChatroomMessage handles POST /api/conversations/:id/messages/:messageId/pin.
+// Only chatroom moderators can pin, and pinning an already pinned message is a no-op.
+func (s *Server) PinChatroomMessage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	roomID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	messageID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+
+	allowed, err := s.canModerateChatroomByUserID(ctx, actorUserID, roomID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewUnauthorizedError("Chatroom moderation access required"))
+	}
+
+	pin := models.PinnedMessage{ConversationID: roomID, MessageID: messageID, PinnedByUserID: actorUserID}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var room models.Conversation
+		if err := tx.Select("id", "is_group").First(&room, roomID).Error; err != nil {
+			return err
+		}
+		if !room.IsGroup {
+			return gorm.ErrRecordNotFound
+		}
+
+		var message models.Message
+		if err := tx.Select("id").
+			Where("id = ? AND conversation_id = ?", messageID, roomID).
+			First(&message).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.PinnedMessage{}).
+			Where("conversation_id = ? AND message_id = ?", roomID, messageID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		var pinned int64
+		if err := tx.Model(&models.PinnedMessage{}).
+			Where("conversation_id = ?", roomID).
+			Count(&pinned).Error; err != nil {
+			return err
+		}
+		if pinned >= maxPinnedMessages {
+			return errPinLimitReached
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&pin).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errPinLimitReached):
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError(fmt.Sprintf("A chatroom can have at most %d pinned messages", maxPinnedMessages)))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Message", messageID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	s.broadcastPinChange(roomID, messageID, actorUserID, "message_pinned")
+	return c.JSON(fiber.Map{"message": "Message pinned"})
+}
+
+// UnpinChatroomMessage handles DELETE /api/conversations/:id/messages/:messageId/pin.
+func (s *Server) UnpinChatroomMessage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	roomID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	messageID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+
+	allowed, err := s.canModerateChatroomByUserID(ctx, actorUserID, roomID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewUnauthorizedError("Chatroom moderation access required"))
+	}
+
+	result := s.db.WithContext(ctx).
+		Where("conversation_id = ? AND message_id = ?", roomID, messageID).
+		Delete(&models.PinnedMessage{})
+	if result.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Pinned message", messageID))
+	}
+
+	s.broadcastPinChange(roomID, messageID, actorUserID, "message_unpinned")
+	return c.JSON(fiber.Map{"message": "Message unpinned"})
+}
+
+func (s *Server) broadcastPinChange(roomID, messageID, actorUserID uint, eventType string) {
+	if s.chatHub == nil {
+		return
+	}
+	s.chatHub.BroadcastToConversation(roomID, notifications.ChatMessage{
+		Type:           eventType,
+		ConversationID: roomID,
+		UserID:         actorUserID,
+		Payload: map[string]interface{}{
+			"conversation_id": roomID,
+			"message_id":      messageID,
+			"actor_id":        actorUserID,
+		},
+	})
+}
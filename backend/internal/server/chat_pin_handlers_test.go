@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupPinTest(t *testing.T) (*gorm.DB, *Server) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.ChatroomModerator{},
+		&models.Message{},
+		&models.PinnedMessage{},
+	))
+	s := &Server{db: db}
+	s.chatService = service.NewChatService(repository.NewChatRepository(db), repository.NewUserRepository(db), db, nil, nil)
+	return db, s
+}
+
+func pinRequest(t *testing.T, s *Server, actorID uint, method, path string) *http.Response {
+	t.Helper()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		return c.Next()
+	})
+	app.Post("/conversations/:id/messages/:messageId/pin", s.PinChatroomMessage)
+	app.Delete("/conversations/:id/messages/:messageId/pin", s.UnpinChatroomMessage)
+	app.Get("/conversations/:id/pins", s.GetPinnedMessages)
+
+	resp, err := app.Test(httptest.NewRequest(method, path, nil))
+	require.NoError(t, err)
+	return resp
+}
+
+func TestChatroomPins(t *testing.T) {
+	t.Parallel()
+	db, s := setupPinTest(t)
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	moderator := models.User{Username: "mod", Email: "mod@example.com", Password: "pw"}
+	regular := models.User{Username: "regular", Email: "regular@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &moderator, &regular} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: owner.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{owner, moderator, regular} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+	require.NoError(t, db.Create(&models.ChatroomModerator{ConversationID: room.ID, UserID: moderator.ID, GrantedByUserID: owner.ID}).Error)
+
+	var messageIDs []uint
+	for i := range maxPinnedMessages + 1 {
+		msg := models.Message{ConversationID: room.ID, SenderID: regular.ID, Content: fmt.Sprintf("message %d", i)}
+		require.NoError(t, db.Create(&msg).Error)
+		messageIDs = append(messageIDs, msg.ID)
+	}
+	pinPath := func(messageID uint) string {
+		return fmt.Sprintf("/conversations/%d/messages/%d/pin", room.ID, messageID)
+	}
+	pins := func() []models.PinnedMessage {
+		resp := pinRequest(t, s, regular.ID, http.MethodGet, fmt.Sprintf("/conversations/%d/pins", room.ID))
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result []models.PinnedMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	t.Run("non-moderator is rejected", func(t *testing.T) {
+		resp := pinRequest(t, s, regular.ID, http.MethodPost, pinPath(messageIDs[0]))
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, pins())
+	})
+
+	t.Run("moderator pins a message", func(t *testing.T) {
+		resp := pinRequest(t, s, moderator.ID, http.MethodPost, pinPath(messageIDs[0]))
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Pinning twice is a no-op.
+		resp = pinRequest(t, s, owner.ID, http.MethodPost, pinPath(messageIDs[0]))
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		got := pins()
+		require.Len(t, got, 1)
+		assert.Equal(t, messageIDs[0], got[0].MessageID)
+		assert.Equal(t, moderator.ID, got[0].PinnedByUserID)
+		require.NotNil(t, got[0].Message)
+		assert.Equal(t, "message 0", got[0].Message.Content)
+	})
+
+	t.Run("pins are capped", func(t *testing.T) {
+		for _, id := range messageIDs[1:maxPinnedMessages] {
+			resp := pinRequest(t, s, moderator.ID, http.MethodPost, pinPath(id))
+			_ = resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+		resp := pinRequest(t, s, moderator.ID, http.MethodPost, pinPath(messageIDs[maxPinnedMessages]))
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Len(t, pins(), maxPinnedMessages)
+	})
+
+	t.Run("non-moderator cannot unpin", func(t *testing.T) {
+		resp := pinRequest(t, s, regular.ID, http.MethodDelete, pinPath(messageIDs[0]))
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("moderator unpins a message", func(t *testing.T) {
+		resp := pinRequest(t, s, moderator.ID, http.MethodDelete, pinPath(messageIDs[0]))
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		for _, pin := range pins() {
+			assert.NotEqual(t, messageIDs[0], pin.MessageID)
+		}
+
+		resp = pinRequest(t, s, moderator.ID, http.MethodDelete, pinPath(messageIDs[0]))
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Chatroom member roles, from most to least privileged.
+const (
+	chatroomRoleCreator   = "creator"
+	chatroomRoleModerator = "moderator"
+	chatroomRoleMember    = "member"
+)
+
+// ChatroomMember is one row of a chatroom roster.
+type ChatroomMember struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	Avatar   string    `json:"avatar"`
+	Role     string    `json:"role"`
+	Online   bool      `json:"online"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// GetConversationMembers handles GET /api/conversations/:id/members.
+// Members of a group chatroom can page through its roster, creator first,
+// then moderators, then everyone else by username.
+func (s *Server) GetConversationMembers(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	roomID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	page := parsePagination(c, 50)
+
+	var room models.Conversation
+	if err := s.db.WithContext(ctx).
+		Select("id", "is_group", "created_by").
+		First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Chatroom", roomID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !room.IsGroup {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Chatroom", roomID))
+	}
+
+	var isMember int64
+	if err := s.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", roomID, userID).
+		Count(&isMember).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if isMember == 0 {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewUnauthorizedError("You are not a member of this chatroom"))
+	}
+
+	roster := s.db.WithContext(ctx).
+		Table("conversation_participants AS cp").
+		Joins("JOIN users ON users.id = cp.user_id AND users.deleted_at IS NULL").
+		Where("cp.conversation_id = ?", roomID)
+
+	var total int64
+	if err := roster.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	var rows []struct {
+		UserID      uint
+		Username    string
+		Avatar      string
+		JoinedAt    time.Time
+		IsModerator bool
+	}
+	if err := roster.Session(&gorm.Session{}).
+		Joins("LEFT JOIN chatroom_moderators cm ON cm.conversation_id = cp.conversation_id AND cm.user_id = cp.user_id").
+		Select("cp.user_id, users.username, users.avatar, cp.joined_at, cm.user_id IS NOT NULL AS is_moderator").
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN cp.user_id = ? THEN 0 WHEN cm.user_id IS NOT NULL THEN 1 ELSE 2 END, users.username",
+			Vars: []interface{}{room.CreatedBy},
+		}}).
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Scan(&rows).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	members := make([]ChatroomMember, 0, len(rows))
+	for _, row := range rows {
+		role := chatroomRoleMember
+		switch {
+		case row.UserID == room.CreatedBy:
+			role = chatroomRoleCreator
+		case row.IsModerator:
+			role = chatroomRoleModerator
+		}
+		members = append(members, ChatroomMember{
+			UserID:   row.UserID,
+			Username: row.Username,
+			Avatar:   row.Avatar,
+			Role:     role,
+			Online:   s.chatHub != nil && s.chatHub.IsUserOnline(row.UserID),
+			JoinedAt: row.JoinedAt,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"members": members,
+		"total":   total,
+	})
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConversationMembers(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	moderator := models.User{Username: "mod", Email: "mod@example.com", Password: "pw"}
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	zed := models.User{Username: "zed", Email: "zed@example.com", Password: "pw"}
+	outsider := models.User{Username: "outsider", Email: "outsider@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &moderator, &alice, &zed, &outsider} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: owner.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{zed, alice, moderator, owner} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+	require.NoError(t, db.Create(&models.ChatroomModerator{ConversationID: room.ID, UserID: moderator.ID, GrantedByUserID: owner.ID}).Error)
+
+	roster := func(userID uint, query string) *http.Response {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", userID)
+			return c.Next()
+		})
+		app.Get("/conversations/:id/members", s.GetConversationMembers)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/members%s", room.ID, query), nil))
+		require.NoError(t, err)
+		return resp
+	}
+	type rosterResponse struct {
+		Members []ChatroomMember `json:"members"`
+		Total   int64            `json:"total"`
+	}
+
+	t.Run("members see roles", func(t *testing.T) {
+		resp := roster(alice.ID, "")
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result rosterResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, int64(4), result.Total)
+		require.Len(t, result.Members, 4)
+
+		roles := map[string]string{}
+		order := make([]string, 0, len(result.Members))
+		for _, m := range result.Members {
+			roles[m.Username] = m.Role
+			order = append(order, m.Username)
+			assert.False(t, m.Online)
+		}
+		assert.Equal(t, []string{"owner", "mod", "alice", "zed"}, order)
+		assert.Equal(t, chatroomRoleCreator, roles["owner"])
+		assert.Equal(t, chatroomRoleModerator, roles["mod"])
+		assert.Equal(t, chatroomRoleMember, roles["alice"])
+	})
+
+	t.Run("paginates", func(t *testing.T) {
+		resp := roster(alice.ID, "?limit=2&offset=2")
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result rosterResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, int64(4), result.Total)
+		require.Len(t, result.Members, 2)
+		assert.Equal(t, "alice", result.Members[0].Username)
+		assert.Equal(t, "zed", result.Members[1].Username)
+	})
+
+	t.Run("non-members are rejected", func(t *testing.T) {
+		resp := roster(outsider.ID, "")
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
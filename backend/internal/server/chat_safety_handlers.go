@@ -14,13 +14,6 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-// MessageReactionSummary is the API shape for reaction counts on a message.
-type MessageReactionSummary struct {
-	Emoji       string `json:"emoji"`
-	Count       int    `json:"count"`
-	ReactedByMe bool   `json:"reacted_by_me"`
-}
-
 // AddMessageReaction adds an emoji reaction to a message.
 func (s *Server) AddMessageReaction(c *fiber.Ctx) error {
 	ctx := c.UserContext()
@@ -175,13 +168,13 @@ func (s *Server) GetMyMentions(c *fiber.Ctx) error {
 	return c.JSON(mentions)
 }
 
-func (s *Server) getMessageReactionSummary(ctx context.Context, messageID, currentUserID uint) ([]MessageReactionSummary, error) {
+func (s *Server) getMessageReactionSummary(ctx context.Context, messageID, currentUserID uint) ([]models.MessageReactionSummary, error) {
 	var reactions []models.MessageReaction
 	if err := s.db.WithContext(ctx).Where("message_id = ?", messageID).Find(&reactions).Error; err != nil {
 		return nil, err
 	}
 	if len(reactions) == 0 {
-		return []MessageReactionSummary{}, nil
+		return []models.MessageReactionSummary{}, nil
 	}
 
 	type aggregate struct {
@@ -198,9 +191,9 @@ func (s *Server) getMessageReactionSummary(ctx context.Context, messageID, curre
 		agg[reaction.Emoji] = entry
 	}
 
-	summary := make([]MessageReactionSummary, 0, len(agg))
+	summary := make([]models.MessageReactionSummary, 0, len(agg))
 	for emoji, entry := range agg {
-		summary = append(summary, MessageReactionSummary{
+		summary = append(summary, models.MessageReactionSummary{
 			Emoji:       emoji,
 			Count:       entry.count,
 			ReactedByMe: entry.mine,
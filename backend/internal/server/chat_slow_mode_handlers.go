@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxSlowModeSeconds caps the per-user send cooldown a chatroom can set.
+const maxSlowModeSeconds = 6 * 60 * 60
+
+func slowModeKey(roomID, userID uint) string {
+	return fmt.Sprintf("chat_slow_mode:%d:%d", roomID, userID)
+}
+
+// SetChatroomSlowMode handles PUT /api/chatrooms/:id/slow-mode. Moderators set
+// how many seconds each member must wait between messages; 0 turns it off.
+func (s *Server) SetChatroomSlowMode(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	roomID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	allowed, err := s.canModerateChatroomByUserID(ctx, actorUserID, roomID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewUnauthorizedError("Chatroom moderation access required"))
+	}
+
+	var req struct {
+		Seconds int `json:"seconds"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	if req.Seconds < 0 || req.Seconds > maxSlowModeSeconds {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError(fmt.Sprintf("seconds must be between 0 and %d", maxSlowModeSeconds)))
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.Conversation{}).
+		Where("id = ? AND is_group = ?", roomID, true).
+		Update("slow_mode_seconds", req.Seconds)
+	if result.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Chatroom", roomID))
+	}
+
+	if s.chatHub != nil {
+		s.chatHub.BroadcastToConversation(roomID, notifications.ChatMessage{
+			Type:           "slow_mode_updated",
+			ConversationID: roomID,
+			UserID:         actorUserID,
+			Payload: map[string]interface{}{
+				"conversation_id":   roomID,
+				"slow_mode_seconds": req.Seconds,
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{"conversation_id": roomID, "slow_mode_seconds": req.Seconds})
+}
+
+// claimSlowModeSlot reserves the user's next send in a slow-mode room. When
+// the user is still inside the cooldown it returns how long is left. The
+// returned release gives the slot back if the send then fails. Moderators,
+// DMs and rooms without slow mode are never throttled.
+func (s *Server) claimSlowModeSlot(ctx context.Context, roomID, userID uint) (time.Duration, func(), error) {
+	release := func() {}
+	if s.redis == nil {
+		return 0, release, nil
+	}
+
+	var room models.Conversation
+	if err := s.db.WithContext(ctx).
+		Select("id", "is_group", "slow_mode_seconds").
+		First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, release, nil
+		}
+		return 0, release, err
+	}
+	if !room.IsGroup || room.SlowModeSeconds <= 0 {
+		return 0, release, nil
+	}
+	exempt, err := s.canModerateChatroomByUserID(ctx, userID, roomID)
+	if err != nil || exempt {
+		return 0, release, err
+	}
+
+	key := slowModeKey(roomID, userID)
+	window := time.Duration(room.SlowModeSeconds) * time.Second
+	claimed, err := s.redis.SetNX(ctx, key, "1", window).Result()
+	if err != nil {
+		return 0, release, err
+	}
+	if !claimed {
+		remaining, err := s.redis.PTTL(ctx, key).Result()
+		if err != nil {
+			return 0, release, err
+		}
+		if remaining <= 0 {
+			remaining = window
+		}
+		return remaining, release, nil
+	}
+	return 0, func() { s.redis.Del(context.Background(), key) }, nil
+}
+
+// respondSlowMode rejects a send made inside the room's cooldown.
+func respondSlowMode(c *fiber.Ctx, remaining time.Duration) error {
+	seconds := int(math.Ceil(remaining.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       slowModeMessage(seconds),
+		"code":        "SLOW_MODE",
+		"retry_after": seconds,
+	})
+}
+
+func slowModeMessage(seconds int) string {
+	return fmt.Sprintf("Slow mode is on; wait %ds before sending another message", seconds)
+}
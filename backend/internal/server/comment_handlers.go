@@ -21,16 +21,21 @@ func (s *Server) CreateComment(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Content string `json:"content"`
+		Content         string `json:"content"`
+		ParentCommentID *uint  `json:"parent_comment_id"`
 	}
 	if parseErr := c.BodyParser(&req); parseErr != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest, models.NewValidationError("Invalid request body"))
 	}
+	if err := s.checkSanctumBanForPost(ctx, userID, postID); err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
 
 	created, err := s.commentSvc().CreateComment(ctx, service.CreateCommentInput{
-		UserID:  userID,
-		PostID:  postID,
-		Content: req.Content,
+		UserID:          userID,
+		PostID:          postID,
+		ParentCommentID: req.ParentCommentID,
+		Content:         req.Content,
 	})
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
@@ -40,7 +45,7 @@ func (s *Server) CreateComment(c *fiber.Ctx) error {
 	if post, postErr := s.postRepo.GetByID(ctx, postID, userID); postErr == nil {
 		commentsCount = post.CommentsCount
 	}
-	s.publishBroadcastEvent(EventCommentCreated, map[string]interface{}{
+	s.publishAuthoredEvent(ctx, userID, EventCommentCreated, map[string]interface{}{
 		"post_id":        postID,
 		"comment":        created,
 		"comments_count": commentsCount,
@@ -50,7 +55,9 @@ func (s *Server) CreateComment(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(created)
 }
 
-// GetComments returns all comments for a post (public)
+// GetComments returns all comments for a post (public). The default is a
+// flat list carrying parent_comment_id and depth; ?view=tree nests replies.
+// ?sort= accepts new (default), old, or top.
 func (s *Server) GetComments(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 
@@ -59,10 +66,13 @@ func (s *Server) GetComments(c *fiber.Ctx) error {
 		return nil
 	}
 
-	comments, err := s.commentSvc().ListComments(ctx, postID)
+	comments, err := s.commentSvc().ListComments(ctx, postID, s.optionalUserID(c), c.Query("sort"))
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
 	}
+	if c.Query("view") == "tree" {
+		comments = service.BuildCommentTree(comments)
+	}
 
 	return c.JSON(comments)
 }
@@ -97,7 +107,7 @@ func (s *Server) UpdateComment(c *fiber.Ctx) error {
 	if post, postErr := s.postRepo.GetByID(ctx, updated.PostID, userID); postErr == nil {
 		commentsCount = post.CommentsCount
 	}
-	s.publishBroadcastEvent(EventCommentUpdated, map[string]interface{}{
+	s.publishAuthoredEvent(ctx, updated.UserID, EventCommentUpdated, map[string]interface{}{
 		"post_id":        updated.PostID,
 		"comment":        updated,
 		"comments_count": commentsCount,
@@ -139,6 +149,47 @@ func (s *Server) DeleteComment(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// LikeComment handles POST /api/posts/:id/comments/:commentId/like
+func (s *Server) LikeComment(c *fiber.Ctx) error {
+	return s.setCommentLike(c, true)
+}
+
+// UnlikeComment handles DELETE /api/posts/:id/comments/:commentId/like
+func (s *Server) UnlikeComment(c *fiber.Ctx) error {
+	return s.setCommentLike(c, false)
+}
+
+func (s *Server) setCommentLike(c *fiber.Ctx, liked bool) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	commentID, err := s.parseID(c, "commentId")
+	if err != nil {
+		return nil
+	}
+
+	toggle := s.commentSvc().UnlikeComment
+	if liked {
+		toggle = s.commentSvc().LikeComment
+	}
+	comment, err := toggle(ctx, userID, postID, commentID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	s.publishAuthoredEvent(ctx, comment.UserID, EventCommentUpdated, map[string]interface{}{
+		"post_id":    postID,
+		"comment":    comment,
+		"updated_at": time.Now().UTC().Format(time.RFC3339Nano),
+	})
+
+	return c.JSON(comment)
+}
+
 func (s *Server) commentSvc() *service.CommentService {
 	return s.commentService
 }
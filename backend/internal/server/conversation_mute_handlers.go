@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MuteConversation handles POST /api/conversations/:id/mute.
+// @Summary Mute a conversation
+// @Description Stop bell and push notifications for a conversation without leaving it. Messages still arrive in realtime.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Success 200 {object} object{conversation_id=int,muted=bool}
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/mute [post]
+func (s *Server) MuteConversation(c *fiber.Ctx) error {
+	return s.setConversationMuted(c, true)
+}
+
+// UnmuteConversation handles DELETE /api/conversations/:id/mute.
+// @Summary Unmute a conversation
+// @Description Resume bell and push notifications for a conversation.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Success 200 {object} object{conversation_id=int,muted=bool}
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/mute [delete]
+func (s *Server) UnmuteConversation(c *fiber.Ctx) error {
+	return s.setConversationMuted(c, false)
+}
+
+func (s *Server) setConversationMuted(c *fiber.Ctx, muted bool) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	res := s.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", convID, userID).
+		Update("muted", muted)
+	if res.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Conversation", convID))
+	}
+
+	return c.JSON(fiber.Map{"conversation_id": convID, "muted": muted})
+}
+
+// mutedParticipants returns the participants of a conversation who have
+// muted it.
+func (s *Server) mutedParticipants(ctx context.Context, convID uint) map[uint]bool {
+	muted := map[uint]bool{}
+	if s.db == nil {
+		return muted
+	}
+	var userIDs []uint
+	if err := s.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND muted = ?", convID, true).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return muted
+	}
+	for _, id := range userIDs {
+		muted[id] = true
+	}
+	return muted
+}
+
+// notifyMessageRecipients raises a bell notification for a new direct message
+// with every other participant who has not muted the conversation. Group
+// traffic never raises one.
+func (s *Server) notifyMessageRecipients(ctx context.Context, conv *models.Conversation, message *models.Message) {
+	if conv.IsGroup {
+		return
+	}
+	muted := s.mutedParticipants(ctx, conv.ID)
+	for _, participant := range conv.Participants {
+		if participant.ID == message.SenderID || muted[participant.ID] {
+			continue
+		}
+		s.publishUserEvent(participant.ID, EventMessageReceived, map[string]interface{}{
+			"conversation_id": conv.ID,
+			"message_id":      message.ID,
+			"is_group":        conv.IsGroup,
+			"from_user":       userSummaryPtr(message.Sender),
+			"preview":         message.Content,
+			"created_at":      time.Now().UTC().Format(time.RFC3339Nano),
+		})
+	}
+}
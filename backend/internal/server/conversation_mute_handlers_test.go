@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendMessage_MutedRecipientGetsMessageButNoBell(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Notification{}))
+	s := newChatHandlerTestServer(db)
+	s.hub = notifications.NewHub(nil)
+	s.chatHub = notifications.NewChatHub(nil)
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	bob := models.User{Username: "bob", Email: "bob@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&bob).Error)
+	conv := models.Conversation{CreatedBy: alice.ID}
+	require.NoError(t, db.Create(&conv).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: conv.ID, UserID: alice.ID}).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: conv.ID, UserID: bob.ID}).Error)
+
+	var actorID uint
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		return c.Next()
+	})
+	app.Post("/conversations/:id/mute", s.MuteConversation)
+	app.Delete("/conversations/:id/mute", s.UnmuteConversation)
+	app.Post("/conversations/:id/messages", s.SendMessage)
+	do := func(userID uint, method, path string, body any) int {
+		t.Helper()
+		actorID = userID
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	send := func() {
+		t.Helper()
+		require.Equal(t, http.StatusCreated, do(alice.ID, http.MethodPost, fmt.Sprintf("/conversations/%d/messages", conv.ID), map[string]string{"content": "hi"}))
+	}
+
+	bell, err := s.hub.Register(bob.ID, nil)
+	require.NoError(t, err)
+	chat := &notifications.Client{Hub: s.chatHub, UserID: bob.ID, Send: make(chan []byte, 8)}
+	s.chatHub.RegisterUser(chat)
+	s.chatHub.JoinConversation(bob.ID, conv.ID)
+	drain := func(ch chan []byte) []string {
+		var types []string
+		for {
+			select {
+			case msg := <-ch:
+				var frame struct {
+					Type string `json:"type"`
+				}
+				require.NoError(t, json.Unmarshal(msg, &frame))
+				types = append(types, frame.Type)
+			case <-time.After(50 * time.Millisecond):
+				return types
+			}
+		}
+	}
+	drain(bell.Send)
+	drain(chat.Send)
+
+	mutePath := fmt.Sprintf("/conversations/%d/mute", conv.ID)
+	require.Equal(t, http.StatusOK, do(bob.ID, http.MethodPost, mutePath, nil))
+	send()
+	assert.Contains(t, drain(chat.Send), "message", "muted recipient still gets the realtime message")
+	assert.NotContains(t, drain(bell.Send), EventMessageReceived)
+
+	require.Equal(t, http.StatusOK, do(bob.ID, http.MethodDelete, mutePath, nil))
+	send()
+	assert.Contains(t, drain(chat.Send), "message")
+	assert.Contains(t, drain(bell.Send), EventMessageReceived)
+
+	var stored int64
+	require.NoError(t, db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", bob.ID, EventMessageReceived).Count(&stored).Error)
+	assert.Equal(t, int64(1), stored, "only the unmuted message is stored as a notification")
+
+	assert.Equal(t, http.StatusNotFound, do(bob.ID, http.MethodPost, "/conversations/9999/mute", nil))
+}
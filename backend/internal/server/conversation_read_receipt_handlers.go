@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShareReadReceipts handles POST /api/conversations/:id/read-receipts.
+// @Summary Share read receipts in a room
+// @Description Publish the caller's read position to the other members of a group room. Direct messages always share it.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Success 200 {object} object{conversation_id=int,share_read_receipts=bool}
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/read-receipts [post]
+func (s *Server) ShareReadReceipts(c *fiber.Ctx) error {
+	return s.setShareReadReceipts(c, true)
+}
+
+// HideReadReceipts handles DELETE /api/conversations/:id/read-receipts.
+// @Summary Stop sharing read receipts in a room
+// @Description Stop publishing the caller's read position to a group room.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Success 200 {object} object{conversation_id=int,share_read_receipts=bool}
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/read-receipts [delete]
+func (s *Server) HideReadReceipts(c *fiber.Ctx) error {
+	return s.setShareReadReceipts(c, false)
+}
+
+func (s *Server) setShareReadReceipts(c *fiber.Ctx, share bool) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	res := s.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", convID, userID).
+		Update("share_read_receipts", share)
+	if res.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Conversation", convID))
+	}
+
+	return c.JSON(fiber.Map{"conversation_id": convID, "share_read_receipts": share})
+}
+
+// sharesReadReceipts reports whether userID's read position in convID may be
+// published: always in direct messages, and in group rooms only once the
+// participant has opted in.
+func (s *Server) sharesReadReceipts(ctx context.Context, convID, userID uint) bool {
+	if s.db == nil {
+		return false
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Joins("JOIN conversations ON conversations.id = conversation_participants.conversation_id").
+		Where("conversation_participants.conversation_id = ? AND conversation_participants.user_id = ?", convID, userID).
+		Where("conversations.is_group = ? OR conversation_participants.share_read_receipts = ?", false, true).
+		Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
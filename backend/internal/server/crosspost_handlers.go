@@ -0,0 +1,106 @@
+package server
+
+import (
+	"errors"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CrosspostPost handles POST /api/posts/:id/crosspost.
+// @Summary Crosspost a post into another sanctum
+// @Description Show an existing published post in another sanctum's feed without duplicating it. The caller must be a member of the target sanctum. A post can be crossposted to each sanctum once.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param request body object{sanctum_id=int} true "Target sanctum"
+// @Success 201 {object} models.Crosspost
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /posts/{id}/crosspost [post]
+func (s *Server) CrosspostPost(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		SanctumID uint `json:"sanctum_id"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.SanctumID == 0 {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("sanctum_id is required"))
+	}
+
+	var post models.Post
+	if err := s.db.WithContext(ctx).Select("id", "sanctum_id", "status").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Post", postID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	// Scheduled and pending posts are invisible to others, so they cannot be
+	// shared yet.
+	if post.Status != models.PostStatusPublished {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Post", postID))
+	}
+	if post.SanctumID != nil && *post.SanctumID == req.SanctumID {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Post already belongs to this sanctum"))
+	}
+
+	var sanctum models.Sanctum
+	if err := s.db.WithContext(ctx).
+		Select("id").
+		Where("id = ? AND status = ?", req.SanctumID, models.SanctumStatusActive).
+		First(&sanctum).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Sanctum", req.SanctumID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	_, member, err := s.getSanctumRoleByUserID(ctx, userID, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !member {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewForbiddenError("Only members can crosspost into this sanctum"))
+	}
+	// Crossposts skip the review queue, so approval-gated sanctums only take
+	// them from their own admins.
+	needsApproval, err := s.checkSanctumPosting(ctx, userID, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	if needsApproval {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewForbiddenError("This sanctum reviews new posts and does not accept crossposts"))
+	}
+
+	crosspost := models.Crosspost{
+		PostID:    post.ID,
+		SanctumID: sanctum.ID,
+		UserID:    userID,
+	}
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&crosspost)
+	if res.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.RespondWithError(c, fiber.StatusConflict,
+			models.NewConflictError("Post is already crossposted to this sanctum"))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(crosspost)
+}
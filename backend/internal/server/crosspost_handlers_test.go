@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCrosspostPost(t *testing.T) {
+	t.Parallel()
+
+	db := setupSanctumHandlerTestDB(t)
+	if err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{}); err != nil {
+		t.Fatalf("migrate posts: %v", err)
+	}
+	s := &Server{
+		db:          db,
+		postService: service.NewPostService(repository.NewPostRepository(db), nil, nil),
+	}
+
+	author := models.User{Username: "author", Email: "author@example.com", Password: "pw"}
+	sharer := models.User{Username: "sharer", Email: "sharer@example.com", Password: "pw"}
+	outsider := models.User{Username: "outsider", Email: "outsider@example.com", Password: "pw"}
+	for _, u := range []*models.User{&author, &sharer, &outsider} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	source := models.Sanctum{Name: "Source", Slug: "source", Status: models.SanctumStatusActive}
+	target := models.Sanctum{Name: "Target", Slug: "target", Status: models.SanctumStatusActive}
+	for _, sc := range []*models.Sanctum{&source, &target} {
+		if err := db.Create(sc).Error; err != nil {
+			t.Fatalf("create sanctum: %v", err)
+		}
+	}
+	if err := db.Create(&models.SanctumMembership{
+		SanctumID: target.ID, UserID: sharer.ID, Role: models.SanctumMembershipRoleMember,
+	}).Error; err != nil {
+		t.Fatalf("create membership: %v", err)
+	}
+	original := models.Post{Title: "Original", Content: "body", UserID: author.ID, SanctumID: &source.ID, Status: models.PostStatusPublished}
+	if err := db.Create(&original).Error; err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		_, _ = fmt.Sscan(c.Get("X-User-ID"), &id)
+		c.Locals("userID", id)
+		return c.Next()
+	})
+	app.Post("/posts/:id/crosspost", s.CrosspostPost)
+	app.Get("/sanctums/:slug/posts", s.GetSanctumPosts)
+
+	crosspostPath := fmt.Sprintf("/posts/%d/crosspost", original.ID)
+	crosspost := func(userID, sanctumID uint) int {
+		t.Helper()
+		body, _ := json.Marshal(map[string]uint{"sanctum_id": sanctumID})
+		req := httptest.NewRequest(http.MethodPost, crosspostPath, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("crosspost: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		return resp.StatusCode
+	}
+
+	if status := crosspost(outsider.ID, target.ID); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-member, got %d", status)
+	}
+	if status := crosspost(sharer.ID, source.ID); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 crossposting into the source sanctum, got %d", status)
+	}
+	if status := crosspost(sharer.ID, target.ID); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if status := crosspost(sharer.ID, target.ID); status != http.StatusConflict {
+		t.Fatalf("expected 409 crossposting the same post twice, got %d", status)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/sanctums/target/posts", nil))
+	if err != nil {
+		t.Fatalf("list target posts: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var feed []models.Post
+	if err := json.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		t.Fatalf("decode feed: %v", err)
+	}
+	if len(feed) != 1 || feed[0].ID != original.ID {
+		t.Fatalf("expected the crossposted post in the target feed, got %+v", feed)
+	}
+	if feed[0].Sanctum == nil || feed[0].Sanctum.Slug != "source" || feed[0].User.ID != author.ID {
+		t.Fatalf("crosspost should be attributed to its source sanctum and author, got %+v", feed[0])
+	}
+
+	var copies int64
+	db.Model(&models.Post{}).Count(&copies)
+	if copies != 1 {
+		t.Fatalf("crossposting must not duplicate the post, found %d posts", copies)
+	}
+}
@@ -38,6 +38,7 @@ func (s *Server) WebsocketHandler() fiber.Handler {
 	return websocket.New(func(conn *websocket.Conn) {
 		middleware.ActiveWebSockets.Inc()
 		defer middleware.ActiveWebSockets.Dec()
+		defer observability.TrackWebSocketConnection("notifications")()
 
 		userIDVal := conn.Locals("userID")
 		if userIDVal == nil {
@@ -18,3 +18,22 @@ func (s *Server) GetFeatureFlags(c *fiber.Ctx) error {
 		"evaluated": s.featureFlags.Snapshot(userID),
 	})
 }
+
+// GetMyFeatureFlags handles GET /api/feature-flags/me.
+// @Summary Resolve feature flags for the current user
+// @Description Evaluates every configured flag (on/off, percentage rollout, or per-user allow-list) for the authenticated user. Raw rollout rules are not exposed.
+// @Tags feature-flags
+// @Produce json
+// @Success 200 {object} object{flags=map[string]bool}
+// @Security BearerAuth
+// @Router /feature-flags/me [get]
+func (s *Server) GetMyFeatureFlags(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(uint)
+
+	flags := map[string]bool{}
+	if s.featureFlags != nil {
+		flags = s.featureFlags.Snapshot(userID)
+	}
+
+	return c.JSON(fiber.Map{"flags": flags})
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/featureflags"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func featureFlagsRequest(t *testing.T, s *Server, userID uint) map[string]bool {
+	t.Helper()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID)
+		return c.Next()
+	})
+	app.Get("/feature-flags/me", s.GetMyFeatureFlags)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/feature-flags/me", nil))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Flags map[string]bool `json:"flags"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body.Flags
+}
+
+func TestGetMyFeatureFlags_ResolvesForUser(t *testing.T) {
+	s := &Server{featureFlags: featureflags.NewManager("beta_dm=users:7|42,new_feed=100%,legacy_ui=off,canary=0%")}
+
+	flags := featureFlagsRequest(t, s, 42)
+	assert.Equal(t, map[string]bool{
+		"beta_dm":   true,
+		"new_feed":  true,
+		"legacy_ui": false,
+		"canary":    false,
+	}, flags)
+
+	flags = featureFlagsRequest(t, s, 8)
+	assert.False(t, flags["beta_dm"])
+	assert.True(t, flags["new_feed"])
+}
+
+func TestGetMyFeatureFlags_NoManager(t *testing.T) {
+	flags := featureFlagsRequest(t, &Server{}, 1)
+	assert.Empty(t, flags)
+}
@@ -156,7 +156,70 @@ func (s *Server) GetFriends(c *fiber.Ctx) error {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
-	return c.JSON(friends)
+	return c.JSON(s.withPresence(friends))
+}
+
+// friendWithPresence is a friend entry annotated with live presence.
+type friendWithPresence struct {
+	models.User
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// withPresence annotates users with whether they are connected right now
+// and, when they are not, when they were last seen.
+func (s *Server) withPresence(users []models.User) []friendWithPresence {
+	out := make([]friendWithPresence, 0, len(users))
+	for _, u := range users {
+		entry := friendWithPresence{User: u}
+		if s.chatHub != nil {
+			entry.Online = s.chatHub.IsUserOnline(u.ID)
+			if !entry.Online {
+				if seen, ok := s.chatHub.LastSeen(u.ID); ok {
+					entry.LastSeenAt = &seen
+				}
+			}
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// GetFriendSuggestions handles GET /api/friends/suggestions
+func (s *Server) GetFriendSuggestions(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	page := parsePagination(c, 20)
+
+	suggestions, err := s.friendSvc().GetFriendSuggestions(ctx, userID, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(suggestions)
+}
+
+// GetMutualFriends handles GET /api/users/:id/mutual-friends
+func (s *Server) GetMutualFriends(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	targetUserID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	friends, err := s.friendSvc().GetMutualFriends(ctx, userID, targetUserID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	if friends == nil {
+		friends = []models.User{}
+	}
+
+	return c.JSON(fiber.Map{
+		"count":   len(friends),
+		"friends": friends,
+	})
 }
 
 // GetFriendshipStatus handles GET /api/friends/status/:userId
@@ -180,6 +243,27 @@ func (s *Server) GetFriendshipStatus(c *fiber.Ctx) error {
 	})
 }
 
+// GetFriendshipStatusBatch handles POST /api/friends/status/batch
+func (s *Server) GetFriendshipStatusBatch(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	var req struct {
+		UserIDs []uint `json:"user_ids"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	statuses, err := s.friendSvc().GetFriendshipStatuses(ctx, userID, req.UserIDs)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(fiber.Map{"statuses": statuses})
+}
+
 // RemoveFriend handles DELETE /api/friends/:userId
 func (s *Server) RemoveFriend(c *fiber.Ctx) error {
 	ctx := c.UserContext()
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGetFriendsIncludesPresence(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Friendship{}))
+
+	me := models.User{Username: "me", Email: "me@example.com", Password: "pw"}
+	online := models.User{Username: "online", Email: "online@example.com", Password: "pw"}
+	away := models.User{Username: "away", Email: "away@example.com", Password: "pw"}
+	for _, u := range []*models.User{&me, &online, &away} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	for _, friend := range []models.User{online, away} {
+		require.NoError(t, db.Create(&models.Friendship{RequesterID: me.ID, AddresseeID: friend.ID, Status: models.FriendshipStatusAccepted}).Error)
+	}
+
+	presence := notifications.NewConnectionManager(nil, notifications.ConnectionManagerConfig{
+		OfflineGracePeriod: 10 * time.Millisecond,
+	})
+	defer presence.Stop()
+	chatHub := notifications.NewChatHub()
+	chatHub.SetPresenceManager(presence)
+
+	ctx := context.Background()
+	presence.Register(ctx, online.ID)
+	presence.Register(ctx, away.ID)
+	presence.Unregister(ctx, away.ID)
+	require.Eventually(t, func() bool {
+		_, ok := presence.LastSeen(ctx, away.ID)
+		return ok
+	}, time.Second, 5*time.Millisecond)
+
+	s := &Server{
+		db:         db,
+		friendRepo: repository.NewFriendRepository(db),
+		userRepo:   repository.NewUserRepository(db),
+		chatHub:    chatHub,
+	}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", me.ID)
+		return c.Next()
+	})
+	app.Get("/friends", s.GetFriends)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/friends", nil))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var friends []struct {
+		ID         uint       `json:"id"`
+		Username   string     `json:"username"`
+		Online     bool       `json:"online"`
+		LastSeenAt *time.Time `json:"last_seen_at"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&friends))
+	require.Len(t, friends, 2)
+
+	byID := map[uint]int{}
+	for i, f := range friends {
+		byID[f.ID] = i
+	}
+	gotOnline := friends[byID[online.ID]]
+	assert.Equal(t, "online", gotOnline.Username)
+	assert.True(t, gotOnline.Online)
+	assert.Nil(t, gotOnline.LastSeenAt)
+
+	gotAway := friends[byID[away.ID]]
+	assert.False(t, gotAway.Online)
+	require.NotNil(t, gotAway.LastSeenAt)
+	assert.WithinDuration(t, time.Now(), *gotAway.LastSeenAt, time.Minute)
+}
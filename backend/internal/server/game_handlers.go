@@ -11,6 +11,7 @@ import (
 	"sanctum/internal/middleware"
 	"sanctum/internal/models"
 	"sanctum/internal/notifications"
+	"sanctum/internal/observability"
 	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -80,6 +81,40 @@ func (s *Server) GetGameStats(c *fiber.Ctx) error {
 	return c.JSON(stats)
 }
 
+// announceAbandonedGame tells a room's players that the cleanup worker
+// cancelled it.
+func (s *Server) announceAbandonedGame(ctx context.Context, room *models.GameRoom) {
+	if s.notifier != nil {
+		if err := s.notifier.PublishGameAction(ctx, room.ID, `{"type":"game_cancelled","payload":{"message":"The game was abandoned"}}`); err != nil {
+			log.Printf("failed to publish game_cancelled for room %d: %v", room.ID, err)
+		}
+	}
+	s.publishGameRoomUpdated(room)
+}
+
+// GetGameLeaderboard handles GET /api/games/leaderboard.
+// @Summary Game leaderboard
+// @Description Top players for a game type by points, then win rate, plus the caller's own rank.
+// @Tags games
+// @Produce json
+// @Param type query string true "Game type"
+// @Param limit query int false "Number of top players (default 10, max 100)"
+// @Success 200 {object} models.GameLeaderboard
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /games/leaderboard [get]
+func (s *Server) GetGameLeaderboard(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	gameType := models.GameType(c.Query("type"))
+
+	board, err := s.gameSvc().GetLeaderboard(ctx, userID, gameType, c.QueryInt("limit", service.DefaultLeaderboardLimit))
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	return c.JSON(board)
+}
+
 // GetGameRoom fetches a specific game room
 func (s *Server) GetGameRoom(c *fiber.Ctx) error {
 	ctx := c.UserContext()
@@ -155,6 +190,7 @@ func (s *Server) WebSocketGameHandler() fiber.Handler {
 	return websocket.New(func(c *websocket.Conn) {
 		middleware.ActiveWebSockets.Inc()
 		defer middleware.ActiveWebSockets.Dec()
+		defer observability.TrackWebSocketConnection("game")()
 
 		userIDVal := c.Locals("userID")
 		if userIDVal == nil {
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGameLeaderboard_RanksByPointsAndIncludesCaller(t *testing.T) {
+	db := setupModerationTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.GameStats{}))
+
+	stats := []struct {
+		name                string
+		wins, losses, games int
+		points              int
+	}{
+		{"ace", 4, 0, 4, 100},
+		{"steady", 4, 4, 8, 100}, // same points as ace, worse win rate
+		{"mid", 3, 1, 4, 75},
+		{"low", 1, 3, 4, 25},
+		{"caller", 0, 5, 5, 0},
+		{"idle", 0, 0, 0, 0}, // never finished a game; not ranked
+	}
+	ids := map[string]uint{}
+	for _, st := range stats {
+		user := models.User{Username: st.name, Email: st.name + "@e.com"}
+		require.NoError(t, db.Create(&user).Error)
+		ids[st.name] = user.ID
+		require.NoError(t, db.Create(&models.GameStats{
+			UserID: user.ID, GameType: models.Othello,
+			Wins: st.wins, Losses: st.losses, TotalGames: st.games, Points: st.points,
+		}).Error)
+	}
+	// Other game types do not leak into the Othello board.
+	require.NoError(t, db.Create(&models.GameStats{
+		UserID: ids["caller"], GameType: models.ConnectFour, Wins: 9, TotalGames: 9, Points: 500,
+	}).Error)
+
+	s := &Server{db: db, gameService: service.NewGameService(repository.NewGameRepository(db))}
+	app := fiber.New()
+	app.Get("/games/leaderboard", func(c *fiber.Ctx) error {
+		c.Locals("userID", ids["caller"])
+		return s.GetGameLeaderboard(c)
+	})
+
+	get := func(query string) (int, models.GameLeaderboard) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/games/leaderboard"+query, nil))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var board models.GameLeaderboard
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&board))
+		}
+		return resp.StatusCode, board
+	}
+
+	status, board := get(fmt.Sprintf("?type=%s&limit=3", models.Othello))
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, board.Entries, 3)
+	assert.Equal(t, []string{"ace", "steady", "mid"}, []string{
+		board.Entries[0].Username, board.Entries[1].Username, board.Entries[2].Username,
+	})
+	assert.Equal(t, []int{1, 2, 3}, []int{board.Entries[0].Rank, board.Entries[1].Rank, board.Entries[2].Rank})
+	assert.InDelta(t, 0.5, board.Entries[1].WinRate, 1e-9)
+
+	require.NotNil(t, board.Me, "caller's rank is returned even outside the top N")
+	assert.Equal(t, ids["caller"], board.Me.UserID)
+	assert.Equal(t, 5, board.Me.Rank)
+
+	status, board = get(fmt.Sprintf("?type=%s", models.Othello))
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, board.Entries, 5, "users without finished games are not ranked")
+
+	status, _ = get("?type=tictactoe")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -27,6 +27,8 @@ type gameRepoStub struct {
 	getMovesFn          func(uint) ([]models.GameMove, error)
 	getStatsFn          func(uint, models.GameType) (*models.GameStats, error)
 	updateStatsFn       func(*models.GameStats) error
+	getLeaderboardFn    func(models.GameType, int, uint) (*models.GameLeaderboard, error)
+	cancelIdleRoomsFn   func(models.GameStatus, time.Time) ([]models.GameRoom, error)
 }
 
 func noopServerGameRepo() *gameRepoStub {
@@ -41,6 +43,10 @@ func noopServerGameRepo() *gameRepoStub {
 		getMovesFn:          func(uint) ([]models.GameMove, error) { return []models.GameMove{}, nil },
 		getStatsFn:          func(uint, models.GameType) (*models.GameStats, error) { return &models.GameStats{}, nil },
 		updateStatsFn:       func(*models.GameStats) error { return nil },
+		getLeaderboardFn: func(gameType models.GameType, _ int, _ uint) (*models.GameLeaderboard, error) {
+			return &models.GameLeaderboard{GameType: gameType}, nil
+		},
+		cancelIdleRoomsFn: func(models.GameStatus, time.Time) ([]models.GameRoom, error) { return nil, nil },
 	}
 }
 
@@ -84,6 +90,14 @@ func (s *gameRepoStub) UpdateStats(stats *models.GameStats) error {
 	return s.updateStatsFn(stats)
 }
 
+func (s *gameRepoStub) GetLeaderboard(gameType models.GameType, limit int, userID uint) (*models.GameLeaderboard, error) {
+	return s.getLeaderboardFn(gameType, limit, userID)
+}
+
+func (s *gameRepoStub) CancelIdleRooms(status models.GameStatus, idleBefore time.Time) ([]models.GameRoom, error) {
+	return s.cancelIdleRoomsFn(status, idleBefore)
+}
+
 func readAction(t *testing.T, client *notifications.Client) map[string]any {
 	t.Helper()
 
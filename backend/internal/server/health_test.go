@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"sanctum/internal/config"
+	"sanctum/internal/notifications"
+
 	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
@@ -111,3 +114,47 @@ func TestReadinessCheck_UnhealthyDB(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestHubsHealthCheck(t *testing.T) {
+	hub := notifications.NewHub()
+	chatHub := notifications.NewChatHub()
+	gameHub := notifications.NewGameHub(nil, nil)
+	s := &Server{hubs: []wireableHub{hub, chatHub, gameHub}}
+
+	client, err := chatHub.Register(7, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { chatHub.UnregisterClient(client) })
+	chatHub.JoinConversation(7, 42)
+
+	app := fiber.New()
+	app.Get("/health/hubs", s.HubsHealthCheck)
+
+	req := httptest.NewRequest(http.MethodGet, "/health/hubs", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Hubs             map[string]notifications.HubStats `json:"hubs"`
+		TotalConnections int                               `json:"total_connections"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, notifications.HubStats{Connections: 1, Users: 1, Rooms: 1, OnlineUsers: 1}, body.Hubs[chatHub.Name()])
+	assert.Equal(t, notifications.HubStats{}, body.Hubs[hub.Name()])
+	assert.Equal(t, notifications.HubStats{}, body.Hubs[gameHub.Name()])
+	assert.Equal(t, 1, body.TotalConnections)
+}
+
+func TestHubsHealthCheckRequiresAuth(t *testing.T) {
+	s := &Server{config: &config.Config{JWTSecret: "test-secret"}}
+	app := fiber.New()
+	s.SetupRoutes(app)
+
+	req := httptest.NewRequest(http.MethodGet, "/health/hubs", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
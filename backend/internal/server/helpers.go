@@ -3,8 +3,11 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 	"unicode"
 
 	"sanctum/internal/models"
@@ -72,6 +75,39 @@ func parsePagination(c *fiber.Ctx, defaultLimit int) Pagination {
 	}
 }
 
+// KeysetCursor identifies the last row of a page ordered by (created_at, id).
+type KeysetCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// parseKeysetCursor reports whether the request is in cursor mode (a cursor
+// query parameter is present, empty for the first page) and decodes it.
+func parseKeysetCursor(c *fiber.Ctx) (*KeysetCursor, bool, error) {
+	if !c.Context().QueryArgs().Has("cursor") {
+		return nil, false, nil
+	}
+	raw := strings.TrimSpace(c.Query("cursor"))
+	if raw == "" {
+		return nil, true, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, true, models.NewValidationError("Invalid cursor")
+	}
+	var nanos int64
+	var id uint
+	if _, err := fmt.Sscanf(string(decoded), "%d:%d", &nanos, &id); err != nil || id == 0 {
+		return nil, true, models.NewValidationError("Invalid cursor")
+	}
+	return &KeysetCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, true, nil
+}
+
+// encodeKeysetCursor returns the opaque cursor string for the given row.
+func encodeKeysetCursor(createdAt time.Time, id uint) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%d", createdAt.UnixNano(), id))
+}
+
 // parseID extracts a route parameter by name as a positive uint.
 // On failure it writes a 400 JSON response and returns errResponseWritten.
 // Callers should check: if err != nil { return nil }
@@ -135,13 +171,32 @@ func (s *Server) isBannedByUserID(ctx context.Context, userID uint) (bool, error
 		return false, nil
 	}
 	var user models.User
-	if err := s.db.WithContext(ctx).Select("is_banned").First(&user, userID).Error; err != nil {
+	if err := s.db.WithContext(ctx).Select("id", "is_banned", "ban_expires_at").First(&user, userID).Error; err != nil {
 		if models.IsSchemaMissingError(err) {
 			return false, nil
 		}
 		return false, err
 	}
-	return user.IsBanned, nil
+	now := time.Now().UTC()
+	if user.IsBanActive(now) {
+		return true, nil
+	}
+	if user.IsBanned {
+		// The suspension has run out; clear it so the user no longer shows
+		// as banned anywhere else.
+		if err := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("id = ? AND is_banned = ? AND ban_expires_at <= ?", userID, true, now).
+			Updates(map[string]interface{}{
+				"is_banned":         false,
+				"banned_at":         nil,
+				"banned_reason":     "",
+				"banned_by_user_id": nil,
+				"ban_expires_at":    nil,
+			}).Error; err != nil {
+			return false, err
+		}
+	}
+	return false, nil
 }
 
 func (s *Server) getSanctumRoleByUserID(ctx context.Context, userID, sanctumID uint) (models.SanctumMembershipRole, bool, error) {
@@ -1,10 +1,21 @@
 package server
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"log"
+	"mime"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/repository"
 	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -32,6 +43,53 @@ type ImageStatusResponse struct {
 	Variants map[string]string `json:"variants"`
 }
 
+const (
+	// imageStatusStreamMaxDuration caps how long a status stream stays open.
+	imageStatusStreamMaxDuration = 5 * time.Minute
+	// imageStatusHeartbeat keeps idle streams alive through proxies.
+	imageStatusHeartbeat = 15 * time.Second
+	// imageStatusPollInterval is used when no notifier is available to push updates.
+	imageStatusPollInterval = time.Second
+	// maxImageStreamsPerUser caps the status streams one user may hold open.
+	maxImageStreamsPerUser = 4
+	// maxImageStreams caps the status streams open on this instance.
+	maxImageStreams = 1000
+)
+
+// imageStreamLimiter tracks open image status streams per user and overall.
+// The zero value is ready to use.
+type imageStreamLimiter struct {
+	mu     sync.Mutex
+	total  int
+	byUser map[uint]int
+}
+
+// acquire reserves a stream slot for userID, reporting false when either cap
+// is reached.
+func (l *imageStreamLimiter) acquire(userID uint) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.total >= maxImageStreams || l.byUser[userID] >= maxImageStreamsPerUser {
+		return false
+	}
+	if l.byUser == nil {
+		l.byUser = make(map[uint]int)
+	}
+	l.total++
+	l.byUser[userID]++
+	return true
+}
+
+// release frees a slot taken by acquire.
+func (l *imageStreamLimiter) release(userID uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	if l.byUser[userID]--; l.byUser[userID] <= 0 {
+		delete(l.byUser, userID)
+	}
+}
+
 // UploadImage handles POST /api/images/upload
 func (s *Server) UploadImage(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(uint)
@@ -80,19 +138,181 @@ func (s *Server) GetImageStatus(c *fiber.Ctx) error {
 	})
 }
 
-// ServeImage is deprecated and now redirects to canonical media URLs.
-func (s *Server) ServeImage(c *fiber.Ctx) error {
+// StreamImageStatus handles GET /api/images/:hash/events. It streams the
+// image's processing status to its uploader as server-sent events, starting
+// with the current status, and closes once the image is ready or has failed.
+func (s *Server) StreamImageStatus(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
 	hash := strings.TrimSpace(c.Params("hash"))
-	if hash == "" {
+	if !isImageHash(hash) {
 		return models.RespondWithError(c, fiber.StatusBadRequest, models.NewValidationError("Invalid image hash"))
 	}
-	// Validate the hash is strictly hex to prevent path traversal
+	img, err := s.imageSvc().GetByHashForUploader(c.UserContext(), hash, userID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	if !s.imageStreams.acquire(userID) {
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": "too many open image status streams",
+		})
+	}
+	initial := notifications.ImageStatusEvent{Hash: img.Hash, Status: img.Status, Error: img.Error}
+
+	parent := s.shutdownCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer s.imageStreams.release(userID)
+		ctx, cancel := context.WithTimeout(parent, imageStatusStreamMaxDuration)
+		defer cancel()
+		s.streamImageStatus(ctx, w, initial)
+	})
+	return nil
+}
+
+func (s *Server) streamImageStatus(ctx context.Context, w *bufio.Writer, current notifications.ImageStatusEvent) {
+	var updates <-chan notifications.ImageStatusEvent
+	if s.notifier != nil {
+		ch, err := s.notifier.SubscribeImageStatus(ctx, current.Hash)
+		if err != nil {
+			log.Printf("image status stream: falling back to polling for %s: %v", current.Hash, err)
+		} else {
+			updates = ch
+		}
+	}
+
+	// Re-read once subscribed so a transition that landed before the
+	// subscription was confirmed is not lost.
+	if latest, ok := s.currentImageStatus(ctx, current.Hash); ok {
+		current = latest
+	}
+	if !writeImageStatusEvent(w, current) || isTerminalImageStatus(current.Status) {
+		return
+	}
+
+	heartbeat := time.NewTicker(imageStatusHeartbeat)
+	defer heartbeat.Stop()
+
+	var poll <-chan time.Time
+	if updates == nil {
+		ticker := time.NewTicker(imageStatusPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-updates:
+			if !ok {
+				return
+			}
+			if !writeImageStatusEvent(w, event) || isTerminalImageStatus(event.Status) {
+				return
+			}
+		case <-poll:
+			latest, ok := s.currentImageStatus(ctx, current.Hash)
+			if !ok || latest.Status == current.Status {
+				continue
+			}
+			current = latest
+			if !writeImageStatusEvent(w, current) || isTerminalImageStatus(current.Status) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := w.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) currentImageStatus(ctx context.Context, hash string) (notifications.ImageStatusEvent, bool) {
+	img, err := s.imageSvc().GetByHashWithVariants(ctx, hash)
+	if err != nil {
+		return notifications.ImageStatusEvent{}, false
+	}
+	return notifications.ImageStatusEvent{Hash: img.Hash, Status: img.Status, Error: img.Error}, true
+}
+
+// writeImageStatusEvent writes one SSE "status" event and reports whether the
+// client is still connected.
+func writeImageStatusEvent(w *bufio.Writer, event notifications.ImageStatusEvent) bool {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return false
+	}
+	if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+		return false
+	}
+	return w.Flush() == nil
+}
+
+func isTerminalImageStatus(status string) bool {
+	return status == repository.ImageStatusReady || status == repository.ImageStatusFailed
+}
+
+// isImageHash reports whether hash is a non-empty lowercase hex string, which
+// keeps it safe to use as a path segment.
+func isImageHash(hash string) bool {
+	if hash == "" {
+		return false
+	}
 	for _, ch := range hash {
 		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
-			return models.RespondWithError(c, fiber.StatusBadRequest, models.NewValidationError("Invalid image hash"))
+			return false
 		}
 	}
-	return c.Redirect(s.imageSvc().BuildMasterImageURL(hash), fiber.StatusMovedPermanently)
+	return true
+}
+
+// ServeImage handles GET /api/images/:hash. Without a size query it redirects
+// to the canonical media URL; with ?size= (thumbnail, medium, original or a
+// ladder width) it serves the closest generated variant directly, in the best
+// format the Accept header allows (avif > webp > jpeg).
+func (s *Server) ServeImage(c *fiber.Ctx) error {
+	hash := strings.TrimSpace(c.Params("hash"))
+	if !isImageHash(hash) {
+		return models.RespondWithError(c, fiber.StatusBadRequest, models.NewValidationError("Invalid image hash"))
+	}
+
+	size := c.Query("size")
+	if size == "" {
+		return c.Redirect(s.imageSvc().BuildMasterImageURL(hash), fiber.StatusMovedPermanently)
+	}
+
+	formats := service.NegotiateImageFormats(c.Get(fiber.HeaderAccept))
+	img, fullPath, final, err := s.imageSvc().ResolveForServing(c.UserContext(), hash, size, formats)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	s.imageSvc().UpdateLastAccessed(c.UserContext(), img.ID)
+
+	contentType := mime.TypeByExtension(filepath.Ext(fullPath))
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	if final {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	} else {
+		// Still processing or served from the master fallback: the same URL
+		// will resolve to a better file shortly, so keep caches short.
+		c.Set(fiber.HeaderCacheControl, "public, max-age=60")
+	}
+	c.Vary(fiber.HeaderAccept)
+	return c.SendFile(fullPath)
 }
 
 func toImageUploadResponse(imageSvc *service.ImageService, image *models.Image) ImageUploadResponse {
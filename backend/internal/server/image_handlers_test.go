@@ -1,19 +1,31 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"sanctum/internal/config"
+	"sanctum/internal/notifications"
+	"sanctum/internal/repository"
 	"sanctum/internal/service"
 	"sanctum/internal/testutil"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 )
 
 func TestUploadAndServeImage(t *testing.T) {
@@ -98,3 +110,282 @@ func TestUploadImageMissingFile(t *testing.T) {
 		t.Fatalf("expected 400, got %d", resp.StatusCode)
 	}
 }
+
+func TestServeImageSizeFallsBackToOriginal(t *testing.T) {
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+	repo := testutil.NewImageRepoStub()
+	svc := service.NewImageService(repo, cfg)
+	s := &Server{config: cfg, imageRepo: repo, imageService: svc}
+
+	uploaded, err := svc.Upload(context.Background(), service.UploadImageInput{
+		UserID:      1,
+		Filename:    "img.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 40, 40),
+	})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	master, err := os.ReadFile(filepath.Join(cfg.ImageUploadDir, uploaded.Hash, "master.jpg"))
+	if err != nil {
+		t.Fatalf("read master: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/images/:hash", s.ServeImage)
+
+	for _, size := range []string{"bogus", service.ImageSizeThumbnail} {
+		resp, reqErr := app.Test(httptest.NewRequest(http.MethodGet, "/api/images/"+uploaded.Hash+"?size="+size, nil))
+		if reqErr != nil {
+			t.Fatalf("serve request failed: %v", reqErr)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("size=%s: expected 200, got %d", size, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "image/jpeg" {
+			t.Fatalf("size=%s: expected image/jpeg, got %q", size, got)
+		}
+		if got := resp.Header.Get("Cache-Control"); strings.Contains(got, "immutable") {
+			t.Fatalf("size=%s: master fallback must not be cached as immutable, got %q", size, got)
+		}
+		if !bytes.Equal(body, master) {
+			t.Fatalf("size=%s: expected original bytes when no variant exists", size)
+		}
+	}
+}
+
+func TestServeImageCachesImmutableOnlyOnceVariantIsReady(t *testing.T) {
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+	repo := testutil.NewImageRepoStub()
+	svc := service.NewImageService(repo, cfg)
+	s := &Server{config: cfg, imageRepo: repo, imageService: svc}
+
+	uploaded, err := svc.Upload(context.Background(), service.UploadImageInput{
+		UserID:      1,
+		Filename:    "img.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 1600, 1600),
+	})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/images/:hash", s.ServeImage)
+
+	cacheControl := func() string {
+		t.Helper()
+		resp, reqErr := app.Test(httptest.NewRequest(http.MethodGet, "/api/images/"+uploaded.Hash+"?size="+service.ImageSizeThumbnail, nil))
+		if reqErr != nil {
+			t.Fatalf("serve request failed: %v", reqErr)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		return resp.Header.Get("Cache-Control")
+	}
+
+	// Still queued: the thumbnail falls back to the master for now.
+	if got := cacheControl(); got != "public, max-age=60" {
+		t.Fatalf("expected short cache while processing, got %q", got)
+	}
+
+	if err := svc.ProcessNext(context.Background()); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if got := cacheControl(); got != "public, max-age=31536000, immutable" {
+		t.Fatalf("expected immutable cache once the variant is ready, got %q", got)
+	}
+}
+
+func TestServeImageNegotiatesFormat(t *testing.T) {
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+	repo := testutil.NewImageRepoStub()
+	svc := service.NewImageService(repo, cfg)
+	s := &Server{config: cfg, imageRepo: repo, imageService: svc}
+
+	uploaded, err := svc.Upload(context.Background(), service.UploadImageInput{
+		UserID:      1,
+		Filename:    "img.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 40, 40),
+	})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/images/:hash", s.ServeImage)
+
+	// The master has JPEG and WebP encodings but no AVIF, so an AVIF-first
+	// client gets WebP and an AVIF-only client falls back to JPEG.
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "image/avif,image/webp,*/*", want: "image/webp"},
+		{accept: "image/avif", want: "image/jpeg"},
+		{accept: "", want: "image/jpeg"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/images/"+uploaded.Hash+"?size=original", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		resp, reqErr := app.Test(req)
+		if reqErr != nil {
+			t.Fatalf("serve request failed: %v", reqErr)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Accept %q: expected 200, got %d", tt.accept, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Type"); got != tt.want {
+			t.Fatalf("Accept %q: expected %s, got %q", tt.accept, tt.want, got)
+		}
+		if !strings.Contains(resp.Header.Get("Vary"), "Accept") {
+			t.Fatalf("Accept %q: expected Vary: Accept", tt.accept)
+		}
+	}
+}
+
+func TestStreamImageStatusReceivesProcessingTransitions(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() { _ = rdb.Close() }()
+
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+	repo := testutil.NewImageRepoStub()
+	svc := service.NewImageService(repo, cfg)
+	notifier := notifications.NewNotifier(rdb)
+	svc.SetStatusPublisher(notifier)
+	s := &Server{config: cfg, imageRepo: repo, imageService: svc, notifier: notifier}
+
+	uploaded, err := svc.Upload(context.Background(), service.UploadImageInput{
+		UserID:      1,
+		Filename:    "img.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 40, 40),
+	})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		userID, _ := strconv.ParseUint(c.Get("X-Test-User"), 10, 32)
+		c.Locals("userID", uint(userID))
+		return c.Next()
+	})
+	app.Get("/api/images/:hash/events", s.StreamImageStatus)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	defer func() { _ = app.Shutdown() }()
+
+	openStream := func(userID uint) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+"/api/images/"+uploaded.Hash+"/events", nil)
+		req.Header.Set("X-Test-User", strconv.FormatUint(uint64(userID), 10))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("events request failed: %v", err)
+		}
+		return resp
+	}
+
+	// Only the uploader may watch the image's processing.
+	stranger := openStream(2)
+	_ = stranger.Body.Close()
+	if stranger.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user, got %d", stranger.StatusCode)
+	}
+
+	resp := openStream(1)
+	defer func() { _ = resp.Body.Close() }()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", got)
+	}
+
+	events := make(chan notifications.ImageStatusEvent, 8)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var event notifications.ImageStatusEvent
+			if json.Unmarshal([]byte(data), &event) == nil {
+				events <- event
+			}
+		}
+	}()
+
+	next := func() notifications.ImageStatusEvent {
+		t.Helper()
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("stream closed early")
+			}
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for status event")
+		}
+		return notifications.ImageStatusEvent{}
+	}
+
+	// The stream opens with the current status once it is subscribed.
+	if got := next(); got.Status != repository.ImageStatusQueued {
+		t.Fatalf("expected initial queued status, got %+v", got)
+	}
+
+	if err := svc.ProcessNext(context.Background()); err != nil {
+		t.Fatalf("process next: %v", err)
+	}
+
+	if got := next(); got.Status != repository.ImageStatusProcessing || got.Hash != uploaded.Hash {
+		t.Fatalf("expected processing event, got %+v", got)
+	}
+	if got := next(); got.Status != repository.ImageStatusReady {
+		t.Fatalf("expected ready event, got %+v", got)
+	}
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected stream to close after ready")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream stayed open after a terminal status")
+	}
+}
+
+func TestImageStreamLimiterCapsStreamsPerUser(t *testing.T) {
+	var limiter imageStreamLimiter
+	for i := 0; i < maxImageStreamsPerUser; i++ {
+		if !limiter.acquire(1) {
+			t.Fatalf("expected stream %d to be allowed", i+1)
+		}
+	}
+	if limiter.acquire(1) {
+		t.Fatal("expected the per-user stream cap to apply")
+	}
+	if !limiter.acquire(2) {
+		t.Fatal("expected another user to still open a stream")
+	}
+	limiter.release(1)
+	if !limiter.acquire(1) {
+		t.Fatal("expected a released slot to be reusable")
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// deviceFingerprintHeader carries an opaque client-generated device ID. There
+// is no fallback: anything shared across browsers, like the User-Agent, would
+// ban everyone on the same browser along with the banned user.
+const deviceFingerprintHeader = "X-Device-Fingerprint"
+
+// clientDeviceHash returns the hex SHA-256 of the request's device
+// fingerprint, or "" when there is nothing to fingerprint.
+func clientDeviceHash(c *fiber.Ctx) string {
+	fingerprint := strings.TrimSpace(c.Get(deviceFingerprintHeader))
+	if fingerprint == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fingerprint))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordClientFingerprint remembers where the user last signed in from so an
+// admin can later ban that IP or device.
+func (s *Server) recordClientFingerprint(ctx context.Context, userID uint, ip, deviceHash string) error {
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"last_ip": ip, "device_hash": deviceHash}).Error
+	if models.IsSchemaMissingError(err) {
+		return nil
+	}
+	return err
+}
+
+// signupBanned reports whether an IP or device ban covers this signup.
+func (s *Server) signupBanned(ctx context.Context, ip, deviceHash string) (bool, error) {
+	if s.db == nil {
+		return false, nil
+	}
+	query := s.db.WithContext(ctx).Model(&models.IPBan{}).Where("ip <> '' AND ip = ?", ip)
+	if deviceHash != "" {
+		query = query.Or("device_hash = ?", deviceHash)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		if models.IsSchemaMissingError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetIPBans handles GET /api/admin/ip-bans.
+// @Summary List IP bans
+// @Description List the IP and device bans that block new signups.
+// @Tags moderation-admin
+// @Produce json
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} models.IPBan
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/ip-bans [get]
+func (s *Server) GetIPBans(c *fiber.Ctx) error {
+	page := parsePagination(c, 100)
+	var bans []models.IPBan
+	if err := s.db.WithContext(c.UserContext()).
+		Preload("BannedByUser").
+		Order("created_at DESC").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&bans).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(bans)
+}
+
+// CreateIPBan handles POST /api/admin/ip-bans.
+// @Summary Ban an IP or a user's device
+// @Description Block new signups from an IP. With user_id instead, bans the IP and device that user last signed in from.
+// @Tags moderation-admin
+// @Accept json
+// @Produce json
+// @Param request body object{ip=string,user_id=int,reason=string} true "Ban target"
+// @Success 201 {object} models.IPBan
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/ip-bans [post]
+func (s *Server) CreateIPBan(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	adminID := c.Locals("userID").(uint)
+
+	var req struct {
+		IP     string `json:"ip"`
+		UserID uint   `json:"user_id"`
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	ip := strings.TrimSpace(req.IP)
+	if (ip == "") == (req.UserID == 0) {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("provide exactly one of ip or user_id"))
+	}
+	if ip != "" && net.ParseIP(ip) == nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("ip must be a valid IP address"))
+	}
+	if req.UserID == adminID {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("cannot ban yourself"))
+	}
+
+	ban := models.IPBan{
+		IP:             ip,
+		Reason:         strings.TrimSpace(req.Reason),
+		BannedByUserID: adminID,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if req.UserID != 0 {
+			var target models.User
+			if err := tx.Select("id", "last_ip", "device_hash").First(&target, req.UserID).Error; err != nil {
+				return err
+			}
+			if target.LastIP == "" && target.DeviceHash == "" {
+				return models.NewValidationError("no IP or device is recorded for this user")
+			}
+			ban.IP = target.LastIP
+			ban.DeviceHash = target.DeviceHash
+		}
+		if err := tx.Create(&ban).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, adminID, models.AuditActionIPBanCreate, "ip_ban", ban.ID, nil, ban)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("User", req.UserID))
+		}
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(ban)
+}
+
+// DeleteIPBan handles DELETE /api/admin/ip-bans/:id.
+// @Summary Lift an IP ban
+// @Description Allow signups from a previously banned IP or device again.
+// @Tags moderation-admin
+// @Produce json
+// @Param id path int true "IP ban ID"
+// @Success 200 {object} object{message=string}
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/ip-bans/{id} [delete]
+func (s *Server) DeleteIPBan(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	adminID := c.Locals("userID").(uint)
+	banID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ban models.IPBan
+		if err := tx.First(&ban, banID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&ban).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, adminID, models.AuditActionIPBanDelete, "ip_ban", banID, ban, nil)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("IP ban", banID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "IP ban lifted"})
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignup_BlockedFromBannedIP(t *testing.T) {
+	db := setupModerationTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.IPBan{}))
+	admin := models.User{Username: "admin", Email: "admin@e.com", IsAdmin: true}
+	banned := models.User{Username: "banned", Email: "banned@e.com", LastIP: "203.0.113.9", DeviceHash: "dev-hash"}
+	require.NoError(t, db.Create(&admin).Error)
+	require.NoError(t, db.Create(&banned).Error)
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	s := &Server{
+		db:       db,
+		config:   &config.Config{JWTSecret: "test_secret"},
+		userRepo: mockRepo,
+	}
+
+	app := fiber.New(fiber.Config{ProxyHeader: "X-Real-IP"})
+	app.Post("/signup", s.Signup)
+	app.Post("/admin/ip-bans", func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return s.CreateIPBan(c)
+	})
+	post := func(path, ip string, body any) int {
+		t.Helper()
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Real-IP", ip)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	signup := func(ip, email string) int {
+		return post("/signup", ip, map[string]string{"username": "newcomer", "email": email, "password": "Password123!"})
+	}
+
+	require.Equal(t, http.StatusCreated, post("/admin/ip-bans", "", map[string]string{"ip": "198.51.100.7", "reason": "ban evasion"}))
+	require.Equal(t, http.StatusCreated, post("/admin/ip-bans", "", map[string]uint{"user_id": banned.ID}))
+
+	assert.Equal(t, http.StatusForbidden, signup("198.51.100.7", "fresh@e.com"), "banned IP")
+	assert.Equal(t, http.StatusForbidden, signup("203.0.113.9", "fresh2@e.com"), "IP recorded for the banned user")
+	assert.Equal(t, http.StatusCreated, signup("192.0.2.44", "fresh3@e.com"), "unrelated IP")
+
+	assert.Equal(t, http.StatusBadRequest, post("/admin/ip-bans", "", map[string]string{"ip": "not-an-ip"}))
+	assert.Equal(t, http.StatusBadRequest, post("/admin/ip-bans", "", map[string]any{}))
+
+	var audited int64
+	require.NoError(t, db.Model(&models.AdminAuditLog{}).Where("action = ?", models.AuditActionIPBanCreate).Count(&audited).Error)
+	assert.Equal(t, int64(2), audited)
+}
+
+func TestClientDeviceHash_IgnoresUserAgent(t *testing.T) {
+	app := fiber.New()
+	var hashes []string
+	app.Get("/", func(c *fiber.Ctx) error {
+		hashes = append(hashes, clientDeviceHash(c))
+		return nil
+	})
+	for _, fingerprint := range []string{"", "device-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderUserAgent, "Mozilla/5.0 (shared browser)")
+		if fingerprint != "" {
+			req.Header.Set(deviceFingerprintHeader, fingerprint)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	require.Len(t, hashes, 2)
+	assert.Empty(t, hashes[0], "a User-Agent alone is shared by too many people to ban")
+	assert.Len(t, hashes[1], 64)
+}
@@ -89,3 +89,67 @@ func TestSetupMiddleware_PreflightBypassesLimiter(t *testing.T) {
 	assert.Equal(t, "http://localhost:5173", preflightResp.Header.Get("Access-Control-Allow-Origin"))
 	assert.Contains(t, preflightResp.Header.Get("Access-Control-Allow-Methods"), http.MethodPost)
 }
+
+func TestSetupMiddleware_StressProfileDisablesLimiter(t *testing.T) {
+	srv := &Server{
+		config: &config.Config{Env: "stress"},
+	}
+
+	app := fiber.New()
+	srv.SetupMiddleware(app)
+	app.Get("/limited", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i := 0; i < 110; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("Origin", "http://localhost:5173")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "http://localhost:5173", resp.Header.Get("Access-Control-Allow-Origin"))
+		_ = resp.Body.Close()
+	}
+}
+
+func TestSetupMiddleware_ProductionProfileEnablesLimiterWithConfiguredOrigins(t *testing.T) {
+	srv := &Server{
+		config: &config.Config{
+			Env:            "production",
+			AllowedOrigins: "https://sanctum.example.com",
+		},
+	}
+
+	app := fiber.New()
+	srv.SetupMiddleware(app)
+	app.Get("/limited", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i := 0; i < 100; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("Origin", "https://sanctum.example.com")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+	req.Header.Set("Origin", "https://sanctum.example.com")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "https://sanctum.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	// The development origins are not allowed once the deployment configures its own.
+	devReq := httptest.NewRequest(http.MethodOptions, "/limited", nil)
+	devReq.Header.Set("Origin", "http://localhost:5173")
+	devReq.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	devResp, err := app.Test(devReq, -1)
+	require.NoError(t, err)
+	defer func() { _ = devResp.Body.Close() }()
+	assert.Empty(t, devResp.Header.Get("Access-Control-Allow-Origin"))
+}
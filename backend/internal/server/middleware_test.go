@@ -117,3 +117,74 @@ func TestServer_AuthRequired(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_AuthRequiredConfiguredIssuers(t *testing.T) {
+	secret := "test-secret-key-12345678901234567890123456789012"
+	s := &Server{
+		config: &config.Config{
+			JWTSecret:    secret,
+			JWTIssuers:   "acme-api, acme-legacy",
+			JWTAudiences: "acme-web",
+		},
+	}
+	app := fiber.New()
+	app.Get("/protected", s.AuthRequired(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/optional", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"userID": s.optionalUserID(c)})
+	})
+
+	sign := func(issuer, audience string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "123",
+			"iss": issuer,
+			"aud": audience,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		str, _ := token.SignedString([]byte(secret))
+		return str
+	}
+
+	minted, err := s.generateAccessToken(123, "acme", "")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		accepted bool
+	}{
+		{name: "configured issuer", token: sign("acme-api", "acme-web"), accepted: true},
+		{name: "secondary configured issuer", token: sign("acme-legacy", "acme-web"), accepted: true},
+		{name: "minted with configured values", token: minted, accepted: true},
+		{name: "default issuer no longer listed", token: sign("sanctum-api", "acme-web")},
+		{name: "unlisted audience", token: sign("acme-api", "sanctum-client")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			_ = resp.Body.Close()
+			if tt.accepted {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/optional", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err = app.Test(req)
+			assert.NoError(t, err)
+			var body map[string]float64
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+			_ = resp.Body.Close()
+			if tt.accepted {
+				assert.Equal(t, float64(123), body["userID"])
+			} else {
+				assert.Zero(t, body["userID"])
+			}
+		})
+	}
+}
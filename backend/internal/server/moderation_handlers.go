@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -204,6 +205,8 @@ func (s *Server) ReportMessage(c *fiber.Ctx) error {
 // @Produce json
 // @Param status query string false "Filter by status"
 // @Param target_type query string false "Filter by target type"
+// @Param priority query string false "Filter by priority (normal or high)"
+// @Param cursor query string false "Keyset cursor; empty for the first page. Switches the response to {reports, next_cursor}"
 // @Success 200 {array} models.ModerationReport
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
@@ -213,6 +216,7 @@ func (s *Server) GetAdminReports(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	status := strings.TrimSpace(c.Query("status"))
 	targetType := strings.TrimSpace(c.Query("target_type"))
+	priority := strings.TrimSpace(strings.ToLower(c.Query("priority")))
 	page := parsePagination(c, 100)
 
 	query := s.db.WithContext(ctx).Model(&models.ModerationReport{})
@@ -222,12 +226,53 @@ func (s *Server) GetAdminReports(c *fiber.Ctx) error {
 	if targetType != "" {
 		query = query.Where("target_type = ?", targetType)
 	}
+	if priority != "" {
+		if priority != models.ReportPriorityNormal && priority != models.ReportPriorityHigh {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("priority must be normal or high"))
+		}
+		query = query.Where("priority = ?", priority)
+	}
 
-	var reports []models.ModerationReport
-	if err := query.
+	query = query.
 		Preload("Reporter").
 		Preload("ReportedUser").
-		Preload("ResolvedByUser").
+		Preload("ResolvedByUser")
+
+	// Cursor mode: ?cursor= (empty for the first page) pages by
+	// (created_at, id) so reports arriving mid-review never shift the queue.
+	// Without it, the legacy limit/offset array is kept.
+	cursor, cursorMode, err := parseKeysetCursor(c)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	if cursorMode {
+		if cursor != nil {
+			query = query.Where("created_at < ? OR (created_at = ? AND id < ?)",
+				cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+		var reports []models.ModerationReport
+		if err := query.
+			Order("created_at DESC").
+			Order("id DESC").
+			Limit(page.Limit).
+			Find(&reports).Error; err != nil {
+			return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		}
+		var nextCursor *string
+		if len(reports) == page.Limit {
+			last := reports[len(reports)-1]
+			encoded := encodeKeysetCursor(last.CreatedAt, last.ID)
+			nextCursor = &encoded
+		}
+		return c.JSON(fiber.Map{
+			"reports":     reports,
+			"next_cursor": nextCursor,
+		})
+	}
+
+	var reports []models.ModerationReport
+	if err := query.
 		Order("created_at DESC").
 		Limit(page.Limit).
 		Offset(page.Offset).
@@ -272,17 +317,17 @@ func (s *Server) ResolveAdminReport(c *fiber.Ctx) error {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
 			models.NewValidationError("status must be resolved or dismissed"))
 	}
-	now := time.Now().UTC()
-	updates := map[string]interface{}{
-		"status":              status,
-		"resolved_by_user_id": adminID,
-		"resolved_at":         now,
-		"resolution_note":     strings.TrimSpace(req.ResolutionNote),
-	}
-	if err := s.db.WithContext(ctx).
-		Model(&models.ModerationReport{}).
-		Where("id = ?", reportID).
-		Updates(updates).Error; err != nil {
+	var previousStatus string
+	note := strings.TrimSpace(req.ResolutionNote)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var resolveErr error
+		previousStatus, resolveErr = resolveReportTx(tx, adminID, reportID, status, note, time.Now().UTC())
+		return resolveErr
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("ModerationReport", reportID))
+		}
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -298,9 +343,154 @@ func (s *Server) ResolveAdminReport(c *fiber.Ctx) error {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
+	// Only the first resolution is announced; re-resolving an already closed
+	// report does not notify the reporter again.
+	if previousStatus == models.ReportStatusOpen {
+		s.notifyReportResolved(&report)
+	}
+
 	return c.JSON(report)
 }
 
+// resolveReportTx marks one report resolved or dismissed inside tx and writes
+// the matching audit row. It returns the report's status before the change.
+func resolveReportTx(tx *gorm.DB, adminID, reportID uint, status, note string, now time.Time) (string, error) {
+	var existing models.ModerationReport
+	if err := tx.First(&existing, reportID).Error; err != nil {
+		return "", err
+	}
+	before := snapshotReportResolution(&existing)
+	if err := tx.Model(&existing).Updates(map[string]interface{}{
+		"status":              status,
+		"resolved_by_user_id": adminID,
+		"resolved_at":         now,
+		"resolution_note":     note,
+	}).Error; err != nil {
+		return "", err
+	}
+	after := reportResolutionSnapshot{Status: status, ResolutionNote: note, ResolvedByUserID: &adminID}
+	if err := recordAdminAction(tx, adminID, models.AuditActionReportResolve, "report", reportID, before, after); err != nil {
+		return "", err
+	}
+	return before.Status, nil
+}
+
+// maxBulkResolveReports caps how many reports one bulk request may resolve.
+const maxBulkResolveReports = 200
+
+// BulkReportResult is the outcome for one report in a bulk resolution.
+type BulkReportResult struct {
+	ID     uint   `json:"id"`
+	OK     bool   `json:"ok"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkResolveAdminReports handles POST /api/admin/reports/resolve-bulk.
+// @Summary Resolve moderation reports in bulk
+// @Description Resolve or dismiss several reports in one transaction. Unknown ids are reported per id and do not block the rest.
+// @Tags moderation-admin
+// @Accept json
+// @Produce json
+// @Param request body object{ids=[]int,status=string,resolution_note=string} true "Reports and resolution"
+// @Success 200 {object} object{results=[]BulkReportResult,resolved=int}
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/reports/resolve-bulk [post]
+func (s *Server) BulkResolveAdminReports(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	adminID := c.Locals("userID").(uint)
+
+	var req struct {
+		IDs            []uint `json:"ids"`
+		Status         string `json:"status"`
+		ResolutionNote string `json:"resolution_note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	status := strings.TrimSpace(strings.ToLower(req.Status))
+	if status != models.ReportStatusResolved && status != models.ReportStatusDismissed {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("status must be resolved or dismissed"))
+	}
+	ids := make([]uint, 0, len(req.IDs))
+	seen := make(map[uint]struct{}, len(req.IDs))
+	for _, id := range req.IDs {
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 || len(ids) > maxBulkResolveReports {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError(fmt.Sprintf("Select between 1 and %d reports", maxBulkResolveReports)))
+	}
+
+	note := strings.TrimSpace(req.ResolutionNote)
+	now := time.Now().UTC()
+	results := make([]BulkReportResult, 0, len(ids))
+	resolved := 0
+	var newlyClosed []uint
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			previousStatus, err := resolveReportTx(tx, adminID, id, status, note, now)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				results = append(results, BulkReportResult{ID: id, Error: "report not found"})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			results = append(results, BulkReportResult{ID: id, OK: true, Status: status})
+			resolved++
+			if previousStatus == models.ReportStatusOpen {
+				newlyClosed = append(newlyClosed, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	if len(newlyClosed) > 0 {
+		var reports []models.ModerationReport
+		if err := s.db.WithContext(ctx).Preload("Reporter").Where("id IN ?", newlyClosed).Find(&reports).Error; err == nil {
+			for i := range reports {
+				s.notifyReportResolved(&reports[i])
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"results":  results,
+		"resolved": resolved,
+	})
+}
+
+// notifyReportResolved tells the reporter how their report was handled. Only
+// the outcome is shared: the resolution note, the acting admin and the
+// reported user stay private. Reporters who opted out are skipped.
+func (s *Server) notifyReportResolved(report *models.ModerationReport) {
+	if report.Reporter == nil || !report.Reporter.NotifyReportUpdates {
+		return
+	}
+	outcome, message := "dismissed", "Thanks for your report. We reviewed it and found no violation of the rules."
+	if report.Status == models.ReportStatusResolved {
+		outcome, message = "actioned", "Thanks for your report. We reviewed it and took action."
+	}
+	s.publishUserEvent(report.ReporterID, EventReportResolved, map[string]interface{}{
+		"report_id":   report.ID,
+		"target_type": report.TargetType,
+		"outcome":     outcome,
+		"message":     message,
+		"resolved_at": report.ResolvedAt,
+	})
+}
+
 func (s *Server) moderationSvc() *service.ModerationService {
 	return s.moderationService
 }
@@ -333,6 +523,7 @@ func (s *Server) GetAdminBanRequests(c *fiber.Ctx) error {
 // @Tags moderation-admin
 // @Produce json
 // @Param q query string false "Search query (username or email)"
+// @Param cursor query string false "Keyset cursor; empty for the first page. Switches the response to {users, next_cursor}"
 // @Success 200 {array} models.User
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
@@ -354,6 +545,33 @@ func (s *Server) GetAdminUsers(c *fiber.Ctx) error {
 		query = query.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ?", like, like)
 	}
 
+	// Cursor mode: ?cursor= (empty for the first page) pages by
+	// (created_at, id) and returns next_cursor; otherwise limit/offset.
+	cursor, cursorMode, err := parseKeysetCursor(c)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	if cursorMode {
+		if cursor != nil {
+			query = query.Where("created_at > ? OR (created_at = ? AND id > ?)",
+				cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+		var users []models.User
+		if err := query.Order("created_at ASC").Order("id ASC").Limit(page.Limit).Find(&users).Error; err != nil {
+			return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		}
+		var nextCursor *string
+		if len(users) == page.Limit {
+			last := users[len(users)-1]
+			encoded := encodeKeysetCursor(last.CreatedAt, last.ID)
+			nextCursor = &encoded
+		}
+		return c.JSON(fiber.Map{
+			"users":       users,
+			"next_cursor": nextCursor,
+		})
+	}
+
 	var users []models.User
 	if err := query.Order("id ASC").Limit(page.Limit).Offset(page.Offset).Find(&users).Error; err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
@@ -393,12 +611,12 @@ func (s *Server) GetAdminUserDetail(c *fiber.Ctx) error {
 
 // BanUser handles POST /api/admin/users/:id/ban.
 // @Summary Ban a user
-// @Description Ban a user from the platform.
+// @Description Ban a user from the platform, permanently or until expires_at (RFC 3339).
 // @Tags moderation-admin
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
-// @Param request body object{reason=string} true "Ban reason"
+// @Param request body object{reason=string,expires_at=string} true "Ban reason and optional expiry"
 // @Success 200 {object} object{message=string}
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
@@ -417,23 +635,59 @@ func (s *Server) BanUser(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Reason string `json:"reason"`
+		Reason    string `json:"reason"`
+		ExpiresAt string `json:"expires_at"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
 			models.NewValidationError("Invalid request body"))
 	}
 	now := time.Now().UTC()
-	updates := map[string]interface{}{
-		"is_banned":         true,
-		"banned_at":         now,
-		"banned_reason":     strings.TrimSpace(req.Reason),
-		"banned_by_user_id": adminID,
+	reason := strings.TrimSpace(req.Reason)
+
+	// An empty expiry keeps the ban permanent; otherwise it is a suspension.
+	var expiresAt *time.Time
+	if raw := strings.TrimSpace(req.ExpiresAt); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("expires_at must be an RFC 3339 timestamp"))
+		}
+		if !parsed.After(now) {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("expires_at must be in the future"))
+		}
+		parsed = parsed.UTC()
+		expiresAt = &parsed
 	}
-	if err := s.db.WithContext(ctx).
-		Model(&models.User{}).
-		Where("id = ?", targetID).
-		Updates(updates).Error; err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target models.User
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+		before := snapshotUserBan(&target)
+		if err := tx.Model(&target).Updates(map[string]interface{}{
+			"is_banned":         true,
+			"banned_at":         now,
+			"banned_reason":     reason,
+			"banned_by_user_id": adminID,
+			"ban_expires_at":    expiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		after := userBanSnapshot{
+			IsBanned:       true,
+			BannedAt:       &now,
+			BannedReason:   reason,
+			BannedByUserID: &adminID,
+			BanExpiresAt:   expiresAt,
+		}
+		return recordAdminAction(tx, adminID, models.AuditActionUserBan, "user", targetID, before, after)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("User", targetID))
+		}
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -453,20 +707,32 @@ func (s *Server) BanUser(c *fiber.Ctx) error {
 // @Router /admin/users/{id}/unban [post]
 func (s *Server) UnbanUser(c *fiber.Ctx) error {
 	ctx := c.UserContext()
+	adminID := c.Locals("userID").(uint)
 	targetID, err := s.parseID(c, "id")
 	if err != nil {
 		return nil
 	}
-	updates := map[string]interface{}{
-		"is_banned":         false,
-		"banned_at":         nil,
-		"banned_reason":     "",
-		"banned_by_user_id": nil,
-	}
-	if err := s.db.WithContext(ctx).
-		Model(&models.User{}).
-		Where("id = ?", targetID).
-		Updates(updates).Error; err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target models.User
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+		before := snapshotUserBan(&target)
+		if err := tx.Model(&target).Updates(map[string]interface{}{
+			"is_banned":         false,
+			"banned_at":         nil,
+			"banned_reason":     "",
+			"banned_by_user_id": nil,
+			"ban_expires_at":    nil,
+		}).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, adminID, models.AuditActionUserUnban, "user", targetID, before, userBanSnapshot{})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("User", targetID))
+		}
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -496,18 +762,72 @@ func (s *Server) createModerationReport(
 		Reason:         reason,
 		Details:        details,
 		Status:         models.ReportStatusOpen,
+		Priority:       models.ReportPriorityNormal,
 	}
-	if err := s.db.WithContext(ctx).Create(report).Error; err != nil {
+	var openCount int64
+	escalated := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		threshold := s.reportEscalationThreshold()
+		if threshold <= 0 {
+			return nil
+		}
+
+		target := tx.Model(&models.ModerationReport{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportStatusOpen)
+		if err := target.Session(&gorm.Session{}).Count(&openCount).Error; err != nil {
+			return err
+		}
+		if openCount < int64(threshold) {
+			return nil
+		}
+
+		// The target is only announced the first time it crosses the
+		// threshold; later reports just join it at high priority.
+		var alreadyHigh int64
+		if err := target.Session(&gorm.Session{}).Where("priority = ?", models.ReportPriorityHigh).Count(&alreadyHigh).Error; err != nil {
+			return err
+		}
+		if err := target.Session(&gorm.Session{}).Update("priority", models.ReportPriorityHigh).Error; err != nil {
+			return err
+		}
+		report.Priority = models.ReportPriorityHigh
+		escalated = alreadyHigh == 0
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+
 	s.publishAdminEvent("moderation_report_created", map[string]interface{}{
 		"id":               report.ID,
 		"target_type":      report.TargetType,
 		"target_id":        report.TargetID,
 		"reported_user_id": report.ReportedUserID,
 		"status":           report.Status,
+		"priority":         report.Priority,
 		"created_at":       report.CreatedAt.UTC().Format(time.RFC3339Nano),
 	})
+	if escalated {
+		s.publishAdminEvent("moderation_report_escalated", map[string]interface{}{
+			"target_type":      report.TargetType,
+			"target_id":        report.TargetID,
+			"reported_user_id": report.ReportedUserID,
+			"open_reports":     openCount,
+			"priority":         models.ReportPriorityHigh,
+		})
+	}
 
 	return report, nil
 }
+
+// reportEscalationThreshold is how many open reports a single target needs
+// before they are all flagged high priority. Zero disables escalation.
+func (s *Server) reportEscalationThreshold() int {
+	if s.config == nil {
+		return 0
+	}
+	return s.config.ReportEscalationThreshold
+}
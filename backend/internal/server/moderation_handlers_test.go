@@ -2,13 +2,18 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"sanctum/internal/config"
 	"sanctum/internal/models"
+	"sanctum/internal/notifications"
 	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -30,6 +35,7 @@ func setupModerationTestDB(t *testing.T) *gorm.DB {
 		&models.Post{},
 		&models.Comment{},
 		&models.ChatroomMute{},
+		&models.AdminAuditLog{},
 	); err != nil {
 		t.Fatalf("migrate sqlite: %v", err)
 	}
@@ -262,6 +268,84 @@ func TestGetAdminReports(t *testing.T) {
 	})
 }
 
+func TestGetAdminReports_CursorPagingIsStable(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db}
+	app := fiber.New()
+	app.Get("/admin/reports", s.GetAdminReports)
+
+	base := time.Now().UTC().Add(-time.Hour)
+	var expected []uint
+	for i := range 5 {
+		report := models.ModerationReport{
+			ReporterID: 1, TargetType: "user", TargetID: 2, Reason: "spam", Status: "open",
+			CreatedAt: base.Add(-time.Duration(i) * time.Minute),
+		}
+		if err := db.Create(&report).Error; err != nil {
+			t.Fatalf("create report: %v", err)
+		}
+		expected = append(expected, report.ID)
+	}
+
+	type cursorPage struct {
+		Reports    []models.ModerationReport `json:"reports"`
+		NextCursor *string                   `json:"next_cursor"`
+	}
+	fetch := func(cursor string) cursorPage {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/admin/reports?limit=2&cursor="+cursor, nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		var page cursorPage
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("decode page: %v", err)
+		}
+		return page
+	}
+
+	var seen []uint
+	page := fetch("")
+	for _, r := range page.Reports {
+		seen = append(seen, r.ID)
+	}
+
+	// A report filed mid-review lands ahead of the cursor and must not shift
+	// the remaining pages.
+	if err := db.Create(&models.ModerationReport{
+		ReporterID: 1, TargetType: "user", TargetID: 3, Reason: "new", Status: "open",
+	}).Error; err != nil {
+		t.Fatalf("create late report: %v", err)
+	}
+
+	for page.NextCursor != nil {
+		page = fetch(*page.NextCursor)
+		for _, r := range page.Reports {
+			seen = append(seen, r.ID)
+		}
+	}
+
+	if fmt.Sprint(seen) != fmt.Sprint(expected) {
+		t.Fatalf("expected reports %v in order, got %v", expected, seen)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/reports?cursor=not-a-cursor", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid cursor, got %d", resp.StatusCode)
+	}
+}
+
 func TestResolveAdminReport(t *testing.T) {
 	t.Parallel()
 	db := setupModerationTestDB(t)
@@ -296,6 +380,92 @@ func TestResolveAdminReport(t *testing.T) {
 	})
 }
 
+func TestResolveAdminReport_NotifiesReporter(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	hub := notifications.NewHub()
+	defer func() {
+		_ = hub.Shutdown(context.Background())
+	}()
+	s := &Server{db: db, hub: hub}
+
+	admin := models.User{Username: "admin", IsAdmin: true, Email: "admin@e.com"}
+	reporter := models.User{Username: "reporter", Email: "reporter@e.com"}
+	optedOut := models.User{Username: "quiet", Email: "quiet@e.com"}
+	for _, u := range []*models.User{&admin, &reporter, &optedOut} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	if err := db.Model(&optedOut).Update("notify_report_updates", false).Error; err != nil {
+		t.Fatalf("opt out: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/admin/reports/:id/resolve", func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return s.ResolveAdminReport(c)
+	})
+	resolve := func(reportID uint, status string) {
+		t.Helper()
+		body := fmt.Sprintf(`{"status":%q,"resolution_note":"warned the user privately"}`, status)
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/admin/reports/%d/resolve", reportID), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+	}
+	newReport := func(reporterID uint) models.ModerationReport {
+		report := models.ModerationReport{ReporterID: reporterID, TargetType: "user", TargetID: admin.ID, Reason: "spam", Status: models.ReportStatusOpen}
+		if err := db.Create(&report).Error; err != nil {
+			t.Fatalf("create report: %v", err)
+		}
+		return report
+	}
+
+	t.Run("reporter hears the outcome", func(t *testing.T) {
+		client, err := hub.Register(reporter.ID, nil)
+		if err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		report := newReport(reporter.ID)
+		resolve(report.ID, models.ReportStatusResolved)
+
+		event := readAction(t, client)
+		if event["type"] != EventReportResolved {
+			t.Fatalf("expected %s, got %v", EventReportResolved, event["type"])
+		}
+		payload, _ := event["payload"].(map[string]any)
+		if payload["outcome"] != "actioned" || payload["report_id"] != float64(report.ID) {
+			t.Fatalf("unexpected payload: %v", payload)
+		}
+		for _, private := range []string{"resolution_note", "resolved_by_user_id", "target_id"} {
+			if _, leaked := payload[private]; leaked {
+				t.Fatalf("payload leaks %s: %v", private, payload)
+			}
+		}
+
+		// Resolving it again does not notify twice.
+		resolve(report.ID, models.ReportStatusDismissed)
+		assertNoAction(t, client)
+	})
+
+	t.Run("opted-out reporter is not notified", func(t *testing.T) {
+		client, err := hub.Register(optedOut.ID, nil)
+		if err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		report := newReport(optedOut.ID)
+		resolve(report.ID, models.ReportStatusDismissed)
+		assertNoAction(t, client)
+	})
+}
+
 func TestGetAdminBanRequests(t *testing.T) {
 	t.Parallel()
 	db := setupModerationTestDB(t)
@@ -353,6 +523,32 @@ func TestGetAdminUsers(t *testing.T) {
 		}
 	})
 
+	t.Run("cursor mode", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/users?limit=1&cursor=", nil)
+		resp, _ := app.Test(req)
+		defer func() { _ = resp.Body.Close() }()
+		var page struct {
+			Users      []models.User `json:"users"`
+			NextCursor *string       `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("decode page: %v", err)
+		}
+		if len(page.Users) != 1 || page.Users[0].ID != admin.ID || page.NextCursor == nil {
+			t.Fatalf("unexpected first page: %+v", page)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/admin/users?limit=1&cursor="+*page.NextCursor, nil)
+		resp2, _ := app.Test(req)
+		defer func() { _ = resp2.Body.Close() }()
+		if err := json.NewDecoder(resp2.Body).Decode(&page); err != nil {
+			t.Fatalf("decode page: %v", err)
+		}
+		if len(page.Users) != 1 || page.Users[0].Username != "target_user" {
+			t.Fatalf("unexpected second page: %+v", page.Users)
+		}
+	})
+
 	t.Run("validation error - query too long", func(t *testing.T) {
 		longQ := "this_is_a_very_long_search_query_that_exceeds_sixty_four_characters_limit_1234567890"
 		req := httptest.NewRequest(http.MethodGet, "/admin/users?q="+longQ, nil)
@@ -445,3 +641,470 @@ func TestUnbanUser(t *testing.T) {
 		}
 	})
 }
+
+func TestBanUser_WritesAdminAuditLog(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db}
+
+	admin := models.User{Username: "admin", IsAdmin: true, Email: "admin7@e.com"}
+	db.Create(&admin)
+	target := models.User{Username: "target", Email: "t7@e.com"}
+	db.Create(&target)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return c.Next()
+	})
+	app.Post("/admin/users/:id/ban", s.BanUser)
+	app.Get("/admin/audit-log", s.GetAdminAuditLog)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/admin/users/%d/ban", target.ID), bytes.NewBufferString(`{"reason":"spam"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("ban request: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var rows []models.AdminAuditLog
+	if err := db.Find(&rows).Error; err != nil {
+		t.Fatalf("load audit rows: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 audit row, got %d", len(rows))
+	}
+	row := rows[0]
+	if row.ActorUserID != admin.ID || row.Action != models.AuditActionUserBan || row.TargetType != "user" || row.TargetID != target.ID {
+		t.Errorf("unexpected audit row: %+v", row)
+	}
+	var before, after userBanSnapshot
+	if err := json.Unmarshal(row.Before, &before); err != nil {
+		t.Fatalf("decode before: %v", err)
+	}
+	if err := json.Unmarshal(row.After, &after); err != nil {
+		t.Fatalf("decode after: %v", err)
+	}
+	if before.IsBanned || !after.IsBanned || after.BannedReason != "spam" {
+		t.Errorf("unexpected snapshots: before=%+v after=%+v", before, after)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/audit-log?action=user.ban", nil)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("audit log request: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var page struct {
+		Entries []models.AdminAuditLog `json:"entries"`
+		Total   int64                  `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("decode audit log: %v", err)
+	}
+	if page.Total != 1 || len(page.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got total=%d entries=%d", page.Total, len(page.Entries))
+	}
+	if page.Entries[0].ID != row.ID || page.Entries[0].Actor == nil || page.Entries[0].Actor.Username != "admin" {
+		t.Errorf("unexpected entry: %+v", page.Entries[0])
+	}
+}
+
+func TestBanUser_UnknownUserWritesNoAudit(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db}
+
+	admin := models.User{Username: "admin", IsAdmin: true, Email: "admin8@e.com"}
+	db.Create(&admin)
+
+	app := fiber.New()
+	app.Post("/admin/users/:id/ban", func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return s.BanUser(c)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/999/ban", bytes.NewBufferString(`{"reason":"spam"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("ban request: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+	var count int64
+	db.Model(&models.AdminAuditLog{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no audit rows, got %d", count)
+	}
+}
+
+func TestExportAdminAuditLog(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db}
+
+	admin := models.User{Username: "admin", IsAdmin: true, Email: "admin-export@e.com"}
+	db.Create(&admin)
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	entries := []models.AdminAuditLog{
+		{ActorUserID: admin.ID, Action: models.AuditActionUserBan, TargetType: "user", TargetID: 1, CreatedAt: from.Add(-time.Minute)},
+		{ActorUserID: admin.ID, Action: models.AuditActionUserBan, TargetType: "user", TargetID: 2, CreatedAt: from, After: json.RawMessage(`{"is_banned":true}`)},
+		{ActorUserID: admin.ID, Action: models.AuditActionUserUnban, TargetType: "user", TargetID: 2, CreatedAt: to.Add(-time.Minute)},
+		{ActorUserID: admin.ID, Action: models.AuditActionUserBan, TargetType: "user", TargetID: 3, CreatedAt: to},
+	}
+	for i := range entries {
+		if err := db.Create(&entries[i]).Error; err != nil {
+			t.Fatalf("create audit entry: %v", err)
+		}
+	}
+	wantIDs := []uint{entries[1].ID, entries[2].ID}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return c.Next()
+	})
+	app.Get("/admin/audit/export", s.ExportAdminAuditLog)
+	export := func(query string) *http.Response {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/admin/audit/export?"+query, nil))
+		if err != nil {
+			t.Fatalf("export request: %v", err)
+		}
+		return resp
+	}
+	rangeQuery := "from=2026-03-01&to=2026-03-08"
+
+	t.Run("json", func(t *testing.T) {
+		resp := export(rangeQuery + "&format=json")
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		var got []models.AdminAuditLog
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode export: %v", err)
+		}
+		if len(got) != len(wantIDs) || got[0].ID != wantIDs[0] || got[1].ID != wantIDs[1] {
+			t.Fatalf("expected entries %v, got %+v", wantIDs, got)
+		}
+		if string(got[0].After) != `{"is_banned":true}` {
+			t.Errorf("unexpected after snapshot: %s", got[0].After)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		resp := export(rangeQuery)
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="admin-audit-2026-03-01-2026-03-08.csv"` {
+			t.Errorf("unexpected content disposition %q", got)
+		}
+		rows, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("parse csv: %v", err)
+		}
+		if len(rows) != len(wantIDs)+1 || rows[0][0] != "id" {
+			t.Fatalf("expected header plus %d rows, got %v", len(wantIDs), rows)
+		}
+		for i, id := range wantIDs {
+			if rows[i+1][0] != fmt.Sprint(id) {
+				t.Errorf("row %d: expected id %d, got %v", i+1, id, rows[i+1])
+			}
+		}
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		resp := export("from=2026-03-08&to=2026-03-01")
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", resp.StatusCode)
+		}
+	})
+
+	var exports []models.AdminAuditLog
+	if err := db.Where("action = ?", models.AuditActionAuditLogExport).Order("id").Find(&exports).Error; err != nil {
+		t.Fatalf("load export audit rows: %v", err)
+	}
+	if len(exports) != 2 {
+		t.Fatalf("expected each successful export to be audited, got %d rows", len(exports))
+	}
+	var snapshot auditExportSnapshot
+	if err := json.Unmarshal(exports[0].After, &snapshot); err != nil {
+		t.Fatalf("decode export snapshot: %v", err)
+	}
+	if exports[0].ActorUserID != admin.ID || snapshot.Format != "json" || snapshot.Entries != int64(len(wantIDs)) || !snapshot.From.Equal(from) || !snapshot.To.Equal(to) {
+		t.Errorf("unexpected export audit row: %+v snapshot=%+v", exports[0], snapshot)
+	}
+}
+
+func TestBulkResolveAdminReports(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db}
+
+	admin := models.User{Username: "admin", IsAdmin: true, Email: "admin9@e.com"}
+	db.Create(&admin)
+	newReport := func() uint {
+		report := models.ModerationReport{ReporterID: admin.ID, TargetType: "user", TargetID: 2, Reason: "spam", Status: models.ReportStatusOpen}
+		if err := db.Create(&report).Error; err != nil {
+			t.Fatalf("create report: %v", err)
+		}
+		return report.ID
+	}
+
+	app := fiber.New()
+	app.Post("/admin/reports/resolve-bulk", func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return s.BulkResolveAdminReports(c)
+	})
+	type bulkResponse struct {
+		Results  []BulkReportResult `json:"results"`
+		Resolved int                `json:"resolved"`
+	}
+	resolve := func(body string) (int, bulkResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/admin/reports/resolve-bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var out bulkResponse
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+		}
+		return resp.StatusCode, out
+	}
+	statusOf := func(id uint) string {
+		var report models.ModerationReport
+		db.First(&report, id)
+		return report.Status
+	}
+
+	t.Run("resolves several reports at once", func(t *testing.T) {
+		a, b, c := newReport(), newReport(), newReport()
+		code, out := resolve(fmt.Sprintf(`{"ids":[%d,%d,%d],"status":"resolved","resolution_note":"spam wave"}`, a, b, c))
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		if out.Resolved != 3 || len(out.Results) != 3 {
+			t.Fatalf("expected 3 resolved, got %+v", out)
+		}
+		for _, id := range []uint{a, b, c} {
+			if got := statusOf(id); got != models.ReportStatusResolved {
+				t.Errorf("report %d: expected resolved, got %q", id, got)
+			}
+		}
+		var audits int64
+		db.Model(&models.AdminAuditLog{}).Where("action = ? AND target_id IN ?", models.AuditActionReportResolve, []uint{a, b, c}).Count(&audits)
+		if audits != 3 {
+			t.Errorf("expected 3 audit rows, got %d", audits)
+		}
+	})
+
+	t.Run("unknown ids return partial results", func(t *testing.T) {
+		valid := newReport()
+		code, out := resolve(fmt.Sprintf(`{"ids":[%d,99999],"status":"dismissed"}`, valid))
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		if out.Resolved != 1 || len(out.Results) != 2 {
+			t.Fatalf("expected 1 of 2 resolved, got %+v", out)
+		}
+		if !out.Results[0].OK || out.Results[0].ID != valid || out.Results[0].Status != models.ReportStatusDismissed {
+			t.Errorf("unexpected result for valid id: %+v", out.Results[0])
+		}
+		if out.Results[1].OK || out.Results[1].ID != 99999 || out.Results[1].Error == "" {
+			t.Errorf("unexpected result for unknown id: %+v", out.Results[1])
+		}
+		if got := statusOf(valid); got != models.ReportStatusDismissed {
+			t.Errorf("expected dismissed, got %q", got)
+		}
+	})
+
+	t.Run("rejects an invalid status", func(t *testing.T) {
+		id := newReport()
+		code, _ := resolve(fmt.Sprintf(`{"ids":[%d],"status":"open"}`, id))
+		if code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", code)
+		}
+		if got := statusOf(id); got != models.ReportStatusOpen {
+			t.Errorf("report should stay open, got %q", got)
+		}
+	})
+
+	t.Run("rejects an empty id list", func(t *testing.T) {
+		code, _ := resolve(`{"ids":[],"status":"resolved"}`)
+		if code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", code)
+		}
+	})
+}
+
+func TestCreateModerationReport_EscalatesAtThreshold(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db, config: &config.Config{ReportEscalationThreshold: 3}}
+	ctx := context.Background()
+
+	var reports []*models.ModerationReport
+	for reporterID := uint(1); reporterID <= 3; reporterID++ {
+		report, err := s.createModerationReport(ctx, reporterID, models.ReportTargetPost, 42, nil, "spam", "")
+		if err != nil {
+			t.Fatalf("create report: %v", err)
+		}
+		reports = append(reports, report)
+	}
+	other, err := s.createModerationReport(ctx, 1, models.ReportTargetPost, 7, nil, "spam", "")
+	if err != nil {
+		t.Fatalf("create report: %v", err)
+	}
+
+	if reports[1].Priority != models.ReportPriorityNormal {
+		t.Errorf("expected second report to stay normal, got %q", reports[1].Priority)
+	}
+	if reports[2].Priority != models.ReportPriorityHigh {
+		t.Errorf("expected third report to be high priority, got %q", reports[2].Priority)
+	}
+	if other.Priority != models.ReportPriorityNormal {
+		t.Errorf("expected unrelated target to stay normal, got %q", other.Priority)
+	}
+
+	app := fiber.New()
+	app.Get("/admin/reports", s.GetAdminReports)
+	req := httptest.NewRequest(http.MethodGet, "/admin/reports?priority=high", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var listed []models.ModerationReport
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(listed) != 3 {
+		t.Fatalf("expected all 3 reports on the target to be high priority, got %d", len(listed))
+	}
+	for _, report := range listed {
+		if report.TargetID != 42 {
+			t.Errorf("unexpected report for target %d in high priority queue", report.TargetID)
+		}
+	}
+}
+
+func TestIsBannedByUserID_BanExpiry(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db}
+	ctx := context.Background()
+
+	past := time.Now().UTC().Add(-time.Hour)
+	future := time.Now().UTC().Add(time.Hour)
+	reason := "cool off"
+	expired := models.User{Username: "expired", Email: "expired@e.com", IsBanned: true, BannedAt: &past, BannedReason: reason, BanExpiresAt: &past}
+	suspended := models.User{Username: "suspended", Email: "suspended@e.com", IsBanned: true, BannedAt: &past, BannedReason: reason, BanExpiresAt: &future}
+	permanent := models.User{Username: "permanent", Email: "permanent@e.com", IsBanned: true, BannedAt: &past, BannedReason: reason}
+	for _, u := range []*models.User{&expired, &suspended, &permanent} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	cases := []struct {
+		name string
+		user models.User
+		want bool
+	}{
+		{"past expiry is unbanned", expired, false},
+		{"future expiry still blocks", suspended, true},
+		{"no expiry is permanent", permanent, true},
+	}
+	for _, tc := range cases {
+		banned, err := s.isBannedByUserID(ctx, tc.user.ID)
+		if err != nil {
+			t.Fatalf("%s: isBannedByUserID: %v", tc.name, err)
+		}
+		if banned != tc.want {
+			t.Errorf("%s: expected banned=%v, got %v", tc.name, tc.want, banned)
+		}
+	}
+
+	var cleared models.User
+	if err := db.First(&cleared, expired.ID).Error; err != nil {
+		t.Fatalf("reload user: %v", err)
+	}
+	if cleared.IsBanned || cleared.BanExpiresAt != nil || cleared.BannedReason != "" {
+		t.Errorf("expected expired ban to be cleared, got %+v", cleared)
+	}
+}
+
+func TestBanUser_WithExpiry(t *testing.T) {
+	t.Parallel()
+	db := setupModerationTestDB(t)
+	s := &Server{db: db}
+
+	admin := models.User{Username: "admin", IsAdmin: true, Email: "admin9@e.com"}
+	db.Create(&admin)
+	target := models.User{Username: "target", Email: "t9@e.com"}
+	db.Create(&target)
+
+	app := fiber.New()
+	app.Post("/admin/users/:id/ban", func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return s.BanUser(c)
+	})
+	ban := func(expiresAt string) int {
+		t.Helper()
+		body, err := json.Marshal(map[string]string{"reason": "suspension", "expires_at": expiresAt})
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/admin/users/%d/ban", target.ID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := ban(time.Now().Add(-time.Minute).Format(time.RFC3339)); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an expiry in the past, got %d", status)
+	}
+	if status := ban("next tuesday"); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unparseable expiry, got %d", status)
+	}
+
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	if status := ban(expiresAt.Format(time.RFC3339)); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	var updated models.User
+	if err := db.First(&updated, target.ID).Error; err != nil {
+		t.Fatalf("reload user: %v", err)
+	}
+	if !updated.IsBanned || updated.BanExpiresAt == nil || !updated.BanExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected ban until %v, got banned=%v expires=%v", expiresAt, updated.IsBanned, updated.BanExpiresAt)
+	}
+}
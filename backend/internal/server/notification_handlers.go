@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/observability"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// storeNotification persists a user event. Failures are logged rather than
+// returned so the realtime delivery still goes out.
+func (s *Server) storeNotification(userID uint, eventType string, payload map[string]interface{}) *models.Notification {
+	if s.db == nil {
+		return nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	notification := &models.Notification{UserID: userID, Type: eventType, Payload: raw}
+	if err := s.db.Create(notification).Error; err != nil {
+		observability.GlobalLogger.ErrorContext(context.Background(), "failed to store notification",
+			slog.String("event_type", eventType),
+			slog.Uint64("user_id", uint64(userID)),
+			slog.String("error", err.Error()),
+		)
+		return nil
+	}
+	return notification
+}
+
+func (s *Server) unreadNotificationCount(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetNotifications handles GET /api/notifications, newest first. Pass
+// ?unread=true to list only unread notifications.
+func (s *Server) GetNotifications(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	page := parsePagination(c, 50)
+
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if c.QueryBool("unread") {
+		query = query.Where("read_at IS NULL")
+	}
+	notifications := []models.Notification{}
+	if err := query.
+		Order("created_at DESC, id DESC").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&notifications).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	unread, err := s.unreadNotificationCount(ctx, userID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"notifications": notifications,
+		"unread_count":  unread,
+	})
+}
+
+// GetUnreadNotificationCount handles GET /api/notifications/unread-count.
+func (s *Server) GetUnreadNotificationCount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	unread, err := s.unreadNotificationCount(c.UserContext(), userID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(fiber.Map{"unread_count": unread})
+}
+
+// MarkNotificationRead handles POST /api/notifications/:id/read.
+func (s *Server) MarkNotificationRead(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	notificationID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	var notification models.Notification
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Limit(1).
+		Find(&notification).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if notification.ID == 0 {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Notification", notificationID))
+	}
+	if notification.ReadAt == nil {
+		now := time.Now().UTC()
+		if err := s.db.WithContext(ctx).Model(&notification).Update("read_at", now).Error; err != nil {
+			return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		}
+	}
+
+	return c.JSON(notification)
+}
+
+// MarkAllNotificationsRead handles POST /api/notifications/read-all.
+func (s *Server) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	result := s.db.WithContext(c.UserContext()).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now().UTC())
+	if result.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, result.Error)
+	}
+	return c.JSON(fiber.Map{"updated": result.RowsAffected, "unread_count": 0})
+}
+
+// ClearNotifications handles DELETE /api/notifications and removes every
+// notification the caller has.
+func (s *Server) ClearNotifications(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	result := s.db.WithContext(c.UserContext()).
+		Where("user_id = ?", userID).
+		Delete(&models.Notification{})
+	if result.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, result.Error)
+	}
+	return c.JSON(fiber.Map{"deleted": result.RowsAffected})
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestNotifications(t *testing.T) {
+	t.Parallel()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Notification{}))
+	s := &Server{db: db}
+
+	user := models.User{Username: "user", Email: "user@example.com", Password: "pw"}
+	other := models.User{Username: "other", Email: "other@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Create(&other).Error)
+
+	s.publishUserEvent(user.ID, EventFriendRequestReceived, map[string]interface{}{"request_id": 1})
+	s.publishUserEvent(user.ID, EventChatMention, map[string]interface{}{"message_id": 2})
+	s.publishUserEvent(user.ID, EventReportResolved, map[string]interface{}{"report_id": 3})
+	s.publishUserEvent(user.ID, EventFriendRemoved, map[string]interface{}{"user_id": other.ID})
+	s.publishUserEvent(other.ID, EventChatMention, map[string]interface{}{"message_id": 4})
+
+	do := func(method, path string) *http.Response {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", user.ID)
+			return c.Next()
+		})
+		app.Get("/notifications", s.GetNotifications)
+		app.Post("/notifications/read-all", s.MarkAllNotificationsRead)
+		app.Delete("/notifications", s.ClearNotifications)
+		app.Post("/notifications/:id/read", s.MarkNotificationRead)
+		resp, err := app.Test(httptest.NewRequest(method, path, nil))
+		require.NoError(t, err)
+		return resp
+	}
+	type listResponse struct {
+		Notifications []models.Notification `json:"notifications"`
+		UnreadCount   int64                 `json:"unread_count"`
+	}
+	list := func(query string) listResponse {
+		resp := do(http.MethodGet, "/notifications"+query)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result listResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	all := list("")
+	require.Len(t, all.Notifications, 3, "only notifiable events are stored, and only the caller's")
+	assert.Equal(t, EventReportResolved, all.Notifications[0].Type, "newest first")
+	assert.JSONEq(t, `{"report_id":3}`, string(all.Notifications[0].Payload))
+	assert.Equal(t, int64(3), all.UnreadCount)
+
+	t.Run("mark one read", func(t *testing.T) {
+		resp := do(http.MethodPost, fmt.Sprintf("/notifications/%d/read", all.Notifications[0].ID))
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		unread := list("?unread=true")
+		assert.Len(t, unread.Notifications, 2)
+		assert.Equal(t, int64(2), unread.UnreadCount)
+		assert.Len(t, list("").Notifications, 3)
+	})
+
+	t.Run("cannot mark another user's notification", func(t *testing.T) {
+		var foreign models.Notification
+		require.NoError(t, db.Where("user_id = ?", other.ID).First(&foreign).Error)
+		resp := do(http.MethodPost, fmt.Sprintf("/notifications/%d/read", foreign.ID))
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("read all", func(t *testing.T) {
+		resp := do(http.MethodPost, "/notifications/read-all")
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result struct {
+			Updated int64 `json:"updated"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, int64(2), result.Updated)
+
+		unread := list("?unread=true")
+		assert.Empty(t, unread.Notifications)
+		assert.Zero(t, unread.UnreadCount)
+	})
+
+	t.Run("clear all", func(t *testing.T) {
+		resp := do(http.MethodDelete, "/notifications")
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, list("").Notifications)
+
+		var remaining int64
+		require.NoError(t, db.Model(&models.Notification{}).Where("user_id = ?", other.ID).Count(&remaining).Error)
+		assert.Equal(t, int64(1), remaining, "other users' notifications are untouched")
+	})
+}
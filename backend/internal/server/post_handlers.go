@@ -2,6 +2,7 @@
 package server
 
 import (
+	"context"
 	"strconv"
 	"time"
 
@@ -40,34 +41,57 @@ func (s *Server) CreatePost(c *fiber.Ctx) error {
 		YoutubeURL string                       `json:"youtube_url,omitempty"`
 		SanctumID  *uint                        `json:"sanctum_id,omitempty"`
 		Poll       *service.CreatePostPollInput `json:"poll,omitempty"`
+		PublishAt  *time.Time                   `json:"publish_at,omitempty"`
+		Tags       []string                     `json:"tags,omitempty"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
 			models.NewValidationError("Invalid request body"))
 	}
 
+	var requiresApproval bool
+	if req.SanctumID != nil {
+		needsApproval, err := s.checkSanctumPosting(ctx, userID, *req.SanctumID)
+		if err != nil {
+			return models.RespondWithError(c, mapServiceError(err), err)
+		}
+		requiresApproval = needsApproval
+	}
+
 	post, err := s.postSvc().CreatePost(ctx, service.CreatePostInput{
-		UserID:     userID,
-		Title:      req.Title,
-		Content:    req.Content,
-		ImageURL:   req.ImageURL,
-		PostType:   req.PostType,
-		LinkURL:    req.LinkURL,
-		YoutubeURL: req.YoutubeURL,
-		SanctumID:  req.SanctumID,
-		Poll:       req.Poll,
+		UserID:           userID,
+		Title:            req.Title,
+		Content:          req.Content,
+		ImageURL:         req.ImageURL,
+		PostType:         req.PostType,
+		LinkURL:          req.LinkURL,
+		YoutubeURL:       req.YoutubeURL,
+		SanctumID:        req.SanctumID,
+		Poll:             req.Poll,
+		PublishAt:        req.PublishAt,
+		Tags:             req.Tags,
+		RequiresApproval: requiresApproval,
 	})
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
 	}
 
-	s.publishBroadcastEvent(EventPostCreated, map[string]interface{}{
+	// Scheduled posts are announced by the publish worker once they go live,
+	// and posts awaiting review once a sanctum admin approves them.
+	if post.Status != models.PostStatusScheduled && post.Status != models.PostStatusPendingReview {
+		s.announcePost(post)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(post)
+}
+
+// announcePost broadcasts post_created for a post that just became visible.
+func (s *Server) announcePost(post *models.Post) {
+	s.publishAuthoredEvent(context.Background(), post.UserID, EventPostCreated, map[string]interface{}{
 		"post_id":    post.ID,
 		"author_id":  post.UserID,
 		"created_at": time.Now().UTC().Format(time.RFC3339Nano),
 	})
-
-	return c.Status(fiber.StatusCreated).JSON(post)
 }
 
 // GetPosts handles GET /api/posts
@@ -152,11 +176,12 @@ func (s *Server) UpdatePost(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Title      string `json:"title"`
-		Content    string `json:"content"`
-		ImageURL   string `json:"image_url,omitempty"`
-		LinkURL    string `json:"link_url,omitempty"`
-		YoutubeURL string `json:"youtube_url,omitempty"`
+		Title      string    `json:"title"`
+		Content    string    `json:"content"`
+		ImageURL   string    `json:"image_url,omitempty"`
+		LinkURL    string    `json:"link_url,omitempty"`
+		YoutubeURL string    `json:"youtube_url,omitempty"`
+		Tags       *[]string `json:"tags,omitempty"`
 	}
 	parseErr := c.BodyParser(&req)
 	if parseErr != nil {
@@ -172,6 +197,7 @@ func (s *Server) UpdatePost(c *fiber.Ctx) error {
 		ImageURL:   req.ImageURL,
 		LinkURL:    req.LinkURL,
 		YoutubeURL: req.YoutubeURL,
+		Tags:       req.Tags,
 	})
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
@@ -248,7 +274,88 @@ func (s *Server) UnlikePost(c *fiber.Ctx) error {
 	return c.JSON(post)
 }
 
-// VotePoll handles POST /api/posts/:id/poll/vote
+// GetPostRevisions handles GET /api/posts/:id/revisions (author or admin only)
+func (s *Server) GetPostRevisions(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	revisions, err := s.postSvc().ListPostRevisions(ctx, postID, userID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(revisions)
+}
+
+// BookmarkPost handles POST /api/posts/:id/bookmark
+func (s *Server) BookmarkPost(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	if err := s.postSvc().BookmarkPost(ctx, userID, postID); err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(fiber.Map{"post_id": postID, "bookmarked": true})
+}
+
+// UnbookmarkPost handles DELETE /api/posts/:id/bookmark
+func (s *Server) UnbookmarkPost(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	if err := s.postSvc().UnbookmarkPost(ctx, userID, postID); err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(fiber.Map{"post_id": postID, "bookmarked": false})
+}
+
+// GetMyBookmarks handles GET /api/users/me/bookmarks
+func (s *Server) GetMyBookmarks(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	page := parsePagination(c, 20)
+
+	posts, err := s.postSvc().ListBookmarks(ctx, userID, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(posts)
+}
+
+// GetFeed handles GET /api/feed: posts from the user's sanctums and friends.
+func (s *Server) GetFeed(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	page := parsePagination(c, 20)
+
+	posts, err := s.postSvc().ListFeed(ctx, userID, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if posts == nil {
+		posts = []*models.Post{}
+	}
+
+	return c.JSON(posts)
+}
+
+// VotePoll handles POST /api/posts/:id/poll/vote. The body carries
+// poll_option_ids (or a single poll_option_id) and replaces the user's vote.
 func (s *Server) VotePoll(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	userID := c.Locals("userID").(uint)
@@ -258,18 +365,23 @@ func (s *Server) VotePoll(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		PollOptionID uint `json:"poll_option_id"`
+		PollOptionID  uint   `json:"poll_option_id"`
+		PollOptionIDs []uint `json:"poll_option_ids"`
 	}
 	if bodyErr := c.BodyParser(&req); bodyErr != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
 			models.NewValidationError("Invalid request body"))
 	}
-	if req.PollOptionID == 0 {
+	optionIDs := req.PollOptionIDs
+	if req.PollOptionID != 0 {
+		optionIDs = append(optionIDs, req.PollOptionID)
+	}
+	if len(optionIDs) == 0 {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
-			models.NewValidationError("poll_option_id is required"))
+			models.NewValidationError("poll_option_id or poll_option_ids is required"))
 	}
 
-	post, err := s.postSvc().VotePoll(ctx, userID, postID, req.PollOptionID)
+	post, err := s.postSvc().VotePoll(ctx, userID, postID, optionIDs)
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
 	}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"sanctum/internal/models"
 	"sanctum/internal/service"
@@ -44,6 +45,21 @@ func (m *MockPostRepository) GetBySanctumID(ctx context.Context, sanctumID uint,
 	return args.Get(0).([]*models.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) GetBySanctumTag(ctx context.Context, sanctumID uint, tag string, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error) {
+	args := m.Called(ctx, sanctumID, tag, limit, offset, currentUserID, sort)
+	return args.Get(0).([]*models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) ListSanctumTags(ctx context.Context, sanctumID uint, limit int) ([]models.TagCount, error) {
+	args := m.Called(ctx, sanctumID, limit)
+	return args.Get(0).([]models.TagCount), args.Error(1)
+}
+
+func (m *MockPostRepository) SetTags(ctx context.Context, postID uint, tags []string) error {
+	args := m.Called(ctx, postID, tags)
+	return args.Error(0)
+}
+
 func (m *MockPostRepository) List(ctx context.Context, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error) {
 	args := m.Called(ctx, limit, offset, currentUserID, sort)
 	return args.Get(0).([]*models.Post), args.Error(1)
@@ -87,21 +103,56 @@ func (m *MockPostRepository) Unlike(ctx context.Context, userID, postID uint) er
 	return args.Error(0)
 }
 
+func (m *MockPostRepository) PublishDue(ctx context.Context, now time.Time) ([]*models.Post, error) {
+	args := m.Called(ctx, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) UpdateWithRevision(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	args := m.Called(ctx, post, revision)
+	return args.Error(0)
+}
+
+func (m *MockPostRepository) GetRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	args := m.Called(ctx, postID)
+	return args.Get(0).([]models.PostRevision), args.Error(1)
+}
+
+func (m *MockPostRepository) Bookmark(ctx context.Context, userID, postID uint) error {
+	args := m.Called(ctx, userID, postID)
+	return args.Error(0)
+}
+
+func (m *MockPostRepository) Unbookmark(ctx context.Context, userID, postID uint) error {
+	args := m.Called(ctx, userID, postID)
+	return args.Error(0)
+}
+
+func (m *MockPostRepository) GetBookmarked(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	return args.Get(0).([]*models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) GetFeed(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	return args.Get(0).([]*models.Post), args.Error(1)
+}
+
 // MockPollRepository is a mock of the PollRepository interface
 type MockPollRepository struct {
 	mock.Mock
 }
 
-func (m *MockPollRepository) Create(ctx context.Context, postID uint, question string, options []string) (*models.Poll, error) {
-	args := m.Called(ctx, postID, question, options)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Poll), args.Error(1)
+func (m *MockPollRepository) Create(ctx context.Context, poll *models.Poll, options []string) error {
+	args := m.Called(ctx, poll, options)
+	return args.Error(0)
 }
 
-func (m *MockPollRepository) Vote(ctx context.Context, userID, pollID, pollOptionID uint) error {
-	args := m.Called(ctx, userID, pollID, pollOptionID)
+func (m *MockPollRepository) SetVotes(ctx context.Context, userID, pollID uint, optionIDs []uint) error {
+	args := m.Called(ctx, userID, pollID, optionIDs)
 	return args.Error(0)
 }
 
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newPushSender builds the Web Push sender from the VAPID config. Push
+// delivery stays disabled (nil sender) until keys are configured.
+func newPushSender(cfg *config.Config) (service.PushSender, error) {
+	if cfg.VAPIDPublicKey == "" || cfg.VAPIDPrivateKey == "" {
+		return nil, nil
+	}
+	return service.NewWebPushSender(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+}
+
+// isUserConnected reports whether the user has a live notification or chat
+// WebSocket, in which case realtime delivery already reaches them.
+func (s *Server) isUserConnected(userID uint) bool {
+	if s.hub != nil && s.hub.IsOnline(userID) {
+		return true
+	}
+	return s.chatHub != nil && s.chatHub.IsUserOnline(userID)
+}
+
+// pushToOfflineRecipients sends a Web Push for a direct message to every
+// other participant who has no active WebSocket and has not muted the
+// conversation.
+func (s *Server) pushToOfflineRecipients(conv *models.Conversation, message *models.Message) {
+	if !s.pushService.Enabled() || conv == nil || conv.IsGroup {
+		return
+	}
+	muted := s.mutedParticipants(context.Background(), conv.ID)
+	var offline []uint
+	for _, participant := range conv.Participants {
+		if participant.ID == message.SenderID || muted[participant.ID] || s.isUserConnected(participant.ID) {
+			continue
+		}
+		offline = append(offline, participant.ID)
+	}
+	s.pushService.DispatchMessage(message, offline)
+}
+
+// GetVAPIDPublicKey handles GET /api/push/vapid-public-key
+// Browsers need the key to create a push subscription.
+func (s *Server) GetVAPIDPublicKey(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"enabled":    s.pushService.Enabled(),
+		"public_key": s.config.VAPIDPublicKey,
+	})
+}
+
+type pushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// SubscribePush handles POST /api/push/subscriptions
+// The body is the browser's PushSubscription serialized with toJSON().
+func (s *Server) SubscribePush(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	var req pushSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	sub, err := s.pushService.Subscribe(ctx, service.SubscribeInput{
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(sub)
+}
+
+// UnsubscribePush handles DELETE /api/push/subscriptions
+func (s *Server) UnsubscribePush(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Endpoint == "" {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("endpoint is required"))
+	}
+
+	if err := s.pushService.Unsubscribe(ctx, userID, req.Endpoint); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPushSender struct {
+	mu   sync.Mutex
+	sent map[uint][]json.RawMessage
+}
+
+func (s *stubPushSender) Send(_ context.Context, sub *models.PushSubscription, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sent == nil {
+		s.sent = make(map[uint][]json.RawMessage)
+	}
+	s.sent[sub.UserID] = append(s.sent[sub.UserID], payload)
+	return nil
+}
+
+func (s *stubPushSender) sentTo(userID uint) []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent[userID]
+}
+
+func TestSendMessage_PushesToOfflineRecipients(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.PushSubscription{}))
+	s := newChatHandlerTestServer(db)
+	sender := &stubPushSender{}
+	s.pushService = service.NewPushService(db, sender)
+	s.hub = notifications.NewHub(nil)
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	bob := models.User{Username: "bob", Email: "bob@example.com", Password: "pw"}
+	carol := models.User{Username: "carol", Email: "carol@example.com", Password: "pw"}
+	for _, u := range []*models.User{&alice, &bob, &carol} {
+		require.NoError(t, db.Create(u).Error)
+	}
+
+	var actorID uint
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		return c.Next()
+	})
+	app.Post("/push/subscriptions", s.SubscribePush)
+	app.Post("/conversations/:id/messages", s.SendMessage)
+	do := func(userID uint, path string, body any) *http.Response {
+		actorID = userID
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	for _, u := range []models.User{bob, carol} {
+		resp := do(u.ID, "/push/subscriptions", map[string]any{
+			"endpoint": "https://push.example.com/" + u.Username,
+			"keys":     map[string]string{"p256dh": "key-" + u.Username, "auth": "auth-" + u.Username},
+		})
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	dm := func(other models.User) models.Conversation {
+		conv := models.Conversation{CreatedBy: alice.ID}
+		require.NoError(t, db.Create(&conv).Error)
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: conv.ID, UserID: alice.ID}).Error)
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: conv.ID, UserID: other.ID}).Error)
+		return conv
+	}
+	withBob := dm(bob)
+	withCarol := dm(carol)
+
+	// Bob has a live notification socket; Carol has none.
+	s.hub.Register(bob.ID, nil)
+
+	for _, conv := range []models.Conversation{withBob, withCarol} {
+		resp := do(alice.ID, fmt.Sprintf("/conversations/%d/messages", conv.ID), map[string]string{"content": "hi"})
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	require.Eventually(t, func() bool { return len(sender.sentTo(carol.ID)) == 1 }, 2*time.Second, 10*time.Millisecond)
+	var payload service.PushMessagePayload
+	require.NoError(t, json.Unmarshal(sender.sentTo(carol.ID)[0], &payload))
+	assert.Equal(t, withCarol.ID, payload.ConversationID)
+	assert.Equal(t, alice.ID, payload.SenderID)
+	assert.Equal(t, "hi", payload.Preview)
+	assert.Empty(t, sender.sentTo(bob.ID), "online recipients are reached over WebSocket")
+	assert.Empty(t, sender.sentTo(alice.ID), "the sender is never pushed")
+}
+
+func TestSubscribePush_RejectsTakeoverAndInternalEndpoints(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.PushSubscription{}))
+	s := newChatHandlerTestServer(db)
+	s.pushService = service.NewPushService(db, &stubPushSender{})
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	mallory := models.User{Username: "mallory", Email: "mallory@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&mallory).Error)
+
+	var actorID uint
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		return c.Next()
+	})
+	app.Post("/push/subscriptions", s.SubscribePush)
+	subscribe := func(userID uint, endpoint, key string) int {
+		actorID = userID
+		payload, err := json.Marshal(map[string]any{
+			"endpoint": endpoint,
+			"keys":     map[string]string{"p256dh": key, "auth": "auth-" + key},
+		})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/push/subscriptions", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	const endpoint = "https://push.example.com/alice-device"
+	require.Equal(t, http.StatusCreated, subscribe(alice.ID, endpoint, "k1"))
+	assert.Equal(t, http.StatusCreated, subscribe(alice.ID, endpoint, "k2"), "the owner can refresh keys")
+	assert.Equal(t, http.StatusConflict, subscribe(mallory.ID, endpoint, "evil"))
+
+	var sub models.PushSubscription
+	require.NoError(t, db.Where("endpoint = ?", endpoint).First(&sub).Error)
+	assert.Equal(t, alice.ID, sub.UserID)
+	assert.Equal(t, "k2", sub.P256dh)
+
+	for _, internal := range []string{"https://127.0.0.1/push", "https://localhost/push", "https://192.168.1.10/push", "https://169.254.169.254/push"} {
+		assert.Equal(t, http.StatusBadRequest, subscribe(mallory.ID, internal, "k"), internal)
+	}
+}
@@ -29,8 +29,21 @@ const (
 	EventSanctumRequestCreated  = "sanctum_request_created"
 	EventSanctumRequestReviewed = "sanctum_request_reviewed"
 	EventGameRoomUpdated        = "game_room_updated"
+	EventReportResolved         = "report_resolved"
 )
 
+// notifiableEvents are the user events that are also stored as notifications,
+// so they can be listed and marked read after the realtime frame is gone.
+var notifiableEvents = map[string]bool{
+	EventMessageReceived:        true,
+	EventChatMention:            true,
+	EventFriendRequestReceived:  true,
+	EventFriendRequestAccepted:  true,
+	EventSanctumRequestCreated:  true,
+	EventSanctumRequestReviewed: true,
+	EventReportResolved:         true,
+}
+
 func (s *Server) publishAdminEvent(eventType string, payload map[string]interface{}) {
 	// Find all admin IDs
 	var adminIDs []uint
@@ -52,6 +65,11 @@ func (s *Server) publishUserEvent(userID uint, eventType string, payload map[str
 		"type":    eventType,
 		"payload": payload,
 	}
+	if notifiableEvents[eventType] {
+		if notification := s.storeNotification(userID, eventType, payload); notification != nil {
+			event["notification_id"] = notification.ID
+		}
+	}
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
 		observability.GlobalLogger.ErrorContext(context.Background(), "failed to marshal user event",
@@ -3,9 +3,11 @@ package server
 import (
 	"context"
 	"errors"
+	"strconv"
 	"strings"
 	"time"
 
+	"sanctum/internal/cache"
 	"sanctum/internal/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -235,6 +237,13 @@ func (s *Server) PromoteSanctumAdmin(c *fiber.Ctx) error {
 	if err := s.upsertSanctumRoomModerator(ctx, sanctum.ID, targetUserID, actorUserID); err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
+	roomIDs, err := joinSanctumRooms(s.db.WithContext(ctx), targetUserID, []uint{sanctum.ID})
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	for _, roomID := range roomIDs {
+		cache.InvalidateRoom(ctx, roomID)
+	}
 
 	var updated models.SanctumMembership
 	if err := s.db.WithContext(ctx).
@@ -330,3 +339,91 @@ func (s *Server) DemoteSanctumAdmin(c *fiber.Ctx) error {
 
 	return c.JSON(toSanctumAdminDTO(updated))
 }
+
+// ownerInactivityWindow resolves the inactivity period from ?days= or the
+// SANCTUM_OWNER_INACTIVE_DAYS policy. Zero means the policy is disabled.
+func (s *Server) ownerInactivityWindow(c *fiber.Ctx) (time.Duration, error) {
+	days := 0
+	if s.config != nil {
+		days = s.config.SanctumOwnerInactiveDays
+	}
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return 0, models.NewValidationError("days must be a positive integer")
+		}
+		days = parsed
+	}
+	if days <= 0 {
+		return 0, models.NewValidationError("Sanctum owner inactivity policy is disabled")
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// GetInactiveOwnerSanctums handles GET /api/admin/sanctums/inactive-owners.
+// @Summary List sanctums with inactive owners
+// @Description Report active sanctums whose owner has been inactive past the configured window, with the member who would take over.
+// @Tags sanctums-admin
+// @Produce json
+// @Param days query int false "Inactivity window in days (defaults to SANCTUM_OWNER_INACTIVE_DAYS)"
+// @Success 200 {array} service.InactiveOwnerSanctum
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/sanctums/inactive-owners [get]
+func (s *Server) GetInactiveOwnerSanctums(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	window, err := s.ownerInactivityWindow(c)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest, err)
+	}
+
+	report, err := s.ownershipService.FindInactiveOwners(ctx, window, time.Now().UTC())
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(report)
+}
+
+// ReassignInactiveSanctumOwner handles POST /api/admin/sanctums/:slug/reassign-owner.
+// @Summary Reassign an inactive sanctum owner
+// @Description Demote an inactive owner and promote the longest-tenured active moderator, or archive the sanctum if none is active.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param days query int false "Inactivity window in days (defaults to SANCTUM_OWNER_INACTIVE_DAYS)"
+// @Success 200 {object} service.OwnershipReassignment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/sanctums/{slug}/reassign-owner [post]
+func (s *Server) ReassignInactiveSanctumOwner(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+
+	window, err := s.ownerInactivityWindow(c)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest, err)
+	}
+
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	result, err := s.ownershipService.ReassignInactiveOwner(ctx, sanctum.ID, window, time.Now().UTC())
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	if result.NewOwnerUserID != nil {
+		if err := s.upsertSanctumRoomModerator(ctx, sanctum.ID, *result.NewOwnerUserID, actorUserID); err != nil {
+			return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		}
+	}
+	cache.InvalidateSanctum(ctx, sanctum.Slug)
+
+	return c.JSON(result)
+}
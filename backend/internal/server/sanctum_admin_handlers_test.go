@@ -1,12 +1,16 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"sanctum/internal/config"
 	"sanctum/internal/models"
+	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/driver/sqlite"
@@ -25,6 +29,8 @@ func setupSanctumAdminTestDB(t *testing.T) *gorm.DB {
 		&models.Sanctum{},
 		&models.Conversation{},
 		&models.ChatroomModerator{},
+		&models.ConversationParticipant{},
+		&models.ChatroomBan{},
 		&models.SanctumMembership{},
 	); err != nil {
 		t.Fatalf("migrate sqlite: %v", err)
@@ -158,3 +164,41 @@ func TestDemoteSanctumAdmin(t *testing.T) {
 		}
 	})
 }
+
+func TestGetInactiveOwnerSanctums_Policy(t *testing.T) {
+	t.Parallel()
+	db := setupSanctumAdminTestDB(t)
+	if err := db.AutoMigrate(&models.Post{}, &models.Comment{}, &models.Message{}); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+	s := &Server{db: db, config: &config.Config{}, ownershipService: service.NewSanctumOwnershipService(db)}
+	app := fiber.New()
+	app.Get("/admin/sanctums/inactive-owners", s.GetInactiveOwnerSanctums)
+
+	owner := models.User{Username: "sleepy", Email: "sleepy@e.com", CreatedAt: time.Now().Add(-200 * 24 * time.Hour)}
+	db.Create(&owner)
+	sanctum := models.Sanctum{Name: "Sleepy", Slug: "sleepy", Status: models.SanctumStatusActive}
+	db.Create(&sanctum)
+	db.Create(&models.SanctumMembership{SanctumID: sanctum.ID, UserID: owner.ID, Role: models.SanctumMembershipRoleOwner})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/sanctums/inactive-owners", nil)
+	resp, _ := app.Test(req)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 while policy is disabled, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/sanctums/inactive-owners?days=90", nil)
+	resp, _ = app.Test(req)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var report []service.InactiveOwnerSanctum
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(report) != 1 || report[0].SanctumID != sanctum.ID || report[0].CandidateUserID != nil {
+		t.Fatalf("unexpected report: %#v", report)
+	}
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	analyticsDefaultDays   = 30
+	analyticsMaxDays       = 90
+	analyticsTopContribMax = 10
+	analyticsDateLayout    = "2006-01-02"
+)
+
+// SanctumMemberDay is one day of a sanctum's membership series. Members is
+// the count at the end of the day; Joined is how many joined that day.
+type SanctumMemberDay struct {
+	Date    string `json:"date"`
+	Members int64  `json:"members"`
+	Joined  int64  `json:"joined"`
+}
+
+// SanctumContributor is a user ranked by activity in a sanctum.
+type SanctumContributor struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+	Posts    int64  `json:"posts"`
+	Comments int64  `json:"comments"`
+}
+
+// SanctumEngagement totals activity on a sanctum's published posts.
+type SanctumEngagement struct {
+	Posts    int64 `json:"posts"`
+	Comments int64 `json:"comments"`
+	Likes    int64 `json:"likes"`
+}
+
+// SanctumAnalytics is the response of GET /api/sanctums/:slug/analytics.
+// Totals are all-time; MemberSeries covers [From, To) and NextBefore pages to
+// the window before it.
+type SanctumAnalytics struct {
+	MemberCount     int64                `json:"member_count"`
+	MemberSeries    []SanctumMemberDay   `json:"member_series"`
+	From            string               `json:"from"`
+	To              string               `json:"to"`
+	NextBefore      string               `json:"next_before"`
+	PostsByType     map[string]int64     `json:"posts_by_type"`
+	TopContributors []SanctumContributor `json:"top_contributors"`
+	Engagement      SanctumEngagement    `json:"engagement"`
+}
+
+// GetSanctumAnalytics handles GET /api/sanctums/:slug/analytics.
+// @Summary Sanctum analytics
+// @Description Member growth, post counts by type, top contributors and engagement totals for a sanctum. The member series is a window of up to 90 days ending before the given date; page back with next_before.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param days query int false "Days in the member series window (default 30, max 90)"
+// @Param before query string false "Exclusive end date of the window, YYYY-MM-DD (default tomorrow, UTC)"
+// @Success 200 {object} SanctumAnalytics
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/analytics [get]
+func (s *Server) GetSanctumAnalytics(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	days := c.QueryInt("days", analyticsDefaultDays)
+	if days <= 0 {
+		days = analyticsDefaultDays
+	}
+	if days > analyticsMaxDays {
+		days = analyticsMaxDays
+	}
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if raw := c.Query("before"); raw != "" {
+		before, err := time.Parse(analyticsDateLayout, raw)
+		if err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("before must be a date in YYYY-MM-DD format"))
+		}
+		to = before
+	}
+	from := to.AddDate(0, 0, -days)
+
+	sanctum, err := s.sanctumForManager(c, false)
+	if err != nil {
+		return nil
+	}
+
+	db := s.db.WithContext(ctx)
+	out := SanctumAnalytics{
+		From:        from.Format(analyticsDateLayout),
+		To:          to.Format(analyticsDateLayout),
+		NextBefore:  from.Format(analyticsDateLayout),
+		PostsByType: map[string]int64{},
+	}
+
+	// Member series: the count before the window, then daily joins inside it.
+	// Memberships are deleted on leave, so history reflects current members.
+	members := db.Model(&models.SanctumMembership{}).Where("sanctum_id = ?", sanctum.ID)
+	if err := members.Session(&gorm.Session{}).Count(&out.MemberCount).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	var running int64
+	if err := members.Session(&gorm.Session{}).Where("created_at < ?", from).Count(&running).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	var joinedAt []time.Time
+	if err := members.Session(&gorm.Session{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Pluck("created_at", &joinedAt).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	joinedByDay := make(map[string]int64, len(joinedAt))
+	for _, t := range joinedAt {
+		joinedByDay[t.UTC().Format(analyticsDateLayout)]++
+	}
+	out.MemberSeries = make([]SanctumMemberDay, 0, days)
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(analyticsDateLayout)
+		running += joinedByDay[key]
+		out.MemberSeries = append(out.MemberSeries, SanctumMemberDay{
+			Date:    key,
+			Members: running,
+			Joined:  joinedByDay[key],
+		})
+	}
+
+	posts := db.Model(&models.Post{}).
+		Where("posts.sanctum_id = ? AND posts.status = ?", sanctum.ID, models.PostStatusPublished)
+
+	var byType []struct {
+		PostType string
+		Count    int64
+	}
+	if err := posts.Session(&gorm.Session{}).
+		Select("post_type, COUNT(*) AS count").
+		Group("post_type").
+		Scan(&byType).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	for _, row := range byType {
+		out.PostsByType[row.PostType] = row.Count
+		out.Engagement.Posts += row.Count
+	}
+
+	postIDs := posts.Session(&gorm.Session{}).Select("posts.id")
+	if err := db.Model(&models.Comment{}).
+		Where("post_id IN (?)", postIDs).
+		Count(&out.Engagement.Comments).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if err := db.Model(&models.Like{}).
+		Where("post_id IN (?)", postIDs).
+		Count(&out.Engagement.Likes).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	contributors, err := s.sanctumTopContributors(ctx, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	out.TopContributors = contributors
+
+	return c.JSON(out)
+}
+
+// sanctumTopContributors ranks users by published posts plus comments on
+// those posts in the sanctum, ties broken by user ID.
+func (s *Server) sanctumTopContributors(ctx context.Context, sanctumID uint) ([]SanctumContributor, error) {
+	db := s.db.WithContext(ctx)
+	published := db.Model(&models.Post{}).
+		Where("sanctum_id = ? AND status = ?", sanctumID, models.PostStatusPublished)
+
+	type userCount struct {
+		UserID uint
+		Count  int64
+	}
+	var postCounts, commentCounts []userCount
+	if err := published.Session(&gorm.Session{}).
+		Select("user_id, COUNT(*) AS count").
+		Group("user_id").
+		Scan(&postCounts).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Comment{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("post_id IN (?)", published.Session(&gorm.Session{}).Select("id")).
+		Group("user_id").
+		Scan(&commentCounts).Error; err != nil {
+		return nil, err
+	}
+
+	byUser := map[uint]*SanctumContributor{}
+	get := func(userID uint) *SanctumContributor {
+		if byUser[userID] == nil {
+			byUser[userID] = &SanctumContributor{UserID: userID}
+		}
+		return byUser[userID]
+	}
+	for _, row := range postCounts {
+		get(row.UserID).Posts = row.Count
+	}
+	for _, row := range commentCounts {
+		get(row.UserID).Comments = row.Count
+	}
+
+	ranked := make([]SanctumContributor, 0, len(byUser))
+	for _, contributor := range byUser {
+		ranked = append(ranked, *contributor)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i].Posts+ranked[i].Comments, ranked[j].Posts+ranked[j].Comments
+		if a != b {
+			return a > b
+		}
+		return ranked[i].UserID < ranked[j].UserID
+	})
+	if len(ranked) > analyticsTopContribMax {
+		ranked = ranked[:analyticsTopContribMax]
+	}
+	if len(ranked) == 0 {
+		return ranked, nil
+	}
+
+	ids := make([]uint, 0, len(ranked))
+	for _, contributor := range ranked {
+		ids = append(ids, contributor.UserID)
+	}
+	var users []models.User
+	if err := db.Select("id", "username", "avatar").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	profiles := make(map[uint]models.User, len(users))
+	for _, u := range users {
+		profiles[u.ID] = u
+	}
+	for i := range ranked {
+		ranked[i].Username = profiles[ranked[i].UserID].Username
+		ranked[i].Avatar = profiles[ranked[i].UserID].Avatar
+	}
+	return ranked, nil
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetSanctumAnalytics(t *testing.T) {
+	t.Parallel()
+
+	db := setupSanctumHandlerTestDB(t)
+	if err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}); err != nil {
+		t.Fatalf("migrate posts: %v", err)
+	}
+	s := &Server{db: db}
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	regular := models.User{Username: "regular", Email: "regular@example.com", Password: "pw"}
+	lurker := models.User{Username: "lurker", Email: "lurker@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &regular, &lurker} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	sanctum := models.Sanctum{Name: "Stats", Slug: "stats", Status: models.SanctumStatusActive}
+	other := models.Sanctum{Name: "Other", Slug: "other", Status: models.SanctumStatusActive}
+	for _, sc := range []*models.Sanctum{&sanctum, &other} {
+		if err := db.Create(sc).Error; err != nil {
+			t.Fatalf("create sanctum: %v", err)
+		}
+	}
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d.Add(12 * time.Hour)
+	}
+	for _, m := range []models.SanctumMembership{
+		{SanctumID: sanctum.ID, UserID: owner.ID, Role: models.SanctumMembershipRoleOwner, CreatedAt: day("2026-01-01")},
+		{SanctumID: sanctum.ID, UserID: regular.ID, Role: models.SanctumMembershipRoleMember, CreatedAt: day("2026-03-02")},
+		{SanctumID: sanctum.ID, UserID: lurker.ID, Role: models.SanctumMembershipRoleMember, CreatedAt: day("2026-03-04")},
+	} {
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatalf("create membership: %v", err)
+		}
+	}
+
+	newPost := func(userID, sanctumID uint, postType, status string) models.Post {
+		t.Helper()
+		p := models.Post{Title: "p", Content: "c", UserID: userID, SanctumID: &sanctumID, PostType: postType, Status: status}
+		if err := db.Create(&p).Error; err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		return p
+	}
+	ownerText := newPost(owner.ID, sanctum.ID, models.PostTypeText, models.PostStatusPublished)
+	newPost(owner.ID, sanctum.ID, models.PostTypeText, models.PostStatusPublished)
+	newPost(owner.ID, sanctum.ID, models.PostTypePoll, models.PostStatusPublished)
+	regularLink := newPost(regular.ID, sanctum.ID, models.PostTypeLink, models.PostStatusPublished)
+	newPost(regular.ID, sanctum.ID, models.PostTypeText, models.PostStatusPendingReview)
+	elsewhere := newPost(lurker.ID, other.ID, models.PostTypeText, models.PostStatusPublished)
+
+	for _, rec := range []any{
+		&models.Comment{Content: "a", UserID: regular.ID, PostID: ownerText.ID},
+		&models.Comment{Content: "b", UserID: regular.ID, PostID: ownerText.ID},
+		&models.Comment{Content: "c", UserID: regular.ID, PostID: ownerText.ID},
+		&models.Comment{Content: "d", UserID: owner.ID, PostID: regularLink.ID},
+		&models.Comment{Content: "e", UserID: lurker.ID, PostID: elsewhere.ID},
+		&models.Like{UserID: regular.ID, PostID: ownerText.ID},
+		&models.Like{UserID: lurker.ID, PostID: ownerText.ID},
+		&models.Like{UserID: owner.ID, PostID: regularLink.ID},
+		&models.Like{UserID: owner.ID, PostID: elsewhere.ID},
+	} {
+		if err := db.Create(rec).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		_, _ = fmt.Sscan(c.Get("X-User-ID"), &id)
+		c.Locals("userID", id)
+		return c.Next()
+	})
+	app.Get("/sanctums/:slug/analytics", s.GetSanctumAnalytics)
+
+	get := func(path string, userID uint, dest any) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if dest != nil {
+			_ = json.NewDecoder(resp.Body).Decode(dest)
+		}
+		return resp.StatusCode
+	}
+
+	if status := get("/sanctums/stats/analytics", regular.ID, nil); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for a plain member, got %d", status)
+	}
+	if status := get("/sanctums/stats/analytics?before=yesterday", owner.ID, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed date, got %d", status)
+	}
+
+	var got SanctumAnalytics
+	if status := get("/sanctums/stats/analytics?days=5&before=2026-03-06", owner.ID, &got); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+
+	if got.MemberCount != 3 {
+		t.Fatalf("expected 3 members, got %d", got.MemberCount)
+	}
+	if got.From != "2026-03-01" || got.To != "2026-03-06" || got.NextBefore != "2026-03-01" {
+		t.Fatalf("unexpected window from=%s to=%s next=%s", got.From, got.To, got.NextBefore)
+	}
+	wantSeries := []SanctumMemberDay{
+		{Date: "2026-03-01", Members: 1},
+		{Date: "2026-03-02", Members: 2, Joined: 1},
+		{Date: "2026-03-03", Members: 2},
+		{Date: "2026-03-04", Members: 3, Joined: 1},
+		{Date: "2026-03-05", Members: 3},
+	}
+	if len(got.MemberSeries) != len(wantSeries) {
+		t.Fatalf("expected %d days, got %+v", len(wantSeries), got.MemberSeries)
+	}
+	for i, want := range wantSeries {
+		if got.MemberSeries[i] != want {
+			t.Fatalf("day %d: expected %+v, got %+v", i, want, got.MemberSeries[i])
+		}
+	}
+
+	wantTypes := map[string]int64{models.PostTypeText: 2, models.PostTypePoll: 1, models.PostTypeLink: 1}
+	if len(got.PostsByType) != len(wantTypes) {
+		t.Fatalf("expected post types %v, got %v", wantTypes, got.PostsByType)
+	}
+	for postType, want := range wantTypes {
+		if got.PostsByType[postType] != want {
+			t.Fatalf("expected %d %s posts, got %d", want, postType, got.PostsByType[postType])
+		}
+	}
+
+	wantEngagement := SanctumEngagement{Posts: 4, Comments: 4, Likes: 3}
+	if got.Engagement != wantEngagement {
+		t.Fatalf("expected engagement %+v, got %+v", wantEngagement, got.Engagement)
+	}
+
+	wantTop := []SanctumContributor{
+		{UserID: owner.ID, Username: "owner", Posts: 3, Comments: 1},
+		{UserID: regular.ID, Username: "regular", Posts: 1, Comments: 3},
+	}
+	if len(got.TopContributors) != len(wantTop) {
+		t.Fatalf("expected %d contributors, got %+v", len(wantTop), got.TopContributors)
+	}
+	for i, want := range wantTop {
+		if got.TopContributors[i] != want {
+			t.Fatalf("contributor %d: expected %+v, got %+v", i, want, got.TopContributors[i])
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// checkSanctumBan returns a forbidden error when userID is banned from
+// sanctumID.
+func (s *Server) checkSanctumBan(ctx context.Context, userID, sanctumID uint) error {
+	var count int64
+	if err := s.db.WithContext(ctx).
+		Model(&models.SanctumBan{}).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return models.NewForbiddenError("You are banned from this sanctum")
+	}
+	return nil
+}
+
+// checkSanctumBanForPost applies checkSanctumBan to the sanctum a post belongs
+// to. Posts outside any sanctum, and unknown posts, pass.
+func (s *Server) checkSanctumBanForPost(ctx context.Context, userID, postID uint) error {
+	var post models.Post
+	if err := s.db.WithContext(ctx).Select("id", "sanctum_id").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if post.SanctumID == nil {
+		return nil
+	}
+	return s.checkSanctumBan(ctx, userID, *post.SanctumID)
+}
+
+// ListSanctumBans handles GET /api/sanctums/:slug/bans.
+// @Summary List sanctum bans
+// @Description List users banned from a sanctum, newest first.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} models.SanctumBan
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/bans [get]
+func (s *Server) ListSanctumBans(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.sanctumForManager(c, false)
+	if err != nil {
+		return nil
+	}
+
+	bans := []models.SanctumBan{}
+	if err := s.db.WithContext(ctx).
+		Where("sanctum_id = ?", sanctum.ID).
+		Preload("User").
+		Preload("BannedByUser").
+		Order("created_at DESC").
+		Find(&bans).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(bans)
+}
+
+// AddSanctumBan handles POST /api/sanctums/:slug/bans/:userId.
+// @Summary Ban a user from a sanctum
+// @Description Block a user from posting and commenting in one sanctum and remove them from its membership and default chatroom. Sanctum owners, moderators and master admins cannot be banned.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param userId path int true "User ID"
+// @Param request body object{reason=string} false "Ban reason"
+// @Success 200 {object} models.SanctumBan
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/bans/{userId} [post]
+func (s *Server) AddSanctumBan(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	targetUserID, err := s.parseID(c, "userId")
+	if err != nil {
+		return nil
+	}
+	sanctum, err := s.sanctumForManager(c, false)
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid request body"))
+		}
+	}
+
+	var target models.User
+	if err := s.db.WithContext(ctx).Select("id").First(&target, targetUserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("User", targetUserID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	targetIsAdmin, err := s.canManageSanctumByUserID(ctx, targetUserID, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if targetIsAdmin {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewForbiddenError("Sanctum admins cannot be banned from their sanctum"))
+	}
+
+	ban := models.SanctumBan{
+		SanctumID:      sanctum.ID,
+		UserID:         targetUserID,
+		BannedByUserID: actorUserID,
+		Reason:         strings.TrimSpace(req.Reason),
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before *models.SanctumBan
+		var existing models.SanctumBan
+		if err := tx.Where("sanctum_id = ? AND user_id = ?", sanctum.ID, targetUserID).
+			Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if existing.UserID != 0 {
+			before = &existing
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{
+				{Name: "sanctum_id"},
+				{Name: "user_id"},
+			},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"banned_by_user_id": actorUserID,
+				"reason":            ban.Reason,
+				"updated_at":        time.Now().UTC(),
+			}),
+		}).Create(&ban).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Where("sanctum_id = ? AND user_id = ?", sanctum.ID, targetUserID).
+			Delete(&models.SanctumMembership{}).Error; err != nil {
+			return err
+		}
+		if err := banFromSanctumRoom(tx, sanctum.ID, targetUserID, actorUserID, ban.Reason); err != nil {
+			return err
+		}
+		return recordAdminAction(tx, actorUserID, models.AuditActionSanctumBanCreate, "user", targetUserID,
+			auditSanctumBan(before), auditSanctumBan(&ban))
+	})
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	if err := s.db.WithContext(ctx).
+		Preload("User").
+		Preload("BannedByUser").
+		Where("sanctum_id = ? AND user_id = ?", sanctum.ID, targetUserID).
+		First(&ban).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(ban)
+}
+
+// RemoveSanctumBan handles DELETE /api/sanctums/:slug/bans/:userId.
+// @Summary Lift a sanctum ban
+// @Description Let a user post, comment and rejoin the default chatroom of a sanctum again.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param userId path int true "User ID"
+// @Success 200 {object} object{message=string}
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/bans/{userId} [delete]
+func (s *Server) RemoveSanctumBan(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+	targetUserID, err := s.parseID(c, "userId")
+	if err != nil {
+		return nil
+	}
+	sanctum, err := s.sanctumForManager(c, false)
+	if err != nil {
+		return nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ban models.SanctumBan
+		if err := tx.Where("sanctum_id = ? AND user_id = ?", sanctum.ID, targetUserID).
+			Limit(1).Find(&ban).Error; err != nil {
+			return err
+		}
+		if ban.UserID == 0 {
+			return nil
+		}
+		if err := tx.
+			Where("sanctum_id = ? AND user_id = ?", sanctum.ID, targetUserID).
+			Delete(&models.SanctumBan{}).Error; err != nil {
+			return err
+		}
+		// Only the room bans this sanctum ban placed; bans moderators set
+		// in the sanctum's rooms on their own stay.
+		if err := tx.
+			Where("user_id = ? AND from_sanctum_ban = ? AND conversation_id IN (?)", targetUserID, true,
+				tx.Model(&models.Conversation{}).Select("id").Where("sanctum_id = ?", sanctum.ID)).
+			Delete(&models.ChatroomBan{}).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, actorUserID, models.AuditActionSanctumBanDelete, "user", targetUserID,
+			auditSanctumBan(&ban), nil)
+	})
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "User unbanned from sanctum"})
+}
+
+// banFromSanctumRoom removes the user from the sanctum's default chatroom and
+// bans them there too, so they cannot rejoin while the sanctum ban stands.
+func banFromSanctumRoom(tx *gorm.DB, sanctumID, userID, bannedByUserID uint, reason string) error {
+	var room models.Conversation
+	if err := tx.Select("id").Where("sanctum_id = ?", sanctumID).First(&room).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// An existing ban was set by a room moderator and is left as theirs.
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ChatroomBan{
+		ConversationID: room.ID,
+		UserID:         userID,
+		BannedByUserID: bannedByUserID,
+		Reason:         reason,
+		FromSanctumBan: true,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.
+		Where("conversation_id = ? AND user_id = ?", room.ID, userID).
+		Delete(&models.ConversationParticipant{}).Error
+}
+
+// sanctumBanSnapshot is the audited part of a sanctum ban.
+type sanctumBanSnapshot struct {
+	SanctumID      uint   `json:"sanctum_id"`
+	BannedByUserID uint   `json:"banned_by_user_id"`
+	Reason         string `json:"reason"`
+}
+
+// auditSanctumBan snapshots ban for the audit log; nil stays nil so the
+// entry records no prior ban.
+func auditSanctumBan(ban *models.SanctumBan) interface{} {
+	if ban == nil {
+		return nil
+	}
+	return sanctumBanSnapshot{SanctumID: ban.SanctumID, BannedByUserID: ban.BannedByUserID, Reason: ban.Reason}
+}
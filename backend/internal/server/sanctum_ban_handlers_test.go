@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSanctumBanBlocksPostingOnlyInThatSanctum(t *testing.T) {
+	t.Parallel()
+
+	db := setupSanctumHandlerTestDB(t)
+	if err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	postRepo := repository.NewPostRepository(db)
+	s := &Server{
+		db:             db,
+		postRepo:       postRepo,
+		postService:    service.NewPostService(postRepo, nil, nil),
+		commentService: service.NewCommentService(repository.NewCommentRepository(db), postRepo, nil),
+	}
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	troll := models.User{Username: "troll", Email: "troll@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &troll} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	strict := models.Sanctum{Name: "Strict", Slug: "strict", Status: models.SanctumStatusActive}
+	other := models.Sanctum{Name: "Other", Slug: "other", Status: models.SanctumStatusActive}
+	for _, sc := range []*models.Sanctum{&strict, &other} {
+		if err := db.Create(sc).Error; err != nil {
+			t.Fatalf("create sanctum: %v", err)
+		}
+	}
+	room := models.Conversation{Name: "strict", IsGroup: true, SanctumID: &strict.ID, CreatedBy: owner.ID}
+	if err := db.Create(&room).Error; err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	for _, rec := range []any{
+		&models.SanctumMembership{SanctumID: strict.ID, UserID: owner.ID, Role: models.SanctumMembershipRoleOwner},
+		&models.SanctumMembership{SanctumID: strict.ID, UserID: troll.ID, Role: models.SanctumMembershipRoleMember},
+		&models.ConversationParticipant{ConversationID: room.ID, UserID: troll.ID},
+	} {
+		if err := db.Create(rec).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	ownerPost := models.Post{Title: "Welcome", Content: "hi", UserID: owner.ID, SanctumID: &strict.ID, Status: models.PostStatusPublished}
+	if err := db.Create(&ownerPost).Error; err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		_, _ = fmt.Sscan(c.Get("X-User-ID"), &id)
+		c.Locals("userID", id)
+		return c.Next()
+	})
+	app.Post("/posts", s.CreatePost)
+	app.Post("/posts/:id/comments", s.CreateComment)
+	app.Get("/sanctums/:slug/bans", s.ListSanctumBans)
+	app.Post("/sanctums/:slug/bans/:userId", s.AddSanctumBan)
+	app.Delete("/sanctums/:slug/bans/:userId", s.RemoveSanctumBan)
+
+	do := func(method, path string, userID uint, body any) int {
+		t.Helper()
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		return resp.StatusCode
+	}
+	post := func(sanctumID uint) int {
+		return do(http.MethodPost, "/posts", troll.ID, map[string]any{"title": "Hi", "content": "body", "sanctum_id": sanctumID})
+	}
+	comment := func() int {
+		return do(http.MethodPost, fmt.Sprintf("/posts/%d/comments", ownerPost.ID), troll.ID, map[string]any{"content": "reply"})
+	}
+	banPath := fmt.Sprintf("/sanctums/strict/bans/%d", troll.ID)
+
+	if status := do(http.MethodPost, fmt.Sprintf("/sanctums/strict/bans/%d", owner.ID), troll.ID, nil); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for a member banning someone, got %d", status)
+	}
+	if status := do(http.MethodPost, fmt.Sprintf("/sanctums/strict/bans/%d", owner.ID), owner.ID, nil); status != http.StatusForbidden {
+		t.Fatalf("expected 403 banning a sanctum owner, got %d", status)
+	}
+	if status := do(http.MethodPost, banPath, owner.ID, map[string]string{"reason": "spam"}); status != http.StatusOK {
+		t.Fatalf("expected 200 banning, got %d", status)
+	}
+
+	if status := post(strict.ID); status != http.StatusForbidden {
+		t.Fatalf("expected 403 posting in the banning sanctum, got %d", status)
+	}
+	if status := comment(); status != http.StatusForbidden {
+		t.Fatalf("expected 403 commenting in the banning sanctum, got %d", status)
+	}
+	if status := post(other.ID); status != http.StatusCreated {
+		t.Fatalf("expected 201 posting in another sanctum, got %d", status)
+	}
+
+	var participants, roomBans, memberships int64
+	db.Model(&models.ConversationParticipant{}).Where("conversation_id = ? AND user_id = ?", room.ID, troll.ID).Count(&participants)
+	db.Model(&models.ChatroomBan{}).Where("conversation_id = ? AND user_id = ?", room.ID, troll.ID).Count(&roomBans)
+	db.Model(&models.SanctumMembership{}).Where("sanctum_id = ? AND user_id = ?", strict.ID, troll.ID).Count(&memberships)
+	if participants != 0 || roomBans != 1 || memberships != 0 {
+		t.Fatalf("expected removal from room and membership, got participants=%d roomBans=%d memberships=%d",
+			participants, roomBans, memberships)
+	}
+
+	var reloaded models.User
+	if err := db.First(&reloaded, troll.ID).Error; err != nil {
+		t.Fatalf("reload user: %v", err)
+	}
+	if reloaded.IsBanned {
+		t.Fatal("a sanctum ban must not set the global ban flag")
+	}
+
+	if status := do(http.MethodDelete, banPath, owner.ID, nil); status != http.StatusOK {
+		t.Fatalf("expected 200 lifting the ban, got %d", status)
+	}
+	if status := post(strict.ID); status != http.StatusCreated {
+		t.Fatalf("expected 201 posting after the ban is lifted, got %d", status)
+	}
+	db.Model(&models.ChatroomBan{}).Where("conversation_id = ? AND user_id = ?", room.ID, troll.ID).Count(&roomBans)
+	if roomBans != 0 {
+		t.Fatal("lifting the sanctum ban should lift the room ban it created")
+	}
+
+	// A room ban a moderator placed on their own survives the sanctum ban
+	// being placed and lifted again.
+	if err := db.Create(&models.ChatroomBan{ConversationID: room.ID, UserID: troll.ID, BannedByUserID: owner.ID, Reason: "flooding"}).Error; err != nil {
+		t.Fatalf("seed room ban: %v", err)
+	}
+	if status := do(http.MethodPost, banPath, owner.ID, nil); status != http.StatusOK {
+		t.Fatalf("expected 200 banning again, got %d", status)
+	}
+	if status := do(http.MethodDelete, banPath, owner.ID, nil); status != http.StatusOK {
+		t.Fatalf("expected 200 lifting the ban again, got %d", status)
+	}
+	db.Model(&models.ChatroomBan{}).Where("conversation_id = ? AND user_id = ?", room.ID, troll.ID).Count(&roomBans)
+	if roomBans != 1 {
+		t.Fatal("lifting the sanctum ban must keep room bans moderators set separately")
+	}
+
+	var audits []models.AdminAuditLog
+	if err := db.Where("target_type = ? AND target_id = ?", "user", troll.ID).Order("id").Find(&audits).Error; err != nil {
+		t.Fatalf("load audit log: %v", err)
+	}
+	if len(audits) != 4 || audits[0].Action != models.AuditActionSanctumBanCreate || audits[1].Action != models.AuditActionSanctumBanDelete {
+		t.Fatalf("expected ban and unban audit entries, got %+v", audits)
+	}
+	if audits[0].ActorUserID != owner.ID || audits[1].ActorUserID != owner.ID {
+		t.Fatalf("expected the owner as actor, got %d and %d", audits[0].ActorUserID, audits[1].ActorUserID)
+	}
+}
@@ -1,11 +1,14 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"time"
 
+	"sanctum/internal/cache"
 	"sanctum/internal/models"
+	"sanctum/internal/service"
 	"sanctum/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,15 +18,17 @@ import (
 
 // SanctumDTO is the API response model for sanctum endpoints.
 type SanctumDTO struct {
-	ID                uint                 `json:"id"`
-	Name              string               `json:"name"`
-	Slug              string               `json:"slug"`
-	Description       string               `json:"description"`
-	CreatedByUserID   *uint                `json:"created_by_user_id"`
-	Status            models.SanctumStatus `json:"status"`
-	CreatedAt         string               `json:"created_at"`
-	UpdatedAt         string               `json:"updated_at"`
-	DefaultChatRoomID *uint                `json:"default_chat_room_id"`
+	ID                   uint                 `json:"id"`
+	Name                 string               `json:"name"`
+	Slug                 string               `json:"slug"`
+	Description          string               `json:"description"`
+	CreatedByUserID      *uint                `json:"created_by_user_id"`
+	Status               models.SanctumStatus `json:"status"`
+	CreatedAt            string               `json:"created_at"`
+	UpdatedAt            string               `json:"updated_at"`
+	DefaultChatRoomID    *uint                `json:"default_chat_room_id"`
+	RequiresPostApproval bool                 `json:"requires_post_approval"`
+	MembersOnlyPosting   bool                 `json:"members_only_posting"`
 }
 
 // SanctumMembershipDTO is the API response model for sanctum memberships.
@@ -38,15 +43,17 @@ type SanctumMembershipDTO struct {
 
 func toSanctumDTO(s models.Sanctum, defaultRoomID *uint) SanctumDTO {
 	return SanctumDTO{
-		ID:                s.ID,
-		Name:              s.Name,
-		Slug:              s.Slug,
-		Description:       s.Description,
-		CreatedByUserID:   s.CreatedByUserID,
-		Status:            s.Status,
-		CreatedAt:         s.CreatedAt.UTC().Format(time.RFC3339Nano),
-		UpdatedAt:         s.UpdatedAt.UTC().Format(time.RFC3339Nano),
-		DefaultChatRoomID: defaultRoomID,
+		ID:                   s.ID,
+		Name:                 s.Name,
+		Slug:                 s.Slug,
+		Description:          s.Description,
+		CreatedByUserID:      s.CreatedByUserID,
+		Status:               s.Status,
+		CreatedAt:            s.CreatedAt.UTC().Format(time.RFC3339Nano),
+		UpdatedAt:            s.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		DefaultChatRoomID:    defaultRoomID,
+		RequiresPostApproval: s.RequiresPostApproval,
+		MembersOnlyPosting:   s.MembersOnlyPosting,
 	}
 }
 
@@ -133,26 +140,99 @@ func (s *Server) GetSanctumBySlug(c *fiber.Ctx) error {
 			models.NewValidationError("slug is required"))
 	}
 
+	sanctum, err := s.activeSanctumBySlug(ctx, slug)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	var room models.Conversation
+	var roomID *uint
+	if err := s.db.WithContext(ctx).Select("id").Where("sanctum_id = ?", sanctum.ID).First(&room).Error; err == nil {
+		roomID = &room.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(toSanctumDTO(*sanctum, roomID))
+}
+
+// activeSanctumBySlug loads an active sanctum, returning a not-found error
+// for unknown or inactive slugs.
+func (s *Server) activeSanctumBySlug(ctx context.Context, slug string) (*models.Sanctum, error) {
 	var sanctum models.Sanctum
 	if err := s.db.WithContext(ctx).
 		Where("slug = ? AND status = ?", slug, models.SanctumStatusActive).
 		First(&sanctum).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.RespondWithError(c, fiber.StatusNotFound,
-				models.NewNotFoundError("Sanctum", slug))
+			return nil, models.NewNotFoundError("Sanctum", slug)
 		}
+		return nil, err
+	}
+	return &sanctum, nil
+}
+
+// GetSanctumPosts handles GET /api/sanctums/:slug/posts
+// @Summary List sanctum posts
+// @Description List an active sanctum's posts, optionally filtered by tag.
+// @Tags sanctums
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param tag query string false "Only posts carrying this tag"
+// @Param sort query string false "new, hot, top, rising or best"
+// @Success 200 {array} models.Post
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sanctums/{slug}/posts [get]
+func (s *Server) GetSanctumPosts(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.activeSanctumBySlug(ctx, strings.TrimSpace(c.Params("slug")))
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	page := parsePagination(c, 20)
+	sort := c.Query("sort", "new")
+	validSorts := map[string]bool{"new": true, "hot": true, "top": true, "rising": true, "best": true}
+	if !validSorts[sort] {
+		sort = "new"
+	}
+
+	posts, err := s.postSvc().ListPosts(ctx, service.ListPostsInput{
+		Limit:         page.Limit,
+		Offset:        page.Offset,
+		CurrentUserID: s.optionalUserID(c),
+		SanctumID:     &sanctum.ID,
+		Tag:           c.Query("tag"),
+		Sort:          sort,
+	})
+	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
-	var room models.Conversation
-	var roomID *uint
-	if err := s.db.WithContext(ctx).Select("id").Where("sanctum_id = ?", sanctum.ID).First(&room).Error; err == nil {
-		roomID = &room.ID
-	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+	return c.JSON(posts)
+}
+
+// GetSanctumTags handles GET /api/sanctums/:slug/tags
+// @Summary List sanctum tags
+// @Description List tags used by an active sanctum's posts with post counts, most used first.
+// @Tags sanctums
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} models.TagCount
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sanctums/{slug}/tags [get]
+func (s *Server) GetSanctumTags(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.activeSanctumBySlug(ctx, strings.TrimSpace(c.Params("slug")))
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	tags, err := s.postSvc().ListSanctumTags(ctx, sanctum.ID, parsePagination(c, 50).Limit)
+	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
-	return c.JSON(toSanctumDTO(sanctum, roomID))
+	return c.JSON(tags)
 }
 
 // CreateSanctumRequest handles POST /api/sanctums/requests
@@ -324,7 +404,7 @@ func (s *Server) GetMySanctumMemberships(c *fiber.Ctx) error {
 
 // UpsertMySanctumMemberships handles POST /api/sanctums/memberships/bulk
 // @Summary Save sanctums I follow
-// @Description Upsert current user's followed sanctums by slug and keep owner/mod memberships intact.
+// @Description Upsert current user's followed sanctums by slug and keep owner/mod memberships intact. Following a sanctum joins its default chatroom; unfollowing leaves it.
 // @Tags sanctums
 // @Accept json
 // @Produce json
@@ -382,12 +462,26 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 		sanctumsByID[sanctum.ID] = sanctum
 	}
 
+	var touchedRoomIDs []uint
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.
+		var unfollowedIDs []uint
+		if err := tx.Model(&models.SanctumMembership{}).
 			Where("user_id = ? AND role = ? AND sanctum_id NOT IN ?", userID, models.SanctumMembershipRoleMember, sanctumIDs).
-			Delete(&models.SanctumMembership{}).Error; err != nil {
+			Pluck("sanctum_id", &unfollowedIDs).Error; err != nil {
 			return err
 		}
+		if len(unfollowedIDs) > 0 {
+			if err := tx.
+				Where("user_id = ? AND role = ? AND sanctum_id IN ?", userID, models.SanctumMembershipRoleMember, unfollowedIDs).
+				Delete(&models.SanctumMembership{}).Error; err != nil {
+				return err
+			}
+			left, err := leaveSanctumRooms(tx, userID, unfollowedIDs)
+			if err != nil {
+				return err
+			}
+			touchedRoomIDs = append(touchedRoomIDs, left...)
+		}
 
 		for _, sanctumID := range sanctumIDs {
 			membership := models.SanctumMembership{
@@ -397,16 +491,24 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 			}
 			if err := tx.Clauses(clause.OnConflict{
 				Columns:   []clause.Column{{Name: "sanctum_id"}, {Name: "user_id"}},
-				DoUpdates: clause.Assignments(map[string]any{"updated_at": gorm.Expr("NOW()")}),
+				DoUpdates: clause.Assignments(map[string]any{"updated_at": time.Now()}),
 			}).Create(&membership).Error; err != nil {
 				return err
 			}
 		}
+		joined, err := joinSanctumRooms(tx, userID, sanctumIDs)
+		if err != nil {
+			return err
+		}
+		touchedRoomIDs = append(touchedRoomIDs, joined...)
 		return nil
 	})
 	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
+	for _, roomID := range touchedRoomIDs {
+		cache.InvalidateRoom(ctx, roomID)
+	}
 
 	var memberships []models.SanctumMembership
 	if err := s.db.WithContext(ctx).
@@ -446,6 +548,47 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 	return c.JSON(resp)
 }
 
+// joinSanctumRooms adds userID to the default chatroom of each sanctum,
+// skipping rooms the user is banned from, and returns the room IDs.
+func joinSanctumRooms(tx *gorm.DB, userID uint, sanctumIDs []uint) ([]uint, error) {
+	var roomIDs []uint
+	if err := tx.Model(&models.Conversation{}).
+		Where("sanctum_id IN ?", sanctumIDs).
+		Where("NOT EXISTS (SELECT 1 FROM chatroom_bans cb WHERE cb.conversation_id = conversations.id AND cb.user_id = ?)", userID).
+		Pluck("id", &roomIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, roomID := range roomIDs {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ConversationParticipant{
+			ConversationID: roomID,
+			UserID:         userID,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return roomIDs, nil
+}
+
+// leaveSanctumRooms removes userID from the default chatroom of each sanctum
+// and returns the room IDs.
+func leaveSanctumRooms(tx *gorm.DB, userID uint, sanctumIDs []uint) ([]uint, error) {
+	var roomIDs []uint
+	if err := tx.Model(&models.Conversation{}).
+		Where("sanctum_id IN ?", sanctumIDs).
+		Pluck("id", &roomIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(roomIDs) == 0 {
+		return nil, nil
+	}
+	if err := tx.
+		Where("user_id = ? AND conversation_id IN ?", userID, roomIDs).
+		Delete(&models.ConversationParticipant{}).Error; err != nil {
+		return nil, err
+	}
+	return roomIDs, nil
+}
+
 // GetAdminSanctumRequests handles GET /api/admin/sanctum-requests
 // @Summary List sanctum requests for admins
 // @Description List sanctum requests by status. Defaults to pending.
@@ -529,6 +672,7 @@ func (s *Server) ApproveSanctumRequest(c *fiber.Ctx) error {
 		if approvedRequest.Status != models.SanctumRequestStatusPending {
 			return models.NewValidationError("sanctum request is not pending")
 		}
+		before := snapshotSanctumRequestReview(&approvedRequest)
 
 		if err := validation.ValidateSanctumSlug(approvedRequest.RequestedSlug); err != nil {
 			return models.NewValidationError(err.Error())
@@ -581,11 +725,23 @@ func (s *Server) ApproveSanctumRequest(c *fiber.Ctx) error {
 		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&roomModerator).Error; err != nil {
 			return err
 		}
+		if err := tx.Create(&models.ConversationParticipant{
+			ConversationID: defaultRoom.ID,
+			UserID:         approvedRequest.RequestedByUserID,
+		}).Error; err != nil {
+			return err
+		}
 
 		approvedRequest.Status = models.SanctumRequestStatusApproved
 		approvedRequest.ReviewedByUserID = &reviewerID
 		approvedRequest.ReviewNotes = strings.TrimSpace(body.ReviewNotes)
-		return tx.Save(&approvedRequest).Error
+		if err := tx.Save(&approvedRequest).Error; err != nil {
+			return err
+		}
+		after := snapshotSanctumRequestReview(&approvedRequest)
+		after.SanctumID = &createdSanctum.ID
+		return recordAdminAction(tx, reviewerID, models.AuditActionSanctumRequestApprove,
+			"sanctum_request", approvedRequest.ID, before, after)
 	})
 	if txErr != nil {
 		var appErr *models.AppError
@@ -646,24 +802,38 @@ func (s *Server) RejectSanctumRequest(c *fiber.Ctx) error {
 	}
 
 	var request models.SanctumRequest
-	if err := s.db.WithContext(ctx).First(&request, requestID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.RespondWithError(c, fiber.StatusNotFound,
-				models.NewNotFoundError("Sanctum request", requestID))
+	txErr := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.NewNotFoundError("Sanctum request", requestID)
+			}
+			return err
 		}
-		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
-	}
 
-	if request.Status != models.SanctumRequestStatusPending {
-		return models.RespondWithError(c, fiber.StatusBadRequest,
-			models.NewValidationError("sanctum request is not pending"))
-	}
+		if request.Status != models.SanctumRequestStatusPending {
+			return models.NewValidationError("sanctum request is not pending")
+		}
+		before := snapshotSanctumRequestReview(&request)
 
-	request.Status = models.SanctumRequestStatusRejected
-	request.ReviewedByUserID = &reviewerID
-	request.ReviewNotes = strings.TrimSpace(body.ReviewNotes)
-	if err := s.db.WithContext(ctx).Save(&request).Error; err != nil {
-		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		request.Status = models.SanctumRequestStatusRejected
+		request.ReviewedByUserID = &reviewerID
+		request.ReviewNotes = strings.TrimSpace(body.ReviewNotes)
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, reviewerID, models.AuditActionSanctumRequestReject,
+			"sanctum_request", request.ID, before, snapshotSanctumRequestReview(&request))
+	})
+	if txErr != nil {
+		var appErr *models.AppError
+		if errors.As(txErr, &appErr) {
+			status := fiber.StatusBadRequest
+			if appErr.Code == "NOT_FOUND" {
+				status = fiber.StatusNotFound
+			}
+			return models.RespondWithError(c, status, appErr)
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, txErr)
 	}
 
 	s.publishBroadcastEvent(EventSanctumRequestReviewed, map[string]interface{}{
@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
 
 	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/driver/sqlite"
@@ -25,9 +29,14 @@ func setupSanctumHandlerTestDB(t *testing.T) *gorm.DB {
 		&models.User{},
 		&models.Conversation{},
 		&models.ChatroomModerator{},
+		&models.ConversationParticipant{},
+		&models.ChatroomBan{},
 		&models.Sanctum{},
 		&models.SanctumRequest{},
 		&models.SanctumMembership{},
+		&models.SanctumBan{},
+		&models.Crosspost{},
+		&models.AdminAuditLog{},
 	); err != nil {
 		t.Fatalf("migrate sqlite: %v", err)
 	}
@@ -114,6 +123,107 @@ func TestApproveSanctumRequestFlow(t *testing.T) {
 	if err := db.Where("conversation_id = ? AND user_id = ?", conv.ID, requester.ID).First(&roomMod).Error; err != nil {
 		t.Fatalf("room moderator row missing: %v", err)
 	}
+
+	var participant models.ConversationParticipant
+	if err := db.Where("conversation_id = ? AND user_id = ?", conv.ID, requester.ID).First(&participant).Error; err != nil {
+		t.Fatalf("owner should join the default room: %v", err)
+	}
+}
+
+func TestSanctumMembershipSyncsDefaultRoom(t *testing.T) {
+	t.Parallel()
+
+	db := setupSanctumHandlerTestDB(t)
+	if err := db.AutoMigrate(&models.Message{}); err != nil {
+		t.Fatalf("migrate messages: %v", err)
+	}
+	s := &Server{db: db}
+	s.chatService = service.NewChatService(repository.NewChatRepository(db), repository.NewUserRepository(db), db, s.isAdminByUserID, s.canModerateChatroomByUserID)
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	follower := models.User{Username: "follower", Email: "follower@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &follower} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	rooms := map[string]uint{}
+	for _, slug := range []string{"gardening", "chess"} {
+		sanctum := models.Sanctum{Name: slug, Slug: slug, Status: models.SanctumStatusActive}
+		if err := db.Create(&sanctum).Error; err != nil {
+			t.Fatalf("create sanctum: %v", err)
+		}
+		room := models.Conversation{Name: slug, IsGroup: true, CreatedBy: owner.ID, SanctumID: &sanctum.ID}
+		if err := db.Create(&room).Error; err != nil {
+			t.Fatalf("create room: %v", err)
+		}
+		rooms[slug] = room.ID
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", follower.ID)
+		return c.Next()
+	})
+	app.Post("/sanctums/memberships/bulk", s.UpsertMySanctumMemberships)
+	app.Get("/chatrooms/joined", s.GetJoinedChatrooms)
+
+	follow := func(slugs ...string) {
+		t.Helper()
+		body, _ := json.Marshal(map[string][]string{"sanctum_slugs": slugs})
+		req := httptest.NewRequest(http.MethodPost, "/sanctums/memberships/bulk", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("follow: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("follow %v: expected 200, got %d", slugs, resp.StatusCode)
+		}
+	}
+	joined := func() []uint {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/chatrooms/joined", nil))
+		if err != nil {
+			t.Fatalf("joined: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("joined: expected 200, got %d", resp.StatusCode)
+		}
+		var got []ChatroomResponse
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode joined: %v", err)
+		}
+		ids := make([]uint, 0, len(got))
+		for _, room := range got {
+			ids = append(ids, room.ID)
+		}
+		return ids
+	}
+
+	follow("gardening")
+	if ids := joined(); len(ids) != 1 || ids[0] != rooms["gardening"] {
+		t.Fatalf("expected to join the gardening room, got %v", ids)
+	}
+
+	// Switching follows leaves the old room and joins the new one.
+	follow("chess")
+	if ids := joined(); len(ids) != 1 || ids[0] != rooms["chess"] {
+		t.Fatalf("expected only the chess room after unfollowing gardening, got %v", ids)
+	}
+
+	// A room ban outlives membership changes.
+	if err := db.Create(&models.ChatroomBan{
+		ConversationID: rooms["gardening"], UserID: follower.ID, BannedByUserID: owner.ID,
+	}).Error; err != nil {
+		t.Fatalf("create room ban: %v", err)
+	}
+	follow("chess", "gardening")
+	if ids := joined(); len(ids) != 1 || ids[0] != rooms["chess"] {
+		t.Fatalf("banned room must not be auto-joined, got %v", ids)
+	}
 }
 
 func TestApproveSanctumRequest_NonPendingFails(t *testing.T) {
@@ -233,3 +343,111 @@ func TestApproveSanctumRequest_TakenSlugFails(t *testing.T) {
 	var response map[string]interface{}
 	_ = json.NewDecoder(resp.Body).Decode(&response)
 }
+
+func TestSanctumPostsFilterByTag(t *testing.T) {
+	t.Parallel()
+
+	db := setupSanctumHandlerTestDB(t)
+	if err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{}); err != nil {
+		t.Fatalf("migrate posts: %v", err)
+	}
+	s := &Server{
+		db:          db,
+		postService: service.NewPostService(repository.NewPostRepository(db), nil, nil),
+	}
+
+	author := models.User{Username: "author", Email: "author@example.com", Password: "pw"}
+	if err := db.Create(&author).Error; err != nil {
+		t.Fatalf("create author: %v", err)
+	}
+	sanctum := models.Sanctum{Name: "Linux", Slug: "linux", Status: models.SanctumStatusActive}
+	if err := db.Create(&sanctum).Error; err != nil {
+		t.Fatalf("create sanctum: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/posts", func(c *fiber.Ctx) error {
+		c.Locals("userID", author.ID)
+		return s.CreatePost(c)
+	})
+	app.Get("/sanctums/:slug/posts", s.GetSanctumPosts)
+	app.Get("/sanctums/:slug/tags", s.GetSanctumTags)
+
+	createPost := func(title string, tags []string) (int, models.Post) {
+		t.Helper()
+		body, _ := json.Marshal(map[string]interface{}{
+			"title": title, "content": "body", "sanctum_id": sanctum.ID, "tags": tags,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var post models.Post
+		_ = json.NewDecoder(resp.Body).Decode(&post)
+		return resp.StatusCode, post
+	}
+	getJSON := func(path string, dest interface{}) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", path, resp.StatusCode)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+
+	status, kernel := createPost("Kernel news", []string{"#Kernel", " news "})
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	names := make([]string, 0, len(kernel.Tags))
+	for _, tag := range kernel.Tags {
+		names = append(names, tag.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "kernel,news" {
+		t.Fatalf("expected normalized tags on the created post, got %v", names)
+	}
+	if status, _ := createPost("Distro news", []string{"news"}); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if status, _ := createPost("Untagged", nil); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if status, _ := createPost("Bad tag", []string{"<script>"}); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid tag, got %d", status)
+	}
+
+	var kernelPosts []models.Post
+	getJSON("/sanctums/linux/posts?tag=KERNEL", &kernelPosts)
+	if len(kernelPosts) != 1 || kernelPosts[0].ID != kernel.ID {
+		t.Fatalf("expected only the kernel post, got %+v", kernelPosts)
+	}
+
+	var newsPosts []models.Post
+	getJSON("/sanctums/linux/posts?tag=news", &newsPosts)
+	if len(newsPosts) != 2 {
+		t.Fatalf("expected 2 news posts, got %d", len(newsPosts))
+	}
+
+	var all []models.Post
+	getJSON("/sanctums/linux/posts", &all)
+	if len(all) != 3 {
+		t.Fatalf("expected 3 posts without a tag filter, got %d", len(all))
+	}
+
+	var tags []models.TagCount
+	getJSON("/sanctums/linux/tags", &tags)
+	want := []models.TagCount{{Name: "news", Count: 2}, {Name: "kernel", Count: 1}}
+	if len(tags) != len(want) || tags[0] != want[0] || tags[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, tags)
+	}
+}
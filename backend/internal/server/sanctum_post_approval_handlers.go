@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// checkSanctumPosting applies a sanctum's posting rules to userID. It returns
+// a forbidden error when the user is banned from the sanctum, or when the
+// sanctum is members-only and the user has no membership, and reports whether
+// the post must wait in the review queue. Owners, moderators and master
+// admins bypass the membership and approval rules. Unknown sanctums are left
+// for post creation to reject.
+func (s *Server) checkSanctumPosting(ctx context.Context, userID, sanctumID uint) (needsApproval bool, err error) {
+	var sanctum models.Sanctum
+	if err := s.db.WithContext(ctx).
+		Select("id", "requires_post_approval", "members_only_posting").
+		First(&sanctum, sanctumID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := s.checkSanctumBan(ctx, userID, sanctumID); err != nil {
+		return false, err
+	}
+	if !sanctum.RequiresPostApproval && !sanctum.MembersOnlyPosting {
+		return false, nil
+	}
+	canManage, err := s.canManageSanctumByUserID(ctx, userID, sanctumID)
+	if err != nil || canManage {
+		return false, err
+	}
+	if sanctum.MembersOnlyPosting {
+		_, member, err := s.getSanctumRoleByUserID(ctx, userID, sanctumID)
+		if err != nil {
+			return false, err
+		}
+		if !member {
+			return false, models.NewForbiddenError("Only members can post in this sanctum")
+		}
+	}
+	return sanctum.RequiresPostApproval, nil
+}
+
+// sanctumForManager loads the sanctum named by :slug and checks the actor may
+// moderate it, or only own it when ownerOnly is set. On failure it writes the
+// error response and returns errResponseWritten.
+func (s *Server) sanctumForManager(c *fiber.Ctx, ownerOnly bool) (*models.Sanctum, error) {
+	ctx := c.UserContext()
+	actorUserID := c.Locals("userID").(uint)
+
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		_ = models.RespondWithError(c, mapServiceError(err), err)
+		return nil, errResponseWritten
+	}
+
+	var authorized bool
+	if ownerOnly {
+		authorized, err = s.canManageSanctumAsOwnerByUserID(ctx, actorUserID, sanctum.ID)
+	} else {
+		authorized, err = s.canManageSanctumByUserID(ctx, actorUserID, sanctum.ID)
+	}
+	if err != nil {
+		_ = models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		return nil, errResponseWritten
+	}
+	if !authorized {
+		msg := "Sanctum owner, moderator or master admin access required"
+		if ownerOnly {
+			msg = "Sanctum owner or master admin access required"
+		}
+		_ = models.RespondWithError(c, fiber.StatusForbidden, models.NewUnauthorizedError(msg))
+		return nil, errResponseWritten
+	}
+	return sanctum, nil
+}
+
+// UpdateSanctumSettings handles PATCH /api/sanctums/:slug/settings.
+// @Summary Update sanctum settings
+// @Description Change community settings: whether new posts need approval and whether only members may post. Omitted fields are left unchanged.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body object{requires_post_approval=bool,members_only_posting=bool} true "Settings to change"
+// @Success 200 {object} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/settings [patch]
+func (s *Server) UpdateSanctumSettings(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.sanctumForManager(c, true)
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		RequiresPostApproval *bool `json:"requires_post_approval"`
+		MembersOnlyPosting   *bool `json:"members_only_posting"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	updates := map[string]any{}
+	if req.RequiresPostApproval != nil {
+		updates["requires_post_approval"] = *req.RequiresPostApproval
+	}
+	if req.MembersOnlyPosting != nil {
+		updates["members_only_posting"] = *req.MembersOnlyPosting
+	}
+	if len(updates) > 0 {
+		if err := s.db.WithContext(ctx).Model(sanctum).Updates(updates).Error; err != nil {
+			return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		}
+	}
+
+	var room models.Conversation
+	var roomID *uint
+	if err := s.db.WithContext(ctx).Select("id").Where("sanctum_id = ?", sanctum.ID).First(&room).Error; err == nil {
+		roomID = &room.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(toSanctumDTO(*sanctum, roomID))
+}
+
+// GetPendingSanctumPosts handles GET /api/sanctums/:slug/posts/pending.
+// @Summary List posts awaiting approval
+// @Description List a sanctum's pending_review posts, oldest first, for its owners and moderators.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} models.Post
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/posts/pending [get]
+func (s *Server) GetPendingSanctumPosts(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.sanctumForManager(c, false)
+	if err != nil {
+		return nil
+	}
+
+	page := parsePagination(c, 20)
+	posts := []models.Post{}
+	if err := s.db.WithContext(ctx).
+		Preload("User").
+		Where("sanctum_id = ? AND status = ?", sanctum.ID, models.PostStatusPendingReview).
+		Order("created_at ASC").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&posts).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(posts)
+}
+
+// ApproveSanctumPost handles POST /api/sanctums/:slug/posts/:id/approve.
+// @Summary Approve a pending post
+// @Description Publish a post held for review in an approval-gated sanctum. A post scheduled for a future time moves to scheduled instead.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param id path int true "Post ID"
+// @Success 200 {object} models.Post
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/posts/{id}/approve [post]
+func (s *Server) ApproveSanctumPost(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	sanctum, err := s.sanctumForManager(c, false)
+	if err != nil {
+		return nil
+	}
+
+	var post models.Post
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND sanctum_id = ?", postID, sanctum.ID).
+		First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Post", postID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	// created_at moves to the approval time so the post sorts as new, the same
+	// way the scheduled-post worker treats publish time.
+	now := time.Now().UTC()
+	updates := map[string]any{
+		"status":     models.PostStatusPublished,
+		"created_at": now,
+	}
+	if post.PublishAt != nil && post.PublishAt.After(now) {
+		updates = map[string]any{"status": models.PostStatusScheduled}
+	}
+	res := s.db.WithContext(ctx).Model(&models.Post{}).
+		Where("id = ? AND status = ?", post.ID, models.PostStatusPendingReview).
+		Updates(updates)
+	if res.Error != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return models.RespondWithError(c, fiber.StatusConflict,
+			models.NewConflictError("Post is not awaiting review"))
+	}
+
+	cache.Invalidate(ctx, cache.PostKey(post.ID))
+	cache.InvalidatePostsList(ctx)
+
+	if err := s.db.WithContext(ctx).Preload("User").First(&post, post.ID).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if post.Status == models.PostStatusPublished {
+		s.announcePost(&post)
+	}
+
+	return c.JSON(post)
+}
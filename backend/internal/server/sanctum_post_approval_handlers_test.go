@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSanctumPostApprovalQueue(t *testing.T) {
+	t.Parallel()
+
+	db := setupSanctumHandlerTestDB(t)
+	if err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{}); err != nil {
+		t.Fatalf("migrate posts: %v", err)
+	}
+	s := &Server{
+		db:          db,
+		postService: service.NewPostService(repository.NewPostRepository(db), nil, nil),
+	}
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	author := models.User{Username: "author", Email: "author@example.com", Password: "pw"}
+	reader := models.User{Username: "reader", Email: "reader@example.com", Password: "pw"}
+	for _, u := range []*models.User{&owner, &author, &reader} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	sanctum := models.Sanctum{Name: "Gated", Slug: "gated", Status: models.SanctumStatusActive}
+	if err := db.Create(&sanctum).Error; err != nil {
+		t.Fatalf("create sanctum: %v", err)
+	}
+	if err := db.Create(&models.SanctumMembership{
+		SanctumID: sanctum.ID, UserID: owner.ID, Role: models.SanctumMembershipRoleOwner,
+	}).Error; err != nil {
+		t.Fatalf("create owner membership: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		_, _ = fmt.Sscan(c.Get("X-User-ID"), &id)
+		c.Locals("userID", id)
+		return c.Next()
+	})
+	app.Post("/posts", s.CreatePost)
+	app.Get("/posts/:id", s.GetPost)
+	app.Get("/sanctums/:slug/posts", s.GetSanctumPosts)
+	app.Patch("/sanctums/:slug/settings", s.UpdateSanctumSettings)
+	app.Get("/sanctums/:slug/posts/pending", s.GetPendingSanctumPosts)
+	app.Post("/sanctums/:slug/posts/:id/approve", s.ApproveSanctumPost)
+
+	do := func(method, path string, userID uint, body any, dest any) int {
+		t.Helper()
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if dest != nil {
+			_ = json.NewDecoder(resp.Body).Decode(dest)
+		}
+		return resp.StatusCode
+	}
+	sanctumPostIDs := func() []uint {
+		t.Helper()
+		var posts []models.Post
+		if status := do(http.MethodGet, "/sanctums/gated/posts", reader.ID, nil, &posts); status != http.StatusOK {
+			t.Fatalf("list sanctum posts: expected 200, got %d", status)
+		}
+		ids := make([]uint, 0, len(posts))
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	if status := do(http.MethodPatch, "/sanctums/gated/settings", author.ID,
+		map[string]bool{"requires_post_approval": true}, nil); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-owner changing settings, got %d", status)
+	}
+	var dto SanctumDTO
+	if status := do(http.MethodPatch, "/sanctums/gated/settings", owner.ID,
+		map[string]bool{"requires_post_approval": true}, &dto); status != http.StatusOK {
+		t.Fatalf("expected 200 updating settings, got %d", status)
+	}
+	if !dto.RequiresPostApproval {
+		t.Fatal("expected requires_post_approval to be on")
+	}
+
+	var post models.Post
+	if status := do(http.MethodPost, "/posts", author.ID, map[string]any{
+		"title": "Needs review", "content": "body", "sanctum_id": sanctum.ID,
+	}, &post); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if post.Status != models.PostStatusPendingReview {
+		t.Fatalf("expected pending_review, got %q", post.Status)
+	}
+
+	if ids := sanctumPostIDs(); len(ids) != 0 {
+		t.Fatalf("pending post must be hidden from the sanctum feed, got %v", ids)
+	}
+	postPath := fmt.Sprintf("/posts/%d", post.ID)
+	if status := do(http.MethodGet, postPath, reader.ID, nil, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user reading a pending post, got %d", status)
+	}
+
+	var pending []models.Post
+	if status := do(http.MethodGet, "/sanctums/gated/posts/pending", owner.ID, nil, &pending); status != http.StatusOK {
+		t.Fatalf("expected 200 listing the queue, got %d", status)
+	}
+	if len(pending) != 1 || pending[0].ID != post.ID {
+		t.Fatalf("expected the pending post in the queue, got %+v", pending)
+	}
+
+	approvePath := fmt.Sprintf("/sanctums/gated/posts/%d/approve", post.ID)
+	if status := do(http.MethodPost, approvePath, reader.ID, nil, nil); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-admin approving, got %d", status)
+	}
+	var approved models.Post
+	if status := do(http.MethodPost, approvePath, owner.ID, nil, &approved); status != http.StatusOK {
+		t.Fatalf("expected 200 approving, got %d", status)
+	}
+	if approved.Status != models.PostStatusPublished {
+		t.Fatalf("expected published after approval, got %q", approved.Status)
+	}
+	if status := do(http.MethodPost, approvePath, owner.ID, nil, nil); status != http.StatusConflict {
+		t.Fatalf("expected 409 approving twice, got %d", status)
+	}
+
+	if ids := sanctumPostIDs(); len(ids) != 1 || ids[0] != post.ID {
+		t.Fatalf("approved post must be visible in the sanctum feed, got %v", ids)
+	}
+	if status := do(http.MethodGet, postPath, reader.ID, nil, nil); status != http.StatusOK {
+		t.Fatalf("expected approved post to be readable, got %d", status)
+	}
+
+	// Owners skip the queue in their own sanctum.
+	var ownerPost models.Post
+	if status := do(http.MethodPost, "/posts", owner.ID, map[string]any{
+		"title": "Announcement", "content": "body", "sanctum_id": sanctum.ID,
+	}, &ownerPost); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if ownerPost.Status != models.PostStatusPublished {
+		t.Fatalf("expected an owner's post to publish directly, got %q", ownerPost.Status)
+	}
+}
+
+func TestSanctumMembersOnlyPosting(t *testing.T) {
+	t.Parallel()
+
+	db := setupSanctumHandlerTestDB(t)
+	if err := db.AutoMigrate(&models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{}); err != nil {
+		t.Fatalf("migrate posts: %v", err)
+	}
+	s := &Server{
+		db:          db,
+		postService: service.NewPostService(repository.NewPostRepository(db), nil, nil),
+	}
+
+	mod := models.User{Username: "mod", Email: "mod@example.com", Password: "pw"}
+	member := models.User{Username: "member", Email: "member@example.com", Password: "pw"}
+	outsider := models.User{Username: "outsider", Email: "outsider@example.com", Password: "pw"}
+	for _, u := range []*models.User{&mod, &member, &outsider} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	sanctum := models.Sanctum{Name: "Club", Slug: "club", Status: models.SanctumStatusActive, MembersOnlyPosting: true}
+	open := models.Sanctum{Name: "Open", Slug: "open", Status: models.SanctumStatusActive}
+	for _, sc := range []*models.Sanctum{&sanctum, &open} {
+		if err := db.Create(sc).Error; err != nil {
+			t.Fatalf("create sanctum: %v", err)
+		}
+	}
+	for _, m := range []models.SanctumMembership{
+		{SanctumID: sanctum.ID, UserID: mod.ID, Role: models.SanctumMembershipRoleMod},
+		{SanctumID: sanctum.ID, UserID: member.ID, Role: models.SanctumMembershipRoleMember},
+	} {
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatalf("create membership: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		_, _ = fmt.Sscan(c.Get("X-User-ID"), &id)
+		c.Locals("userID", id)
+		return c.Next()
+	})
+	app.Post("/posts", s.CreatePost)
+
+	createPost := func(userID, sanctumID uint) int {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{"title": "Hello", "content": "body", "sanctum_id": sanctumID})
+		req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("create post: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		return resp.StatusCode
+	}
+
+	if status := createPost(outsider.ID, sanctum.ID); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-member, got %d", status)
+	}
+	if status := createPost(member.ID, sanctum.ID); status != http.StatusCreated {
+		t.Fatalf("expected 201 for a member, got %d", status)
+	}
+	if status := createPost(mod.ID, sanctum.ID); status != http.StatusCreated {
+		t.Fatalf("expected 201 for a moderator, got %d", status)
+	}
+	if status := createPost(outsider.ID, open.ID); status != http.StatusCreated {
+		t.Fatalf("expected 201 in a sanctum open to everyone, got %d", status)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,8 +21,10 @@ import (
 	"sanctum/internal/middleware"
 	"sanctum/internal/models"
 	"sanctum/internal/notifications"
+	"sanctum/internal/observability"
 	"sanctum/internal/repository"
 	"sanctum/internal/service"
+	"sanctum/internal/validation"
 
 	"github.com/ansrivas/fiberprometheus/v2"
 	"github.com/gofiber/fiber/v2"
@@ -43,6 +46,7 @@ type wireableHub interface {
 	Name() string
 	StartWiring(ctx context.Context, n *notifications.Notifier) error
 	Shutdown(ctx context.Context) error
+	Stats() notifications.HubStats
 }
 
 const (
@@ -90,12 +94,20 @@ type Server struct {
 	userService       *service.UserService
 	moderationService *service.ModerationService
 	gameService       *service.GameService
+	webhookService    *service.MessageWebhookService
+	pushService       *service.PushService
+	ownershipService  *service.SanctumOwnershipService
+	purgeService      *service.ContentPurgeService
+	typingTracker     *notifications.TypingTracker
 
 	// consumedTickets is a short-lived in-process cache allowing the WS upgrade
 	// multi-pass handshake to succeed after GETDEL has atomically consumed the
 	// ticket from Redis.
 	consumedTicketsMu sync.Mutex
 	consumedTickets   map[string]consumedTicketEntry
+
+	// imageStreams counts open image status streams so they can be capped.
+	imageStreams imageStreamLimiter
 }
 
 // NewServer creates a new server instance with all dependencies
@@ -112,7 +124,8 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
-	postRepo := repository.NewPostRepository(db)
+	postRepo := repository.NewPostRepository(db,
+		repository.WithHotHalfLife(time.Duration(cfg.FeedHotHalfLifeHours*float64(time.Hour))))
 	pollRepo := repository.NewPollRepository(db)
 	imageRepo := repository.NewImageRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
@@ -122,9 +135,13 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	// Initialize Prometheus metrics
 	prom := middleware.InitMetrics("sanctum-api")
+	if err := observability.RegisterActiveGamesCollector(db); err != nil {
+		return nil, fmt.Errorf("failed to register game metrics: %w", err)
+	}
 
-	// Initialize Logger with correct env
-	middleware.InitLogger(cfg.Env)
+	// Initialize Logger with the environment profile's format and level
+	profile := cfg.Profile()
+	middleware.InitLogger(profile.JSONLogs, profile.LogLevel)
 
 	server := &Server{
 		config:          cfg,
@@ -143,6 +160,10 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		consumedTickets: make(map[string]consumedTicketEntry),
 	}
 	server.postService = service.NewPostService(server.postRepo, server.pollRepo, server.isAdminByUserID)
+	server.postService.SetPublishedHook(func(_ context.Context, post *models.Post) {
+		server.announcePost(post)
+	})
+	server.postService.SetShadowBanCheck(server.isShadowBanned)
 	server.imageService = service.NewImageService(server.imageRepo, cfg)
 	server.commentService = service.NewCommentService(server.commentRepo, server.postRepo, server.isAdminByUserID)
 	server.chatService = service.NewChatService(
@@ -152,18 +173,46 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		server.isAdminByUserID,
 		server.canModerateChatroomByUserID,
 	)
+	server.chatService.SetMinDMAccountAge(time.Duration(cfg.DMMinAccountAgeHours) * time.Hour)
+	profanity := validation.NewProfanityFilter(cfg.ProfanityExtraWordList()...)
+	server.chatService.SetConversationNameRules(validation.ConversationNameRules{
+		MaxLength: cfg.ConversationNameMaxLength,
+		Profanity: profanity,
+	})
+	server.chatService.SetMessageContentFilter(profanity, cfg.ChatProfanityFilter)
+	server.chatService.SetImageService(server.imageService)
 	server.userService = service.NewUserService(server.userRepo)
 	server.moderationService = service.NewModerationService(server.db)
 	server.gameService = service.NewGameService(server.gameRepo)
+	server.gameService.SetCancelledHook(server.announceAbandonedGame)
+	server.webhookService = service.NewMessageWebhookService(server.db, cfg.Env != "production" && cfg.Env != "prod")
+	pushSender, err := newPushSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	server.pushService = service.NewPushService(server.db, pushSender)
+	server.ownershipService = service.NewSanctumOwnershipService(server.db)
+	server.purgeService = service.NewContentPurgeService(server.db, cfg)
+	server.typingTracker = notifications.NewTypingTracker(notifications.TypingIndicatorTTL, server.handleTypingExpired)
 	// NOTE: built-in sanctum seeding is intentionally NOT performed here.
 	// Seeding should be explicit during runtime bootstrap (cmd) or test setup.
 
+	notifications.SetHeartbeat(
+		time.Duration(cfg.WSPingIntervalSeconds)*time.Second,
+		time.Duration(cfg.WSPongTimeoutSeconds)*time.Second,
+	)
+
 	// Initialize notifier and hub if Redis is available
 	if redisClient != nil {
 		server.notifier = notifications.NewNotifier(redisClient)
+		server.imageService.SetStatusPublisher(server.notifier)
 
 		// Create a single shared ConnectionManager and wire it into both hubs.
-		sharedPresence := notifications.NewConnectionManager(redisClient, notifications.ConnectionManagerConfig{})
+		// Presence keys are refreshed on pongs, so they must outlive the pong
+		// timeout with room for a late one.
+		sharedPresence := notifications.NewConnectionManager(redisClient, notifications.ConnectionManagerConfig{
+			LastSeenTTL: notifications.PongWait * 5 / 2,
+		})
 
 		server.hub = notifications.NewHub(redisClient)
 		// Replace hub's manager with the shared instance
@@ -192,7 +241,8 @@ func NewServer(cfg *config.Config) (*Server, error) {
 func NewServerWithDeps(cfg *config.Config, db *gorm.DB, redisClient *redis.Client) (*Server, error) {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
-	postRepo := repository.NewPostRepository(db)
+	postRepo := repository.NewPostRepository(db,
+		repository.WithHotHalfLife(time.Duration(cfg.FeedHotHalfLifeHours*float64(time.Hour))))
 	pollRepo := repository.NewPollRepository(db)
 	imageRepo := repository.NewImageRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
@@ -202,9 +252,13 @@ func NewServerWithDeps(cfg *config.Config, db *gorm.DB, redisClient *redis.Clien
 
 	// Initialize Prometheus metrics
 	prom := middleware.InitMetrics("sanctum-api")
+	if err := observability.RegisterActiveGamesCollector(db); err != nil {
+		return nil, fmt.Errorf("failed to register game metrics: %w", err)
+	}
 
-	// Initialize Logger with correct env
-	middleware.InitLogger(cfg.Env)
+	// Initialize Logger with the environment profile's format and level
+	profile := cfg.Profile()
+	middleware.InitLogger(profile.JSONLogs, profile.LogLevel)
 
 	server := &Server{
 		config:          cfg,
@@ -224,6 +278,10 @@ func NewServerWithDeps(cfg *config.Config, db *gorm.DB, redisClient *redis.Clien
 	}
 
 	server.postService = service.NewPostService(server.postRepo, server.pollRepo, server.isAdminByUserID)
+	server.postService.SetPublishedHook(func(_ context.Context, post *models.Post) {
+		server.announcePost(post)
+	})
+	server.postService.SetShadowBanCheck(server.isShadowBanned)
 	server.imageService = service.NewImageService(server.imageRepo, cfg)
 	server.commentService = service.NewCommentService(server.commentRepo, server.postRepo, server.isAdminByUserID)
 	server.chatService = service.NewChatService(
@@ -233,16 +291,44 @@ func NewServerWithDeps(cfg *config.Config, db *gorm.DB, redisClient *redis.Clien
 		server.isAdminByUserID,
 		server.canModerateChatroomByUserID,
 	)
+	server.chatService.SetMinDMAccountAge(time.Duration(cfg.DMMinAccountAgeHours) * time.Hour)
+	profanity := validation.NewProfanityFilter(cfg.ProfanityExtraWordList()...)
+	server.chatService.SetConversationNameRules(validation.ConversationNameRules{
+		MaxLength: cfg.ConversationNameMaxLength,
+		Profanity: profanity,
+	})
+	server.chatService.SetMessageContentFilter(profanity, cfg.ChatProfanityFilter)
+	server.chatService.SetImageService(server.imageService)
 	server.userService = service.NewUserService(server.userRepo)
 	server.moderationService = service.NewModerationService(server.db)
 	server.gameService = service.NewGameService(server.gameRepo)
+	server.gameService.SetCancelledHook(server.announceAbandonedGame)
+	server.webhookService = service.NewMessageWebhookService(server.db, cfg.Env != "production" && cfg.Env != "prod")
+	pushSender, err := newPushSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	server.pushService = service.NewPushService(server.db, pushSender)
+	server.ownershipService = service.NewSanctumOwnershipService(server.db)
+	server.purgeService = service.NewContentPurgeService(server.db, cfg)
+	server.typingTracker = notifications.NewTypingTracker(notifications.TypingIndicatorTTL, server.handleTypingExpired)
+
+	notifications.SetHeartbeat(
+		time.Duration(cfg.WSPingIntervalSeconds)*time.Second,
+		time.Duration(cfg.WSPongTimeoutSeconds)*time.Second,
+	)
 
 	// Initialize notifier and hub if Redis is available
 	if redisClient != nil {
 		server.notifier = notifications.NewNotifier(redisClient)
+		server.imageService.SetStatusPublisher(server.notifier)
 
 		// Create a single shared ConnectionManager and wire it into both hubs.
-		sharedPresence := notifications.NewConnectionManager(redisClient, notifications.ConnectionManagerConfig{})
+		// Presence keys are refreshed on pongs, so they must outlive the pong
+		// timeout with room for a late one.
+		sharedPresence := notifications.NewConnectionManager(redisClient, notifications.ConnectionManagerConfig{
+			LastSeenTTL: notifications.PongWait * 5 / 2,
+		})
 
 		server.hub = notifications.NewHub(redisClient)
 		server.hub.SetPresenceManager(sharedPresence)
@@ -296,20 +382,19 @@ func (s *Server) SetupMiddleware(app *fiber.App) {
 
 	// CORS middleware should run before middlewares that can short-circuit (e.g. limiter)
 	// so browser clients still receive CORS headers on error responses.
-	origins := s.config.AllowedOrigins
-	if origins == "" {
-		origins = "http://localhost:5173,http://localhost:3000,http://127.0.0.1:5173"
-	}
+	profile := s.config.Profile()
 
 	app.Use(cors.New(cors.Config{
-		AllowOrigins:     origins,
+		AllowOrigins:     profile.AllowedOrigins,
 		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Upgrade, Connection, Sec-WebSocket-Key, Sec-WebSocket-Version",
+		ExposeHeaders:    "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset",
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 hours
 	}))
 
-	// Global rate limiting (100 requests per minute per IP); disabled in development/test/stress so workflows are not throttled.
-	if s.config.Env != "development" && s.config.Env != "test" && s.config.Env != "stress" {
+	// Global rate limiting (100 requests per minute per IP); the development, test
+	// and stress profiles turn it off so workflows are not throttled.
+	if profile.RateLimit {
 		app.Use(limiter.New(limiter.Config{
 			Max:        100,
 			Expiration: 1 * time.Minute,
@@ -339,6 +424,8 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	// Health checks
 	app.Get("/health/live", s.LivenessCheck)
 	app.Get("/health/ready", s.ReadinessCheck)
+	// Per-hub load is operational detail, so it is restricted to admins.
+	app.Get("/health/hubs", s.AuthRequired(), s.AdminRequired(), s.HubsHealthCheck)
 	// Backwards-compatible legacy route: map /health to readiness (keeps existing scripts working)
 	app.Get("/health", s.ReadinessCheck)
 	api.Get("/", s.HealthCheck) // Vibecheck alias
@@ -362,6 +449,9 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 		s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "login"), s.Login)
 	auth.Post("/refresh", s.Refresh)
 	auth.Post("/logout", s.AuthRequired(), s.Logout)
+	auth.Get("/sessions", s.AuthRequired(), s.GetSessions)
+	auth.Delete("/sessions", s.AuthRequired(), s.RevokeAllSessions)
+	auth.Delete("/sessions/:jti", s.AuthRequired(), s.RevokeSession)
 
 	// Public post routes (browse/search)
 	publicPosts := api.Group("/posts")
@@ -373,11 +463,15 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	images := api.Group("/images")
 	images.Get("/:hash", s.ServeImage)
 	images.Get("/:hash/status", s.GetImageStatus)
+	images.Get("/:hash/events", s.AuthRequired(), middleware.RateLimit(
+		s.redis, s.config.Env, 30, time.Minute, "image_events"), s.StreamImageStatus)
 
 	// Public sanctum routes
 	sanctums := api.Group("/sanctums")
 	sanctums.Get("/", s.GetSanctums)
 	sanctums.Get("/:slug", s.GetSanctumBySlug)
+	sanctums.Get("/:slug/posts", s.GetSanctumPosts)
+	sanctums.Get("/:slug/tags", s.GetSanctumTags)
 
 	// Protected routes
 	protected := api.Group("", s.AuthRequired())
@@ -391,6 +485,14 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	sanctumAdmins.Get("/", s.GetSanctumAdmins)
 	sanctumAdmins.Post("/:userId", s.PromoteSanctumAdmin)
 	sanctumAdmins.Delete("/:userId", s.DemoteSanctumAdmin)
+	protected.Patch("/sanctums/:slug/settings", s.UpdateSanctumSettings)
+	protected.Get("/sanctums/:slug/posts/pending", s.GetPendingSanctumPosts)
+	protected.Post("/sanctums/:slug/posts/:id/approve", s.ApproveSanctumPost)
+	protected.Get("/sanctums/:slug/analytics", s.GetSanctumAnalytics)
+	sanctumBans := protected.Group("/sanctums/:slug/bans")
+	sanctumBans.Get("/", s.ListSanctumBans)
+	sanctumBans.Post("/:userId", s.AddSanctumBan)
+	sanctumBans.Delete("/:userId", s.RemoveSanctumBan)
 	sanctumMemberships := protected.Group("/sanctums/memberships")
 	sanctumMemberships.Get("/me", s.GetMySanctumMemberships)
 	sanctumMemberships.Post("/bulk", s.UpsertMySanctumMemberships)
@@ -399,7 +501,11 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	users := protected.Group("/users")
 	users.Get("/me", s.GetMyProfile)
 	users.Put("/me", s.UpdateMyProfile)
+	users.Delete("/me", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "reauth"), s.DeleteMyAccount)
+	users.Post("/me/password", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "reauth"), s.ChangeMyPassword)
+	users.Post("/me/email", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "reauth"), s.ChangeMyEmail)
 	users.Get("/me/mentions", s.GetMyMentions)
+	users.Get("/me/bookmarks", s.GetMyBookmarks)
 	users.Get("/blocks/me", s.GetMyBlocks)
 	users.Get("/search", s.SearchUsers)
 	users.Get("/", s.GetAllUsers)
@@ -411,16 +517,34 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	// Define specific /:id/:resource routes BEFORE generic /:id route
 	users.Get("/:id/cached", s.GetUserCached)
 	users.Get("/:id/posts", s.GetUserPosts)
+	users.Get("/:id/mutual-friends", s.GetMutualFriends)
 	users.Post("/:id/promote-admin", s.AdminRequired(), s.PromoteToAdmin)
 	users.Post("/:id/demote-admin", s.AdminRequired(), s.DemoteFromAdmin)
 	users.Post("/:id/block", s.BlockUser)
 	users.Delete("/:id/block", s.UnblockUser)
 	users.Post("/:id/report", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "report"), s.ReportUser)
 	users.Get("/:id", s.GetUserProfile)
+	protected.Get("/mentions/suggest", middleware.RateLimit(
+		s.redis, s.config.Env, 60, time.Minute, "mention_suggest"), s.SuggestMentions)
+
+	// Notification routes
+	notificationRoutes := protected.Group("/notifications")
+	notificationRoutes.Get("/", s.GetNotifications)
+	notificationRoutes.Get("/unread-count", s.GetUnreadNotificationCount)
+	notificationRoutes.Post("/read-all", s.MarkAllNotificationsRead)
+	notificationRoutes.Delete("/", s.ClearNotifications)
+	notificationRoutes.Post("/:id/read", s.MarkNotificationRead)
+
+	// Web Push routes
+	push := protected.Group("/push")
+	push.Get("/vapid-public-key", s.GetVAPIDPublicKey)
+	push.Post("/subscriptions", s.SubscribePush)
+	push.Delete("/subscriptions", s.UnsubscribePush)
 
 	// Friend routes
 	friends := protected.Group("/friends")
 	friends.Get("/", s.GetFriends)
+	friends.Get("/suggestions", s.GetFriendSuggestions)
 	// Specific /requests routes before generic /:userId
 	friends.Post("/requests/:userId", middleware.RateLimit(
 		s.redis, s.config.Env, 5, 5*time.Minute, "friend_request"), s.SendFriendRequest)
@@ -430,9 +554,13 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	friends.Post("/requests/:requestId/reject", s.RejectFriendRequest)
 	// Specific /status routes before generic /:userId
 	friends.Get("/status/:userId", s.GetFriendshipStatus)
+	friends.Post("/status/batch", s.GetFriendshipStatusBatch)
 	// Generic /:userId route must be last
 	friends.Delete("/:userId", s.RemoveFriend)
 
+	protected.Get("/feed", s.GetFeed)
+	protected.Get("/feature-flags/me", s.GetMyFeatureFlags)
+
 	// Protected post routes
 	posts := protected.Group("/posts")
 	posts.Post("/", middleware.RateLimit(
@@ -440,12 +568,19 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	// Define specific /:id/:resource routes BEFORE generic /:id route
 	posts.Post("/:id/like", s.LikePost)
 	posts.Delete("/:id/like", s.UnlikePost)
+	posts.Post("/:id/bookmark", s.BookmarkPost)
+	posts.Get("/:id/revisions", s.GetPostRevisions)
+	posts.Delete("/:id/bookmark", s.UnbookmarkPost)
 	posts.Post("/:id/comments", middleware.RateLimit(
 		s.redis, s.config.Env, 1, time.Minute, "create_comment"), s.CreateComment)
 	posts.Put("/:id/comments/:commentId", s.UpdateComment)
 	posts.Delete("/:id/comments/:commentId", s.DeleteComment)
+	posts.Post("/:id/comments/:commentId/like", s.LikeComment)
+	posts.Delete("/:id/comments/:commentId/like", s.UnlikeComment)
 	posts.Post("/:id/report", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "report"), s.ReportPost)
 	posts.Post("/:id/poll/vote", s.VotePoll)
+	posts.Post("/:id/crosspost", middleware.RateLimit(
+		s.redis, s.config.Env, 10, 5*time.Minute, "crosspost"), s.CrosspostPost)
 	// Generic /:id routes (for item detail, update, delete)
 	posts.Put("/:id", s.UpdatePost)
 	posts.Delete("/:id", s.DeletePost)
@@ -454,13 +589,30 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	conversations := protected.Group("/conversations")
 	conversations.Post("/", s.CreateConversation)
 	conversations.Get("/", s.GetConversations)
+	conversations.Get("/unread-summary", s.GetUnreadSummary)
+	conversations.Patch("/:id", s.RenameConversation)
+	conversations.Put("/:id", s.RenameConversation)
+	conversations.Post("/:id/transfer", s.TransferConversation)
 	// Define specific /:id/:resource routes BEFORE generic /:id route
 	conversations.Get("/:id/messages", s.GetMessages)
 	conversations.Post("/:id/messages", middleware.RateLimit(
 		s.redis, s.config.Env, 15, time.Minute, "send_chat"), s.SendMessage)
+	conversations.Get("/:id/messages/:messageId", s.GetMessage)
 	conversations.Post("/:id/read", s.MarkConversationRead)
+	conversations.Get("/:id/read-state", s.GetConversationReadState)
+	conversations.Post("/:id/read-receipts", s.ShareReadReceipts)
+	conversations.Delete("/:id/read-receipts", s.HideReadReceipts)
+	conversations.Post("/:id/mute", s.MuteConversation)
+	conversations.Delete("/:id/mute", s.UnmuteConversation)
+	conversations.Get("/:id/webhook", s.GetConversationWebhook)
+	conversations.Put("/:id/webhook", s.UpsertConversationWebhook)
+	conversations.Delete("/:id/webhook", s.DeleteConversationWebhook)
 	conversations.Post("/:id/messages/:messageId/reactions", s.AddMessageReaction)
 	conversations.Delete("/:id/messages/:messageId/reactions", s.RemoveMessageReaction)
+	conversations.Post("/:id/messages/:messageId/pin", s.PinChatroomMessage)
+	conversations.Delete("/:id/messages/:messageId/pin", s.UnpinChatroomMessage)
+	conversations.Get("/:id/pins", s.GetPinnedMessages)
+	conversations.Get("/:id/members", s.GetConversationMembers)
 	conversations.Post("/:id/messages/:messageId/report", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "report"), s.ReportMessage)
 	conversations.Post("/:id/participants", s.AddParticipant)
 	conversations.Delete("/:id", s.LeaveConversation)
@@ -482,6 +634,10 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	chatrooms.Get("/:id/moderators", s.GetChatroomModerators)
 	chatrooms.Post("/:id/moderators/:userId", s.AddChatroomModerator)
 	chatrooms.Delete("/:id/moderators/:userId", s.RemoveChatroomModerator)
+	chatrooms.Post("/:id/messages/bulk-delete", s.BulkDeleteChatroomMessages)
+	chatrooms.Put("/:id/slow-mode", s.SetChatroomSlowMode)
+	chatrooms.Get("/:id/content-filter", s.GetChatroomContentFilter)
+	chatrooms.Put("/:id/content-filter", s.UpdateChatroomContentFilter)
 
 	// Game routes
 	games := protected.Group("/games")
@@ -489,6 +645,7 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	games.Get("/rooms/active", s.GetActiveGameRooms)
 	games.Post("/rooms/:id/leave", s.LeaveGameRoom)
 	games.Get("/stats/:type", s.GetGameStats)
+	games.Get("/leaderboard", s.GetGameLeaderboard)
 	games.Get("/rooms/:id", s.GetGameRoom)
 	games.Get("/rooms/:id/messages", s.GetGameRoomMessages)
 
@@ -500,15 +657,24 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 
 	// Admin routes
 	admin := protected.Group("/admin", s.AdminRequired())
+	admin.Get("/sanctums/inactive-owners", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetInactiveOwnerSanctums)
+	admin.Post("/sanctums/:slug/reassign-owner", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.ReassignInactiveSanctumOwner)
 	admin.Delete("/sanctums/:slug", s.DeleteSanctum)
 	admin.Get("/feature-flags", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetFeatureFlags)
 	admin.Get("/reports", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminReports)
+	admin.Post("/reports/resolve-bulk", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.BulkResolveAdminReports)
 	admin.Post("/reports/:id/resolve", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.ResolveAdminReport)
 	admin.Get("/ban-requests", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminBanRequests)
 	admin.Get("/users", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminUsers)
 	admin.Get("/users/:id", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminUserDetail)
 	admin.Post("/users/:id/ban", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.BanUser)
 	admin.Post("/users/:id/unban", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.UnbanUser)
+	admin.Post("/users/:id/shadow-ban", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.SetUserShadowBan)
+	admin.Get("/ip-bans", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetIPBans)
+	admin.Post("/ip-bans", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.CreateIPBan)
+	admin.Delete("/ip-bans/:id", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.DeleteIPBan)
+	admin.Get("/audit-log", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminAuditLog)
+	admin.Get("/audit/export", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 5*time.Minute, middleware.FailClosed, "admin_export"), s.ExportAdminAuditLog)
 	adminSanctumRequests := admin.Group("/sanctum-requests")
 	adminSanctumRequests.Get("/", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminSanctumRequests)
 	adminSanctumRequests.Post("/:id/approve", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.ApproveSanctumRequest)
@@ -570,6 +736,22 @@ func (s *Server) ReadinessCheck(c *fiber.Ctx) error {
 	})
 }
 
+// HubsHealthCheck reports live WebSocket load for each hub on this instance.
+func (s *Server) HubsHealthCheck(c *fiber.Ctx) error {
+	hubs := make(map[string]notifications.HubStats, len(s.hubs))
+	totalConnections := 0
+	for _, hub := range s.hubs {
+		stats := hub.Stats()
+		hubs[hub.Name()] = stats
+		totalConnections += stats.Connections
+	}
+	return c.JSON(fiber.Map{
+		"hubs":              hubs,
+		"total_connections": totalConnections,
+		"time":              time.Now(),
+	})
+}
+
 // AdminRequired returns middleware that rejects non-admin users with 403.
 // Must be placed after AuthRequired so that userID is available in locals.
 func (s *Server) AdminRequired() fiber.Handler {
@@ -688,11 +870,11 @@ func (s *Server) AuthRequired() fiber.Handler {
 		}
 
 		// Validate issuer and audience
-		if issuer, issuerOk := claims["iss"].(string); !issuerOk || (issuer != "sanctum-api" && issuer != "vibeshift-api") {
+		if issuer, issuerOk := claims["iss"].(string); !issuerOk || !slices.Contains(s.config.JWTIssuerList(), issuer) {
 			return models.RespondWithError(c, fiber.StatusUnauthorized,
 				models.NewUnauthorizedError("Invalid token issuer"))
 		}
-		if audience, audienceOk := claims["aud"].(string); !audienceOk || (audience != "sanctum-client" && audience != "vibeshift-client") {
+		if audience, audienceOk := claims["aud"].(string); !audienceOk || !slices.Contains(s.config.JWTAudienceList(), audience) {
 			return models.RespondWithError(c, fiber.StatusUnauthorized,
 				models.NewUnauthorizedError("Invalid token audience"))
 		}
@@ -710,15 +892,10 @@ func (s *Server) AuthRequired() fiber.Handler {
 				models.NewUnauthorizedError("Invalid user ID in token"))
 		}
 
-		// Check JTI for revocation
-		if jti, exists := claims["jti"].(string); exists && jti != "" {
-			if s.redis != nil {
-				isBlacklisted, err := s.redis.Exists(c.Context(), "blacklist:"+jti).Result()
-				if err == nil && isBlacklisted > 0 {
-					return models.RespondWithError(c, fiber.StatusUnauthorized,
-						models.NewUnauthorizedError("Token has been revoked"))
-				}
-			}
+		// Check JTI and session for revocation
+		if s.tokenRevoked(c.Context(), claims) {
+			return models.RespondWithError(c, fiber.StatusUnauthorized,
+				models.NewUnauthorizedError("Token has been revoked"))
 		}
 
 		// Store user ID in context
@@ -865,6 +1042,12 @@ func (s *Server) optionalUserID(c *fiber.Ctx) uint {
 	if !ok {
 		return 0
 	}
+	if issuer, _ := claims["iss"].(string); !slices.Contains(s.config.JWTIssuerList(), issuer) {
+		return 0
+	}
+	if audience, _ := claims["aud"].(string); !slices.Contains(s.config.JWTAudienceList(), audience) {
+		return 0
+	}
 
 	sub, ok := claims["sub"].(string)
 	if !ok {
@@ -874,16 +1057,40 @@ func (s *Server) optionalUserID(c *fiber.Ctx) uint {
 	if err != nil {
 		return 0
 	}
+	if s.tokenRevoked(c.Context(), claims) {
+		return 0
+	}
 	return uint(userID)
 }
 
+// tokenRevoked reports whether the access token was blacklisted on its own
+// (logout) or belongs to a revoked session. Checking the sid cuts off every
+// access token minted for the session, not just the latest one.
+func (s *Server) tokenRevoked(ctx context.Context, claims jwt.MapClaims) bool {
+	if s.redis == nil {
+		return false
+	}
+	keys := make([]string, 0, 2)
+	if jti, _ := claims["jti"].(string); jti != "" {
+		keys = append(keys, "blacklist:"+jti)
+	}
+	if sid, _ := claims["sid"].(string); sid != "" {
+		keys = append(keys, revokedSessionKey(sid))
+	}
+	if len(keys) == 0 {
+		return false
+	}
+	revoked, err := s.redis.Exists(ctx, keys...).Result()
+	return err == nil && revoked > 0
+}
+
 // Start starts the server
 func (s *Server) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	s.shutdownCtx = ctx
 	s.shutdownFn = cancel
 
-	isProduction := s.config.Env == "production" || s.config.Env == "prod"
+	isProduction := s.config.Profile().Production
 
 	fiberCfg := fiber.Config{
 		AppName: "Social Media API",
@@ -921,6 +1128,9 @@ func (s *Server) Start() error {
 	s.SetupMiddleware(app)
 	s.SetupRoutes(app)
 	s.imageSvc().StartBackgroundWorker(s.shutdownCtx)
+	s.purgeService.StartBackgroundWorker(s.shutdownCtx)
+	s.postSvc().StartBackgroundWorker(s.shutdownCtx)
+	s.gameSvc().StartBackgroundWorker(s.shutdownCtx)
 
 	// Start consumed ticket cache cleanup
 	go s.cleanupConsumedTickets(s.shutdownCtx)
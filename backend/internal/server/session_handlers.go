@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// accessTokenTTL is the lifetime of an access token, and therefore how long a
+// revoked session must stay marked as revoked.
+const accessTokenTTL = 15 * time.Minute
+
+// Session is one signed-in device. Its ID is the jti of the refresh token
+// family started at login, and it lives as long as that family does.
+type Session struct {
+	JTI       string    `json:"jti"`
+	IssuedAt  time.Time `json:"issued_at"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	LastUsed  time.Time `json:"last_used"`
+	Current   bool      `json:"current"`
+}
+
+func sessionKey(userID uint, sessionID string) string {
+	return fmt.Sprintf("session:%d:%s", userID, sessionID)
+}
+
+func userSessionsKey(userID uint) string {
+	return fmt.Sprintf("sessions:%d", userID)
+}
+
+// revokedSessionKey marks a session whose access tokens must be refused, no
+// matter how many were minted for it.
+func revokedSessionKey(sessionID string) string {
+	return "revoked_session:" + sessionID
+}
+
+// startSession records a new session for the request's device.
+func (s *Server) startSession(c *fiber.Ctx, userID uint, sessionID string) error {
+	if s.redis == nil {
+		return nil
+	}
+	ctx := c.Context()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	key := sessionKey(userID, sessionID)
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, key,
+		"issued_at", now,
+		"last_used", now,
+		"user_agent", c.Get(fiber.HeaderUserAgent),
+		"ip", c.IP(),
+	)
+	pipe.Expire(ctx, key, refreshTokenTTL)
+	pipe.SAdd(ctx, userSessionsKey(userID), sessionID)
+	pipe.Expire(ctx, userSessionsKey(userID), refreshTokenTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
+
+// touchSession marks the session as used.
+func (s *Server) touchSession(ctx context.Context, userID uint, sessionID string) error {
+	key := sessionKey(userID, sessionID)
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, key, "last_used", strconv.FormatInt(time.Now().Unix(), 10))
+	pipe.Expire(ctx, key, refreshTokenTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// revokeSession ends a session: its refresh token family can no longer be
+// used, and every access token carrying its sid is refused until the last of
+// them has expired.
+func (s *Server) revokeSession(ctx context.Context, userID uint, sessionID string) error {
+	key := sessionKey(userID, sessionID)
+	if err := s.redis.Set(ctx, revokedSessionKey(sessionID), "1", accessTokenTTL).Err(); err != nil {
+		return err
+	}
+	if err := s.revokeRefreshTokenFamily(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	if err := s.redis.Del(ctx, key).Err(); err != nil {
+		return err
+	}
+	return s.redis.SRem(ctx, userSessionsKey(userID), sessionID).Err()
+}
+
+// revokeAllSessions revokes every session the user has and returns how many
+// there were.
+func (s *Server) revokeAllSessions(ctx context.Context, userID uint) (int, error) {
+	if s.redis == nil {
+		return 0, nil
+	}
+	ids, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.revokeSession(ctx, userID, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// listSessions returns the user's live sessions, most recently used first,
+// pruning index entries whose session has expired.
+func (s *Server) listSessions(ctx context.Context, userID uint) ([]Session, error) {
+	ids, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		fields, err := s.redis.HGetAll(ctx, sessionKey(userID, id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			s.redis.SRem(ctx, userSessionsKey(userID), id)
+			continue
+		}
+		sessions = append(sessions, Session{
+			JTI:       id,
+			IssuedAt:  unixField(fields["issued_at"]),
+			UserAgent: fields["user_agent"],
+			IP:        fields["ip"],
+			LastUsed:  unixField(fields["last_used"]),
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsed.After(sessions[j].LastUsed)
+	})
+	return sessions, nil
+}
+
+func unixField(v string) time.Time {
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0).UTC()
+}
+
+// currentSessionID returns the session the request's access token belongs to.
+func (s *Server) currentSessionID(c *fiber.Ctx) string {
+	tokenString := bearerToken(c)
+	if tokenString == "" {
+		return ""
+	}
+	token, err := jwt.Parse(tokenString, func(_ *jwt.Token) (interface{}, error) {
+		return []byte(s.config.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+// GetSessions handles GET /api/auth/sessions
+// @Summary List active sessions
+// @Description List the devices currently signed in to the caller's account
+// @Tags auth
+// @Produce json
+// @Success 200 {array} Session
+// @Router /auth/sessions [get]
+func (s *Server) GetSessions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	if s.redis == nil {
+		return c.JSON([]Session{})
+	}
+
+	sessions, err := s.listSessions(c.Context(), userID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, models.NewInternalError(err))
+	}
+	current := s.currentSessionID(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].JTI == current
+	}
+	return c.JSON(sessions)
+}
+
+// RevokeSession handles DELETE /api/auth/sessions/:jti
+// @Summary Revoke a session
+// @Description Sign out one of the caller's devices remotely
+// @Tags auth
+// @Produce json
+// @Param jti path string true "Session ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} object{error=string}
+// @Router /auth/sessions/{jti} [delete]
+func (s *Server) RevokeSession(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	sessionID := c.Params("jti")
+	if s.redis == nil {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Session", sessionID))
+	}
+
+	exists, err := s.redis.Exists(c.Context(), sessionKey(userID, sessionID)).Result()
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, models.NewInternalError(err))
+	}
+	if exists == 0 {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Session", sessionID))
+	}
+
+	if err := s.revokeSession(c.Context(), userID, sessionID); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, models.NewInternalError(err))
+	}
+	return c.JSON(fiber.Map{"message": "Session revoked"})
+}
+
+// RevokeAllSessions handles DELETE /api/auth/sessions
+// @Summary Log out everywhere
+// @Description Revoke every session on the caller's account, including this one
+// @Tags auth
+// @Produce json
+// @Success 200 {object} object{message=string,count=int}
+// @Router /auth/sessions [delete]
+func (s *Server) RevokeAllSessions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	count, err := s.revokeAllSessions(c.Context(), userID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, models.NewInternalError(err))
+	}
+
+	// The caller's own token may predate sessions; revoke it explicitly.
+	s.blacklistAccessToken(c)
+	c.ClearCookie("refresh_token")
+	return c.JSON(fiber.Map{"message": "Logged out everywhere", "count": count})
+}
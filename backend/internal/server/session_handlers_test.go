@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSessionsListAndRevoke(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("Password123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{ID: 1, Email: "test@example.com", Username: "testuser", Password: string(hashedPassword)}
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	s := &Server{
+		config:   &config.Config{JWTSecret: "test_secret"},
+		userRepo: mockRepo,
+		redis:    rdb,
+	}
+	app := fiber.New()
+	app.Post("/auth/login", s.Login)
+	app.Post("/auth/refresh", s.Refresh)
+	app.Get("/auth/sessions", s.AuthRequired(), s.GetSessions)
+	app.Delete("/auth/sessions/:jti", s.AuthRequired(), s.RevokeSession)
+
+	do := func(method, path, token string, body any) *http.Response {
+		var payload []byte
+		if body != nil {
+			payload, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "agent-"+path)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+		return resp
+	}
+	login := func() (string, string) {
+		resp := do(http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": "Password123!"})
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result.Token, result.RefreshToken
+	}
+
+	laptopAccess, _ := login()
+	phoneAccess, phoneRefresh := login()
+
+	// Refreshing mints a second access token for the same session while the
+	// first one is still valid.
+	resp := do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": phoneRefresh})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var refreshed struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&refreshed))
+	_ = resp.Body.Close()
+	require.NotEqual(t, phoneAccess, refreshed.Token)
+
+	resp = do(http.MethodGet, "/auth/sessions", laptopAccess, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var sessions []Session
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sessions))
+	_ = resp.Body.Close()
+	require.Len(t, sessions, 2)
+
+	var laptop, phone Session
+	for _, session := range sessions {
+		assert.Equal(t, "agent-/auth/login", session.UserAgent)
+		assert.False(t, session.IssuedAt.IsZero())
+		if session.Current {
+			laptop = session
+		} else {
+			phone = session
+		}
+	}
+	require.NotEmpty(t, laptop.JTI, "the caller's own session is marked current")
+	require.NotEmpty(t, phone.JTI)
+
+	resp = do(http.MethodDelete, "/auth/sessions/"+phone.JTI, laptopAccess, nil)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Every access token of the revoked session is refused, not just the
+	// latest; the other session keeps working.
+	for _, token := range []string{phoneAccess, refreshed.Token} {
+		resp = do(http.MethodGet, "/auth/sessions", token, nil)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+	resp = do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": refreshed.RefreshToken})
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/auth/sessions", laptopAccess, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	sessions = nil
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sessions))
+	_ = resp.Body.Close()
+	require.Len(t, sessions, 1)
+	assert.Equal(t, laptop.JTI, sessions[0].JTI)
+
+	resp = do(http.MethodDelete, "/auth/sessions/"+phone.JTI, laptopAccess, nil)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// shadowBanSnapshot is the audited part of a user for shadow bans.
+type shadowBanSnapshot struct {
+	ShadowBanned bool `json:"shadow_banned"`
+}
+
+// shadowBannedAmong returns which of userIDs are shadow banned, leaving out
+// viewerID: shadow-banned users always see their own content.
+func (s *Server) shadowBannedAmong(ctx context.Context, viewerID uint, userIDs []uint) (map[uint]bool, error) {
+	if s.db == nil || len(userIDs) == 0 {
+		return nil, nil
+	}
+	var ids []uint
+	if err := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("id IN ? AND id <> ? AND shadow_banned = ?", userIDs, viewerID, true).
+		Pluck("id", &ids).Error; err != nil {
+		if models.IsSchemaMissingError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	hidden := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		hidden[id] = true
+	}
+	return hidden, nil
+}
+
+// isShadowBanned reports whether userID is shadow banned.
+func (s *Server) isShadowBanned(ctx context.Context, userID uint) (bool, error) {
+	hidden, err := s.shadowBannedAmong(ctx, 0, []uint{userID})
+	return hidden[userID], err
+}
+
+// publishAuthoredEvent broadcasts an event about content authorID just
+// created. Content by shadow-banned authors is only announced back to the
+// author; so is everything when the lookup fails, rather than risk a leak.
+func (s *Server) publishAuthoredEvent(ctx context.Context, authorID uint, eventType string, payload map[string]interface{}) {
+	banned, err := s.isShadowBanned(ctx, authorID)
+	if err != nil {
+		log.Printf("shadow ban lookup error: %v", err)
+	}
+	if banned || err != nil {
+		s.publishUserEvent(authorID, eventType, payload)
+		return
+	}
+	s.publishBroadcastEvent(eventType, payload)
+}
+
+// echoShadowBannedMessage delivers a chatroom message from a shadow-banned
+// sender back to the sender only, and reports whether it did. Callers skip
+// the normal room broadcast, mentions, webhooks and push when it returns true.
+func (s *Server) echoShadowBannedMessage(ctx context.Context, conv *models.Conversation, message *models.Message, username string) bool {
+	if !conv.IsGroup {
+		return false
+	}
+	banned, err := s.isShadowBanned(ctx, message.SenderID)
+	if err != nil {
+		log.Printf("shadow ban lookup error: %v", err)
+		return false
+	}
+	if !banned {
+		return false
+	}
+	if s.chatHub != nil {
+		for _, eventType := range []string{"message", "room_message"} {
+			s.chatHub.SendToUser(message.SenderID, notifications.ChatMessage{
+				Type:           eventType,
+				ConversationID: conv.ID,
+				UserID:         message.SenderID,
+				Username:       username,
+				Payload:        message,
+			})
+		}
+	}
+	return true
+}
+
+// SetUserShadowBan handles POST /api/admin/users/:id/shadow-ban.
+// @Summary Shadow ban or unshadow ban a user
+// @Description Hide a user's posts, comments and chatroom messages from everyone else without telling them.
+// @Tags moderation-admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body object{shadow_banned=bool} true "Shadow ban state"
+// @Success 200 {object} object{message=string,shadow_banned=bool}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/users/{id}/shadow-ban [post]
+func (s *Server) SetUserShadowBan(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	adminID := c.Locals("userID").(uint)
+	targetID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	if adminID == targetID {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("cannot shadow ban yourself"))
+	}
+
+	var req struct {
+		ShadowBanned *bool `json:"shadow_banned"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ShadowBanned == nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("shadow_banned is required"))
+	}
+	shadowBanned := *req.ShadowBanned
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target models.User
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+		before := shadowBanSnapshot{ShadowBanned: target.ShadowBanned}
+		if err := tx.Model(&target).Update("shadow_banned", shadowBanned).Error; err != nil {
+			return err
+		}
+		after := shadowBanSnapshot{ShadowBanned: shadowBanned}
+		return recordAdminAction(tx, adminID, models.AuditActionUserShadowBan, "user", targetID, before, after)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("User", targetID))
+		}
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	message := "User shadow banned"
+	if !shadowBanned {
+		message = "User shadow ban lifted"
+	}
+	return c.JSON(fiber.Map{"message": message, "shadow_banned": shadowBanned})
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowBannedPostsAreHiddenInQueries(t *testing.T) {
+	db := setupModerationTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Tag{}, &models.Like{}, &models.Poll{}, &models.PollOption{}))
+	shadow := models.User{Username: "shadow", Email: "shadow@e.com", ShadowBanned: true}
+	other := models.User{Username: "other", Email: "other@e.com"}
+	admin := models.User{Username: "admin", Email: "admin@e.com", IsAdmin: true}
+	require.NoError(t, db.Create(&shadow).Error)
+	require.NoError(t, db.Create(&other).Error)
+	require.NoError(t, db.Create(&admin).Error)
+
+	otherPost := models.Post{UserID: other.ID, Title: "from other", Content: "c", Status: models.PostStatusPublished}
+	require.NoError(t, db.Create(&otherPost).Error)
+	shadowPost := models.Post{UserID: shadow.ID, Title: "from shadow", Content: "c", Status: models.PostStatusPublished}
+	require.NoError(t, db.Create(&shadowPost).Error)
+
+	s := &Server{
+		db:     db,
+		config: &config.Config{JWTSecret: "test_secret"},
+	}
+	s.postService = service.NewPostService(repository.NewPostRepository(db), nil, s.isAdminByUserID)
+	s.postService.SetShadowBanCheck(s.isShadowBanned)
+	app := fiber.New()
+	app.Get("/posts", s.GetPosts)
+	app.Get("/posts/:id", s.GetPost)
+
+	get := func(path string, user *models.User, dest any) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if user != nil {
+			token, err := s.generateAccessToken(user.ID, user.Username, "")
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		if dest != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(dest))
+		}
+		return resp.StatusCode
+	}
+	listAs := func(user *models.User, query string) []uint {
+		t.Helper()
+		var posts []models.Post
+		require.Equal(t, http.StatusOK, get("/posts"+query, user, &posts))
+		ids := make([]uint, 0, len(posts))
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []uint{shadowPost.ID, otherPost.ID}, listAs(&shadow, ""), "shadow-banned user still sees their own post")
+	assert.Equal(t, []uint{otherPost.ID}, listAs(&other, ""))
+	assert.Equal(t, []uint{otherPost.ID}, listAs(nil, ""))
+	assert.ElementsMatch(t, []uint{shadowPost.ID, otherPost.ID}, listAs(&admin, ""), "admins still see it to moderate")
+	// Filtering happens before pagination, so pages stay full.
+	assert.Equal(t, []uint{otherPost.ID}, listAs(&other, "?limit=1"))
+
+	shadowPostPath := fmt.Sprintf("/posts/%d", shadowPost.ID)
+	assert.Equal(t, http.StatusOK, get(shadowPostPath, &shadow, nil))
+	assert.Equal(t, http.StatusNotFound, get(shadowPostPath, &other, nil))
+	assert.Equal(t, http.StatusNotFound, get(shadowPostPath, nil, nil))
+}
+
+func TestShadowBannedRoomMessagesStayWithSender(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+
+	shadow := models.User{Username: "shadow", Email: "shadow@e.com", Password: "pw", ShadowBanned: true}
+	member := models.User{Username: "member", Email: "member@e.com", Password: "pw"}
+	require.NoError(t, db.Create(&shadow).Error)
+	require.NoError(t, db.Create(&member).Error)
+
+	room := models.Conversation{Name: "Room", IsGroup: true, CreatedBy: member.ID}
+	require.NoError(t, db.Create(&room).Error)
+	for _, id := range []uint{shadow.ID, member.ID} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: id}).Error)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		_, _ = fmt.Sscan(c.Get("X-User-ID"), &id)
+		c.Locals("userID", id)
+		return c.Next()
+	})
+	app.Post("/conversations/:id/messages", s.SendMessage)
+	app.Get("/conversations/:id/messages", s.GetMessages)
+	app.Get("/conversations/:id/messages/:messageId", s.GetMessage)
+
+	send := func(user models.User, content string) {
+		t.Helper()
+		body, _ := json.Marshal(map[string]string{"content": content})
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/conversations/%d/messages", room.ID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", fmt.Sprint(user.ID))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	history := func(user models.User, query string) []string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/messages%s", room.ID, query), nil)
+		req.Header.Set("X-User-ID", fmt.Sprint(user.ID))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page struct {
+			Messages []models.Message `json:"messages"`
+		}
+		raw := new(bytes.Buffer)
+		_, _ = raw.ReadFrom(resp.Body)
+		var messages []models.Message
+		if err := json.Unmarshal(raw.Bytes(), &messages); err != nil {
+			require.NoError(t, json.Unmarshal(raw.Bytes(), &page))
+			messages = page.Messages
+		}
+		contents := make([]string, 0, len(messages))
+		for _, m := range messages {
+			contents = append(contents, m.Content)
+		}
+		return contents
+	}
+
+	send(member, "hello")
+	send(shadow, "spam")
+
+	assert.ElementsMatch(t, []string{"hello", "spam"}, history(shadow, ""))
+	assert.Equal(t, []string{"hello"}, history(member, ""))
+	assert.Equal(t, []string{"hello"}, history(member, "?before=0"))
+
+	var spam models.Message
+	require.NoError(t, db.Where("sender_id = ?", shadow.ID).First(&spam).Error)
+	fetch := func(user models.User) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/conversations/%d/messages/%d", room.ID, spam.ID), nil)
+		req.Header.Set("X-User-ID", fmt.Sprint(user.ID))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusNotFound, fetch(member), "a deep link does not reveal the hidden message")
+	assert.Equal(t, http.StatusOK, fetch(shadow))
+
+	var unread int
+	require.NoError(t, db.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", room.ID, member.ID).
+		Pluck("unread_count", &unread).Error)
+	assert.Zero(t, unread, "hidden messages are not unread for anyone else")
+	require.NoError(t, db.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", room.ID, shadow.ID).
+		Pluck("unread_count", &unread).Error)
+	assert.Equal(t, 1, unread)
+}
+
+func TestSetUserShadowBan(t *testing.T) {
+	db := setupModerationTestDB(t)
+	admin := models.User{Username: "admin", Email: "admin@e.com", IsAdmin: true}
+	target := models.User{Username: "target", Email: "target@e.com"}
+	require.NoError(t, db.Create(&admin).Error)
+	require.NoError(t, db.Create(&target).Error)
+
+	s := &Server{db: db}
+	app := fiber.New()
+	app.Post("/admin/users/:id/shadow-ban", func(c *fiber.Ctx) error {
+		c.Locals("userID", admin.ID)
+		return s.SetUserShadowBan(c)
+	})
+	toggle := func(id uint, body string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/admin/users/%d/shadow-ban", id), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, toggle(target.ID, `{"shadow_banned":true}`))
+	var updated models.User
+	require.NoError(t, db.First(&updated, target.ID).Error)
+	assert.True(t, updated.ShadowBanned)
+
+	var entry models.AdminAuditLog
+	require.NoError(t, db.Where("action = ?", models.AuditActionUserShadowBan).First(&entry).Error)
+	assert.Equal(t, target.ID, entry.TargetID)
+	assert.JSONEq(t, `{"shadow_banned":true}`, string(entry.After))
+
+	require.Equal(t, http.StatusOK, toggle(target.ID, `{"shadow_banned":false}`))
+	require.NoError(t, db.First(&updated, target.ID).Error)
+	assert.False(t, updated.ShadowBanned)
+
+	assert.Equal(t, http.StatusBadRequest, toggle(target.ID, `{}`))
+	assert.Equal(t, http.StatusBadRequest, toggle(admin.ID, `{"shadow_banned":true}`))
+	assert.Equal(t, http.StatusNotFound, toggle(9999, `{"shadow_banned":true}`))
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"sanctum/internal/cache"
 	"sanctum/internal/models"
 	"sanctum/internal/service"
 
@@ -29,6 +30,22 @@ func (s *Server) SearchUsers(c *fiber.Ctx) error {
 	return c.JSON(users)
 }
 
+// SuggestMentions handles GET /api/mentions/suggest?q=...
+// Returns users whose username starts with q for @-autocomplete, friends and
+// recent chat partners first, never anyone on either side of a block.
+func (s *Server) SuggestMentions(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	page := parsePagination(c, 10)
+
+	suggestions, err := s.userSvc().SuggestMentions(ctx, userID, c.Query("q"), page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(suggestions)
+}
+
 // GetAllUsers handles GET /api/users
 func (s *Server) GetAllUsers(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
@@ -83,9 +100,10 @@ func (s *Server) UpdateMyProfile(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(uint)
 
 	var req struct {
-		Username string `json:"username"`
-		Bio      string `json:"bio"`
-		Avatar   string `json:"avatar"`
+		Username            string `json:"username"`
+		Bio                 string `json:"bio"`
+		Avatar              string `json:"avatar"`
+		NotifyReportUpdates *bool  `json:"notify_report_updates"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
@@ -93,10 +111,11 @@ func (s *Server) UpdateMyProfile(c *fiber.Ctx) error {
 	}
 
 	user, err := s.userSvc().UpdateProfile(ctx, service.UpdateProfileInput{
-		UserID:   userID,
-		Username: req.Username,
-		Bio:      req.Bio,
-		Avatar:   req.Avatar,
+		UserID:              userID,
+		Username:            req.Username,
+		Bio:                 req.Bio,
+		Avatar:              req.Avatar,
+		NotifyReportUpdates: req.NotifyReportUpdates,
 	})
 	if err != nil {
 		return models.RespondWithError(c, mapServiceError(err), err)
@@ -105,6 +124,97 @@ func (s *Server) UpdateMyProfile(c *fiber.Ctx) error {
 	return c.JSON(user)
 }
 
+// ChangeMyPassword handles POST /api/users/me/password. Every session is
+// revoked afterwards, so all devices, this one included, must log in again.
+func (s *Server) ChangeMyPassword(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userID := c.Locals("userID").(uint)
+
+	var req struct {
+		CurrentPassword string `json:"current_password"` // #nosec G117 -- API request body field
+		NewPassword     string `json:"new_password"`     // #nosec G117 -- API request body field
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	if err := s.userSvc().ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword); err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	if _, err := s.revokeAllSessions(ctx, userID); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, models.NewInternalError(err))
+	}
+	s.blacklistAccessToken(c)
+	c.ClearCookie("refresh_token")
+
+	return c.JSON(fiber.Map{"message": "Password changed, please log in again"})
+}
+
+// ChangeMyEmail handles POST /api/users/me/email
+func (s *Server) ChangeMyEmail(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userID := c.Locals("userID").(uint)
+
+	var req struct {
+		Password string `json:"password"` // #nosec G117 -- API request body field
+		Email    string `json:"email"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	user, err := s.userSvc().ChangeEmail(ctx, userID, req.Password, req.Email)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(user)
+}
+
+// DeleteMyAccount handles DELETE /api/users/me. The account is deactivated
+// and anonymized, every session is revoked and the user is taken offline.
+// Their posts drop out of public listings but stay in place, so replies and
+// conversations keep pointing at a valid row.
+func (s *Server) DeleteMyAccount(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userID := c.Locals("userID").(uint)
+
+	var req struct {
+		Password string `json:"password"` // #nosec G117 -- API request body field
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	if err := s.userSvc().DeactivateAccount(ctx, userID, req.Password, s.reactivationGrace()); err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	if _, err := s.revokeAllSessions(ctx, userID); err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, models.NewInternalError(err))
+	}
+	s.blacklistAccessToken(c)
+	c.ClearCookie("refresh_token")
+	if s.chatHub != nil {
+		s.chatHub.DisconnectUser(userID)
+	}
+	cache.InvalidatePostsList(ctx)
+
+	return c.JSON(fiber.Map{"message": "Account deleted"})
+}
+
+// reactivationGrace is how long a deleted account can be restored by logging in.
+func (s *Server) reactivationGrace() time.Duration {
+	if s.config == nil {
+		return 0
+	}
+	return time.Duration(s.config.AccountReactivationGraceDays) * 24 * time.Hour
+}
+
 // PromoteToAdmin handles POST /api/users/:id/promote-admin (admin only)
 // Admin check is enforced by AdminRequired middleware on the route.
 func (s *Server) PromoteToAdmin(c *fiber.Ctx) error {
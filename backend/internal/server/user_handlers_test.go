@@ -1,15 +1,26 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"sanctum/internal/config"
 	"sanctum/internal/models"
+	"sanctum/internal/repository"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestGetUserProfile(t *testing.T) {
@@ -79,3 +90,169 @@ func TestGetMyProfile(t *testing.T) {
 	defer func() { _ = resp.Body.Close() }()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
+
+func TestChangeMyPassword(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("OldPassword123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{ID: 1, Email: "me@example.com", Username: "me", Password: string(hashed)}
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	s := &Server{
+		config:   &config.Config{JWTSecret: "test_secret"},
+		userRepo: mockRepo,
+		redis:    rdb,
+	}
+	app := fiber.New()
+	app.Post("/auth/login", s.Login)
+	app.Post("/auth/refresh", s.Refresh)
+	app.Get("/users/me", s.AuthRequired(), s.GetMyProfile)
+	app.Post("/users/me/password", s.AuthRequired(), s.ChangeMyPassword)
+
+	do := func(method, path, token string, body any) int {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	login := func(password string) (string, string) {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login",
+			bytes.NewBufferString(`{"email":"me@example.com","password":"`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result.Token, result.RefreshToken
+	}
+
+	access, _ := login("OldPassword123!")
+	otherAccess, otherRefresh := login("OldPassword123!")
+
+	t.Run("wrong current password is rejected", func(t *testing.T) {
+		status := do(http.MethodPost, "/users/me/password", access, map[string]string{
+			"current_password": "NotMyPassword1!",
+			"new_password":     "NewPassword456!",
+		})
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("OldPassword123!")))
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users/me", access, nil))
+	})
+
+	t.Run("success revokes every session", func(t *testing.T) {
+		status := do(http.MethodPost, "/users/me/password", access, map[string]string{
+			"current_password": "OldPassword123!",
+			"new_password":     "NewPassword456!",
+		})
+		require.Equal(t, http.StatusOK, status)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("NewPassword456!")))
+
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/users/me", access, nil))
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/users/me", otherAccess, nil))
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": otherRefresh}))
+
+		fresh, _ := login("NewPassword456!")
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users/me", fresh, nil))
+	})
+}
+
+func TestSuggestMentions(t *testing.T) {
+	t.Parallel()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{}, &models.Friendship{}, &models.UserBlock{},
+		&models.Conversation{}, &models.ConversationParticipant{}, &models.Message{},
+	))
+	s := &Server{userRepo: repository.NewUserRepository(db)}
+
+	newUser := func(name string) models.User {
+		u := models.User{Username: name, Email: name + "@example.com", Password: "pw"}
+		require.NoError(t, db.Create(&u).Error)
+		return u
+	}
+	me := newUser("me")
+	exact := newUser("alex")
+	longer := newUser("alexander")
+	friend := newUser("alexa_friend")
+	chatter := newUser("alexchat")
+	blocker := newUser("alexblocker")
+	blocked := newUser("alexblocked")
+	gone := newUser("alex_gone")
+	newUser("bob")
+
+	now := time.Now().UTC()
+	require.NoError(t, db.Model(&gone).Update("deactivated_at", now).Error)
+	require.NoError(t, db.Create(&models.Friendship{
+		RequesterID: friend.ID, AddresseeID: me.ID, Status: models.FriendshipStatusAccepted,
+	}).Error)
+	require.NoError(t, db.Create(&models.UserBlock{BlockerID: blocker.ID, BlockedID: me.ID}).Error)
+	require.NoError(t, db.Create(&models.UserBlock{BlockerID: me.ID, BlockedID: blocked.ID}).Error)
+	room := models.Conversation{Name: "lobby", IsGroup: true, CreatedBy: me.ID}
+	require.NoError(t, db.Create(&room).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: me.ID}).Error)
+	require.NoError(t, db.Create(&models.Message{
+		ConversationID: room.ID, SenderID: chatter.ID, Content: "hi", MessageType: "text",
+	}).Error)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", me.ID)
+		return c.Next()
+	})
+	app.Get("/mentions/suggest", s.SuggestMentions)
+
+	suggest := func(query string) (int, []uint) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/mentions/suggest?"+query, nil))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var got []models.MentionSuggestion
+		_ = json.NewDecoder(resp.Body).Decode(&got)
+		ids := make([]uint, 0, len(got))
+		for _, suggestion := range got {
+			ids = append(ids, suggestion.User.ID)
+		}
+		return resp.StatusCode, ids
+	}
+
+	status, ids := suggest("q=alex")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []uint{exact.ID, friend.ID, chatter.ID, longer.ID}, ids,
+		"exact match, then friend, then recent chat partner; blocks and deactivated accounts excluded")
+
+	_, ids = suggest("q=%40ALEX&limit=2&offset=2")
+	assert.Equal(t, []uint{chatter.ID, longer.ID}, ids, "leading @ and case are ignored; results page")
+
+	_, ids = suggest("q=alex_")
+	assert.Empty(t, ids, "underscore is matched literally")
+
+	status, _ = suggest("q=al%25")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	for _, empty := range []string{"", "q=", "q=%40"} {
+		status, _ = suggest(empty)
+		assert.Equal(t, http.StatusBadRequest, status, "an empty prefix would list everyone")
+	}
+}
@@ -0,0 +1,89 @@
+// Package server contains HTTP and WebSocket handlers for the application's API endpoints.
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetConversationWebhook handles GET /api/conversations/:id/webhook
+func (s *Server) GetConversationWebhook(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	hook, err := s.webhookService.GetWebhook(ctx, convID, userID)
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.JSON(hook)
+}
+
+// UpsertConversationWebhook handles PUT /api/conversations/:id/webhook
+// The signing secret is only included in the response when the webhook is created.
+func (s *Server) UpsertConversationWebhook(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		URL            string `json:"url"`
+		IncludeContent bool   `json:"include_content"`
+		Enabled        *bool  `json:"enabled,omitempty"`
+	}
+	if parseErr := c.BodyParser(&req); parseErr != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	hook, secret, err := s.webhookService.UpsertWebhook(ctx, service.UpsertWebhookInput{
+		ConversationID: convID,
+		UserID:         userID,
+		URL:            req.URL,
+		IncludeContent: req.IncludeContent,
+		Enabled:        enabled,
+	})
+	if err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	if secret == "" {
+		return c.JSON(hook)
+	}
+	return c.Status(fiber.StatusCreated).JSON(struct {
+		*models.ConversationWebhook
+		Secret string `json:"secret"`
+	}{
+		ConversationWebhook: hook,
+		Secret:              secret,
+	})
+}
+
+// DeleteConversationWebhook handles DELETE /api/conversations/:id/webhook
+func (s *Server) DeleteConversationWebhook(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	if err := s.webhookService.DeleteWebhook(ctx, convID, userID); err != nil {
+		return models.RespondWithError(c, mapServiceError(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"sanctum/internal/middleware"
 	"sanctum/internal/notifications"
+	"sanctum/internal/observability"
 	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -21,6 +23,7 @@ func (s *Server) WebSocketChatHandler() fiber.Handler {
 	return websocket.New(func(conn *websocket.Conn) {
 		middleware.ActiveWebSockets.Inc()
 		defer middleware.ActiveWebSockets.Dec()
+		defer observability.TrackWebSocketConnection("chat")()
 
 		// Use the server shutdown context as parent so all DB operations
 		// are bounded by the server lifecycle and have timeouts.
@@ -86,142 +89,38 @@ func (s *Server) WebSocketChatHandler() fiber.Handler {
 			// Handle different message types
 			switch msgType {
 			case "join":
-				// Join a conversation
 				if convIDFloat, ok := incomingMsg["conversation_id"].(float64); ok {
-					convID := uint(convIDFloat)
-					if s.chatHub != nil {
-						// Verify user is participant before joining
-						if s.isUserParticipant(ctx, userID, convID) {
-							s.chatHub.JoinConversation(userID, convID)
-
-							// Send confirmation to user
-							response := notifications.ChatMessage{
-								Type:           "joined",
-								ConversationID: convID,
-								Payload:        map[string]interface{}{"conversation_id": convID},
-							}
-							responseJSON, _ := json.Marshal(response)
-							c.TrySend(responseJSON)
-						}
-					}
+					s.handleChatJoinFrame(ctx, c, userID, uint(convIDFloat))
 				}
 
 			case "leave":
-				// Leave a conversation
 				if convIDFloat, ok := incomingMsg["conversation_id"].(float64); ok {
-					convID := uint(convIDFloat)
-					if s.chatHub != nil {
-						s.chatHub.LeaveConversation(userID, convID)
-					}
+					s.handleChatLeaveFrame(c, userID, uint(convIDFloat))
+				}
+
+			case "delivered":
+				convIDFloat, convOK := incomingMsg["conversation_id"].(float64)
+				msgIDFloat, msgOK := incomingMsg["message_id"].(float64)
+				if convOK && msgOK {
+					s.handleChatDeliveredFrame(ctx, c, userID, uint(convIDFloat), uint(msgIDFloat))
 				}
 
 			case "typing":
-				// Typing indicator - limit to 10 per 10 seconds to prevent spam
 				if convIDFloat, ok := incomingMsg["conversation_id"].(float64); ok {
-					convID := uint(convIDFloat)
 					isTyping, _ := incomingMsg["is_typing"].(bool)
+					s.handleChatTypingFrame(ctx, userID, username, uint(convIDFloat), isTyping)
+				}
 
-					if s.notifier != nil && s.isUserParticipant(ctx, userID, convID) {
-						// Rate limit typing indicators
-						id := fmt.Sprintf("user:%d", userID)
-						allowed, err := middleware.CheckRateLimit(ctx, s.redis, s.config.Env, "typing", id, 10, 10*time.Second)
-						if err != nil {
-							log.Printf("rate limit check error: %v", err)
-						}
-						if !allowed {
-							return // Silently drop spammy typing indicators
-						}
-
-						if perr := s.notifier.PublishTypingIndicator(ctx, convID, userID, username, isTyping); perr != nil {
-							log.Printf("publish typing indicator error: %v", perr)
-						}
-					}
+			case "typing_stopped":
+				if convIDFloat, ok := incomingMsg["conversation_id"].(float64); ok {
+					s.handleChatTypingFrame(ctx, userID, username, uint(convIDFloat), false)
 				}
 
 			case "message":
 				// Send a message (alternative to HTTP endpoint)
 				if convIDFloat, ok := incomingMsg["conversation_id"].(float64); ok {
-					convID := uint(convIDFloat)
 					content, _ := incomingMsg["content"].(string)
-
-					if content != "" && s.isUserParticipant(ctx, userID, convID) {
-						// Rate limit messages - same as HTTP (15 per minute)
-						id := fmt.Sprintf("user:%d", userID)
-						allowed, err := middleware.CheckRateLimit(ctx, s.redis, s.config.Env, "send_chat", id, 15, time.Minute)
-						if err != nil {
-							log.Printf("rate limit check error: %v", err)
-						}
-						if !allowed {
-							response := notifications.ChatMessage{
-								Type: "error",
-								Payload: map[string]string{
-									"message": "Rate limit exceeded. Please wait a moment.",
-								},
-							}
-							if respJSON, marshalErr := json.Marshal(response); marshalErr == nil {
-								c.TrySend(respJSON)
-							}
-							return
-						}
-
-						message, conv, err := s.chatSvc().SendMessage(ctx, service.SendMessageInput{
-							UserID:         userID,
-							ConversationID: convID,
-							Content:        content,
-							MessageType:    "text",
-						})
-						if err != nil {
-							response := notifications.ChatMessage{
-								Type: "error",
-								Payload: map[string]string{
-									"message": err.Error(),
-								},
-							}
-							if respJSON, marshalErr := json.Marshal(response); marshalErr == nil {
-								c.TrySend(respJSON)
-							}
-							return
-						}
-						s.persistMessageMentions(ctx, convID, message, userID, conv.Participants)
-
-						// Broadcast via Redis
-						if s.notifier != nil {
-							messageJSON, err := json.Marshal(notifications.ChatMessage{
-								Type:           "message",
-								ConversationID: convID,
-								UserID:         userID,
-								Username:       username,
-								Payload:        message,
-							})
-							if err != nil {
-								log.Printf("marshal chat message error: %v", err)
-								return
-							}
-							if perr := s.notifier.PublishChatMessage(ctx, convID, string(messageJSON)); perr != nil {
-								log.Printf("publish chat message error: %v", perr)
-							}
-						}
-
-						// NOTE: Direct BroadcastToConversation is intentionally NOT called here.
-						// The Redis pub/sub path (PublishChatMessage above) already delivers
-						// the message to conversation viewers via ChatHub.StartWiring.
-
-						if !conv.IsGroup {
-							for _, participant := range conv.Participants {
-								if participant.ID == userID {
-									continue
-								}
-								s.publishUserEvent(participant.ID, EventMessageReceived, map[string]interface{}{
-									"conversation_id": conv.ID,
-									"message_id":      message.ID,
-									"is_group":        conv.IsGroup,
-									"from_user":       userSummaryPtr(message.Sender),
-									"preview":         message.Content,
-									"created_at":      time.Now().UTC().Format(time.RFC3339Nano),
-								})
-							}
-						}
-					}
+					s.handleChatMessageFrame(ctx, c, userID, username, uint(convIDFloat), content)
 				}
 
 			case "read":
@@ -234,7 +133,7 @@ func (s *Server) WebSocketChatHandler() fiber.Handler {
 						}
 
 						// Broadcast read receipt
-						if s.notifier != nil {
+						if s.notifier != nil && s.sharesReadReceipts(ctx, convID, userID) {
 							readMsg := notifications.ChatMessage{
 								Type:           "message_read",
 								ConversationID: convID,
@@ -284,6 +183,228 @@ func (s *Server) WebSocketChatHandler() fiber.Handler {
 	})
 }
 
+// handleChatJoinFrame subscribes the client's user to a conversation's realtime
+// traffic after confirming they are a participant, replying with a "joined" ack
+// or an "error" frame when the join is rejected.
+func (s *Server) handleChatJoinFrame(ctx context.Context, c *notifications.Client, userID, convID uint) {
+	if s.chatHub == nil {
+		return
+	}
+
+	if _, err := s.chatSvc().GetConversationForUser(ctx, convID, userID); err != nil {
+		sendChatFrame(c, notifications.ChatMessage{
+			Type:           "error",
+			ConversationID: convID,
+			Payload: map[string]interface{}{
+				"conversation_id": convID,
+				"message":         "Cannot join conversation",
+			},
+		})
+		return
+	}
+
+	s.chatHub.JoinConversation(userID, convID)
+	sendChatFrame(c, notifications.ChatMessage{
+		Type:           "joined",
+		ConversationID: convID,
+		Payload:        map[string]interface{}{"conversation_id": convID},
+	})
+}
+
+// handleChatLeaveFrame unsubscribes the client's user from a conversation's
+// realtime traffic and replies with a "left" ack. Membership is not changed.
+func (s *Server) handleChatLeaveFrame(c *notifications.Client, userID, convID uint) {
+	if s.chatHub == nil {
+		return
+	}
+
+	s.chatHub.LeaveConversation(userID, convID)
+	sendChatFrame(c, notifications.ChatMessage{
+		Type:           "left",
+		ConversationID: convID,
+		Payload:        map[string]interface{}{"conversation_id": convID},
+	})
+}
+
+// handleChatMessageFrame sends a chat message over the WebSocket, applying the
+// same rate limit and slow mode as the HTTP endpoint.
+func (s *Server) handleChatMessageFrame(ctx context.Context, c *notifications.Client, userID uint, username string, convID uint, content string) {
+	if content == "" || !s.isUserParticipant(ctx, userID, convID) {
+		return
+	}
+	sendError := func(message string) {
+		sendChatFrame(c, notifications.ChatMessage{
+			Type:    "error",
+			Payload: map[string]string{"message": message},
+		})
+	}
+
+	// Rate limit messages - same as HTTP (15 per minute)
+	id := fmt.Sprintf("user:%d", userID)
+	allowed, err := middleware.CheckRateLimit(ctx, s.redis, s.config.Env, "send_chat", id, 15, time.Minute)
+	if err != nil {
+		log.Printf("rate limit check error: %v", err)
+	}
+	if !allowed {
+		sendError("Rate limit exceeded. Please wait a moment.")
+		return
+	}
+
+	wait, releaseSlowMode, err := s.claimSlowModeSlot(ctx, convID, userID)
+	if err != nil {
+		log.Printf("slow mode check error: %v", err)
+		sendError("Failed to send message")
+		return
+	}
+	if wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		sendChatFrame(c, notifications.ChatMessage{
+			Type:           "error",
+			ConversationID: convID,
+			Payload: map[string]interface{}{
+				"message":     slowModeMessage(seconds),
+				"code":        "SLOW_MODE",
+				"retry_after": seconds,
+			},
+		})
+		return
+	}
+
+	message, conv, err := s.chatSvc().SendMessage(ctx, service.SendMessageInput{
+		UserID:         userID,
+		ConversationID: convID,
+		Content:        content,
+		MessageType:    "text",
+	})
+	if err != nil {
+		releaseSlowMode()
+		sendError(err.Error())
+		return
+	}
+	if s.echoShadowBannedMessage(ctx, conv, message, username) {
+		return
+	}
+	s.persistMessageMentions(ctx, convID, message, userID, conv.Participants)
+	s.webhookService.DispatchMessage(ctx, conv, message)
+	s.pushToOfflineRecipients(conv, message)
+
+	// Broadcast via Redis
+	if s.notifier != nil {
+		messageJSON, err := json.Marshal(notifications.ChatMessage{
+			Type:           "message",
+			ConversationID: convID,
+			UserID:         userID,
+			Username:       username,
+			Payload:        message,
+		})
+		if err != nil {
+			log.Printf("marshal chat message error: %v", err)
+			return
+		}
+		if perr := s.notifier.PublishChatMessage(ctx, convID, string(messageJSON)); perr != nil {
+			log.Printf("publish chat message error: %v", perr)
+		}
+	}
+
+	// NOTE: Direct BroadcastToConversation is intentionally NOT called here.
+	// The Redis pub/sub path (PublishChatMessage above) already delivers
+	// the message to conversation viewers via ChatHub.StartWiring.
+
+	s.notifyMessageRecipients(ctx, conv, message)
+}
+
+// handleChatTypingFrame relays a typing indicator to the conversation. While
+// the user keeps typing the indicator is refreshed; if the refreshes stop for
+// longer than the tracker's TTL a "typing_stopped" event is broadcast for them.
+func (s *Server) handleChatTypingFrame(ctx context.Context, userID uint, username string, convID uint, isTyping bool) {
+	if !s.isUserParticipant(ctx, userID, convID) {
+		return
+	}
+
+	// Typing indicator - limit to 10 per 10 seconds to prevent spam
+	id := fmt.Sprintf("user:%d", userID)
+	allowed, err := middleware.CheckRateLimit(ctx, s.redis, s.config.Env, "typing", id, 10, 10*time.Second)
+	if err != nil {
+		log.Printf("rate limit check error: %v", err)
+	}
+	if !allowed {
+		return // Silently drop spammy typing indicators
+	}
+
+	if !isTyping {
+		if s.typingTracker != nil {
+			s.typingTracker.Stop(convID, userID)
+		}
+		s.publishTypingEvent(ctx, notifications.TypingStoppedEvent(convID, userID, username, notifications.TypingStoppedExplicit))
+		return
+	}
+
+	if s.typingTracker != nil {
+		s.typingTracker.Touch(convID, userID, username)
+	}
+	s.publishTypingEvent(ctx, notifications.TypingEvent(convID, userID, username, true))
+}
+
+// handleTypingExpired is the typing tracker's expiry callback.
+func (s *Server) handleTypingExpired(convID, userID uint, username string) {
+	s.publishTypingEvent(context.Background(), notifications.TypingStoppedEvent(convID, userID, username, notifications.TypingStoppedExpired))
+}
+
+// publishTypingEvent fans a typing frame out through Redis when available and
+// straight to this instance's chat hub otherwise.
+func (s *Server) publishTypingEvent(ctx context.Context, event notifications.ChatMessage) {
+	if s.notifier != nil {
+		if err := s.notifier.PublishTypingEvent(ctx, event); err != nil {
+			log.Printf("publish typing indicator error: %v", err)
+		}
+		return
+	}
+	if s.chatHub != nil {
+		s.chatHub.BroadcastToConversation(event.ConversationID, event)
+	}
+}
+
+// handleChatDeliveredFrame records a recipient's delivery ack for a direct
+// message and lets the conversation know via a "message_delivered" event.
+func (s *Server) handleChatDeliveredFrame(ctx context.Context, c *notifications.Client, userID, convID, msgID uint) {
+	message, err := s.chatSvc().MarkMessageDelivered(ctx, convID, msgID, userID)
+	if err != nil {
+		sendChatFrame(c, notifications.ChatMessage{
+			Type:           "error",
+			ConversationID: convID,
+			Payload: map[string]interface{}{
+				"conversation_id": convID,
+				"message_id":      msgID,
+				"message":         "Cannot acknowledge message",
+			},
+		})
+		return
+	}
+
+	if s.chatHub != nil {
+		s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
+			Type:           "message_delivered",
+			ConversationID: convID,
+			UserID:         userID,
+			Payload: map[string]interface{}{
+				"conversation_id": convID,
+				"message_id":      message.ID,
+				"user_id":         userID,
+				"delivered_at":    message.DeliveredAt.UTC().Format(time.RFC3339Nano),
+			},
+		})
+	}
+}
+
+func sendChatFrame(c *notifications.Client, msg notifications.ChatMessage) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("marshal chat frame error: %v", err)
+		return
+	}
+	c.TrySend(payload)
+}
+
 // isUserParticipant checks if a user is a participant in a conversation
 func (s *Server) isUserParticipant(ctx context.Context, userID, conversationID uint) bool {
 	ok, err := s.chatRepo.IsUserParticipant(ctx, conversationID, userID)
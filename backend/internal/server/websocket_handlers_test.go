@@ -2,10 +2,24 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/service"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsUserParticipant(t *testing.T) {
@@ -47,3 +61,224 @@ func TestIsUserParticipant(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleChatJoinFrame(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	hub := notifications.NewChatHub()
+	defer func() { _ = hub.Shutdown(context.Background()) }()
+
+	s := &Server{
+		chatRepo:    mockChatRepo,
+		chatHub:     hub,
+		chatService: service.NewChatService(mockChatRepo, nil, nil, nil, nil),
+	}
+
+	mockChatRepo.On("GetConversation", mock.Anything, uint(10)).Return(&models.Conversation{
+		ID:           10,
+		Participants: []models.User{{ID: 1}},
+	}, nil)
+
+	t.Run("Participant joins and receives ack", func(t *testing.T) {
+		client := &notifications.Client{Hub: hub, UserID: 1, Send: make(chan []byte, 10)}
+		hub.RegisterUser(client)
+		defer hub.UnregisterUser(client)
+
+		s.handleChatJoinFrame(context.Background(), client, 1, 10)
+
+		assert.True(t, hub.IsUserActive(1, 10))
+		ack := readChatFrame(t, client.Send, "joined")
+		assert.Equal(t, uint(10), ack.ConversationID)
+	})
+
+	t.Run("Non-participant is rejected", func(t *testing.T) {
+		client := &notifications.Client{Hub: hub, UserID: 2, Send: make(chan []byte, 10)}
+		hub.RegisterUser(client)
+		defer hub.UnregisterUser(client)
+
+		s.handleChatJoinFrame(context.Background(), client, 2, 10)
+
+		assert.False(t, hub.IsUserActive(2, 10))
+		frame := readChatFrame(t, client.Send, "error")
+		assert.Equal(t, uint(10), frame.ConversationID)
+	})
+}
+
+func TestHandleChatLeaveFrame(t *testing.T) {
+	hub := notifications.NewChatHub()
+	defer func() { _ = hub.Shutdown(context.Background()) }()
+	s := &Server{chatHub: hub}
+
+	client := &notifications.Client{Hub: hub, UserID: 1, Send: make(chan []byte, 10)}
+	hub.RegisterUser(client)
+	defer hub.UnregisterUser(client)
+	hub.JoinConversation(1, 10)
+
+	s.handleChatLeaveFrame(client, 1, 10)
+
+	assert.False(t, hub.IsUserActive(1, 10))
+	ack := readChatFrame(t, client.Send, "left")
+	assert.Equal(t, uint(10), ack.ConversationID)
+}
+
+// readChatFrame drains buffered frames until one of the wanted type appears.
+func readChatFrame(t *testing.T, ch <-chan []byte, wantType string) notifications.ChatMessage {
+	t.Helper()
+	for {
+		select {
+		case raw := <-ch:
+			var msg notifications.ChatMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				continue
+			}
+			if msg.Type == wantType {
+				return msg
+			}
+		default:
+			t.Fatalf("no %q frame sent", wantType)
+			return notifications.ChatMessage{}
+		}
+	}
+}
+
+func TestHandleChatDeliveredFrame_ThenRead(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+	hub := notifications.NewChatHub()
+	defer func() { _ = hub.Shutdown(context.Background()) }()
+	s.chatHub = hub
+
+	sender := models.User{Username: "sender", Email: "sender@example.com", Password: "pw"}
+	recipient := models.User{Username: "recipient", Email: "recipient@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&sender).Error)
+	require.NoError(t, db.Create(&recipient).Error)
+
+	dm := models.Conversation{CreatedBy: sender.ID}
+	require.NoError(t, db.Create(&dm).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: dm.ID, UserID: sender.ID}).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: dm.ID, UserID: recipient.ID}).Error)
+
+	acked := models.Message{ConversationID: dm.ID, SenderID: sender.ID, Content: "first"}
+	unacked := models.Message{ConversationID: dm.ID, SenderID: sender.ID, Content: "second"}
+	require.NoError(t, db.Create(&acked).Error)
+	require.NoError(t, db.Create(&unacked).Error)
+
+	senderClient := &notifications.Client{Hub: hub, UserID: sender.ID, Send: make(chan []byte, 10)}
+	recipientClient := &notifications.Client{Hub: hub, UserID: recipient.ID, Send: make(chan []byte, 10)}
+	hub.RegisterUser(senderClient)
+	hub.RegisterUser(recipientClient)
+	defer hub.UnregisterUser(senderClient)
+	defer hub.UnregisterUser(recipientClient)
+	hub.JoinConversation(sender.ID, dm.ID)
+
+	// The sender cannot ack their own message.
+	s.handleChatDeliveredFrame(context.Background(), senderClient, sender.ID, dm.ID, acked.ID)
+	readChatFrame(t, senderClient.Send, "error")
+
+	s.handleChatDeliveredFrame(context.Background(), recipientClient, recipient.ID, dm.ID, acked.ID)
+	event := readChatFrame(t, senderClient.Send, "message_delivered")
+	assert.Equal(t, dm.ID, event.ConversationID)
+
+	var delivered models.Message
+	require.NoError(t, db.First(&delivered, acked.ID).Error)
+	require.NotNil(t, delivered.DeliveredAt)
+	assert.Nil(t, delivered.ReadAt)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", recipient.ID)
+		return c.Next()
+	})
+	app.Post("/conversations/:id/read", s.MarkConversationRead)
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, fmt.Sprintf("/conversations/%d/read", dm.ID), nil))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var read models.Message
+	require.NoError(t, db.First(&read, acked.ID).Error)
+	require.NotNil(t, read.ReadAt)
+	require.NotNil(t, read.DeliveredAt)
+	assert.True(t, read.DeliveredAt.Equal(*delivered.DeliveredAt), "read must not move delivered_at")
+	assert.False(t, read.ReadAt.Before(*read.DeliveredAt))
+
+	// Reading a message that was never acked also marks it delivered.
+	var readUnacked models.Message
+	require.NoError(t, db.First(&readUnacked, unacked.ID).Error)
+	assert.NotNil(t, readUnacked.DeliveredAt)
+}
+
+func TestHandleChatTypingFrame_AutoExpires(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	hub := notifications.NewChatHub()
+	defer func() { _ = hub.Shutdown(context.Background()) }()
+
+	s := &Server{chatRepo: mockChatRepo, chatHub: hub, config: &config.Config{Env: "test"}}
+	s.typingTracker = notifications.NewTypingTracker(50*time.Millisecond, s.handleTypingExpired)
+	mockChatRepo.On("IsUserParticipant", mock.Anything, uint(10), uint(1)).Return(true, nil)
+
+	watcher := &notifications.Client{Hub: hub, UserID: 2, Send: make(chan []byte, 10)}
+	hub.RegisterUser(watcher)
+	defer hub.UnregisterUser(watcher)
+	hub.JoinConversation(2, 10)
+
+	s.handleChatTypingFrame(context.Background(), 1, "alice", 10, true)
+	started := readChatFrame(t, watcher.Send, "typing")
+	assert.Equal(t, uint(1), started.UserID)
+
+	// No refresh and no explicit stop: the server clears the indicator itself.
+	select {
+	case raw := <-watcher.Send:
+		var stopped notifications.ChatMessage
+		require.NoError(t, json.Unmarshal(raw, &stopped))
+		assert.Equal(t, "typing_stopped", stopped.Type)
+		assert.Equal(t, uint(10), stopped.ConversationID)
+		assert.Equal(t, uint(1), stopped.UserID)
+		payload, _ := stopped.Payload.(map[string]interface{})
+		assert.Equal(t, notifications.TypingStoppedExpired, payload["reason"])
+	case <-time.After(time.Second):
+		t.Fatal("typing indicator was not cleared after the expiry window")
+	}
+	assert.False(t, s.typingTracker.IsTyping(10, 1))
+}
+
+func TestHandleChatMessageFrame_EnforcesSlowMode(t *testing.T) {
+	db := setupChatHandlerTestDB(t)
+	s := newChatHandlerTestServer(db)
+	s.config = &config.Config{Env: "test"}
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	s.redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	owner := models.User{Username: "owner", Email: "owner@example.com", Password: "pw"}
+	member := models.User{Username: "member", Email: "member@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&owner).Error)
+	require.NoError(t, db.Create(&member).Error)
+	room := models.Conversation{Name: "busy", IsGroup: true, CreatedBy: owner.ID, SlowModeSeconds: 30}
+	require.NoError(t, db.Create(&room).Error)
+	for _, u := range []models.User{owner, member} {
+		require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: u.ID}).Error)
+	}
+
+	client := &notifications.Client{UserID: member.ID, Send: make(chan []byte, 10)}
+	countMessages := func() int64 {
+		var count int64
+		require.NoError(t, db.Model(&models.Message{}).Where("conversation_id = ? AND sender_id = ?", room.ID, member.ID).Count(&count).Error)
+		return count
+	}
+
+	s.handleChatMessageFrame(context.Background(), client, member.ID, member.Username, room.ID, "first")
+	require.Equal(t, int64(1), countMessages())
+
+	mr.FastForward(10 * time.Second)
+	s.handleChatMessageFrame(context.Background(), client, member.ID, member.Username, room.ID, "too soon")
+	frame := readChatFrame(t, client.Send, "error")
+	payload, _ := frame.Payload.(map[string]interface{})
+	assert.Equal(t, "SLOW_MODE", payload["code"])
+	assert.EqualValues(t, 20, payload["retry_after"])
+	assert.Equal(t, int64(1), countMessages(), "the WebSocket path must not bypass slow mode")
+
+	mr.FastForward(20 * time.Second)
+	s.handleChatMessageFrame(context.Background(), client, member.ID, member.Username, room.ID, "later")
+	assert.Equal(t, int64(2), countMessages())
+}
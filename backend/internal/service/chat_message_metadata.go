@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"sanctum/internal/models"
+)
+
+// Chat message types with typed metadata.
+const (
+	MessageTypeText  = "text"
+	MessageTypeImage = "image"
+	MessageTypeLink  = "link"
+)
+
+const (
+	maxLinkURLLen         = 2048
+	maxLinkTitleLen       = 200
+	maxLinkDescriptionLen = 500
+)
+
+// ImageMessageMetadata is the metadata of an image message. Only ImageHash is
+// accepted from clients; the rest is filled in when messages are read.
+type ImageMessageMetadata struct {
+	ImageHash    string            `json:"image_hash"`
+	ImageURL     string            `json:"image_url,omitempty"`
+	ThumbnailURL string            `json:"thumbnail_url,omitempty"`
+	Width        int               `json:"width,omitempty"`
+	Height       int               `json:"height,omitempty"`
+	Blurhash     string            `json:"blurhash,omitempty"`
+	Variants     map[string]string `json:"variants,omitempty"`
+}
+
+// LinkMessageMetadata is the metadata of a link message.
+type LinkMessageMetadata struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SetImageService sets the service used to validate image messages and
+// render their URLs. Without it image messages are rejected.
+func (s *ChatService) SetImageService(images *ImageService) {
+	s.images = images
+}
+
+// normalizeMessageMetadata validates metadata for typed messages and returns
+// it in canonical form. Text messages keep whatever metadata the client sent.
+func (s *ChatService) normalizeMessageMetadata(ctx context.Context, messageType string, metadata json.RawMessage) (json.RawMessage, error) {
+	switch messageType {
+	case MessageTypeText:
+		return metadata, nil
+	case MessageTypeImage:
+		var meta ImageMessageMetadata
+		if err := json.Unmarshal(metadata, &meta); err != nil {
+			return nil, models.NewValidationError("Image messages require metadata with an image_hash")
+		}
+		hash := strings.TrimSpace(meta.ImageHash)
+		if !isValidImageHash(hash) {
+			return nil, models.NewValidationError("Image messages require a valid image_hash")
+		}
+		if s.images == nil {
+			return nil, models.NewValidationError("Image messages are not supported")
+		}
+		if _, err := s.images.GetByHashWithVariants(ctx, hash); err != nil {
+			var appErr *models.AppError
+			if errors.As(err, &appErr) && appErr.Code == "NOT_FOUND" {
+				return nil, models.NewValidationError("Image not found")
+			}
+			return nil, err
+		}
+		return json.Marshal(ImageMessageMetadata{ImageHash: hash})
+	case MessageTypeLink:
+		var meta LinkMessageMetadata
+		if err := json.Unmarshal(metadata, &meta); err != nil {
+			return nil, models.NewValidationError("Link messages require metadata with a url")
+		}
+		meta.URL = strings.TrimSpace(meta.URL)
+		meta.Title = strings.TrimSpace(meta.Title)
+		meta.Description = strings.TrimSpace(meta.Description)
+		if !isValidLinkURL(meta.URL) {
+			return nil, models.NewValidationError("Link messages require an absolute http or https url")
+		}
+		if utf8.RuneCountInString(meta.Title) > maxLinkTitleLen {
+			return nil, models.NewValidationError("Link title is too long")
+		}
+		if utf8.RuneCountInString(meta.Description) > maxLinkDescriptionLen {
+			return nil, models.NewValidationError("Link description is too long")
+		}
+		return json.Marshal(meta)
+	default:
+		return nil, models.NewValidationError("Unsupported message type")
+	}
+}
+
+func isValidLinkURL(raw string) bool {
+	if raw == "" || len(raw) > maxLinkURLLen {
+		return false
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// renderImageMetadata expands the stored image hash of each image message into
+// URLs and dimensions from one lookup over the page. Messages whose image has
+// since been removed keep just the hash.
+func (s *ChatService) renderImageMetadata(ctx context.Context, messages []*models.Message) error {
+	if s.images == nil || s.db == nil || len(messages) == 0 {
+		return nil
+	}
+
+	metas := make(map[*models.Message]*ImageMessageMetadata)
+	hashes := make([]string, 0)
+	for _, message := range messages {
+		if message.MessageType != MessageTypeImage {
+			continue
+		}
+		var meta ImageMessageMetadata
+		if err := json.Unmarshal(message.Metadata, &meta); err != nil || meta.ImageHash == "" {
+			continue
+		}
+		metas[message] = &meta
+		hashes = append(hashes, meta.ImageHash)
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	var images []models.Image
+	if err := s.db.WithContext(ctx).
+		Preload("Variants").
+		Where("hash IN ?", hashes).
+		Find(&images).Error; err != nil {
+		return err
+	}
+	byHash := make(map[string]*models.Image, len(images))
+	for i := range images {
+		byHash[images[i].Hash] = &images[i]
+	}
+
+	for message, meta := range metas {
+		img := byHash[meta.ImageHash]
+		if img == nil {
+			continue
+		}
+		meta.ImageURL = s.images.BuildImageURL(img.Hash, ImageSizeOriginal)
+		meta.ThumbnailURL = s.images.BuildImageURL(img.Hash, ImageSizeThumbnail)
+		meta.Width = img.Width
+		meta.Height = img.Height
+		meta.Blurhash = img.Blurhash
+		if len(img.Variants) > 0 {
+			meta.Variants = s.images.BuildVariantsMap(img.Hash, img.Variants)
+		}
+		rendered, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+		message.Metadata = rendered
+	}
+	return nil
+}
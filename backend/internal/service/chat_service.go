@@ -5,11 +5,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"sanctum/internal/cache"
 	"sanctum/internal/models"
+	"sanctum/internal/observability"
 	"sanctum/internal/repository"
+	"sanctum/internal/validation"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -22,6 +26,11 @@ type ChatService struct {
 	db                  *gorm.DB
 	isAdmin             func(ctx context.Context, userID uint) (bool, error)
 	canModerateChatroom func(ctx context.Context, userID, roomID uint) (bool, error)
+	minDMAccountAge     time.Duration
+	nameRules           validation.ConversationNameRules
+	profanity           *validation.ProfanityFilter
+	profanityMode       string
+	images              *ImageService
 }
 
 // CreateConversationInput is the input for creating a conversation.
@@ -58,6 +67,25 @@ func NewChatService(
 	}
 }
 
+// SetMinDMAccountAge sets how old an account must be before it can start a
+// new direct message. Zero disables the check.
+func (s *ChatService) SetMinDMAccountAge(age time.Duration) {
+	s.minDMAccountAge = age
+}
+
+// SetConversationNameRules sets the checks applied to group conversation
+// names on creation and rename.
+func (s *ChatService) SetConversationNameRules(rules validation.ConversationNameRules) {
+	s.nameRules = rules
+}
+
+// SetMessageContentFilter sets the word list and the default profanity mode
+// applied to chatroom messages. Rooms can override the mode and add words.
+func (s *ChatService) SetMessageContentFilter(filter *validation.ProfanityFilter, mode string) {
+	s.profanity = filter
+	s.profanityMode = mode
+}
+
 // ChatroomWithJoined pairs a conversation with joined status.
 type ChatroomWithJoined struct {
 	Conversation *models.Conversation
@@ -66,8 +94,14 @@ type ChatroomWithJoined struct {
 
 // CreateConversation creates a new conversation (DM or group).
 func (s *ChatService) CreateConversation(ctx context.Context, in CreateConversationInput) (*models.Conversation, error) {
-	if in.IsGroup && in.Name == "" {
-		return nil, models.NewValidationError("Group conversations require a name")
+	if in.IsGroup {
+		in.Name = strings.TrimSpace(in.Name)
+		if in.Name == "" {
+			return nil, models.NewValidationError("Group conversations require a name")
+		}
+		if err := s.validateGroupName(ctx, in.Name, 0); err != nil {
+			return nil, err
+		}
 	}
 	if len(in.ParticipantIDs) == 0 {
 		return nil, models.NewValidationError("At least one participant is required")
@@ -75,7 +109,7 @@ func (s *ChatService) CreateConversation(ctx context.Context, in CreateConversat
 
 	if !in.IsGroup && len(in.ParticipantIDs) == 1 && in.ParticipantIDs[0] != in.UserID && s.db != nil {
 		otherUserID := in.ParticipantIDs[0]
-		blocked, err := s.usersBlocked(ctx, in.UserID, otherUserID)
+		blocked, err := s.areBlocked(ctx, in.UserID, otherUserID)
 		if err != nil {
 			return nil, err
 		}
@@ -109,6 +143,9 @@ func (s *ChatService) CreateConversation(ctx context.Context, in CreateConversat
 		default:
 			return nil, findErr
 		}
+		if err := s.checkDMAccountAge(ctx, in.UserID); err != nil {
+			return nil, err
+		}
 	}
 
 	conv := &models.Conversation{
@@ -136,11 +173,122 @@ func (s *ChatService) CreateConversation(ctx context.Context, in CreateConversat
 	return s.chatRepo.GetConversation(ctx, conv.ID)
 }
 
+// RenameConversation renames a group conversation. The creator, chatroom
+// moderators and admins may rename it.
+func (s *ChatService) RenameConversation(ctx context.Context, convID, actorUserID uint, name string) (*models.Conversation, error) {
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsGroup {
+		return nil, models.NewValidationError("Only group conversations can be renamed")
+	}
+	if conv.CreatedBy != actorUserID {
+		allowed := false
+		if s.canModerateChatroom != nil {
+			if allowed, err = s.canModerateChatroom(ctx, actorUserID, convID); err != nil {
+				return nil, err
+			}
+		}
+		if !allowed {
+			return nil, models.NewForbiddenError("Only the creator or a moderator can rename this conversation")
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.validateGroupName(ctx, name, convID); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).
+		Model(&models.Conversation{}).
+		Where("id = ?", convID).
+		Update("name", name).Error; err != nil {
+		return nil, err
+	}
+	cache.InvalidateRoom(ctx, convID)
+	return s.chatRepo.GetConversation(ctx, convID)
+}
+
+// TransferConversation hands creator rights of a group conversation to
+// another participant. Only the current creator may transfer ownership.
+func (s *ChatService) TransferConversation(ctx context.Context, convID, actorUserID, newOwnerID uint) (*models.Conversation, error) {
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsGroup {
+		return nil, models.NewValidationError("Only group conversations can be transferred")
+	}
+	if conv.CreatedBy != actorUserID {
+		return nil, models.NewForbiddenError("Only the creator can transfer this conversation")
+	}
+	if newOwnerID == 0 || newOwnerID == actorUserID {
+		return nil, models.NewValidationError("Choose another participant to transfer ownership to")
+	}
+
+	isParticipant, err := s.chatRepo.IsUserParticipant(ctx, convID, newOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if !isParticipant {
+		return nil, models.NewValidationError("New owner must be a participant in this conversation")
+	}
+
+	if err := s.db.WithContext(ctx).
+		Model(&models.Conversation{}).
+		Where("id = ? AND created_by = ?", convID, actorUserID).
+		Update("created_by", newOwnerID).Error; err != nil {
+		return nil, err
+	}
+	cache.InvalidateRoom(ctx, convID)
+	return s.chatRepo.GetConversation(ctx, convID)
+}
+
+// validateGroupName applies the configured name rules and keeps group names
+// unique (case-insensitively), since every group is listed as a chatroom.
+// excludeID skips the conversation being renamed.
+func (s *ChatService) validateGroupName(ctx context.Context, name string, excludeID uint) error {
+	if err := s.nameRules.Validate(name); err != nil {
+		return models.NewValidationError(err.Error())
+	}
+	if s.db == nil {
+		return nil
+	}
+	query := s.db.WithContext(ctx).
+		Model(&models.Conversation{}).
+		Where("is_group = ? AND LOWER(name) = ?", true, strings.ToLower(name))
+	if excludeID != 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return models.NewValidationError("A chatroom with this name already exists")
+	}
+	return nil
+}
+
 // GetConversations returns conversations for the user.
 func (s *ChatService) GetConversations(ctx context.Context, userID uint) ([]*models.Conversation, error) {
 	return s.chatRepo.GetUserConversations(ctx, userID)
 }
 
+// GetUnreadSummary returns the user's unread message counts per conversation
+// and their total.
+func (s *ChatService) GetUnreadSummary(ctx context.Context, userID uint) (*models.UnreadSummary, error) {
+	counts, err := s.chatRepo.GetUnreadCounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.UnreadSummary{Conversations: counts}
+	for _, c := range counts {
+		summary.Total += c.UnreadCount
+	}
+	return summary, nil
+}
+
 // GetConversationForUser returns the conversation if the user is a participant.
 func (s *ChatService) GetConversationForUser(ctx context.Context, convID, userID uint) (*models.Conversation, error) {
 	conv, err := s.chatRepo.GetConversation(ctx, convID)
@@ -157,14 +305,14 @@ const maxMessageContentLen = 10000 // 10K characters
 
 // SendMessage sends a message in a conversation.
 func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*models.Message, *models.Conversation, error) {
-	if in.Content == "" {
+	if in.Content == "" && in.MessageType != MessageTypeImage {
 		return nil, nil, models.NewValidationError("Message content is required")
 	}
 	if len(in.Content) > maxMessageContentLen {
 		return nil, nil, models.NewValidationError("Message content too long (max 10000 characters)")
 	}
 	if in.MessageType == "" {
-		in.MessageType = "text"
+		in.MessageType = MessageTypeText
 	}
 	if in.Metadata == nil {
 		in.Metadata = json.RawMessage("{}")
@@ -182,7 +330,7 @@ func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*mo
 			if participant.ID == in.UserID {
 				continue
 			}
-			blocked, berr := s.usersBlocked(ctx, in.UserID, participant.ID)
+			blocked, berr := s.areBlocked(ctx, in.UserID, participant.ID)
 			if berr != nil {
 				return nil, nil, berr
 			}
@@ -199,13 +347,39 @@ func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*mo
 		if banned {
 			return nil, nil, models.NewForbiddenError("You are banned from this room")
 		}
-		muted, merr := s.userMutedInRoom(ctx, conv.ID, in.UserID)
+		mute, merr := s.activeRoomMute(ctx, conv.ID, in.UserID)
 		if merr != nil {
 			return nil, nil, merr
 		}
-		if muted {
+		if mute != nil {
+			if mute.MutedUntil != nil {
+				return nil, nil, models.NewForbiddenError(fmt.Sprintf("You are muted in this room until %s", mute.MutedUntil.UTC().Format(time.RFC3339)))
+			}
 			return nil, nil, models.NewForbiddenError("You are muted in this room")
 		}
+		switch in.MessageType {
+		case MessageTypeText:
+			if ferr := s.filterRoomMessage(ctx, conv.ID, in.UserID, &in.Content); ferr != nil {
+				return nil, nil, ferr
+			}
+		case MessageTypeLink:
+			// The preview text and the link itself are shown to the room too.
+			var meta LinkMessageMetadata
+			if json.Unmarshal(in.Metadata, &meta) == nil {
+				if ferr := s.filterRoomMessage(ctx, conv.ID, in.UserID,
+					&in.Content, &meta.URL, &meta.Title, &meta.Description); ferr != nil {
+					return nil, nil, ferr
+				}
+				if in.Metadata, err = json.Marshal(meta); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	metadata, err := s.normalizeMessageMetadata(ctx, in.MessageType, in.Metadata)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	message := &models.Message{
@@ -213,32 +387,146 @@ func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*mo
 		SenderID:       in.UserID,
 		Content:        in.Content,
 		MessageType:    in.MessageType,
-		Metadata:       in.Metadata,
+		Metadata:       metadata,
 	}
 	if err := s.chatRepo.CreateMessage(ctx, message); err != nil {
 		return nil, nil, err
 	}
+	observability.ChatMessagesSent.WithLabelValues(observability.ConversationType(conv.IsGroup)).Inc()
 
 	if sender, err := s.userRepo.GetByID(ctx, in.UserID); err == nil {
 		message.Sender = sender
 	}
+	if err := s.renderImageMetadata(ctx, []*models.Message{message}); err != nil {
+		return nil, nil, err
+	}
 
 	return message, conv, nil
 }
 
 // GetMessagesForUser returns messages for a conversation (participant check applied).
 func (s *ChatService) GetMessagesForUser(ctx context.Context, convID, userID uint, limit, offset int) ([]*models.Message, error) {
-	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if _, err := s.GetConversationForUser(ctx, convID, userID); err != nil {
+		return nil, err
+	}
+	messages, err := s.chatRepo.GetMessages(ctx, convID, userID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
-	if !isConversationParticipant(conv, userID) {
-		return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	filtered, err := s.filterBlockedSenders(ctx, userID, messages)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactionSummaries(ctx, userID, filtered); err != nil {
+		return nil, err
+	}
+	if err := s.renderImageMetadata(ctx, filtered); err != nil {
+		return nil, err
+	}
+	return filtered, nil
+}
+
+// GetMessagesBeforeForUser returns up to limit messages older than beforeID
+// (the newest page when beforeID is 0) together with the cursor for the next
+// older page, which is nil once the start of the conversation is reached.
+func (s *ChatService) GetMessagesBeforeForUser(ctx context.Context, convID, userID, beforeID uint, limit int) ([]*models.Message, *uint, error) {
+	if _, err := s.GetConversationForUser(ctx, convID, userID); err != nil {
+		return nil, nil, err
+	}
+	messages, err := s.chatRepo.GetMessagesBefore(ctx, convID, userID, beforeID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The cursor comes from the unfiltered page so hidden senders never shift
+	// page boundaries.
+	var nextCursor *uint
+	if len(messages) == limit && len(messages) > 0 {
+		oldest := messages[0].ID
+		nextCursor = &oldest
+	}
+
+	filtered, err := s.filterBlockedSenders(ctx, userID, messages)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.attachReactionSummaries(ctx, userID, filtered); err != nil {
+		return nil, nil, err
+	}
+	if err := s.renderImageMetadata(ctx, filtered); err != nil {
+		return nil, nil, err
+	}
+	return filtered, nextCursor, nil
+}
+
+// MarkMessageDelivered records that the recipient's client received a direct
+// message. It is idempotent: the first acknowledgement wins and later ones
+// return the message unchanged.
+func (s *ChatService) MarkMessageDelivered(ctx context.Context, convID, msgID, userID uint) (*models.Message, error) {
+	conv, err := s.GetConversationForUser(ctx, convID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if conv.IsGroup {
+		return nil, models.NewValidationError("Delivery receipts are only tracked for direct messages")
 	}
-	messages, err := s.chatRepo.GetMessages(ctx, convID, limit, offset)
+
+	var message models.Message
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND conversation_id = ?", msgID, convID).
+		First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Message", msgID)
+		}
+		return nil, err
+	}
+	if message.SenderID == userID {
+		return nil, models.NewValidationError("Cannot acknowledge your own message")
+	}
+	if message.DeliveredAt != nil {
+		return &message, nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("id = ? AND delivered_at IS NULL", msgID).
+		Update("delivered_at", now).Error; err != nil {
+		return nil, err
+	}
+	cache.Invalidate(ctx, cache.MessageHistoryKey(convID))
+
+	message.DeliveredAt = &now
+	return &message, nil
+}
+
+// GetMessageForUser returns one message from a conversation the user
+// participates in. Messages from blocked senders are reported as not found.
+func (s *ChatService) GetMessageForUser(ctx context.Context, convID, msgID, userID uint) (*models.Message, error) {
+	if _, err := s.GetConversationForUser(ctx, convID, userID); err != nil {
+		return nil, err
+	}
+	message, err := s.chatRepo.GetMessage(ctx, convID, userID, msgID)
 	if err != nil {
 		return nil, err
 	}
+	visible, err := s.filterBlockedSenders(ctx, userID, []*models.Message{message})
+	if err != nil {
+		return nil, err
+	}
+	if len(visible) == 0 {
+		return nil, models.NewNotFoundError("Message", msgID)
+	}
+	if err := s.renderImageMetadata(ctx, visible); err != nil {
+		return nil, err
+	}
+	if err := s.attachReactionSummaries(ctx, userID, visible); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (s *ChatService) filterBlockedSenders(ctx context.Context, userID uint, messages []*models.Message) ([]*models.Message, error) {
 	blockedByUser, berr := s.blockedUserIDs(ctx, userID)
 	if berr != nil {
 		return nil, berr
@@ -256,6 +544,106 @@ func (s *ChatService) GetMessagesForUser(ctx context.Context, convID, userID uin
 	return filtered, nil
 }
 
+// filterRoomMessage applies the room's content filter to every user-visible
+// text of a message, masking them in place if needed, or returns an error
+// when the message is rejected. Moderators bypass the filter.
+func (s *ChatService) filterRoomMessage(ctx context.Context, roomID, userID uint, texts ...*string) error {
+	if s.db == nil {
+		return nil
+	}
+	var settings models.ChatroomContentFilter
+	if err := s.db.WithContext(ctx).
+		Where("conversation_id = ?", roomID).
+		Limit(1).
+		Find(&settings).Error; err != nil && !models.IsSchemaMissingError(err) {
+		return err
+	}
+	mode := settings.ProfanityMode
+	if mode == "" {
+		mode = s.profanityMode
+	}
+	if mode == "" {
+		mode = models.ProfanityFilterOff
+	}
+	if mode == models.ProfanityFilterOff && !settings.BlockLinks {
+		return nil
+	}
+
+	if s.canModerateChatroom != nil {
+		moderator, err := s.canModerateChatroom(ctx, userID, roomID)
+		if err != nil {
+			return err
+		}
+		if moderator {
+			return nil
+		}
+	}
+
+	filter := s.profanity
+	if filter == nil {
+		filter = validation.NewProfanityFilter()
+	}
+	filter = filter.With(settings.BlockedWordList()...)
+	for _, text := range texts {
+		if settings.BlockLinks && validation.ContainsLink(*text) {
+			return models.NewForbiddenError("Links are not allowed in this room")
+		}
+		if mode == models.ProfanityFilterReject && filter.Contains(*text) {
+			return models.NewValidationError("Message contains blocked words")
+		}
+	}
+	if mode == models.ProfanityFilterMask {
+		for _, text := range texts {
+			*text, _ = filter.Mask(*text)
+		}
+	}
+	return nil
+}
+
+// attachReactionSummaries fills ReactionSummary on each message from one
+// grouped query over the page, most used emoji first.
+func (s *ChatService) attachReactionSummaries(ctx context.Context, userID uint, messages []*models.Message) error {
+	if s.db == nil || len(messages) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	var rows []struct {
+		MessageID   uint
+		Emoji       string
+		Count       int
+		ReactedByMe bool
+	}
+	if err := s.db.WithContext(ctx).
+		Model(&models.MessageReaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, MAX(CASE WHEN user_id = ? THEN 1 ELSE 0 END) = 1 AS reacted_by_me", userID).
+		Where("message_id IN ?", ids).
+		Group("message_id, emoji").
+		Order("message_id, count DESC, emoji").
+		Scan(&rows).Error; err != nil {
+		if models.IsSchemaMissingError(err) {
+			return nil
+		}
+		return err
+	}
+
+	byMessage := make(map[uint][]models.MessageReactionSummary, len(messages))
+	for _, row := range rows {
+		byMessage[row.MessageID] = append(byMessage[row.MessageID], models.MessageReactionSummary{
+			Emoji:       row.Emoji,
+			Count:       row.Count,
+			ReactedByMe: row.ReactedByMe,
+		})
+	}
+	for _, message := range messages {
+		message.ReactionSummary = byMessage[message.ID]
+	}
+	return nil
+}
+
 // AddParticipant adds a participant to a group conversation.
 func (s *ChatService) AddParticipant(ctx context.Context, convID, actorUserID, participantUserID uint) error {
 	conv, err := s.chatRepo.GetConversation(ctx, convID)
@@ -296,7 +684,7 @@ func (s *ChatService) GetAllChatrooms(ctx context.Context, userID uint) ([]Chatr
 			Where("is_group = ?", true).
 			Preload("Participants").
 			Preload("Messages", func(db *gorm.DB) *gorm.DB {
-				return db.Order("created_at DESC").Limit(1)
+				return db.Scopes(repository.VisibleMessagesFor(0)).Order("created_at DESC").Limit(1)
 			}).
 			Preload("Messages.Sender").
 			Order("name ASC").
@@ -332,7 +720,7 @@ func (s *ChatService) GetJoinedChatrooms(ctx context.Context, userID uint) ([]*m
 		Where("conversations.is_group = ? AND cp.user_id = ?", true, userID).
 		Preload("Participants").
 		Preload("Messages", func(db *gorm.DB) *gorm.DB {
-			return db.Order("created_at DESC").Limit(1)
+			return db.Scopes(repository.VisibleMessagesFor(userID)).Order("created_at DESC").Limit(1)
 		}).
 		Preload("Messages.Sender").
 		Order("conversations.name ASC").
@@ -423,6 +811,55 @@ func (s *ChatService) RemoveParticipant(ctx context.Context, roomID, actorUserID
 	return username, nil
 }
 
+// BulkDeleteRoomMessagesInput selects the messages a moderator removes from a
+// room: either explicit MessageIDs, or the Last most recent from UserID.
+type BulkDeleteRoomMessagesInput struct {
+	RoomID     uint
+	MessageIDs []uint
+	UserID     uint
+	Last       int
+}
+
+// BulkDeleteRoomMessages soft-deletes the selected messages in one
+// transaction and returns their IDs. Only messages in the room are eligible,
+// whichever selector is used. Callers check moderation rights.
+func (s *ChatService) BulkDeleteRoomMessages(ctx context.Context, in BulkDeleteRoomMessagesInput) ([]uint, error) {
+	deletedIDs := []uint{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var room models.Conversation
+		if err := tx.Select("id", "is_group").First(&room, in.RoomID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.NewNotFoundError("Chatroom", in.RoomID)
+			}
+			return err
+		}
+		if !room.IsGroup {
+			return models.NewNotFoundError("Chatroom", in.RoomID)
+		}
+
+		query := tx.Model(&models.Message{}).Where("conversation_id = ?", in.RoomID)
+		if len(in.MessageIDs) > 0 {
+			query = query.Where("id IN ?", in.MessageIDs)
+		} else {
+			query = query.Where("sender_id = ?", in.UserID).Order("id DESC").Limit(in.Last)
+		}
+		if err := query.Pluck("id", &deletedIDs).Error; err != nil {
+			return err
+		}
+		if len(deletedIDs) == 0 {
+			return nil
+		}
+		return tx.Where("id IN ?", deletedIDs).Delete(&models.Message{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(deletedIDs) > 0 {
+		cache.Invalidate(ctx, cache.MessageHistoryKey(in.RoomID))
+	}
+	return deletedIDs, nil
+}
+
 func (s *ChatService) userBannedInRoom(ctx context.Context, roomID, userID uint) (bool, error) {
 	if s.db == nil {
 		return false, nil
@@ -450,47 +887,75 @@ func isConversationParticipant(conv *models.Conversation, userID uint) bool {
 	return false
 }
 
-func (s *ChatService) usersBlocked(ctx context.Context, userID, otherUserID uint) (bool, error) {
-	if s.db == nil {
-		return false, nil
+// checkDMAccountAge rejects new DMs from accounts younger than the configured
+// minimum age. Admins are trusted and exempt.
+func (s *ChatService) checkDMAccountAge(ctx context.Context, userID uint) error {
+	if s.minDMAccountAge <= 0 {
+		return nil
 	}
-	var count int64
-	if err := s.db.WithContext(ctx).
-		Model(&models.UserBlock{}).
-		Where(
-			"(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
-			userID, otherUserID, otherUserID, userID,
-		).
-		Count(&count).Error; err != nil {
-		if models.IsSchemaMissingError(err) {
-			return false, nil
+	if s.isAdmin != nil {
+		admin, err := s.isAdmin(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if admin {
+			return nil
 		}
-		return false, err
 	}
-	return count > 0, nil
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("id", "created_at").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if time.Since(user.CreatedAt) < s.minDMAccountAge {
+		return models.NewForbiddenError(fmt.Sprintf(
+			"New accounts must be at least %s old to start direct messages",
+			formatAccountAge(s.minDMAccountAge)))
+	}
+	return nil
 }
 
-func (s *ChatService) userMutedInRoom(ctx context.Context, roomID, userID uint) (bool, error) {
+// formatAccountAge renders a minimum account age for user-facing errors.
+func formatAccountAge(d time.Duration) string {
+	if hours := int(d / time.Hour); hours >= 1 && d%time.Hour == 0 {
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return d.Round(time.Minute).String()
+}
+
+func (s *ChatService) areBlocked(ctx context.Context, userID, otherUserID uint) (bool, error) {
 	if s.db == nil {
 		return false, nil
 	}
+	return repository.AreBlocked(ctx, s.db, userID, otherUserID)
+}
+
+// activeRoomMute returns the user's mute in the room, or nil when they are not
+// muted or their mute has expired.
+func (s *ChatService) activeRoomMute(ctx context.Context, roomID, userID uint) (*models.ChatroomMute, error) {
+	if s.db == nil {
+		return nil, nil
+	}
 	var mute models.ChatroomMute
 	err := s.db.WithContext(ctx).
 		Where("conversation_id = ? AND user_id = ?", roomID, userID).
 		First(&mute).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return false, nil
+			return nil, nil
 		}
 		if models.IsSchemaMissingError(err) {
-			return false, nil
+			return nil, nil
 		}
-		return false, err
+		return nil, err
 	}
-	if mute.MutedUntil == nil {
-		return true, nil
+	if mute.MutedUntil != nil && !mute.MutedUntil.After(time.Now().UTC()) {
+		return nil, nil
 	}
-	return mute.MutedUntil.After(time.Now().UTC()), nil
+	return &mute, nil
 }
 
 func (s *ChatService) blockedUserIDs(ctx context.Context, userID uint) (map[uint]bool, error) {
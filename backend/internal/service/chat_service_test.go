@@ -2,13 +2,21 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/observability"
 	"sanctum/internal/repository"
+	"sanctum/internal/validation"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -21,6 +29,8 @@ type chatRepoStub struct {
 	removeParticipantFn    func(context.Context, uint, uint) error
 	createMessageFn        func(context.Context, *models.Message) error
 	getMessagesFn          func(context.Context, uint, int, int) ([]*models.Message, error)
+	getMessagesBeforeFn    func(context.Context, uint, uint, int) ([]*models.Message, error)
+	getMessageFn           func(context.Context, uint, uint) (*models.Message, error)
 	markMessageReadFn      func(context.Context, uint) error
 	updateLastReadFn       func(context.Context, uint, uint) error
 }
@@ -43,9 +53,15 @@ func (s *chatRepoStub) RemoveParticipant(ctx context.Context, convID, userID uin
 func (s *chatRepoStub) CreateMessage(ctx context.Context, msg *models.Message) error {
 	return s.createMessageFn(ctx, msg)
 }
-func (s *chatRepoStub) GetMessages(ctx context.Context, convID uint, limit, offset int) ([]*models.Message, error) {
+func (s *chatRepoStub) GetMessages(ctx context.Context, convID, viewerID uint, limit, offset int) ([]*models.Message, error) {
 	return s.getMessagesFn(ctx, convID, limit, offset)
 }
+func (s *chatRepoStub) GetMessagesBefore(ctx context.Context, convID, viewerID, beforeID uint, limit int) ([]*models.Message, error) {
+	return s.getMessagesBeforeFn(ctx, convID, beforeID, limit)
+}
+func (s *chatRepoStub) GetMessage(ctx context.Context, convID, viewerID, msgID uint) (*models.Message, error) {
+	return s.getMessageFn(ctx, convID, msgID)
+}
 func (s *chatRepoStub) MarkMessageRead(ctx context.Context, msgID uint) error {
 	return s.markMessageReadFn(ctx, msgID)
 }
@@ -55,6 +71,9 @@ func (s *chatRepoStub) UpdateLastRead(ctx context.Context, convID, userID uint)
 func (s *chatRepoStub) IsUserParticipant(_ context.Context, _, _ uint) (bool, error) {
 	return true, nil
 }
+func (s *chatRepoStub) GetUnreadCounts(_ context.Context, _ uint) ([]models.ConversationUnread, error) {
+	return nil, nil
+}
 
 func noopChatRepo() *chatRepoStub {
 	return &chatRepoStub{
@@ -65,6 +84,8 @@ func noopChatRepo() *chatRepoStub {
 		removeParticipantFn:    func(context.Context, uint, uint) error { return nil },
 		createMessageFn:        func(context.Context, *models.Message) error { return nil },
 		getMessagesFn:          func(context.Context, uint, int, int) ([]*models.Message, error) { return nil, nil },
+		getMessagesBeforeFn:    func(context.Context, uint, uint, int) ([]*models.Message, error) { return nil, nil },
+		getMessageFn:           func(context.Context, uint, uint) (*models.Message, error) { return &models.Message{}, nil },
 		markMessageReadFn:      func(context.Context, uint) error { return nil },
 		updateLastReadFn:       func(context.Context, uint, uint) error { return nil },
 	}
@@ -172,6 +193,49 @@ func TestChatService_FullFlow(t *testing.T) {
 		assert.Len(t, msgs, 1)
 	})
 
+	t.Run("Cursor pagination", func(t *testing.T) {
+		conv, _ := svc.CreateConversation(ctx, CreateConversationInput{
+			UserID:         u1.ID,
+			IsGroup:        true,
+			Name:           "Cursor Group",
+			ParticipantIDs: []uint{u2.ID},
+		})
+
+		ids := make([]uint, 0, 5)
+		for i := 0; i < 5; i++ {
+			msg, _, err := svc.SendMessage(ctx, SendMessageInput{
+				UserID:         u1.ID,
+				ConversationID: conv.ID,
+				Content:        fmt.Sprintf("m%d", i),
+			})
+			assert.NoError(t, err)
+			ids = append(ids, msg.ID)
+		}
+
+		newest, cursor, err := svc.GetMessagesBeforeForUser(ctx, conv.ID, u2.ID, 0, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{ids[3], ids[4]}, messageIDs(newest))
+		if assert.NotNil(t, cursor) {
+			assert.Equal(t, ids[3], *cursor)
+		}
+
+		// A message arriving mid-scrollback must not shift older pages.
+		_, _, err = svc.SendMessage(ctx, SendMessageInput{UserID: u1.ID, ConversationID: conv.ID, Content: "late"})
+		assert.NoError(t, err)
+
+		older, cursor, err := svc.GetMessagesBeforeForUser(ctx, conv.ID, u2.ID, *cursor, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{ids[1], ids[2]}, messageIDs(older))
+		for _, msg := range older {
+			assert.Less(t, msg.ID, ids[3])
+		}
+
+		oldest, cursor, err := svc.GetMessagesBeforeForUser(ctx, conv.ID, u2.ID, *cursor, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{ids[0]}, messageIDs(oldest))
+		assert.Nil(t, cursor)
+	})
+
 	t.Run("Add and Leave", func(t *testing.T) {
 		conv, _ := svc.CreateConversation(ctx, CreateConversationInput{
 			UserID:         u1.ID,
@@ -197,6 +261,126 @@ func TestChatService_FullFlow(t *testing.T) {
 	})
 }
 
+func TestChatService_CreateConversation_MinAccountAge(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	_ = db.AutoMigrate(&models.Conversation{}, &models.User{}, &models.ConversationParticipant{}, &models.Message{}, &models.UserBlock{})
+
+	isAdmin := func(_ context.Context, userID uint) (bool, error) {
+		var user models.User
+		if err := db.Select("is_admin").First(&user, userID).Error; err != nil {
+			return false, err
+		}
+		return user.IsAdmin, nil
+	}
+	svc := NewChatService(repository.NewChatRepository(db), repository.NewUserRepository(db), db, isAdmin, nil)
+	svc.SetMinDMAccountAge(24 * time.Hour)
+
+	ctx := context.Background()
+	old := &models.User{Username: "old", Email: "old@e.com", CreatedAt: time.Now().Add(-72 * time.Hour)}
+	fresh := &models.User{Username: "fresh", Email: "fresh@e.com"}
+	freshAdmin := &models.User{Username: "fresh_admin", Email: "fa@e.com", IsAdmin: true}
+	target := &models.User{Username: "target", Email: "target@e.com"}
+	for _, u := range []*models.User{old, fresh, freshAdmin, target} {
+		db.Create(u)
+	}
+
+	_, err := svc.CreateConversation(ctx, CreateConversationInput{UserID: fresh.ID, ParticipantIDs: []uint{target.ID}})
+	var appErr *models.AppError
+	if assert.True(t, errors.As(err, &appErr)) {
+		assert.Equal(t, "FORBIDDEN", appErr.Code)
+		assert.Contains(t, appErr.Message, "24 hours")
+	}
+
+	conv, err := svc.CreateConversation(ctx, CreateConversationInput{UserID: old.ID, ParticipantIDs: []uint{fresh.ID}})
+	assert.NoError(t, err)
+
+	// Replying to an existing DM is allowed for new accounts.
+	existing, err := svc.CreateConversation(ctx, CreateConversationInput{UserID: fresh.ID, ParticipantIDs: []uint{old.ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, conv.ID, existing.ID)
+
+	_, err = svc.CreateConversation(ctx, CreateConversationInput{UserID: freshAdmin.ID, ParticipantIDs: []uint{target.ID}})
+	assert.NoError(t, err, "trusted accounts are exempt")
+}
+
+func TestChatService_GroupNameValidation(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	_ = db.AutoMigrate(&models.Conversation{}, &models.User{}, &models.ConversationParticipant{}, &models.Message{})
+
+	svc := NewChatService(repository.NewChatRepository(db), repository.NewUserRepository(db), db, nil, nil)
+	svc.SetConversationNameRules(validation.ConversationNameRules{MaxLength: 24})
+
+	ctx := context.Background()
+	owner := &models.User{Username: "owner", Email: "owner@e.com"}
+	member := &models.User{Username: "member", Email: "member@e.com"}
+	db.Create(owner)
+	db.Create(member)
+
+	create := func(name string) (*models.Conversation, error) {
+		return svc.CreateConversation(ctx, CreateConversationInput{
+			UserID: owner.ID, IsGroup: true, Name: name, ParticipantIDs: []uint{member.ID},
+		})
+	}
+	assertValidation := func(t *testing.T, err error, contains string) {
+		t.Helper()
+		var appErr *models.AppError
+		if assert.True(t, errors.As(err, &appErr), "expected AppError, got %v", err) {
+			assert.Equal(t, "VALIDATION_ERROR", appErr.Code)
+			assert.Contains(t, appErr.Message, contains)
+		}
+	}
+
+	_, err := create("This room name is far too long")
+	assertValidation(t, err, "must not exceed 24")
+
+	_, err = create("shit posting")
+	assertValidation(t, err, "inappropriate")
+
+	room, err := create("  Night Owls  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Night Owls", room.Name)
+
+	_, err = create("night owls")
+	assertValidation(t, err, "already exists")
+
+	t.Run("rename", func(t *testing.T) {
+		_, err := svc.RenameConversation(ctx, room.ID, member.ID, "Early Birds")
+		var appErr *models.AppError
+		if assert.True(t, errors.As(err, &appErr)) {
+			assert.Equal(t, "FORBIDDEN", appErr.Code)
+		}
+
+		_, err = svc.RenameConversation(ctx, room.ID, owner.ID, "f*ck <3")
+		assertValidation(t, err, "can only contain")
+
+		renamed, err := svc.RenameConversation(ctx, room.ID, owner.ID, "Early Birds")
+		assert.NoError(t, err)
+		assert.Equal(t, "Early Birds", renamed.Name)
+
+		// Keeping the current name (in a different case) is not a duplicate.
+		_, err = svc.RenameConversation(ctx, room.ID, owner.ID, "early birds")
+		assert.NoError(t, err)
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		_, err := svc.TransferConversation(ctx, room.ID, owner.ID, owner.ID)
+		assertValidation(t, err, "another participant")
+
+		_, err = svc.TransferConversation(ctx, room.ID, owner.ID, member.ID+100)
+		assertValidation(t, err, "must be a participant")
+
+		transferred, err := svc.TransferConversation(ctx, room.ID, owner.ID, member.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, member.ID, transferred.CreatedBy)
+
+		_, err = svc.TransferConversation(ctx, room.ID, owner.ID, member.ID)
+		var appErr *models.AppError
+		if assert.True(t, errors.As(err, &appErr)) {
+			assert.Equal(t, "FORBIDDEN", appErr.Code)
+		}
+	})
+}
+
 func TestChatService_Chatrooms(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	_ = db.AutoMigrate(
@@ -303,6 +487,45 @@ func TestChatService_SendMessage_BannedUserForbidden(t *testing.T) {
 	assert.Equal(t, "FORBIDDEN", appErr.Code)
 }
 
+func TestChatService_SendMessage_CountsMessagesSent(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	_ = db.AutoMigrate(
+		&models.Conversation{},
+		&models.User{},
+		&models.ConversationParticipant{},
+		&models.Message{},
+		&models.ChatroomBan{},
+		&models.ChatroomMute{},
+		&models.ChatroomContentFilter{},
+	)
+
+	repo := repository.NewChatRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	svc := NewChatService(repo, userRepo, db, nil, nil)
+
+	ctx := context.Background()
+	user := &models.User{Username: "u1", Email: "u1@e.com"}
+	db.Create(user)
+	room := &models.Conversation{Name: "Public", IsGroup: true, CreatedBy: user.ID}
+	db.Create(room)
+	db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: user.ID})
+
+	groupSent := observability.ChatMessagesSent.WithLabelValues("group")
+	directSent := observability.ChatMessagesSent.WithLabelValues("direct")
+	groupBefore := testutil.ToFloat64(groupSent)
+	directBefore := testutil.ToFloat64(directSent)
+
+	_, _, err := svc.SendMessage(ctx, SendMessageInput{
+		UserID:         user.ID,
+		ConversationID: room.ID,
+		Content:        "hello",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, groupBefore+1, testutil.ToFloat64(groupSent))
+	assert.Equal(t, directBefore, testutil.ToFloat64(directSent))
+}
+
 func TestChatService_RemoveParticipant_Authorization(t *testing.T) {
 	repo := noopChatRepo()
 	// Since RemoveParticipant uses s.db.First, we need a DB.
@@ -335,3 +558,147 @@ func TestChatService_RemoveParticipant_Authorization(t *testing.T) {
 	_, err = svcAdmin.RemoveParticipant(context.Background(), 1, 1, 3)
 	assert.NoError(t, err)
 }
+
+func messageIDs(messages []*models.Message) []uint {
+	ids := make([]uint, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestChatService_BlockedUsersCannotDM(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	_ = db.AutoMigrate(&models.Conversation{}, &models.User{}, &models.ConversationParticipant{}, &models.Message{}, &models.UserBlock{})
+
+	svc := NewChatService(repository.NewChatRepository(db), repository.NewUserRepository(db), db, nil, nil)
+	ctx := context.Background()
+
+	blocker := &models.User{Username: "blocker", Email: "blocker@e.com"}
+	blocked := &models.User{Username: "blocked", Email: "blocked@e.com"}
+	db.Create(blocker)
+	db.Create(blocked)
+
+	conv, err := svc.CreateConversation(ctx, CreateConversationInput{UserID: blocker.ID, ParticipantIDs: []uint{blocked.ID}})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&models.UserBlock{BlockerID: blocker.ID, BlockedID: blocked.ID}).Error)
+
+	assertForbidden := func(t *testing.T, err error) {
+		t.Helper()
+		var appErr *models.AppError
+		if assert.True(t, errors.As(err, &appErr), "expected AppError, got %v", err) {
+			assert.Equal(t, "FORBIDDEN", appErr.Code)
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		from, to *models.User
+	}{
+		{name: "blocker to blocked", from: blocker, to: blocked},
+		{name: "blocked to blocker", from: blocked, to: blocker},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.CreateConversation(ctx, CreateConversationInput{UserID: tc.from.ID, ParticipantIDs: []uint{tc.to.ID}})
+			assertForbidden(t, err)
+
+			_, _, err = svc.SendMessage(ctx, SendMessageInput{ConversationID: conv.ID, UserID: tc.from.ID, Content: "hello"})
+			assertForbidden(t, err)
+		})
+	}
+
+	var count int64
+	db.Model(&models.Message{}).Count(&count)
+	assert.Zero(t, count, "no message is delivered across a block")
+}
+
+func TestChatService_TypedMessageMetadata(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Conversation{}, &models.User{}, &models.ConversationParticipant{}, &models.Message{}, &models.ImageVariant{},
+	))
+	createSQLiteImagesTable(t, db)
+
+	svc := NewChatService(repository.NewChatRepository(db), repository.NewUserRepository(db), db, nil, nil)
+	svc.SetImageService(NewImageService(repository.NewImageRepository(db), nil))
+	ctx := context.Background()
+
+	u1 := &models.User{Username: "u1", Email: "u1@e.com"}
+	u2 := &models.User{Username: "u2", Email: "u2@e.com"}
+	require.NoError(t, db.Create(u1).Error)
+	require.NoError(t, db.Create(u2).Error)
+	conv, err := svc.CreateConversation(ctx, CreateConversationInput{UserID: u1.ID, ParticipantIDs: []uint{u2.ID}})
+	require.NoError(t, err)
+
+	hash := strings.Repeat("ab", 32)
+	img := &models.Image{
+		Hash: hash, UserID: u1.ID, OriginalFilename: "cat.png", MimeType: "image/png",
+		SizeBytes: 10, Width: 640, Height: 480, OriginalPath: "x", ThumbnailPath: "x", MediumPath: "x",
+		UploadedAt: time.Now().UTC(),
+	}
+	require.NoError(t, db.Create(img).Error)
+	require.NoError(t, db.Create(&models.ImageVariant{ImageID: img.ID, SizeName: "sm", SizePx: 320, Format: "webp", Path: "x", Width: 320, Height: 240}).Error)
+
+	send := func(messageType, metadata string) (*models.Message, error) {
+		content := "look at this"
+		if messageType == MessageTypeImage {
+			content = "" // the caption is optional for images
+		}
+		msg, _, err := svc.SendMessage(ctx, SendMessageInput{
+			UserID: u1.ID, ConversationID: conv.ID, Content: content, MessageType: messageType, Metadata: json.RawMessage(metadata),
+		})
+		return msg, err
+	}
+	assertValidation := func(t *testing.T, err error, contains string) {
+		t.Helper()
+		var appErr *models.AppError
+		if assert.True(t, errors.As(err, &appErr), "expected AppError, got %v", err) {
+			assert.Equal(t, "VALIDATION_ERROR", appErr.Code)
+			assert.Contains(t, appErr.Message, contains)
+		}
+	}
+
+	t.Run("image message renders urls", func(t *testing.T) {
+		sent, err := send(MessageTypeImage, `{"image_hash":"`+hash+`","extra":"dropped"}`)
+		require.NoError(t, err)
+
+		var stored models.Message
+		require.NoError(t, db.First(&stored, sent.ID).Error)
+		assert.JSONEq(t, `{"image_hash":"`+hash+`"}`, string(stored.Metadata))
+
+		msgs, err := svc.GetMessagesForUser(ctx, conv.ID, u2.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		var meta ImageMessageMetadata
+		require.NoError(t, json.Unmarshal(msgs[0].Metadata, &meta))
+		assert.Equal(t, "/api/images/"+hash, meta.ImageURL)
+		assert.Equal(t, "/api/images/"+hash+"?size=thumbnail", meta.ThumbnailURL)
+		assert.Equal(t, 640, meta.Width)
+		assert.Equal(t, "/media/i/"+hash+"/320.webp", meta.Variants["320_webp"])
+	})
+
+	t.Run("image message with unknown hash is rejected", func(t *testing.T) {
+		_, err := send(MessageTypeImage, `{"image_hash":"`+strings.Repeat("cd", 32)+`"}`)
+		assertValidation(t, err, "Image not found")
+
+		_, err = send(MessageTypeImage, `{"image_hash":"../etc/passwd"}`)
+		assertValidation(t, err, "valid image_hash")
+	})
+
+	t.Run("link metadata is validated", func(t *testing.T) {
+		_, err := send(MessageTypeLink, `{"url":"javascript:alert(1)"}`)
+		assertValidation(t, err, "http or https")
+
+		_, err = send(MessageTypeLink, `"https://example.com"`)
+		assertValidation(t, err, "require metadata")
+
+		_, err = send("file", `{}`)
+		assertValidation(t, err, "Unsupported")
+	})
+
+	var count int64
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(1), count, "rejected messages are not stored")
+}
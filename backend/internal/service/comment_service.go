@@ -2,11 +2,18 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"sanctum/internal/models"
 	"sanctum/internal/repository"
 )
 
+// MaxCommentDepth is how many levels a comment thread may nest. Top-level
+// comments have depth 0, so the deepest allowed reply has depth
+// MaxCommentDepth-1.
+const MaxCommentDepth = 5
+
 // CommentService provides comment business logic.
 type CommentService struct {
 	commentRepo repository.CommentRepository
@@ -16,9 +23,10 @@ type CommentService struct {
 
 // CreateCommentInput is the input for creating a comment.
 type CreateCommentInput struct {
-	UserID  uint
-	PostID  uint
-	Content string
+	UserID          uint
+	PostID          uint
+	ParentCommentID *uint
+	Content         string
 }
 
 // UpdateCommentInput is the input for updating a comment.
@@ -49,7 +57,7 @@ func NewCommentService(
 
 // CreateComment creates a new comment on a post.
 func (s *CommentService) CreateComment(ctx context.Context, in CreateCommentInput) (*models.Comment, error) {
-	if _, err := s.postRepo.GetByID(ctx, in.PostID, 0); err != nil {
+	if _, err := visiblePost(ctx, s.postRepo, in.PostID, in.UserID); err != nil {
 		return nil, err
 	}
 	const maxCommentLen = 10000
@@ -66,6 +74,20 @@ func (s *CommentService) CreateComment(ctx context.Context, in CreateCommentInpu
 		UserID:  in.UserID,
 		PostID:  in.PostID,
 	}
+	if in.ParentCommentID != nil {
+		parent, err := s.commentRepo.GetByID(ctx, *in.ParentCommentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != in.PostID {
+			return nil, models.NewValidationError("Parent comment belongs to a different post")
+		}
+		if parent.Depth+1 >= MaxCommentDepth {
+			return nil, models.NewValidationError(fmt.Sprintf("Replies cannot nest more than %d levels deep", MaxCommentDepth))
+		}
+		comment.ParentCommentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
 	if err := s.commentRepo.Create(ctx, comment); err != nil {
 		return nil, err
 	}
@@ -73,12 +95,91 @@ func (s *CommentService) CreateComment(ctx context.Context, in CreateCommentInpu
 	return s.commentRepo.GetByID(ctx, comment.ID)
 }
 
-// ListComments returns comments for a post.
-func (s *CommentService) ListComments(ctx context.Context, postID uint) ([]*models.Comment, error) {
-	if _, err := s.postRepo.GetByID(ctx, postID, 0); err != nil {
+// ListComments returns comments for a post as seen by viewerID (0 when
+// anonymous). sort is "new" (the default), "old", or "top" (most liked first).
+func (s *CommentService) ListComments(ctx context.Context, postID, viewerID uint, sort string) ([]*models.Comment, error) {
+	if _, err := visiblePost(ctx, s.postRepo, postID, viewerID); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.ListByPost(ctx, postID, viewerID, sort)
+}
+
+// ListCommentThreads returns a post's comments assembled into reply trees.
+// sort orders the top-level comments; replies always read oldest first.
+func (s *CommentService) ListCommentThreads(ctx context.Context, postID, viewerID uint, sort string) ([]*models.Comment, error) {
+	comments, err := s.ListComments(ctx, postID, viewerID, sort)
+	if err != nil {
 		return nil, err
 	}
-	return s.commentRepo.ListByPost(ctx, postID)
+	return BuildCommentTree(comments), nil
+}
+
+// BuildCommentTree nests a flat comment list under each comment's parent.
+// Top-level comments keep their input order; replies are sorted oldest
+// first so conversations read top to bottom. A reply whose parent is
+// missing from the list (e.g. deleted) is promoted to the top level.
+func BuildCommentTree(comments []*models.Comment) []*models.Comment {
+	byID := make(map[uint]*models.Comment, len(comments))
+	for _, comment := range comments {
+		comment.Replies = nil
+		byID[comment.ID] = comment
+	}
+
+	roots := make([]*models.Comment, 0, len(comments))
+	for _, comment := range comments {
+		if comment.ParentCommentID != nil {
+			if parent, ok := byID[*comment.ParentCommentID]; ok && parent != comment {
+				parent.Replies = append(parent.Replies, comment)
+				continue
+			}
+		}
+		roots = append(roots, comment)
+	}
+
+	for _, comment := range comments {
+		sort.SliceStable(comment.Replies, func(i, j int) bool {
+			a, b := comment.Replies[i], comment.Replies[j]
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		})
+	}
+	return roots
+}
+
+// LikeComment records a like on a comment of the given post.
+func (s *CommentService) LikeComment(ctx context.Context, userID, postID, commentID uint) (*models.Comment, error) {
+	if _, err := s.commentOnPost(ctx, postID, commentID); err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.Like(ctx, userID, commentID); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.GetByID(ctx, commentID)
+}
+
+// UnlikeComment removes the user's like from a comment of the given post.
+func (s *CommentService) UnlikeComment(ctx context.Context, userID, postID, commentID uint) (*models.Comment, error) {
+	if _, err := s.commentOnPost(ctx, postID, commentID); err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.Unlike(ctx, userID, commentID); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.GetByID(ctx, commentID)
+}
+
+// commentOnPost loads a comment and checks it belongs to postID.
+func (s *CommentService) commentOnPost(ctx context.Context, postID, commentID uint) (*models.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.PostID != postID {
+		return nil, models.NewNotFoundError("Comment", commentID)
+	}
+	return comment, nil
 }
 
 // UpdateComment updates a comment (owner or admin).
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"sanctum/internal/models"
 
@@ -16,9 +17,11 @@ import (
 type commentRepoStub struct {
 	createFn     func(context.Context, *models.Comment) error
 	getByIDFn    func(context.Context, uint) (*models.Comment, error)
-	listByPostFn func(context.Context, uint) ([]*models.Comment, error)
+	listByPostFn func(context.Context, uint, string) ([]*models.Comment, error)
 	updateFn     func(context.Context, *models.Comment) error
 	deleteFn     func(context.Context, uint) error
+	likeFn       func(context.Context, uint, uint) error
+	unlikeFn     func(context.Context, uint, uint) error
 }
 
 func (s *commentRepoStub) Create(ctx context.Context, comment *models.Comment) error {
@@ -27,8 +30,8 @@ func (s *commentRepoStub) Create(ctx context.Context, comment *models.Comment) e
 func (s *commentRepoStub) GetByID(ctx context.Context, id uint) (*models.Comment, error) {
 	return s.getByIDFn(ctx, id)
 }
-func (s *commentRepoStub) ListByPost(ctx context.Context, postID uint) ([]*models.Comment, error) {
-	return s.listByPostFn(ctx, postID)
+func (s *commentRepoStub) ListByPost(ctx context.Context, postID, viewerID uint, sort string) ([]*models.Comment, error) {
+	return s.listByPostFn(ctx, postID, sort)
 }
 func (s *commentRepoStub) Update(ctx context.Context, comment *models.Comment) error {
 	return s.updateFn(ctx, comment)
@@ -36,14 +39,22 @@ func (s *commentRepoStub) Update(ctx context.Context, comment *models.Comment) e
 func (s *commentRepoStub) Delete(ctx context.Context, id uint) error {
 	return s.deleteFn(ctx, id)
 }
+func (s *commentRepoStub) Like(ctx context.Context, userID, commentID uint) error {
+	return s.likeFn(ctx, userID, commentID)
+}
+func (s *commentRepoStub) Unlike(ctx context.Context, userID, commentID uint) error {
+	return s.unlikeFn(ctx, userID, commentID)
+}
 
 func noopCommentRepo() *commentRepoStub {
 	return &commentRepoStub{
 		createFn:     func(_ context.Context, _ *models.Comment) error { return nil },
 		getByIDFn:    func(_ context.Context, _ uint) (*models.Comment, error) { return &models.Comment{}, nil },
-		listByPostFn: func(_ context.Context, _ uint) ([]*models.Comment, error) { return nil, nil },
+		listByPostFn: func(_ context.Context, _ uint, _ string) ([]*models.Comment, error) { return nil, nil },
 		updateFn:     func(_ context.Context, _ *models.Comment) error { return nil },
 		deleteFn:     func(_ context.Context, _ uint) error { return nil },
+		likeFn:       func(_ context.Context, _, _ uint) error { return nil },
+		unlikeFn:     func(_ context.Context, _, _ uint) error { return nil },
 	}
 }
 
@@ -105,6 +116,101 @@ func TestCommentService_CreateComment_Success(t *testing.T) {
 	assert.Equal(t, "hello", comment.Content)
 }
 
+func TestCommentService_CreateComment_Replies(t *testing.T) {
+	t.Parallel()
+
+	stored := map[uint]*models.Comment{
+		1: {ID: 1, PostID: 1, Depth: 0},
+		2: {ID: 2, PostID: 1, Depth: MaxCommentDepth - 1},
+		3: {ID: 3, PostID: 2, Depth: 0},
+	}
+	newRepo := func() (*commentRepoStub, **models.Comment) {
+		var created *models.Comment
+		repo := noopCommentRepo()
+		repo.createFn = func(_ context.Context, c *models.Comment) error {
+			c.ID = 99
+			created = c
+			return nil
+		}
+		repo.getByIDFn = func(_ context.Context, id uint) (*models.Comment, error) {
+			if id == 99 {
+				return created, nil
+			}
+			return stored[id], nil
+		}
+		return repo, &created
+	}
+	parent := func(id uint) *uint { return &id }
+
+	t.Run("reply is nested one level below its parent", func(t *testing.T) {
+		t.Parallel()
+		repo, _ := newRepo()
+		svc := NewCommentService(repo, noopPostRepo(), nil)
+		reply, err := svc.CreateComment(context.Background(), CreateCommentInput{
+			UserID: 1, PostID: 1, ParentCommentID: parent(1), Content: "reply",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, reply.ParentCommentID)
+		assert.Equal(t, uint(1), *reply.ParentCommentID)
+		assert.Equal(t, 1, reply.Depth)
+	})
+
+	t.Run("reply beyond the depth limit is rejected", func(t *testing.T) {
+		t.Parallel()
+		repo, created := newRepo()
+		svc := NewCommentService(repo, noopPostRepo(), nil)
+		_, err := svc.CreateComment(context.Background(), CreateCommentInput{
+			UserID: 1, PostID: 1, ParentCommentID: parent(2), Content: "too deep",
+		})
+		assertValidationError(t, err)
+		assert.Nil(t, *created)
+	})
+
+	t.Run("parent on another post is rejected", func(t *testing.T) {
+		t.Parallel()
+		repo, _ := newRepo()
+		svc := NewCommentService(repo, noopPostRepo(), nil)
+		_, err := svc.CreateComment(context.Background(), CreateCommentInput{
+			UserID: 1, PostID: 1, ParentCommentID: parent(3), Content: "wrong post",
+		})
+		assertValidationError(t, err)
+	})
+}
+
+func TestBuildCommentTree(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	parent := func(id uint) *uint { return &id }
+
+	// Newest first, as returned by ListByPost.
+	comments := []*models.Comment{
+		{ID: 6, ParentCommentID: parent(1), Depth: 1, CreatedAt: at(6)},
+		{ID: 5, ParentCommentID: parent(40), Depth: 1, CreatedAt: at(5)},
+		{ID: 4, ParentCommentID: parent(2), Depth: 2, CreatedAt: at(4)},
+		{ID: 3, CreatedAt: at(3)},
+		{ID: 2, ParentCommentID: parent(1), Depth: 1, CreatedAt: at(2)},
+		{ID: 1, CreatedAt: at(1)},
+	}
+
+	roots := BuildCommentTree(comments)
+	ids := func(cs []*models.Comment) []uint {
+		out := make([]uint, 0, len(cs))
+		for _, c := range cs {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	// Comment 5's parent is gone, so it surfaces at the top level.
+	assert.Equal(t, []uint{5, 3, 1}, ids(roots))
+	root1 := roots[2]
+	assert.Equal(t, []uint{2, 6}, ids(root1.Replies), "replies read oldest first")
+	assert.Equal(t, []uint{4}, ids(root1.Replies[0].Replies))
+	assert.Empty(t, roots[1].Replies)
+}
+
 func TestCommentService_UpdateComment_Ownership(t *testing.T) {
 	t.Parallel()
 
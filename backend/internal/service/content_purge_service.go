@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+	"sanctum/internal/observability"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// ContentPurgeInterval is how often the purge worker looks for expired soft-deletes.
+	ContentPurgeInterval = time.Hour
+	// ContentPurgeBatchSize caps how many posts, comments and audit entries one
+	// pass hard-deletes.
+	ContentPurgeBatchSize = 500
+)
+
+// ContentPurgeResult summarises one purge pass.
+type ContentPurgeResult struct {
+	Posts    int64 `json:"posts"`
+	Comments int64 `json:"comments"`
+	Images   int   `json:"images"`
+	// Accounts counts deactivated accounts whose reactivation window closed
+	// and whose original username and email were forgotten.
+	Accounts int64 `json:"accounts"`
+	// AuditEntries counts admin audit log entries older than their retention.
+	AuditEntries int64 `json:"audit_entries"`
+}
+
+// ContentPurgeService hard-deletes posts and comments that have been
+// soft-deleted for longer than the retention period, along with images that
+// are no longer referenced by anything once those posts are gone. It also
+// forgets the original identity of deactivated accounts once they can no
+// longer be reactivated, and drops admin audit entries past their retention.
+type ContentPurgeService struct {
+	db             *gorm.DB
+	uploadDir      string
+	retention      time.Duration
+	accountGrace   time.Duration
+	auditRetention time.Duration
+	workerOnce     sync.Once
+}
+
+// NewContentPurgeService returns a new ContentPurgeService. A zero
+// SOFT_DELETE_RETENTION_DAYS disables content purging, a zero
+// ACCOUNT_REACTIVATION_GRACE_DAYS leaves nothing for the account step and a
+// zero ADMIN_AUDIT_RETENTION_DAYS keeps the audit log forever.
+func NewContentPurgeService(db *gorm.DB, cfg *config.Config) *ContentPurgeService {
+	svc := &ContentPurgeService{db: db, uploadDir: DefaultImageUploadDir}
+	if cfg != nil {
+		if cfg.ImageUploadDir != "" {
+			svc.uploadDir = cfg.ImageUploadDir
+		}
+		svc.retention = time.Duration(cfg.SoftDeleteRetentionDays) * 24 * time.Hour
+		svc.accountGrace = time.Duration(cfg.AccountReactivationGraceDays) * 24 * time.Hour
+		svc.auditRetention = time.Duration(cfg.AdminAuditRetentionDays) * 24 * time.Hour
+	}
+	return svc
+}
+
+// Enabled reports whether a retention period or reactivation grace window is
+// configured.
+func (s *ContentPurgeService) Enabled() bool {
+	return s != nil && s.db != nil && (s.retention > 0 || s.accountGrace > 0 || s.auditRetention > 0)
+}
+
+// StartBackgroundWorker runs PurgeExpired every ContentPurgeInterval until ctx is done.
+func (s *ContentPurgeService) StartBackgroundWorker(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+	s.workerOnce.Do(func() {
+		go s.workerLoop(ctx)
+	})
+}
+
+func (s *ContentPurgeService) workerLoop(ctx context.Context) {
+	ticker := time.NewTicker(ContentPurgeInterval)
+	defer ticker.Stop()
+	for {
+		result, err := s.PurgeExpired(ctx, time.Now().UTC())
+		if err != nil && ctx.Err() == nil {
+			observability.GlobalLogger.ErrorContext(ctx, "content purge failed", slog.String("error", err.Error()))
+		} else if result.Posts > 0 || result.Comments > 0 || result.Images > 0 || result.Accounts > 0 || result.AuditEntries > 0 {
+			observability.GlobalLogger.InfoContext(ctx, "purged soft-deleted content",
+				slog.Int64("posts", result.Posts),
+				slog.Int64("comments", result.Comments),
+				slog.Int("images", result.Images),
+				slog.Int64("accounts", result.Accounts),
+				slog.Int64("audit_entries", result.AuditEntries),
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired hard-deletes posts and comments soft-deleted before
+// now-retention. Content that is still the target of an open moderation
+// report is kept until the report is resolved. Images attached to purged
+// posts are removed once no other post, avatar or message references them.
+// Deactivated accounts past their reactivation window are forgotten and
+// admin audit entries created before now-auditRetention are deleted.
+func (s *ContentPurgeService) PurgeExpired(ctx context.Context, now time.Time) (ContentPurgeResult, error) {
+	var result ContentPurgeResult
+	if !s.Enabled() {
+		return result, nil
+	}
+	if s.accountGrace > 0 {
+		res := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("deactivated_at IS NOT NULL AND deactivated_at < ?", now.Add(-s.accountGrace)).
+			Where("original_email <> '' OR original_username <> ''").
+			Updates(map[string]interface{}{"original_email": "", "original_username": ""})
+		if res.Error != nil {
+			return result, res.Error
+		}
+		result.Accounts = res.RowsAffected
+	}
+	if s.auditRetention > 0 {
+		var auditIDs []uint
+		if err := s.db.WithContext(ctx).Model(&models.AdminAuditLog{}).
+			Where("created_at < ?", now.Add(-s.auditRetention)).
+			Order("id ASC").
+			Limit(ContentPurgeBatchSize).
+			Pluck("id", &auditIDs).Error; err != nil {
+			return result, err
+		}
+		if len(auditIDs) > 0 {
+			res := s.db.WithContext(ctx).Where("id IN ?", auditIDs).Delete(&models.AdminAuditLog{})
+			if res.Error != nil {
+				return result, res.Error
+			}
+			result.AuditEntries = res.RowsAffected
+		}
+	}
+	if s.retention <= 0 {
+		return result, nil
+	}
+	cutoff := now.Add(-s.retention)
+	db := s.db.WithContext(ctx)
+
+	openReports := db.Model(&models.ModerationReport{}).
+		Select("target_id").
+		Where("target_type = ? AND status = ?", models.ReportTargetPost, models.ReportStatusOpen)
+
+	var posts []models.Post
+	if err := db.Unscoped().
+		Select("id", "user_id", "image_hash").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Where("id NOT IN (?)", openReports).
+		Order("id ASC").
+		Limit(ContentPurgeBatchSize).
+		Find(&posts).Error; err != nil {
+		return result, err
+	}
+
+	var commentIDs []uint
+	if err := db.Unscoped().Model(&models.Comment{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("id ASC").
+		Limit(ContentPurgeBatchSize).
+		Pluck("id", &commentIDs).Error; err != nil {
+		return result, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(commentIDs) > 0 {
+			res := tx.Unscoped().Where("id IN ?", commentIDs).Delete(&models.Comment{})
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Comments = res.RowsAffected
+		}
+		if len(posts) == 0 {
+			return nil
+		}
+		ids := make([]uint, 0, len(posts))
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		// Comments on a purged post go with it, whatever their own state.
+		res := tx.Unscoped().Where("post_id IN ?", ids).Delete(&models.Comment{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Comments += res.RowsAffected
+
+		res = tx.Unscoped().Where("id IN ?", ids).Delete(&models.Post{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Posts = res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	type imageClaim struct {
+		hash   string
+		userID uint
+	}
+	seen := make(map[imageClaim]bool)
+	for _, p := range posts {
+		claim := imageClaim{hash: p.ImageHash, userID: p.UserID}
+		if claim.hash == "" || seen[claim] {
+			continue
+		}
+		seen[claim] = true
+		removed, err := s.removeImageIfUnreferenced(ctx, claim.hash, claim.userID)
+		if err != nil {
+			return result, err
+		}
+		if removed {
+			result.Images++
+		}
+	}
+	return result, nil
+}
+
+// removeImageIfUnreferenced releases authorID's claim on the image once none
+// of their posts (including soft-deleted ones still inside retention), avatar
+// or messages point at hash any more. The record and its files are deleted
+// when no post, avatar or message of anyone references it and no other
+// uploader of the same bytes still holds a claim.
+func (s *ContentPurgeService) removeImageIfUnreferenced(ctx context.Context, hash string, authorID uint) (bool, error) {
+	if !isValidImageHash(hash) {
+		return false, nil
+	}
+	db := s.db.WithContext(ctx)
+
+	var img models.Image
+	if err := db.Where("hash = ?", hash).First(&img).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	authorRefs, err := s.countImageReferences(ctx, hash, authorID)
+	if err != nil {
+		return false, err
+	}
+	if authorRefs == 0 {
+		if err := db.Where("image_id = ? AND user_id = ?", img.ID, authorID).
+			Delete(&models.ImageUploader{}).Error; err != nil {
+			return false, err
+		}
+	}
+
+	refs, err := s.countImageReferences(ctx, hash, 0)
+	if err != nil || refs > 0 {
+		return false, err
+	}
+	// Deduplicated uploads share one record; an uploader who has not used
+	// it yet still needs the files.
+	var claims int64
+	if err := db.Model(&models.ImageUploader{}).Where("image_id = ?", img.ID).Count(&claims).Error; err != nil {
+		return false, err
+	}
+	if claims > 0 {
+		return false, nil
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("image_id = ?", img.ID).Delete(&models.ImageVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("image_id = ?", img.ID).Delete(&models.ImageUploader{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&img).Error
+	}); err != nil {
+		return false, err
+	}
+	if err := os.RemoveAll(filepath.Join(s.uploadDir, hash)); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// countImageReferences counts posts (including soft-deleted ones), avatars and
+// messages pointing at hash. A non-zero userID counts only that user's.
+func (s *ContentPurgeService) countImageReferences(ctx context.Context, hash string, userID uint) (int64, error) {
+	db := s.db.WithContext(ctx)
+	pattern := "%" + hash + "%"
+	scope := func(column string) func(*gorm.DB) *gorm.DB {
+		return func(q *gorm.DB) *gorm.DB {
+			if userID != 0 {
+				return q.Where(column+" = ?", userID)
+			}
+			return q
+		}
+	}
+
+	var total, refs int64
+	if err := db.Unscoped().Model(&models.Post{}).Scopes(scope("user_id")).
+		Where("image_hash = ?", hash).Count(&refs).Error; err != nil {
+		return 0, err
+	}
+	total += refs
+	if err := db.Model(&models.User{}).Scopes(scope("id")).
+		Where("avatar LIKE ?", pattern).Count(&refs).Error; err != nil {
+		return 0, err
+	}
+	total += refs
+	if err := db.Model(&models.Conversation{}).Scopes(scope("created_by")).
+		Where("avatar LIKE ?", pattern).Count(&refs).Error; err != nil {
+		return 0, err
+	}
+	total += refs
+	if err := db.Model(&models.Message{}).Scopes(scope("sender_id")).
+		Where("content LIKE ? OR CAST(metadata AS TEXT) LIKE ?", pattern, pattern).
+		Count(&refs).Error; err != nil {
+		return 0, err
+	}
+	return total + refs, nil
+}
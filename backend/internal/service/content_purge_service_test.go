@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupContentPurgeTest(t *testing.T) (*gorm.DB, *ContentPurgeService, string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Post{},
+		&models.Comment{},
+		&models.Conversation{},
+		&models.Message{},
+		&models.ModerationReport{},
+		&models.ImageVariant{},
+	))
+	createSQLiteImagesTable(t, db)
+	uploadDir := t.TempDir()
+	svc := NewContentPurgeService(db, &config.Config{ImageUploadDir: uploadDir, SoftDeleteRetentionDays: 30})
+	return db, svc, uploadDir
+}
+
+// createSQLiteImagesTable creates the images table by hand because
+// images.uploaded_at defaults to now(), which sqlite cannot parse.
+func createSQLiteImagesTable(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	require.NoError(t, db.Exec(`CREATE TABLE images (
+		id integer PRIMARY KEY AUTOINCREMENT, hash text NOT NULL UNIQUE, content_hash text,
+		user_id integer NOT NULL,
+		original_filename text NOT NULL, mime_type text NOT NULL, size_bytes integer NOT NULL DEFAULT 0,
+		width integer NOT NULL DEFAULT 0, height integer NOT NULL DEFAULT 0, original_path text NOT NULL,
+		thumbnail_path text NOT NULL, medium_path text NOT NULL, status text NOT NULL DEFAULT 'ready',
+		blurhash text, error text, crop_mode text NOT NULL DEFAULT 'free', crop_x integer NOT NULL DEFAULT 0,
+		crop_y integer NOT NULL DEFAULT 0, crop_w integer NOT NULL DEFAULT 0, crop_h integer NOT NULL DEFAULT 0,
+		processing_started_at datetime, processing_attempts integer NOT NULL DEFAULT 0,
+		uploaded_at datetime NOT NULL, last_accessed_at datetime, created_at datetime, updated_at datetime
+	)`).Error)
+	require.NoError(t, db.AutoMigrate(&models.ImageUploader{}))
+}
+
+func createPurgeImage(t *testing.T, db *gorm.DB, uploadDir string, userID uint, hash string) *models.Image {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(uploadDir, hash), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadDir, hash, "master.jpg"), []byte("jpg"), 0o600))
+	img := &models.Image{
+		Hash:             hash,
+		UserID:           userID,
+		OriginalFilename: "x.jpg",
+		MimeType:         "image/jpeg",
+		OriginalPath:     hash + "/master.jpg",
+		ThumbnailPath:    hash + "/master.jpg",
+		MediumPath:       hash + "/master.jpg",
+		UploadedAt:       time.Now().UTC(),
+	}
+	require.NoError(t, db.Create(img).Error)
+	require.NoError(t, db.Create(&models.ImageUploader{ImageID: img.ID, UserID: userID}).Error)
+	return img
+}
+
+func softDeleteAt(t *testing.T, db *gorm.DB, model any, id uint, at time.Time) {
+	t.Helper()
+	require.NoError(t, db.Unscoped().Model(model).Where("id = ?", id).Update("deleted_at", at).Error)
+}
+
+func TestContentPurgeService_PurgesExpiredAndRetainsRecent(t *testing.T) {
+	db, svc, uploadDir := setupContentPurgeTest(t)
+	now := time.Now().UTC()
+	longAgo := now.Add(-45 * 24 * time.Hour)
+	recently := now.Add(-2 * 24 * time.Hour)
+
+	author := models.User{Username: "author", Email: "author@e.com"}
+	require.NoError(t, db.Create(&author).Error)
+
+	expiredHash := strings.Repeat("a", 64)
+	sharedHash := strings.Repeat("b", 64)
+	createPurgeImage(t, db, uploadDir, author.ID, expiredHash)
+	createPurgeImage(t, db, uploadDir, author.ID, sharedHash)
+
+	expired := models.Post{Title: "old", Content: "old", UserID: author.ID, ImageHash: expiredHash}
+	expiredShared := models.Post{Title: "old shared", Content: "old", UserID: author.ID, ImageHash: sharedHash}
+	recent := models.Post{Title: "recent", Content: "recent", UserID: author.ID, ImageHash: sharedHash}
+	live := models.Post{Title: "live", Content: "live", UserID: author.ID}
+	reported := models.Post{Title: "reported", Content: "reported", UserID: author.ID}
+	for _, p := range []*models.Post{&expired, &expiredShared, &recent, &live, &reported} {
+		require.NoError(t, db.Create(p).Error)
+	}
+	softDeleteAt(t, db, &models.Post{}, expired.ID, longAgo)
+	softDeleteAt(t, db, &models.Post{}, expiredShared.ID, longAgo)
+	softDeleteAt(t, db, &models.Post{}, recent.ID, recently)
+	softDeleteAt(t, db, &models.Post{}, reported.ID, longAgo)
+	require.NoError(t, db.Create(&models.ModerationReport{
+		ReporterID: author.ID,
+		TargetType: models.ReportTargetPost,
+		TargetID:   reported.ID,
+		Reason:     "spam",
+		Status:     models.ReportStatusOpen,
+	}).Error)
+
+	oldComment := models.Comment{Content: "old", PostID: live.ID, UserID: author.ID}
+	recentComment := models.Comment{Content: "recent", PostID: live.ID, UserID: author.ID}
+	onExpiredPost := models.Comment{Content: "child", PostID: expired.ID, UserID: author.ID}
+	for _, c := range []*models.Comment{&oldComment, &recentComment, &onExpiredPost} {
+		require.NoError(t, db.Create(c).Error)
+	}
+	softDeleteAt(t, db, &models.Comment{}, oldComment.ID, longAgo)
+	softDeleteAt(t, db, &models.Comment{}, recentComment.ID, recently)
+
+	result, err := svc.PurgeExpired(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Posts)
+	assert.Equal(t, int64(2), result.Comments)
+	assert.Equal(t, 1, result.Images)
+
+	postExists := func(id uint) bool {
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&models.Post{}).Where("id = ?", id).Count(&count).Error)
+		return count > 0
+	}
+	assert.False(t, postExists(expired.ID))
+	assert.False(t, postExists(expiredShared.ID))
+	assert.True(t, postExists(recent.ID), "soft-deleted inside retention must be kept")
+	assert.True(t, postExists(live.ID))
+	assert.True(t, postExists(reported.ID), "posts with open reports must be kept")
+
+	var remainingComments []uint
+	require.NoError(t, db.Unscoped().Model(&models.Comment{}).Order("id").Pluck("id", &remainingComments).Error)
+	assert.Equal(t, []uint{recentComment.ID}, remainingComments)
+
+	// Only the image nothing else references is collected.
+	var images []string
+	require.NoError(t, db.Model(&models.Image{}).Order("hash").Pluck("hash", &images).Error)
+	assert.Equal(t, []string{sharedHash}, images)
+	_, statErr := os.Stat(filepath.Join(uploadDir, expiredHash))
+	assert.True(t, os.IsNotExist(statErr))
+	_, statErr = os.Stat(filepath.Join(uploadDir, sharedHash, "master.jpg"))
+	assert.NoError(t, statErr)
+}
+
+func TestContentPurgeService_KeepsSharedImageFiles(t *testing.T) {
+	db, svc, uploadDir := setupContentPurgeTest(t)
+	now := time.Now().UTC()
+
+	author := models.User{Username: "author", Email: "author@e.com"}
+	other := models.User{Username: "other", Email: "other@e.com"}
+	require.NoError(t, db.Create(&author).Error)
+	require.NoError(t, db.Create(&other).Error)
+	hash := strings.Repeat("c", 64)
+	img := createPurgeImage(t, db, uploadDir, author.ID, hash)
+	// A second upload of the same bytes was deduplicated onto this record.
+	require.NoError(t, db.Create(&models.ImageUploader{ImageID: img.ID, UserID: other.ID}).Error)
+
+	post := models.Post{Title: "old", Content: "old", UserID: author.ID, ImageHash: hash}
+	require.NoError(t, db.Create(&post).Error)
+	softDeleteAt(t, db, &models.Post{}, post.ID, now.Add(-45*24*time.Hour))
+
+	for i := 0; i < 2; i++ {
+		result, err := svc.PurgeExpired(context.Background(), now)
+		require.NoError(t, err)
+		assert.Zero(t, result.Images)
+	}
+
+	// Only the author's claim is released; the other uploader keeps the files.
+	var uploaders []uint
+	require.NoError(t, db.Model(&models.ImageUploader{}).Where("image_id = ?", img.ID).Pluck("user_id", &uploaders).Error)
+	assert.Equal(t, []uint{other.ID}, uploaders)
+	_, statErr := os.Stat(filepath.Join(uploadDir, hash, "master.jpg"))
+	assert.NoError(t, statErr)
+
+	// Once the other uploader's post is purged too, the image goes.
+	otherPost := models.Post{Title: "dup", Content: "dup", UserID: other.ID, ImageHash: hash}
+	require.NoError(t, db.Create(&otherPost).Error)
+	softDeleteAt(t, db, &models.Post{}, otherPost.ID, now.Add(-45*24*time.Hour))
+	result, err := svc.PurgeExpired(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Images)
+	_, statErr = os.Stat(filepath.Join(uploadDir, hash))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestContentPurgeService_DisabledWithoutRetention(t *testing.T) {
+	db, _, uploadDir := setupContentPurgeTest(t)
+	svc := NewContentPurgeService(db, &config.Config{ImageUploadDir: uploadDir})
+	assert.False(t, svc.Enabled())
+
+	author := models.User{Username: "author", Email: "author@e.com"}
+	require.NoError(t, db.Create(&author).Error)
+	post := models.Post{Title: "old", Content: "old", UserID: author.ID}
+	require.NoError(t, db.Create(&post).Error)
+	softDeleteAt(t, db, &models.Post{}, post.ID, time.Now().UTC().Add(-365*24*time.Hour))
+
+	result, err := svc.PurgeExpired(context.Background(), time.Now().UTC())
+	require.NoError(t, err)
+	assert.Zero(t, result.Posts)
+
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&models.Post{}).Where("id = ?", post.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestContentPurgeService_PurgesAuditEntriesPastRetention(t *testing.T) {
+	db, _, uploadDir := setupContentPurgeTest(t)
+	require.NoError(t, db.AutoMigrate(&models.AdminAuditLog{}))
+	svc := NewContentPurgeService(db, &config.Config{ImageUploadDir: uploadDir, AdminAuditRetentionDays: 90})
+	assert.True(t, svc.Enabled())
+
+	now := time.Now().UTC()
+	expired := models.AdminAuditLog{ActorUserID: 1, Action: models.AuditActionUserBan, TargetType: "user", TargetID: 2, CreatedAt: now.Add(-91 * 24 * time.Hour)}
+	recent := models.AdminAuditLog{ActorUserID: 1, Action: models.AuditActionUserUnban, TargetType: "user", TargetID: 2, CreatedAt: now.Add(-89 * 24 * time.Hour)}
+	require.NoError(t, db.Create(&expired).Error)
+	require.NoError(t, db.Create(&recent).Error)
+
+	result, err := svc.PurgeExpired(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.AuditEntries)
+
+	var remaining []uint
+	require.NoError(t, db.Model(&models.AdminAuditLog{}).Pluck("id", &remaining).Error)
+	assert.Equal(t, []uint{recent.ID}, remaining)
+}
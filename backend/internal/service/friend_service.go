@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 
 	"sanctum/internal/models"
 	"sanctum/internal/repository"
@@ -31,6 +32,14 @@ func (s *FriendService) SendFriendRequest(ctx context.Context, userID, targetUse
 		return nil, err
 	}
 
+	blocked, err := s.friendRepo.AreBlocked(ctx, userID, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, models.NewForbiddenError("Cannot send a friend request to this user")
+	}
+
 	existing, err := s.friendRepo.GetFriendshipBetweenUsers(ctx, userID, targetUserID)
 	if err != nil {
 		return nil, err
@@ -116,6 +125,31 @@ func (s *FriendService) GetFriends(ctx context.Context, userID uint) ([]models.U
 	return s.friendRepo.GetFriends(ctx, userID)
 }
 
+// MaxFriendSuggestions caps how many suggestions a single request returns.
+const MaxFriendSuggestions = 50
+
+// GetFriendSuggestions returns people the user may know, ranked by mutual friends.
+func (s *FriendService) GetFriendSuggestions(ctx context.Context, userID uint, limit, offset int) ([]models.FriendSuggestion, error) {
+	if limit <= 0 || limit > MaxFriendSuggestions {
+		limit = MaxFriendSuggestions
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.friendRepo.GetFriendSuggestions(ctx, userID, limit, offset)
+}
+
+// GetMutualFriends returns the friends the user shares with targetUserID.
+func (s *FriendService) GetMutualFriends(ctx context.Context, userID, targetUserID uint) ([]models.User, error) {
+	if userID == targetUserID {
+		return nil, models.NewValidationError("Cannot list mutual friends with yourself")
+	}
+	if _, err := s.userRepo.GetByID(ctx, targetUserID); err != nil {
+		return nil, err
+	}
+	return s.friendRepo.GetMutualFriends(ctx, userID, targetUserID)
+}
+
 // GetFriendshipStatus returns the friendship status between two users.
 func (s *FriendService) GetFriendshipStatus(ctx context.Context, userID, targetUserID uint) (string, uint, *models.Friendship, error) {
 	if _, err := s.userRepo.GetByID(ctx, targetUserID); err != nil {
@@ -127,25 +161,90 @@ func (s *FriendService) GetFriendshipStatus(ctx context.Context, userID, targetU
 		return "", 0, nil, err
 	}
 
-	status := "none"
-	var requestID uint
-	if friendship != nil {
-		switch friendship.Status {
-		case models.FriendshipStatusAccepted:
-			status = "friends"
-		case models.FriendshipStatusPending:
-			requestID = friendship.ID
-			if friendship.RequesterID == userID {
-				status = "pending_sent"
-			} else {
-				status = "pending_received"
-			}
-		default:
-			status = string(friendship.Status)
+	status, requestID := friendshipStatusFor(userID, friendship)
+	return status, requestID, friendship, nil
+}
+
+// MaxFriendshipStatusBatch caps how many users a single batch status lookup may include.
+const MaxFriendshipStatusBatch = 100
+
+// FriendshipStatusEntry is one user's relationship to the requester in a batch lookup.
+type FriendshipStatusEntry struct {
+	UserID    uint   `json:"user_id"`
+	Status    string `json:"status"`
+	RequestID uint   `json:"request_id,omitempty"`
+}
+
+// GetFriendshipStatuses returns the requester's relationship to each target user.
+// Blocks in either direction report "blocked"; unknown users report "none".
+func (s *FriendService) GetFriendshipStatuses(ctx context.Context, userID uint, targetUserIDs []uint) ([]FriendshipStatusEntry, error) {
+	if len(targetUserIDs) == 0 {
+		return nil, models.NewValidationError("user_ids is required")
+	}
+
+	seen := make(map[uint]bool, len(targetUserIDs))
+	ids := make([]uint, 0, len(targetUserIDs))
+	for _, id := range targetUserIDs {
+		if id == 0 || seen[id] {
+			continue
 		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) > MaxFriendshipStatusBatch {
+		return nil, models.NewValidationError(fmt.Sprintf("At most %d user_ids may be requested", MaxFriendshipStatusBatch))
 	}
 
-	return status, requestID, friendship, nil
+	friendships, err := s.friendRepo.GetFriendshipsWithUsers(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	blockedIDs, err := s.friendRepo.GetBlockedUserIDs(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint]*models.Friendship, len(friendships))
+	for i := range friendships {
+		other := friendships[i].AddresseeID
+		if other == userID {
+			other = friendships[i].RequesterID
+		}
+		byUser[other] = &friendships[i]
+	}
+	blocked := make(map[uint]bool, len(blockedIDs))
+	for _, id := range blockedIDs {
+		blocked[id] = true
+	}
+
+	entries := make([]FriendshipStatusEntry, 0, len(ids))
+	for _, id := range ids {
+		if blocked[id] {
+			entries = append(entries, FriendshipStatusEntry{UserID: id, Status: "blocked"})
+			continue
+		}
+		status, requestID := friendshipStatusFor(userID, byUser[id])
+		entries = append(entries, FriendshipStatusEntry{UserID: id, Status: status, RequestID: requestID})
+	}
+
+	return entries, nil
+}
+
+func friendshipStatusFor(userID uint, friendship *models.Friendship) (string, uint) {
+	if friendship == nil {
+		return "none", 0
+	}
+	switch friendship.Status {
+	case models.FriendshipStatusAccepted:
+		return "friends", 0
+	case models.FriendshipStatusPending:
+		if friendship.RequesterID == userID {
+			return "pending_sent", friendship.ID
+		}
+		return "pending_received", friendship.ID
+	default:
+		return string(friendship.Status), 0
+	}
 }
 
 // RemoveFriend removes the friendship between two users.
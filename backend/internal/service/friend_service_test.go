@@ -4,20 +4,30 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 type friendRepoStub struct {
 	createFn                    func(context.Context, *models.Friendship) error
 	getByIDFn                   func(context.Context, uint) (*models.Friendship, error)
 	getFriendshipBetweenUsersFn func(context.Context, uint, uint) (*models.Friendship, error)
+	getFriendshipsWithUsersFn   func(context.Context, uint, []uint) ([]models.Friendship, error)
+	getBlockedUserIDsFn         func(context.Context, uint, []uint) ([]uint, error)
+	areBlockedFn                func(context.Context, uint, uint) (bool, error)
 	getFriendsFn                func(context.Context, uint) ([]models.User, error)
 	getPendingRequestsFn        func(context.Context, uint) ([]models.Friendship, error)
 	getSentRequestsFn           func(context.Context, uint) ([]models.Friendship, error)
 	updateStatusFn              func(context.Context, uint, models.FriendshipStatus) error
 	deleteFn                    func(context.Context, uint) error
 	removeFriendshipFn          func(context.Context, uint, uint) error
+	getFriendSuggestionsFn      func(context.Context, uint, int, int) ([]models.FriendSuggestion, error)
+	getMutualFriendsFn          func(context.Context, uint, uint) ([]models.User, error)
 }
 
 func (s *friendRepoStub) Create(ctx context.Context, friendship *models.Friendship) error {
@@ -29,6 +39,15 @@ func (s *friendRepoStub) GetByID(ctx context.Context, id uint) (*models.Friendsh
 func (s *friendRepoStub) GetFriendshipBetweenUsers(ctx context.Context, userID1, userID2 uint) (*models.Friendship, error) {
 	return s.getFriendshipBetweenUsersFn(ctx, userID1, userID2)
 }
+func (s *friendRepoStub) GetFriendshipsWithUsers(ctx context.Context, userID uint, otherUserIDs []uint) ([]models.Friendship, error) {
+	return s.getFriendshipsWithUsersFn(ctx, userID, otherUserIDs)
+}
+func (s *friendRepoStub) GetBlockedUserIDs(ctx context.Context, userID uint, otherUserIDs []uint) ([]uint, error) {
+	return s.getBlockedUserIDsFn(ctx, userID, otherUserIDs)
+}
+func (s *friendRepoStub) AreBlocked(ctx context.Context, userID1, userID2 uint) (bool, error) {
+	return s.areBlockedFn(ctx, userID1, userID2)
+}
 func (s *friendRepoStub) GetFriends(ctx context.Context, userID uint) ([]models.User, error) {
 	return s.getFriendsFn(ctx, userID)
 }
@@ -47,6 +66,12 @@ func (s *friendRepoStub) Delete(ctx context.Context, friendshipID uint) error {
 func (s *friendRepoStub) RemoveFriendship(ctx context.Context, userID1, userID2 uint) error {
 	return s.removeFriendshipFn(ctx, userID1, userID2)
 }
+func (s *friendRepoStub) GetFriendSuggestions(ctx context.Context, userID uint, limit, offset int) ([]models.FriendSuggestion, error) {
+	return s.getFriendSuggestionsFn(ctx, userID, limit, offset)
+}
+func (s *friendRepoStub) GetMutualFriends(ctx context.Context, userID, otherUserID uint) ([]models.User, error) {
+	return s.getMutualFriendsFn(ctx, userID, otherUserID)
+}
 
 type userRepoStub struct {
 	getByIDFn          func(context.Context, uint) (*models.User, error)
@@ -58,6 +83,9 @@ type userRepoStub struct {
 	deleteFn           func(context.Context, uint) error
 	listFn             func(context.Context, int, int) ([]models.User, error)
 	searchFn           func(context.Context, string, int, int) ([]models.User, error)
+	suggestMentionsFn  func(context.Context, uint, string, time.Time, int, int) ([]models.MentionSuggestion, error)
+	deactivateFn       func(context.Context, uint, time.Time, bool) error
+	reactivateFn       func(context.Context, uint) (*models.User, error)
 }
 
 func (s *userRepoStub) GetByID(ctx context.Context, id uint) (*models.User, error) {
@@ -87,6 +115,15 @@ func (s *userRepoStub) List(ctx context.Context, limit, offset int) ([]models.Us
 func (s *userRepoStub) Search(ctx context.Context, q string, limit, offset int) ([]models.User, error) {
 	return s.searchFn(ctx, q, limit, offset)
 }
+func (s *userRepoStub) SuggestMentions(ctx context.Context, viewerID uint, prefix string, since time.Time, limit, offset int) ([]models.MentionSuggestion, error) {
+	return s.suggestMentionsFn(ctx, viewerID, prefix, since, limit, offset)
+}
+func (s *userRepoStub) Deactivate(ctx context.Context, id uint, at time.Time, restorable bool) error {
+	return s.deactivateFn(ctx, id, at, restorable)
+}
+func (s *userRepoStub) Reactivate(ctx context.Context, id uint) (*models.User, error) {
+	return s.reactivateFn(ctx, id)
+}
 
 func noopUserRepo() *userRepoStub {
 	return &userRepoStub{
@@ -99,6 +136,8 @@ func noopUserRepo() *userRepoStub {
 		deleteFn:           func(context.Context, uint) error { return nil },
 		listFn:             func(context.Context, int, int) ([]models.User, error) { return nil, nil },
 		searchFn:           func(context.Context, string, int, int) ([]models.User, error) { return nil, nil },
+		deactivateFn:       func(context.Context, uint, time.Time, bool) error { return nil },
+		reactivateFn:       func(context.Context, uint) (*models.User, error) { return &models.User{}, nil },
 	}
 }
 
@@ -107,12 +146,17 @@ func noopFriendRepo() *friendRepoStub {
 		createFn:                    func(context.Context, *models.Friendship) error { return nil },
 		getByIDFn:                   func(context.Context, uint) (*models.Friendship, error) { return &models.Friendship{}, nil },
 		getFriendshipBetweenUsersFn: func(context.Context, uint, uint) (*models.Friendship, error) { return nil, nil },
+		getFriendshipsWithUsersFn:   func(context.Context, uint, []uint) ([]models.Friendship, error) { return nil, nil },
+		getBlockedUserIDsFn:         func(context.Context, uint, []uint) ([]uint, error) { return nil, nil },
+		areBlockedFn:                func(context.Context, uint, uint) (bool, error) { return false, nil },
 		getFriendsFn:                func(context.Context, uint) ([]models.User, error) { return nil, nil },
 		getPendingRequestsFn:        func(context.Context, uint) ([]models.Friendship, error) { return nil, nil },
 		getSentRequestsFn:           func(context.Context, uint) ([]models.Friendship, error) { return nil, nil },
 		updateStatusFn:              func(context.Context, uint, models.FriendshipStatus) error { return nil },
 		deleteFn:                    func(context.Context, uint) error { return nil },
 		removeFriendshipFn:          func(context.Context, uint, uint) error { return nil },
+		getFriendSuggestionsFn:      func(context.Context, uint, int, int) ([]models.FriendSuggestion, error) { return nil, nil },
+		getMutualFriendsFn:          func(context.Context, uint, uint) ([]models.User, error) { return nil, nil },
 	}
 }
 
@@ -169,3 +213,152 @@ func TestFriendServiceRemoveFriendNotAccepted(t *testing.T) {
 		t.Fatalf("expected not-found app error, got %#v", err)
 	}
 }
+
+func TestFriendServiceGetFriendshipStatuses(t *testing.T) {
+	repo := noopFriendRepo()
+	repo.getFriendshipsWithUsersFn = func(context.Context, uint, []uint) ([]models.Friendship, error) {
+		return []models.Friendship{
+			{ID: 20, RequesterID: 1, AddresseeID: 2, Status: models.FriendshipStatusAccepted},
+			{ID: 21, RequesterID: 1, AddresseeID: 3, Status: models.FriendshipStatusPending},
+			{ID: 22, RequesterID: 4, AddresseeID: 1, Status: models.FriendshipStatusPending},
+			{ID: 23, RequesterID: 6, AddresseeID: 1, Status: models.FriendshipStatusAccepted},
+		}, nil
+	}
+	repo.getBlockedUserIDsFn = func(context.Context, uint, []uint) ([]uint, error) {
+		return []uint{6}, nil
+	}
+
+	svc := NewFriendService(repo, noopUserRepo())
+	entries, err := svc.GetFriendshipStatuses(context.Background(), 1, []uint{2, 3, 4, 5, 6, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []FriendshipStatusEntry{
+		{UserID: 2, Status: "friends"},
+		{UserID: 3, Status: "pending_sent", RequestID: 21},
+		{UserID: 4, Status: "pending_received", RequestID: 22},
+		{UserID: 5, Status: "none"},
+		{UserID: 6, Status: "blocked"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %#v", len(want), len(entries), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Fatalf("entry %d: expected %#v, got %#v", i, want[i], entries[i])
+		}
+	}
+}
+
+func TestFriendServiceGetFriendshipStatusesCap(t *testing.T) {
+	svc := NewFriendService(noopFriendRepo(), noopUserRepo())
+
+	ids := make([]uint, MaxFriendshipStatusBatch+1)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+	}
+	_, err := svc.GetFriendshipStatuses(context.Background(), 1000, ids)
+	var appErr *models.AppError
+	if !errors.As(err, &appErr) || appErr.Code != "VALIDATION_ERROR" {
+		t.Fatalf("expected validation app error, got %#v", err)
+	}
+
+	if _, err := svc.GetFriendshipStatuses(context.Background(), 1000, ids[:MaxFriendshipStatusBatch]); err != nil {
+		t.Fatalf("expected batch at the cap to succeed, got %v", err)
+	}
+}
+
+func TestFriendServiceSendFriendRequestBlocked(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Friendship{}, &models.UserBlock{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	blocker := &models.User{Username: "blocker", Email: "blocker@e.com"}
+	blocked := &models.User{Username: "blocked", Email: "blocked@e.com"}
+	for _, u := range []*models.User{blocker, blocked} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	if err := db.Create(&models.UserBlock{BlockerID: blocker.ID, BlockedID: blocked.ID}).Error; err != nil {
+		t.Fatalf("create block: %v", err)
+	}
+
+	svc := NewFriendService(repository.NewFriendRepository(db), repository.NewUserRepository(db))
+	for _, tc := range []struct {
+		name     string
+		from, to uint
+	}{
+		{name: "blocker to blocked", from: blocker.ID, to: blocked.ID},
+		{name: "blocked to blocker", from: blocked.ID, to: blocker.ID},
+	} {
+		_, err := svc.SendFriendRequest(context.Background(), tc.from, tc.to)
+		var appErr *models.AppError
+		if !errors.As(err, &appErr) || appErr.Code != "FORBIDDEN" {
+			t.Fatalf("%s: expected forbidden app error, got %#v", tc.name, err)
+		}
+	}
+
+	var count int64
+	db.Model(&models.Friendship{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected no friendship rows, got %d", count)
+	}
+}
+
+func TestFriendServiceSuggestionsSkipDeactivatedUsers(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Friendship{}, &models.UserBlock{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	me := &models.User{Username: "me", Email: "me@e.com"}
+	friend := &models.User{Username: "friend", Email: "friend@e.com"}
+	stays := &models.User{Username: "stays", Email: "stays@e.com"}
+	leaves := &models.User{Username: "leaves", Email: "leaves@e.com"}
+	for _, u := range []*models.User{me, friend, stays, leaves} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	for _, f := range []models.Friendship{
+		{RequesterID: me.ID, AddresseeID: friend.ID, Status: models.FriendshipStatusAccepted},
+		{RequesterID: friend.ID, AddresseeID: stays.ID, Status: models.FriendshipStatusAccepted},
+		{RequesterID: leaves.ID, AddresseeID: friend.ID, Status: models.FriendshipStatusAccepted},
+	} {
+		if err := db.Create(&f).Error; err != nil {
+			t.Fatalf("create friendship: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	svc := NewFriendService(repository.NewFriendRepository(db), userRepo)
+	suggestedIDs := func() []uint {
+		suggestions, err := svc.GetFriendSuggestions(ctx, me.ID, 10, 0)
+		if err != nil {
+			t.Fatalf("suggestions: %v", err)
+		}
+		ids := make([]uint, 0, len(suggestions))
+		for _, s := range suggestions {
+			ids = append(ids, s.User.ID)
+		}
+		return ids
+	}
+
+	if got := suggestedIDs(); len(got) != 2 {
+		t.Fatalf("expected both friends-of-friends before deactivation, got %v", got)
+	}
+	if err := userRepo.Deactivate(ctx, leaves.ID, time.Now(), true); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if got := suggestedIDs(); len(got) != 1 || got[0] != stays.ID {
+		t.Fatalf("expected only user %d after deactivation, got %v", stays.ID, got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"sync"
 	"time"
 
 	"sanctum/internal/models"
@@ -16,6 +17,15 @@ import (
 
 const pendingRoomMaxIdle = 10 * time.Minute
 
+const (
+	// AbandonedGameInterval is how often the cleanup worker looks for
+	// abandoned rooms.
+	AbandonedGameInterval = time.Minute
+	// StalledGameMaxIdle is how long an active room may go without a move
+	// before the cleanup worker cancels it.
+	StalledGameMaxIdle = 24 * time.Hour
+)
+
 func isPendingRoomStale(room models.GameRoom, now time.Time) bool {
 	if room.Status != models.GamePending {
 		return false
@@ -25,7 +35,9 @@ func isPendingRoomStale(room models.GameRoom, now time.Time) bool {
 
 // GameService provides game-room business logic.
 type GameService struct {
-	gameRepo repository.GameRepository
+	gameRepo    repository.GameRepository
+	onCancelled func(ctx context.Context, room *models.GameRoom)
+	workerOnce  sync.Once
 }
 
 // NewGameService returns a new GameService.
@@ -119,6 +131,64 @@ func (s *GameService) GetActiveGameRooms(_ context.Context, gameType *models.Gam
 	return filtered, nil
 }
 
+// SetCancelledHook registers a callback invoked for each room the cleanup
+// worker cancels.
+func (s *GameService) SetCancelledHook(fn func(ctx context.Context, room *models.GameRoom)) {
+	s.onCancelled = fn
+}
+
+// StartBackgroundWorker runs CancelAbandonedRooms every AbandonedGameInterval
+// until ctx is done.
+func (s *GameService) StartBackgroundWorker(ctx context.Context) {
+	if s.gameRepo == nil {
+		return
+	}
+	s.workerOnce.Do(func() {
+		go s.workerLoop(ctx)
+	})
+}
+
+func (s *GameService) workerLoop(ctx context.Context) {
+	ticker := time.NewTicker(AbandonedGameInterval)
+	defer ticker.Stop()
+	for {
+		cancelled, err := s.CancelAbandonedRooms(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			observability.GlobalLogger.ErrorContext(ctx, "abandoned game cleanup failed", slog.String("error", err.Error()))
+		} else if cancelled > 0 {
+			observability.GlobalLogger.InfoContext(ctx, "cancelled abandoned game rooms", slog.Int("rooms", cancelled))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CancelAbandonedRooms cancels pending rooms nobody joined within
+// pendingRoomMaxIdle and active rooms without a move for StalledGameMaxIdle,
+// running the cancelled hook for each. It returns how many were cancelled.
+func (s *GameService) CancelAbandonedRooms(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	for status, maxIdle := range map[models.GameStatus]time.Duration{
+		models.GamePending: pendingRoomMaxIdle,
+		models.GameActive:  StalledGameMaxIdle,
+	} {
+		rooms, err := s.gameRepo.CancelIdleRooms(status, now.Add(-maxIdle))
+		total += len(rooms)
+		if s.onCancelled != nil {
+			for i := range rooms {
+				s.onCancelled(ctx, &rooms[i])
+			}
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
 // GetGameStats returns game statistics for the user and game type.
 func (s *GameService) GetGameStats(_ context.Context, userID uint, gameType models.GameType) (*models.GameStats, error) {
 	stats, err := s.gameRepo.GetStats(userID, gameType)
@@ -128,6 +198,34 @@ func (s *GameService) GetGameStats(_ context.Context, userID uint, gameType mode
 	return stats, nil
 }
 
+// Leaderboard size bounds for GetLeaderboard.
+const (
+	DefaultLeaderboardLimit = 10
+	MaxLeaderboardLimit     = 100
+)
+
+// GetLeaderboard returns the top players for a game type by points, along with
+// userID's own rank.
+func (s *GameService) GetLeaderboard(_ context.Context, userID uint, gameType models.GameType, limit int) (*models.GameLeaderboard, error) {
+	switch gameType {
+	case models.ConnectFour, models.Othello, models.Battleship, models.Checkers:
+	default:
+		return nil, models.NewValidationError("unknown game type")
+	}
+	if limit <= 0 {
+		limit = DefaultLeaderboardLimit
+	}
+	if limit > MaxLeaderboardLimit {
+		limit = MaxLeaderboardLimit
+	}
+
+	board, err := s.gameRepo.GetLeaderboard(gameType, limit, userID)
+	if err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	return board, nil
+}
+
 // GetGameRoom returns a game room by ID.
 func (s *GameService) GetGameRoom(_ context.Context, roomID uint) (*models.GameRoom, error) {
 	room, err := s.gameRepo.GetRoom(roomID)
@@ -166,6 +264,9 @@ func (s *GameService) LeaveGameRoom(_ context.Context, userID, roomID uint) (*mo
 	room.NextTurnID = 0
 
 	if err := s.gameRepo.UpdateRoom(room); err != nil {
+		if errors.Is(err, models.ErrGameRoomConflict) {
+			return nil, false, models.NewConflictError("Game state changed, please retry")
+		}
 		return nil, false, models.NewInternalError(err)
 	}
 
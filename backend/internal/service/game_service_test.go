@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/repository"
 
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -23,6 +25,8 @@ type gameRepoStub struct {
 	getMovesFn                func(uint) ([]models.GameMove, error)
 	getStatsFn                func(uint, models.GameType) (*models.GameStats, error)
 	updateStatsFn             func(*models.GameStats) error
+	getLeaderboardFn          func(models.GameType, int, uint) (*models.GameLeaderboard, error)
+	cancelIdleRoomsFn         func(models.GameStatus, time.Time) ([]models.GameRoom, error)
 }
 
 func (s *gameRepoStub) CreateRoom(room *models.GameRoom) error {
@@ -55,6 +59,12 @@ func (s *gameRepoStub) GetStats(userID uint, gameType models.GameType) (*models.
 func (s *gameRepoStub) UpdateStats(stats *models.GameStats) error {
 	return s.updateStatsFn(stats)
 }
+func (s *gameRepoStub) GetLeaderboard(gameType models.GameType, limit int, userID uint) (*models.GameLeaderboard, error) {
+	return s.getLeaderboardFn(gameType, limit, userID)
+}
+func (s *gameRepoStub) CancelIdleRooms(status models.GameStatus, idleBefore time.Time) ([]models.GameRoom, error) {
+	return s.cancelIdleRoomsFn(status, idleBefore)
+}
 
 func noopGameRepo() *gameRepoStub {
 	return &gameRepoStub{
@@ -68,6 +78,10 @@ func noopGameRepo() *gameRepoStub {
 		getMovesFn:                func(uint) ([]models.GameMove, error) { return nil, nil },
 		getStatsFn:                func(uint, models.GameType) (*models.GameStats, error) { return &models.GameStats{}, nil },
 		updateStatsFn:             func(*models.GameStats) error { return nil },
+		getLeaderboardFn: func(gameType models.GameType, _ int, _ uint) (*models.GameLeaderboard, error) {
+			return &models.GameLeaderboard{GameType: gameType}, nil
+		},
+		cancelIdleRoomsFn: func(models.GameStatus, time.Time) ([]models.GameRoom, error) { return nil, nil },
 	}
 }
 
@@ -210,3 +224,78 @@ func TestGameServiceCreateGameRoomInitializesOthelloBoard(t *testing.T) {
 		t.Fatalf("unexpected initial othello board center pieces: %#v", board)
 	}
 }
+
+func TestGameService_CancelAbandonedRooms(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.GameRoom{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	creator := models.User{Username: "creator", Email: "creator@example.com", Password: "pw"}
+	opponent := models.User{Username: "opponent", Email: "opponent@example.com", Password: "pw"}
+	if err := db.Create(&creator).Error; err != nil {
+		t.Fatalf("create creator: %v", err)
+	}
+	if err := db.Create(&opponent).Error; err != nil {
+		t.Fatalf("create opponent: %v", err)
+	}
+
+	now := time.Now()
+	newRoom := func(status models.GameStatus, idle time.Duration) uint {
+		room := models.GameRoom{Type: models.ConnectFour, Status: status, CreatorID: &creator.ID, NextTurnID: creator.ID, CurrentState: "{}"}
+		if status == models.GameActive {
+			room.OpponentID = &opponent.ID
+		}
+		if err := db.Create(&room).Error; err != nil {
+			t.Fatalf("create room: %v", err)
+		}
+		if err := db.Model(&room).UpdateColumn("updated_at", now.Add(-idle)).Error; err != nil {
+			t.Fatalf("age room: %v", err)
+		}
+		return room.ID
+	}
+	oldPending := newRoom(models.GamePending, pendingRoomMaxIdle+time.Minute)
+	recentPending := newRoom(models.GamePending, time.Minute)
+	stalledActive := newRoom(models.GameActive, StalledGameMaxIdle+time.Hour)
+	liveActive := newRoom(models.GameActive, time.Hour)
+
+	svc := NewGameService(repository.NewGameRepository(db))
+	announced := map[uint]bool{}
+	svc.SetCancelledHook(func(_ context.Context, room *models.GameRoom) {
+		if room.Status != models.GameCancelled {
+			t.Errorf("hook saw room %d in status %s", room.ID, room.Status)
+		}
+		announced[room.ID] = true
+	})
+
+	n, err := svc.CancelAbandonedRooms(context.Background(), now)
+	if err != nil {
+		t.Fatalf("cancel abandoned rooms: %v", err)
+	}
+	if n != 2 || len(announced) != 2 || !announced[oldPending] || !announced[stalledActive] {
+		t.Fatalf("expected rooms %d and %d cancelled, got n=%d announced=%v", oldPending, stalledActive, n, announced)
+	}
+
+	for id, want := range map[uint]models.GameStatus{
+		oldPending:    models.GameCancelled,
+		recentPending: models.GamePending,
+		stalledActive: models.GameCancelled,
+		liveActive:    models.GameActive,
+	} {
+		var room models.GameRoom
+		if err := db.First(&room, id).Error; err != nil {
+			t.Fatalf("load room %d: %v", id, err)
+		}
+		if room.Status != want {
+			t.Errorf("room %d: expected status %s, got %s", id, want, room.Status)
+		}
+	}
+
+	// A second pass has nothing left to cancel.
+	if n, err := svc.CancelAbandonedRooms(context.Background(), now); err != nil || n != 0 {
+		t.Fatalf("second pass: expected 0 cancelled, got %d (err %v)", n, err)
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"sanctum/internal/models"
+	"sanctum/internal/observability"
+)
+
+// backfillBatchSize is how many images BackfillVariants loads per page.
+const backfillBatchSize = 100
+
+// BackfillVariantsInput controls a derivative backfill run.
+type BackfillVariantsInput struct {
+	// DryRun only counts the missing derivatives; nothing is written.
+	DryRun bool
+	// Concurrency caps how many images are processed at once; <= 0 means 1.
+	Concurrency int
+}
+
+// BackfillVariantsResult summarises a backfill run.
+type BackfillVariantsResult struct {
+	Scanned int `json:"scanned"`
+	// Updated is how many images were missing at least one derivative.
+	Updated int `json:"updated"`
+	// Generated is how many derivatives were written, or would be on a dry run.
+	Generated int `json:"generated"`
+	Failed    int `json:"failed"`
+}
+
+// BackfillVariants walks every ready image and generates the derivatives the
+// current sizes and formats call for but the image does not have yet, e.g.
+// after IMAGE_VARIANT_SIZES gains a width. Failures are logged and counted
+// so one bad master does not stop the run.
+func (s *ImageService) BackfillVariants(ctx context.Context, in BackfillVariantsInput) (BackfillVariantsResult, error) {
+	concurrency := in.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		result BackfillVariantsResult
+		mu     sync.Mutex
+	)
+	var afterID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		images, err := s.repo.ListReadyAfter(ctx, afterID, backfillBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(images) == 0 {
+			return result, nil
+		}
+		afterID = images[len(images)-1].ID
+
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range images {
+			img := &images[i]
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				generated, err := s.backfillImage(ctx, img, in.DryRun)
+
+				mu.Lock()
+				defer mu.Unlock()
+				result.Scanned++
+				if err != nil {
+					result.Failed++
+					observability.GlobalLogger.WarnContext(ctx, "image backfill failed",
+						slog.Uint64("image_id", uint64(img.ID)),
+						slog.String("hash", img.Hash),
+						slog.String("error", err.Error()),
+					)
+					return
+				}
+				if generated > 0 {
+					result.Updated++
+					result.Generated += generated
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// backfillImage generates img's missing derivatives and returns how many
+// there were.
+func (s *ImageService) backfillImage(ctx context.Context, img *models.Image, dryRun bool) (int, error) {
+	existing, err := s.repo.GetVariantsByImageID(ctx, img.ID)
+	if err != nil {
+		return 0, err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		have[variantSpec{size: v.SizePx, format: v.Format}.key()] = true
+	}
+
+	master, err := s.loadMaster(img)
+	if err != nil {
+		return 0, err
+	}
+	var missing []variantSpec
+	for _, spec := range s.plannedVariants(master.Bounds()) {
+		if !have[spec.key()] {
+			missing = append(missing, spec)
+		}
+	}
+	if dryRun || len(missing) == 0 {
+		return len(missing), nil
+	}
+	return len(missing), s.generateVariants(ctx, img, master, missing)
+}
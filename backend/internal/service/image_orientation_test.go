@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sanctum/internal/config"
+	"sanctum/internal/testutil"
+)
+
+// exifJPEG encodes a w×h JPEG whose left half is red and right half is blue,
+// with an APP1 EXIF block carrying the given orientation tag.
+func exifJPEG(t *testing.T, w, h, orientation int, order binary.ByteOrder) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			if x < w/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+
+	// TIFF header + IFD0 with a single SHORT orientation entry.
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], uint16(orientation))
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(payload)+2))
+	app1 = append(app1, payload...)
+
+	raw := encoded.Bytes()
+	out := append([]byte{}, raw[:2]...)
+	out = append(out, app1...)
+	return append(out, raw[2:]...)
+}
+
+func TestJPEGEXIFOrientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation int
+		order       binary.ByteOrder
+	}{
+		{name: "little endian 6", orientation: 6, order: binary.LittleEndian},
+		{name: "big endian 8", orientation: 8, order: binary.BigEndian},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := exifJPEG(t, 8, 4, tt.orientation, tt.order)
+			if got := jpegEXIFOrientation(data); got != tt.orientation {
+				t.Fatalf("expected orientation %d, got %d", tt.orientation, got)
+			}
+		})
+	}
+
+	if got := jpegEXIFOrientation(testutil.TinyPNG(t, 4, 4)); got != 1 {
+		t.Fatalf("expected non-JPEG input to be upright, got %d", got)
+	}
+}
+
+func TestImageServiceUploadAutoOrientsAndStripsEXIF(t *testing.T) {
+	tests := []struct {
+		orientation int
+		// Rotating 90° clockwise puts the red (left) half on top;
+		// counter-clockwise puts it at the bottom.
+		redOnTop bool
+	}{
+		{orientation: 6, redOnTop: true},
+		{orientation: 8, redOnTop: false},
+	}
+	for _, tt := range tests {
+		repo := testutil.NewImageRepoStub()
+		cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+		svc := NewImageService(repo, cfg)
+
+		img, err := svc.Upload(context.Background(), UploadImageInput{
+			UserID:      1,
+			Filename:    "phone.jpg",
+			ContentType: "image/jpeg",
+			Content:     exifJPEG(t, 400, 200, tt.orientation, binary.BigEndian),
+		})
+		if err != nil {
+			t.Fatalf("orientation %d: upload failed: %v", tt.orientation, err)
+		}
+		if img.Width >= img.Height {
+			t.Fatalf("orientation %d: expected portrait master after rotation, got %dx%d", tt.orientation, img.Width, img.Height)
+		}
+
+		master, err := os.ReadFile(filepath.Join(cfg.ImageUploadDir, img.Hash, "master.jpg"))
+		if err != nil {
+			t.Fatalf("orientation %d: read master: %v", tt.orientation, err)
+		}
+		if bytes.Contains(master, []byte("Exif\x00\x00")) {
+			t.Fatalf("orientation %d: master still carries an EXIF segment", tt.orientation)
+		}
+
+		decoded, err := jpeg.Decode(bytes.NewReader(master))
+		if err != nil {
+			t.Fatalf("orientation %d: decode master: %v", tt.orientation, err)
+		}
+		r, _, b, _ := decoded.At(decoded.Bounds().Dx()/2, 2).RGBA()
+		if gotRedOnTop := r > b; gotRedOnTop != tt.redOnTop {
+			t.Fatalf("orientation %d: expected red on top=%v (r=%d b=%d)", tt.orientation, tt.redOnTop, r, b)
+		}
+	}
+}
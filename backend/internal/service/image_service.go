@@ -10,11 +10,14 @@ import (
 	"image"
 	"image/draw"
 	"image/jpeg"
+	"image/png"
 	"log/slog"
 	"mime"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,14 +38,18 @@ const (
 	DefaultImageUploadDir = "/tmp/sanctum/uploads/images"
 	// DefaultImageMaxUploadSizeMB is the default max upload size in MB.
 	DefaultImageMaxUploadSizeMB = 10
+	// DefaultImageMaxMegapixels is the default decoded pixel budget per upload.
+	DefaultImageMaxMegapixels = 40
 	// MasterMaxSize is the max dimension in px for the master image.
 	MasterMaxSize = 2048
 	// OriginalMaxSize is the size label for the original (same as MasterMaxSize).
 	OriginalMaxSize = MasterMaxSize
-	// JPEGQuality is the quality for JPEG encoding (0-100).
-	JPEGQuality = 82
-	// WebPQuality is the quality for WebP encoding (0-100).
-	WebPQuality = 70
+	// DefaultJPEGQuality is the default quality for JPEG encoding (1-100).
+	DefaultJPEGQuality = 82
+	// DefaultWebPQuality is the default quality for WebP encoding (1-100).
+	DefaultWebPQuality = 70
+	// AVIFQuality is the quality for AVIF encoding (0-100).
+	AVIFQuality = 60
 )
 
 const (
@@ -54,7 +61,19 @@ const (
 	ImageSizeMedium = "medium"
 )
 
-var sizeLadder = []int{256, 640, 1080, 1440, 2048}
+const (
+	// ImageFormatAVIF is the variant format key for AVIF files.
+	ImageFormatAVIF = "avif"
+	// ImageFormatWebP is the variant format key for WebP files.
+	ImageFormatWebP = "webp"
+	// ImageFormatJPEG is the variant format key for JPEG files.
+	ImageFormatJPEG = "jpg"
+)
+
+const avifencBinary = "avifenc"
+
+// defaultVariantSizes is used when IMAGE_VARIANT_SIZES is unset.
+var defaultVariantSizes = []int{256, 640, 1080, 1440, 2048}
 
 var allowedRatios = []struct {
 	name  string
@@ -78,13 +97,30 @@ type ImageService struct {
 	repo               repository.ImageRepository
 	uploadDir          string
 	maxUploadSizeBytes int64
+	maxPixels          int64
 	workerOnce         sync.Once
+	// variantSizes is every width a derivative is generated at, ascending.
+	variantSizes []int
+	jpegQuality  int
+	webpQuality  int
+	webpVariants bool
+	concurrency  int
+	// avifEncoder produces the optional AVIF derivative; nil disables it.
+	avifEncoder     func(image.Image, int) ([]byte, error)
+	statusPublisher ImageStatusPublisher
+}
+
+// ImageStatusPublisher receives image processing status transitions so
+// clients can follow them without polling.
+type ImageStatusPublisher interface {
+	PublishImageStatus(ctx context.Context, hash, status, errMsg string) error
 }
 
 // NewImageService returns a new ImageService.
 func NewImageService(repo repository.ImageRepository, cfg *config.Config) *ImageService {
 	uploadDir := DefaultImageUploadDir
 	maxUploadSizeMB := DefaultImageMaxUploadSizeMB
+	maxMegapixels := DefaultImageMaxMegapixels
 
 	if cfg != nil {
 		if cfg.ImageUploadDir != "" {
@@ -93,22 +129,58 @@ func NewImageService(repo repository.ImageRepository, cfg *config.Config) *Image
 		if cfg.ImageMaxUploadSizeMB > 0 {
 			maxUploadSizeMB = cfg.ImageMaxUploadSizeMB
 		}
+		if cfg.ImageMaxMegapixels > 0 {
+			maxMegapixels = cfg.ImageMaxMegapixels
+		}
 	}
 
-	return &ImageService{
+	svc := &ImageService{
 		repo:               repo,
 		uploadDir:          uploadDir,
 		maxUploadSizeBytes: int64(maxUploadSizeMB) * 1024 * 1024,
+		maxPixels:          int64(maxMegapixels) * 1000 * 1000,
+		variantSizes:       defaultVariantSizes,
+		jpegQuality:        DefaultJPEGQuality,
+		webpQuality:        DefaultWebPQuality,
+		webpVariants:       true,
+		concurrency:        1,
 	}
+	if cfg != nil {
+		if sizes, err := cfg.ImageVariantSizeList(); err == nil && sizes != nil {
+			svc.variantSizes = sizes
+		}
+		if cfg.ImageJPEGQuality > 0 {
+			svc.jpegQuality = cfg.ImageJPEGQuality
+		}
+		if cfg.ImageWebPQuality > 0 {
+			svc.webpQuality = cfg.ImageWebPQuality
+		}
+		svc.webpVariants = !cfg.ImageSkipWebPVariants
+		if cfg.ImageWorkerConcurrency > 0 {
+			svc.concurrency = cfg.ImageWorkerConcurrency
+		}
+	}
+	if cfg != nil && cfg.ImageAVIFEnabled {
+		if _, err := exec.LookPath(avifencBinary); err != nil {
+			observability.GlobalLogger.Warn("IMAGE_AVIF_ENABLED is set but avifenc was not found; AVIF variants are disabled")
+		} else {
+			svc.avifEncoder = encodeAVIF
+		}
+	}
+	return svc
 }
 
-// StartBackgroundWorker starts the background image processing worker.
+// StartBackgroundWorker starts the background image processing workers. At
+// most IMAGE_WORKER_CONCURRENCY images are processed at once.
 func (s *ImageService) StartBackgroundWorker(ctx context.Context) {
 	if s.repo == nil {
 		return
 	}
 	s.workerOnce.Do(func() {
-		go s.workerLoop(ctx)
+		go s.maintenanceLoop(ctx)
+		for range s.concurrency {
+			go s.workerLoop(ctx)
+		}
 	})
 }
 
@@ -129,6 +201,36 @@ func (s *ImageService) Upload(ctx context.Context, in UploadImageInput) (*models
 		return nil, models.NewValidationError("Invalid image type")
 	}
 
+	// Identical bytes that were already processed are shared rather than
+	// decoded and encoded again, whoever uploaded them first. The caller is
+	// recorded as an uploader and gets the record back as their own upload.
+	contentHash := sha256Hex(in.Content)
+	if s.repo != nil {
+		existing, getErr := s.repo.GetReadyByContentHash(ctx, contentHash)
+		if getErr == nil {
+			if err := s.repo.AddUploader(ctx, existing.ID, in.UserID); err != nil {
+				return nil, models.NewInternalError(err)
+			}
+			shared := *existing
+			shared.UserID = in.UserID
+			shared.User = nil
+			return &shared, nil
+		}
+		if !errors.Is(getErr, gorm.ErrRecordNotFound) {
+			return nil, models.NewInternalError(getErr)
+		}
+	}
+
+	// Check the declared dimensions before decoding: a small, highly
+	// compressed file can otherwise expand to gigabytes of pixels.
+	header, _, err := image.DecodeConfig(bytes.NewReader(in.Content))
+	if err != nil {
+		return nil, models.NewValidationError("Invalid image file")
+	}
+	if int64(header.Width)*int64(header.Height) > s.maxPixels {
+		return nil, models.NewValidationError(fmt.Sprintf("Image dimensions too large (max %d megapixels)", s.maxPixels/(1000*1000)))
+	}
+
 	decoded, format, err := image.Decode(bytes.NewReader(in.Content))
 	if err != nil {
 		return nil, models.NewValidationError("Invalid image file")
@@ -137,6 +239,12 @@ func (s *ImageService) Upload(ctx context.Context, in UploadImageInput) (*models
 		return nil, models.NewValidationError("Unsupported image format")
 	}
 
+	// Bake the EXIF rotation into the pixels; re-encoding below drops the
+	// EXIF block (GPS, camera data) entirely.
+	if format == "jpeg" {
+		decoded = applyEXIFOrientation(decoded, jpegEXIFOrientation(in.Content))
+	}
+
 	sourceMimeType := decodedFormatToMime(format)
 	if provided := normalizeContentType(in.ContentType); strings.HasPrefix(provided, "image/") && !isMatchingContentType(provided, sourceMimeType) {
 		return nil, models.NewValidationError("Image content type mismatch")
@@ -147,11 +255,11 @@ func (s *ImageService) Upload(ctx context.Context, in UploadImageInput) (*models
 	cropped := cropToRect(decoded, cropX, cropY, cropW, cropH)
 	master := resizeToFit(cropped, MasterMaxSize, MasterMaxSize)
 
-	encodedMasterJPG, err := encodeJPEG(master, JPEGQuality)
+	encodedMasterJPG, err := encodeJPEG(master, s.jpegQuality)
 	if err != nil {
 		return nil, models.NewInternalError(err)
 	}
-	encodedMasterWebP, err := encodeWebP(master, WebPQuality)
+	encodedMasterWebP, err := encodeWebP(master, s.webpQuality)
 	if err != nil {
 		return nil, models.NewInternalError(err)
 	}
@@ -184,6 +292,7 @@ func (s *ImageService) Upload(ctx context.Context, in UploadImageInput) (*models
 	masterBounds := master.Bounds()
 	record := &models.Image{
 		Hash:               hash,
+		ContentHash:        contentHash,
 		UserID:             in.UserID,
 		OriginalFilename:   in.Filename,
 		MimeType:           "image/jpeg",
@@ -230,6 +339,23 @@ func (s *ImageService) GetByHashWithVariants(ctx context.Context, hash string) (
 	return img, nil
 }
 
+// GetByHashForUploader returns an image by hash with its variants, reporting
+// it as not found unless userID uploaded it.
+func (s *ImageService) GetByHashForUploader(ctx context.Context, hash string, userID uint) (*models.Image, error) {
+	img, err := s.GetByHashWithVariants(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	uploader, err := s.repo.IsUploader(ctx, img.ID, userID)
+	if err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	if !uploader {
+		return nil, models.NewNotFoundError("Image", hash)
+	}
+	return img, nil
+}
+
 // BuildMasterImageURL returns the URL path for the master image.
 func (s *ImageService) BuildMasterImageURL(hash string) string {
 	return fmt.Sprintf("/media/i/%s/master.jpg", hash)
@@ -250,7 +376,7 @@ func (s *ImageService) BuildVariantsMap(hash string, variants []models.ImageVari
 	return m
 }
 
-// BuildImageURL is retained for compatibility and always returns the canonical master URL.
+// BuildImageURL returns the API URL that serves the image at the given named size.
 func (s *ImageService) BuildImageURL(hash, size string) string {
 	url := fmt.Sprintf("/api/images/%s", hash)
 	variant := NormalizeImageSize(size)
@@ -274,26 +400,138 @@ func isValidImageHash(hash string) bool {
 	return true
 }
 
-// ResolveForServing is retained for compatibility and resolves to the master image on disk.
-func (s *ImageService) ResolveForServing(ctx context.Context, hash string, _ string) (*models.Image, string, error) {
+// ResolveForServing resolves the file on disk that best matches the requested
+// size and formats. size may be a named size (thumbnail, medium, original) or
+// a ladder width in px; the closest generated variant is chosen, falling back
+// to the master image when the size is unknown or no variants exist yet.
+// formats lists acceptable formats in preference order (see
+// NegotiateImageFormats); JPEG is always used as the last resort.
+//
+// final reports whether the resolved file is the one this request will keep
+// resolving to: the image has finished processing and, for a width request,
+// a generated variant (not the master fallback) was picked. Only final
+// responses are safe to cache as immutable.
+func (s *ImageService) ResolveForServing(ctx context.Context, hash, size string, formats []string) (img *models.Image, fullPath string, final bool, err error) {
 	if !isValidImageHash(hash) {
-		return nil, "", models.NewValidationError("Invalid image hash")
+		return nil, "", false, models.NewValidationError("Invalid image hash")
 	}
-	img, err := s.repo.GetByHash(ctx, hash)
+	img, err = s.repo.GetByHash(ctx, hash)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", models.NewNotFoundError("Image", hash)
+			return nil, "", false, models.NewNotFoundError("Image", hash)
 		}
-		return nil, "", models.NewInternalError(err)
+		return nil, "", false, models.NewInternalError(err)
 	}
-	fullPath := filepath.Join(s.uploadDir, hash, "master.jpg")
-	if _, err := os.Stat(fullPath); err != nil {
-		if os.IsNotExist(err) {
-			return nil, "", models.NewNotFoundError("Image", hash)
+
+	// Candidate files for the chosen size, keyed by format.
+	candidates := map[string]string{
+		ImageFormatJPEG: filepath.ToSlash(filepath.Join(hash, "master.jpg")),
+		ImageFormatWebP: filepath.ToSlash(filepath.Join(hash, "master.webp")),
+	}
+	usedVariant := false
+	width, wantsWidth := s.requestedImageWidth(size)
+	if wantsWidth {
+		variants, verr := s.repo.GetVariantsByImageID(ctx, img.ID)
+		if verr != nil && !errors.Is(verr, gorm.ErrRecordNotFound) {
+			return nil, "", false, models.NewInternalError(verr)
+		}
+		if v := closestVariant(variants, width, max(img.Width, img.Height)); v != nil {
+			usedVariant = true
+			candidates = make(map[string]string)
+			for _, other := range variants {
+				if other.SizePx == v.SizePx && other.Path != "" {
+					candidates[other.Format] = other.Path
+				}
+			}
 		}
-		return nil, "", models.NewInternalError(err)
 	}
-	return img, fullPath, nil
+
+	order := append(append([]string(nil), formats...), ImageFormatJPEG)
+	for _, format := range order {
+		rel, ok := candidates[format]
+		if !ok {
+			continue
+		}
+		fullPath = filepath.Join(s.uploadDir, filepath.FromSlash(rel))
+		if _, statErr := os.Stat(fullPath); statErr != nil {
+			if os.IsNotExist(statErr) {
+				continue
+			}
+			return nil, "", false, models.NewInternalError(statErr)
+		}
+		final = img.Status == repository.ImageStatusReady && (!wantsWidth || usedVariant)
+		return img, fullPath, final, nil
+	}
+	return nil, "", false, models.NewNotFoundError("Image", hash)
+}
+
+// NegotiateImageFormats turns an Accept header into the formats the client
+// can render, ordered avif > webp > jpg regardless of q-values.
+func NegotiateImageFormats(accept string) []string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		rejected := false
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q <= 0 {
+				rejected = true
+			}
+		}
+		if !rejected {
+			accepted[mediaType] = true
+		}
+	}
+
+	formats := make([]string, 0, 3)
+	if accepted["image/avif"] {
+		formats = append(formats, ImageFormatAVIF)
+	}
+	if accepted["image/webp"] {
+		formats = append(formats, ImageFormatWebP)
+	}
+	return append(formats, ImageFormatJPEG)
+}
+
+// requestedImageWidth maps a size query to a target width in px. Thumbnail is
+// the smallest configured width and medium the middle one. It reports false
+// for the original and for sizes it does not recognise.
+func (s *ImageService) requestedImageWidth(size string) (int, bool) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	switch size {
+	case ImageSizeThumbnail:
+		return s.variantSizes[0], true
+	case ImageSizeMedium:
+		return s.variantSizes[len(s.variantSizes)/2], true
+	}
+	width, err := strconv.Atoi(size)
+	if err != nil || width <= 0 {
+		return 0, false
+	}
+	return width, true
+}
+
+// closestVariant returns the JPEG variant whose width is nearest to want, or
+// nil when the master (masterSize px on its long edge) is at least as close.
+func closestVariant(variants []models.ImageVariant, want, masterSize int) *models.ImageVariant {
+	var best *models.ImageVariant
+	bestDiff := absInt(masterSize - want)
+	for i := range variants {
+		v := &variants[i]
+		if v.Format != "jpg" || v.Path == "" {
+			continue
+		}
+		diff := absInt(v.SizePx - want)
+		if diff < bestDiff || (best != nil && diff == bestDiff && v.SizePx > best.SizePx) {
+			best = v
+			bestDiff = diff
+		}
+	}
+	return best
 }
 
 // UpdateLastAccessed records that the image was accessed (for cleanup policies).
@@ -315,24 +553,37 @@ func NormalizeImageSize(size string) string {
 	}
 }
 
-func (s *ImageService) workerLoop(ctx context.Context) {
+// maintenanceLoop requeues images stuck in processing and publishes the
+// queue depth metric.
+func (s *ImageService) maintenanceLoop(ctx context.Context) {
 	const staleDuration = 15 * time.Minute
-	const idleSleep = 750 * time.Millisecond
+	const tick = 15 * time.Second
 
 	_, _ = s.repo.RequeueStaleProcessing(ctx, staleDuration)
 	lastRequeue := time.Now().UTC()
-
 	for {
-		if ctx.Err() != nil {
-			return
-		}
 		if time.Since(lastRequeue) >= time.Minute {
 			_, _ = s.repo.RequeueStaleProcessing(ctx, staleDuration)
 			lastRequeue = time.Now().UTC()
 		}
+		if depth, err := s.repo.CountQueued(ctx); err == nil {
+			observability.ImageProcessingQueueDepth.Set(float64(depth))
+		}
+		if !sleepContext(ctx, tick) {
+			return
+		}
+	}
+}
 
-		img, err := s.repo.ClaimNextQueued(ctx)
-		if err != nil {
+func (s *ImageService) workerLoop(ctx context.Context) {
+	const idleSleep = 750 * time.Millisecond
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := s.ProcessNext(ctx); err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				if !sleepContext(ctx, idleSleep) {
 					return
@@ -342,88 +593,165 @@ func (s *ImageService) workerLoop(ctx context.Context) {
 			if !sleepContext(ctx, time.Second) {
 				return
 			}
-			continue
 		}
+	}
+}
 
-		if err := s.processQueuedImage(ctx, img); err != nil {
-			if ferr := s.repo.MarkFailed(ctx, img.ID, err.Error()); ferr != nil {
-				observability.GlobalLogger.ErrorContext(ctx, "failed to mark image as failed",
-					slog.Uint64("image_id", uint64(img.ID)),
-					slog.String("mark_error", ferr.Error()),
-					slog.String("original_error", err.Error()),
-				)
-			}
+// ProcessNext claims the next queued image and generates its variants,
+// publishing each status transition. It returns gorm.ErrRecordNotFound when
+// nothing is queued; processing failures are recorded on the image instead.
+func (s *ImageService) ProcessNext(ctx context.Context) error {
+	img, err := s.repo.ClaimNextQueued(ctx)
+	if err != nil {
+		return err
+	}
+	s.publishStatus(ctx, img.Hash, repository.ImageStatusProcessing, "")
+
+	if err := s.processQueuedImage(ctx, img); err != nil {
+		if ferr := s.repo.MarkFailed(ctx, img.ID, err.Error()); ferr != nil {
+			observability.GlobalLogger.ErrorContext(ctx, "failed to mark image as failed",
+				slog.Uint64("image_id", uint64(img.ID)),
+				slog.String("mark_error", ferr.Error()),
+				slog.String("original_error", err.Error()),
+			)
 		}
+		s.publishStatus(ctx, img.Hash, repository.ImageStatusFailed, err.Error())
+		return nil
+	}
+	s.publishStatus(ctx, img.Hash, repository.ImageStatusReady, "")
+	return nil
+}
+
+// SetStatusPublisher registers where processing status transitions are sent.
+func (s *ImageService) SetStatusPublisher(p ImageStatusPublisher) {
+	s.statusPublisher = p
+}
+
+func (s *ImageService) publishStatus(ctx context.Context, hash, status, errMsg string) {
+	if s.statusPublisher == nil {
+		return
+	}
+	if err := s.statusPublisher.PublishImageStatus(ctx, hash, status, errMsg); err != nil {
+		observability.GlobalLogger.WarnContext(ctx, "failed to publish image status",
+			slog.String("hash", hash),
+			slog.String("status", status),
+			slog.String("error", err.Error()),
+		)
 	}
 }
 
 func (s *ImageService) processQueuedImage(ctx context.Context, img *models.Image) error {
+	master, err := s.loadMaster(img)
+	if err != nil {
+		return err
+	}
+	if err := s.generateVariants(ctx, img, master, s.plannedVariants(master.Bounds())); err != nil {
+		return err
+	}
+	return s.repo.MarkReady(ctx, img.ID)
+}
+
+// variantSpec is one derivative: a ladder width in one format.
+type variantSpec struct {
+	size   int
+	format string
+}
+
+func (v variantSpec) key() string {
+	return fmt.Sprintf("%d.%s", v.size, v.format)
+}
+
+func (s *ImageService) loadMaster(img *models.Image) (image.Image, error) {
 	masterPath := filepath.Join(s.uploadDir, img.Hash, "master.jpg")
 	// #nosec G304: masterPath is constructed from validated hash
 	f, err := os.Open(masterPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = f.Close() }()
 
 	master, _, err := image.Decode(f)
-	if err != nil {
-		return err
-	}
-	b := master.Bounds()
+	return master, err
+}
 
-	for _, size := range sizeLadder {
+// plannedVariants lists the derivatives the current configuration wants for a
+// master of the given bounds. Sizes larger than the master are skipped.
+func (s *ImageService) plannedVariants(b image.Rectangle) []variantSpec {
+	var specs []variantSpec
+	for _, size := range s.variantSizes {
 		if b.Dx() < size || b.Dy() < size {
 			continue
 		}
-		resized := resizeToFit(master, size, size)
-		rb := resized.Bounds()
-		sizeName := sizeNameFor(size)
-
-		webpBytes, err := encodeWebP(resized, WebPQuality)
-		if err != nil {
-			return err
+		if s.webpVariants {
+			specs = append(specs, variantSpec{size: size, format: ImageFormatWebP})
 		}
-		webpRel := filepath.ToSlash(filepath.Join(img.Hash, fmt.Sprintf("%d.webp", size)))
-		if writeErr := writeBytesToFile(filepath.Join(s.uploadDir, webpRel), webpBytes); writeErr != nil {
-			return writeErr
-		}
-		if upsertErr := s.repo.UpsertVariant(ctx, &models.ImageVariant{
-			ImageID:  img.ID,
-			SizeName: sizeName,
-			SizePx:   size,
-			Format:   "webp",
-			Path:     webpRel,
-			Width:    rb.Dx(),
-			Height:   rb.Dy(),
-			Bytes:    int64(len(webpBytes)),
-		}); upsertErr != nil {
-			return upsertErr
+		specs = append(specs, variantSpec{size: size, format: ImageFormatJPEG})
+		if s.avifEncoder != nil {
+			specs = append(specs, variantSpec{size: size, format: ImageFormatAVIF})
 		}
+	}
+	return specs
+}
 
-		jpgBytes, err := encodeJPEG(resized, JPEGQuality)
+// generateVariants encodes and stores specs, resizing the master once per
+// width. specs must be grouped by width, as plannedVariants returns them.
+func (s *ImageService) generateVariants(ctx context.Context, img *models.Image, master image.Image, specs []variantSpec) error {
+	var resized image.Image
+	resizedSize := 0
+	for _, spec := range specs {
+		if spec.size != resizedSize {
+			resized = resizeToFit(master, spec.size, spec.size)
+			resizedSize = spec.size
+		}
+		rb := resized.Bounds()
+		sizeName := sizeNameFor(spec.size)
+
+		var data []byte
+		var err error
+		switch spec.format {
+		case ImageFormatWebP:
+			data, err = encodeWebP(resized, s.webpQuality)
+		case ImageFormatJPEG:
+			data, err = encodeJPEG(resized, s.jpegQuality)
+		case ImageFormatAVIF:
+			// AVIF is an optional extra; WebP and JPEG remain the fallbacks.
+			data, err = s.avifEncoder(resized, AVIFQuality)
+			if err != nil {
+				observability.GlobalLogger.WarnContext(ctx, "avif encode failed",
+					slog.Uint64("image_id", uint64(img.ID)),
+					slog.Int("size", spec.size),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+		}
 		if err != nil {
 			return err
 		}
-		jpgRel := filepath.ToSlash(filepath.Join(img.Hash, fmt.Sprintf("%d.jpg", size)))
-		if writeErr := writeBytesToFile(filepath.Join(s.uploadDir, jpgRel), jpgBytes); writeErr != nil {
-			return writeErr
-		}
-		if upsertErr := s.repo.UpsertVariant(ctx, &models.ImageVariant{
-			ImageID:  img.ID,
-			SizeName: sizeName,
-			SizePx:   size,
-			Format:   "jpg",
-			Path:     jpgRel,
-			Width:    rb.Dx(),
-			Height:   rb.Dy(),
-			Bytes:    int64(len(jpgBytes)),
-		}); upsertErr != nil {
-			return upsertErr
+		if err := s.storeVariant(ctx, img, spec.size, sizeName, spec.format, rb, data); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return s.repo.MarkReady(ctx, img.ID)
+// storeVariant writes an encoded derivative to <hash>/<size>.<format> and
+// records it against the image.
+func (s *ImageService) storeVariant(ctx context.Context, img *models.Image, size int, sizeName, format string, bounds image.Rectangle, data []byte) error {
+	rel := filepath.ToSlash(filepath.Join(img.Hash, fmt.Sprintf("%d.%s", size, format)))
+	if err := writeBytesToFile(filepath.Join(s.uploadDir, rel), data); err != nil {
+		return err
+	}
+	return s.repo.UpsertVariant(ctx, &models.ImageVariant{
+		ImageID:  img.ID,
+		SizeName: sizeName,
+		SizePx:   size,
+		Format:   format,
+		Path:     rel,
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+		Bytes:    int64(len(data)),
+	})
 }
 
 func selectCropMode(w, h int) (mode string, cropX, cropY, cropW, cropH int) {
@@ -536,6 +864,32 @@ func encodeWebP(img image.Image, quality int) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// encodeAVIF shells out to avifenc, as there is no pure-Go AVIF encoder.
+func encodeAVIF(img image.Image, quality int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "sanctum-avif-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	src := filepath.Join(dir, "in.png")
+	dst := filepath.Join(dir, "out.avif")
+	buf := bytes.NewBuffer(nil)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(src, buf.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+	// #nosec G204: arguments are fixed paths inside our own temp dir
+	out, err := exec.Command(avifencBinary, "-q", strconv.Itoa(quality), src, dst).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("avifenc: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	// #nosec G304: dst is inside our own temp dir
+	return os.ReadFile(dst)
+}
+
 func isAllowedImageMIME(contentType string) bool {
 	switch normalizeContentType(contentType) {
 	case "image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp":
@@ -596,6 +950,11 @@ func buildDeterministicImageHash(userID uint, content []byte) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
 func writeBytesToFile(path string, data []byte) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
@@ -621,6 +980,13 @@ func sleepContext(ctx context.Context, d time.Duration) bool {
 	}
 }
 
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 func absFloat(v float64) float64 {
 	if v < 0 {
 		return -v
@@ -3,16 +3,28 @@ package service
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"image"
 	"image/color"
 	"image/png"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"sanctum/internal/config"
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
 	"sanctum/internal/testutil"
+
+	"gorm.io/gorm"
 )
 
 func TestImageServiceUploadAndResolve(t *testing.T) {
@@ -58,7 +70,7 @@ func TestImageServiceUploadAndResolve(t *testing.T) {
 		t.Fatalf("expected deduped record id %d, got %d", img.ID, img2.ID)
 	}
 
-	_, fullPath, err := svc.ResolveForServing(context.Background(), img.Hash, ImageSizeThumbnail)
+	_, fullPath, _, err := svc.ResolveForServing(context.Background(), img.Hash, ImageSizeThumbnail, nil)
 	if err != nil {
 		t.Fatalf("resolve thumbnail failed: %v", err)
 	}
@@ -146,6 +158,99 @@ func TestImageServiceUploadValidation(t *testing.T) {
 	}
 }
 
+// pngBomb returns a tiny PNG whose header declares w×h pixels, the shape of
+// a decompression bomb: a few bytes on disk, gigabytes once decoded.
+func pngBomb(w, h uint32) []byte {
+	chunk := func(kind string, data []byte) []byte {
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+		out = append(out, kind...)
+		out = append(out, data...)
+		return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(kind), data...)))
+	}
+	ihdr := binary.BigEndian.AppendUint32(nil, w)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, h)
+	ihdr = append(ihdr, 1, 0, 0, 0, 0) // 1-bit grayscale
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = append(out, chunk("IHDR", ihdr)...)
+	return append(out, chunk("IEND", nil)...)
+}
+
+func TestImageServiceRejectsOversizedDimensions(t *testing.T) {
+	repo := testutil.NewImageRepoStub()
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10, ImageMaxMegapixels: 1}
+	svc := NewImageService(repo, cfg)
+
+	bomb := pngBomb(50000, 50000)
+	_, err := svc.Upload(context.Background(), UploadImageInput{
+		UserID:      1,
+		Filename:    "bomb.png",
+		ContentType: "image/png",
+		Content:     bomb,
+	})
+	var appErr *models.AppError
+	if !errors.As(err, &appErr) || appErr.Code != "VALIDATION_ERROR" {
+		t.Fatalf("expected validation error for %d-byte bomb, got %v", len(bomb), err)
+	}
+	if !strings.Contains(appErr.Message, "1 megapixels") {
+		t.Fatalf("expected the pixel budget in the error, got %q", appErr.Message)
+	}
+
+	if _, err := svc.Upload(context.Background(), UploadImageInput{
+		UserID:      1,
+		Filename:    "ok.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 800, 600),
+	}); err != nil {
+		t.Fatalf("expected image within the budget to upload, got %v", err)
+	}
+}
+
+func TestImageServiceDeduplicatesIdenticalUploads(t *testing.T) {
+	repo := testutil.NewImageRepoStub()
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+	svc := NewImageService(repo, cfg)
+	ctx := context.Background()
+	content := testutil.TinyPNG(t, 800, 600)
+
+	first, err := svc.Upload(ctx, UploadImageInput{UserID: 1, Filename: "a.png", ContentType: "image/png", Content: content})
+	if err != nil {
+		t.Fatalf("first upload: %v", err)
+	}
+	if err := svc.ProcessNext(ctx); err != nil {
+		t.Fatalf("process first upload: %v", err)
+	}
+
+	second, err := svc.Upload(ctx, UploadImageInput{UserID: 2, Filename: "b.png", ContentType: "image/png", Content: content})
+	if err != nil {
+		t.Fatalf("duplicate upload: %v", err)
+	}
+	if second.ID != first.ID || second.Hash != first.Hash {
+		t.Fatalf("expected duplicate to reuse image %d (%s), got %d (%s)", first.ID, first.Hash, second.ID, second.Hash)
+	}
+	if second.Status != repository.ImageStatusReady || len(second.Variants) == 0 {
+		t.Fatalf("expected the ready record with its variants, got status %q and %d variants", second.Status, len(second.Variants))
+	}
+	if second.UserID != 2 {
+		t.Fatalf("expected the duplicate to be returned as the uploader's own, got owner %d", second.UserID)
+	}
+	if _, err := svc.Upload(ctx, UploadImageInput{UserID: 2, Filename: "c.png", ContentType: "image/png", Content: content}); err != nil {
+		t.Fatalf("repeat upload: %v", err)
+	}
+	if uploaders := repo.Uploaders(first.ID); !reflect.DeepEqual(uploaders, []uint{1, 2}) {
+		t.Fatalf("expected uploaders [1 2], got %v", uploaders)
+	}
+	if queued, _ := repo.CountQueued(ctx); queued != 0 {
+		t.Fatalf("expected no new processing job, got %d queued", queued)
+	}
+	entries, err := os.ReadDir(cfg.ImageUploadDir)
+	if err != nil {
+		t.Fatalf("read upload dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected a single stored image directory, got %d", len(entries))
+	}
+}
+
 func TestBuildImageURLIsRelative(t *testing.T) {
 	svc := NewImageService(nil, nil)
 
@@ -201,3 +306,329 @@ func transparentPNG(t *testing.T, w, h int) []byte {
 	}
 	return buf.Bytes()
 }
+
+func TestImageServiceResolveForServingPicksClosestVariant(t *testing.T) {
+	repo := testutil.NewImageRepoStub()
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+	svc := NewImageService(repo, cfg)
+	ctx := context.Background()
+
+	img, err := svc.Upload(ctx, UploadImageInput{
+		UserID:      7,
+		Filename:    "photo.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 1600, 1600),
+	})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if err := svc.processQueuedImage(ctx, img); err != nil {
+		t.Fatalf("process variants: %v", err)
+	}
+
+	fileSize := func(size string) (string, int64) {
+		t.Helper()
+		_, path, _, resolveErr := svc.ResolveForServing(ctx, img.Hash, size, nil)
+		if resolveErr != nil {
+			t.Fatalf("resolve %q: %v", size, resolveErr)
+		}
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			t.Fatalf("stat %s: %v", path, statErr)
+		}
+		return filepath.Base(path), info.Size()
+	}
+
+	originalName, originalBytes := fileSize(ImageSizeOriginal)
+	if originalName != "master.jpg" {
+		t.Fatalf("expected original to resolve to master.jpg, got %s", originalName)
+	}
+	thumbName, thumbBytes := fileSize(ImageSizeThumbnail)
+	if thumbName != "256.jpg" {
+		t.Fatalf("expected thumbnail to resolve to 256.jpg, got %s", thumbName)
+	}
+	if thumbBytes >= originalBytes {
+		t.Fatalf("expected thumbnail (%d bytes) to be smaller than original (%d bytes)", thumbBytes, originalBytes)
+	}
+	if name, _ := fileSize("700"); name != "640.jpg" {
+		t.Fatalf("expected width 700 to resolve to 640.jpg, got %s", name)
+	}
+	if name, _ := fileSize("huge"); name != "master.jpg" {
+		t.Fatalf("expected unknown size to fall back to master.jpg, got %s", name)
+	}
+}
+
+func TestImageServiceAVIFVariantsAndNegotiation(t *testing.T) {
+	repo := testutil.NewImageRepoStub()
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
+	svc := NewImageService(repo, cfg)
+	// avifenc is not available in CI; stand in an encoder so the pipeline runs.
+	svc.avifEncoder = func(image.Image, int) ([]byte, error) { return []byte("avif"), nil }
+	ctx := context.Background()
+
+	img, err := svc.Upload(ctx, UploadImageInput{
+		UserID:      9,
+		Filename:    "photo.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 700, 700),
+	})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if err := svc.processQueuedImage(ctx, img); err != nil {
+		t.Fatalf("process variants: %v", err)
+	}
+
+	variants, err := repo.GetVariantsByImageID(ctx, img.ID)
+	if err != nil {
+		t.Fatalf("variants: %v", err)
+	}
+	avifCount := 0
+	for _, v := range variants {
+		if v.Format == ImageFormatAVIF {
+			avifCount++
+			if _, statErr := os.Stat(filepath.Join(cfg.ImageUploadDir, v.Path)); statErr != nil {
+				t.Fatalf("expected avif file %s: %v", v.Path, statErr)
+			}
+		}
+	}
+	if avifCount != 2 {
+		t.Fatalf("expected avif variants for 256 and 640, got %d", avifCount)
+	}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "image/avif,image/webp,image/*,*/*;q=0.8", want: "256.avif"},
+		{accept: "image/webp,*/*", want: "256.webp"},
+		{accept: "image/avif;q=0,image/webp", want: "256.webp"},
+		{accept: "", want: "256.jpg"},
+	}
+	for _, tt := range tests {
+		_, path, _, resolveErr := svc.ResolveForServing(ctx, img.Hash, ImageSizeThumbnail, NegotiateImageFormats(tt.accept))
+		if resolveErr != nil {
+			t.Fatalf("resolve with Accept %q: %v", tt.accept, resolveErr)
+		}
+		if got := filepath.Base(path); got != tt.want {
+			t.Fatalf("Accept %q: expected %s, got %s", tt.accept, tt.want, got)
+		}
+	}
+}
+
+func TestImageServiceReducedVariantLadder(t *testing.T) {
+	repo := testutil.NewImageRepoStub()
+	cfg := &config.Config{
+		ImageUploadDir:        t.TempDir(),
+		ImageMaxUploadSizeMB:  10,
+		ImageVariantSizes:     "256, 1080",
+		ImageSkipWebPVariants: true,
+	}
+	svc := NewImageService(repo, cfg)
+	ctx := context.Background()
+
+	img, err := svc.Upload(ctx, UploadImageInput{
+		UserID:      3,
+		Filename:    "big.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 1600, 1600),
+	})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if err := svc.processQueuedImage(ctx, img); err != nil {
+		t.Fatalf("process variants: %v", err)
+	}
+
+	variants, err := repo.GetVariantsByImageID(ctx, img.ID)
+	if err != nil {
+		t.Fatalf("variants: %v", err)
+	}
+	got := make([]string, 0, len(variants))
+	for _, v := range variants {
+		got = append(got, fmt.Sprintf("%d.%s", v.SizePx, v.Format))
+	}
+	want := []string{"256.jpg", "1080.jpg"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected variants %v, got %v", want, got)
+	}
+	if _, statErr := os.Stat(filepath.Join(cfg.ImageUploadDir, img.Hash, "640.jpg")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no 640px variant on disk, stat err: %v", statErr)
+	}
+}
+
+func TestImageServiceCustomSizeLadder(t *testing.T) {
+	repo := testutil.NewImageRepoStub()
+	cfg := &config.Config{
+		ImageUploadDir:        t.TempDir(),
+		ImageMaxUploadSizeMB:  10,
+		ImageVariantSizes:     "200,500,900",
+		ImageJPEGQuality:      60,
+		ImageSkipWebPVariants: true,
+	}
+	svc := NewImageService(repo, cfg)
+	ctx := context.Background()
+
+	img, err := svc.Upload(ctx, UploadImageInput{
+		UserID:      3,
+		Filename:    "big.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 1600, 1600),
+	})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if err := svc.processQueuedImage(ctx, img); err != nil {
+		t.Fatalf("process variants: %v", err)
+	}
+
+	variants, err := repo.GetVariantsByImageID(ctx, img.ID)
+	if err != nil {
+		t.Fatalf("variants: %v", err)
+	}
+	got := make([]string, 0, len(variants))
+	for _, v := range variants {
+		got = append(got, fmt.Sprintf("%d.%s", v.SizePx, v.Format))
+	}
+	want := []string{"200.jpg", "500.jpg", "900.jpg"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected variants %v, got %v", want, got)
+	}
+	if width, ok := svc.requestedImageWidth(ImageSizeThumbnail); !ok || width != 200 {
+		t.Fatalf("expected thumbnail to map to the smallest width, got %d", width)
+	}
+	if width, ok := svc.requestedImageWidth(ImageSizeMedium); !ok || width != 500 {
+		t.Fatalf("expected medium to map to the middle width, got %d", width)
+	}
+}
+
+func TestImageServiceBackfillVariantsAddsMissingSize(t *testing.T) {
+	repo := testutil.NewImageRepoStub()
+	uploadDir := t.TempDir()
+	cfg := &config.Config{
+		ImageUploadDir:        uploadDir,
+		ImageMaxUploadSizeMB:  10,
+		ImageVariantSizes:     "256",
+		ImageSkipWebPVariants: true,
+	}
+	svc := NewImageService(repo, cfg)
+	ctx := context.Background()
+
+	img, err := svc.Upload(ctx, UploadImageInput{
+		UserID:      3,
+		Filename:    "big.png",
+		ContentType: "image/png",
+		Content:     testutil.TinyPNG(t, 1600, 1600),
+	})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if err := svc.processQueuedImage(ctx, img); err != nil {
+		t.Fatalf("process variants: %v", err)
+	}
+
+	// The ladder grows: 640 is now wanted but the image predates it.
+	cfg.ImageVariantSizes = "256,640"
+	svc = NewImageService(repo, cfg)
+
+	dry, err := svc.BackfillVariants(ctx, BackfillVariantsInput{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.Scanned != 1 || dry.Updated != 1 || dry.Generated != 1 {
+		t.Fatalf("unexpected dry run result %+v", dry)
+	}
+	if _, statErr := os.Stat(filepath.Join(uploadDir, img.Hash, "640.jpg")); !os.IsNotExist(statErr) {
+		t.Fatalf("dry run must not write files, stat err: %v", statErr)
+	}
+
+	result, err := svc.BackfillVariants(ctx, BackfillVariantsInput{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if result.Generated != 1 || result.Failed != 0 {
+		t.Fatalf("unexpected backfill result %+v", result)
+	}
+	variants, err := repo.GetVariantsByImageID(ctx, img.ID)
+	if err != nil {
+		t.Fatalf("variants: %v", err)
+	}
+	got := make([]string, 0, len(variants))
+	for _, v := range variants {
+		got = append(got, fmt.Sprintf("%d.%s", v.SizePx, v.Format))
+	}
+	if want := "256.jpg,640.jpg"; strings.Join(got, ",") != want {
+		t.Fatalf("expected variants %s, got %v", want, got)
+	}
+	if _, statErr := os.Stat(filepath.Join(uploadDir, img.Hash, "640.jpg")); statErr != nil {
+		t.Fatalf("expected 640px variant on disk: %v", statErr)
+	}
+
+	again, err := svc.BackfillVariants(ctx, BackfillVariantsInput{})
+	if err != nil {
+		t.Fatalf("second backfill: %v", err)
+	}
+	if again.Generated != 0 {
+		t.Fatalf("expected nothing left to backfill, got %+v", again)
+	}
+}
+
+// concurrencyRepo hands out queued images and records how many are being
+// processed at once. MarkFailed is slowed down so workers overlap.
+type concurrencyRepo struct {
+	*testutil.ImageRepoStub
+	mu       sync.Mutex
+	pending  int
+	inFlight int
+	maxSeen  int
+	done     chan struct{}
+}
+
+func (r *concurrencyRepo) ClaimNextQueued(context.Context) (*models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	r.pending--
+	r.inFlight++
+	if r.inFlight > r.maxSeen {
+		r.maxSeen = r.inFlight
+	}
+	// The master file does not exist, so processing fails fast into MarkFailed.
+	return &models.Image{ID: uint(100 + r.pending), Hash: fmt.Sprintf("%064x", r.pending)}, nil
+}
+
+func (r *concurrencyRepo) MarkFailed(context.Context, uint, string) error {
+	time.Sleep(20 * time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight--
+	r.done <- struct{}{}
+	return nil
+}
+
+func TestImageServiceWorkerConcurrencyIsBounded(t *testing.T) {
+	const jobs = 8
+	repo := &concurrencyRepo{ImageRepoStub: testutil.NewImageRepoStub(), pending: jobs, done: make(chan struct{}, jobs)}
+	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10, ImageWorkerConcurrency: 2}
+	svc := NewImageService(repo, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.StartBackgroundWorker(ctx)
+
+	for i := 0; i < jobs; i++ {
+		select {
+		case <-repo.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d images were processed", i, jobs)
+		}
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.maxSeen != 2 {
+		t.Fatalf("expected at most 2 images in flight (and both workers used), saw %d", repo.maxSeen)
+	}
+}
@@ -21,6 +21,9 @@ type BanRequestRow struct {
 // AdminUserDetail aggregates user and moderation data for admin views.
 type AdminUserDetail struct {
 	User           models.User               `json:"user"`
+	LastIP         string                    `json:"last_ip,omitempty"`
+	DeviceHash     string                    `json:"device_hash,omitempty"`
+	ShadowBanned   bool                      `json:"shadow_banned"`
 	Reports        []models.ModerationReport `json:"reports"`
 	ActiveMutes    []models.ChatroomMute     `json:"active_mutes"`
 	BlocksGiven    []models.UserBlock        `json:"blocks_given"`
@@ -95,7 +98,10 @@ func (s *ModerationService) GetAdminUserDetail(ctx context.Context, userID uint)
 	}
 
 	detail := &AdminUserDetail{
-		User: user,
+		User:         user,
+		LastIP:       user.LastIP,
+		DeviceHash:   user.DeviceHash,
+		ShadowBanned: user.ShadowBanned,
 	}
 
 	// 1. Reports
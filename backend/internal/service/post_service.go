@@ -2,25 +2,44 @@ package service
 
 import (
 	"context"
+	"log/slog"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"sanctum/internal/cache"
 	"sanctum/internal/models"
+	"sanctum/internal/observability"
 	"sanctum/internal/repository"
+	"sanctum/internal/validation"
+)
+
+const (
+	// ScheduledPostInterval is how often the publish worker looks for due posts.
+	ScheduledPostInterval = 30 * time.Second
+	// MaxPostScheduleAhead caps how far in the future a post may be scheduled.
+	MaxPostScheduleAhead = 365 * 24 * time.Hour
 )
 
 // PostService provides post and feed business logic.
 type PostService struct {
-	postRepo repository.PostRepository
-	pollRepo repository.PollRepository
-	isAdmin  func(ctx context.Context, userID uint) (bool, error)
+	postRepo    repository.PostRepository
+	pollRepo    repository.PollRepository
+	isAdmin     func(ctx context.Context, userID uint) (bool, error)
+	onPublished func(ctx context.Context, post *models.Post)
+	// isShadowBanned lets shadow-banned viewers (and admins) skip the shared
+	// feed cache, which never contains shadow-banned posts.
+	isShadowBanned func(ctx context.Context, userID uint) (bool, error)
+	workerOnce     sync.Once
 }
 
 // CreatePostPollInput is the poll payload when creating a poll post.
 type CreatePostPollInput struct {
-	Question string   `json:"question"`
-	Options  []string `json:"options"`
+	Question      string     `json:"question"`
+	Options       []string   `json:"options"`
+	AllowMultiple bool       `json:"allow_multiple"`
+	ClosesAt      *time.Time `json:"closes_at,omitempty"`
 }
 
 // CreatePostInput is the input for creating a post.
@@ -34,6 +53,12 @@ type CreatePostInput struct {
 	YoutubeURL string
 	SanctumID  *uint
 	Poll       *CreatePostPollInput
+	// PublishAt schedules the post; it stays hidden from feeds until then.
+	PublishAt *time.Time
+	Tags      []string
+	// RequiresApproval holds the post in pending_review until a sanctum
+	// owner or moderator approves it.
+	RequiresApproval bool
 }
 
 // ListPostsInput is the input for listing posts.
@@ -42,6 +67,7 @@ type ListPostsInput struct {
 	Offset        int
 	CurrentUserID uint
 	SanctumID     *uint
+	Tag           string // only applies together with SanctumID
 	Sort          string // "new" | "hot" | "top" | "rising" | "best"; defaults to "new"
 }
 
@@ -54,6 +80,8 @@ type UpdatePostInput struct {
 	ImageURL   string
 	LinkURL    string
 	YoutubeURL string
+	// Tags replaces the post's tags when non-nil; an empty slice clears them.
+	Tags *[]string
 }
 
 // DeletePostInput is the input for deleting a post.
@@ -117,6 +145,19 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 	if len(in.Content) > maxContentLen {
 		return nil, models.NewValidationError("Content too long (max 50000 characters)")
 	}
+	tags, err := validation.NormalizeTags(in.Tags)
+	if err != nil {
+		return nil, models.NewValidationError(err.Error())
+	}
+	if in.PublishAt != nil {
+		now := time.Now()
+		if !in.PublishAt.After(now) {
+			return nil, models.NewValidationError("publish_at must be in the future")
+		}
+		if in.PublishAt.After(now.Add(MaxPostScheduleAhead)) {
+			return nil, models.NewValidationError("publish_at cannot be more than a year ahead")
+		}
+	}
 	// Content required for text posts.
 	if postType == models.PostTypeText {
 		if in.Content == "" {
@@ -164,6 +205,9 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 			return nil, models.NewValidationError("Poll must have at least two non-empty options")
 		}
 		in.Poll.Options = opts
+		if in.Poll.ClosesAt != nil && !in.Poll.ClosesAt.After(time.Now()) {
+			return nil, models.NewValidationError("Poll closing time must be in the future")
+		}
 	}
 
 	content := in.Content
@@ -181,13 +225,36 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 		YoutubeURL: in.YoutubeURL,
 		UserID:     in.UserID,
 		SanctumID:  in.SanctumID,
+		Status:     models.PostStatusPublished,
+	}
+	if in.PublishAt != nil {
+		publishAt := in.PublishAt.UTC()
+		post.Status = models.PostStatusScheduled
+		post.PublishAt = &publishAt
+	}
+	if in.RequiresApproval {
+		post.Status = models.PostStatusPendingReview
 	}
 	if err := s.postRepo.Create(ctx, post); err != nil {
 		return nil, err
 	}
 
 	if postType == models.PostTypePoll && s.pollRepo != nil {
-		if _, err := s.pollRepo.Create(ctx, post.ID, in.Poll.Question, in.Poll.Options); err != nil {
+		poll := &models.Poll{
+			PostID:        post.ID,
+			Question:      in.Poll.Question,
+			AllowMultiple: in.Poll.AllowMultiple,
+		}
+		if in.Poll.ClosesAt != nil {
+			closesAt := in.Poll.ClosesAt.UTC()
+			poll.ClosesAt = &closesAt
+		}
+		if err := s.pollRepo.Create(ctx, poll, in.Poll.Options); err != nil {
+			return nil, err
+		}
+	}
+	if len(tags) > 0 {
+		if err := s.postRepo.SetTags(ctx, post.ID, tags); err != nil {
 			return nil, err
 		}
 	}
@@ -208,7 +275,7 @@ func (s *PostService) ListPosts(ctx context.Context, in ListPostsInput) ([]*mode
 	// Only cache the first page of the default "new" sort (global feed).
 	// Non-new sorts are dynamic and skip the cache.
 	switch {
-	case in.SanctumID == nil && in.Offset == 0 && in.Limit <= 20 && sort == "new":
+	case in.SanctumID == nil && in.Offset == 0 && in.Limit <= 20 && sort == "new" && !s.viewerSeesHiddenPosts(ctx, in.CurrentUserID):
 		key := cache.PostsListKey(ctx)
 		err = cache.Aside(ctx, key, &posts, cache.ListTTL, func() error {
 			var fetchErr error
@@ -239,6 +306,8 @@ func (s *PostService) ListPosts(ctx context.Context, in ListPostsInput) ([]*mode
 				}
 			}
 		}
+	case in.SanctumID != nil && in.Tag != "":
+		posts, err = s.postRepo.GetBySanctumTag(ctx, *in.SanctumID, validation.NormalizeTag(in.Tag), in.Limit, in.Offset, in.CurrentUserID, sort)
 	case in.SanctumID != nil:
 		posts, err = s.postRepo.GetBySanctumID(ctx, *in.SanctumID, in.Limit, in.Offset, in.CurrentUserID, sort)
 	default:
@@ -257,8 +326,10 @@ func (s *PostService) ListPosts(ctx context.Context, in ListPostsInput) ([]*mode
 }
 
 // GetPost returns a single post by ID with poll enriched if present.
+// Scheduled and pending_review posts are only visible to their author until
+// they publish.
 func (s *PostService) GetPost(ctx context.Context, id uint, currentUserID uint) (*models.Post, error) {
-	post, err := s.postRepo.GetByID(ctx, id, currentUserID)
+	post, err := visiblePost(ctx, s.postRepo, id, currentUserID)
 	if err != nil {
 		return nil, err
 	}
@@ -268,6 +339,94 @@ func (s *PostService) GetPost(ctx context.Context, id uint, currentUserID uint)
 	return post, nil
 }
 
+// visiblePost loads a post as viewerID may see it: scheduled and
+// pending_review posts are reported as not found to anyone but their author,
+// so they cannot be voted on, liked or commented on before they publish.
+func visiblePost(ctx context.Context, postRepo repository.PostRepository, id, viewerID uint) (*models.Post, error) {
+	post, err := postRepo.GetByID(ctx, id, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	hidden := post.Status == models.PostStatusScheduled || post.Status == models.PostStatusPendingReview
+	if hidden && post.UserID != viewerID {
+		return nil, models.NewNotFoundError("Post", id)
+	}
+	return post, nil
+}
+
+// SetPublishedHook registers a callback invoked for each scheduled post the
+// worker publishes, so the server can fan out the same notifications a freshly
+// created post would get.
+func (s *PostService) SetPublishedHook(fn func(ctx context.Context, post *models.Post)) {
+	s.onPublished = fn
+}
+
+// SetShadowBanCheck sets how ListPosts tells whether a viewer is shadow
+// banned.
+func (s *PostService) SetShadowBanCheck(fn func(ctx context.Context, userID uint) (bool, error)) {
+	s.isShadowBanned = fn
+}
+
+// viewerSeesHiddenPosts reports whether userID is shadow banned or an admin,
+// and so sees posts the shared cache leaves out. Lookup errors count as yes
+// so the viewer falls back to an uncached query.
+func (s *PostService) viewerSeesHiddenPosts(ctx context.Context, userID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	if s.isShadowBanned != nil {
+		if banned, err := s.isShadowBanned(ctx, userID); banned || err != nil {
+			return true
+		}
+	}
+	if s.isAdmin != nil {
+		if admin, err := s.isAdmin(ctx, userID); admin || err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// StartBackgroundWorker runs PublishDuePosts every ScheduledPostInterval until ctx is done.
+func (s *PostService) StartBackgroundWorker(ctx context.Context) {
+	if s.postRepo == nil {
+		return
+	}
+	s.workerOnce.Do(func() {
+		go s.workerLoop(ctx)
+	})
+}
+
+func (s *PostService) workerLoop(ctx context.Context) {
+	ticker := time.NewTicker(ScheduledPostInterval)
+	defer ticker.Stop()
+	for {
+		published, err := s.PublishDuePosts(ctx, time.Now().UTC())
+		if err != nil && ctx.Err() == nil {
+			observability.GlobalLogger.ErrorContext(ctx, "scheduled post publish failed", slog.String("error", err.Error()))
+		} else if published > 0 {
+			observability.GlobalLogger.InfoContext(ctx, "published scheduled posts", slog.Int("posts", published))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDuePosts publishes scheduled posts whose publish time is at or before
+// now and runs the published hook for each. It returns how many were published.
+func (s *PostService) PublishDuePosts(ctx context.Context, now time.Time) (int, error) {
+	published, err := s.postRepo.PublishDue(ctx, now)
+	if s.onPublished != nil {
+		for _, p := range published {
+			s.onPublished(ctx, p)
+		}
+	}
+	return len(published), err
+}
+
 func (s *PostService) getPostWithPollEnriched(ctx context.Context, postID, currentUserID uint) (*models.Post, error) {
 	post, err := s.postRepo.GetByID(ctx, postID, currentUserID)
 	if err != nil {
@@ -296,29 +455,45 @@ func isYouTubeURL(u string) bool {
 	return strings.Contains(host, "youtube.com") || strings.Contains(host, "youtu.be")
 }
 
-// VotePoll records or updates the current user's vote on a poll.
-func (s *PostService) VotePoll(ctx context.Context, userID, postID, pollOptionID uint) (*models.Post, error) {
-	post, err := s.postRepo.GetByID(ctx, postID, userID)
+// VotePoll records the current user's selection on a poll, replacing any
+// earlier vote. Single-choice polls accept exactly one option.
+func (s *PostService) VotePoll(ctx context.Context, userID, postID uint, pollOptionIDs []uint) (*models.Post, error) {
+	post, err := visiblePost(ctx, s.postRepo, postID, userID)
 	if err != nil {
 		return nil, err
 	}
 	if post.PostType != models.PostTypePoll || post.Poll == nil {
 		return nil, models.NewValidationError("Post is not a poll")
 	}
-	var optionBelongs bool
+	if post.Poll.IsClosed(time.Now()) {
+		return nil, models.NewValidationError("Poll is closed")
+	}
+
+	valid := make(map[uint]bool, len(post.Poll.Options))
 	for _, opt := range post.Poll.Options {
-		if opt.ID == pollOptionID {
-			optionBelongs = true
-			break
+		valid[opt.ID] = true
+	}
+	selected := make([]uint, 0, len(pollOptionIDs))
+	seen := make(map[uint]bool, len(pollOptionIDs))
+	for _, id := range pollOptionIDs {
+		if !valid[id] {
+			return nil, models.NewValidationError("Invalid poll option")
+		}
+		if !seen[id] {
+			seen[id] = true
+			selected = append(selected, id)
 		}
 	}
-	if !optionBelongs {
-		return nil, models.NewValidationError("Invalid poll option")
+	if len(selected) == 0 {
+		return nil, models.NewValidationError("Select at least one option")
+	}
+	if len(selected) > 1 && !post.Poll.AllowMultiple {
+		return nil, models.NewValidationError("This poll allows only one choice")
 	}
 	if s.pollRepo == nil {
 		return nil, models.NewValidationError("Poll voting is not available")
 	}
-	if err := s.pollRepo.Vote(ctx, userID, post.Poll.ID, pollOptionID); err != nil {
+	if err := s.pollRepo.SetVotes(ctx, userID, post.Poll.ID, selected); err != nil {
 		return nil, err
 	}
 	cache.Invalidate(ctx, cache.PostKey(postID))
@@ -350,6 +525,23 @@ func (s *PostService) UpdatePost(ctx context.Context, in UpdatePostInput) (*mode
 		return nil, models.NewUnauthorizedError("You can only update your own posts")
 	}
 
+	var tags []string
+	if in.Tags != nil {
+		if tags, err = validation.NormalizeTags(*in.Tags); err != nil {
+			return nil, models.NewValidationError(err.Error())
+		}
+	}
+
+	revision := &models.PostRevision{
+		PostID:     post.ID,
+		EditorID:   in.UserID,
+		Title:      post.Title,
+		Content:    post.Content,
+		ImageURL:   post.ImageURL,
+		LinkURL:    post.LinkURL,
+		YoutubeURL: post.YoutubeURL,
+	}
+
 	if in.Title != "" {
 		post.Title = in.Title
 	}
@@ -367,12 +559,58 @@ func (s *PostService) UpdatePost(ctx context.Context, in UpdatePostInput) (*mode
 		post.YoutubeURL = in.YoutubeURL
 	}
 
-	if err := s.postRepo.Update(ctx, post); err != nil {
+	if revision.Title == post.Title && revision.Content == post.Content &&
+		revision.ImageURL == post.ImageURL && revision.LinkURL == post.LinkURL &&
+		revision.YoutubeURL == post.YoutubeURL {
+		// Nothing visible changed, so there is no prior version worth keeping.
+		if err := s.postRepo.Update(ctx, post); err != nil {
+			return nil, err
+		}
+	} else if err := s.postRepo.UpdateWithRevision(ctx, post, revision); err != nil {
 		return nil, err
 	}
+
+	if in.Tags != nil {
+		if err := s.postRepo.SetTags(ctx, post.ID, tags); err != nil {
+			return nil, err
+		}
+		post.Tags = make([]models.Tag, len(tags))
+		for i, name := range tags {
+			post.Tags[i] = models.Tag{Name: name}
+		}
+	}
 	return post, nil
 }
 
+// ListSanctumTags returns the tags used in a sanctum with their post counts.
+func (s *PostService) ListSanctumTags(ctx context.Context, sanctumID uint, limit int) ([]models.TagCount, error) {
+	return s.postRepo.ListSanctumTags(ctx, sanctumID, limit)
+}
+
+// ListPostRevisions returns the edit history of a post, oldest first. Only the
+// author and admins may read it.
+func (s *PostService) ListPostRevisions(ctx context.Context, postID, viewerID uint) ([]models.PostRevision, error) {
+	post, err := s.postRepo.GetByID(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.UserID != viewerID {
+		if s.isAdmin == nil {
+			return nil, models.NewUnauthorizedError("Only the author can view a post's revisions")
+		}
+		admin, err := s.isAdmin(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !admin {
+			return nil, models.NewUnauthorizedError("Only the author can view a post's revisions")
+		}
+	}
+
+	return s.postRepo.GetRevisions(ctx, postID)
+}
+
 // DeletePost deletes a post (owner or admin).
 func (s *PostService) DeletePost(ctx context.Context, in DeletePostInput) error {
 	post, err := s.postRepo.GetByID(ctx, in.PostID, in.UserID)
@@ -398,6 +636,9 @@ func (s *PostService) DeletePost(ctx context.Context, in DeletePostInput) error
 
 // ToggleLike adds or removes a like on a post.
 func (s *PostService) ToggleLike(ctx context.Context, userID, postID uint) (*models.Post, error) {
+	if _, err := visiblePost(ctx, s.postRepo, postID, userID); err != nil {
+		return nil, err
+	}
 	isLiked, err := s.postRepo.IsLiked(ctx, userID, postID)
 	if err != nil {
 		return nil, err
@@ -416,6 +657,49 @@ func (s *PostService) ToggleLike(ctx context.Context, userID, postID uint) (*mod
 	return s.getPostWithPollEnriched(ctx, postID, userID)
 }
 
+// BookmarkPost saves a post to the user's private bookmarks. Bookmarking a
+// post twice is a no-op.
+func (s *PostService) BookmarkPost(ctx context.Context, userID, postID uint) error {
+	if _, err := s.GetPost(ctx, postID, userID); err != nil {
+		return err
+	}
+	return s.postRepo.Bookmark(ctx, userID, postID)
+}
+
+// UnbookmarkPost removes a post from the user's bookmarks.
+func (s *PostService) UnbookmarkPost(ctx context.Context, userID, postID uint) error {
+	return s.postRepo.Unbookmark(ctx, userID, postID)
+}
+
+// ListBookmarks returns the user's bookmarked posts, most recently saved first.
+func (s *PostService) ListBookmarks(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	posts, err := s.postRepo.GetBookmarked(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		if err := s.enrichPollIfPresent(ctx, p, userID); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
+}
+
+// ListFeed returns the user's personalized feed: posts from their sanctums
+// and their friends, newest first.
+func (s *PostService) ListFeed(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	posts, err := s.postRepo.GetFeed(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		if err := s.enrichPollIfPresent(ctx, p, userID); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
+}
+
 // UnlikePost removes a like from a post.
 func (s *PostService) UnlikePost(ctx context.Context, userID, postID uint) (*models.Post, error) {
 	if err := s.postRepo.Unlike(ctx, userID, postID); err != nil {
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/repository"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 // postRepoStub is a stub for repository.PostRepository.
@@ -18,6 +22,9 @@ type postRepoStub struct {
 	getByIDFn         func(context.Context, uint, uint) (*models.Post, error)
 	getByUserIDFn     func(context.Context, uint, int, int, uint) ([]*models.Post, error)
 	getBySanctumIDFn  func(context.Context, uint, int, int, uint, string) ([]*models.Post, error)
+	getBySanctumTagFn func(context.Context, uint, string, int, int, uint, string) ([]*models.Post, error)
+	listSanctumTagsFn func(context.Context, uint, int) ([]models.TagCount, error)
+	setTagsFn         func(context.Context, uint, []string) error
 	listFn            func(context.Context, int, int, uint, string) ([]*models.Post, error)
 	searchFn          func(context.Context, string, int, int, uint) ([]*models.Post, error)
 	updateFn          func(context.Context, *models.Post) error
@@ -26,6 +33,13 @@ type postRepoStub struct {
 	getLikedPostIDsFn func(context.Context, uint, []uint) ([]uint, error)
 	likeFn            func(context.Context, uint, uint) error
 	unlikeFn          func(context.Context, uint, uint) error
+	publishDueFn      func(context.Context, time.Time) ([]*models.Post, error)
+	updateWithRevFn   func(context.Context, *models.Post, *models.PostRevision) error
+	getRevisionsFn    func(context.Context, uint) ([]models.PostRevision, error)
+	bookmarkFn        func(context.Context, uint, uint) error
+	unbookmarkFn      func(context.Context, uint, uint) error
+	getBookmarkedFn   func(context.Context, uint, int, int) ([]*models.Post, error)
+	getFeedFn         func(context.Context, uint, int, int) ([]*models.Post, error)
 }
 
 func (s *postRepoStub) Create(ctx context.Context, post *models.Post) error {
@@ -40,6 +54,15 @@ func (s *postRepoStub) GetByUserID(ctx context.Context, userID uint, limit, offs
 func (s *postRepoStub) GetBySanctumID(ctx context.Context, sanctumID uint, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error) {
 	return s.getBySanctumIDFn(ctx, sanctumID, limit, offset, currentUserID, sort)
 }
+func (s *postRepoStub) GetBySanctumTag(ctx context.Context, sanctumID uint, tag string, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error) {
+	return s.getBySanctumTagFn(ctx, sanctumID, tag, limit, offset, currentUserID, sort)
+}
+func (s *postRepoStub) ListSanctumTags(ctx context.Context, sanctumID uint, limit int) ([]models.TagCount, error) {
+	return s.listSanctumTagsFn(ctx, sanctumID, limit)
+}
+func (s *postRepoStub) SetTags(ctx context.Context, postID uint, tags []string) error {
+	return s.setTagsFn(ctx, postID, tags)
+}
 func (s *postRepoStub) List(ctx context.Context, limit, offset int, currentUserID uint, sort string) ([]*models.Post, error) {
 	return s.listFn(ctx, limit, offset, currentUserID, sort)
 }
@@ -64,13 +87,39 @@ func (s *postRepoStub) Like(ctx context.Context, userID, postID uint) error {
 func (s *postRepoStub) Unlike(ctx context.Context, userID, postID uint) error {
 	return s.unlikeFn(ctx, userID, postID)
 }
+func (s *postRepoStub) PublishDue(ctx context.Context, now time.Time) ([]*models.Post, error) {
+	return s.publishDueFn(ctx, now)
+}
+func (s *postRepoStub) UpdateWithRevision(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	return s.updateWithRevFn(ctx, post, revision)
+}
+func (s *postRepoStub) GetRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	return s.getRevisionsFn(ctx, postID)
+}
+func (s *postRepoStub) Bookmark(ctx context.Context, userID, postID uint) error {
+	return s.bookmarkFn(ctx, userID, postID)
+}
+func (s *postRepoStub) Unbookmark(ctx context.Context, userID, postID uint) error {
+	return s.unbookmarkFn(ctx, userID, postID)
+}
+func (s *postRepoStub) GetBookmarked(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	return s.getBookmarkedFn(ctx, userID, limit, offset)
+}
+func (s *postRepoStub) GetFeed(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	return s.getFeedFn(ctx, userID, limit, offset)
+}
 
 func noopPostRepo() *postRepoStub {
 	return &postRepoStub{
-		createFn:          func(_ context.Context, _ *models.Post) error { return nil },
-		getByIDFn:         func(_ context.Context, _, _ uint) (*models.Post, error) { return &models.Post{}, nil },
-		getByUserIDFn:     func(_ context.Context, _ uint, _, _ int, _ uint) ([]*models.Post, error) { return nil, nil },
-		getBySanctumIDFn:  func(_ context.Context, _ uint, _, _ int, _ uint, _ string) ([]*models.Post, error) { return nil, nil },
+		createFn:         func(_ context.Context, _ *models.Post) error { return nil },
+		getByIDFn:        func(_ context.Context, _, _ uint) (*models.Post, error) { return &models.Post{}, nil },
+		getByUserIDFn:    func(_ context.Context, _ uint, _, _ int, _ uint) ([]*models.Post, error) { return nil, nil },
+		getBySanctumIDFn: func(_ context.Context, _ uint, _, _ int, _ uint, _ string) ([]*models.Post, error) { return nil, nil },
+		getBySanctumTagFn: func(_ context.Context, _ uint, _ string, _, _ int, _ uint, _ string) ([]*models.Post, error) {
+			return nil, nil
+		},
+		listSanctumTagsFn: func(_ context.Context, _ uint, _ int) ([]models.TagCount, error) { return nil, nil },
+		setTagsFn:         func(_ context.Context, _ uint, _ []string) error { return nil },
 		listFn:            func(_ context.Context, _, _ int, _ uint, _ string) ([]*models.Post, error) { return nil, nil },
 		searchFn:          func(_ context.Context, _ string, _, _ int, _ uint) ([]*models.Post, error) { return nil, nil },
 		updateFn:          func(_ context.Context, _ *models.Post) error { return nil },
@@ -79,21 +128,28 @@ func noopPostRepo() *postRepoStub {
 		getLikedPostIDsFn: func(_ context.Context, _ uint, _ []uint) ([]uint, error) { return nil, nil },
 		likeFn:            func(_ context.Context, _, _ uint) error { return nil },
 		unlikeFn:          func(_ context.Context, _, _ uint) error { return nil },
+		publishDueFn:      func(_ context.Context, _ time.Time) ([]*models.Post, error) { return nil, nil },
+		updateWithRevFn:   func(_ context.Context, _ *models.Post, _ *models.PostRevision) error { return nil },
+		getRevisionsFn:    func(_ context.Context, _ uint) ([]models.PostRevision, error) { return nil, nil },
+		bookmarkFn:        func(_ context.Context, _, _ uint) error { return nil },
+		unbookmarkFn:      func(_ context.Context, _, _ uint) error { return nil },
+		getBookmarkedFn:   func(_ context.Context, _ uint, _, _ int) ([]*models.Post, error) { return nil, nil },
+		getFeedFn:         func(_ context.Context, _ uint, _, _ int) ([]*models.Post, error) { return nil, nil },
 	}
 }
 
 // pollRepoStub is a stub for repository.PollRepository.
 type pollRepoStub struct {
-	createFn            func(context.Context, uint, string, []string) (*models.Poll, error)
-	voteFn              func(context.Context, uint, uint, uint) error
+	createFn            func(context.Context, *models.Poll, []string) error
+	setVotesFn          func(context.Context, uint, uint, []uint) error
 	enrichWithResultsFn func(context.Context, *models.Poll, uint) error
 }
 
-func (s *pollRepoStub) Create(ctx context.Context, postID uint, question string, options []string) (*models.Poll, error) {
-	return s.createFn(ctx, postID, question, options)
+func (s *pollRepoStub) Create(ctx context.Context, poll *models.Poll, options []string) error {
+	return s.createFn(ctx, poll, options)
 }
-func (s *pollRepoStub) Vote(ctx context.Context, userID, pollID, pollOptionID uint) error {
-	return s.voteFn(ctx, userID, pollID, pollOptionID)
+func (s *pollRepoStub) SetVotes(ctx context.Context, userID, pollID uint, optionIDs []uint) error {
+	return s.setVotesFn(ctx, userID, pollID, optionIDs)
 }
 func (s *pollRepoStub) EnrichWithResults(ctx context.Context, poll *models.Poll, currentUserID uint) error {
 	return s.enrichWithResultsFn(ctx, poll, currentUserID)
@@ -101,10 +157,8 @@ func (s *pollRepoStub) EnrichWithResults(ctx context.Context, poll *models.Poll,
 
 func noopPollRepo() *pollRepoStub {
 	return &pollRepoStub{
-		createFn: func(_ context.Context, _ uint, _ string, _ []string) (*models.Poll, error) {
-			return &models.Poll{}, nil
-		},
-		voteFn:              func(_ context.Context, _, _, _ uint) error { return nil },
+		createFn:            func(_ context.Context, _ *models.Poll, _ []string) error { return nil },
+		setVotesFn:          func(_ context.Context, _, _ uint, _ []uint) error { return nil },
 		enrichWithResultsFn: func(_ context.Context, _ *models.Poll, _ uint) error { return nil },
 	}
 }
@@ -118,6 +172,15 @@ func assertValidationError(t *testing.T, err error) {
 	assert.Equal(t, "VALIDATION_ERROR", appErr.Code)
 }
 
+// assertNotFoundError asserts that err is an AppError with code NOT_FOUND.
+func assertNotFoundError(t *testing.T, err error) {
+	t.Helper()
+	require.Error(t, err)
+	var appErr *models.AppError
+	require.True(t, errors.As(err, &appErr), "expected AppError, got %T: %v", err, err)
+	assert.Equal(t, "NOT_FOUND", appErr.Code)
+}
+
 // assertUnauthorizedError asserts that err is an AppError with code UNAUTHORIZED.
 func assertUnauthorizedError(t *testing.T, err error) {
 	t.Helper()
@@ -206,9 +269,9 @@ func TestPostService_CreatePost_PollCallsRepo(t *testing.T) {
 
 	pollCreated := false
 	pr := noopPollRepo()
-	pr.createFn = func(_ context.Context, _ uint, _ string, _ []string) (*models.Poll, error) {
+	pr.createFn = func(_ context.Context, _ *models.Poll, _ []string) error {
 		pollCreated = true
-		return &models.Poll{}, nil
+		return nil
 	}
 	svc := NewPostService(noopPostRepo(), pr, nil)
 
@@ -356,3 +419,405 @@ func TestExtractImageHash(t *testing.T) {
 		})
 	}
 }
+
+func TestPostService_ScheduledPosts(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{}))
+
+	author := models.User{Username: "author", Email: "author@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&author).Error)
+
+	svc := NewPostService(repository.NewPostRepository(db), nil, nil)
+	var announced []uint
+	svc.SetPublishedHook(func(_ context.Context, p *models.Post) {
+		announced = append(announced, p.ID)
+	})
+	ctx := context.Background()
+
+	t.Run("rejects a publish time in the past", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		_, err := svc.CreatePost(ctx, CreatePostInput{UserID: author.ID, Title: "Late", Content: "x", PublishAt: &past})
+		assertValidationError(t, err)
+	})
+
+	publishAt := time.Now().Add(time.Hour)
+	post, err := svc.CreatePost(ctx, CreatePostInput{UserID: author.ID, Title: "Later", Content: "soon", PublishAt: &publishAt})
+	require.NoError(t, err)
+	assert.Equal(t, models.PostStatusScheduled, post.Status)
+
+	feedIDs := func() []uint {
+		posts, err := svc.ListPosts(ctx, ListPostsInput{Limit: 20, Sort: "top"})
+		require.NoError(t, err)
+		ids := make([]uint, 0, len(posts))
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	// Hidden from the feed and from other viewers before its time.
+	assert.Empty(t, feedIDs())
+	_, err = svc.GetPost(ctx, post.ID, author.ID+1)
+	require.Error(t, err)
+	own, err := svc.GetUserPosts(ctx, author.ID, 20, 0, author.ID)
+	require.NoError(t, err)
+	assert.Len(t, own, 1, "authors still see their scheduled posts")
+
+	n, err := svc.PublishDuePosts(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Empty(t, announced)
+
+	n, err = svc.PublishDuePosts(ctx, publishAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []uint{post.ID}, announced)
+	assert.Equal(t, []uint{post.ID}, feedIDs())
+
+	visible, err := svc.GetPost(ctx, post.ID, author.ID+1)
+	require.NoError(t, err)
+	assert.Equal(t, models.PostStatusPublished, visible.Status)
+
+	// A second pass finds nothing left to publish.
+	n, err = svc.PublishDuePosts(ctx, publishAt.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestPostService_UnpublishedPostsRejectInteractions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.CommentLike{}, &models.Poll{}, &models.PollOption{}, &models.PollVote{}))
+
+	author := models.User{Username: "author", Email: "author@example.com", Password: "pw"}
+	other := models.User{Username: "other", Email: "other@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&author).Error)
+	require.NoError(t, db.Create(&other).Error)
+
+	postRepo := repository.NewPostRepository(db)
+	posts := NewPostService(postRepo, repository.NewPollRepository(db), nil)
+	comments := NewCommentService(repository.NewCommentRepository(db), postRepo, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{"scheduled", models.PostStatusScheduled},
+		{"awaiting sanctum approval", models.PostStatusPendingReview},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post, err := posts.CreatePost(ctx, CreatePostInput{
+				UserID:   author.ID,
+				Title:    tt.name,
+				Content:  "not yet",
+				PostType: models.PostTypePoll,
+				Poll:     &CreatePostPollInput{Question: "Which?", Options: []string{"a", "b"}},
+			})
+			require.NoError(t, err)
+			require.NoError(t, db.Model(&models.Post{}).Where("id = ?", post.ID).Update("status", tt.status).Error)
+			stored, err := posts.GetPost(ctx, post.ID, author.ID)
+			require.NoError(t, err)
+			require.NotNil(t, stored.Poll)
+			option := stored.Poll.Options[0].ID
+
+			_, err = posts.VotePoll(ctx, other.ID, post.ID, []uint{option})
+			assertNotFoundError(t, err)
+			_, err = posts.ToggleLike(ctx, other.ID, post.ID)
+			assertNotFoundError(t, err)
+			_, err = comments.CreateComment(ctx, CreateCommentInput{UserID: other.ID, PostID: post.ID, Content: "first"})
+			assertNotFoundError(t, err)
+			_, err = comments.ListComments(ctx, post.ID, other.ID, "")
+			assertNotFoundError(t, err)
+
+			var likes, votes int64
+			require.NoError(t, db.Model(&models.Like{}).Where("post_id = ?", post.ID).Count(&likes).Error)
+			require.NoError(t, db.Model(&models.PollVote{}).Count(&votes).Error)
+			assert.Zero(t, likes)
+			assert.Zero(t, votes)
+
+			// The author can still work on their own post.
+			_, err = comments.CreateComment(ctx, CreateCommentInput{UserID: author.ID, PostID: post.ID, Content: "note"})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPostService_Bookmarks(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Bookmark{}, &models.Poll{}, &models.PollOption{}))
+
+	alice := models.User{Username: "alice", Email: "alice@example.com", Password: "pw"}
+	bob := models.User{Username: "bob", Email: "bob@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&bob).Error)
+
+	svc := NewPostService(repository.NewPostRepository(db), nil, nil)
+	ctx := context.Background()
+
+	var postIDs []uint
+	for _, title := range []string{"first", "second", "third"} {
+		post, err := svc.CreatePost(ctx, CreatePostInput{UserID: bob.ID, Title: title, Content: "body"})
+		require.NoError(t, err)
+		postIDs = append(postIDs, post.ID)
+	}
+
+	bookmarkedIDs := func(userID uint) []uint {
+		posts, err := svc.ListBookmarks(ctx, userID, 20, 0)
+		require.NoError(t, err)
+		ids := make([]uint, 0, len(posts))
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	require.NoError(t, svc.BookmarkPost(ctx, alice.ID, postIDs[0]))
+	require.NoError(t, svc.BookmarkPost(ctx, alice.ID, postIDs[2]))
+	// Bookmarking again is idempotent.
+	require.NoError(t, svc.BookmarkPost(ctx, alice.ID, postIDs[0]))
+	// Force a deterministic save order regardless of clock resolution.
+	require.NoError(t, db.Model(&models.Bookmark{}).Where("post_id = ?", postIDs[0]).
+		Update("created_at", time.Now().Add(-time.Hour)).Error)
+	require.NoError(t, svc.BookmarkPost(ctx, bob.ID, postIDs[1]))
+
+	assert.Equal(t, []uint{postIDs[2], postIDs[0]}, bookmarkedIDs(alice.ID), "newest bookmark first")
+	assert.Equal(t, []uint{postIDs[1]}, bookmarkedIDs(bob.ID), "bookmarks are scoped to their owner")
+
+	require.NoError(t, svc.UnbookmarkPost(ctx, alice.ID, postIDs[2]))
+	assert.Equal(t, []uint{postIDs[0]}, bookmarkedIDs(alice.ID))
+
+	err = svc.BookmarkPost(ctx, alice.ID, 9999)
+	require.Error(t, err)
+}
+
+func TestPostService_Revisions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.PostRevision{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{}))
+
+	author := models.User{Username: "author", Email: "author@example.com", Password: "pw"}
+	other := models.User{Username: "other", Email: "other@example.com", Password: "pw"}
+	admin := models.User{Username: "admin", Email: "admin@example.com", Password: "pw", IsAdmin: true}
+	for _, u := range []*models.User{&author, &other, &admin} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	isAdmin := func(_ context.Context, userID uint) (bool, error) { return userID == admin.ID, nil }
+	svc := NewPostService(repository.NewPostRepository(db), nil, isAdmin)
+	ctx := context.Background()
+
+	post, err := svc.CreatePost(ctx, CreatePostInput{UserID: author.ID, Title: "v1", Content: "first draft"})
+	require.NoError(t, err)
+
+	_, err = svc.UpdatePost(ctx, UpdatePostInput{UserID: author.ID, PostID: post.ID, Title: "v2"})
+	require.NoError(t, err)
+	_, err = svc.UpdatePost(ctx, UpdatePostInput{UserID: author.ID, PostID: post.ID, Content: "final draft"})
+	require.NoError(t, err)
+	// A no-op edit leaves no revision behind.
+	_, err = svc.UpdatePost(ctx, UpdatePostInput{UserID: author.ID, PostID: post.ID, Title: "v2"})
+	require.NoError(t, err)
+
+	revisions, err := svc.ListPostRevisions(ctx, post.ID, author.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "v1", revisions[0].Title)
+	assert.Equal(t, "first draft", revisions[0].Content)
+	assert.Equal(t, "v2", revisions[1].Title)
+	assert.Equal(t, "first draft", revisions[1].Content)
+	assert.Equal(t, author.ID, revisions[1].EditorID)
+
+	_, err = svc.ListPostRevisions(ctx, post.ID, other.ID)
+	assertUnauthorizedError(t, err)
+
+	revisions, err = svc.ListPostRevisions(ctx, post.ID, admin.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2)
+}
+
+func TestPostService_PollVoting(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.CommentLike{}, &models.Poll{}, &models.PollOption{}, &models.PollVote{}))
+
+	voter := models.User{Username: "voter", Email: "voter@example.com", Password: "pw"}
+	require.NoError(t, db.Create(&voter).Error)
+
+	svc := NewPostService(repository.NewPostRepository(db), repository.NewPollRepository(db), nil)
+	ctx := context.Background()
+
+	createPoll := func(allowMultiple bool, closesAt *time.Time) *models.Post {
+		post, err := svc.CreatePost(ctx, CreatePostInput{
+			UserID:   voter.ID,
+			Title:    "Poll",
+			PostType: models.PostTypePoll,
+			Poll: &CreatePostPollInput{
+				Question:      "Pick",
+				Options:       []string{"a", "b", "c"},
+				AllowMultiple: allowMultiple,
+				ClosesAt:      closesAt,
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, post.Poll)
+		require.Len(t, post.Poll.Options, 3)
+		return post
+	}
+	optionIDs := func(post *models.Post, idx ...int) []uint {
+		ids := make([]uint, 0, len(idx))
+		for _, i := range idx {
+			ids = append(ids, post.Poll.Options[i].ID)
+		}
+		return ids
+	}
+	counts := func(post *models.Post) []int {
+		out := make([]int, 0, len(post.Poll.Options))
+		for _, opt := range post.Poll.Options {
+			out = append(out, opt.VotesCount)
+		}
+		return out
+	}
+
+	t.Run("multi-select records every chosen option", func(t *testing.T) {
+		post := createPoll(true, nil)
+		voted, err := svc.VotePoll(ctx, voter.ID, post.ID, optionIDs(post, 0, 2, 0))
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 0, 1}, counts(voted))
+		assert.Equal(t, optionIDs(post, 0, 2), voted.Poll.UserVoteOptionIDs)
+	})
+
+	t.Run("single-choice rejects several options", func(t *testing.T) {
+		post := createPoll(false, nil)
+		_, err := svc.VotePoll(ctx, voter.ID, post.ID, optionIDs(post, 0, 1))
+		assertValidationError(t, err)
+	})
+
+	t.Run("voting again replaces the selection", func(t *testing.T) {
+		post := createPoll(true, nil)
+		_, err := svc.VotePoll(ctx, voter.ID, post.ID, optionIDs(post, 0, 1))
+		require.NoError(t, err)
+		voted, err := svc.VotePoll(ctx, voter.ID, post.ID, optionIDs(post, 2))
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 0, 1}, counts(voted))
+		require.NotNil(t, voted.Poll.UserVoteOptionID)
+		assert.Equal(t, post.Poll.Options[2].ID, *voted.Poll.UserVoteOptionID)
+	})
+
+	t.Run("votes after closing are rejected", func(t *testing.T) {
+		closesAt := time.Now().Add(time.Hour)
+		post := createPoll(false, &closesAt)
+		_, err := svc.VotePoll(ctx, voter.ID, post.ID, optionIDs(post, 0))
+		require.NoError(t, err)
+
+		require.NoError(t, db.Model(&models.Poll{}).Where("id = ?", post.Poll.ID).
+			Update("closes_at", time.Now().Add(-time.Minute)).Error)
+		_, err = svc.VotePoll(ctx, voter.ID, post.ID, optionIDs(post, 1))
+		assertValidationError(t, err)
+	})
+
+	t.Run("closing time must be in the future", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		_, err := svc.CreatePost(ctx, CreatePostInput{
+			UserID:   voter.ID,
+			Title:    "Poll",
+			PostType: models.PostTypePoll,
+			Poll:     &CreatePostPollInput{Question: "Pick", Options: []string{"a", "b"}, ClosesAt: &past},
+		})
+		assertValidationError(t, err)
+	})
+}
+
+func TestPostService_Feed(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{},
+		&models.Sanctum{}, &models.SanctumMembership{}, &models.Friendship{}, &models.UserBlock{}))
+
+	users := map[string]*models.User{}
+	for _, name := range []string{"reader", "friend", "member", "stranger", "blocked", "blocker"} {
+		u := &models.User{Username: name, Email: name + "@example.com", Password: "pw"}
+		require.NoError(t, db.Create(u).Error)
+		users[name] = u
+	}
+	reader := users["reader"]
+
+	svc := NewPostService(repository.NewPostRepository(db), nil, nil)
+	ctx := context.Background()
+
+	feedTitles := func() []string {
+		posts, err := svc.ListFeed(ctx, reader.ID, 20, 0)
+		require.NoError(t, err)
+		titles := make([]string, 0, len(posts))
+		for _, p := range posts {
+			titles = append(titles, p.Title)
+		}
+		return titles
+	}
+
+	followed := models.Sanctum{Name: "Followed", Slug: "followed", Status: models.SanctumStatusActive}
+	other := models.Sanctum{Name: "Other", Slug: "other", Status: models.SanctumStatusActive}
+	require.NoError(t, db.Create(&followed).Error)
+	require.NoError(t, db.Create(&other).Error)
+
+	post := func(author, title string, sanctumID *uint) {
+		_, err := svc.CreatePost(ctx, CreatePostInput{UserID: users[author].ID, Title: title, Content: "body", SanctumID: sanctumID})
+		require.NoError(t, err)
+	}
+	post("member", "sanctum post", &followed.ID)
+	post("stranger", "unrelated sanctum post", &other.ID)
+	post("stranger", "stranger post", nil)
+
+	assert.Empty(t, feedTitles(), "no memberships or friends means an empty feed")
+
+	require.NoError(t, db.Create(&models.SanctumMembership{SanctumID: followed.ID, UserID: reader.ID, Role: models.SanctumMembershipRoleMember}).Error)
+	require.NoError(t, db.Create(&models.Friendship{RequesterID: users["friend"].ID, AddresseeID: reader.ID, Status: models.FriendshipStatusAccepted}).Error)
+	post("friend", "friend post", nil)
+	post("blocked", "blocked in sanctum", &followed.ID)
+	post("blocker", "blocker in sanctum", &followed.ID)
+	require.NoError(t, db.Create(&models.UserBlock{BlockerID: reader.ID, BlockedID: users["blocked"].ID}).Error)
+	require.NoError(t, db.Create(&models.UserBlock{BlockerID: users["blocker"].ID, BlockedID: reader.ID}).Error)
+
+	assert.Equal(t, []string{"friend post", "sanctum post"}, feedTitles(), "newest first")
+}
+
+func TestPostService_ListPostsHidesDeactivatedAuthors(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{}, &models.Poll{}, &models.PollOption{},
+		&models.Sanctum{}, &models.SanctumMembership{}, &models.Friendship{}, &models.UserBlock{}))
+
+	stays := &models.User{Username: "stays", Email: "stays@example.com", Password: "pw"}
+	leaves := &models.User{Username: "leaves", Email: "leaves@example.com", Password: "pw"}
+	require.NoError(t, db.Create(stays).Error)
+	require.NoError(t, db.Create(leaves).Error)
+
+	svc := NewPostService(repository.NewPostRepository(db), nil, nil)
+	ctx := context.Background()
+	for _, in := range []CreatePostInput{
+		{UserID: stays.ID, Title: "kept", Content: "body"},
+		{UserID: leaves.ID, Title: "hidden", Content: "body"},
+	} {
+		_, err := svc.CreatePost(ctx, in)
+		require.NoError(t, err)
+	}
+
+	titles := func() []string {
+		posts, err := svc.ListPosts(ctx, ListPostsInput{Limit: 20})
+		require.NoError(t, err)
+		out := make([]string, 0, len(posts))
+		for _, p := range posts {
+			out = append(out, p.Title)
+		}
+		return out
+	}
+	assert.ElementsMatch(t, []string{"kept", "hidden"}, titles())
+
+	require.NoError(t, repository.NewUserRepository(db).Deactivate(ctx, leaves.ID, time.Now(), true))
+	assert.Equal(t, []string{"kept"}, titles())
+
+	_, err = repository.NewUserRepository(db).Reactivate(ctx, leaves.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"kept", "hidden"}, titles(), "reactivated authors reappear")
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	pushDeliveryTimeout = 30 * time.Second
+	// pushPreviewMaxRunes keeps payloads well under the 4KB Web Push record.
+	pushPreviewMaxRunes = 200
+)
+
+// PushMessagePayload is the JSON body delivered to a user's browsers when a
+// direct message arrives while they are offline.
+type PushMessagePayload struct {
+	Type           string `json:"type"`
+	ConversationID uint   `json:"conversation_id"`
+	MessageID      uint   `json:"message_id"`
+	SenderID       uint   `json:"sender_id"`
+	SenderUsername string `json:"sender_username,omitempty"`
+	Preview        string `json:"preview"`
+}
+
+// PushService stores browser push subscriptions and fans messages out to
+// them. A nil sender disables delivery while subscriptions are still kept.
+type PushService struct {
+	db     *gorm.DB
+	sender PushSender
+}
+
+// NewPushService returns a new PushService.
+func NewPushService(db *gorm.DB, sender PushSender) *PushService {
+	return &PushService{db: db, sender: sender}
+}
+
+// Enabled reports whether push messages are actually delivered.
+func (s *PushService) Enabled() bool {
+	return s != nil && s.sender != nil
+}
+
+// SubscribeInput is the input for Subscribe.
+type SubscribeInput struct {
+	UserID    uint
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	UserAgent string
+}
+
+// Subscribe registers a browser subscription for the user. Re-subscribing the
+// same endpoint refreshes its keys; an endpoint registered to another user is
+// rejected rather than taken over.
+func (s *PushService) Subscribe(ctx context.Context, in SubscribeInput) (*models.PushSubscription, error) {
+	endpoint, err := url.Parse(in.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, models.NewValidationError("Push endpoint must be an https URL")
+	}
+	if isPrivateHost(endpoint.Hostname()) {
+		return nil, models.NewValidationError("Push endpoint must point to a public host")
+	}
+	if in.P256dh == "" || in.Auth == "" {
+		return nil, models.NewValidationError("Push subscription keys are required")
+	}
+
+	sub := &models.PushSubscription{
+		UserID:    in.UserID,
+		Endpoint:  in.Endpoint,
+		P256dh:    in.P256dh,
+		Auth:      in.Auth,
+		UserAgent: in.UserAgent,
+	}
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "push_subscriptions.user_id", Value: in.UserID}}},
+		DoUpdates: clause.AssignmentColumns([]string{"p256dh", "auth", "user_agent", "updated_at"}),
+	}).Create(sub)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, models.NewConflictError("Push endpoint is registered to another account")
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes one of the user's subscriptions by endpoint.
+func (s *PushService) Unsubscribe(ctx context.Context, userID uint, endpoint string) error {
+	return s.db.WithContext(ctx).
+		Where("user_id = ? AND endpoint = ?", userID, endpoint).
+		Delete(&models.PushSubscription{}).Error
+}
+
+// DispatchMessage pushes a direct message to each recipient's browsers in the
+// background. Callers decide which recipients are offline.
+func (s *PushService) DispatchMessage(message *models.Message, recipientIDs []uint) {
+	if !s.Enabled() || message == nil || len(recipientIDs) == 0 {
+		return
+	}
+	payload := PushMessagePayload{
+		Type:           "message_received",
+		ConversationID: message.ConversationID,
+		MessageID:      message.ID,
+		SenderID:       message.SenderID,
+		Preview:        truncateRunes(message.Content, pushPreviewMaxRunes),
+	}
+	if message.Sender != nil {
+		payload.SenderUsername = message.Sender.Username
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	for _, userID := range recipientIDs {
+		go func(userID uint) {
+			ctx, cancel := context.WithTimeout(context.Background(), pushDeliveryTimeout)
+			defer cancel()
+			if err := s.NotifyUser(ctx, userID, body); err != nil {
+				log.Printf("push delivery to user %d failed: %v", userID, err)
+			}
+		}(userID)
+	}
+}
+
+// NotifyUser sends payload to every subscription the user has, forgetting
+// subscriptions the push service reports as gone.
+func (s *PushService) NotifyUser(ctx context.Context, userID uint, payload []byte) error {
+	if !s.Enabled() {
+		return nil
+	}
+	var subs []models.PushSubscription
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Find(&subs).Error; err != nil {
+		if models.IsSchemaMissingError(err) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	for i := range subs {
+		err := s.sender.Send(ctx, &subs[i], payload)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrPushSubscriptionGone):
+			if delErr := s.db.WithContext(ctx).Delete(&models.PushSubscription{}, subs[i].ID).Error; delErr != nil {
+				errs = append(errs, delErr)
+			}
+		default:
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// InactiveOwnerSanctum is one row of the at-risk sanctum report: an active
+// sanctum whose owner has not posted, commented or chatted within the window.
+type InactiveOwnerSanctum struct {
+	SanctumID         uint      `json:"sanctum_id"`
+	Slug              string    `json:"slug"`
+	Name              string    `json:"name"`
+	OwnerUserID       uint      `json:"owner_user_id"`
+	OwnerLastActiveAt time.Time `json:"owner_last_active_at"`
+	CandidateUserID   *uint     `json:"candidate_user_id"` // nil means the sanctum would be archived
+}
+
+// OwnershipReassignment describes the outcome of ReassignInactiveOwner.
+type OwnershipReassignment struct {
+	SanctumID           uint  `json:"sanctum_id"`
+	PreviousOwnerUserID uint  `json:"previous_owner_user_id"`
+	NewOwnerUserID      *uint `json:"new_owner_user_id"`
+	Archived            bool  `json:"archived"`
+}
+
+// SanctumOwnershipService detects inactive sanctum owners and hands their
+// sanctums to the longest-tenured active moderator.
+type SanctumOwnershipService struct {
+	db *gorm.DB
+}
+
+// NewSanctumOwnershipService returns a new SanctumOwnershipService.
+func NewSanctumOwnershipService(db *gorm.DB) *SanctumOwnershipService {
+	return &SanctumOwnershipService{db: db}
+}
+
+// FindInactiveOwners reports active sanctums whose owner has been inactive
+// since before now-inactiveFor, with the member who would take over.
+func (s *SanctumOwnershipService) FindInactiveOwners(ctx context.Context, inactiveFor time.Duration, now time.Time) ([]InactiveOwnerSanctum, error) {
+	if inactiveFor <= 0 {
+		return nil, models.NewValidationError("Inactivity period must be positive")
+	}
+	cutoff := now.Add(-inactiveFor)
+
+	var owners []models.SanctumMembership
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN sanctums ON sanctums.id = sanctum_memberships.sanctum_id").
+		Where("sanctum_memberships.role = ? AND sanctums.status = ?", models.SanctumMembershipRoleOwner, models.SanctumStatusActive).
+		Preload("Sanctum").
+		Order("sanctum_memberships.sanctum_id ASC").
+		Find(&owners).Error; err != nil {
+		return nil, err
+	}
+
+	ownerIDs := make([]uint, 0, len(owners))
+	for _, owner := range owners {
+		ownerIDs = append(ownerIDs, owner.UserID)
+	}
+	lastActive, err := s.lastActivity(ctx, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]InactiveOwnerSanctum, 0)
+	for _, owner := range owners {
+		if lastActive[owner.UserID].After(cutoff) || owner.Sanctum == nil {
+			continue
+		}
+		candidate, err := s.successor(ctx, owner.SanctumID, cutoff)
+		if err != nil {
+			return nil, err
+		}
+		row := InactiveOwnerSanctum{
+			SanctumID:         owner.SanctumID,
+			Slug:              owner.Sanctum.Slug,
+			Name:              owner.Sanctum.Name,
+			OwnerUserID:       owner.UserID,
+			OwnerLastActiveAt: lastActive[owner.UserID],
+		}
+		if candidate != nil {
+			row.CandidateUserID = &candidate.UserID
+		}
+		report = append(report, row)
+	}
+	return report, nil
+}
+
+// ReassignInactiveOwner demotes an inactive owner to member and promotes the
+// longest-tenured active moderator to owner. When no moderator is active the
+// sanctum is archived instead.
+func (s *SanctumOwnershipService) ReassignInactiveOwner(ctx context.Context, sanctumID uint, inactiveFor time.Duration, now time.Time) (*OwnershipReassignment, error) {
+	if inactiveFor <= 0 {
+		return nil, models.NewValidationError("Inactivity period must be positive")
+	}
+	cutoff := now.Add(-inactiveFor)
+
+	var owner models.SanctumMembership
+	if err := s.db.WithContext(ctx).
+		Where("sanctum_id = ? AND role = ?", sanctumID, models.SanctumMembershipRoleOwner).
+		Order("created_at ASC").
+		First(&owner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Sanctum owner", sanctumID)
+		}
+		return nil, err
+	}
+
+	lastActive, err := s.lastActivity(ctx, []uint{owner.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if lastActive[owner.UserID].After(cutoff) {
+		return nil, models.NewValidationError("Sanctum owner is still active")
+	}
+
+	candidate, err := s.successor(ctx, sanctumID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OwnershipReassignment{SanctumID: sanctumID, PreviousOwnerUserID: owner.UserID}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if candidate == nil {
+			result.Archived = true
+			return tx.Model(&models.Sanctum{}).
+				Where("id = ?", sanctumID).
+				Updates(map[string]any{"status": models.SanctumStatusArchived, "updated_at": now}).Error
+		}
+
+		if err := tx.Model(&models.SanctumMembership{}).
+			Where("sanctum_id = ? AND user_id = ?", sanctumID, owner.UserID).
+			Updates(map[string]any{"role": models.SanctumMembershipRoleMember, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SanctumMembership{}).
+			Where("sanctum_id = ? AND user_id = ?", sanctumID, candidate.UserID).
+			Updates(map[string]any{"role": models.SanctumMembershipRoleOwner, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		result.NewOwnerUserID = &candidate.UserID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// successor returns the longest-tenured moderator active since cutoff.
+func (s *SanctumOwnershipService) successor(ctx context.Context, sanctumID uint, cutoff time.Time) (*models.SanctumMembership, error) {
+	var mods []models.SanctumMembership
+	if err := s.db.WithContext(ctx).
+		Where("sanctum_id = ? AND role = ?", sanctumID, models.SanctumMembershipRoleMod).
+		Order("created_at ASC").
+		Order("user_id ASC").
+		Find(&mods).Error; err != nil {
+		return nil, err
+	}
+	if len(mods) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, 0, len(mods))
+	for _, mod := range mods {
+		ids = append(ids, mod.UserID)
+	}
+	lastActive, err := s.lastActivity(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mods {
+		if lastActive[mods[i].UserID].After(cutoff) {
+			return &mods[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// lastActivity returns, per user, the most recent of account creation and the
+// newest post, comment or chat message they authored.
+func (s *SanctumOwnershipService) lastActivity(ctx context.Context, userIDs []uint) (map[uint]time.Time, error) {
+	latest := make(map[uint]time.Time, len(userIDs))
+	if len(userIDs) == 0 {
+		return latest, nil
+	}
+
+	sources := []struct {
+		table     string
+		userCol   string
+		createdAt string
+	}{
+		{table: "users", userCol: "id", createdAt: "created_at"},
+		{table: "posts", userCol: "user_id", createdAt: "created_at"},
+		{table: "comments", userCol: "user_id", createdAt: "created_at"},
+		{table: "messages", userCol: "sender_id", createdAt: "created_at"},
+	}
+	for _, src := range sources {
+		var rows []struct {
+			UserID uint
+			Latest aggregateTime
+		}
+		if err := s.db.WithContext(ctx).
+			Table(src.table).
+			Select(src.userCol+" AS user_id, MAX("+src.createdAt+") AS latest").
+			Where(src.userCol+" IN ?", userIDs).
+			Group(src.userCol).
+			Scan(&rows).Error; err != nil {
+			if models.IsSchemaMissingError(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, row := range rows {
+			if ts := time.Time(row.Latest); ts.After(latest[row.UserID]) {
+				latest[row.UserID] = ts
+			}
+		}
+	}
+	return latest, nil
+}
+
+// aggregateTime scans MAX(timestamp) results, which Postgres returns as a
+// time but SQLite returns as text.
+type aggregateTime time.Time
+
+var aggregateTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// Scan implements sql.Scanner.
+func (t *aggregateTime) Scan(value any) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*t = aggregateTime{}
+		return nil
+	case time.Time:
+		*t = aggregateTime(v)
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("unsupported timestamp type %T", value)
+	}
+	for _, layout := range aggregateTimeLayouts {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			*t = aggregateTime(parsed)
+			return nil
+		}
+	}
+	return fmt.Errorf("unparseable timestamp %q", raw)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type ownershipFixture struct {
+	db      *gorm.DB
+	svc     *SanctumOwnershipService
+	now     time.Time
+	sanctum models.Sanctum
+}
+
+func setupOwnershipFixture(t *testing.T) *ownershipFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Post{},
+		&models.Comment{},
+		&models.Conversation{},
+		&models.Message{},
+		&models.Sanctum{},
+		&models.SanctumMembership{},
+	))
+
+	sanctum := models.Sanctum{Name: "Quiet", Slug: "quiet", Status: models.SanctumStatusActive}
+	require.NoError(t, db.Create(&sanctum).Error)
+
+	return &ownershipFixture{
+		db:      db,
+		svc:     NewSanctumOwnershipService(db),
+		now:     time.Now().UTC(),
+		sanctum: sanctum,
+	}
+}
+
+// addMember creates a user who last acted activeAgo before now and joined the
+// sanctum joinedAgo before now.
+func (f *ownershipFixture) addMember(t *testing.T, name string, role models.SanctumMembershipRole, joinedAgo, activeAgo time.Duration) models.User {
+	t.Helper()
+	user := models.User{
+		Username:  name,
+		Email:     fmt.Sprintf("%s@e.com", name),
+		CreatedAt: f.now.Add(-activeAgo),
+	}
+	require.NoError(t, f.db.Create(&user).Error)
+	require.NoError(t, f.db.Create(&models.SanctumMembership{
+		SanctumID: f.sanctum.ID,
+		UserID:    user.ID,
+		Role:      role,
+		CreatedAt: f.now.Add(-joinedAgo),
+	}).Error)
+	return user
+}
+
+func TestSanctumOwnershipService_FlagsInactiveOwner(t *testing.T) {
+	f := setupOwnershipFixture(t)
+	const day = 24 * time.Hour
+
+	owner := f.addMember(t, "owner", models.SanctumMembershipRoleOwner, 400*day, 200*day)
+	mod := f.addMember(t, "mod", models.SanctumMembershipRoleMod, 100*day, 200*day)
+	// A recent post keeps the moderator active even though the account is old.
+	require.NoError(t, f.db.Create(&models.Post{Title: "hi", Content: "hi", UserID: mod.ID, CreatedAt: f.now.Add(-2 * day)}).Error)
+
+	report, err := f.svc.FindInactiveOwners(context.Background(), 90*day, f.now)
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, f.sanctum.ID, report[0].SanctumID)
+	assert.Equal(t, owner.ID, report[0].OwnerUserID)
+	require.NotNil(t, report[0].CandidateUserID)
+	assert.Equal(t, mod.ID, *report[0].CandidateUserID)
+
+	// Once the owner posts again the sanctum drops off the report.
+	require.NoError(t, f.db.Create(&models.Post{Title: "back", Content: "back", UserID: owner.ID, CreatedAt: f.now.Add(-day)}).Error)
+	report, err = f.svc.FindInactiveOwners(context.Background(), 90*day, f.now)
+	require.NoError(t, err)
+	assert.Empty(t, report)
+}
+
+func TestSanctumOwnershipService_ReassignPromotesLongestTenuredActiveMod(t *testing.T) {
+	f := setupOwnershipFixture(t)
+	const day = 24 * time.Hour
+
+	owner := f.addMember(t, "owner", models.SanctumMembershipRoleOwner, 400*day, 200*day)
+	f.addMember(t, "idle_veteran", models.SanctumMembershipRoleMod, 300*day, 150*day)
+	senior := f.addMember(t, "senior", models.SanctumMembershipRoleMod, 200*day, 5*day)
+	f.addMember(t, "junior", models.SanctumMembershipRoleMod, 10*day, day)
+	f.addMember(t, "member", models.SanctumMembershipRoleMember, 350*day, day)
+
+	result, err := f.svc.ReassignInactiveOwner(context.Background(), f.sanctum.ID, 90*day, f.now)
+	require.NoError(t, err)
+	assert.False(t, result.Archived)
+	require.NotNil(t, result.NewOwnerUserID)
+	assert.Equal(t, senior.ID, *result.NewOwnerUserID)
+
+	var roles []models.SanctumMembership
+	require.NoError(t, f.db.Where("sanctum_id = ? AND user_id IN ?", f.sanctum.ID, []uint{owner.ID, senior.ID}).Find(&roles).Error)
+	byUser := map[uint]models.SanctumMembershipRole{}
+	for _, m := range roles {
+		byUser[m.UserID] = m.Role
+	}
+	assert.Equal(t, models.SanctumMembershipRoleMember, byUser[owner.ID])
+	assert.Equal(t, models.SanctumMembershipRoleOwner, byUser[senior.ID])
+}
+
+func TestSanctumOwnershipService_ReassignArchivesWithoutActiveMod(t *testing.T) {
+	f := setupOwnershipFixture(t)
+	const day = 24 * time.Hour
+
+	f.addMember(t, "owner", models.SanctumMembershipRoleOwner, 400*day, 200*day)
+	f.addMember(t, "idle_mod", models.SanctumMembershipRoleMod, 300*day, 150*day)
+
+	result, err := f.svc.ReassignInactiveOwner(context.Background(), f.sanctum.ID, 90*day, f.now)
+	require.NoError(t, err)
+	assert.True(t, result.Archived)
+	assert.Nil(t, result.NewOwnerUserID)
+
+	var sanctum models.Sanctum
+	require.NoError(t, f.db.First(&sanctum, f.sanctum.ID).Error)
+	assert.Equal(t, models.SanctumStatusArchived, sanctum.Status)
+}
+
+func TestSanctumOwnershipService_ReassignRejectsActiveOwner(t *testing.T) {
+	f := setupOwnershipFixture(t)
+	const day = 24 * time.Hour
+
+	f.addMember(t, "owner", models.SanctumMembershipRoleOwner, 400*day, day)
+
+	_, err := f.svc.ReassignInactiveOwner(context.Background(), f.sanctum.ID, 90*day, f.now)
+	assertAppErrorCode(t, err, "VALIDATION_ERROR")
+}
@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"regexp"
+	"strings"
+	"time"
 
 	"sanctum/internal/models"
 	"sanctum/internal/repository"
+	"sanctum/internal/validation"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // UserService provides user and profile business logic.
@@ -18,6 +24,8 @@ type UpdateProfileInput struct {
 	Username string
 	Bio      string
 	Avatar   string
+	// NotifyReportUpdates is left unchanged when nil.
+	NotifyReportUpdates *bool
 }
 
 // NewUserService returns a new UserService.
@@ -35,12 +43,43 @@ func (s *UserService) SearchUsers(ctx context.Context, q string, limit, offset i
 	return s.userRepo.Search(ctx, q, limit, offset)
 }
 
+// MaxMentionSuggestions caps how many mention suggestions a single request
+// returns.
+const MaxMentionSuggestions = 20
+
+// mentionInteractionWindow is how far back a shared conversation counts as
+// recent interaction when ranking mention suggestions.
+const mentionInteractionWindow = 30 * 24 * time.Hour
+
+var mentionPrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_]{1,32}$`)
+
+// SuggestMentions returns users whose username starts with prefix for
+// @-autocomplete, ranked for viewerID. A leading @ is ignored; at least one
+// character must follow it so the whole user list cannot be paged through.
+func (s *UserService) SuggestMentions(ctx context.Context, viewerID uint, prefix string, limit, offset int) ([]models.MentionSuggestion, error) {
+	prefix = strings.TrimPrefix(strings.TrimSpace(prefix), "@")
+	if prefix == "" {
+		return nil, models.NewValidationError("q must contain at least 1 character")
+	}
+	if !mentionPrefixPattern.MatchString(prefix) {
+		return nil, models.NewValidationError("q may only contain letters, numbers and underscores")
+	}
+	if limit <= 0 || limit > MaxMentionSuggestions {
+		limit = MaxMentionSuggestions
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	since := time.Now().UTC().Add(-mentionInteractionWindow)
+	return s.userRepo.SuggestMentions(ctx, viewerID, strings.ToLower(prefix), since, limit, offset)
+}
+
 // GetUserByID returns a user by ID.
 func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
 	return s.userRepo.GetByID(ctx, id)
 }
 
-// UpdateProfile updates the user profile (username, bio, avatar).
+// UpdateProfile updates the user profile (username, bio, avatar) and notification preferences.
 func (s *UserService) UpdateProfile(ctx context.Context, in UpdateProfileInput) (*models.User, error) {
 	user, err := s.userRepo.GetByID(ctx, in.UserID)
 	if err != nil {
@@ -65,6 +104,9 @@ func (s *UserService) UpdateProfile(ctx context.Context, in UpdateProfileInput)
 	if in.Avatar != "" {
 		user.Avatar = in.Avatar
 	}
+	if in.NotifyReportUpdates != nil {
+		user.NotifyReportUpdates = *in.NotifyReportUpdates
+	}
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, err
@@ -73,6 +115,101 @@ func (s *UserService) UpdateProfile(ctx context.Context, in UpdateProfileInput)
 	return user, nil
 }
 
+// ChangePassword replaces the user's password after re-checking the current one.
+func (s *UserService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	user, err := s.reauthenticate(ctx, userID, currentPassword)
+	if err != nil {
+		return err
+	}
+	if err := validation.ValidatePassword(newPassword); err != nil {
+		return models.NewValidationError(err.Error())
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(newPassword)) == nil {
+		return models.NewValidationError("New password must be different from the current password")
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return models.NewInternalError(err)
+	}
+	user.Password = string(hashed)
+	return s.userRepo.Update(ctx, user)
+}
+
+// ChangeEmail moves the account to a new email address after re-checking the
+// password. The address must not belong to another account.
+func (s *UserService) ChangeEmail(ctx context.Context, userID uint, password, email string) (*models.User, error) {
+	user, err := s.reauthenticate(ctx, userID, password)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.TrimSpace(email)
+	if err := validation.ValidateEmail(email); err != nil {
+		return nil, models.NewValidationError(err.Error())
+	}
+	if strings.EqualFold(email, user.Email) {
+		return nil, models.NewValidationError("New email must be different from the current email")
+	}
+
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != user.ID {
+		return nil, models.NewConflictError("Email is already in use")
+	}
+
+	user.Email = email
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeactivateAccount deletes the user's own account after re-checking the
+// password. With a positive grace window the account can still be restored
+// by logging in with reactivation until the window closes.
+func (s *UserService) DeactivateAccount(ctx context.Context, userID uint, password string, grace time.Duration) error {
+	user, err := s.reauthenticate(ctx, userID, password)
+	if err != nil {
+		return err
+	}
+	if user.IsDeactivated() {
+		return models.NewValidationError("Account is already deactivated")
+	}
+	return s.userRepo.Deactivate(ctx, userID, time.Now().UTC(), grace > 0)
+}
+
+// ReactivateAccount restores a deactivated account while its grace window is
+// still open.
+func (s *UserService) ReactivateAccount(ctx context.Context, user *models.User, grace time.Duration, now time.Time) (*models.User, error) {
+	if !CanReactivate(user, grace, now) {
+		return nil, models.NewForbiddenError("This account can no longer be reactivated")
+	}
+	return s.userRepo.Reactivate(ctx, user.ID)
+}
+
+// CanReactivate reports whether a deactivated account is still inside its
+// reactivation grace window.
+func CanReactivate(user *models.User, grace time.Duration, now time.Time) bool {
+	return user.IsDeactivated() && user.OriginalEmail != "" && now.Before(user.DeactivatedAt.Add(grace))
+}
+
+// reauthenticate loads the user and confirms password is theirs.
+func (s *UserService) reauthenticate(ctx context.Context, userID uint, password string) (*models.User, error) {
+	if password == "" {
+		return nil, models.NewValidationError("Current password is required")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, models.NewUnauthorizedError("Current password is incorrect")
+	}
+	return user, nil
+}
+
 // SetAdmin sets or unsets the admin flag for a user.
 func (s *UserService) SetAdmin(ctx context.Context, targetID uint, isAdmin bool) (*models.User, error) {
 	user, err := s.userRepo.GetByID(ctx, targetID)
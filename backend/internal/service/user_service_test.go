@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // userRepoStub and noopUserRepo are defined in friend_service_test.go (same package).
@@ -195,3 +196,52 @@ func TestUserService_GetUserByID(t *testing.T) {
 		assert.ErrorIs(t, err, repoErr)
 	})
 }
+
+func TestUserService_ChangeEmail(t *testing.T) {
+	t.Parallel()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("Password123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	newRepo := func() *userRepoStub {
+		repo := noopUserRepo()
+		repo.getByIDFn = func(_ context.Context, id uint) (*models.User, error) {
+			return &models.User{ID: id, Email: "me@example.com", Password: string(hashed)}, nil
+		}
+		repo.getByEmailFn = func(_ context.Context, email string) (*models.User, error) {
+			if email == "taken@example.com" {
+				return &models.User{ID: 2, Email: email}, nil
+			}
+			return nil, nil
+		}
+		return repo
+	}
+
+	t.Run("wrong password", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewUserService(newRepo()).ChangeEmail(context.Background(), 1, "nope", "new@example.com")
+		assertUnauthorizedError(t, err)
+	})
+
+	t.Run("address already in use", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewUserService(newRepo()).ChangeEmail(context.Background(), 1, "Password123!", "taken@example.com")
+		var appErr *models.AppError
+		require.True(t, errors.As(err, &appErr))
+		assert.Equal(t, "CONFLICT", appErr.Code)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+		repo := newRepo()
+		var saved *models.User
+		repo.updateFn = func(_ context.Context, u *models.User) error {
+			saved = u
+			return nil
+		}
+		user, err := NewUserService(repo).ChangeEmail(context.Background(), 1, "Password123!", " new@example.com ")
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", user.Email)
+		require.NotNil(t, saved)
+		assert.Equal(t, "new@example.com", saved.Email)
+	})
+}
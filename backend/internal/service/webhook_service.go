@@ -0,0 +1,325 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// WebhookSignatureHeader carries the hex HMAC-SHA256 of "<timestamp>.<body>".
+	WebhookSignatureHeader = "X-Sanctum-Signature"
+	// WebhookTimestampHeader carries the unix timestamp used in the signature.
+	WebhookTimestampHeader = "X-Sanctum-Timestamp"
+
+	webhookEventMessageSent = "message.sent"
+	webhookMaxAttempts      = 3
+	webhookDeliveryTimeout  = 30 * time.Second
+	// webhookMaxConcurrent caps in-flight deliveries; events beyond it are
+	// dropped rather than queued without bound.
+	webhookMaxConcurrent = 32
+)
+
+// MessageWebhookPayload is the JSON body delivered to conversation webhooks.
+// Content is only populated for the webhook owner's own messages, and only
+// when they opted in to it; the other participant never agreed to share theirs.
+type MessageWebhookPayload struct {
+	Event          string    `json:"event"`
+	ConversationID uint      `json:"conversation_id"`
+	MessageID      uint      `json:"message_id"`
+	SenderID       uint      `json:"sender_id"`
+	SenderUsername string    `json:"sender_username,omitempty"`
+	MessageType    string    `json:"message_type"`
+	Content        string    `json:"content,omitempty"`
+	SentAt         time.Time `json:"sent_at"`
+}
+
+// MessageWebhookService manages per-participant DM webhooks and delivers
+// signed message events to them.
+type MessageWebhookService struct {
+	db            *gorm.DB
+	client        *http.Client
+	allowInsecure bool
+	// allowPrivate skips the internal-address check on webhook URLs (tests).
+	allowPrivate bool
+	retryBackoff time.Duration
+	slots        chan struct{}
+}
+
+// NewMessageWebhookService returns a new MessageWebhookService. Plain-http
+// webhook URLs are only accepted when allowInsecure is true. Webhooks can
+// never reach loopback, private or link-local addresses.
+func NewMessageWebhookService(db *gorm.DB, allowInsecure bool) *MessageWebhookService {
+	return &MessageWebhookService{
+		db:            db,
+		client:        newPublicHTTPClient(5 * time.Second),
+		allowInsecure: allowInsecure,
+		retryBackoff:  500 * time.Millisecond,
+		slots:         make(chan struct{}, webhookMaxConcurrent),
+	}
+}
+
+// AllowPrivateNetworks lets webhooks reach loopback and private addresses.
+// It exists for tests that deliver to a local httptest server.
+func (s *MessageWebhookService) AllowPrivateNetworks() {
+	s.allowPrivate = true
+	s.client = &http.Client{Timeout: 5 * time.Second}
+}
+
+// UpsertWebhookInput is the input for UpsertWebhook.
+type UpsertWebhookInput struct {
+	ConversationID uint
+	UserID         uint
+	URL            string
+	IncludeContent bool
+	Enabled        bool
+}
+
+// GetWebhook returns the caller's webhook for a conversation.
+func (s *MessageWebhookService) GetWebhook(ctx context.Context, convID, userID uint) (*models.ConversationWebhook, error) {
+	var hook models.ConversationWebhook
+	if err := s.db.WithContext(ctx).
+		Where("conversation_id = ? AND user_id = ?", convID, userID).
+		First(&hook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Webhook", convID)
+		}
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// UpsertWebhook creates or updates the caller's webhook for a direct
+// conversation. The signing secret is returned only when a webhook is created.
+func (s *MessageWebhookService) UpsertWebhook(ctx context.Context, in UpsertWebhookInput) (*models.ConversationWebhook, string, error) {
+	if err := s.validateURL(in.URL); err != nil {
+		return nil, "", err
+	}
+	if err := s.requireDirectParticipant(ctx, in.ConversationID, in.UserID); err != nil {
+		return nil, "", err
+	}
+
+	var existing models.ConversationWebhook
+	err := s.db.WithContext(ctx).
+		Where("conversation_id = ? AND user_id = ?", in.ConversationID, in.UserID).
+		First(&existing).Error
+	switch {
+	case err == nil:
+		existing.URL = in.URL
+		existing.IncludeContent = in.IncludeContent
+		existing.Enabled = in.Enabled
+		if err := s.db.WithContext(ctx).Save(&existing).Error; err != nil {
+			return nil, "", err
+		}
+		return &existing, "", nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, "", err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	hook := &models.ConversationWebhook{
+		ConversationID: in.ConversationID,
+		UserID:         in.UserID,
+		URL:            in.URL,
+		Secret:         secret,
+		IncludeContent: in.IncludeContent,
+		Enabled:        in.Enabled,
+	}
+	if err := s.db.WithContext(ctx).Create(hook).Error; err != nil {
+		return nil, "", err
+	}
+	return hook, secret, nil
+}
+
+// DeleteWebhook removes the caller's webhook for a conversation.
+func (s *MessageWebhookService) DeleteWebhook(ctx context.Context, convID, userID uint) error {
+	return s.db.WithContext(ctx).
+		Where("conversation_id = ? AND user_id = ?", convID, userID).
+		Delete(&models.ConversationWebhook{}).Error
+}
+
+// DispatchMessage delivers a message.sent event to every enabled webhook on a
+// direct conversation. Deliveries run in the background and never block the
+// sender; when too many are already in flight the event is dropped. Group
+// conversations are ignored.
+func (s *MessageWebhookService) DispatchMessage(ctx context.Context, conv *models.Conversation, message *models.Message) {
+	if s == nil || s.db == nil || conv == nil || message == nil || conv.IsGroup {
+		return
+	}
+
+	var hooks []models.ConversationWebhook
+	if err := s.db.WithContext(ctx).
+		Where("conversation_id = ? AND enabled = ?", conv.ID, true).
+		Find(&hooks).Error; err != nil {
+		if !models.IsSchemaMissingError(err) {
+			log.Printf("webhook lookup error for conversation %d: %v", conv.ID, err)
+		}
+		return
+	}
+
+	for _, hook := range hooks {
+		payload := MessageWebhookPayload{
+			Event:          webhookEventMessageSent,
+			ConversationID: conv.ID,
+			MessageID:      message.ID,
+			SenderID:       message.SenderID,
+			MessageType:    message.MessageType,
+			SentAt:         message.CreatedAt.UTC(),
+		}
+		if message.Sender != nil {
+			payload.SenderUsername = message.Sender.Username
+		}
+		if hook.IncludeContent && hook.UserID == message.SenderID {
+			payload.Content = message.Content
+		}
+
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			log.Printf("webhook %d delivery dropped: too many deliveries in flight", hook.ID)
+			continue
+		}
+		go func(hook models.ConversationWebhook, payload MessageWebhookPayload) {
+			defer func() { <-s.slots }()
+			deliverCtx, cancel := context.WithTimeout(context.Background(), webhookDeliveryTimeout)
+			defer cancel()
+			if err := s.Deliver(deliverCtx, &hook, payload); err != nil {
+				log.Printf("webhook %d delivery failed: %v", hook.ID, err)
+			}
+		}(hook, payload)
+	}
+}
+
+// Deliver POSTs a signed payload to a webhook, retrying on network errors,
+// 429 and 5xx responses.
+func (s *MessageWebhookService) Deliver(ctx context.Context, hook *models.ConversationWebhook, payload MessageWebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		retry, err := s.post(ctx, hook, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || attempt == webhookMaxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.retryBackoff * time.Duration(attempt)):
+		}
+	}
+	return lastErr
+}
+
+func (s *MessageWebhookService) post(ctx context.Context, hook *models.ConversationWebhook, body []byte) (bool, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(hook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 signature receivers use to
+// verify a delivery.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *MessageWebhookService) validateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return models.NewValidationError("Webhook URL must be an absolute URL")
+	}
+	if !s.allowPrivate && isPrivateHost(parsed.Hostname()) {
+		return models.NewValidationError("Webhook URL must point to a public host")
+	}
+	switch parsed.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if s.allowInsecure {
+			return nil
+		}
+	}
+	return models.NewValidationError("Webhook URL must use https")
+}
+
+func (s *MessageWebhookService) requireDirectParticipant(ctx context.Context, convID, userID uint) error {
+	var conv models.Conversation
+	if err := s.db.WithContext(ctx).Select("id", "is_group").First(&conv, convID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NewNotFoundError("Conversation", convID)
+		}
+		return err
+	}
+	if conv.IsGroup {
+		return models.NewValidationError("Webhooks are only supported for direct messages")
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).
+		Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", convID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type webhookDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+func setupWebhookTest(t *testing.T) (*gorm.DB, *models.Conversation, *models.User, *models.User) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.Message{},
+		&models.ConversationWebhook{},
+	))
+
+	owner := &models.User{Username: "owner", Email: "owner@e.com"}
+	sender := &models.User{Username: "sender", Email: "sender@e.com"}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(sender).Error)
+
+	conv := &models.Conversation{CreatedBy: owner.ID}
+	require.NoError(t, db.Create(conv).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: conv.ID, UserID: owner.ID}).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: conv.ID, UserID: sender.ID}).Error)
+
+	return db, conv, owner, sender
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan webhookDelivery, *int32) {
+	t.Helper()
+	deliveries := make(chan webhookDelivery, 10)
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		status := http.StatusOK
+		if int(n) <= len(statuses) {
+			status = statuses[n-1]
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			deliveries <- webhookDelivery{header: r.Header.Clone(), body: body}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, deliveries, &calls
+}
+
+func TestMessageWebhookService_DispatchSignedPost(t *testing.T) {
+	db, conv, owner, sender := setupWebhookTest(t)
+	srv, deliveries, _ := newWebhookReceiver(t)
+	svc := NewMessageWebhookService(db, true)
+	svc.AllowPrivateNetworks()
+	ctx := context.Background()
+
+	_, secret, err := svc.UpsertWebhook(ctx, UpsertWebhookInput{
+		ConversationID: conv.ID,
+		UserID:         owner.ID,
+		URL:            srv.URL,
+		IncludeContent: true,
+		Enabled:        true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, secret)
+
+	msg := &models.Message{ID: 7, ConversationID: conv.ID, SenderID: owner.ID, Sender: owner, Content: "hello bot", MessageType: "text"}
+	svc.DispatchMessage(ctx, conv, msg)
+
+	select {
+	case d := <-deliveries:
+		timestamp := d.header.Get(WebhookTimestampHeader)
+		require.NotEmpty(t, timestamp)
+		assert.Equal(t, "sha256="+SignWebhookPayload(secret, timestamp, d.body), d.header.Get(WebhookSignatureHeader))
+
+		var payload MessageWebhookPayload
+		require.NoError(t, json.Unmarshal(d.body, &payload))
+		assert.Equal(t, "message.sent", payload.Event)
+		assert.Equal(t, conv.ID, payload.ConversationID)
+		assert.Equal(t, uint(7), payload.MessageID)
+		assert.Equal(t, owner.ID, payload.SenderID)
+		assert.Equal(t, "owner", payload.SenderUsername)
+		assert.Equal(t, "hello bot", payload.Content)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	// The other participant never opted in, so their text stays out.
+	svc.DispatchMessage(ctx, conv, &models.Message{ID: 8, ConversationID: conv.ID, SenderID: sender.ID, Sender: sender, Content: "private"})
+	select {
+	case d := <-deliveries:
+		var payload MessageWebhookPayload
+		require.NoError(t, json.Unmarshal(d.body, &payload))
+		assert.Equal(t, uint(8), payload.MessageID)
+		assert.Empty(t, payload.Content)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestMessageWebhookService_OmitsContentWithoutOptIn(t *testing.T) {
+	db, conv, owner, sender := setupWebhookTest(t)
+	srv, deliveries, _ := newWebhookReceiver(t)
+	svc := NewMessageWebhookService(db, true)
+	svc.AllowPrivateNetworks()
+	ctx := context.Background()
+
+	_, _, err := svc.UpsertWebhook(ctx, UpsertWebhookInput{ConversationID: conv.ID, UserID: owner.ID, URL: srv.URL, Enabled: true})
+	require.NoError(t, err)
+
+	svc.DispatchMessage(ctx, conv, &models.Message{ID: 8, ConversationID: conv.ID, SenderID: sender.ID, Content: "private"})
+
+	select {
+	case d := <-deliveries:
+		var payload MessageWebhookPayload
+		require.NoError(t, json.Unmarshal(d.body, &payload))
+		assert.Empty(t, payload.Content)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestMessageWebhookService_DisabledWebhookIsSuppressed(t *testing.T) {
+	db, conv, owner, sender := setupWebhookTest(t)
+	srv, _, calls := newWebhookReceiver(t)
+	svc := NewMessageWebhookService(db, true)
+	svc.AllowPrivateNetworks()
+	ctx := context.Background()
+
+	_, _, err := svc.UpsertWebhook(ctx, UpsertWebhookInput{ConversationID: conv.ID, UserID: owner.ID, URL: srv.URL, Enabled: false})
+	require.NoError(t, err)
+
+	svc.DispatchMessage(ctx, conv, &models.Message{ID: 9, ConversationID: conv.ID, SenderID: sender.ID, Content: "hi"})
+
+	assert.Never(t, func() bool { return atomic.LoadInt32(calls) > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestMessageWebhookService_DeliverRetriesServerErrors(t *testing.T) {
+	db, _, _, _ := setupWebhookTest(t)
+	srv, deliveries, calls := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	svc := NewMessageWebhookService(db, true)
+	svc.AllowPrivateNetworks()
+	svc.retryBackoff = time.Millisecond
+
+	err := svc.Deliver(context.Background(), &models.ConversationWebhook{URL: srv.URL, Secret: "s"}, MessageWebhookPayload{Event: "message.sent"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	assert.Len(t, deliveries, 1)
+}
+
+func TestMessageWebhookService_UpsertValidation(t *testing.T) {
+	db, conv, owner, _ := setupWebhookTest(t)
+	ctx := context.Background()
+
+	secureOnly := NewMessageWebhookService(db, false)
+	_, _, err := secureOnly.UpsertWebhook(ctx, UpsertWebhookInput{ConversationID: conv.ID, UserID: owner.ID, URL: "http://example.com/hook", Enabled: true})
+	assertAppErrorCode(t, err, "VALIDATION_ERROR")
+
+	room := &models.Conversation{IsGroup: true, Name: "room", CreatedBy: owner.ID}
+	require.NoError(t, db.Create(room).Error)
+	require.NoError(t, db.Create(&models.ConversationParticipant{ConversationID: room.ID, UserID: owner.ID}).Error)
+	_, _, err = secureOnly.UpsertWebhook(ctx, UpsertWebhookInput{ConversationID: room.ID, UserID: owner.ID, URL: "https://example.com/hook", Enabled: true})
+	assertAppErrorCode(t, err, "VALIDATION_ERROR")
+
+	_, _, err = secureOnly.UpsertWebhook(ctx, UpsertWebhookInput{ConversationID: conv.ID, UserID: 999, URL: "https://example.com/hook", Enabled: true})
+	assertAppErrorCode(t, err, "UNAUTHORIZED")
+
+	for _, internal := range []string{"https://127.0.0.1/hook", "https://localhost:8443/hook", "https://10.1.2.3/hook", "https://169.254.169.254/latest", "https://[::1]/hook"} {
+		_, _, err = secureOnly.UpsertWebhook(ctx, UpsertWebhookInput{ConversationID: conv.ID, UserID: owner.ID, URL: internal, Enabled: true})
+		assertAppErrorCode(t, err, "VALIDATION_ERROR")
+	}
+}
+
+func TestMessageWebhookService_RefusesPrivateAddressesAtDialTime(t *testing.T) {
+	db, _, _, _ := setupWebhookTest(t)
+	srv, _, calls := newWebhookReceiver(t)
+	svc := NewMessageWebhookService(db, true)
+
+	// A stored URL (or a hostname that resolves) pointing inside the network
+	// is refused when connecting, not only when the webhook is saved.
+	err := svc.Deliver(context.Background(), &models.ConversationWebhook{URL: srv.URL, Secret: "s"}, MessageWebhookPayload{Event: "message.sent"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errPrivateAddress)
+	assert.Zero(t, atomic.LoadInt32(calls))
+}
+
+func assertAppErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var appErr *models.AppError
+	if !errors.As(err, &appErr) || appErr.Code != code {
+		t.Fatalf("expected %s app error, got %#v", code, err)
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrPushSubscriptionGone is returned when the push service reports that a
+// subscription has expired or been revoked and should be forgotten.
+var ErrPushSubscriptionGone = errors.New("push subscription is no longer valid")
+
+// PushSender delivers one Web Push message to one browser subscription.
+type PushSender interface {
+	Send(ctx context.Context, sub *models.PushSubscription, payload []byte) error
+}
+
+const (
+	webPushTTL        = 24 * time.Hour
+	webPushRecordSize = 4096
+	vapidTokenTTL     = 12 * time.Hour
+)
+
+// WebPushSender sends messages encrypted per RFC 8291 and authenticated with
+// VAPID (RFC 8292).
+type WebPushSender struct {
+	publicKey  string
+	privateKey *ecdsa.PrivateKey
+	subject    string
+	client     *http.Client
+}
+
+// NewWebPushSender builds a sender from base64url-encoded VAPID keys: the
+// uncompressed P-256 public point and the raw 32-byte private scalar. subject
+// is the contact URI (mailto: or https:) sent to push services.
+func NewWebPushSender(publicKey, privateKey, subject string) (*WebPushSender, error) {
+	rawPrivate, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), rawPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	rawPublic, err := decodeBase64URL(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID public key: %w", err)
+	}
+	derived, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(rawPublic, derived) {
+		return nil, errors.New("VAPID public key does not match the private key")
+	}
+	if subject == "" {
+		return nil, errors.New("VAPID subject is required")
+	}
+	return &WebPushSender{
+		publicKey:  base64.RawURLEncoding.EncodeToString(derived),
+		privateKey: key,
+		subject:    subject,
+		client:     newPublicHTTPClient(10 * time.Second),
+	}, nil
+}
+
+// PublicKey returns the base64url VAPID public key browsers subscribe with.
+func (s *WebPushSender) PublicKey() string {
+	return s.publicKey
+}
+
+// Send encrypts payload for the subscription and posts it to its push service.
+func (s *WebPushSender) Send(ctx context.Context, sub *models.PushSubscription, payload []byte) error {
+	body, err := encryptWebPush(sub, payload)
+	if err != nil {
+		return err
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid push endpoint: %w", err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(vapidTokenTTL).Unix(),
+		"sub": s.subject,
+	}).SignedString(s.privateKey)
+	if err != nil {
+		return fmt.Errorf("sign VAPID token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(webPushTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, s.publicKey))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrPushSubscriptionGone
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encryptWebPush produces an aes128gcm message body (RFC 8188) for the
+// subscription's keys, as described in RFC 8291.
+func encryptWebPush(sub *models.PushSubscription, payload []byte) ([]byte, error) {
+	uaPublicRaw, err := decodeBase64URL(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64URL(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return sealWebPush(asPrivate, uaPublic, authSecret, salt, payload)
+}
+
+func sealWebPush(asPrivate *ecdh.PrivateKey, uaPublic *ecdh.PublicKey, authSecret, salt, payload []byte) ([]byte, error) {
+	if len(payload)+17+16 > webPushRecordSize {
+		return nil, errors.New("push payload is too large")
+	}
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublicRaw := asPrivate.PublicKey().Bytes()
+
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic.Bytes()...), asPublicRaw...)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// A single record: the payload followed by the last-record delimiter.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublicRaw))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPublicRaw)))
+	header = append(header, asPublicRaw...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
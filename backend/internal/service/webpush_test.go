@@ -0,0 +1,137 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decryptWebPush is the user agent's side of RFC 8291, used to check that
+// pushes can actually be read by the subscribing browser.
+func decryptWebPush(t *testing.T, uaPrivate *ecdh.PrivateKey, authSecret, body []byte) []byte {
+	t.Helper()
+	require.Greater(t, len(body), 21)
+	salt := body[:16]
+	assert.Equal(t, uint32(webPushRecordSize), binary.BigEndian.Uint32(body[16:20]))
+	idLen := int(body[20])
+	asPublic, err := ecdh.P256().NewPublicKey(body[21 : 21+idLen])
+	require.NoError(t, err)
+
+	shared, err := uaPrivate.ECDH(asPublic)
+	require.NoError(t, err)
+	info := append(append([]byte("WebPush: info\x00"), uaPrivate.PublicKey().Bytes()...), asPublic.Bytes()...)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, string(info), 32)
+	require.NoError(t, err)
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	require.NoError(t, err)
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(cek)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := gcm.Open(nil, nonce, body[21+idLen:], nil)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x02), plaintext[len(plaintext)-1], "single record ends with the last-record delimiter")
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestWebPushSender_Send(t *testing.T) {
+	vapidKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	vapidPrivate, err := vapidKey.Bytes()
+	require.NoError(t, err)
+	vapidPublic, err := vapidKey.PublicKey.Bytes()
+	require.NoError(t, err)
+	sender, err := NewWebPushSender(
+		base64.RawURLEncoding.EncodeToString(vapidPublic),
+		base64.RawURLEncoding.EncodeToString(vapidPrivate),
+		"mailto:ops@example.com",
+	)
+	require.NoError(t, err)
+
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authSecret := make([]byte, 16)
+	_, err = rand.Read(authSecret)
+	require.NoError(t, err)
+
+	var (
+		gotHeader http.Header
+		gotBody   []byte
+		status    = http.StatusCreated
+	)
+	pushServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer pushServer.Close()
+	sender.client = pushServer.Client()
+
+	sub := &models.PushSubscription{
+		Endpoint: pushServer.URL + "/send/abc",
+		P256dh:   base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(authSecret),
+	}
+	payload := []byte(`{"type":"message_received","preview":"hello"}`)
+	require.NoError(t, sender.Send(context.Background(), sub, payload))
+
+	assert.Equal(t, "aes128gcm", gotHeader.Get("Content-Encoding"))
+	assert.NotEmpty(t, gotHeader.Get("TTL"))
+	assert.True(t, bytes.Equal(payload, decryptWebPush(t, uaPrivate, authSecret, gotBody)))
+
+	auth := gotHeader.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "vapid t="))
+	parts := strings.SplitN(strings.TrimPrefix(auth, "vapid t="), ", k=", 2)
+	require.Len(t, parts, 2)
+	assert.Equal(t, sender.PublicKey(), parts[1])
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(parts[0], claims, func(*jwt.Token) (interface{}, error) {
+		return &vapidKey.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(pushServer.URL))
+	require.NoError(t, err)
+	assert.Equal(t, "mailto:ops@example.com", claims["sub"])
+
+	status = http.StatusGone
+	assert.ErrorIs(t, sender.Send(context.Background(), sub, payload), ErrPushSubscriptionGone)
+}
+
+func TestNewWebPushSender_RejectsMismatchedKeys(t *testing.T) {
+	a, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	b, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	private, err := a.Bytes()
+	require.NoError(t, err)
+	public, err := b.PublicKey.Bytes()
+	require.NoError(t, err)
+
+	_, err = NewWebPushSender(
+		base64.RawURLEncoding.EncodeToString(public),
+		base64.RawURLEncoding.EncodeToString(private),
+		"mailto:ops@example.com",
+	)
+	assert.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"image"
 	"image/png"
+	"sort"
 	"time"
 
 	"sanctum/internal/models"
@@ -16,13 +17,14 @@ import (
 
 // ImageRepoStub is an in-memory image repository implementation for tests.
 type ImageRepoStub struct {
-	items  map[string]*models.Image
-	nextID uint
+	items     map[string]*models.Image
+	uploaders map[uint]map[uint]bool
+	nextID    uint
 }
 
 // NewImageRepoStub creates an in-memory image repository stub for tests.
 func NewImageRepoStub() *ImageRepoStub {
-	return &ImageRepoStub{items: make(map[string]*models.Image), nextID: 1}
+	return &ImageRepoStub{items: make(map[string]*models.Image), uploaders: make(map[uint]map[uint]bool), nextID: 1}
 }
 
 // Create stores image metadata in-memory.
@@ -35,6 +37,7 @@ func (s *ImageRepoStub) Create(_ context.Context, img *models.Image) error {
 	img.CreatedAt = now
 	img.UpdatedAt = now
 	s.items[img.Hash] = img
+	s.uploaders[img.ID] = map[uint]bool{img.UserID: true}
 	return nil
 }
 
@@ -52,6 +55,50 @@ func (s *ImageRepoStub) GetByHashWithVariants(ctx context.Context, hash string)
 	return s.GetByHash(ctx, hash)
 }
 
+// GetReadyByContentHash returns the first ready image uploaded with the given content hash.
+func (s *ImageRepoStub) GetReadyByContentHash(_ context.Context, contentHash string) (*models.Image, error) {
+	var found *models.Image
+	for _, item := range s.items {
+		if item.ContentHash == contentHash && item.Status == repository.ImageStatusReady &&
+			(found == nil || item.ID < found.ID) {
+			found = item
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return found, nil
+}
+
+// AddUploader records userID as an uploader of the image, once per user.
+func (s *ImageRepoStub) AddUploader(_ context.Context, imageID, userID uint) error {
+	for _, item := range s.items {
+		if item.ID == imageID {
+			if s.uploaders[imageID] == nil {
+				s.uploaders[imageID] = make(map[uint]bool)
+			}
+			s.uploaders[imageID][userID] = true
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// Uploaders returns the IDs of users recorded as uploaders of the image.
+func (s *ImageRepoStub) Uploaders(imageID uint) []uint {
+	ids := make([]uint, 0, len(s.uploaders[imageID]))
+	for id := range s.uploaders[imageID] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// IsUploader reports whether userID has uploaded the image.
+func (s *ImageRepoStub) IsUploader(_ context.Context, imageID, userID uint) (bool, error) {
+	return s.uploaders[imageID][userID], nil
+}
+
 // UpdateLastAccessed updates LastAccessedAt for the matching image.
 func (s *ImageRepoStub) UpdateLastAccessed(_ context.Context, imageID uint) error {
 	for _, item := range s.items {
@@ -124,6 +171,32 @@ func (s *ImageRepoStub) RequeueStaleProcessing(_ context.Context, _ time.Duratio
 	return 0, nil
 }
 
+// CountQueued returns the number of images still waiting to be processed.
+func (s *ImageRepoStub) CountQueued(_ context.Context) (int64, error) {
+	var count int64
+	for _, item := range s.items {
+		if item.Status == repository.ImageStatusQueued {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListReadyAfter returns ready images with an id above afterID, in id order.
+func (s *ImageRepoStub) ListReadyAfter(_ context.Context, afterID uint, limit int) ([]models.Image, error) {
+	var images []models.Image
+	for _, item := range s.items {
+		if item.Status == repository.ImageStatusReady && item.ID > afterID {
+			images = append(images, *item)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].ID < images[j].ID })
+	if len(images) > limit {
+		images = images[:limit]
+	}
+	return images, nil
+}
+
 // TinyPNG returns an in-memory PNG byte slice with the requested dimensions.
 func TinyPNG(t interface {
 	Helper()
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// ConversationNameMinLength is the shortest accepted group conversation name.
+	ConversationNameMinLength = 2
+	// DefaultConversationNameMaxLength is used when no maximum is configured.
+	DefaultConversationNameMaxLength = 64
+)
+
+var conversationNameRegex = regexp.MustCompile(`^[\p{L}\p{N} _\-.,'!?&#()]+$`)
+
+// ConversationNameRules configures group conversation and chatroom name checks.
+type ConversationNameRules struct {
+	MaxLength int
+	Profanity *ProfanityFilter
+}
+
+var defaultProfanityFilter = NewProfanityFilter()
+
+// Validate checks length, allowed characters and blocked words. The name is
+// expected to be trimmed already.
+func (r ConversationNameRules) Validate(name string) error {
+	maxLength := r.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultConversationNameMaxLength
+	}
+	length := utf8.RuneCountInString(name)
+	if length < ConversationNameMinLength {
+		return fmt.Errorf("name must be at least %d characters long", ConversationNameMinLength)
+	}
+	if length > maxLength {
+		return fmt.Errorf("name must not exceed %d characters", maxLength)
+	}
+	if !conversationNameRegex.MatchString(name) || strings.Contains(name, "  ") {
+		return fmt.Errorf("name can only contain letters, numbers, single spaces, and - _ . , ' ! ? & # ( )")
+	}
+
+	filter := r.Profanity
+	if filter == nil {
+		filter = defaultProfanityFilter
+	}
+	if filter.Contains(name) {
+		return fmt.Errorf("name contains inappropriate language")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConversationNameRules(t *testing.T) {
+	t.Parallel()
+
+	rules := ConversationNameRules{MaxLength: 20, Profanity: NewProfanityFilter("frak")}
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{name: "valid", input: "Weekend Raid Group", ok: true},
+		{name: "punctuation", input: "Q&A (Linux)", ok: true},
+		{name: "unicode letters", input: "Café Crème", ok: true},
+		{name: "too short", input: "a", ok: false},
+		{name: "too long", input: strings.Repeat("a", 21), ok: false},
+		{name: "disallowed symbol", input: "room<script>", ok: false},
+		{name: "double space", input: "two  spaces", ok: false},
+		{name: "profane", input: "shit talk", ok: false},
+		{name: "profane inflection", input: "Fucking Room", ok: false},
+		{name: "leetspeak", input: "sh1t posting", ok: false},
+		{name: "configured word", input: "frakking", ok: false},
+		{name: "embedded substring", input: "Scunthorpe fans", ok: true},
+		{name: "cocktail", input: "cocktail hour", ok: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := rules.Validate(tc.input)
+			if tc.ok && err != nil {
+				t.Fatalf("expected %q to be valid, got %v", tc.input, err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected %q to be rejected", tc.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package validation
+
+import "regexp"
+
+// linkRegex matches explicit URLs, www. hosts and bare domains on common
+// top-level domains.
+var linkRegex = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|gg|co|ly|me|app|dev|xyz|tv|info|biz|ru|cn)\b`)
+
+// ContainsLink reports whether text includes something that looks like a link
+// to an external site.
+func ContainsLink(text string) bool {
+	return linkRegex.MatchString(text)
+}
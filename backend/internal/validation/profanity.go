@@ -0,0 +1,126 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultProfanity is the built-in list of blocked words. Matching is done on
+// whole words (plus common inflections) so names like "Scunthorpe" or
+// "cocktail hour" are not caught.
+var defaultProfanity = []string{
+	"asshole", "bastard", "bitch", "bollocks", "cock", "cunt", "dick", "fag",
+	"faggot", "fuck", "motherfucker", "nigga", "nigger", "piss", "pussy",
+	"retard", "shit", "slut", "twat", "wanker", "whore",
+}
+
+var profanitySuffixes = []string{"", "s", "es", "er", "ers", "ing", "ed", "y"}
+
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+)
+
+// ProfanityFilter detects blocked words in user-supplied text.
+type ProfanityFilter struct {
+	words map[string]struct{}
+}
+
+// NewProfanityFilter returns a filter for the built-in word list plus any
+// extra words supplied by configuration.
+func NewProfanityFilter(extra ...string) *ProfanityFilter {
+	f := &ProfanityFilter{words: make(map[string]struct{}, len(defaultProfanity)+len(extra))}
+	for _, w := range append(append([]string{}, defaultProfanity...), extra...) {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			f.words[w] = struct{}{}
+		}
+	}
+	return f
+}
+
+// With returns a copy of the filter that also blocks the given words.
+func (f *ProfanityFilter) With(extra ...string) *ProfanityFilter {
+	out := &ProfanityFilter{words: make(map[string]struct{}, len(f.words)+len(extra))}
+	for w := range f.words {
+		out.words[w] = struct{}{}
+	}
+	for _, w := range extra {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			out.words[w] = struct{}{}
+		}
+	}
+	return out
+}
+
+// Contains reports whether text includes a blocked word.
+func (f *ProfanityFilter) Contains(text string) bool {
+	normalized := leetReplacer.Replace(strings.ToLower(text))
+	tokens := strings.FieldsFunc(normalized, func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, token := range tokens {
+		if f.blocked(token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask replaces every blocked word in text with asterisks and reports whether
+// anything was masked. Everything else, including spacing and punctuation, is
+// left as it was.
+func (f *ProfanityFilter) Mask(text string) (string, bool) {
+	runes := []rune(text)
+	masked := false
+	for start := 0; start < len(runes); {
+		if !isWordRune(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		token := leetReplacer.Replace(strings.ToLower(string(runes[start:end])))
+		if f.blocked(token) {
+			for i := start; i < end; i++ {
+				runes[i] = '*'
+			}
+			masked = true
+		}
+		start = end
+	}
+	if !masked {
+		return text, false
+	}
+	return string(runes), true
+}
+
+// isWordRune reports whether r is part of a word once leetspeak is undone.
+func isWordRune(r rune) bool {
+	if unicode.IsLetter(r) {
+		return true
+	}
+	for _, c := range leetReplacer.Replace(string(r)) {
+		return unicode.IsLetter(c)
+	}
+	return false
+}
+
+// blocked reports whether a normalized token is a blocked word or one of its
+// inflections.
+func (f *ProfanityFilter) blocked(token string) bool {
+	for _, suffix := range profanitySuffixes {
+		stem, ok := strings.CutSuffix(token, suffix)
+		if !ok {
+			continue
+		}
+		if _, blocked := f.words[stem]; blocked {
+			return true
+		}
+		// Inflections may double the final consonant ("frakking" -> "frak").
+		if n := len(stem); suffix != "" && n > 2 && stem[n-1] == stem[n-2] {
+			if _, blocked := f.words[stem[:n-1]]; blocked {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package validation
+
+import "testing"
+
+func TestProfanityFilterMask(t *testing.T) {
+	t.Parallel()
+
+	filter := NewProfanityFilter().With("frak")
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		masked bool
+	}{
+		{name: "clean", input: "hello there", want: "hello there"},
+		{name: "keeps punctuation", input: "well, shit!", want: "well, ****!", masked: true},
+		{name: "inflection", input: "Fucking hell", want: "******* hell", masked: true},
+		{name: "leetspeak", input: "sh1t happens", want: "**** happens", masked: true},
+		{name: "room word", input: "frakking toaster", want: "******** toaster", masked: true},
+		{name: "embedded substring", input: "Scunthorpe cocktail", want: "Scunthorpe cocktail"},
+		{name: "unicode", input: "café shit crème", want: "café **** crème", masked: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, masked := filter.Mask(tc.input)
+			if got != tc.want || masked != tc.masked {
+				t.Fatalf("Mask(%q) = %q, %v; want %q, %v", tc.input, got, masked, tc.want, tc.masked)
+			}
+		})
+	}
+
+	if NewProfanityFilter().Contains("frak") {
+		t.Fatal("With must not change the original filter")
+	}
+}
+
+func TestContainsLink(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"see https://example.com/x": true,
+		"http://10.0.0.1":           true,
+		"go to www.example.org":     true,
+		"join discord.gg/abc":       true,
+		"my site is foo.dev":        true,
+		"no links here":             false,
+		"end of sentence.Next one":  false,
+		"version 1.2.3":             false,
+	}
+	for input, want := range tests {
+		if got := ContainsLink(input); got != want {
+			t.Errorf("ContainsLink(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxPostTags is the most tags a single post may carry.
+	MaxPostTags = 5
+	// MaxTagLength is the longest accepted tag, after normalization.
+	MaxTagLength = 32
+)
+
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NormalizeTag trims, lowercases and strips a leading # from a tag.
+func NormalizeTag(raw string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "#"))
+}
+
+// NormalizeTags normalizes and validates a post's tags, dropping blanks and
+// duplicates while keeping the caller's order.
+func NormalizeTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, r := range raw {
+		tag := NormalizeTag(r)
+		if tag == "" {
+			continue
+		}
+		if _, dup := seen[tag]; dup {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q must not exceed %d characters", tag, MaxTagLength)
+		}
+		if !tagRegex.MatchString(tag) {
+			return nil, fmt.Errorf("tag %q can only contain letters, numbers, hyphens and underscores", tag)
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	if len(tags) > MaxPostTags {
+		return nil, fmt.Errorf("a post can have at most %d tags", MaxPostTags)
+	}
+	return tags, nil
+}
@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+		ok    bool
+	}{
+		{name: "normalizes case, space and hash", input: []string{"  #Linux ", "Rust"}, want: []string{"linux", "rust"}, ok: true},
+		{name: "drops blanks and duplicates", input: []string{"go", "", "GO", "#go"}, want: []string{"go"}, ok: true},
+		{name: "hyphen and underscore", input: []string{"pc-gaming", "how_to"}, want: []string{"pc-gaming", "how_to"}, ok: true},
+		{name: "max length", input: []string{strings.Repeat("a", MaxTagLength)}, want: []string{strings.Repeat("a", MaxTagLength)}, ok: true},
+		{name: "too long", input: []string{strings.Repeat("a", MaxTagLength+1)}, ok: false},
+		{name: "space inside", input: []string{"pc gaming"}, ok: false},
+		{name: "markup", input: []string{"<b>"}, ok: false},
+		{name: "leading hyphen", input: []string{"-news"}, ok: false},
+		{name: "too many", input: []string{"a1", "b2", "c3", "d4", "e5", "f6"}, ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NormalizeTags(tc.input)
+			if !tc.ok {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -18,11 +18,14 @@ import type {
   CreateConversationRequest,
   CreatePostRequest,
   CreateSanctumRequestInput,
+  Crosspost,
   FriendRequest,
   FriendshipStatus,
+  GameLeaderboard,
   GameRoom,
   GameRoomChatMessage,
   LoginRequest,
+  MentionSuggestion,
   Message,
   MessageMention,
   MessageReactionResponse,
@@ -33,15 +36,19 @@ import type {
   ReportRequest,
   ResolveModerationReportRequest,
   SanctumAdmin,
+  SanctumAnalytics,
+  SanctumBan,
   SanctumDTO,
   SanctumMembership,
   SanctumRequest,
   SearchParams,
   SendMessageRequest,
   SignupRequest,
+  UnreadSummary,
   UpdateCommentRequest,
   UpdatePostRequest,
   UpdateProfileRequest,
+  UpdateSanctumSettingsInput,
   UploadedImage,
   User,
   UserBlock,
@@ -385,6 +392,13 @@ class ApiClient {
     })
   }
 
+  async crosspostPost(id: number, sanctumId: number): Promise<Crosspost> {
+    return this.request(`/posts/${id}/crosspost`, {
+      method: 'POST',
+      body: JSON.stringify({ sanctum_id: sanctumId }),
+    })
+  }
+
   async reportPost(id: number, data: ReportRequest): Promise<ModerationReport> {
     return this.request(`/posts/${id}/report`, {
       method: 'POST',
@@ -522,6 +536,17 @@ class ApiClient {
     return this.request(`/users/me/mentions${queryString}`)
   }
 
+  async suggestMentions(
+    q: string,
+    params?: PaginationParams
+  ): Promise<MentionSuggestion[]> {
+    const query = new URLSearchParams({ q })
+    if (params?.offset !== undefined)
+      query.set('offset', params.offset.toString())
+    if (params?.limit !== undefined) query.set('limit', params.limit.toString())
+    return this.request(`/mentions/suggest?${query.toString()}`)
+  }
+
   async getMyBlocks(): Promise<UserBlock[]> {
     return this.request('/users/blocks/me')
   }
@@ -553,6 +578,10 @@ class ApiClient {
     return this.request('/conversations')
   }
 
+  async getUnreadSummary(): Promise<UnreadSummary> {
+    return this.request('/conversations/unread-summary')
+  }
+
   async getConversation(id: number): Promise<Conversation> {
     return this.request(`/conversations/${id}`)
   }
@@ -566,6 +595,23 @@ class ApiClient {
     })
   }
 
+  async renameConversation(id: number, name: string): Promise<Conversation> {
+    return this.request(`/conversations/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify({ name }),
+    })
+  }
+
+  async transferConversation(
+    id: number,
+    userId: number
+  ): Promise<Conversation> {
+    return this.request(`/conversations/${id}/transfer`, {
+      method: 'POST',
+      body: JSON.stringify({ user_id: userId }),
+    })
+  }
+
   async leaveConversation(id: number): Promise<{ message: string }> {
     return this.request(`/conversations/${id}`, {
       method: 'DELETE',
@@ -578,6 +624,15 @@ class ApiClient {
     })
   }
 
+  async setConversationMuted(
+    id: number,
+    muted: boolean
+  ): Promise<{ conversation_id: number; muted: boolean }> {
+    return this.request(`/conversations/${id}/mute`, {
+      method: muted ? 'POST' : 'DELETE',
+    })
+  }
+
   // Chat - Messages
   async getMessages(
     conversationId: number,
@@ -844,6 +899,69 @@ class ApiClient {
     })
   }
 
+  async updateSanctumSettings(
+    slug: string,
+    data: UpdateSanctumSettingsInput
+  ): Promise<SanctumDTO> {
+    return this.request(`/sanctums/${slug}/settings`, {
+      method: 'PATCH',
+      body: JSON.stringify(data),
+    })
+  }
+
+  async getPendingSanctumPosts(
+    slug: string,
+    params?: PaginationParams
+  ): Promise<Post[]> {
+    const query = new URLSearchParams()
+    if (params?.offset !== undefined)
+      query.set('offset', params.offset.toString())
+    if (params?.limit !== undefined) query.set('limit', params.limit.toString())
+    const queryString = query.toString() ? `?${query.toString()}` : ''
+    return this.request(`/sanctums/${slug}/posts/pending${queryString}`)
+  }
+
+  async approveSanctumPost(slug: string, postId: number): Promise<Post> {
+    return this.request(`/sanctums/${slug}/posts/${postId}/approve`, {
+      method: 'POST',
+    })
+  }
+
+  async getSanctumAnalytics(
+    slug: string,
+    params?: { days?: number; before?: string }
+  ): Promise<SanctumAnalytics> {
+    const query = new URLSearchParams()
+    if (params?.days !== undefined) query.set('days', params.days.toString())
+    if (params?.before) query.set('before', params.before)
+    const queryString = query.toString() ? `?${query.toString()}` : ''
+    return this.request(`/sanctums/${slug}/analytics${queryString}`)
+  }
+
+  async getSanctumBans(slug: string): Promise<SanctumBan[]> {
+    return this.request(`/sanctums/${slug}/bans`)
+  }
+
+  async banSanctumUser(
+    slug: string,
+    userId: number,
+    data?: { reason?: string }
+  ): Promise<SanctumBan> {
+    return this.request(`/sanctums/${slug}/bans/${userId}`, {
+      method: 'POST',
+      body: JSON.stringify(data ?? {}),
+    })
+  }
+
+  async unbanSanctumUser(
+    slug: string,
+    userId: number
+  ): Promise<{ message: string }> {
+    return this.request(`/sanctums/${slug}/bans/${userId}`, {
+      method: 'DELETE',
+    })
+  }
+
   async getAdminReports(params?: {
     status?: string
     target_type?: string
@@ -946,6 +1064,11 @@ class ApiClient {
     return this.request(`/games/stats/${type}`)
   }
 
+  async getGameLeaderboard(type: string, limit = 10): Promise<GameLeaderboard> {
+    const query = new URLSearchParams({ type, limit: limit.toString() })
+    return this.request(`/games/leaderboard?${query.toString()}`)
+  }
+
   async getCurrentUser(): Promise<User> {
     return this.request('/users/me')
   }
@@ -11,6 +11,7 @@ export interface User {
   banned_at?: string
   banned_reason?: string
   banned_by_user_id?: number
+  ban_expires_at?: string
   created_at: string
   liked?: boolean
   updated_at: string
@@ -31,7 +32,10 @@ export interface Poll {
   post_id: number
   question: string
   options: PollOption[]
+  allow_multiple?: boolean
+  closes_at?: string
   user_vote_option_id?: number
+  user_vote_option_ids?: number[]
 }
 
 export interface UploadedImage {
@@ -63,16 +67,33 @@ export interface Post {
   comments_count?: number
   user_id: number
   sanctum_id?: number
+  // Source sanctum, included in sanctum feeds so crossposts can be attributed.
+  sanctum?: Pick<SanctumDTO, 'id' | 'name' | 'slug'>
+  status?: PostStatus
   user?: User
   created_at: string
   updated_at: string
 }
 
+export interface Crosspost {
+  id: number
+  post_id: number
+  sanctum_id: number
+  user_id: number
+  created_at: string
+}
+
+export type PostStatus = 'published' | 'scheduled' | 'pending_review'
+
 export interface Comment {
   id: number
   content: string
   post_id: number
   user_id: number
+  parent_comment_id?: number
+  depth?: number
+  replies?: Comment[]
+  likes_count?: number
   user?: User
   created_at: string
   updated_at: string
@@ -114,6 +135,8 @@ export interface AuthResponse {
 export interface CreatePostPollInput {
   question: string
   options: string[]
+  allow_multiple?: boolean
+  closes_at?: string
 }
 
 export interface CreatePostRequest {
@@ -137,6 +160,7 @@ export interface UpdatePostRequest {
 
 export interface CreateCommentRequest {
   content: string
+  parent_comment_id?: number
 }
 
 export interface UpdateCommentRequest {
@@ -182,6 +206,11 @@ export interface Conversation {
   capabilities?: ChatroomCapabilities
 }
 
+export interface UnreadSummary {
+  total: number
+  conversations: { conversation_id: number; unread_count: number }[]
+}
+
 export interface ChatroomCapabilities {
   can_moderate: boolean
   can_manage_moderators: boolean
@@ -200,7 +229,7 @@ export interface Message {
   sender_id: number
   sender?: User
   content: string
-  message_type: 'text' | 'image' | 'file'
+  message_type: 'text' | 'image' | 'link' | 'file'
   metadata?: Record<string, unknown>
   is_read: boolean
   read_at?: string
@@ -246,6 +275,12 @@ export interface MessageMention {
   mentioned_by_user?: User
 }
 
+export interface MentionSuggestion {
+  user: User
+  is_friend: boolean
+  recently_interacted: boolean
+}
+
 export interface UserBlock {
   id: number
   blocker_id: number
@@ -312,6 +347,47 @@ export interface ChatroomBan {
   user_id: number
   banned_by_user_id: number
   reason: string
+  from_sanctum_ban?: boolean
+  created_at: string
+  updated_at: string
+  user?: User
+  banned_by_user?: User
+}
+
+export interface SanctumMemberDay {
+  date: string
+  members: number
+  joined: number
+}
+
+export interface SanctumContributor {
+  user_id: number
+  username: string
+  avatar: string
+  posts: number
+  comments: number
+}
+
+export interface SanctumAnalytics {
+  member_count: number
+  member_series: SanctumMemberDay[]
+  from: string
+  to: string
+  next_before: string
+  posts_by_type: Record<string, number>
+  top_contributors: SanctumContributor[]
+  engagement: {
+    posts: number
+    comments: number
+    likes: number
+  }
+}
+
+export interface SanctumBan {
+  sanctum_id: number
+  user_id: number
+  banned_by_user_id: number
+  reason: string
   created_at: string
   updated_at: string
   user?: User
@@ -345,7 +421,7 @@ export interface CreateConversationRequest {
 
 export interface SendMessageRequest {
   content: string
-  message_type?: 'text' | 'image' | 'file'
+  message_type?: 'text' | 'image' | 'link' | 'file'
   // Backend expects a JSON object
   metadata?: Record<string, unknown>
 }
@@ -360,6 +436,25 @@ export interface GameRoomChatMessage {
   text: string
 }
 
+export interface GameLeaderboardEntry {
+  rank: number
+  user_id: number
+  username: string
+  wins: number
+  losses: number
+  draws: number
+  total_games: number
+  points: number
+  rating: number
+  win_rate: number
+}
+
+export interface GameLeaderboard {
+  game_type: string
+  entries: GameLeaderboardEntry[]
+  me: GameLeaderboardEntry | null
+}
+
 export interface GameRoom {
   id: number
   type: string
@@ -370,6 +465,7 @@ export interface GameRoom {
   is_draw: boolean
   next_turn_id: number
   current_state: string
+  version?: number
   creator?: User
   opponent?: User
 }
@@ -381,10 +477,17 @@ export interface SanctumDTO {
   description: string
   status: string
   default_chat_room_id: number
+  requires_post_approval?: boolean
+  members_only_posting?: boolean
   created_at: string
   updated_at: string
 }
 
+export interface UpdateSanctumSettingsInput {
+  requires_post_approval?: boolean
+  members_only_posting?: boolean
+}
+
 export type SanctumRequestStatus = 'pending' | 'approved' | 'rejected'
 
 export interface SanctumRequest {
@@ -34,6 +34,7 @@ type RealtimeEventType =
   | 'sanctum_request_reviewed'
   | 'chat_mention'
   | 'game_room_updated'
+  | 'report_resolved'
 
 interface RealtimeEvent {
   type?: RealtimeEventType
@@ -448,6 +449,13 @@ export function useRealtimeNotifications(enabled = true) {
           }
           break
         }
+        case 'report_resolved': {
+          const message = asString(payload.message)
+          toast.info('Report reviewed', {
+            description: message ?? 'A report you submitted has been reviewed.',
+          })
+          break
+        }
         case 'game_room_updated': {
           const roomId = asNumber(payload.room_id)
           dispatchGameRoomRealtimeUpdate({
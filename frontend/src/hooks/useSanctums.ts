@@ -7,6 +7,7 @@ import type {
   CreateSanctumRequestInput,
   SanctumRequest,
 } from '@/api/types'
+import { chatKeys } from './useChat'
 
 export const sanctumKeys = {
   all: ['sanctums'] as const,
@@ -71,6 +72,8 @@ export function useUpsertMySanctumMemberships() {
       apiClient.upsertMySanctumMemberships(payload),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: sanctumKeys.myMemberships() })
+      // Following a sanctum joins its default chatroom; unfollowing leaves it.
+      queryClient.invalidateQueries({ queryKey: chatKeys.chatroomsJoined() })
     },
   })
 }
@@ -159,7 +159,7 @@ export const sendMessageSchema = z.object({
     .string()
     .min(1, 'Message cannot be empty')
     .max(2000, 'Message must be less than 2000 characters'),
-  message_type: z.enum(['text', 'image', 'file']).optional(),
+  message_type: z.enum(['text', 'image', 'link', 'file']).optional(),
   metadata: z.record(z.string(), z.unknown()).optional(),
 })
 
@@ -608,12 +608,30 @@ export function ChatProvider({ children }: ChatProviderProps) {
             break
           }
 
-          case 'typing': {
+          case 'messages_deleted': {
+            const payload = data.payload || data
+            const convId = payload.conversation_id || data.conversation_id
+            const ids = payload.message_ids
+            if (!convId || !Array.isArray(ids)) break
+            const removed = new Set(ids)
+            queryClient.setQueryData<Message[]>(
+              ['chat', 'messages', convId],
+              old => old?.filter(m => !removed.has(m.id))
+            )
+            scheduleConversationsInvalidate()
+            break
+          }
+
+          case 'typing':
+          case 'typing_stopped': {
             const payload = data.payload || data
             const convId = payload.conversation_id || data.conversation_id
             const userId = payload.user_id || payload.userId || data.user_id
             const username = payload.username || data.username
-            const isTyping = payload.is_typing ?? payload.isTyping
+            const isTyping =
+              data.type === 'typing_stopped'
+                ? false
+                : (payload.is_typing ?? payload.isTyping)
             const expiresInMsRaw = payload.expires_in_ms
             const expiresInMs =
               typeof expiresInMsRaw === 'number' ? expiresInMsRaw : 5000